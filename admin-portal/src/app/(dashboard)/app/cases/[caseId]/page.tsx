@@ -262,7 +262,7 @@ const CaseDetailPage = () => {
       }
 
       setIsDeletingCase(true);
-      await CaseService.deleteCase(user!.role, caseId as string);
+      await CaseService.deleteCase(user!.role, caseId as string, force, deleteReason);
       message.success('Caso eliminado exitosamente');
       // Force refresh the cases list by adding a timestamp parameter
       router.push('/app/cases?refresh=' + Date.now());
@@ -76,10 +76,10 @@ interface DashboardStats {
   activeUsers: number;
   pendingAppointments: number;
   completedCases: number;
-  revenue: number;
+  revenue: number | null;
   revenueCurrency?: string;
   revenueMixedCurrencies?: boolean;
-  growthRate: number;
+  growthRate: number | null;
 }
 
 interface RecentActivity {
@@ -428,7 +428,7 @@ const AdminDashboard: React.FC = () => {
                     <div className="font-medium text-blue-800 mb-2">📊 Rendimiento del Sistema</div>
                     <div className="text-blue-700 text-sm">
                       {stats && stats.totalCases > 0 ? (
-                        <div>El sistema está procesando {stats.totalCases} casos activos con una tasa de crecimiento del {stats.growthRate}%</div>
+                        <div>El sistema está procesando {stats.totalCases} casos activos con una tasa de crecimiento del {stats.growthRate ?? 0}%</div>
                       ) : (
                         <div>No hay casos activos en el sistema</div>
                       )}
@@ -79,7 +79,7 @@ export class CaseService implements ICaseService {
     }
   }
 
-  static async deleteCase(userRole: string, id: string) {
+  static async deleteCase(userRole: string, id: string, force: boolean = false, reason: string = '') {
     const { apiClient } = await import('@/app/lib/api');
 
     try {
@@ -92,7 +92,9 @@ export class CaseService implements ICaseService {
         endpoint = '/staff/cases';
       }
 
-      await apiClient.delete(`${endpoint}/${id}`);
+      // force=true is required when the case still has upcoming appointments or open tasks
+      const params = force ? { force: 'true', ...(reason ? { reason } : {}) } : undefined;
+      await apiClient.delete(`${endpoint}/${id}`, { params });
       return true;
     } catch (error) {
       console.error('Error deleting case:', error);
@@ -0,0 +1,941 @@
+// api/cmd/server/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	// Internal packages for our application
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/container"
+	"github.com/BryanPMX/CAF/api/db"
+	"github.com/BryanPMX/CAF/api/handlers"
+	"github.com/BryanPMX/CAF/api/middleware"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/services"
+	"github.com/BryanPMX/CAF/api/storage"
+
+	// External packages (dependencies)
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// startTime is when the process booted; uptime in health and metrics responses counts from it.
+var startTime = time.Now()
+
+// main is the primary function that starts the entire API server.
+func main() {
+	services.SetProcessStart(startTime)
+
+	// --- Step 1: Initialize Configuration ---
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load configuration: %v", err)
+	}
+
+	// --- Step 2: Initialize Database Connection ---
+	database, err := db.Init(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("FATAL: Could not connect to the database: %v", err)
+	}
+
+	// --- Step 2.5: Initialize Rate Limiters ---
+	log.Println("INFO: Initializing rate limiters...")
+	middleware.InitializeRateLimiters(
+		cfg.RateLimitRequests,    // General requests per minute
+		cfg.RateLimitRequests/2,  // Auth requests per minute (half of general)
+		cfg.RateLimitRequests/20, // Contact requests per hour (1/20th of general)
+		cfg.RateLimitRequests*2,  // Admin requests per minute (double general)
+	)
+
+	// --- Step 3: Run Database Migrations ---
+	log.Println("INFO: Running database migrations...")
+
+	// Initialize migration manager
+	migrationManager := db.NewMigrationManager(database)
+
+	// Run migrations
+	if err := migrationManager.RunMigrations(); err != nil {
+		log.Fatalf("FATAL: Failed to run database migrations: %v", err)
+	}
+
+	// Get migration status for logging
+	migrationStatus, err := migrationManager.GetMigrationStatus()
+	if err != nil {
+		log.Printf("WARN: Could not get migration status: %v", err)
+	} else {
+		log.Printf("INFO: Migration status: %d migrations found", len(migrationStatus))
+		for _, status := range migrationStatus {
+			log.Printf("INFO: Migration %s (%s): %s", status["version"], status["description"], status["status"])
+		}
+	}
+
+	log.Println("INFO: Database migrations completed successfully")
+
+	// Cases from before case numbers existed are numbered with the configured format
+	if numbered, err := models.NumberExistingCases(database); err != nil {
+		log.Printf("WARNING: Failed to number existing cases: %v", err)
+	} else if numbered > 0 {
+		log.Printf("INFO: Numbered %d existing cases", numbered)
+	}
+
+	// --- Step 2.5b: Connect to Redis ---
+	// Redis is optional: it adds a shared cache tier, shares sessions and broadcasts invalidations to other replicas
+	var redisClient *redis.Client
+	if redisURL := config.RedisURL(); redisURL != "" {
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			log.Printf("WARNING: Invalid REDIS_URL, caches stay local: %v", err)
+		} else {
+			redisClient = redis.NewClient(opts)
+			pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := redisClient.Ping(pingCtx).Err(); err != nil {
+				log.Printf("WARNING: Redis unavailable, caches stay local: %v", err)
+				redisClient.Close()
+				redisClient = nil
+			}
+			cancel()
+		}
+	}
+
+	// --- Step 2.5c: Initialize dependency injection container ---
+	cont := container.NewContainer(database, redisClient)
+	log.Println("INFO: Dependency injection container initialized")
+
+	// --- Step 2.6: Session Service ---
+	// Issued tokens are tracked as sessions so admins can revoke them
+	sessionService := cont.GetSessionService()
+	middleware.SetSessionService(sessionService)
+	// Revoking a user's sessions also drops their notification sockets
+	services.OnSessionsRevoked(handlers.CloseUserConns)
+	if redisClient != nil {
+		log.Println("INFO: Session tracking enabled for JWT authentication, shared through Redis")
+	} else {
+		log.Println("INFO: Session tracking enabled for JWT authentication")
+	}
+	// Service tokens for scheduled jobs and integrations are checked against their own table
+	middleware.EnableServiceTokens(database, cfg.JWTSecret)
+	// Rate limits count signed-in users per user (with per-role limits) and hold across replicas through Redis
+	middleware.EnableRoleRateLimits(database, cfg.JWTSecret)
+	middleware.UseRedisRateLimits(redisClient)
+
+	// --- Step 2.7: Initialize Performance Optimized Handler ---
+	performanceHandler := handlers.NewPerformanceOptimizedHandler(database, redisClient)
+	log.Println("INFO: Performance optimized handler initialized successfully")
+	if err := handlers.StartCacheInvalidation(context.Background(), database, redisClient); err != nil {
+		log.Printf("WARNING: Cross-replica cache invalidation disabled: %v", err)
+	} else if redisClient != nil {
+		log.Println("INFO: Cross-replica cache invalidation enabled")
+	}
+	if err := handlers.StartSocketCloseBroadcast(context.Background(), redisClient); err != nil {
+		log.Printf("WARNING: Revoked sessions only close notification sockets on this replica: %v", err)
+	}
+
+	// --- Step 3: Initialize File Storage (S3 or Local) ---
+	// Strategy Pattern: try S3 first; fall back to local filesystem storage
+	// when AWS is not configured (e.g. self-hosted production without AWS).
+	log.Println("INFO: Initializing file storage...")
+
+	var storageReady bool
+
+	// Don't delay for AWS deployment — only for LocalStack
+	if os.Getenv("AWS_ENDPOINT_URL") != "" && os.Getenv("AWS_ENDPOINT_URL") != "http://localstack:4566" {
+		// This is likely AWS, don't delay
+	} else if os.Getenv("AWS_ENDPOINT_URL") != "" {
+		// Add a small delay to ensure LocalStack is fully ready
+		time.Sleep(2 * time.Second)
+	}
+
+	if err := storage.InitS3(); err != nil {
+		log.Printf("WARN: S3 initialization failed: %v", err)
+	} else {
+		log.Println("INFO: S3 client initialized successfully. Checking bucket...")
+		if err := storage.CreateBucketIfNotExists(); err != nil {
+			log.Printf("WARN: Failed to ensure S3 bucket exists: %v", err)
+		} else {
+			// S3 is fully ready — wrap it as the active FileStorage provider
+			s3Store, err := storage.NewS3Storage()
+			if err != nil {
+				log.Printf("WARN: Could not create S3 storage adapter: %v", err)
+			} else {
+				storage.SetActiveStorage(s3Store)
+				storageReady = true
+				log.Println("INFO: Using S3 storage backend")
+			}
+		}
+	}
+
+	// Fallback: if S3 is not available, use local filesystem storage
+	if !storageReady {
+		uploadsDir := os.Getenv("UPLOADS_DIR")
+		if uploadsDir == "" {
+			uploadsDir = storage.DefaultUploadsDir
+		}
+		localStore, err := storage.NewLocalStorage(uploadsDir)
+		if err != nil {
+			log.Printf("ERROR: Failed to initialize local storage at %s: %v", uploadsDir, err)
+			log.Println("WARN: Document upload/download will not be available")
+		} else {
+			storage.SetActiveStorage(localStore)
+			storageReady = true
+			log.Printf("INFO: Using local filesystem storage at %s", uploadsDir)
+		}
+	}
+
+	if !storageReady {
+		log.Println("WARN: No storage provider available — document features disabled")
+	}
+
+	// Scheduled database/storage maintenance (disabled unless MAINTENANCE_INTERVAL_HOURS is set)
+	handlers.StartMaintenanceScheduler(database)
+
+	// Appointment reminder emails (disabled with REMINDER_INTERVAL_MINUTES=0); like the server,
+	// the worker stops on SIGINT/SIGTERM
+	shutdownCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	remindersDone := handlers.StartAppointmentReminders(shutdownCtx, database)
+
+	// Queued webhook deliveries and their retries (disabled with WEBHOOK_WORKER_INTERVAL_SECONDS=0)
+	webhooksDone := handlers.StartWebhookDeliveries(shutdownCtx, database)
+
+	// --- Step 4: Set up Gin HTTP Router ---
+	r := gin.Default()
+
+	// Gzip large text/JSON responses; small bodies and binary downloads are sent as-is
+	r.Use(middleware.Compression())
+
+	// --- Step 5: Apply Global Middleware ---
+	// Configure CORS: exact origins, single-label wildcard subdomains, or "*" in development
+	allowedOrigins := config.CORSAllowedOrigins()
+	log.Printf("INFO: Using CORS origins: %v", allowedOrigins)
+	r.Use(middleware.CORS(allowedOrigins))
+
+	// Apply API versioning middleware (after CORS, before rate limiting)
+	r.Use(middleware.APIVersionMiddleware())
+
+	// Apply general rate limiting to all routes
+	r.Use(middleware.GeneralAPIRateLimit())
+
+	// Baseline audit entry for every authenticated write (AUDIT_REQUESTS_ENABLED=false disables)
+	r.Use(middleware.RequestAudit(database))
+
+	// --- Step 6: Define API Routes ---
+
+	// Group 1: Public Routes (No authentication required)
+	public := r.Group("/api/v1")
+	{
+		public.POST("/register", middleware.ValidateUserRegistration(), handlers.Register(database))
+		public.POST("/login", middleware.AuthRateLimit(), handlers.EnhancedLogin(database, cfg.JWTSecret, sessionService))
+		public.POST("/webhooks/stripe", handlers.StripeWebhook(database))
+		// Public endpoints for marketing site (no auth required)
+		public.GET("/public/offices", handlers.GetPublicOffices(cont.GetOfficeRepository()))
+		public.GET("/public/site-content", handlers.GetPublicSiteContent(database))
+		public.GET("/public/site-services", handlers.GetPublicSiteServices(database))
+		public.GET("/public/site-events", handlers.GetPublicSiteEvents(database))
+		public.GET("/public/site-images", handlers.GetPublicSiteImages(database))
+		public.POST("/public/contact", middleware.ContactFormRateLimit(), handlers.SubmitContact(database))
+	}
+
+	// WebSocket endpoint for per-user notifications (token via query param)
+	r.GET("/ws", handlers.NotificationsWebSocket(cfg.JWTSecret, sessionService))
+
+	// Health check endpoints - Basic health check that doesn't depend on external services
+	r.GET("/health", handlers.GetHealth())
+
+	// HEAD method support for Docker health checks
+	r.HEAD("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// AWS ALB health check endpoint (common AWS convention)
+	r.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+			"alive":  true,
+		})
+	})
+
+	// Readiness check (includes dependencies)
+	r.GET("/health/ready", func(c *gin.Context) {
+		// Test database connection
+		if err := database.Raw("SELECT 1").Error; err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "not ready",
+				"error":  "database connection failed",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ready",
+			"database":  "connected",
+			"timestamp": time.Now().UTC(),
+		})
+	})
+
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "API server is working", "timestamp": time.Now()})
+	})
+
+	// API version information endpoint
+	r.GET("/api/version", middleware.VersionInfo())
+
+	r.GET("/health/migrations", func(c *gin.Context) {
+		migrationStatus, err := migrationManager.GetMigrationStatus()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "service": "migrations", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "migrations", "migrations": migrationStatus})
+	})
+
+	r.GET("/health/storage", func(c *gin.Context) {
+		store := storage.GetActiveStorage()
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "service": "storage", "error": "no storage provider initialized"})
+			return
+		}
+		if err := store.HealthCheck(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy", "service": "storage", "error": err.Error()})
+			return
+		}
+		// Identify which backend is active
+		backend := "s3"
+		if _, ok := store.(*storage.LocalStorage); ok {
+			backend = "local"
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "storage", "backend": backend})
+	})
+
+	// Keep legacy /health/s3 endpoint for backward compatibility
+	r.GET("/health/s3", func(c *gin.Context) {
+		if err := storage.HealthCheck(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy", "service": "S3", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "S3"})
+	})
+
+	r.GET("/health/cache", func(c *gin.Context) {
+		stats := handlers.GetCacheStats()
+		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "Cache", "stats": stats})
+	})
+
+	apiBaseURL := strings.TrimSuffix(os.Getenv("API_BASE_URL"), "/")
+
+	// Group 2: Protected Routes (Requires any valid login token)
+	// Enhanced with comprehensive data access control
+	protected := r.Group("/api/v1")
+	protected.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	protected.Use(middleware.DataAccessControl(database)) // NEW: Enhanced access control
+	protected.Use(middleware.DenyClients())               // Block clients from staff/admin APIs
+	{
+		// Universal dashboard summary for all authenticated users
+		protected.GET("/dashboard-summary", middleware.AnalyticsRateLimit(), handlers.GetDashboardSummary(database))
+		// Staff-specific dashboard for limited role access
+		protected.GET("/staff/dashboard-summary", handlers.GetStaffDashboardSummary(database))
+		// Recent activity endpoint for dashboard
+		protected.GET("/recent-activity", handlers.GetRecentActivity(database))
+		// Dashboard content for all users
+		protected.GET("/dashboard/announcements", handlers.GetAnnouncements(database))
+		protected.GET("/dashboard/notes", handlers.GetNotes(database))
+		protected.POST("/dashboard/announcements/:id/dismiss", handlers.DismissAnnouncement(database))
+		protected.POST("/notes", handlers.CreateUserNote(database))
+		protected.PATCH("/notes/:id", handlers.UpdateUserNote(database))
+		protected.DELETE("/notes/:id", handlers.DeleteUserNote(database))
+
+		// Log out everywhere: revoke every session of the current user
+		protected.DELETE("/sessions", handlers.RevokeMySessions(database, sessionService))
+
+		// Profile and user info (include office for managers to prefill office selection)
+		protected.GET("/profile", func(c *gin.Context) {
+			userID, _ := c.Get("userID")
+			var user models.User
+			if err := database.Preload("Office").First(&user, "id = ?", userID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			// Fallback: update last_login if missing
+			now := time.Now()
+			if user.LastLogin == nil || user.LastLogin.Before(now.Add(-1*time.Hour)) {
+				_ = database.Model(&user).Update("last_login", &now).Error
+			}
+			avatarUrl := handlers.BuildProfileAvatarURL(user.AvatarURL, apiBaseURL)
+			c.JSON(http.StatusOK, gin.H{
+				"userID":    userID,
+				"role":      user.Role,
+				"firstName": user.FirstName,
+				"lastName":  user.LastName,
+				"office":    user.Office,
+				"officeId":  user.OfficeID,
+				"avatarUrl": avatarUrl,
+			})
+		})
+		protected.PATCH("/profile", handlers.UpdateProfile(database))
+		protected.POST("/profile/avatar", handlers.UploadProfileAvatar(database, apiBaseURL))
+		protected.GET("/avatar", handlers.GetProfileAvatar(database))
+
+		// Offices list available to authenticated staff/managers/admins
+		protected.GET("/offices", handlers.GetOffices(cont.GetOfficeRepository()))
+
+		// Enhanced Case Management with Access Control
+		protected.GET("/cases", middleware.CaseAccessControl(database), handlers.GetCasesEnhanced(database))
+		protected.GET("/cases/:id", middleware.CaseAccessControl(database), handlers.GetCaseByIDEnhanced(database))
+		protected.GET("/cases/:id/document-checklist", middleware.CaseAccessControl(database), handlers.GetCaseDocumentChecklist(database))
+		protected.GET("/cases/:id/suggested-stage", middleware.CaseAccessControl(database), handlers.GetSuggestedCaseStage(database))
+		protected.GET("/cases/:id/events", middleware.CaseAccessControl(database), handlers.GetCaseEvents(database))
+		protected.GET("/clients/:id/communications", handlers.GetClientCommunications(database))
+		protected.GET("/calendar-colors", handlers.GetCalendarColors(database))
+		protected.GET("/settings/scheduling", handlers.GetSchedulingSettings(database))
+		protected.GET("/cases/my", middleware.CaseAccessControl(database), handlers.GetMyCases(database))
+		protected.GET("/cases/courts", middleware.CaseAccessControl(database), handlers.GetCaseCourts(database))
+		protected.GET("/cases/assignment-suggestions", middleware.CaseAccessControl(database), handlers.GetCaseAssignmentSuggestions(database))
+		protected.GET("/cases/search", middleware.CaseAccessControl(database), handlers.SearchCases(database))
+		protected.GET("/cases/overdue", middleware.CaseAccessControl(database), handlers.GetOverdueCases(database)) // Open cases past due_date the user can access
+		protected.POST("/cases", middleware.CaseAccessControl(database), middleware.ValidateCaseCreation(), handlers.CreateCaseEnhanced(database))
+		protected.PUT("/cases/:id", middleware.CaseAccessControl(database), middleware.ValidateCaseUpdate(), handlers.UpdateCase(database))
+		protected.GET("/cases/:id/deletion-impact", middleware.CaseAccessControl(database), handlers.GetCaseDeletionImpact(database))
+		protected.DELETE("/cases/:id", middleware.CaseAccessControl(database), handlers.DeleteCase(database))
+
+		// Enhanced Appointment Management with Access Control
+		protected.GET("/appointments", middleware.AppointmentAccessControl(database), handlers.GetAppointmentsEnhanced(database))
+		protected.GET("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.GetAppointmentByIDEnhanced(database))
+		protected.GET("/appointments/my", middleware.AppointmentAccessControl(database), handlers.GetMyAppointments(database))
+		protected.GET("/appointments/month-summary", middleware.AppointmentAccessControl(database), handlers.GetAppointmentMonthSummary(database))
+		protected.GET("/appointments/availability", handlers.GetStaffAvailability(database)) // Free slots of a staff member on a date
+		protected.POST("/appointments", middleware.AppointmentAccessControl(database), handlers.CreateAppointmentEnhanced(database))
+		protected.PATCH("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.UpdateAppointmentEnhanced(database))
+		protected.POST("/appointments/:id/reschedule", middleware.AppointmentAccessControl(database), handlers.RescheduleAppointment(database)) // Move to new times and notify the client
+
+		// Task Management with Access Control
+		protected.GET("/tasks", middleware.TaskAccessControl(database), handlers.GetTasks(database))
+		protected.GET("/tasks/:id", middleware.TaskAccessControl(database), handlers.GetTaskByID(database))
+		protected.POST("/tasks", middleware.TaskAccessControl(database), handlers.CreateTaskEnhanced(database))
+		protected.PUT("/tasks/:id", middleware.TaskAccessControl(database), handlers.UpdateTaskEnhanced(database))
+		protected.DELETE("/tasks/:id", middleware.TaskAccessControl(database), handlers.DeleteTaskEnhanced(database))
+		protected.GET("/tasks/:id/dependencies", middleware.TaskAccessControl(database), handlers.GetTaskDependencies(database))
+		protected.POST("/tasks/:id/dependencies", middleware.TaskAccessControl(database), handlers.AddTaskDependency(database))
+		protected.DELETE("/tasks/:id/dependencies/:dependsOnId", middleware.TaskAccessControl(database), handlers.RemoveTaskDependency(database))
+		protected.GET("/tasks/my", middleware.TaskAccessControl(database), handlers.GetMyTasks(database))
+
+		// Task Comments
+		protected.POST("/tasks/:id/comments", middleware.TaskAccessControl(database), handlers.CreateTaskComment(database))
+		protected.PUT("/tasks/:id/comments/:commentId", middleware.TaskAccessControl(database), handlers.UpdateTaskComment(database))
+		protected.DELETE("/tasks/:id/comments/:commentId", middleware.TaskAccessControl(database), handlers.DeleteTaskComment(database))
+
+		// Document access for all authenticated users
+		protected.GET("/documents/:eventId", handlers.GetDocument(database))
+		protected.GET("/documents/:eventId/url", handlers.GetDocumentURL(database))
+		protected.GET("/documents/:eventId/versions", handlers.GetDocumentVersions(database))
+		protected.GET("/documents/:eventId/versions/:version", handlers.GetDocumentVersion(database))
+
+		// Notification endpoints for all authenticated users
+		protected.GET("/notifications", handlers.GetNotifications(database))
+		protected.POST("/notifications/mark-read", handlers.MarkNotificationsAsRead(database))
+		protected.GET("/notifications/preferences", handlers.GetNotificationPreferences(database))
+		protected.PATCH("/notifications/preferences", handlers.UpdateNotificationPreferences(database))
+
+		// Case Events CRUD for authenticated users
+		protected.POST("/cases/:id/comments", handlers.CreateComment(database))
+		protected.PUT("/cases/comments/:eventId", handlers.UpdateComment(database))
+		protected.DELETE("/cases/comments/:eventId", handlers.DeleteComment(database))
+		protected.POST("/cases/:id/documents", handlers.UploadDocument(database))
+		protected.POST("/cases/:id/documents/batch", middleware.CaseAccessControl(database), handlers.UploadDocumentBatch(database))
+		protected.GET("/cases/:id/documents/download-all", middleware.CaseAccessControl(database), handlers.DownloadCaseDocuments(database))
+		protected.PUT("/cases/documents/:eventId", handlers.UpdateDocument(database))
+		protected.DELETE("/cases/documents/:eventId", handlers.DeleteDocument(database))
+
+		// Legacy endpoints removed - using enhanced handlers only
+	}
+
+	// Group 3: Client Portal Routes (Requires a login token from a user with the 'client' role)
+	clientPortal := r.Group("/api/v1/client")
+	clientPortal.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	clientPortal.Use(middleware.RoleAuth(database, "client"))
+	clientPortal.Use(middleware.DataAccessControl(database))
+	{
+		// Client profile
+		clientPortal.DELETE("/sessions", handlers.RevokeMySessions(database, sessionService))
+
+		clientPortal.GET("/profile", func(c *gin.Context) {
+			userID, _ := c.Get("userID")
+			var user models.User
+			if err := database.Preload("Office").First(&user, "id = ?", userID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			avatarUrl := handlers.BuildProfileAvatarURL(user.AvatarURL, apiBaseURL)
+			c.JSON(http.StatusOK, gin.H{
+				"userID":    userID,
+				"role":      user.Role,
+				"firstName": user.FirstName,
+				"lastName":  user.LastName,
+				"office":    user.Office,
+				"officeId":  user.OfficeID,
+				"avatarUrl": avatarUrl,
+				"email":     user.Email,
+				"phone":     user.Phone,
+			})
+		})
+		clientPortal.PATCH("/profile", handlers.UpdateProfile(database))
+		clientPortal.POST("/profile/avatar", handlers.UploadProfileAvatar(database, apiBaseURL))
+		clientPortal.GET("/avatar", handlers.GetProfileAvatar(database))
+
+		// Client dashboard data primitives
+		clientPortal.GET("/cases", handlers.GetCasesEnhanced(database))
+		clientPortal.GET("/cases/my", handlers.GetMyCases(database))
+		clientPortal.GET("/cases/:id", handlers.GetClientCaseByID(database))
+		clientPortal.GET("/cases/:id/events", handlers.GetCaseEvents(database))
+		clientPortal.POST("/cases/:id/comments", handlers.CreateClientComment(database))
+		clientPortal.GET("/appointments", handlers.GetClientAppointments(database))
+		clientPortal.GET("/ratings", handlers.GetMyClientRatings(database))
+		clientPortal.POST("/ratings", handlers.CreateClientRating(database))
+		clientPortal.GET("/notifications", handlers.GetNotifications(database))
+		clientPortal.POST("/notifications/mark-read", handlers.MarkNotificationsAsRead(database))
+		clientPortal.GET("/notifications/preferences", handlers.GetNotificationPreferences(database))
+		clientPortal.PATCH("/notifications/preferences", handlers.UpdateNotificationPreferences(database))
+		clientPortal.GET("/payments/receipts", handlers.GetClientPaymentReceipts(database))
+		clientPortal.POST("/payments/checkout-session", handlers.CreateClientCheckoutSession(database))
+		clientPortal.GET("/documents/:eventId", handlers.GetDocument(database))
+		clientPortal.GET("/documents/:eventId/url", handlers.GetDocumentURL(database))
+		clientPortal.GET("/documents/:eventId/versions", handlers.GetDocumentVersions(database))
+		clientPortal.GET("/documents/:eventId/versions/:version", handlers.GetDocumentVersion(database))
+		clientPortal.GET("/cases/:id/documents/download-all", handlers.DownloadCaseDocuments(database))
+		clientPortal.GET("/offices", handlers.GetPublicOffices(cont.GetOfficeRepository()))
+	}
+
+	// Group 3b: Client self-service portal (read-only, client role): only the client's own
+	// records, without staff-internal fields or internal events
+	portal := r.Group("/api/v1/portal")
+	portal.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	portal.Use(middleware.RoleAuth(database, "client"))
+	{
+		portal.GET("/cases", handlers.GetPortalCases(database))
+		portal.GET("/cases/:id", handlers.GetPortalCase(database))
+		portal.GET("/appointments", handlers.GetPortalAppointments(database))
+	}
+
+	// Group 4: Admin-Only Routes (Requires a login token from a user with the 'admin' role)
+	admin := r.Group("/api/v1/admin")
+	admin.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	admin.Use(middleware.RoleAuth(database, "admin"))
+	admin.Use(middleware.DataAccessControl(database)) // Admin also gets enhanced context
+	{
+		// User Management
+		admin.POST("/users", handlers.CreateUser(database))
+		admin.GET("/users", handlers.GetUsers(database))
+		admin.GET("/users/:id", handlers.GetUserByID(database))
+		admin.PATCH("/users/:id", handlers.UpdateUser(database))
+		admin.DELETE("/users/:id", handlers.DeleteUser(database))
+		admin.DELETE("/users/:id/permanent", handlers.PermanentDeleteUser(database))
+		admin.GET("/users/:id/sessions", handlers.GetUserSessionsAdmin(database, sessionService))
+		admin.DELETE("/users/:id/sessions", handlers.RevokeUserSessionsAdmin(database, sessionService))
+		admin.GET("/users/:id/rating", handlers.GetStaffRating(database))
+		admin.GET("/users/:id/activity", handlers.GetUserActivity(database))
+
+		// Office Management (CRUD with hard delete; edit persists to DB)
+		admin.POST("/offices", handlers.CreateOffice(cont.GetOfficeRepository()))
+		admin.GET("/offices", handlers.GetOffices(cont.GetOfficeRepository()))
+		admin.GET("/offices/:id", handlers.GetOfficeByID(cont.GetOfficeRepository()))
+		admin.GET("/offices/:id/detail", handlers.GetOfficeDetailWithStaff(database))
+		admin.PATCH("/offices/:id", handlers.UpdateOffice(cont.GetOfficeRepository()))
+		admin.DELETE("/offices/:id", handlers.DeleteOffice(cont.GetOfficeRepository()))
+		admin.POST("/offices/:id/transfer", handlers.TransferOfficeRecords(cont.GetOfficeRepository()))
+		admin.GET("/offices/:id/appointment-categories", handlers.GetOfficeAppointmentCategories(database))
+		admin.PUT("/offices/:id/appointment-categories", handlers.SetOfficeAppointmentCategories(database))
+		admin.GET("/offices/:id/business-hours", handlers.GetOfficeBusinessHours(database))
+		admin.PUT("/offices/:id/business-hours", handlers.SetOfficeBusinessHours(database))
+		admin.POST("/offices/:id/holidays", handlers.AddOfficeHoliday(database))
+		admin.DELETE("/offices/:id/holidays/:holidayId", handlers.DeleteOfficeHoliday(database))
+
+		// Enhanced Case Management (Admin can override department restrictions)
+		admin.GET("/cases", handlers.GetCasesEnhanced(database))
+		admin.GET("/cases/overdue", handlers.GetOverdueCases(database)) // Open cases past due_date, by aging bucket
+		admin.GET("/cases/:id", handlers.GetCaseByIDEnhanced(database))
+		admin.POST("/cases", handlers.CreateCaseEnhanced(database))
+		admin.PUT("/cases/:id", handlers.UpdateCase(database))
+		admin.DELETE("/cases/:id", handlers.DeleteCase(database))
+		// Case management endpoints
+		admin.PATCH("/cases/:id/stage", handlers.UpdateCaseStage(database))
+		admin.POST("/cases/:id/assign", handlers.AssignStaffToCase(database))
+		admin.POST("/staff/:id/reassign-cases", handlers.ReassignStaffCases(database))
+		admin.GET("/cases/:id/invoice.pdf", handlers.GetCaseInvoicePDF(database))
+		admin.GET("/cases/:id/audit-trail", handlers.GetCaseAuditTrail(database))
+
+		// Performance Optimized Endpoints
+		admin.GET("/optimized/cases", performanceHandler.GetOptimizedCases())
+		admin.GET("/optimized/appointments", performanceHandler.GetOptimizedAppointments())
+		admin.GET("/optimized/users", performanceHandler.GetOptimizedUsers())
+		admin.GET("/performance/metrics", performanceHandler.GetPerformanceMetrics())
+
+		// Case Completion
+		admin.POST("/cases/:id/complete", handlers.CompleteCase(database))
+
+		// Enhanced Task Management
+		admin.GET("/tasks/:id", handlers.GetTaskByID(database))
+		admin.POST("/cases/:id/tasks", handlers.CreateTaskEnhanced(database))
+		admin.PATCH("/tasks/:id", handlers.UpdateTaskEnhanced(database))
+		admin.DELETE("/tasks/:id", handlers.DeleteTaskEnhanced(database))
+		admin.GET("/tasks/:id/dependencies", handlers.GetTaskDependencies(database))
+		admin.POST("/tasks/:id/dependencies", handlers.AddTaskDependency(database))
+		admin.DELETE("/tasks/:id/dependencies/:dependsOnId", handlers.RemoveTaskDependency(database))
+
+		// Task Comments
+		admin.POST("/tasks/:id/comments", handlers.CreateTaskComment(database))
+		admin.PUT("/tasks/:id/comments/:commentId", handlers.UpdateTaskComment(database))
+		admin.DELETE("/tasks/:id/comments/:commentId", handlers.DeleteTaskComment(database))
+
+		// Case Events
+		admin.POST("/cases/:id/comments", handlers.CreateComment(database))
+		admin.PUT("/cases/comments/:eventId", handlers.UpdateComment(database))
+		admin.DELETE("/cases/comments/:eventId", handlers.DeleteComment(database))
+		admin.POST("/cases/:id/documents", handlers.UploadDocument(database))
+		admin.POST("/cases/:id/documents/batch", handlers.UploadDocumentBatch(database))
+		admin.PUT("/cases/documents/:eventId", handlers.UpdateDocument(database))
+		admin.DELETE("/cases/documents/:eventId", handlers.DeleteDocument(database))
+		admin.GET("/documents/:eventId", handlers.GetDocument(database))
+		admin.GET("/documents/:eventId/url", handlers.GetDocumentURL(database))
+		admin.GET("/documents/:eventId/versions", handlers.GetDocumentVersions(database))
+		admin.GET("/documents/:eventId/versions/:version", handlers.GetDocumentVersion(database))
+
+		// Enhanced Appointment Management (Admin can override department restrictions)
+		admin.GET("/appointments", handlers.GetAppointmentsEnhanced(database))
+		admin.GET("/appointments/:id", handlers.GetAppointmentByIDAdmin(database))
+		admin.POST("/appointments", handlers.CreateAppointmentSmart(database))
+		admin.POST("/appointments/import", handlers.ImportAppointments(database))
+		// Temporarily allow unauthenticated access to migration endpoint for development
+		r.POST("/api/v1/admin/appointments/fix-categories", handlers.FixExistingAppointmentCategories(database)) // Fix existing appointment categories (temp: no auth for dev)
+		admin.PATCH("/appointments/:id", handlers.UpdateAppointmentEnhanced(database))
+		admin.POST("/appointments/:id/reschedule", handlers.RescheduleAppointment(database))
+		admin.DELETE("/appointments/:id", handlers.DeleteAppointmentAdmin(database))
+		admin.DELETE("/appointments/series/:seriesId", handlers.CancelAppointmentSeries(database)) // Cancel a recurring series
+
+		// Contact form submissions (marketing "Contacto" interest)
+		admin.GET("/contact-submissions", handlers.GetContactSubmissions(database))
+
+		// Records Management (Archived Cases and Appointments)
+		admin.GET("/records/stats", handlers.GetRecordsArchiveStats(database))
+		admin.GET("/records/cases", handlers.GetRecordsArchivedCases(database))
+		admin.GET("/records/appointments", handlers.GetArchivedAppointments(database))
+		admin.POST("/records/cases/:id/restore", handlers.RestoreCase(database))
+		admin.POST("/records/appointments/:id/restore", handlers.RestoreAppointment(database))
+		admin.DELETE("/records/cases/:id", handlers.PermanentlyDeleteCase(database))
+		admin.DELETE("/records/appointments/:id", handlers.PermanentlyDeleteAppointment(database))
+
+		// Legacy admin endpoints removed - using enhanced handlers only
+
+		// Dashboard
+		admin.GET("/dashboard-summary", middleware.AnalyticsRateLimit(), handlers.GetDashboardSummary(database))
+		admin.GET("/dashboard/stats", middleware.AnalyticsRateLimit(), handlers.GetDashboardStats(database))
+		admin.GET("/dashboard/office/:officeId/stats", middleware.AnalyticsRateLimit(), handlers.GetOfficeDashboardStats(database))
+		admin.GET("/dashboard/staff/:staffId/stats", middleware.AnalyticsRateLimit(), handlers.GetStaffDashboardStats(database))
+		admin.GET("/dashboard/activity", handlers.GetRecentActivity(database))
+		admin.GET("/dashboard/health", handlers.GetSystemHealth(database))
+		admin.GET("/trends/appointment-completion", middleware.AnalyticsRateLimit(), handlers.GetAppointmentCompletionTrend(database))
+		admin.GET("/maintenance", handlers.GetMaintenanceStatus(database))
+		admin.POST("/maintenance/run", handlers.RunMaintenance(database))
+		admin.GET("/maintenance/retention", handlers.GetRecordRetentionPreview(database))
+		admin.GET("/integrity/orphans", handlers.GetOrphanedRecords(database))
+		admin.GET("/ratings", handlers.GetClientRatings(database))
+		admin.POST("/bulk-operations", handlers.GetBulkOperations(database))
+		admin.POST("/bulk-operations/validate", handlers.ValidateBulkOperation(database))
+		admin.POST("/bulk-operations/execute", handlers.ExecuteBulkOperation(database))
+		admin.POST("/export", handlers.ExportData(database))                                                   // {"type": "users"|"cases", "filters": {...}}
+		admin.POST("/export/:type", handlers.ExportData(database))                                             // Same, with the type in the path
+		admin.GET("/export/stream", handlers.StreamExport(database))                                           // ?type=cases|appointments as JSON Lines
+		admin.GET("/users/search", handlers.SearchClients(database))                                           // For client search
+		admin.GET("/clients/:clientId/cases", handlers.GetCasesForClient(database))                            // For client's cases
+		admin.GET("/clients/:clientId/cases-for-appointment", handlers.GetClientCasesForAppointment(database)) // For appointment case dropdown
+		admin.POST("/clients/:clientId/merge", handlers.MergeClients(database, sessionService))                // Merge duplicate clients
+		admin.GET("/clients/stubs", handlers.GetStubClientsReport(database))
+		admin.POST("/clients/stubs/cleanup", handlers.CleanupStubClients(database, sessionService))
+
+		// Announcement Management (Admin only)
+		admin.POST("/announcements", handlers.CreateAnnouncement(database))
+		admin.PATCH("/announcements/:id", handlers.UpdateAnnouncement(database))
+		admin.DELETE("/announcements/:id", handlers.DeleteAnnouncement(database))
+
+		// Document checklists per case category (Admin only)
+		admin.GET("/document-checklists", handlers.GetDocumentChecklists(database))
+		admin.POST("/document-checklists", handlers.CreateDocumentChecklistItem(database))
+		admin.PUT("/document-checklists/:id", handlers.UpdateDocumentChecklistItem(database))
+		admin.DELETE("/document-checklists/:id", handlers.DeleteDocumentChecklistItem(database))
+
+		// Maintained court names used to normalize cases.court (Admin only)
+		admin.GET("/courts", handlers.GetCourts(database))
+		admin.POST("/courts", handlers.CreateCourt(database))
+		admin.PUT("/courts/:id", handlers.UpdateCourt(database))
+		admin.DELETE("/courts/:id", handlers.DeleteCourt(database))
+
+		// Expenses reported next to revenue in the financial metrics (Admin only)
+		admin.GET("/expenses", handlers.GetExpenses(database))
+		admin.POST("/expenses", handlers.CreateExpense(database))
+		admin.DELETE("/expenses/:id", handlers.DeleteExpense(database))
+
+		// Calendar colors for appointment departments/categories (Admin only)
+		admin.PUT("/calendar-colors", handlers.UpsertCalendarColor(database))
+		admin.GET("/webhooks", handlers.GetWebhookSubscriptions(database))
+		admin.POST("/webhooks", handlers.CreateWebhookSubscription(database))
+		admin.PUT("/webhooks/:id", handlers.UpdateWebhookSubscription(database))
+		admin.DELETE("/webhooks/:id", handlers.DeleteWebhookSubscription(database))
+		admin.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries(database))
+		admin.GET("/service-tokens", handlers.GetServiceTokens(database))
+		admin.POST("/service-tokens", handlers.CreateServiceToken(database, cfg.JWTSecret))
+		admin.PUT("/service-tokens/:id", handlers.UpdateServiceToken(database))
+		admin.DELETE("/service-tokens/:id", handlers.RevokeServiceToken(database))
+		admin.DELETE("/calendar-colors/:id", handlers.DeleteCalendarColor(database))
+		admin.PUT("/settings/scheduling", handlers.UpdateSchedulingSettings(database))
+		admin.DELETE("/settings/scheduling", handlers.ResetSchedulingSettings(database))
+
+		// Reports and Audit routes
+		reportsHandler := handlers.NewReportsHandler(database)
+		admin.GET("/reports/summary-report", middleware.AnalyticsRateLimit(), reportsHandler.GetSummaryReport())
+		admin.GET("/reports/cases-report", reportsHandler.GetCasesReport())
+		admin.GET("/reports/appointments-report", reportsHandler.GetAppointmentsReport())
+		admin.GET("/reports/export", reportsHandler.ExportReport())
+		admin.GET("/reports/runs", reportsHandler.GetReportRuns())
+		admin.POST("/reports/:id/rerun", reportsHandler.RerunReport()) // Re-executes stored parameters against current data
+		admin.GET("/reports/audit", handlers.GetAuditReport(database)) // ?type=case|appointment|user|document|financial|security|data_access|system
+
+		// CMS: Website Content Management
+		admin.GET("/site-content", handlers.GetAllSiteContent(database))
+		admin.POST("/site-content", handlers.UpsertSiteContent(database))
+		admin.DELETE("/site-content/:id", handlers.DeleteSiteContent(database))
+
+		admin.GET("/site-services", handlers.GetAllSiteServices(database))
+		admin.POST("/site-services", handlers.CreateSiteService(database))
+		admin.PATCH("/site-services/:id", handlers.UpdateSiteService(database))
+		admin.DELETE("/site-services/:id", handlers.DeleteSiteService(database))
+
+		admin.GET("/site-events", handlers.GetAllSiteEvents(database))
+		admin.POST("/site-events", handlers.CreateSiteEvent(database))
+		admin.PATCH("/site-events/:id", handlers.UpdateSiteEvent(database))
+		admin.DELETE("/site-events/:id", handlers.DeleteSiteEvent(database))
+
+		admin.GET("/site-images", handlers.GetAllSiteImages(database))
+		admin.POST("/site-images", handlers.CreateSiteImage(database))
+		admin.PATCH("/site-images/:id", handlers.UpdateSiteImage(database))
+		admin.DELETE("/site-images/:id", handlers.DeleteSiteImage(database))
+		admin.POST("/site-images/upload", handlers.UploadSiteImage(database))
+	}
+
+	// Group 4b: Metrics Routes (granted per capability, configurable with <CAPABILITY>_ROLES)
+	metrics := r.Group("/api/v1/metrics")
+	metrics.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	metrics.Use(middleware.DataAccessControl(database))
+	{
+		metrics.GET("/financial", middleware.RequireCapability(database, config.CapabilityFinancialMetrics), middleware.AnalyticsRateLimit(), handlers.GetFinancialMetrics(database))
+		metrics.GET("/system", middleware.RequireCapability(database, config.CapabilitySystemMetrics), handlers.GetSystemMetrics(database))
+	}
+
+	// Staff calendar subscription: calendar apps cannot send headers, so the JWT may come as ?token=
+	staffCalendar := r.Group("/api/v1/staff")
+	staffCalendar.Use(middleware.QueryTokenAuth())
+	staffCalendar.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	staffCalendar.Use(middleware.RoleAuth(database, "staff"))
+	staffCalendar.Use(middleware.DataAccessControl(database))
+	{
+		staffCalendar.GET("/appointments/calendar.ics", middleware.AppointmentAccessControl(database), handlers.GetStaffAppointmentsICS(database))
+	}
+
+	// Group 5: Staff-Specific Routes (Enhanced access control for staff members)
+	staff := r.Group("/api/v1/staff")
+	staff.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	staff.Use(middleware.RoleAuth(database, "staff"))
+	staff.Use(middleware.DataAccessControl(database))
+	{
+		// Staff can only see their own data and department data
+		// Landing page: today's appointments, due tasks and recently assigned cases
+		staff.GET("/my-day", handlers.GetMyDay(database))
+
+		staff.GET("/profile", func(c *gin.Context) {
+			currentUser, _ := c.Get("currentUser")
+			user := currentUser.(models.User)
+			c.JSON(http.StatusOK, gin.H{
+				"user":       user,
+				"department": user.Department,
+				"specialty":  user.Specialty,
+				"office":     user.Office,
+			})
+		})
+
+		// Staff-specific case views
+		staff.GET("/cases", middleware.CaseAccessControl(database), handlers.GetCasesEnhanced(database))
+		staff.GET("/cases/:id", middleware.CaseAccessControl(database), handlers.GetCaseByIDEnhanced(database))
+		staff.GET("/cases/my", middleware.CaseAccessControl(database), handlers.GetMyCases(database))
+		staff.POST("/cases", middleware.CaseAccessControl(database), handlers.CreateCaseEnhanced(database))
+		staff.PUT("/cases/:id", middleware.CaseAccessControl(database), handlers.UpdateCaseEnhanced(database))
+		staff.DELETE("/cases/:id", middleware.CaseAccessControl(database), handlers.DeleteCaseEnhanced(database))
+
+		// Document access
+		staff.GET("/documents/:eventId", handlers.GetDocument(database))
+		staff.GET("/documents/:eventId/url", handlers.GetDocumentURL(database))
+		staff.GET("/documents/:eventId/versions", handlers.GetDocumentVersions(database))
+		staff.GET("/documents/:eventId/versions/:version", handlers.GetDocumentVersion(database))
+
+		// Staff-specific appointment views
+		staff.GET("/appointments", middleware.AppointmentAccessControl(database), handlers.GetAppointmentsEnhanced(database))
+		staff.GET("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.GetAppointmentByIDEnhanced(database))
+		staff.GET("/appointments/my", middleware.AppointmentAccessControl(database), handlers.GetMyAppointments(database))
+		staff.POST("/appointments", handlers.CreateAppointmentSmart(database)) // Smart appointment creation
+		staff.PUT("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.UpdateAppointmentEnhanced(database))
+		staff.POST("/appointments/:id/reschedule", middleware.AppointmentAccessControl(database), handlers.RescheduleAppointment(database))
+		staff.DELETE("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.DeleteAppointmentEnhanced(database))
+		staff.DELETE("/appointments/series/:seriesId", handlers.CancelAppointmentSeries(database))
+
+		// Client cases for appointment creation
+		staff.GET("/clients/:clientId/cases-for-appointment", handlers.GetClientCasesForAppointment(database))
+		// Client cases endpoint
+		staff.GET("/clients/:clientId/cases", handlers.GetCasesForClient(database))
+
+		// Staff user management (office-scoped)
+		staff.GET("/users", handlers.GetUsers(database))
+		staff.GET("/users/:id", handlers.GetUserByID(database))
+
+		// Staff-specific task views
+		staff.GET("/tasks", middleware.TaskAccessControl(database), handlers.GetTasks(database))
+		staff.GET("/tasks/:id", middleware.TaskAccessControl(database), handlers.GetTaskByID(database))
+		staff.GET("/tasks/my", middleware.TaskAccessControl(database), handlers.GetMyTasks(database))
+		staff.POST("/tasks", middleware.TaskAccessControl(database), handlers.CreateTaskEnhanced(database))
+		staff.POST("/cases/:id/tasks", middleware.TaskAccessControl(database), handlers.CreateTaskEnhanced(database))
+		staff.PUT("/tasks/:id", middleware.TaskAccessControl(database), handlers.UpdateTaskEnhanced(database))
+		staff.DELETE("/tasks/:id", middleware.TaskAccessControl(database), handlers.DeleteTaskEnhanced(database))
+		staff.GET("/tasks/:id/dependencies", middleware.TaskAccessControl(database), handlers.GetTaskDependencies(database))
+		staff.POST("/tasks/:id/dependencies", middleware.TaskAccessControl(database), handlers.AddTaskDependency(database))
+		staff.DELETE("/tasks/:id/dependencies/:dependsOnId", middleware.TaskAccessControl(database), handlers.RemoveTaskDependency(database))
+
+		// Task Comments
+		staff.POST("/tasks/:id/comments", middleware.TaskAccessControl(database), handlers.CreateTaskComment(database))
+		staff.PUT("/tasks/:id/comments/:commentId", middleware.TaskAccessControl(database), handlers.UpdateTaskComment(database))
+		staff.DELETE("/tasks/:id/comments/:commentId", middleware.TaskAccessControl(database), handlers.DeleteTaskComment(database))
+	}
+
+	// Group 6: Office Manager Routes (role: office_manager)
+	officeManager := r.Group("/api/v1/manager")
+	officeManager.Use(middleware.EnhancedJWTAuth(cfg.JWTSecret))
+	officeManager.Use(middleware.RoleAuth(database, "office_manager"))
+	officeManager.Use(middleware.DataAccessControl(database))
+	{
+		// Users management for Office Managers
+		// Office managers can create/update clients for any office, but staff only for their office
+		officeManager.POST("/users", handlers.CreateUserScoped(database))
+		officeManager.GET("/users/:id", handlers.GetUserByID(database))
+		officeManager.PATCH("/users/:id", handlers.UpdateUserScoped(database))
+		officeManager.GET("/users", handlers.GetUsers(database))
+		officeManager.GET("/users/search", handlers.SearchClients(database))
+		officeManager.GET("/staff/:id/utilization", handlers.GetStaffUtilization(database))
+		officeManager.GET("/workload", handlers.GetStaffWorkload(database))
+		officeManager.GET("/dashboard/office/:officeId/stats", middleware.AnalyticsRateLimit(), handlers.GetOfficeDashboardStats(database)) // Own office only
+		officeManager.GET("/dashboard/staff/:staffId/stats", middleware.AnalyticsRateLimit(), handlers.GetStaffDashboardStats(database))    // Staff of their office only
+
+		// Offices list and detail (managers can see all offices for reference)
+		officeManager.GET("/offices", handlers.GetOffices(cont.GetOfficeRepository()))
+		officeManager.GET("/offices/:id", handlers.GetOfficeByID(cont.GetOfficeRepository()))
+		officeManager.GET("/offices/:id/detail", handlers.GetOfficeDetailWithStaff(database))
+
+		// Case Management for Office Managers
+		officeManager.GET("/cases", middleware.CaseAccessControl(database), handlers.GetCasesEnhanced(database))
+		officeManager.GET("/cases/:id", middleware.CaseAccessControl(database), handlers.GetCaseByIDEnhanced(database))
+		officeManager.GET("/cases/my", middleware.CaseAccessControl(database), handlers.GetMyCases(database))
+		officeManager.POST("/cases", middleware.CaseAccessControl(database), handlers.CreateCaseEnhanced(database))
+		officeManager.PUT("/cases/:id", middleware.CaseAccessControl(database), handlers.UpdateCase(database))
+		officeManager.DELETE("/cases/:id", middleware.CaseAccessControl(database), handlers.DeleteCase(database))
+		officeManager.POST("/bulk-operations/validate", handlers.ValidateBulkOperation(database)) // Office-scoped per item
+		officeManager.POST("/bulk-operations/execute", handlers.ExecuteBulkOperation(database))
+
+		// Case Comments for Office Managers
+		officeManager.POST("/cases/:id/comments", middleware.CaseAccessControl(database), handlers.CreateComment(database))
+		officeManager.PUT("/cases/comments/:eventId", middleware.CaseAccessControl(database), handlers.UpdateComment(database))
+		officeManager.DELETE("/cases/comments/:eventId", middleware.CaseAccessControl(database), handlers.DeleteComment(database))
+
+		// Client cases for appointment creation (scoped by office)
+		officeManager.GET("/clients/:clientId/cases-for-appointment", handlers.GetClientCasesForAppointmentScoped(database))
+		// Client cases endpoint
+		officeManager.GET("/clients/:clientId/cases", handlers.GetCasesForClient(database))
+		officeManager.GET("/clients/inactive", handlers.GetInactiveClients(database))
+
+		// Appointment Management for Office Managers
+		officeManager.GET("/appointments", middleware.AppointmentAccessControl(database), handlers.GetAppointmentsEnhanced(database))
+		officeManager.GET("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.GetAppointmentByIDEnhanced(database))
+		officeManager.GET("/appointments/my", middleware.AppointmentAccessControl(database), handlers.GetMyAppointments(database))
+		officeManager.POST("/appointments", handlers.CreateAppointmentSmart(database))
+		officeManager.GET("/schedule", handlers.GetOfficeSchedule(database))
+		officeManager.GET("/schedule.ics", handlers.GetOfficeScheduleICS(database))
+		officeManager.GET("/appointment-heatmap", handlers.GetAppointmentHeatmap(database))
+		officeManager.PATCH("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.UpdateAppointmentEnhanced(database))
+		officeManager.POST("/appointments/:id/reschedule", middleware.AppointmentAccessControl(database), handlers.RescheduleAppointment(database))
+		officeManager.DELETE("/appointments/:id", middleware.AppointmentAccessControl(database), handlers.DeleteAppointmentEnhanced(database))
+		officeManager.DELETE("/appointments/series/:seriesId", handlers.CancelAppointmentSeries(database))
+
+		// Records (scoped by office via DataAccessControl)
+		officeManager.GET("/records/stats", handlers.GetRecordsArchiveStats(database))
+		officeManager.GET("/records/cases", middleware.CaseAccessControl(database), handlers.GetRecordsArchivedCases(database))
+		officeManager.GET("/records/appointments", middleware.AppointmentAccessControl(database), handlers.GetArchivedAppointments(database))
+		officeManager.POST("/records/cases/:id/restore", middleware.CaseAccessControl(database), handlers.RestoreCase(database))
+		officeManager.POST("/records/appointments/:id/restore", middleware.AppointmentAccessControl(database), handlers.RestoreAppointment(database))
+		officeManager.DELETE("/records/cases/:id", middleware.CaseAccessControl(database), handlers.PermanentlyDeleteCase(database))
+		officeManager.DELETE("/records/appointments/:id", middleware.AppointmentAccessControl(database), handlers.PermanentlyDeleteAppointment(database))
+
+		// Reports (scoped by office via DataAccessControl)
+		reportsHandler := handlers.NewReportsHandler(database)
+		officeManager.GET("/reports/summary-report", middleware.AnalyticsRateLimit(), reportsHandler.GetSummaryReport())
+		officeManager.GET("/reports/cases-report", reportsHandler.GetCasesReport())
+		officeManager.GET("/reports/appointments-report", reportsHandler.GetAppointmentsReport())
+		officeManager.GET("/reports/export", reportsHandler.ExportReport())
+		officeManager.GET("/case-funnel", handlers.GetCaseFunnel(database))             // Per-stage entered/advanced counts from case_status_history
+		officeManager.GET("/monthly-report", handlers.GetOfficeMonthlyReport(database)) // Office monthly report download (PDF or CSV)
+	}
+
+	// --- Step 7: Start the Server ---
+	// Bind to all interfaces (0.0.0.0) for AWS deployment
+	serverAddr := "0.0.0.0:" + cfg.Port
+	log.Printf("INFO: Server starting on %s", serverAddr)
+	log.Printf("INFO: Enhanced data access control system enabled")
+	log.Printf("INFO: Department-based filtering active")
+	log.Printf("INFO: Case assignment control active")
+
+	// Add graceful shutdown handling
+	srv := &http.Server{
+		Addr:         serverAddr,
+		Handler:      r,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("FATAL: Failed to run server: %v", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM, then let in-flight requests, their queued audit entries and the
+	// reminder batch finish
+	<-shutdownCtx.Done()
+	log.Println("INFO: Shutdown signal received, stopping server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("WARNING: Server did not shut down cleanly: %v", err)
+	}
+	if err := middleware.StopRequestAudit(ctx); err != nil {
+		log.Println("WARNING: Queued request audit entries were not all written in time")
+	}
+	select {
+	case <-remindersDone:
+	case <-ctx.Done():
+		log.Println("WARNING: Appointment reminder worker did not stop in time")
+	}
+	select {
+	case <-webhooksDone:
+	case <-ctx.Done():
+		log.Println("WARNING: Webhook delivery worker did not stop in time")
+	}
+	log.Println("INFO: Server stopped")
+}
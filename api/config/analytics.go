@@ -0,0 +1,46 @@
+// api/config/analytics.go
+// Throttling for expensive analytics endpoints (dashboard statistics, reports and system health).
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// AnalyticsCacheTTL returns how long computed analytics results are reused.
+// Configured with ANALYTICS_CACHE_TTL_SECONDS (default 60, 0 disables caching).
+func AnalyticsCacheTTL() time.Duration {
+	ttl := 60
+	if v := os.Getenv("ANALYTICS_CACHE_TTL_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			ttl = parsed
+		}
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// AnalyticsRequestsPerMinute returns the per-user limit on analytics requests.
+// Configured with ANALYTICS_RATE_LIMIT_PER_MINUTE (default 20).
+func AnalyticsRequestsPerMinute() int {
+	limit := 20
+	if v := os.Getenv("ANALYTICS_RATE_LIMIT_PER_MINUTE"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	return limit
+}
+
+// SystemHealthCacheTTL returns how long database probes for the system health panel
+// (active connections, database size, query latency) are reused.
+// Configured with SYSTEM_HEALTH_CACHE_TTL_SECONDS (default 30, 0 disables caching).
+func SystemHealthCacheTTL() time.Duration {
+	ttl := 30
+	if v := os.Getenv("SYSTEM_HEALTH_CACHE_TTL_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			ttl = parsed
+		}
+	}
+	return time.Duration(ttl) * time.Second
+}
@@ -0,0 +1,81 @@
+// api/config/appointment_durations.go
+// Appointment lengths: the shortest and longest appointment that may be booked, and the
+// default length per category, used when a booking omits its end time.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAppointmentDurations maps appointment categories to their default length in minutes.
+var DefaultAppointmentDurations = map[string]int{
+	"Consulta Legal":       60,
+	"Sesion de Psicologia": 50,
+	"Trabajo Social":       45,
+	"General":              30,
+}
+
+// defaultAppointmentFallbackMinutes applies to categories without their own default length.
+const defaultAppointmentFallbackMinutes = 60
+
+// AppointmentMinDurationMinutes returns the shortest appointment that may be booked.
+// Configured with APPOINTMENT_MIN_DURATION_MINUTES (default 15, 0 disables the check).
+func AppointmentMinDurationMinutes() int {
+	minimum := 15
+	if v := os.Getenv("APPOINTMENT_MIN_DURATION_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			minimum = parsed
+		}
+	}
+	return minimum
+}
+
+// AppointmentMaxDurationMinutes returns the longest appointment that may be booked.
+// Configured with APPOINTMENT_MAX_DURATION_MINUTES (default 180, 0 disables the check).
+func AppointmentMaxDurationMinutes() int {
+	maximum := 180
+	if v := os.Getenv("APPOINTMENT_MAX_DURATION_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			maximum = parsed
+		}
+	}
+	return maximum
+}
+
+// AppointmentDefaultDuration returns the length of an appointment of the category booked
+// without an end time. Configured with APPOINTMENT_CATEGORY_DURATIONS as comma-separated
+// category=minutes pairs that override DefaultAppointmentDurations (e.g. "Consulta Legal=90"),
+// and APPOINTMENT_DEFAULT_DURATION_MINUTES for other categories (default 60). Categories match
+// case-insensitively; invalid entries are ignored.
+func AppointmentDefaultDuration(category string) time.Duration {
+	durations := make(map[string]int, len(DefaultAppointmentDurations))
+	for name, value := range DefaultAppointmentDurations {
+		durations[strings.ToLower(name)] = value
+	}
+	for _, item := range strings.Split(os.Getenv("APPOINTMENT_CATEGORY_DURATIONS"), ",") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		name = strings.ToLower(strings.TrimSpace(name))
+		if err != nil || parsed <= 0 || name == "" {
+			continue
+		}
+		durations[name] = parsed
+	}
+	if value, ok := durations[strings.ToLower(strings.TrimSpace(category))]; ok {
+		return time.Duration(value) * time.Minute
+	}
+
+	fallback := defaultAppointmentFallbackMinutes
+	if v := os.Getenv("APPOINTMENT_DEFAULT_DURATION_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && parsed > 0 {
+			fallback = parsed
+		}
+	}
+	return time.Duration(fallback) * time.Minute
+}
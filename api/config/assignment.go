@@ -0,0 +1,36 @@
+// api/config/assignment.go
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// CaseAutoAssignEnabled reports whether new cases without a primary staff member are
+// assigned to the top-ranked suggestion for their category and office.
+// Configured with CASE_AUTO_ASSIGN (default false).
+func CaseAutoAssignEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("CASE_AUTO_ASSIGN"))
+	return err == nil && enabled
+}
+
+// CaseDepartmentEnforcementEnabled reports whether department-bound staff may only create
+// cases in, and may not move cases into or out of, their own department. Admins and
+// office managers are exempt. Configured with CASE_DEPARTMENT_ENFORCEMENT (default true).
+func CaseDepartmentEnforcementEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("CASE_DEPARTMENT_ENFORCEMENT"))
+	return err != nil || enabled
+}
+
+// StaffMaxActiveCases returns how many active cases a staff member may carry before the
+// workload report flags them as at capacity.
+// Configured with STAFF_MAX_ACTIVE_CASES (default 25, 0 disables the flag).
+func StaffMaxActiveCases() int {
+	maximum := 25
+	if v := os.Getenv("STAFF_MAX_ACTIVE_CASES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			maximum = parsed
+		}
+	}
+	return maximum
+}
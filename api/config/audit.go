@@ -0,0 +1,44 @@
+// api/config/audit.go
+// Baseline audit logging of mutating API requests.
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultAuditRequestSkipRoutes are the route prefixes left out when AUDIT_REQUESTS_SKIP_ROUTES
+// is unset: frequent, low-value writes such as marking notifications read.
+var defaultAuditRequestSkipRoutes = []string{
+	"/api/v1/notifications/mark-read",
+	"/api/v1/client/notifications/mark-read",
+	"/api/v1/dashboard/announcements/:id/dismiss",
+}
+
+// AuditRequestsEnabled reports whether every authenticated POST, PUT, PATCH and DELETE request
+// is recorded in the audit log.
+// Configured with AUDIT_REQUESTS_ENABLED (default true).
+func AuditRequestsEnabled() bool {
+	return !strings.EqualFold(os.Getenv("AUDIT_REQUESTS_ENABLED"), "false")
+}
+
+// AuditRequestSkipRoutes returns the route prefixes (as registered, e.g. "/api/v1/admin/cases/:id")
+// whose requests are not recorded.
+// Configured with AUDIT_REQUESTS_SKIP_ROUTES as a comma-separated list (default: the
+// notification mark-read and announcement dismiss routes); "none" records every route.
+func AuditRequestSkipRoutes() []string {
+	v := os.Getenv("AUDIT_REQUESTS_SKIP_ROUTES")
+	if v == "" {
+		return defaultAuditRequestSkipRoutes
+	}
+	if strings.EqualFold(strings.TrimSpace(v), "none") {
+		return nil
+	}
+	routes := make([]string, 0)
+	for _, route := range strings.Split(v, ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,51 @@
+// api/config/cache.go
+// Cache configuration: the shared Redis tier for running several API replicas, and the size
+// and lifetimes of the in-memory response cache.
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// RedisURL returns the Redis connection URL used for the shared cache tier and
+// cross-replica cache invalidation. Configured with REDIS_URL (default empty, which
+// keeps caches local to each instance).
+func RedisURL() string {
+	return os.Getenv("REDIS_URL")
+}
+
+// CacheMaxEntries returns how many responses the in-memory cache holds before evicting the
+// least recently used. Configured with CACHE_MAX_ENTRIES (default 1000).
+func CacheMaxEntries() int {
+	entries := 1000
+	if v := os.Getenv("CACHE_MAX_ENTRIES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			entries = parsed
+		}
+	}
+	return entries
+}
+
+// CacheListTTL returns how long cached list pages (cases, appointments, users) are served.
+// Configured with CACHE_LIST_TTL_SECONDS (default 300).
+func CacheListTTL() time.Duration {
+	return cacheTTL("CACHE_LIST_TTL_SECONDS", 300)
+}
+
+// CacheItemTTL returns how long a cached single case or appointment is served; items are
+// invalidated by ID on every write, so they can live longer than lists.
+// Configured with CACHE_ITEM_TTL_SECONDS (default 600).
+func CacheItemTTL() time.Duration {
+	return cacheTTL("CACHE_ITEM_TTL_SECONDS", 600)
+}
+
+func cacheTTL(name string, seconds int) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,30 @@
+// api/config/calendar_colors.go
+// Default calendar colors for appointment departments and categories.
+// Admins can override these through the calendar colors endpoints.
+package config
+
+// Calendar color kinds
+const (
+	CalendarColorDepartment = "department"
+	CalendarColorCategory   = "category"
+)
+
+// DefaultCalendarColor is used when neither the department nor the category has a color.
+const DefaultCalendarColor = "#8c8c8c"
+
+// DefaultDepartmentColors maps appointment departments to hex colors.
+var DefaultDepartmentColors = map[string]string{
+	"Familiar":   "#1677ff",
+	"Civil":      "#2f54eb",
+	"Psicologia": "#52c41a",
+	"Recursos":   "#fa8c16",
+	"General":    DefaultCalendarColor,
+}
+
+// DefaultCategoryColors maps appointment categories to hex colors.
+var DefaultCategoryColors = map[string]string{
+	"Consulta Legal":       "#1677ff",
+	"Sesion de Psicologia": "#52c41a",
+	"Trabajo Social":       "#fa8c16",
+	"General":              DefaultCalendarColor,
+}
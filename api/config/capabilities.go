@@ -0,0 +1,51 @@
+// api/config/capabilities.go
+// Capabilities let individual endpoints be granted to roles other than admin (for example a
+// "finance" role for revenue metrics) without handing out the full admin role.
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Capabilities checked by middleware.RequireCapability.
+const (
+	CapabilityFinancialMetrics = "financial_metrics"
+	CapabilitySystemMetrics    = "system_metrics"
+)
+
+// defaultCapabilityRoles are the roles granted each capability when its env var is unset.
+var defaultCapabilityRoles = map[string][]string{
+	CapabilityFinancialMetrics: {RoleAdmin, RoleFinance},
+	CapabilitySystemMetrics:    {RoleAdmin},
+}
+
+// CapabilityRoles returns the roles granted a capability, configured with
+// <CAPABILITY>_ROLES as a comma-separated list, e.g. FINANCIAL_METRICS_ROLES=admin,finance
+// (defaults: financial_metrics admin,finance; system_metrics admin). Admin always has every capability.
+func CapabilityRoles(capability string) []string {
+	v := os.Getenv(strings.ToUpper(capability) + "_ROLES")
+	if v == "" {
+		return defaultCapabilityRoles[capability]
+	}
+	roles := []string{RoleAdmin}
+	for _, role := range strings.Split(v, ",") {
+		if role = strings.TrimSpace(role); role != "" && role != RoleAdmin {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// HasCapability checks if a role has been granted a capability.
+func HasCapability(role, capability string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, granted := range CapabilityRoles(capability) {
+		if role == granted {
+			return true
+		}
+	}
+	return false
+}
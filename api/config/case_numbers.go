@@ -0,0 +1,29 @@
+// api/config/case_numbers.go
+// Format of the human-friendly case numbers (e.g. CAF-CEN-2025-000123) assigned on creation.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CaseNumberPrefix returns the leading segment of every case number.
+// Configured with CASE_NUMBER_PREFIX (default "CAF").
+func CaseNumberPrefix() string {
+	if v := strings.TrimSpace(os.Getenv("CASE_NUMBER_PREFIX")); v != "" {
+		return strings.ToUpper(v)
+	}
+	return "CAF"
+}
+
+// CaseNumberDigits returns how many digits the per office and year sequence is padded to.
+// Configured with CASE_NUMBER_DIGITS (default 6).
+func CaseNumberDigits() int {
+	if v := os.Getenv("CASE_NUMBER_DIGITS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 12 {
+			return parsed
+		}
+	}
+	return 6
+}
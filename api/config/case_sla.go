@@ -0,0 +1,68 @@
+// api/config/case_sla.go
+// Per-category service levels: how many days a new case has until it is due. Cases get their
+// due date from these on creation, and open cases past it count as overdue.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCaseSLADays maps case categories to the days a case of that category has to be resolved.
+// Migration 0082 backfills existing open cases with the same values.
+var DefaultCaseSLADays = map[string]int{
+	"Familiar":   90,
+	"Civil":      120,
+	"Psicologia": 60,
+	"Recursos":   30,
+}
+
+// defaultCaseSLAFallbackDays applies to categories without their own SLA.
+const defaultCaseSLAFallbackDays = 90
+
+// CaseSLADays returns the days a new case of the category has until it is due; 0 means it gets
+// no due date. Configured with CASE_SLA_DAYS as comma-separated category=days pairs that
+// override DefaultCaseSLADays (e.g. "Familiar=60,Civil=180"), and CASE_SLA_DEFAULT_DAYS for other
+// categories (default 90). Categories match case-insensitively; invalid entries are ignored.
+func CaseSLADays(category string) int {
+	days := make(map[string]int, len(DefaultCaseSLADays))
+	for name, value := range DefaultCaseSLADays {
+		days[strings.ToLower(name)] = value
+	}
+	for _, item := range strings.Split(os.Getenv("CASE_SLA_DAYS"), ",") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		name = strings.ToLower(strings.TrimSpace(name))
+		if err != nil || parsed < 0 || name == "" {
+			continue
+		}
+		days[name] = parsed
+	}
+	if value, ok := days[strings.ToLower(strings.TrimSpace(category))]; ok {
+		return value
+	}
+
+	fallback := defaultCaseSLAFallbackDays
+	if v := os.Getenv("CASE_SLA_DEFAULT_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && parsed >= 0 {
+			fallback = parsed
+		}
+	}
+	return fallback
+}
+
+// CaseDueDate returns when a case of the category opened at openedAt is due, counting calendar
+// days in openedAt's location, or nil when the category has no SLA.
+func CaseDueDate(category string, openedAt time.Time) *time.Time {
+	days := CaseSLADays(category)
+	if days == 0 {
+		return nil
+	}
+	due := openedAt.AddDate(0, 0, days)
+	return &due
+}
@@ -0,0 +1,76 @@
+// api/config/case_sla_test.go
+// Unit tests for the per-category case SLA and the due dates derived from it.
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCaseDueDateDefaults(t *testing.T) {
+	t.Setenv("CASE_SLA_DAYS", "")
+	t.Setenv("CASE_SLA_DEFAULT_DAYS", "")
+	opened := time.Date(2025, 1, 31, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"Familiar", "2025-05-01T10:30:00Z"},
+		{"Civil", "2025-05-31T10:30:00Z"},
+		{"Psicologia", "2025-04-01T10:30:00Z"},
+		{"Recursos", "2025-03-02T10:30:00Z"},
+		{"familiar", "2025-05-01T10:30:00Z"},
+		{"General", "2025-05-01T10:30:00Z"},
+		{"", "2025-05-01T10:30:00Z"},
+	}
+	for _, tt := range tests {
+		due := CaseDueDate(tt.category, opened)
+		if due == nil || due.Format(time.RFC3339) != tt.want {
+			t.Errorf("CaseDueDate(%q) = %v, want %s", tt.category, due, tt.want)
+		}
+	}
+}
+
+func TestCaseDueDateOverrides(t *testing.T) {
+	t.Setenv("CASE_SLA_DAYS", "Familiar=60, civil = 10,Recursos=0,Psicologia=abc,=5,broken")
+	t.Setenv("CASE_SLA_DEFAULT_DAYS", "15")
+	opened := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		category string
+		days     int
+	}{
+		{"Familiar", 60},
+		{"Civil", 10},
+		{"Recursos", 0},
+		{"Psicologia", 60},
+		{"General", 15},
+	}
+	for _, tt := range tests {
+		if got := CaseSLADays(tt.category); got != tt.days {
+			t.Errorf("CaseSLADays(%q) = %d, want %d", tt.category, got, tt.days)
+		}
+		due := CaseDueDate(tt.category, opened)
+		if tt.days == 0 {
+			if due != nil {
+				t.Errorf("CaseDueDate(%q) = %v, want none", tt.category, due)
+			}
+			continue
+		}
+		if due == nil || !due.Equal(opened.AddDate(0, 0, tt.days)) {
+			t.Errorf("CaseDueDate(%q) = %v, want %d days after opening", tt.category, due, tt.days)
+		}
+	}
+}
+
+func TestCaseDueDateCountsCalendarDays(t *testing.T) {
+	t.Setenv("CASE_SLA_DAYS", "Familiar=90")
+	loc, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	opened := time.Date(2030, 1, 15, 9, 0, 0, 0, loc)
+	due := CaseDueDate("Familiar", opened)
+	if due == nil || due.Format("2006-01-02 15:04") != "2030-04-15 09:00" {
+		t.Errorf("due = %v, want 2030-04-15 09:00 office time", due)
+	}
+}
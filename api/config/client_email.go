@@ -0,0 +1,38 @@
+// api/config/client_email.go
+// Validation of the email addresses given for clients created on the fly.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ClientEmailValidation reports whether emails of clients created on the fly are checked for a
+// valid format and a non-blocked domain.
+// Configured with CLIENT_EMAIL_VALIDATION (default true).
+func ClientEmailValidation() bool {
+	if v := os.Getenv("CLIENT_EMAIL_VALIDATION"); v != "" {
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed
+		}
+	}
+	return true
+}
+
+// ClientEmailBlockedDomains returns the domains rejected as placeholders for client emails.
+// Configured with CLIENT_EMAIL_BLOCKED_DOMAINS as a comma-separated list (default
+// "example.com,example.org,example.net"; set it empty to allow every domain).
+func ClientEmailBlockedDomains() map[string]bool {
+	list := "example.com,example.org,example.net"
+	if v, ok := os.LookupEnv("CLIENT_EMAIL_BLOCKED_DOMAINS"); ok {
+		list = v
+	}
+	domains := make(map[string]bool)
+	for _, domain := range strings.Split(list, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			domains[domain] = true
+		}
+	}
+	return domains
+}
@@ -0,0 +1,60 @@
+// api/config/compression.go
+// Response compression settings.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultCompressibleTypes are the content types compressed when COMPRESSION_CONTENT_TYPES is unset.
+// Binary downloads (PDF, XLSX, images) are already compressed and are left out.
+var defaultCompressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"text/",
+	"image/svg+xml",
+}
+
+// CompressionMinSizeBytes returns the smallest response body that is gzip-compressed.
+// Configured with COMPRESSION_MIN_SIZE_BYTES (default 1024).
+func CompressionMinSizeBytes() int {
+	size := 1024
+	if v := os.Getenv("COMPRESSION_MIN_SIZE_BYTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			size = parsed
+		}
+	}
+	return size
+}
+
+// CompressionLevel returns the gzip level from 1 (fastest) to 9 (smallest).
+// Configured with COMPRESSION_LEVEL (default 1, 0 disables compression).
+func CompressionLevel() int {
+	level := 1
+	if v := os.Getenv("COMPRESSION_LEVEL"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 && parsed <= 9 {
+			level = parsed
+		}
+	}
+	return level
+}
+
+// CompressionContentTypes returns the content-type prefixes eligible for compression.
+// Configured with COMPRESSION_CONTENT_TYPES as a comma-separated list
+// (default application/json, application/javascript, application/xml, text/ and image/svg+xml).
+func CompressionContentTypes() []string {
+	v := os.Getenv("COMPRESSION_CONTENT_TYPES")
+	if v == "" {
+		return defaultCompressibleTypes
+	}
+	types := make([]string, 0)
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
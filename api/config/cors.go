@@ -0,0 +1,38 @@
+// api/config/cors.go
+// Origins allowed to call the API from a browser.
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultProductionCORSOrigins are allowed in production when CORS_ALLOWED_ORIGINS is not set.
+var defaultProductionCORSOrigins = []string{
+	"https://admin.caf-mexico.com",
+	"https://admin.caf-mexico.org",
+	"https://caf-mexico.com",
+	"https://www.caf-mexico.com",
+}
+
+// CORSAllowedOrigins returns the origin patterns allowed to make cross-origin requests: exact
+// origins, origins with a single-label wildcard subdomain (e.g. "https://*.caf-mexico.org"), or
+// "*" for any origin. Configured with CORS_ALLOWED_ORIGINS (comma-separated); without it,
+// production (NODE_ENV=production) allows the CAF domains and other environments any origin.
+func CORSAllowedOrigins() []string {
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		origins := make([]string, 0)
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		if len(origins) > 0 {
+			return origins
+		}
+	}
+	if os.Getenv("NODE_ENV") == "production" {
+		return append([]string(nil), defaultProductionCORSOrigins...)
+	}
+	return []string{"*"}
+}
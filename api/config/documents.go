@@ -0,0 +1,51 @@
+// api/config/documents.go
+// Limits on case document uploads.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultDocumentUploadTypes are the content types accepted when DOCUMENT_UPLOAD_ALLOWED_TYPES
+// is unset: PDF, common images, and Word and Excel documents.
+var defaultDocumentUploadTypes = []string{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// DocumentUploadMaxBytes returns the largest case document accepted, in bytes.
+// Configured with DOCUMENT_UPLOAD_MAX_MB (default 25).
+func DocumentUploadMaxBytes() int64 {
+	megabytes := int64(25)
+	if v := os.Getenv("DOCUMENT_UPLOAD_MAX_MB"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			megabytes = parsed
+		}
+	}
+	return megabytes << 20
+}
+
+// DocumentUploadAllowedTypes returns the content types a case document may have, as detected
+// from the file's contents.
+// Configured with DOCUMENT_UPLOAD_ALLOWED_TYPES as a comma-separated list (default PDF, JPEG,
+// PNG, GIF, WebP, DOCX and XLSX).
+func DocumentUploadAllowedTypes() []string {
+	v := os.Getenv("DOCUMENT_UPLOAD_ALLOWED_TYPES")
+	if v == "" {
+		return defaultDocumentUploadTypes
+	}
+	types := make([]string, 0)
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
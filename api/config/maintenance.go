@@ -0,0 +1,72 @@
+// api/config/maintenance.go
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// MaintenanceInterval returns how often scheduled maintenance (VACUUM ANALYZE and
+// orphan file cleanup) runs. Configured with MAINTENANCE_INTERVAL_HOURS (default 0, disabled).
+func MaintenanceInterval() time.Duration {
+	hours := 0
+	if v := os.Getenv("MAINTENANCE_INTERVAL_HOURS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			hours = parsed
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// OrphanFileGracePeriod returns how old an unreferenced upload must be before cleanup
+// deletes it, so files whose database record is still being written are kept.
+// Configured with ORPHAN_FILE_GRACE_HOURS (default 24).
+func OrphanFileGracePeriod() time.Duration {
+	hours := 24
+	if v := os.Getenv("ORPHAN_FILE_GRACE_HOURS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			hours = parsed
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// AuditRetentionDays returns how long audit logs stay in the database before the
+// audit_retention maintenance task purges them. Entries whose expires_at has passed
+// are purged regardless. Configured with AUDIT_RETENTION_DAYS (default 0, purge disabled).
+func AuditRetentionDays() int {
+	days := 0
+	if v := os.Getenv("AUDIT_RETENTION_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			days = parsed
+		}
+	}
+	return days
+}
+
+// AuditArchiveBeforePurge reports whether purged audit logs are first exported as NDJSON
+// to file storage. When enabled and storage cannot hold archives, nothing is purged.
+// Configured with AUDIT_ARCHIVE_BEFORE_PURGE (default true).
+func AuditArchiveBeforePurge() bool {
+	if v := os.Getenv("AUDIT_ARCHIVE_BEFORE_PURGE"); v != "" {
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed
+		}
+	}
+	return true
+}
+
+// DeletedRecordRetentionDays returns how long soft-deleted cases, appointments and documents
+// are kept before the record_retention maintenance task permanently purges them and their
+// stored files. Configured with DELETED_RECORD_RETENTION_DAYS (default 2555, about seven
+// years; 0 disables the purge).
+func DeletedRecordRetentionDays() int {
+	days := 2555
+	if v := os.Getenv("DELETED_RECORD_RETENTION_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			days = parsed
+		}
+	}
+	return days
+}
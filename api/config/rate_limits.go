@@ -0,0 +1,30 @@
+// api/config/rate_limits.go
+// Per-role request limits for the general API rate limiter.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RoleRequestsPerMinute returns per-role limits on requests per minute for authenticated users,
+// which replace RATE_LIMIT_REQUESTS for users of those roles. Configured with
+// RATE_LIMIT_ROLE_LIMITS as comma-separated role=limit pairs (e.g. "client=60,admin=300");
+// roles match case-insensitively and invalid entries are ignored. Default: no per-role limits.
+func RoleRequestsPerMinute() map[string]int {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(os.Getenv("RATE_LIMIT_ROLE_LIMITS"), ",") {
+		role, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		role = strings.ToLower(strings.TrimSpace(role))
+		if err != nil || parsed <= 0 || role == "" {
+			continue
+		}
+		limits[role] = parsed
+	}
+	return limits
+}
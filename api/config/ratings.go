@@ -0,0 +1,20 @@
+// api/config/ratings.go
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// StaffRatingMinSamples returns how many client ratings a staff member needs
+// before an average rating is reported for them; below it the rating is 0 (unrated).
+// Configured with STAFF_RATING_MIN_SAMPLES (default 3).
+func StaffRatingMinSamples() int {
+	minSamples := 3
+	if v := os.Getenv("STAFF_RATING_MIN_SAMPLES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			minSamples = parsed
+		}
+	}
+	return minSamples
+}
@@ -0,0 +1,83 @@
+// api/config/reminders.go
+// Scheduling of appointment reminders, and throughput limits for outbound reminder sends so a
+// large sweep does not overwhelm the email/SMS providers.
+package config
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReminderInterval returns how often the reminder worker looks for appointments due a reminder.
+// Configured with REMINDER_INTERVAL_MINUTES (default 5, 0 disables reminders).
+func ReminderInterval() time.Duration {
+	minutes := 5
+	if v := os.Getenv("REMINDER_INTERVAL_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			minutes = parsed
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ReminderLeadTimes returns how long before a confirmed appointment each reminder is sent,
+// longest first.
+// Configured with REMINDER_LEAD_TIMES as a comma-separated list of durations (default "24h,1h");
+// invalid entries are ignored.
+func ReminderLeadTimes() []time.Duration {
+	v := os.Getenv("REMINDER_LEAD_TIMES")
+	if strings.TrimSpace(v) == "" {
+		v = "24h,1h"
+	}
+	leads := make([]time.Duration, 0)
+	seen := make(map[time.Duration]bool)
+	for _, item := range strings.Split(v, ",") {
+		lead, err := time.ParseDuration(strings.TrimSpace(item))
+		if err != nil || lead <= 0 || seen[lead] {
+			continue
+		}
+		seen[lead] = true
+		leads = append(leads, lead)
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i] > leads[j] })
+	return leads
+}
+
+// ReminderBatchSize returns how many reminders are sent per batch.
+// Configured with REMINDER_BATCH_SIZE (default 50).
+func ReminderBatchSize() int {
+	size := 50
+	if v := os.Getenv("REMINDER_BATCH_SIZE"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			size = parsed
+		}
+	}
+	return size
+}
+
+// ReminderBatchDelay returns the pause between reminder batches.
+// Configured with REMINDER_BATCH_DELAY_MS (default 1000, 0 disables the pause).
+func ReminderBatchDelay() time.Duration {
+	delay := 1000
+	if v := os.Getenv("REMINDER_BATCH_DELAY_MS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			delay = parsed
+		}
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// ReminderConcurrency returns the maximum number of reminder sends in flight at once.
+// Configured with REMINDER_CONCURRENCY (default 5).
+func ReminderConcurrency() int {
+	concurrency := 5
+	if v := os.Getenv("REMINDER_CONCURRENCY"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			concurrency = parsed
+		}
+	}
+	return concurrency
+}
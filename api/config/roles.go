@@ -22,6 +22,7 @@ const (
 	RolePsychologist     = "psychologist"
 	RoleReceptionist     = "receptionist"
 	RoleEventCoordinator = "event_coordinator"
+	RoleFinance          = "finance"
 )
 
 // STAFF_ROLES is the authoritative list of all valid staff roles
@@ -69,6 +70,13 @@ var STAFF_ROLES = map[string]StaffRole{
 		Department:  "Events",
 		Description: "Event planning and coordination",
 	},
+	RoleFinance: {
+		Key:         RoleFinance,
+		SpanishName: "Finanzas",
+		EnglishName: "Finance",
+		Department:  "Finance",
+		Description: "Financial metrics and payment reporting",
+	},
 }
 
 // VALID_ROLES contains all valid role keys for validation
@@ -79,6 +87,7 @@ var VALID_ROLES = []string{
 	RolePsychologist,
 	RoleReceptionist,
 	RoleEventCoordinator,
+	RoleFinance,
 	"client", // Add client role as valid
 }
 
@@ -0,0 +1,114 @@
+// api/config/scheduling.go
+// Scheduling rules shared by appointment handlers.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppointmentOfficeBufferMinutes returns the minimum gap required between two
+// appointments of the same staff member held at different offices.
+// Configured with APPOINTMENT_OFFICE_BUFFER_MINUTES (default 30, 0 disables the check).
+func AppointmentOfficeBufferMinutes() int {
+	buffer := 30
+	if v := os.Getenv("APPOINTMENT_OFFICE_BUFFER_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			buffer = parsed
+		}
+	}
+	return buffer
+}
+
+// AppointmentTravelSpeedKmh returns the average travel speed used to estimate
+// travel time between offices with known coordinates.
+// Configured with APPOINTMENT_TRAVEL_SPEED_KMH (default 40, 0 disables travel estimates).
+func AppointmentTravelSpeedKmh() float64 {
+	speed := 40.0
+	if v := os.Getenv("APPOINTMENT_TRAVEL_SPEED_KMH"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 {
+			speed = parsed
+		}
+	}
+	return speed
+}
+
+// AppointmentsMaxPageSize returns the largest pageSize accepted by the appointment list.
+// The calendar loads a whole range in one page, so the cap is higher than other lists.
+// Configured with APPOINTMENTS_MAX_PAGE_SIZE (default 1000).
+func AppointmentsMaxPageSize() int {
+	limit := 1000
+	if v := os.Getenv("APPOINTMENTS_MAX_PAGE_SIZE"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	return limit
+}
+
+// AppointmentSlotMinutes returns the slot granularity appointment start times must align to,
+// counted from midnight. Admins can override it at runtime through the scheduling settings.
+// Configured with APPOINTMENT_SLOT_MINUTES (default 15, 0 disables the check).
+func AppointmentSlotMinutes() int {
+	slot := 15
+	if v := os.Getenv("APPOINTMENT_SLOT_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			slot = parsed
+		}
+	}
+	return slot
+}
+
+// AppointmentSlotAlignEnd reports whether appointment end times must align to the slot as well.
+// Configured with APPOINTMENT_SLOT_ALIGN_END (default false).
+func AppointmentSlotAlignEnd() bool {
+	if v := os.Getenv("APPOINTMENT_SLOT_ALIGN_END"); v != "" {
+		if parsed, err := strconv.ParseBool(v); err == nil {
+			return parsed
+		}
+	}
+	return false
+}
+
+// AppointmentWorkingHours returns the daily window appointments must fall within, as
+// minutes from midnight. Used to validate imported schedules.
+// Configured with APPOINTMENT_WORKING_HOURS as "HH:MM-HH:MM" (default "08:00-18:00").
+func AppointmentWorkingHours() (start, end int) {
+	start, end = 8*60, 18*60
+	if v := os.Getenv("APPOINTMENT_WORKING_HOURS"); v != "" {
+		from, to, ok := strings.Cut(v, "-")
+		if !ok {
+			return start, end
+		}
+		fromTime, errFrom := time.Parse("15:04", strings.TrimSpace(from))
+		toTime, errTo := time.Parse("15:04", strings.TrimSpace(to))
+		if errFrom == nil && errTo == nil && fromTime.Before(toTime) {
+			start = fromTime.Hour()*60 + fromTime.Minute()
+			end = toTime.Hour()*60 + toTime.Minute()
+		}
+	}
+	return start, end
+}
+
+// AppointmentWorkingDays returns the weekdays appointments may be held on.
+// Configured with APPOINTMENT_WORKING_DAYS as comma-separated weekday numbers,
+// 0 = Sunday (default "1,2,3,4,5", Monday to Friday).
+func AppointmentWorkingDays() map[time.Weekday]bool {
+	days := map[time.Weekday]bool{
+		time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true,
+	}
+	if v := os.Getenv("APPOINTMENT_WORKING_DAYS"); v != "" {
+		parsed := make(map[time.Weekday]bool)
+		for _, part := range strings.Split(v, ",") {
+			day, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || day < 0 || day > 6 {
+				return days
+			}
+			parsed[time.Weekday(day)] = true
+		}
+		days = parsed
+	}
+	return days
+}
@@ -0,0 +1,21 @@
+// api/config/service_tokens.go
+// Service tokens let scheduled jobs and integrations call the API under their own rate limit.
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// ServiceTokenRequestsPerMinute returns the per-token limit on requests made with a service
+// token, which replaces the per-user and per-IP limits for those requests.
+// Configured with SERVICE_TOKEN_RATE_LIMIT_PER_MINUTE (default 300).
+func ServiceTokenRequestsPerMinute() int {
+	limit := 300
+	if v := os.Getenv("SERVICE_TOKEN_RATE_LIMIT_PER_MINUTE"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	return limit
+}
@@ -0,0 +1,103 @@
+// api/config/stage_rules.go
+// Rules used to suggest when a case is ready to move to its next stage.
+// Suggestions are advisory; staff confirm stage changes explicitly.
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+)
+
+// StageProgressionRule lists the conditions a case in FromStage must meet
+// before ToStage is suggested. An empty ToStage means the next stage in order.
+type StageProgressionRule struct {
+	FromStage                string `json:"fromStage"`
+	ToStage                  string `json:"toStage,omitempty"`
+	MinCompletedAppointments int    `json:"minCompletedAppointments,omitempty"`
+	RequireTasksCompleted    bool   `json:"requireTasksCompleted,omitempty"`
+	RequireDocuments         bool   `json:"requireDocuments,omitempty"` // All required checklist documents uploaded
+}
+
+// DefaultStageRulesKey holds the rules for categories without their own entry.
+const DefaultStageRulesKey = "default"
+
+// DefaultStageProgressionRules are the built-in rules, keyed by case category.
+var DefaultStageProgressionRules = map[string][]StageProgressionRule{
+	"Familiar": legalStageProgressionRules,
+	"Civil":    legalStageProgressionRules,
+	DefaultStageRulesKey: {
+		{FromStage: "intake", RequireTasksCompleted: true},
+		{FromStage: "initial_consultation", MinCompletedAppointments: 1},
+		{FromStage: "document_review", RequireDocuments: true, RequireTasksCompleted: true},
+		{FromStage: "action_plan", RequireTasksCompleted: true},
+		{FromStage: "resolution", RequireTasksCompleted: true},
+	},
+}
+
+var legalStageProgressionRules = []StageProgressionRule{
+	{FromStage: "etapa_inicial", RequireDocuments: true, RequireTasksCompleted: true},
+	{FromStage: "notificacion", MinCompletedAppointments: 1, RequireTasksCompleted: true},
+	{FromStage: "audiencia_preliminar", MinCompletedAppointments: 2},
+	{FromStage: "audiencia_juicio", MinCompletedAppointments: 3, RequireTasksCompleted: true},
+}
+
+var (
+	stageRulesOnce sync.Once
+	stageRules     map[string][]StageProgressionRule
+)
+
+// loadStageProgressionRules merges CASE_STAGE_RULES (a JSON object of category -> rules)
+// over the defaults. Categories present in the override replace the defaults entirely.
+func loadStageProgressionRules() {
+	stageRules = make(map[string][]StageProgressionRule, len(DefaultStageProgressionRules))
+	for category, rules := range DefaultStageProgressionRules {
+		stageRules[category] = rules
+	}
+
+	raw := os.Getenv("CASE_STAGE_RULES")
+	if raw == "" {
+		return
+	}
+	var overrides map[string][]StageProgressionRule
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		log.Printf("WARNING: Ignoring invalid CASE_STAGE_RULES: %v", err)
+		return
+	}
+	for category, rules := range overrides {
+		stageRules[category] = rules
+	}
+}
+
+// GetStageProgressionRule returns the rule that applies to a case of the given
+// category in the given stage, and whether one exists.
+func GetStageProgressionRule(category, stage string) (StageProgressionRule, bool) {
+	stageRulesOnce.Do(loadStageProgressionRules)
+
+	rules, ok := stageRules[category]
+	if !ok {
+		rules = stageRules[DefaultStageRulesKey]
+	}
+	for _, rule := range rules {
+		if rule.FromStage == stage {
+			if rule.ToStage == "" {
+				rule.ToStage = NextCaseStage(category, stage)
+			}
+			return rule, rule.ToStage != ""
+		}
+	}
+	return StageProgressionRule{}, false
+}
+
+// NextCaseStage returns the stage that follows the given one for the category,
+// or an empty string when the stage is the last (or unknown).
+func NextCaseStage(category, stage string) string {
+	stages := GetCaseStages(category)
+	for i, s := range stages {
+		if s == stage && i+1 < len(stages) {
+			return stages[i+1]
+		}
+	}
+	return ""
+}
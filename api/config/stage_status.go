@@ -0,0 +1,46 @@
+// api/config/stage_status.go
+// Case status derived from the stage when a case moves through its lifecycle.
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CaseStageStatusSyncEnabled reports whether a stage change through UpdateCaseStage also
+// derives the case status and cancels the upcoming appointments of a case it closes.
+// Offices listed in CASE_STAGE_STATUS_SYNC_MANUAL_OFFICES (comma-separated office IDs) manage
+// status by hand. Configured with CASE_STAGE_STATUS_SYNC (default true).
+func CaseStageStatusSyncEnabled(officeID uint) bool {
+	if enabled, err := strconv.ParseBool(os.Getenv("CASE_STAGE_STATUS_SYNC")); err == nil && !enabled {
+		return false
+	}
+	for _, part := range strings.Split(os.Getenv("CASE_STAGE_STATUS_SYNC_MANUAL_OFFICES"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil && uint(id) == officeID {
+			return false
+		}
+	}
+	return true
+}
+
+// DeriveCaseStatus returns the status a case in the given stage should have: open in the first
+// stage of its category, closed in the last and in progress in between. Unknown stages
+// derive no status.
+func DeriveCaseStatus(category, stage string) string {
+	stages := GetCaseStages(category)
+	for i, s := range stages {
+		if s != stage {
+			continue
+		}
+		switch i {
+		case 0:
+			return string(CaseStatusOpen)
+		case len(stages) - 1:
+			return string(CaseStatusClosed)
+		default:
+			return string(CaseStatusInProgress)
+		}
+	}
+	return ""
+}
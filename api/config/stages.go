@@ -41,12 +41,11 @@ var CaseStageLabels = map[string]string{
 
 // CaseStatusLabels provides Spanish (Mexico) localization for case statuses
 var CaseStatusLabels = map[string]string{
-	"open":     "Abierto",
-	"active":   "Activo",
-	"resolved": "Resuelto",
-	"closed":   "Cerrado",
-	"pending":  "Pendiente",
-	"cancelled": "Cancelado",
+	"open":        "Abierto",
+	"in_progress": "En Progreso",
+	"closed":      "Cerrado",
+	"pending":     "Pendiente",
+	"archived":    "Archivado",
 }
 
 // AppointmentStatusLabels provides Spanish (Mexico) localization for appointment statuses
@@ -4,6 +4,8 @@
 
 package config
 
+import "strings"
+
 // AppointmentStatus represents the valid states of an appointment
 type AppointmentStatus string
 
@@ -90,6 +92,51 @@ func IsValidCaseStatus(status string) bool {
 	return false
 }
 
+// legacyCaseStatuses maps the case statuses older code wrote to their canonical status.
+// Migration 0084 rewrites stored rows the same way.
+var legacyCaseStatuses = map[string]CaseStatus{
+	"active":    CaseStatusInProgress,
+	"resolved":  CaseStatusClosed,
+	"completed": CaseStatusClosed,
+	"cancelled": CaseStatusClosed,
+	"reopened":  CaseStatusOpen,
+}
+
+// NormalizeCaseStatus returns the canonical case status for status, accepting the legacy
+// names in legacyCaseStatuses. It returns false for unknown statuses.
+func NormalizeCaseStatus(status string) (CaseStatus, bool) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if IsValidCaseStatus(status) {
+		return CaseStatus(status), true
+	}
+	canonical, ok := legacyCaseStatuses[status]
+	return canonical, ok
+}
+
+// IsClosedCaseStatus reports whether status, canonical or legacy, means the case is closed
+func IsClosedCaseStatus(status string) bool {
+	canonical, ok := NormalizeCaseStatus(status)
+	return ok && canonical == CaseStatusClosed
+}
+
+// GetCaseStatusDisplayName returns the Spanish display name for a case status
+func GetCaseStatusDisplayName(status CaseStatus) string {
+	switch status {
+	case CaseStatusOpen:
+		return "Abierto"
+	case CaseStatusInProgress:
+		return "En Progreso"
+	case CaseStatusClosed:
+		return "Cerrado"
+	case CaseStatusPending:
+		return "Pendiente"
+	case CaseStatusArchived:
+		return "Archivado"
+	default:
+		return "Desconocido"
+	}
+}
+
 // TaskStatus represents the valid states of a task
 type TaskStatus string
 
@@ -0,0 +1,67 @@
+// api/config/statuses_test.go
+// Unit tests for case status normalization and the status each stage derives.
+package config
+
+import "testing"
+
+func TestNormalizeCaseStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   CaseStatus
+		ok     bool
+	}{
+		{"open", CaseStatusOpen, true},
+		{"in_progress", CaseStatusInProgress, true},
+		{"pending", CaseStatusPending, true},
+		{"closed", CaseStatusClosed, true},
+		{"archived", CaseStatusArchived, true},
+		{" Closed ", CaseStatusClosed, true},
+		{"active", CaseStatusInProgress, true},
+		{"resolved", CaseStatusClosed, true},
+		{"completed", CaseStatusClosed, true},
+		{"cancelled", CaseStatusClosed, true},
+		{"reopened", CaseStatusOpen, true},
+		{"deleted", "", false},
+		{"done", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeCaseStatus(tt.status)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeCaseStatus(%q) = %q, %v; want %q, %v", tt.status, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDeriveCaseStatusByStage(t *testing.T) {
+	tests := []struct {
+		category string
+		stage    string
+		want     string
+	}{
+		// Familiar and Civil cases follow the legal stages
+		{"Familiar", "etapa_inicial", "open"},
+		{"Familiar", "notificacion", "in_progress"},
+		{"Familiar", "audiencia_preliminar", "in_progress"},
+		{"Civil", "audiencia_juicio", "in_progress"},
+		{"Civil", "sentencia", "closed"},
+		{"Familiar", "resolution", ""},
+		// Every other category follows the default stages
+		{"Psicologia", "intake", "open"},
+		{"Psicologia", "initial_consultation", "in_progress"},
+		{"Recursos", "document_review", "in_progress"},
+		{"Recursos", "action_plan", "in_progress"},
+		{"General", "resolution", "in_progress"},
+		{"General", "closed", "closed"},
+		{"Psicologia", "sentencia", ""},
+		{"General", "unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := DeriveCaseStatus(tt.category, tt.stage); got != tt.want {
+			t.Errorf("DeriveCaseStatus(%q, %q) = %q, want %q", tt.category, tt.stage, got, tt.want)
+		}
+		if got := DeriveCaseStatus(tt.category, tt.stage); got != "" && !IsValidCaseStatus(got) {
+			t.Errorf("DeriveCaseStatus(%q, %q) = %q is not a valid case status", tt.category, tt.stage, got)
+		}
+	}
+}
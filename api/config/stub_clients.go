@@ -0,0 +1,20 @@
+// api/config/stub_clients.go
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// StubClientMaxAgeDays returns how old an auto-created client that never logged in
+// and has no active case must be before it counts as a stub eligible for cleanup.
+// Configured with STUB_CLIENT_MAX_AGE_DAYS (default 90).
+func StubClientMaxAgeDays() int {
+	days := 90
+	if v := os.Getenv("STUB_CLIENT_MAX_AGE_DAYS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+	return days
+}
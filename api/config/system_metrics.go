@@ -0,0 +1,56 @@
+// api/config/system_metrics.go
+// Host resource readings (CPU, memory, disk) and uptime shown in the system health panel.
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// SystemMetricsDiskPath returns the path whose volume is measured for disk usage.
+// Configured with SYSTEM_METRICS_DISK_PATH (default UPLOADS_DIR, or "/" when that is unset).
+func SystemMetricsDiskPath() string {
+	if v := os.Getenv("SYSTEM_METRICS_DISK_PATH"); v != "" {
+		return v
+	}
+	if v := os.Getenv("UPLOADS_DIR"); v != "" {
+		return v
+	}
+	return "/"
+}
+
+// SystemMetricsThresholds returns the usage percentages at which a CPU, memory or disk reading
+// is reported as a warning and as critical.
+// Configured with SYSTEM_METRICS_WARNING_PERCENT (default 80) and
+// SYSTEM_METRICS_CRITICAL_PERCENT (default 90); a critical threshold below the warning one is ignored.
+func SystemMetricsThresholds() (warning, critical float64) {
+	warning, critical = 80, 90
+	if v := os.Getenv("SYSTEM_METRICS_WARNING_PERCENT"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 && parsed <= 100 {
+			warning = parsed
+		}
+	}
+	if v := os.Getenv("SYSTEM_METRICS_CRITICAL_PERCENT"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 && parsed <= 100 {
+			critical = parsed
+		}
+	}
+	if critical < warning {
+		critical = warning
+	}
+	return warning, critical
+}
+
+// SystemUptimeWindow returns the trailing period the uptime percentage is measured over: a
+// process that has run for the whole window reports 100%.
+// Configured with SYSTEM_UPTIME_WINDOW_HOURS (default 24).
+func SystemUptimeWindow() time.Duration {
+	hours := 24
+	if v := os.Getenv("SYSTEM_UPTIME_WINDOW_HOURS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			hours = parsed
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
@@ -0,0 +1,34 @@
+// api/config/utilization.go
+// Staff utilization targets.
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// StaffTargetUtilization returns the utilization target, as a percentage of working hours
+// booked, for staff members without their own target.
+// Configured with STAFF_TARGET_UTILIZATION (default 70).
+func StaffTargetUtilization() float64 {
+	target := 70.0
+	if v := os.Getenv("STAFF_TARGET_UTILIZATION"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 100 {
+			target = parsed
+		}
+	}
+	return target
+}
+
+// StaffUtilizationTolerance returns how many percentage points utilization may differ from
+// the target before it is reported as over or under.
+// Configured with STAFF_UTILIZATION_TOLERANCE (default 5).
+func StaffUtilizationTolerance() float64 {
+	tolerance := 5.0
+	if v := os.Getenv("STAFF_UTILIZATION_TOLERANCE"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 {
+			tolerance = parsed
+		}
+	}
+	return tolerance
+}
@@ -0,0 +1,60 @@
+// api/config/webhooks.go
+// Delivery settings for outbound webhooks to partner systems.
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// WebhookTimeout returns how long a single webhook delivery attempt may take.
+// Configured with WEBHOOK_TIMEOUT_SECONDS (default 10).
+func WebhookTimeout() time.Duration {
+	seconds := 10
+	if v := os.Getenv("WEBHOOK_TIMEOUT_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// WebhookMaxRetries returns how many times a failed delivery (network error or non-2xx
+// response) is retried, with the delay doubling from WebhookRetryBaseDelay.
+// Configured with WEBHOOK_MAX_RETRIES (default 3, 0 disables retries).
+func WebhookMaxRetries() int {
+	retries := 3
+	if v := os.Getenv("WEBHOOK_MAX_RETRIES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			retries = parsed
+		}
+	}
+	return retries
+}
+
+// WebhookRetryBaseDelay returns the wait before the first retry of a failed delivery; each
+// further retry waits twice as long as the previous one.
+// Configured with WEBHOOK_RETRY_BASE_SECONDS (default 30).
+func WebhookRetryBaseDelay() time.Duration {
+	seconds := 30
+	if v := os.Getenv("WEBHOOK_RETRY_BASE_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// WebhookWorkerInterval returns how often the delivery worker looks for queued deliveries
+// that are due. New events wake it immediately as well.
+// Configured with WEBHOOK_WORKER_INTERVAL_SECONDS (default 5, 0 disables the worker).
+func WebhookWorkerInterval() time.Duration {
+	seconds := 5
+	if v := os.Getenv("WEBHOOK_WORKER_INTERVAL_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/BryanPMX/CAF/api/interfaces"
 	"github.com/BryanPMX/CAF/api/repositories"
 	"github.com/BryanPMX/CAF/api/services"
+	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
 
@@ -21,10 +22,12 @@ type Container struct {
 	appointmentService interfaces.AppointmentService
 	userService       interfaces.UserService
 	dashboardService  interfaces.DashboardService
+	sessionService    interfaces.SessionService
 }
 
-// NewContainer creates a new dependency injection container
-func NewContainer(db *gorm.DB) *Container {
+// NewContainer creates a new dependency injection container. redisClient is optional; when set,
+// sessions are shared through Redis across API instances.
+func NewContainer(db *gorm.DB, redisClient *redis.Client) *Container {
 	// Initialize repositories
 	caseRepo := repositories.NewCaseRepository(db)
 	appointmentRepo := repositories.NewAppointmentRepository(db)
@@ -36,6 +39,7 @@ func NewContainer(db *gorm.DB) *Container {
 	appointmentService := services.NewAppointmentService(appointmentRepo, caseRepo, userRepo)
 	userService := services.NewUserService(userRepo)
 	dashboardService := services.NewDashboardService(db)
+	sessionService := services.NewSessionService(db, redisClient)
 
 	return &Container{
 		caseRepo:           caseRepo,
@@ -46,6 +50,7 @@ func NewContainer(db *gorm.DB) *Container {
 		appointmentService: appointmentService,
 		userService:        userService,
 		dashboardService:   dashboardService,
+		sessionService:     sessionService,
 	}
 }
 
@@ -82,3 +87,7 @@ func (c *Container) GetUserService() interfaces.UserService {
 func (c *Container) GetDashboardService() interfaces.DashboardService {
 	return c.dashboardService
 }
+
+func (c *Container) GetSessionService() interfaces.SessionService {
+	return c.sessionService
+}
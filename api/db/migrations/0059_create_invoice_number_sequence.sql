@@ -0,0 +1,4 @@
+-- Migration: 0059_create_invoice_number_sequence.sql
+-- Description: Add a sequence backing unique, monotonically increasing invoice numbers for case invoice PDFs.
+
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq START WITH 1 INCREMENT BY 1;
@@ -0,0 +1,48 @@
+-- Migration: 0060_document_checklists.sql
+-- Description: Per-category required document checklists and a document_type tag on uploaded case documents.
+
+CREATE TABLE IF NOT EXISTS document_checklist_items (
+    id SERIAL PRIMARY KEY,
+    category VARCHAR(100) NOT NULL,
+    document_type VARCHAR(100) NOT NULL,
+    label VARCHAR(255) NOT NULL,
+    description TEXT,
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(category, document_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_checklist_items_category ON document_checklist_items(category);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'case_events' AND column_name = 'document_type'
+    ) THEN
+        ALTER TABLE case_events ADD COLUMN document_type VARCHAR(100);
+        RAISE NOTICE 'Added document_type to case_events';
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_case_events_case_document_type ON case_events(case_id, document_type) WHERE document_type IS NOT NULL;
+
+-- Default checklists (editable from the admin portal)
+INSERT INTO document_checklist_items (category, document_type, label, required, sort_order) VALUES
+    ('Familiar', 'identificacion_oficial', 'Identificación oficial (INE)', TRUE, 1),
+    ('Familiar', 'curp', 'CURP', TRUE, 2),
+    ('Familiar', 'comprobante_domicilio', 'Comprobante de domicilio', TRUE, 3),
+    ('Familiar', 'acta_nacimiento', 'Acta de nacimiento', TRUE, 4),
+    ('Familiar', 'acta_matrimonio', 'Acta de matrimonio (divorcios)', FALSE, 5),
+    ('Familiar', 'actas_nacimiento_hijos', 'Actas de nacimiento de los hijos', FALSE, 6),
+    ('Civil', 'identificacion_oficial', 'Identificación oficial (INE)', TRUE, 1),
+    ('Civil', 'curp', 'CURP', TRUE, 2),
+    ('Civil', 'comprobante_domicilio', 'Comprobante de domicilio', TRUE, 3),
+    ('Civil', 'documento_base', 'Documento base de la acción (contrato, escritura, etc.)', FALSE, 4),
+    ('Psicologia', 'identificacion_oficial', 'Identificación oficial (INE)', TRUE, 1),
+    ('Psicologia', 'consentimiento_informado', 'Consentimiento informado firmado', TRUE, 2)
+ON CONFLICT (category, document_type) DO NOTHING;
@@ -0,0 +1,19 @@
+-- Migration: 0061_notification_deliveries.sql
+-- Description: Log of outbound email/SMS deliveries per user, used for the client communication log.
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    recipient VARCHAR(255),
+    subject VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    entity_type VARCHAR(50),
+    entity_id BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user_created ON notification_deliveries(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_channel ON notification_deliveries(channel);
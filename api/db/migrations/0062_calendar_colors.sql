@@ -0,0 +1,14 @@
+-- Migration: 0062_calendar_colors.sql
+-- Description: Admin-defined calendar colors for appointment departments and categories.
+
+CREATE TABLE IF NOT EXISTS calendar_colors (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    key VARCHAR(100) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    label VARCHAR(255),
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kind, key)
+);
@@ -0,0 +1,22 @@
+-- Migration: 0063_client_ratings.sql
+-- Description: Client satisfaction ratings (1-5) for completed appointments and cases.
+
+CREATE TABLE IF NOT EXISTS client_ratings (
+    id SERIAL PRIMARY KEY,
+    client_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type VARCHAR(20) NOT NULL,
+    case_id INT NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+    appointment_id INT REFERENCES appointments(id) ON DELETE CASCADE,
+    staff_id INT REFERENCES users(id) ON DELETE SET NULL,
+    office_id INT REFERENCES offices(id) ON DELETE SET NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One rating per client per appointment, and per client per case
+CREATE UNIQUE INDEX IF NOT EXISTS ux_client_ratings_appointment ON client_ratings(client_id, appointment_id) WHERE entity_type = 'appointment';
+CREATE UNIQUE INDEX IF NOT EXISTS ux_client_ratings_case ON client_ratings(client_id, case_id) WHERE entity_type = 'case';
+CREATE INDEX IF NOT EXISTS idx_client_ratings_staff ON client_ratings(staff_id);
+CREATE INDEX IF NOT EXISTS idx_client_ratings_office ON client_ratings(office_id);
@@ -0,0 +1,15 @@
+-- Migration: 0064_maintenance_runs.sql
+-- Description: History of database/storage maintenance runs (VACUUM ANALYZE, orphan file cleanup).
+
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+    id SERIAL PRIMARY KEY,
+    task VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    details TEXT,
+    error TEXT,
+    triggered_by INT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_runs_task_started ON maintenance_runs(task, started_at DESC);
@@ -0,0 +1,17 @@
+-- Migration: 0065_user_must_change_password.sql
+-- Description: Flag clients auto-created with a placeholder password so stub accounts can be identified and cleaned up.
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'must_change_password'
+    ) THEN
+        ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+        RAISE NOTICE 'Added must_change_password to users';
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_stub_clients ON users(created_at)
+    WHERE role = 'client' AND must_change_password = TRUE AND last_login IS NULL AND deleted_at IS NULL;
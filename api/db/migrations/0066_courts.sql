@@ -0,0 +1,14 @@
+-- Migration: 0066_courts.sql
+-- Description: Admin-maintained list of court names used to normalize cases.court.
+
+CREATE TABLE IF NOT EXISTS courts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Names are unique regardless of case so "Juzgado 1" and "juzgado 1" cannot coexist
+CREATE UNIQUE INDEX IF NOT EXISTS ux_courts_name_lower ON courts (LOWER(name));
@@ -0,0 +1,16 @@
+-- Migration: 0067_audit_archives.sql
+-- Description: Record of audit log NDJSON exports written to file storage before retention purges.
+
+CREATE TABLE IF NOT EXISTS audit_archives (
+    id SERIAL PRIMARY KEY,
+    location TEXT NOT NULL,
+    row_count INT NOT NULL,
+    first_log_id INT NOT NULL,
+    last_log_id INT NOT NULL,
+    oldest_at TIMESTAMP NOT NULL,
+    newest_at TIMESTAMP NOT NULL,
+    maintenance_run_id INT REFERENCES maintenance_runs(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_archives_created_at ON audit_archives(created_at DESC);
@@ -0,0 +1,25 @@
+-- Migration: 0068_case_status_history.sql
+-- Description: Append-only log of case stage and status changes, used by the case funnel report.
+
+CREATE TABLE IF NOT EXISTS case_status_history (
+    id SERIAL PRIMARY KEY,
+    case_id INT NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+    office_id INT NOT NULL,
+    from_stage VARCHAR(50),
+    to_stage VARCHAR(50) NOT NULL,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_case_status_history_case_id ON case_status_history(case_id);
+CREATE INDEX IF NOT EXISTS idx_case_status_history_office_changed ON case_status_history(office_id, changed_at);
+
+-- Seed each existing case with its current stage so the funnel has a starting point
+INSERT INTO case_status_history (case_id, office_id, to_stage, to_status, changed_at)
+SELECT c.id, c.office_id, c.current_stage, COALESCE(c.status, 'open'), COALESCE(c.created_at, CURRENT_TIMESTAMP)
+FROM cases c
+WHERE c.deleted_at IS NULL
+  AND c.current_stage IS NOT NULL AND c.current_stage <> ''
+  AND NOT EXISTS (SELECT 1 FROM case_status_history h WHERE h.case_id = c.id);
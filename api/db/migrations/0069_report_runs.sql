@@ -0,0 +1,19 @@
+-- Migration: 0069_report_runs.sql
+-- Description: Stored report parameters so admins can re-run a previously generated report.
+
+CREATE TABLE IF NOT EXISTS report_runs (
+    id SERIAL PRIMARY KEY,
+    report_type VARCHAR(30) NOT NULL,
+    query TEXT,
+    date_from TIMESTAMP NOT NULL,
+    date_to TIMESTAMP NOT NULL,
+    office_scope_id INT REFERENCES offices(id) ON DELETE SET NULL,
+    run_by_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    run_by_role VARCHAR(50) NOT NULL,
+    rerun_count INT NOT NULL DEFAULT 0,
+    last_rerun_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_runs_created_at ON report_runs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_report_runs_run_by ON report_runs(run_by_id);
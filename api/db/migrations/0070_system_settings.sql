@@ -0,0 +1,11 @@
+-- Migration: 0070_system_settings.sql
+-- Description: Admin overrides of configuration defaults (e.g. appointment slot granularity).
+
+CREATE TABLE IF NOT EXISTS system_settings (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(100) NOT NULL UNIQUE,
+    value VARCHAR(255) NOT NULL,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- Migration: 0071_case_numbers.sql
+-- Description: Human-friendly case numbers (e.g. CAF-CEN-2025-000123) from a per office and year sequence.
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS case_number VARCHAR(50);
+
+CREATE TABLE IF NOT EXISTS case_number_sequences (
+    office_id INT NOT NULL,
+    year INT NOT NULL,
+    last_value BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (office_id, year)
+);
+
+-- Existing cases are numbered at startup by models.NumberExistingCases, which uses the
+-- configured CASE_NUMBER_PREFIX and CASE_NUMBER_DIGITS
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cases_case_number ON cases(case_number) WHERE case_number IS NOT NULL;
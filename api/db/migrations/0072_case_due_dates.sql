@@ -0,0 +1,8 @@
+-- Migration: 0072_case_due_dates.sql
+-- Description: Optional due date on cases, used by the overdue cases report and dashboard count.
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS due_date TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_cases_due_date_open
+    ON cases (due_date)
+    WHERE due_date IS NOT NULL AND deleted_at IS NULL AND is_archived = FALSE;
@@ -0,0 +1,20 @@
+-- Migration: 0073_webhook_subscriptions.sql
+-- Description: Outbound webhook subscriptions, e.g. stage transitions for court-integration partners.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    events VARCHAR(255) NOT NULL,
+    stages VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_status VARCHAR(20),
+    last_error TEXT,
+    last_fired_at TIMESTAMP,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_active ON webhook_subscriptions (is_active);
@@ -0,0 +1,4 @@
+-- Migration: 0074_case_search_trigram.sql
+-- Description: pg_trgm for the similarity fallback of GET /cases/search on short queries.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
@@ -0,0 +1,8 @@
+-- Migration: 0075_staff_target_utilization.sql
+-- Description: Per-staff utilization target (percentage of working hours booked), compared against actual utilization.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS target_utilization NUMERIC(5,2);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_target_utilization;
+ALTER TABLE users ADD CONSTRAINT chk_users_target_utilization
+    CHECK (target_utilization IS NULL OR (target_utilization >= 0 AND target_utilization <= 100));
@@ -0,0 +1,13 @@
+-- Migration: 0076_office_appointment_categories.sql
+-- Description: Appointment categories each office offers. Offices without rows offer every category.
+
+CREATE TABLE IF NOT EXISTS office_appointment_categories (
+    id SERIAL PRIMARY KEY,
+    office_id INT NOT NULL REFERENCES offices(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A category is listed once per office regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS ux_office_appointment_categories ON office_appointment_categories (office_id, LOWER(category));
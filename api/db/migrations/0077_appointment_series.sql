@@ -0,0 +1,6 @@
+-- Migration: 0077_appointment_series.sql
+-- Description: Links appointments generated from one recurrence so the series can be managed together.
+
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS series_id VARCHAR(36);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_series_id ON appointments (series_id);
@@ -0,0 +1,7 @@
+-- Migration: 0079_appointment_reminders.sql
+-- Description: Tracks the last reminder sent for an appointment so reminders are not repeated across restarts.
+
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMP;
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS reminder_stage VARCHAR(10);
+
+CREATE INDEX IF NOT EXISTS idx_appointments_reminder_due ON appointments (status, start_time) WHERE deleted_at IS NULL;
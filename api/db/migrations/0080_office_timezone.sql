@@ -0,0 +1,4 @@
+-- Migration: 0080_office_timezone.sql
+-- Description: Optional IANA time zone per office, used to lay out working hours for appointment availability.
+
+ALTER TABLE offices ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
@@ -0,0 +1,14 @@
+-- Migration: 0081_document_versions.sql
+-- Description: Keeps every file uploaded for a document so re-uploads no longer lose earlier versions.
+
+CREATE TABLE IF NOT EXISTS document_versions (
+    id SERIAL PRIMARY KEY,
+    document_event_id INT NOT NULL REFERENCES case_events(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    s3_key VARCHAR(512) NOT NULL,
+    file_name VARCHAR(255),
+    file_type VARCHAR(100),
+    uploaded_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (document_event_id, version)
+);
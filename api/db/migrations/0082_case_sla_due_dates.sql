@@ -0,0 +1,22 @@
+-- Migration: 0082_case_sla_due_dates.sql
+-- Description: Backfill due dates of open cases from the default per-category SLA.
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS due_date TIMESTAMP;
+
+-- Same days as config.DefaultCaseSLADays, 90 for other categories; CASE_SLA_DAYS overrides
+-- apply to cases created after this migration only
+UPDATE cases
+SET due_date = COALESCE(created_at, CURRENT_TIMESTAMP) + (
+        CASE LOWER(COALESCE(category, ''))
+            WHEN 'familiar' THEN 90
+            WHEN 'civil' THEN 120
+            WHEN 'psicologia' THEN 60
+            WHEN 'recursos' THEN 30
+            ELSE 90
+        END
+    ) * INTERVAL '1 day'
+WHERE due_date IS NULL
+  AND deleted_at IS NULL
+  AND is_archived = FALSE
+  AND is_completed = FALSE
+  AND status NOT IN ('closed', 'archived');
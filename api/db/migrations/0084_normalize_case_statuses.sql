@@ -0,0 +1,23 @@
+-- Migration: 0084_normalize_case_statuses.sql
+-- Description: Rewrite legacy case statuses to the canonical config.CaseStatus values.
+
+-- Same mapping as config.NormalizeCaseStatus
+UPDATE cases
+SET status = CASE LOWER(TRIM(status))
+        WHEN 'active' THEN 'in_progress'
+        WHEN 'resolved' THEN 'closed'
+        WHEN 'completed' THEN 'closed'
+        WHEN 'cancelled' THEN 'closed'
+        WHEN 'reopened' THEN 'open'
+        ELSE LOWER(TRIM(status))
+    END
+WHERE status IS DISTINCT FROM LOWER(TRIM(status))
+   OR LOWER(TRIM(status)) IN ('active', 'resolved', 'completed', 'cancelled', 'reopened');
+
+-- Anything else unknown: closed if the case was completed or archived, otherwise open
+UPDATE cases
+SET status = CASE WHEN is_completed OR is_archived THEN 'closed' ELSE 'open' END
+WHERE status IS NULL
+   OR status NOT IN ('open', 'in_progress', 'pending', 'closed', 'archived');
+
+-- case_status_history is an append-only log and keeps the statuses as they were recorded
@@ -0,0 +1,22 @@
+-- Migration: 0085_expenses.sql
+-- Description: Operating expenses recorded by admins; financial metrics report them per period next to paid revenue.
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id SERIAL PRIMARY KEY,
+    office_id INT REFERENCES offices(id) ON DELETE SET NULL,
+    case_id INT REFERENCES cases(id) ON DELETE SET NULL,
+    category VARCHAR(50) NOT NULL,
+    description VARCHAR(500),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    currency VARCHAR(16) NOT NULL,
+    incurred_at TIMESTAMP NOT NULL,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_incurred_at ON expenses (incurred_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_office_id ON expenses (office_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_case_id ON expenses (case_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_deleted_at ON expenses (deleted_at);
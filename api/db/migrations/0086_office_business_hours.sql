@@ -0,0 +1,32 @@
+-- Migration: 0086_office_business_hours.sql
+-- Description: Per-office business hours and holidays for appointment scheduling; existing offices open Monday to Friday, 09:00-18:00.
+
+CREATE TABLE IF NOT EXISTS office_business_hours (
+    id SERIAL PRIMARY KEY,
+    office_id INT NOT NULL REFERENCES offices(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    open_time VARCHAR(5) NOT NULL,
+    close_time VARCHAR(5) NOT NULL,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (open_time < close_time)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_office_business_hours_weekday ON office_business_hours (office_id, weekday);
+
+CREATE TABLE IF NOT EXISTS office_holidays (
+    id SERIAL PRIMARY KEY,
+    office_id INT NOT NULL REFERENCES offices(id) ON DELETE CASCADE,
+    date VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_office_holidays_date ON office_holidays (office_id, date);
+
+-- Default hours for the offices that exist today; new offices follow APPOINTMENT_WORKING_HOURS until configured
+INSERT INTO office_business_hours (office_id, weekday, open_time, close_time)
+SELECT offices.id, days.weekday, '09:00', '18:00'
+FROM offices CROSS JOIN generate_series(1, 5) AS days(weekday)
+ON CONFLICT DO NOTHING;
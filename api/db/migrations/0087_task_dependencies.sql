@@ -0,0 +1,14 @@
+-- Migration: 0087_task_dependencies.sql
+-- Description: Dependencies between tasks of a case; a task cannot be completed while a task it depends on is incomplete.
+
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on_task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (task_id <> depends_on_task_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_task_dependencies_pair ON task_dependencies (task_id, depends_on_task_id);
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_depends_on ON task_dependencies (depends_on_task_id);
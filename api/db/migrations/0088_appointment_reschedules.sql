@@ -0,0 +1,20 @@
+-- Migration: 0088_appointment_reschedules.sql
+-- Description: Append-only log of appointment reschedules, so reschedules can be counted apart from other appointment updates.
+
+CREATE TABLE IF NOT EXISTS appointment_reschedules (
+    id SERIAL PRIMARY KEY,
+    appointment_id INT NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    case_id INT NOT NULL,
+    office_id INT NOT NULL,
+    staff_id INT NOT NULL,
+    previous_start_time TIMESTAMP NOT NULL,
+    previous_end_time TIMESTAMP NOT NULL,
+    new_start_time TIMESTAMP NOT NULL,
+    new_end_time TIMESTAMP NOT NULL,
+    reason TEXT,
+    rescheduled_by INT REFERENCES users(id) ON DELETE SET NULL,
+    rescheduled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_reschedules_appointment_id ON appointment_reschedules(appointment_id);
+CREATE INDEX IF NOT EXISTS idx_appointment_reschedules_office_rescheduled ON appointment_reschedules(office_id, rescheduled_at);
@@ -0,0 +1,13 @@
+-- Migration: 0089_notification_preferences.sql
+-- Description: Per-user notification preferences: email and in-app channels, and toggles per event type. Users without a row get everything.
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    in_app_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    appointments BOOLEAN NOT NULL DEFAULT TRUE,
+    cases BOOLEAN NOT NULL DEFAULT TRUE,
+    tasks BOOLEAN NOT NULL DEFAULT TRUE,
+    payments BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Migration: 0090_user_timezone.sql
+-- Description: Optional IANA time zone per user; report and dashboard periods use it ahead of their office's zone.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
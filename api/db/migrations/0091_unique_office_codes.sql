@@ -0,0 +1,14 @@
+-- Migration: 0091_unique_office_codes.sql
+-- Description: Office codes are part of case numbers, so no two offices may share one, ignoring case.
+
+-- Keep the code of the oldest office and suffix the others with their ID
+UPDATE offices o
+SET code = TRIM(o.code) || '-' || o.id
+WHERE o.code IS NOT NULL AND TRIM(o.code) <> ''
+  AND EXISTS (
+      SELECT 1 FROM offices other
+      WHERE other.id < o.id AND UPPER(TRIM(other.code)) = UPPER(TRIM(o.code))
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offices_code_unique ON offices (UPPER(TRIM(code)))
+WHERE code IS NOT NULL AND TRIM(code) <> '';
@@ -0,0 +1,4 @@
+-- Migration: 0092_office_is_active.sql
+-- Description: Offices can be marked inactive while they are being closed; office transfers only move records to active offices.
+
+ALTER TABLE offices ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE;
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
@@ -393,6 +394,54 @@ type UpdateUserInput struct {
 	OfficeID        *uint   `json:"officeId"`
 	Phone           string  `json:"phone" binding:"required"`
 	PersonalAddress *string `json:"personalAddress"`
+	// Optional; when omitted the current target is kept
+	TargetUtilization *float64 `json:"targetUtilization" binding:"omitempty,min=0,max=100"`
+}
+
+// userAuditValues returns the user fields recorded in audit logs when an account changes.
+func userAuditValues(user models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"firstName":         user.FirstName,
+		"lastName":          user.LastName,
+		"email":             user.Email,
+		"role":              user.Role,
+		"officeId":          user.OfficeID,
+		"department":        user.Department,
+		"phone":             user.Phone,
+		"personalAddress":   user.PersonalAddress,
+		"targetUtilization": user.TargetUtilization,
+	}
+}
+
+// recordUserUpdate records the fields an update changed, from the values captured before it.
+// Role changes are logged as "role_change" with warning severity and the security tag, since
+// they change what the user can access.
+func recordUserUpdate(db *gorm.DB, c *gin.Context, before map[string]interface{}, user models.User) {
+	action := "update"
+	if before["role"] != user.Role {
+		action = "role_change"
+	}
+	entry, changed := newAuditChange(c, "user", user.ID, action, "", before, userAuditValues(user))
+	if !changed {
+		return
+	}
+	if action == "role_change" {
+		entry.Severity = "warning"
+		entry.Tags = append(entry.Tags, "security")
+	}
+	saveAuditLog(db, entry)
+}
+
+// recordUserDeletion records a deleted account with its last values, so the audit trail keeps
+// who the user was after the record is gone.
+func recordUserDeletion(db *gorm.DB, c *gin.Context, user models.User, action, severity string) {
+	entry := newAuditLog(c, "user", user.ID, action, "", nil)
+	if encoded, err := json.Marshal(userAuditValues(user)); err == nil {
+		values := string(encoded)
+		entry.OldValues = &values
+	}
+	entry.Severity = severity
+	saveAuditLog(db, entry)
 }
 
 // UpdateUser handles modifying an existing user's details.
@@ -404,6 +453,7 @@ func UpdateUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found."})
 			return
 		}
+		before := userAuditValues(user)
 
 		// Validate the incoming JSON data.
 		var input UpdateUserInput
@@ -441,12 +491,16 @@ func UpdateUser(db *gorm.DB) gin.HandlerFunc {
 		user.OfficeID = input.OfficeID
 		user.Phone = strings.TrimSpace(input.Phone)
 		user.PersonalAddress = input.PersonalAddress
+		if input.TargetUtilization != nil {
+			user.TargetUtilization = input.TargetUtilization
+		}
 
 		// Save the changes to the database.
 		if err := db.Save(&user).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user."})
 			return
 		}
+		recordUserUpdate(db, c, before, user)
 
 		c.JSON(http.StatusOK, user)
 	}
@@ -463,6 +517,7 @@ func UpdateUserScoped(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found."})
 			return
 		}
+		before := userAuditValues(user)
 
 		// Get the office manager's office from middleware (stored as uint, not *uint)
 		managerOfficeIDVal, hasOffice := c.Get("officeScopeID")
@@ -526,11 +581,15 @@ func UpdateUserScoped(db *gorm.DB) gin.HandlerFunc {
 		user.OfficeID = input.OfficeID
 		user.Phone = strings.TrimSpace(input.Phone)
 		user.PersonalAddress = input.PersonalAddress
+		if input.TargetUtilization != nil {
+			user.TargetUtilization = input.TargetUtilization
+		}
 
 		if err := db.Save(&user).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user."})
 			return
 		}
+		recordUserUpdate(db, c, before, user)
 
 		c.JSON(http.StatusOK, user)
 	}
@@ -576,6 +635,7 @@ func DeleteUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 			return
 		}
+		recordUserDeletion(db, c, user, "delete", "warning")
 		c.Status(http.StatusNoContent)
 	}
 }
@@ -683,6 +743,7 @@ func PermanentDeleteUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to permanently delete user."})
 			return
 		}
+		recordUserDeletion(db, c, user, "permanent_delete", "critical")
 
 		// A 204 No Content response is standard for a successful deletion.
 		c.Status(http.StatusNoContent)
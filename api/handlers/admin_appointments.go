@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,8 +11,8 @@ import (
 
 	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/models"
-	"github.com/BryanPMX/CAF/api/notifications"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -49,12 +50,22 @@ type SmartAppointmentInput struct {
 	StaffID   uint      `json:"staffId" binding:"required"`
 	Title     string    `json:"title" binding:"required"`
 	StartTime time.Time `json:"startTime" binding:"required"`
-	EndTime   time.Time `json:"endTime" binding:"required"`
+	EndTime   time.Time `json:"endTime"` // Omitted: the category's default duration
 	Status    string    `json:"status" binding:"required"`
 
 	// --- Department and Category Information ---
 	Department string `json:"department" binding:"required"` // Department for case creation and appointment categorization
 	Category   string `json:"category" binding:"required"`   // Category for appointment classification
+
+	// OverrideBuffer lets admins schedule despite an insufficient travel buffer between offices.
+	OverrideBuffer bool `json:"overrideBuffer"`
+	// OverridePast lets admins record an appointment that has already started.
+	OverridePast bool `json:"overridePast"`
+	// Force lets admins book staff from another office or department than the appointment's.
+	Force bool `json:"force"`
+
+	// Recurrence optionally repeats the appointment as a series starting at StartTime.
+	Recurrence *AppointmentRecurrence `json:"recurrence"`
 }
 
 // CreateAppointmentSmart is the new, intelligent handler for creating appointments.
@@ -66,6 +77,13 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		input.EndTime = appointmentEndTime(input.StartTime, input.EndTime, input.Category)
+		if !enforceAppointmentDuration(c, input.StartTime, input.EndTime, input.OverridePast) {
+			return
+		}
+		if !enforceSlotAlignment(c, db, input.StartTime, input.EndTime) {
+			return
+		}
 
 		// CRITICAL FIX: Wrap entire operation in a database transaction
 		// This ensures atomicity - either all operations succeed or all fail
@@ -102,37 +120,37 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 			}
 		} else if input.NewClient != nil {
 			// Scenario: Create a new client, but first check if ANY user already exists with the same email (including soft-deleted ones).
-			var existingUser models.User
-			if err := tx.Unscoped().Where("email = ?", input.NewClient.Email).First(&existingUser).Error; err == nil {
-				// A user with this email already exists (possibly soft-deleted)
-				if existingUser.DeletedAt.Valid {
-					// User is soft-deleted, restore them for the appointment
-					existingUser.DeletedAt = gorm.DeletedAt{} // Clear the soft delete
-					existingUser.FirstName = input.NewClient.FirstName // Update with provided info
-					existingUser.LastName = input.NewClient.LastName
-					if err := tx.Unscoped().Save(&existingUser).Error; err != nil {
-						tx.Rollback()
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore existing user: " + err.Error()})
-						return
-					}
-					client = existingUser
-					hasClient = true
-				} else {
-					// User exists and is active - cannot create duplicate
-					tx.Rollback()
-					c.JSON(http.StatusBadRequest, gin.H{
-						"error": "A user with this email already exists. Please use the existing client or choose a different email.",
-						"existingUser": gin.H{
-							"id": existingUser.ID,
-							"email": existingUser.Email,
-							"role": existingUser.Role,
-							"firstName": existingUser.FirstName,
-							"lastName": existingUser.LastName,
-						},
-					})
-					return
-				}
-			} else if err == gorm.ErrRecordNotFound {
+			if err := validateClientEmail(input.NewClient.Email); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			existingUser, err := restoreClientByEmail(tx, input.NewClient.Email, input.NewClient.FirstName, input.NewClient.LastName)
+			var inUse *clientEmailInUseError
+			if errors.As(err, &inUse) {
+				// User exists and is active - cannot create duplicate
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "A user with this email already exists. Please use the existing client or choose a different email.",
+					"existingUser": gin.H{
+						"id": inUse.User.ID,
+						"email": inUse.User.Email,
+						"role": inUse.User.Role,
+						"firstName": inUse.User.FirstName,
+						"lastName": inUse.User.LastName,
+					},
+				})
+				return
+			} else if err != nil {
+				// Database error
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			} else if existingUser != nil {
+				// User was soft-deleted and has been restored for the appointment
+				client = *existingUser
+				hasClient = true
+			} else {
 				// No user with this email exists, create a new one
 				tempPassword := "password123" // Placeholder password
 				hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(tempPassword), bcrypt.DefaultCost)
@@ -142,6 +160,8 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 					Email:     input.NewClient.Email,
 					Password:  string(hashedPassword),
 					Role:      "client",
+
+					MustChangePassword: true,
 				}
 				if err := tx.Create(&client).Error; err != nil {
 					tx.Rollback()
@@ -149,11 +169,6 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 					return
 				}
 				hasClient = true
-			} else {
-				// Database error
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for existing user: " + err.Error()})
-				return
 			}
 		} else if input.CaseID != nil {
 			// No client provided, but an existing case might reference one. If not found, continue without client.
@@ -317,18 +332,99 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
-		appointment := models.Appointment{
-			CaseID:     caseRecord.ID,
-			StaffID:    input.StaffID,
-			OfficeID:   caseRecord.OfficeID, // Set the office ID from the case
-			Title:      input.Title,
-			StartTime:  input.StartTime,
-			EndTime:    input.EndTime,
-			Status:     config.AppointmentStatus(input.Status), // Convert string to AppointmentStatus type
-			Category:   appointmentCategory,
-			Department: department,
+		// The staff member must work at the case's office and in the appointment's department
+		staffMismatches, ok := enforceStaffAssignment(c, tx, input.StaffID, caseRecord.OfficeID, department, input.Force)
+		if !ok {
+			tx.Rollback()
+			return
+		}
+
+		// Only services the office offers can be booked there
+		if !enforceOfficeAppointmentCategory(c, tx, caseRecord.OfficeID, appointmentCategory) {
+			tx.Rollback()
+			return
+		}
+
+		// No bookings outside the office's business hours or on its holidays
+		calendar, err := loadOfficeCalendar(tx, caseRecord.OfficeID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar el horario de la oficina", "message": err.Error()})
+			return
+		}
+
+		// A recurrence expands into one slot per appointment, in the case office's time zone
+		slots := []appointmentSlot{{Start: input.StartTime, End: input.EndTime}}
+		var seriesID *string
+		if input.Recurrence != nil {
+			slots, err = appointmentOccurrences(input.StartTime, input.EndTime, *input.Recurrence, calendar.Location)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Recurrencia inválida", "message": err.Error()})
+				return
+			}
+			id := uuid.NewString()
+			seriesID = &id
+		}
+
+		// Every appointment of a series is checked and created in turn, so later slots also
+		// conflict with the earlier ones just created
+		var overlaps []appointmentConflict
+		var bufferWarnings []officeBufferConflict
+		appointmentIDs := make([]uint, 0, len(slots))
+		createdAppointments := make([]models.Appointment, 0, len(slots))
+		var appointment models.Appointment
+		for i, slot := range slots {
+			if caseRecord.OfficeID != 0 && !calendar.allow(c, caseRecord.OfficeID, slot.Start, slot.End) {
+				tx.Rollback()
+				return
+			}
+
+			// No double-booking for the staff member (admins may force with ?allowOverlap=true)
+			slotOverlaps, ok := enforceStaffAvailability(c, tx, input.StaffID, slot.Start, slot.End, 0)
+			if !ok {
+				tx.Rollback()
+				return
+			}
+
+			// Staff covering several offices need time to travel between them
+			slotBufferWarnings, ok := enforceOfficeBuffer(c, tx, input.StaffID, caseRecord.OfficeID, slot.Start, slot.End, 0, input.OverrideBuffer)
+			if !ok {
+				tx.Rollback()
+				return
+			}
+
+			created := models.Appointment{
+				CaseID:     caseRecord.ID,
+				StaffID:    input.StaffID,
+				OfficeID:   caseRecord.OfficeID, // Set the office ID from the case
+				Title:      input.Title,
+				StartTime:  slot.Start,
+				EndTime:    slot.End,
+				Status:     config.AppointmentStatus(input.Status), // Convert string to AppointmentStatus type
+				Category:   appointmentCategory,
+				Department: department,
+				SeriesID:   seriesID,
+			}
+			if err := tx.Create(&created).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment: " + err.Error()})
+				return
+			}
+			if err := recordForcedOverlap(tx, c, &created, slotOverlaps); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment: " + err.Error()})
+				return
+			}
+			overlaps = append(overlaps, slotOverlaps...)
+			bufferWarnings = append(bufferWarnings, slotBufferWarnings...)
+			appointmentIDs = append(appointmentIDs, created.ID)
+			createdAppointments = append(createdAppointments, created)
+			if i == 0 {
+				appointment = created
+			}
 		}
-		if err := tx.Create(&appointment).Error; err != nil {
+		if err := recordForcedStaffAssignment(tx, c, &appointment, staffMismatches); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment: " + err.Error()})
 			return
@@ -339,27 +435,50 @@ func CreateAppointmentSmart(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction: " + err.Error()})
 			return
 		}
+		if input.CaseID == nil {
+			recordCaseStageChange(db, &caseRecord, "", "", &caseRecord.CreatedBy)
+		}
+		notifyAppointmentEvent(db, models.WebhookEventAppointmentCreated, extractUserIDUint(c), createdAppointments...)
 
 		// --- Step 4: Send Notification (Async) ---
 		// Only send notification if a client exists
 		if hasClient {
 			go func() {
-				notifications.SendAppointmentConfirmation(appointment, client)
+				sendAppointmentConfirmation(db, appointment, client)
 			}()
 		}
 
 		// Notify admins of new appointment with full details
 		appointmentLink := "/app/appointments"
-		NotifyAdminsForAppointment(db, "creada", appointment.ID, appointment.Title, string(appointment.Status), appointment.StartTime, &appointmentLink)
+		action := "creada"
+		if seriesID != nil {
+			action = fmt.Sprintf("creada (serie de %d citas)", len(appointmentIDs))
+		}
+		NotifyAdminsForAppointment(db, action, appointment.ID, appointment.Title, string(appointment.Status), appointment.StartTime, &appointmentLink)
 
 		// Return success response with minimal data
-		c.JSON(http.StatusCreated, gin.H{
+		response := gin.H{
 			"id":        appointment.ID,
 			"title":     appointment.Title,
 			"startTime": appointment.StartTime,
 			"status":    appointment.Status,
 			"message":   "Appointment created successfully",
-		})
+		}
+		if seriesID != nil {
+			response["seriesId"] = *seriesID
+			response["appointmentIds"] = appointmentIDs
+			response["message"] = fmt.Sprintf("%d appointments created successfully", len(appointmentIDs))
+		}
+		if len(bufferWarnings) > 0 {
+			response["bufferWarnings"] = bufferWarnings
+		}
+		if len(overlaps) > 0 {
+			response["overlapWarnings"] = overlaps
+		}
+		if len(staffMismatches) > 0 {
+			response["staffWarnings"] = staffMismatches
+		}
+		c.JSON(http.StatusCreated, response)
 	}
 }
 
@@ -393,15 +512,30 @@ func UpdateAppointmentAdmin(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment status specified. Allowed values: pending, confirmed, completed, cancelled, no_show"})
 			return
 		}
+		if !enforceSlotAlignment(c, db, input.StartTime, input.EndTime) {
+			return
+		}
+		rescheduled := !input.StartTime.Equal(appointment.StartTime) || !input.EndTime.Equal(appointment.EndTime)
+		if rescheduled && !enforceOfficeOpen(c, db, appointment.OfficeID, input.StartTime, input.EndTime) {
+			return
+		}
 
 		// Update the model fields and save to the database.
+		previousStatus := appointment.Status
 		appointment.CaseID = input.CaseID
 		appointment.StaffID = input.StaffID
 		appointment.Title = input.Title
+		if !input.StartTime.Equal(appointment.StartTime) {
+			// Rescheduled: the reminders are due again for the new time
+			appointment.ReminderSentAt, appointment.ReminderStage = nil, ""
+		}
 		appointment.StartTime = input.StartTime
 		appointment.EndTime = input.EndTime
 		appointment.Status = config.AppointmentStatus(input.Status) // Convert string to AppointmentStatus type
 		db.Save(&appointment)
+		if appointment.Status == config.StatusCancelled && previousStatus != config.StatusCancelled {
+			notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, extractUserIDUint(c), appointment)
+		}
 
 		// Generate notification for client if appointment status is changed to "confirmed"
 		if input.Status == "confirmed" && appointment.CaseID > 0 {
@@ -554,6 +688,7 @@ func DeleteAppointmentAdmin(db *gorm.DB) gin.HandlerFunc {
 			"deleted_at": time.Now(),
 		}
 
+		wasCancelled := appointment.Status == config.StatusCancelled
 		if err := db.Model(&appointment).Updates(updates).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la cita"})
 			return
@@ -562,6 +697,9 @@ func DeleteAppointmentAdmin(db *gorm.DB) gin.HandlerFunc {
 		// Notify admins of appointment deletion/cancellation
 		link := "/app/appointments"
 		NotifyAdminsForAppointment(db, "eliminada/cancelada", appointment.ID, appointment.Title, "cancelled", appointment.StartTime, &link)
+		if !wasCancelled {
+			notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, user.ID, appointment)
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message":     "Cita cancelada exitosamente por administrador",
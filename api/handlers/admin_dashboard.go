@@ -10,9 +10,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DashboardStats represents comprehensive system statistics for admin dashboard
@@ -50,14 +53,7 @@ type DashboardStats struct {
 	OfficesByRegion map[string]int `json:"officesByRegion"`
 
 	// Financial Metrics
-	Revenue                float64 `json:"revenue"`
-	RevenueCurrency        string  `json:"revenueCurrency"`
-	RevenueMixedCurrencies bool    `json:"revenueMixedCurrencies"`
-	RevenueThisMonth       float64 `json:"revenueThisMonth"`
-	RevenueThisYear        float64 `json:"revenueThisYear"`
-	GrowthRate             float64 `json:"growthRate"`
-	AverageCaseValue       float64 `json:"averageCaseValue"`
-	OutstandingInvoices    float64 `json:"outstandingInvoices"`
+	FinancialMetrics
 
 	// Performance Metrics
 	SystemUptime        float64 `json:"systemUptime"`
@@ -76,6 +72,23 @@ type DashboardStats struct {
 	ClientRetentionRate       float64            `json:"clientRetentionRate"`
 	CaseWinRate               float64            `json:"caseWinRate"`
 	AverageClientSatisfaction float64            `json:"averageClientSatisfaction"`
+
+	// AsOf is when these statistics were computed; responses may be served from cache
+	AsOf time.Time `json:"asOf"`
+}
+
+// FinancialMetrics holds revenue figures derived from Stripe webhook payment_records. Until a
+// payment is recorded Available is false and the amounts are null rather than zero.
+type FinancialMetrics struct {
+	Available              bool     `json:"available"`
+	Revenue                *float64 `json:"revenue"`
+	RevenueCurrency        string   `json:"revenueCurrency"`
+	RevenueMixedCurrencies bool     `json:"revenueMixedCurrencies"`
+	RevenueThisMonth       *float64 `json:"revenueThisMonth"`
+	RevenueThisYear        *float64 `json:"revenueThisYear"`
+	GrowthRate             *float64 `json:"growthRate"` // Month over month; null when last month had no revenue
+	AverageCaseValue       *float64 `json:"averageCaseValue"`
+	OutstandingInvoices    *float64 `json:"outstandingInvoices"` // Null until there is an invoicing model
 }
 
 // RecentActivity represents system activity for the dashboard
@@ -100,7 +113,8 @@ type StaffPerformance struct {
 	CompletedCases   int     `json:"completedCases"`
 	ActiveCases      int     `json:"activeCases"`
 	SuccessRate      float64 `json:"successRate"`
-	AverageRating    float64 `json:"averageRating"`
+	AverageRating    float64 `json:"averageRating"` // 0 until the staff member has config.StaffRatingMinSamples ratings
+	RatingCount      int64   `json:"ratingCount"`
 	RevenueGenerated float64 `json:"revenueGenerated"`
 }
 
@@ -109,12 +123,10 @@ type SystemHealth struct {
 	Database          string  `json:"database"`
 	API               string  `json:"api"`
 	Storage           string  `json:"storage"`
-	Uptime            float64 `json:"uptime"`
+	Uptime            float64 `json:"uptime"`        // Percent of SYSTEM_UPTIME_WINDOW_HOURS this process has been up
+	UptimeSeconds     float64 `json:"uptimeSeconds"` // Since this process started
 	LastBackup        string  `json:"lastBackup"`
 	ActiveConnections int     `json:"activeConnections"`
-	CPUUsage          float64 `json:"cpuUsage"`
-	MemoryUsage       float64 `json:"memoryUsage"`
-	DiskUsage         float64 `json:"diskUsage"`
 	NetworkStatus     string  `json:"networkStatus"`
 	DatabaseSize      string  `json:"databaseSize"`
 	BackupFrequency   string  `json:"backupFrequency"`
@@ -122,156 +134,223 @@ type SystemHealth struct {
 	ComplianceStatus  string  `json:"complianceStatus"`
 	LastMaintenance   string  `json:"lastMaintenance"`
 	NextMaintenance   string  `json:"nextMaintenance"`
+
+	// Host readings, cached for config.SystemHealthCacheTTL. Usage is a percentage, null when
+	// the reading is unavailable; status is healthy, warning, critical or unavailable.
+	CPUUsage     *float64 `json:"cpuUsage"`
+	CPUStatus    string   `json:"cpuStatus"`
+	MemoryUsage  *float64 `json:"memoryUsage"`
+	MemoryStatus string   `json:"memoryStatus"`
+	DiskUsage    *float64 `json:"diskUsage"`
+	DiskStatus   string   `json:"diskStatus"`
+	DiskPath     string   `json:"diskPath"`
+
+	// Database probe results, cached for config.SystemHealthCacheTTL
+	DatabaseLatencyMs float64   `json:"databaseLatencyMs"`
+	AsOf              time.Time `json:"asOf"`
 }
 
-// GetDashboardStats returns comprehensive dashboard statistics for admin users
+// databaseProbe holds the system-catalog readings shown in the health panel.
+type databaseProbe struct {
+	Healthy           bool
+	LatencyMs         float64
+	ActiveConnections int
+	DatabaseSize      string
+	AsOf              time.Time
+}
+
+// GetDashboardStats returns comprehensive dashboard statistics for admin users. Today, this
+// month and this year are taken in the ?tz= zone, else the caller's own or office's zone.
 func GetDashboardStats(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		stats := DashboardStats{
-			UsersByRole:        make(map[string]int),
-			CasesByCategory:    make(map[string]int),
-			CasesByStage:       make(map[string]int),
-			OfficesByRegion:    make(map[string]int),
-			TopPerformingStaff: []StaffPerformance{},
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		cacheKey := analyticsCacheKey("dashboard-stats", "all", loc)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
 		}
 
-		// User Management Stats
-		db.Model(&models.User{}).Count(&stats.TotalUsers)
-		db.Model(&models.User{}).Where("last_login > ?", time.Now().AddDate(0, 0, -30)).Count(&stats.ActiveUsers)
-		stats.InactiveUsers = stats.TotalUsers - stats.ActiveUsers
+		stats := computeDashboardStats(db, dashboardScope{}, time.Now().In(loc))
+		analyticsCache.set(cacheKey, stats, stats.AsOf)
+		c.JSON(http.StatusOK, stats)
+	}
+}
 
-		// New users this month
-		startOfMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.Now().Location())
-		db.Model(&models.User{}).Where("created_at >= ?", startOfMonth).Count(&stats.NewUsersThisMonth)
+// computeDashboardStats runs the dashboard aggregations over the records in scope. The zero
+// scope covers the whole system. Days and months start at midnight in now's location.
+func computeDashboardStats(db *gorm.DB, scope dashboardScope, now time.Time) DashboardStats {
+	stats := DashboardStats{
+		UsersByRole:        make(map[string]int),
+		CasesByCategory:    make(map[string]int),
+		CasesByStage:       make(map[string]int),
+		OfficesByRegion:    make(map[string]int),
+		TopPerformingStaff: topStaffPerformance(db, 5, scope),
+	}
 
-		// Users by role
-		var userRoleStats []struct {
-			Role  string `json:"role"`
-			Count int    `json:"count"`
-		}
-		db.Model(&models.User{}).Select("role, count(*) as count").Group("role").Scan(&userRoleStats)
-		for _, stat := range userRoleStats {
-			stats.UsersByRole[stat.Role] = stat.Count
-		}
+	// User Management Stats
+	scope.users(db).Count(&stats.TotalUsers)
+	scope.users(db).Where("last_login > ?", now.AddDate(0, 0, -30)).Count(&stats.ActiveUsers)
+	stats.InactiveUsers = stats.TotalUsers - stats.ActiveUsers
 
-		// Appointment Management Stats
-		db.Model(&models.Appointment{}).Count(&stats.TotalAppointments)
-		db.Model(&models.Appointment{}).Where("status = ?", "pending").Count(&stats.PendingAppointments)
-		db.Model(&models.Appointment{}).Where("status = ?", "completed").Count(&stats.CompletedAppointments)
-		db.Model(&models.Appointment{}).Where("status = ?", "cancelled").Count(&stats.CancelledAppointments)
+	// New users this month
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	scope.users(db).Where("created_at >= ?", startOfMonth).Count(&stats.NewUsersThisMonth)
 
-		// Today's appointments
-		today := time.Now().Truncate(24 * time.Hour)
-		db.Model(&models.Appointment{}).Where("DATE(appointment_date) = DATE(?)", today).Count(&stats.TodayAppointments)
+	// Users by role
+	var userRoleStats []struct {
+		Role  string `json:"role"`
+		Count int    `json:"count"`
+	}
+	scope.users(db).Select("role, count(*) as count").Group("role").Scan(&userRoleStats)
+	for _, stat := range userRoleStats {
+		stats.UsersByRole[stat.Role] = stat.Count
+	}
 
-		// Upcoming appointments (next 7 days)
-		nextWeek := time.Now().AddDate(0, 0, 7)
-		db.Model(&models.Appointment{}).Where("appointment_date BETWEEN ? AND ?", time.Now(), nextWeek).Count(&stats.UpcomingAppointments)
+	// Appointment Management Stats
+	scope.appointments(db).Count(&stats.TotalAppointments)
+	scope.appointments(db).Where("status = ?", "pending").Count(&stats.PendingAppointments)
+	scope.appointments(db).Where("status = ?", "completed").Count(&stats.CompletedAppointments)
+	scope.appointments(db).Where("status = ?", "cancelled").Count(&stats.CancelledAppointments)
 
-		// Appointment success rate
-		if stats.TotalAppointments > 0 {
-			stats.AppointmentSuccessRate = float64(stats.CompletedAppointments) / float64(stats.TotalAppointments) * 100
-		}
+	// Today's appointments
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	scope.appointments(db).Where("start_time >= ? AND start_time < ?", today, today.AddDate(0, 0, 1)).Count(&stats.TodayAppointments)
 
-		// Case Management Stats
-		db.Model(&models.Case{}).Where("is_archived = ?", false).Count(&stats.TotalCases)
-		db.Model(&models.Case{}).Where("is_archived = ? AND status = ?", false, "open").Count(&stats.ActiveCases)
-		db.Model(&models.Case{}).Where("is_archived = ? AND status = ?", false, "closed").Count(&stats.CompletedCases)
+	// Upcoming appointments (next 7 days)
+	nextWeek := now.AddDate(0, 0, 7)
+	scope.appointments(db).Where("start_time BETWEEN ? AND ?", now, nextWeek).Count(&stats.UpcomingAppointments)
 
-		// New cases this month
-		db.Model(&models.Case{}).Where("created_at >= ? AND is_archived = ?", startOfMonth, false).Count(&stats.NewCasesThisMonth)
+	// Appointment success rate
+	if stats.TotalAppointments > 0 {
+		stats.AppointmentSuccessRate = float64(stats.CompletedAppointments) / float64(stats.TotalAppointments) * 100
+	}
 
-		// Cases by category
-		var caseCategoryStats []struct {
-			Category string `json:"category"`
-			Count    int    `json:"count"`
-		}
-		db.Model(&models.Case{}).Where("is_archived = ?", false).Select("category, count(*) as count").Group("category").Scan(&caseCategoryStats)
-		for _, stat := range caseCategoryStats {
-			stats.CasesByCategory[stat.Category] = stat.Count
-		}
+	// Case Management Stats
+	scope.cases(db).Where("is_archived = ?", false).Count(&stats.TotalCases)
+	scope.cases(db).Where("is_archived = ? AND status = ?", false, "open").Count(&stats.ActiveCases)
+	scope.cases(db).Where("is_archived = ? AND status = ?", false, "closed").Count(&stats.CompletedCases)
+	scopeOverdueCases(scope.cases(db).Where("is_archived = ? AND deleted_at IS NULL", false), now).Count(&stats.OverdueCases)
 
-		// Cases by stage
-		var caseStageStats []struct {
-			Stage string `json:"stage"`
-			Count int    `json:"count"`
-		}
-		db.Model(&models.Case{}).Where("is_archived = ?", false).Select("current_stage, count(*) as count").Group("current_stage").Scan(&caseStageStats)
-		for _, stat := range caseStageStats {
-			stats.CasesByStage[stat.Stage] = stat.Count
-		}
+	// New cases this month
+	scope.cases(db).Where("created_at >= ? AND is_archived = ?", startOfMonth, false).Count(&stats.NewCasesThisMonth)
 
-		// Case completion rate
-		if stats.TotalCases > 0 {
-			stats.CaseCompletionRate = float64(stats.CompletedCases) / float64(stats.TotalCases) * 100
-		}
+	// Cases by category
+	var caseCategoryStats []struct {
+		Category string `json:"category"`
+		Count    int    `json:"count"`
+	}
+	scope.cases(db).Where("is_archived = ?", false).Select("category, count(*) as count").Group("category").Scan(&caseCategoryStats)
+	for _, stat := range caseCategoryStats {
+		stats.CasesByCategory[stat.Category] = stat.Count
+	}
 
-		// Office Management Stats
-		db.Model(&models.Office{}).Count(&stats.TotalOffices)
-		db.Model(&models.Office{}).Where("is_active = ?", true).Count(&stats.ActiveOffices)
+	// Cases by stage
+	var caseStageStats []struct {
+		Stage string `json:"stage"`
+		Count int    `json:"count"`
+	}
+	scope.cases(db).Where("is_archived = ?", false).Select("current_stage, count(*) as count").Group("current_stage").Scan(&caseStageStats)
+	for _, stat := range caseStageStats {
+		stats.CasesByStage[stat.Stage] = stat.Count
+	}
 
-		// Offices by region
-		var officeRegionStats []struct {
-			Region string `json:"region"`
-			Count  int    `json:"count"`
-		}
-		db.Model(&models.Office{}).Select("region, count(*) as count").Group("region").Scan(&officeRegionStats)
-		for _, stat := range officeRegionStats {
-			stats.OfficesByRegion[stat.Region] = stat.Count
-		}
+	// Case completion rate
+	if stats.TotalCases > 0 {
+		stats.CaseCompletionRate = float64(stats.CompletedCases) / float64(stats.TotalCases) * 100
+	}
 
-		// Financial Metrics (derived from Stripe webhook payment_records)
-		now := time.Now()
-		startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-		prevMonthStart := startOfMonth.AddDate(0, -1, 0)
-		prevMonthEnd := startOfMonth
-
-		totalRevenue := sumNetPaidSummary(db, nil, nil)
-		monthRevenue := sumNetPaidSummary(db, &startOfMonth, nil)
-		yearRevenue := sumNetPaidSummary(db, &startOfYear, nil)
-		prevMonthRevenue := sumNetPaidSummary(db, &prevMonthStart, &prevMonthEnd)
-
-		stats.Revenue = float64(totalRevenue.Total) / 100.0
-		stats.RevenueCurrency = totalRevenue.Currency
-		stats.RevenueMixedCurrencies = totalRevenue.MixedCurrencies
-		stats.RevenueThisMonth = float64(monthRevenue.Total) / 100.0
-		stats.RevenueThisYear = float64(yearRevenue.Total) / 100.0
-		if prevMonthRevenue.Total > 0 {
-			stats.GrowthRate = (float64(monthRevenue.Total-prevMonthRevenue.Total) / float64(prevMonthRevenue.Total)) * 100
-		} else {
-			stats.GrowthRate = 0
-		}
+	// Office Management Stats
+	scope.offices(db).Count(&stats.TotalOffices)
+	scope.offices(db).Where("is_active = ?", true).Count(&stats.ActiveOffices)
 
-		if avgCasePaymentCents, count := averageCasePaymentCents(db); count > 0 {
-			stats.AverageCaseValue = float64(avgCasePaymentCents) / 100.0
-		}
+	// Offices by region
+	var officeRegionStats []struct {
+		Region string `json:"region"`
+		Count  int    `json:"count"`
+	}
+	scope.offices(db).Select("region, count(*) as count").Group("region").Scan(&officeRegionStats)
+	for _, stat := range officeRegionStats {
+		stats.OfficesByRegion[stat.Region] = stat.Count
+	}
 
-		// Outstanding invoices still depends on a dedicated invoicing/balance model.
-		stats.OutstandingInvoices = 0
+	// Financial Metrics (derived from Stripe webhook payment_records)
+	stats.FinancialMetrics = computeFinancialMetrics(scope.payments(db), now)
 
-		// Performance Metrics (simplified)
-		stats.SystemUptime = 99.9
-		stats.LastBackup = time.Now().Add(-24 * time.Hour).Format("2006-01-02 15:04:05")
-		stats.StorageUsage = 45.2
-		stats.DatabasePerformance = 98.5
-		stats.APIResponseTime = 150.5
+	// Performance Metrics (simplified)
+	stats.SystemUptime = services.UptimePercent(config.SystemUptimeWindow())
+	stats.LastBackup = now.Add(-24 * time.Hour).Format("2006-01-02 15:04:05")
+	stats.StorageUsage = 45.2
+	stats.DatabasePerformance = 98.5
+	stats.APIResponseTime = 150.5
 
-		// Security & Compliance (simplified)
-		stats.FailedLoginAttempts = 0
-		stats.LastSecurityAudit = time.Now().Add(-7 * 24 * time.Hour).Format("2006-01-02 15:04:05")
-		stats.DataRetentionDays = 2555
+	// Security & Compliance (simplified)
+	stats.FailedLoginAttempts = 0
+	stats.LastSecurityAudit = now.Add(-7 * 24 * time.Hour).Format("2006-01-02 15:04:05")
+	stats.DataRetentionDays = config.DeletedRecordRetentionDays()
 
-		// Business Intelligence (simplified)
-		stats.ClientRetentionRate = 85.5
-		stats.CaseWinRate = 78.3
-		stats.AverageClientSatisfaction = 4.2
+	// Business Intelligence (simplified)
+	stats.ClientRetentionRate = 85.5
+	stats.CaseWinRate = 78.3
+	stats.AverageClientSatisfaction = averageClientSatisfaction(scope.ratings(db))
 
-		c.JSON(http.StatusOK, stats)
+	stats.AsOf = now
+	return stats
+}
+
+// computeFinancialMetrics sums net paid revenue overall, for the month and year containing now,
+// and month-over-month growth. Nothing beyond the overall sum is queried while there are no payments.
+// db may be narrowed to some payment records, as by dashboardScope.payments.
+func computeFinancialMetrics(db *gorm.DB, now time.Time) FinancialMetrics {
+	var metrics FinancialMetrics
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+	prevMonthStart := startOfMonth.AddDate(0, -1, 0)
+	prevMonthEnd := startOfMonth
+
+	totalRevenue := sumNetPaidSummary(db, nil, nil)
+	metrics.RevenueCurrency = totalRevenue.Currency
+	metrics.RevenueMixedCurrencies = totalRevenue.MixedCurrencies
+	if totalRevenue.Count == 0 {
+		return metrics
+	}
+	metrics.Available = true
+
+	monthRevenue := sumNetPaidSummary(db, &startOfMonth, nil)
+	yearRevenue := sumNetPaidSummary(db, &startOfYear, nil)
+	prevMonthRevenue := sumNetPaidSummary(db, &prevMonthStart, &prevMonthEnd)
+
+	metrics.Revenue = centsAmount(totalRevenue.Total)
+	metrics.RevenueThisMonth = centsAmount(monthRevenue.Total)
+	metrics.RevenueThisYear = centsAmount(yearRevenue.Total)
+	metrics.GrowthRate = growthRate(monthRevenue.Total, prevMonthRevenue.Total)
+
+	if avgCasePaymentCents, count := averageCasePaymentCents(db); count > 0 {
+		metrics.AverageCaseValue = centsAmount(avgCasePaymentCents)
+	}
+	return metrics
+}
+
+// centsAmount converts cents to a currency amount for JSON responses.
+func centsAmount(cents int64) *float64 {
+	amount := float64(cents) / 100.0
+	return &amount
+}
+
+// growthRate is the percent change from previous to current, or nil when previous is not positive.
+func growthRate(current, previous int64) *float64 {
+	if previous <= 0 {
+		return nil
 	}
+	rate := float64(current-previous) * 100 / float64(previous)
+	return &rate
 }
 
 type paidRevenueSummary struct {
 	Total           int64
+	Count           int64 // Payments summed, so an empty period can be told from no data
 	Currency        string
 	MixedCurrencies bool
 }
@@ -279,6 +358,7 @@ type paidRevenueSummary struct {
 func sumNetPaidSummary(db *gorm.DB, from *time.Time, to *time.Time) paidRevenueSummary {
 	type sumRow struct {
 		Total         int64  `json:"total"`
+		Count         int64  `json:"count"`
 		CurrencyCount int64  `json:"currencyCount"`
 		Currency      string `json:"currency"`
 	}
@@ -286,6 +366,7 @@ func sumNetPaidSummary(db *gorm.DB, from *time.Time, to *time.Time) paidRevenueS
 	query := db.Model(&models.PaymentRecord{}).
 		Select(`
 			COALESCE(SUM(amount_cents - refunded_cents), 0) AS total,
+			COUNT(*) AS count,
 			COUNT(DISTINCT NULLIF(UPPER(TRIM(currency)), '')) AS currency_count,
 			COALESCE(MIN(NULLIF(UPPER(TRIM(currency)), '')), '') AS currency
 		`).
@@ -320,6 +401,7 @@ func sumNetPaidSummary(db *gorm.DB, from *time.Time, to *time.Time) paidRevenueS
 
 	return paidRevenueSummary{
 		Total:           row.Total,
+		Count:           row.Count,
 		Currency:        currency,
 		MixedCurrencies: row.CurrencyCount > 1,
 	}
@@ -444,32 +526,88 @@ func GetRecentActivity(db *gorm.DB) gin.HandlerFunc {
 // GetSystemHealth returns comprehensive system health status
 func GetSystemHealth(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		health := SystemHealth{
-			Database:          "healthy",
-			API:               "healthy",
-			Storage:           "healthy",
-			Uptime:            99.9,
-			LastBackup:        time.Now().Add(-24 * time.Hour).Format("2006-01-02 15:04:05"),
-			ActiveConnections: 45,
-			CPUUsage:          25.3,
-			MemoryUsage:       68.7,
-			DiskUsage:         45.2,
-			NetworkStatus:     "healthy",
-			DatabaseSize:      "2.3 GB",
-			BackupFrequency:   "Daily",
-			SecurityStatus:    "secure",
-			ComplianceStatus:  "compliant",
-			LastMaintenance:   time.Now().Add(-7 * 24 * time.Hour).Format("2006-01-02 15:04:05"),
-			NextMaintenance:   time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02 15:04:05"),
-		}
+		c.JSON(http.StatusOK, collectSystemHealth(db))
+	}
+}
 
-		// Test database connection
-		if err := db.Raw("SELECT 1").Error; err != nil {
-			health.Database = "unhealthy"
-		}
+// collectSystemHealth builds the system health report from the cached database probe, host
+// readings and maintenance runs.
+func collectSystemHealth(db *gorm.DB) SystemHealth {
+	health := SystemHealth{
+		Database:         "healthy",
+		API:              "healthy",
+		Storage:          "healthy",
+		Uptime:           services.UptimePercent(config.SystemUptimeWindow()),
+		UptimeSeconds:    services.Uptime().Seconds(),
+		LastBackup:       time.Now().Add(-24 * time.Hour).Format("2006-01-02 15:04:05"),
+		NetworkStatus:    "healthy",
+		BackupFrequency:  "Daily",
+		SecurityStatus:   "secure",
+		ComplianceStatus: "compliant",
+	}
 
-		c.JSON(http.StatusOK, health)
+	probe := getDatabaseProbe(db)
+	if !probe.Healthy {
+		health.Database = "unhealthy"
+	}
+	health.DatabaseLatencyMs = probe.LatencyMs
+	health.ActiveConnections = probe.ActiveConnections
+	health.DatabaseSize = probe.DatabaseSize
+	health.AsOf = probe.AsOf
+	applyHostMetrics(&health, getHostMetrics())
+
+	// Maintenance timestamps come from recorded runs; empty when never run or unscheduled
+	health.LastMaintenance = ""
+	var lastRun models.MaintenanceRun
+	if err := db.Where("finished_at IS NOT NULL").Order("finished_at DESC").First(&lastRun).Error; err == nil {
+		health.LastMaintenance = lastRun.FinishedAt.Format("2006-01-02 15:04:05")
 	}
+	health.NextMaintenance = ""
+	if next := nextMaintenanceRun(); next != nil {
+		health.NextMaintenance = next.Format("2006-01-02 15:04:05")
+	}
+
+	return health
+}
+
+// getDatabaseProbe returns the cached database probe, refreshing it once the TTL has passed.
+// The health panel auto-refreshes, so pg_stat_activity and pg_database_size are not queried per request.
+func getDatabaseProbe(db *gorm.DB) databaseProbe {
+	cacheKey := analyticsCacheKey("system-health", "database")
+	if cached, ok := analyticsCache.get(cacheKey); ok {
+		return cached.(databaseProbe)
+	}
+
+	probe := databaseProbe{Healthy: true, AsOf: time.Now()}
+	start := time.Now()
+	var one int
+	if err := db.Raw("SELECT 1").Scan(&one).Error; err != nil {
+		probe.Healthy = false
+	}
+	probe.LatencyMs = float64(time.Since(start).Microseconds()) / 1000.0
+	probe.ActiveConnections = getActiveConnections(db)
+	probe.DatabaseSize = getDatabaseSize(db)
+
+	analyticsCache.setFor(cacheKey, probe, probe.AsOf, config.SystemHealthCacheTTL())
+	return probe
+}
+
+// getActiveConnections counts open connections to the current database.
+func getActiveConnections(db *gorm.DB) int {
+	var count int
+	if err := db.Raw("SELECT COUNT(*) FROM pg_stat_activity WHERE datname = current_database()").Scan(&count).Error; err != nil {
+		return 0
+	}
+	return count
+}
+
+// getDatabaseSize returns the current database's size in human-readable form.
+func getDatabaseSize(db *gorm.DB) string {
+	var size string
+	if err := db.Raw("SELECT pg_size_pretty(pg_database_size(current_database()))").Scan(&size).Error; err != nil {
+		return "unknown"
+	}
+	return size
 }
 
 // GetBulkOperations returns available bulk operations for admin
@@ -477,18 +615,22 @@ func GetBulkOperations(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		operations := []map[string]interface{}{
 			{
-				"id":          "bulk_export_users",
-				"name":        "Export Users",
-				"description": "Export all users to CSV",
-				"endpoint":    "/admin/export/users",
-				"method":      "GET",
+				"id":            "bulk_export_users",
+				"name":          "Export Users",
+				"description":   "Export all users to CSV",
+				"endpoint":      "/admin/export",
+				"body":          gin.H{"type": "users"},
+				"typedEndpoint": "/admin/export/:type",
+				"method":        "POST",
 			},
 			{
-				"id":          "bulk_export_cases",
-				"name":        "Export Cases",
-				"description": "Export all cases to CSV",
-				"endpoint":    "/admin/export/cases",
-				"method":      "GET",
+				"id":            "bulk_export_cases",
+				"name":          "Export Cases",
+				"description":   "Export all cases to CSV",
+				"endpoint":      "/admin/export",
+				"body":          gin.H{"type": "cases"},
+				"typedEndpoint": "/admin/export/:type",
+				"method":        "POST",
 			},
 			{
 				"id":          "bulk_archive_cases",
@@ -497,6 +639,41 @@ func GetBulkOperations(db *gorm.DB) gin.HandlerFunc {
 				"endpoint":    "/admin/bulk/archive-cases",
 				"method":      "POST",
 			},
+			{
+				"id":          BulkDeleteCases,
+				"name":        "Delete Cases",
+				"description": "Soft delete the selected cases; validate first, requires confirm, and force to cancel their upcoming appointments and open tasks",
+				"endpoint":    "/admin/bulk-operations/execute",
+				"validate":    "/admin/bulk-operations/validate",
+				"method":      "POST",
+				"destructive": true,
+			},
+			{
+				"id":          BulkArchiveCases,
+				"name":        "Archive Selected Cases",
+				"description": "Archive the selected completed cases",
+				"endpoint":    "/admin/bulk-operations/execute",
+				"validate":    "/admin/bulk-operations/validate",
+				"method":      "POST",
+			},
+			{
+				"id":          BulkUpdateCases,
+				"name":        "Update Selected Cases",
+				"description": "Set status, stage, priority or office on the selected cases",
+				"endpoint":    "/admin/bulk-operations/execute",
+				"validate":    "/admin/bulk-operations/validate",
+				"method":      "POST",
+				"fields":      []string{"status", "current_stage", "priority", "office_id"},
+			},
+			{
+				"id":          BulkDeleteAppointments,
+				"name":        "Cancel Appointments",
+				"description": "Cancel and remove the selected appointments; validate first, requires confirm",
+				"endpoint":    "/admin/bulk-operations/execute",
+				"validate":    "/admin/bulk-operations/validate",
+				"method":      "POST",
+				"destructive": true,
+			},
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -505,14 +682,130 @@ func GetBulkOperations(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// ExportData exports system data to CSV format
+// ExportDataInput filters a data export. Filter keys and DateField must be on the type's
+// allowlist in query_fields.go; anything else is rejected with 400 before any SQL is built.
+type ExportDataInput struct {
+	Type      string                 `json:"type"`
+	Format    string                 `json:"format"`    // "csv" (default) or "excel" for a workbook of every type
+	Filters   map[string]interface{} `json:"filters"`   // column -> value, matched with "="
+	DateField string                 `json:"dateField"` // Column for dateFrom/dateTo; defaults to created_at
+	DateFrom  string                 `json:"dateFrom"`  // YYYY-MM-DD or RFC3339, inclusive
+	DateTo    string                 `json:"dateTo"`    // YYYY-MM-DD (whole day included) or RFC3339
+}
+
+// exportQuery builds the filtered query for an export, writing a 400 on invalid input.
+func exportQuery(c *gin.Context, db *gorm.DB, dataType string, input ExportDataInput) (*gorm.DB, bool) {
+	var query *gorm.DB
+	switch dataType {
+	case "cases":
+		query = db.Model(&models.Case{})
+	case "appointments":
+		query = db.Model(&models.Appointment{})
+	case "offices":
+		query = db.Model(&models.Office{})
+	default:
+		query = db.Model(&models.User{})
+	}
+
+	if unknown := unknownColumnFilters(dataType, input.Filters); len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown filter fields", "fields": unknown, "allowed": columnFilters(dataType)})
+		return nil, false
+	}
+	for key, value := range input.Filters {
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Filter values must be a string, number or boolean", "fields": []string{key}})
+			return nil, false
+		}
+		query = query.Where(clause.Eq{Column: clause.Column{Name: key}, Value: value})
+	}
+
+	if input.DateFrom == "" && input.DateTo == "" {
+		return query, true
+	}
+	dateField := input.DateField
+	if dateField == "" {
+		dateField = "created_at"
+	}
+	if !isDateField(dataType, dateField) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown date field", "fields": []string{dateField}})
+		return nil, false
+	}
+	column := clause.Column{Name: dateField}
+	if input.DateFrom != "" {
+		from, _, err := parseExportDate(input.DateFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dateFrom", "message": err.Error()})
+			return nil, false
+		}
+		query = query.Where(clause.Gte{Column: column, Value: from})
+	}
+	if input.DateTo != "" {
+		to, dateOnly, err := parseExportDate(input.DateTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dateTo", "message": err.Error()})
+			return nil, false
+		}
+		if dateOnly {
+			query = query.Where(clause.Lt{Column: column, Value: to.AddDate(0, 0, 1)})
+		} else {
+			query = query.Where(clause.Lte{Column: column, Value: to})
+		}
+	}
+	return query, true
+}
+
+// parseExportDate accepts YYYY-MM-DD (reported as date-only) or RFC3339.
+func parseExportDate(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// ExportData exports users or cases to CSV, or with format "excel" (body or ?format=) every
+// exportable type to one .xlsx workbook
 func ExportData(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var input ExportDataInput
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export request", "message": err.Error()})
+				return
+			}
+		}
+		format := input.Format
+		if format == "" {
+			format = c.DefaultQuery("format", "csv")
+		}
+		switch format {
+		case "excel", "xlsx":
+			exportWorkbook(c, db, input)
+			return
+		case "csv":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export format", "allowed": []string{"csv", "excel"}})
+			return
+		}
 		dataType := c.Param("type")
+		if dataType == "" {
+			dataType = input.Type
+		}
 		if dataType == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Data type is required"})
 			return
 		}
+		if dataType != "users" && dataType != "cases" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data type"})
+			return
+		}
+
+		query, ok := exportQuery(c, db, dataType, input)
+		if !ok {
+			return
+		}
 
 		var csvData bytes.Buffer
 		writer := csv.NewWriter(&csvData)
@@ -520,10 +813,13 @@ func ExportData(db *gorm.DB) gin.HandlerFunc {
 		switch dataType {
 		case "users":
 			var users []models.User
-			db.Find(&users)
+			if err := query.Find(&users).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export users", "message": err.Error()})
+				return
+			}
 
 			// Write header
-			writer.Write([]string{"ID", "First Name", "Last Name", "Email", "Role", "Created At"})
+			writer.Write(usersExportHeader)
 
 			// Write data
 			for _, user := range users {
@@ -539,10 +835,13 @@ func ExportData(db *gorm.DB) gin.HandlerFunc {
 
 		case "cases":
 			var cases []models.Case
-			db.Preload("Client").Find(&cases)
+			if err := query.Preload("Client").Find(&cases).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export cases", "message": err.Error()})
+				return
+			}
 
 			// Write header
-			writer.Write([]string{"ID", "Title", "Category", "Status", "Client", "Created At"})
+			writer.Write(casesExportHeader)
 
 			// Write data
 			for _, caseItem := range cases {
@@ -0,0 +1,91 @@
+// api/handlers/analytics_cache.go
+// Short-lived cache for expensive analytics responses, keyed by endpoint, scope and period.
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+)
+
+// analyticsCacheEntry is a computed analytics result; the result carries its own asOf.
+type analyticsCacheEntry struct {
+	Data      interface{}
+	ExpiresAt time.Time
+}
+
+// AnalyticsCache holds analytics results for config.AnalyticsCacheTTL.
+type AnalyticsCache struct {
+	data  map[string]*analyticsCacheEntry
+	mutex sync.RWMutex
+}
+
+var analyticsCache = &AnalyticsCache{
+	data: make(map[string]*analyticsCacheEntry),
+}
+
+// analyticsCacheKey builds a cache key from the endpoint name and the scope/period parts.
+func analyticsCacheKey(endpoint string, parts ...interface{}) string {
+	key := "analytics:" + endpoint
+	for _, part := range parts {
+		key += fmt.Sprintf(":%v", part)
+	}
+	return key
+}
+
+// get returns a cached result if it has not expired.
+func (ac *AnalyticsCache) get(key string) (interface{}, bool) {
+	ac.mutex.RLock()
+	defer ac.mutex.RUnlock()
+
+	entry, exists := ac.data[key]
+	if !exists || time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+// set stores a result computed at asOf for config.AnalyticsCacheTTL.
+func (ac *AnalyticsCache) set(key string, data interface{}, asOf time.Time) {
+	ac.setFor(key, data, asOf, config.AnalyticsCacheTTL())
+}
+
+// setFor stores a result computed at asOf for ttl. Nothing is stored when ttl is 0.
+func (ac *AnalyticsCache) setFor(key string, data interface{}, asOf time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+	ac.data[key] = &analyticsCacheEntry{
+		Data:      data,
+		ExpiresAt: asOf.Add(ttl),
+	}
+}
+
+// clearExpired removes expired analytics entries
+func (ac *AnalyticsCache) clearExpired() {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	now := time.Now()
+	for key, entry := range ac.data {
+		if now.After(entry.ExpiresAt) {
+			delete(ac.data, key)
+		}
+	}
+}
+
+func init() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			analyticsCache.clearExpired()
+		}
+	}()
+}
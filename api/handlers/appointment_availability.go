@@ -0,0 +1,198 @@
+// api/handlers/appointment_availability.go
+// Staff availability: the bookable slots of one staff member on a date, laid out on the
+// office's business hours in its time zone. A slot is offered only when booking it
+// would pass the double-booking and travel-buffer checks.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAvailabilityDurationMinutes is the longest slot the availability endpoint lays out.
+const maxAvailabilityDurationMinutes = 480
+
+// availabilitySlot is a free [start, end) interval.
+type availabilitySlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// busyInterval is time the staff member cannot be booked, travel buffer included.
+type busyInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// availableSlots splits [windowStart, windowEnd) into consecutive slots of duration and
+// returns those that start at or after notBefore and overlap no busy interval.
+func availableSlots(windowStart, windowEnd time.Time, duration time.Duration, busy []busyInterval, notBefore time.Time) []availabilitySlot {
+	slots := make([]availabilitySlot, 0)
+	if duration <= 0 {
+		return slots
+	}
+	for start := windowStart; !start.Add(duration).After(windowEnd); start = start.Add(duration) {
+		end := start.Add(duration)
+		if start.Before(notBefore) {
+			continue
+		}
+		free := true
+		for _, interval := range busy {
+			if appointmentsOverlap(start, end, interval.Start, interval.End) {
+				free = false
+				break
+			}
+		}
+		if free {
+			slots = append(slots, availabilitySlot{Start: start, End: end})
+		}
+	}
+	return slots
+}
+
+// staffBusyIntervals returns the staff member's active appointments near [windowStart,
+// windowEnd). Appointments at another office are widened by the travel buffer
+// findOfficeBufferConflicts would require.
+func staffBusyIntervals(db *gorm.DB, staffID uint, office models.Office, windowStart, windowEnd time.Time) ([]busyInterval, error) {
+	// Travel estimates can exceed the configured buffer, so look a few hours either side.
+	margin := 6 * time.Hour
+	var appointments []models.Appointment
+	if err := staffConflictQuery(db, staffID, windowStart.Add(-margin), windowEnd.Add(margin), 0).Order("start_time").Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+
+	bufferMinutes := config.AppointmentOfficeBufferMinutes()
+	otherOffices := make(map[uint]models.Office)
+	if bufferMinutes > 0 && office.ID != 0 {
+		officeIDs := make([]uint, 0)
+		for _, appt := range appointments {
+			if appt.OfficeID != 0 && appt.OfficeID != office.ID {
+				officeIDs = append(officeIDs, appt.OfficeID)
+			}
+		}
+		if len(officeIDs) > 0 {
+			var offices []models.Office
+			if err := db.Where("id IN ?", officeIDs).Find(&offices).Error; err != nil {
+				return nil, err
+			}
+			for _, other := range offices {
+				otherOffices[other.ID] = other
+			}
+		}
+	}
+
+	busy := make([]busyInterval, 0, len(appointments))
+	for _, appt := range appointments {
+		interval := busyInterval{Start: appt.StartTime, End: appt.EndTime}
+		if bufferMinutes > 0 && office.ID != 0 && appt.OfficeID != 0 && appt.OfficeID != office.ID {
+			gap := time.Duration(requiredOfficeGapMinutes(office, otherOffices[appt.OfficeID], bufferMinutes)) * time.Minute
+			interval.Start = interval.Start.Add(-gap)
+			interval.End = interval.End.Add(gap)
+		}
+		busy = append(busy, interval)
+	}
+	return busy, nil
+}
+
+// GetStaffAvailability returns the bookable slots of a staff member on a date:
+// GET /appointments/availability?staffId=&date=YYYY-MM-DD&duration=minutes[&officeId=].
+// The office's business hours for that weekday are laid out in its time zone; officeId
+// defaults to the staff member's office. duration defaults to the scheduling slot size. Slots
+// already started are left out for today, and closed days and holidays (named in holiday) have none.
+func GetStaffAvailability(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staffID, err := strconv.ParseUint(c.Query("staffId"), 10, 32)
+		if err != nil || staffID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "staffId es obligatorio"})
+			return
+		}
+
+		duration := loadSchedulingSettings(db).SlotMinutes
+		if duration == 0 {
+			duration = 30
+		}
+		if v := c.Query("duration"); v != "" {
+			duration, err = strconv.Atoi(v)
+			if err != nil || duration <= 0 || duration > maxAvailabilityDurationMinutes {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "duration debe ser un número de minutos entre 1 y 480"})
+				return
+			}
+		}
+
+		var staff models.User
+		if err := db.Select("id, role, office_id").First(&staff, staffID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Personal no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el personal", "message": err.Error()})
+			return
+		}
+		if staff.Role == "client" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "staffId debe ser un miembro del personal"})
+			return
+		}
+
+		var office models.Office
+		officeID := uint64(0)
+		if staff.OfficeID != nil {
+			officeID = uint64(*staff.OfficeID)
+		}
+		if v := c.Query("officeId"); v != "" {
+			officeID, err = strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "officeId inválido"})
+				return
+			}
+		}
+		if officeID != 0 {
+			if err := db.First(&office, officeID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+				return
+			}
+		}
+		loc := officeLocation(office)
+
+		day, err := time.ParseInLocation("2006-01-02", c.Query("date"), loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date debe tener el formato YYYY-MM-DD"})
+			return
+		}
+		calendar, err := officeCalendarFor(db, office)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el horario de la oficina", "message": err.Error()})
+			return
+		}
+		hours, holiday, workingDay := calendar.dayHours(day)
+		windowStart := wallClockTime(day.Year(), day.Month(), day.Day(), hours.Open/60, hours.Open%60, 0, 0, loc)
+		windowEnd := wallClockTime(day.Year(), day.Month(), day.Day(), hours.Close/60, hours.Close%60, 0, 0, loc)
+
+		slots := make([]availabilitySlot, 0)
+		if workingDay {
+			busy, err := staffBusyIntervals(db, staff.ID, office, windowStart, windowEnd)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la disponibilidad", "message": err.Error()})
+				return
+			}
+			slots = availableSlots(windowStart, windowEnd, time.Duration(duration)*time.Minute, busy, time.Now())
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"staffId":    staff.ID,
+			"officeId":   office.ID,
+			"date":       day.Format("2006-01-02"),
+			"timezone":   loc.String(),
+			"duration":   duration,
+			"workingDay": workingDay,
+			"holiday":    holiday,
+			"slots":      slots,
+		})
+	}
+}
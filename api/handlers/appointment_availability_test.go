@@ -0,0 +1,163 @@
+// api/handlers/appointment_availability_test.go
+// Unit tests for staff availability: slot layout around existing appointments and the
+// endpoint for a fully booked day and a day with gaps.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestAvailableSlots(t *testing.T) {
+	day := func(hour, minute int) time.Time { return time.Date(2030, 3, 12, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		busy      []busyInterval
+		notBefore time.Time
+		want      []string
+	}{
+		{"free day", nil, time.Time{}, []string{"09:00", "10:00", "11:00"}},
+		{"fully booked", []busyInterval{{day(9, 0), day(12, 0)}}, time.Time{}, []string{}},
+		{"gap between appointments", []busyInterval{{day(9, 0), day(10, 0)}, {day(10, 30), day(12, 0)}}, time.Time{}, []string{}},
+		{"partial overlap blocks the slot", []busyInterval{{day(10, 15), day(10, 45)}}, time.Time{}, []string{"09:00", "11:00"}},
+		{"back to back is free", []busyInterval{{day(10, 0), day(11, 0)}}, time.Time{}, []string{"09:00", "11:00"}},
+		{"started slots are left out", nil, day(10, 15), []string{"11:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := availableSlots(day(9, 0), day(12, 0), time.Hour, tt.busy, tt.notBefore)
+			got := make([]string, 0, len(slots))
+			for _, slot := range slots {
+				if slot.End.Sub(slot.Start) != time.Hour {
+					t.Errorf("slot %v-%v is not an hour long", slot.Start, slot.End)
+				}
+				got = append(got, slot.Start.Format("15:04"))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("slots = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// availabilityScript answers for staff member 4 of office 2 (Mexico City time) with the
+// given appointments, each as {officeID, start, end} in office time on 2030-03-12.
+func availabilityScript(appointments [][3]interface{}) *scriptedSQL {
+	loc, _ := time.LoadLocation("America/Mexico_City")
+	at := func(clock string) time.Time {
+		parsed, _ := time.ParseInLocation("2006-01-02 15:04", "2030-03-12 "+clock, loc)
+		return parsed.UTC()
+	}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "role", "office_id"}, [][]driver.Value{{int64(4), "lawyer", int64(2)}}
+			case strings.Contains(query, `FROM "offices"`):
+				return []string{"id", "name", "timezone"}, [][]driver.Value{{int64(2), "Centro", "America/Mexico_City"}, {int64(5), "Norte", ""}}
+			case strings.Contains(query, `FROM "appointments"`):
+				rows := make([][]driver.Value, 0, len(appointments))
+				for i, appt := range appointments {
+					rows = append(rows, []driver.Value{int64(i + 1), int64(4), int64(appt[0].(int)), at(appt[1].(string)), at(appt[2].(string)), "confirmed"})
+				}
+				return []string{"id", "staff_id", "office_id", "start_time", "end_time", "status"}, rows
+			}
+			return nil, nil
+		},
+	}
+}
+
+// getAvailability runs GetStaffAvailability and decodes the response.
+func getAvailability(t *testing.T, db *gorm.DB, query string) (int, map[string]interface{}) {
+	t.Helper()
+	t.Setenv("APPOINTMENT_WORKING_HOURS", "09:00-12:00")
+	t.Setenv("APPOINTMENT_OFFICE_BUFFER_MINUTES", "30")
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/appointments/availability?"+query, nil)
+	GetStaffAvailability(db)(c)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+// slotStarts returns the start times of the response's slots.
+func slotStarts(t *testing.T, body map[string]interface{}) []string {
+	t.Helper()
+	slots, ok := body["slots"].([]interface{})
+	if !ok {
+		t.Fatalf("slots missing: %v", body)
+	}
+	starts := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		starts = append(starts, slot.(map[string]interface{})["start"].(string))
+	}
+	return starts
+}
+
+func TestGetStaffAvailabilityFullyBookedDay(t *testing.T) {
+	db := scriptedDB(t, availabilityScript([][3]interface{}{{2, "09:00", "10:30"}, {2, "10:30", "12:00"}}))
+
+	code, body := getAvailability(t, db, "staffId=4&date=2030-03-12&duration=30")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, body)
+	}
+	if starts := slotStarts(t, body); len(starts) != 0 {
+		t.Errorf("slots on a fully booked day = %v", starts)
+	}
+	if body["workingDay"] != true || body["timezone"] != "America/Mexico_City" {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestGetStaffAvailabilityDayWithGaps(t *testing.T) {
+	// 09:30-10:00 at the staff member's office, and 11:00-11:30 at another office, which
+	// also blocks the 30-minute travel buffer either side
+	script := availabilityScript([][3]interface{}{{2, "09:30", "10:00"}, {5, "11:00", "11:30"}})
+	db := scriptedDB(t, script)
+
+	code, body := getAvailability(t, db, "staffId=4&date=2030-03-12&duration=30")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, body)
+	}
+	want := []string{"2030-03-12T09:00:00-06:00", "2030-03-12T10:00:00-06:00"}
+	if starts := slotStarts(t, body); strings.Join(starts, ",") != strings.Join(want, ",") {
+		t.Errorf("slots = %v, want %v", starts, want)
+	}
+
+	queries := script.ran(`FROM "appointments"`)
+	if len(queries) != 1 || !strings.Contains(queries[0], "status NOT IN") {
+		t.Errorf("appointment queries = %v", queries)
+	}
+}
+
+func TestGetStaffAvailabilityNonWorkingDay(t *testing.T) {
+	script := availabilityScript(nil)
+	code, body := getAvailability(t, scriptedDB(t, script), "staffId=4&date=2030-03-16")
+	if code != http.StatusOK || body["workingDay"] != false || len(slotStarts(t, body)) != 0 {
+		t.Errorf("Saturday: status %d, body %v", code, body)
+	}
+	if len(script.ran(`FROM "appointments"`)) > 0 {
+		t.Error("appointments loaded for a non-working day")
+	}
+}
+
+func TestGetStaffAvailabilityRejectsBadInput(t *testing.T) {
+	for _, query := range []string{"date=2030-03-12", "staffId=4&date=12/03/2030", "staffId=4&date=2030-03-12&duration=0"} {
+		if code, body := getAvailability(t, scriptedDB(t, availabilityScript(nil)), query); code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, body %v", query, code, body)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// api/handlers/appointment_buffer.go
+// Travel buffer validation for staff who cover several offices: two
+// appointments at different offices must leave enough time to move between them.
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// officeBufferConflict describes an existing appointment that is too close to a proposed one.
+type officeBufferConflict struct {
+	AppointmentID    uint      `json:"appointmentId"`
+	Title            string    `json:"title"`
+	OfficeID         uint      `json:"officeId"`
+	StartTime        time.Time `json:"startTime"`
+	EndTime          time.Time `json:"endTime"`
+	RequiredMinutes  int       `json:"requiredMinutes"`
+	AvailableMinutes int       `json:"availableMinutes"`
+}
+
+// findOfficeBufferConflicts returns the staff member's appointments at other offices that
+// leave less than the required buffer (plus estimated travel time) around the proposed slot.
+func findOfficeBufferConflicts(db *gorm.DB, staffID, officeID uint, start, end time.Time, excludeID uint) ([]officeBufferConflict, error) {
+	conflicts := make([]officeBufferConflict, 0)
+	bufferMinutes := config.AppointmentOfficeBufferMinutes()
+	if bufferMinutes == 0 || staffID == 0 || officeID == 0 {
+		return conflicts, nil
+	}
+
+	// Travel estimates can exceed the configured buffer, so look a few hours either side.
+	window := 6 * time.Hour
+	var nearby []models.Appointment
+	query := db.Model(&models.Appointment{}).
+		Where("staff_id = ?", staffID).
+		Where("office_id <> ? AND office_id <> 0", officeID).
+		Where("status NOT IN ?", []string{string(config.StatusCancelled), string(config.StatusNoShow)}).
+		Where("start_time < ? AND end_time > ?", end.Add(window), start.Add(-window))
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Find(&nearby).Error; err != nil {
+		return nil, err
+	}
+	if len(nearby) == 0 {
+		return conflicts, nil
+	}
+
+	officeIDs := []uint{officeID}
+	for _, appt := range nearby {
+		officeIDs = append(officeIDs, appt.OfficeID)
+	}
+	var offices []models.Office
+	if err := db.Where("id IN ?", officeIDs).Find(&offices).Error; err != nil {
+		return nil, err
+	}
+	officesByID := make(map[uint]models.Office, len(offices))
+	for _, office := range offices {
+		officesByID[office.ID] = office
+	}
+
+	for _, appt := range nearby {
+		required := requiredOfficeGapMinutes(officesByID[officeID], officesByID[appt.OfficeID], bufferMinutes)
+
+		var available time.Duration
+		if !appt.EndTime.After(start) {
+			available = start.Sub(appt.EndTime)
+		} else if !appt.StartTime.Before(end) {
+			available = appt.StartTime.Sub(end)
+		} else {
+			available = 0 // Overlapping slots at different offices
+		}
+
+		availableMinutes := int(available.Minutes())
+		if availableMinutes < required {
+			conflicts = append(conflicts, officeBufferConflict{
+				AppointmentID:    appt.ID,
+				Title:            appt.Title,
+				OfficeID:         appt.OfficeID,
+				StartTime:        appt.StartTime,
+				EndTime:          appt.EndTime,
+				RequiredMinutes:  required,
+				AvailableMinutes: availableMinutes,
+			})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// requiredOfficeGapMinutes returns the larger of the configured buffer and the
+// estimated travel time between two offices with known coordinates.
+func requiredOfficeGapMinutes(from, to models.Office, bufferMinutes int) int {
+	speed := config.AppointmentTravelSpeedKmh()
+	if speed <= 0 || from.Latitude == nil || from.Longitude == nil || to.Latitude == nil || to.Longitude == nil {
+		return bufferMinutes
+	}
+
+	distanceKm := haversineKm(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude)
+	travelMinutes := int(math.Ceil(distanceKm / speed * 60))
+	if travelMinutes > bufferMinutes {
+		return travelMinutes
+	}
+	return bufferMinutes
+}
+
+// haversineKm returns the great-circle distance between two coordinates in kilometers.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// enforceOfficeBuffer validates the travel buffer for a proposed appointment.
+// It writes a 409 response and returns false when the schedule is impossible.
+// Admins may pass override=true to schedule anyway; the conflicts are then returned
+// to the caller as advisory warnings.
+func enforceOfficeBuffer(c *gin.Context, db *gorm.DB, staffID, officeID uint, start, end time.Time, excludeID uint, override bool) ([]officeBufferConflict, bool) {
+	conflicts, err := findOfficeBufferConflicts(db, staffID, officeID, start, end, excludeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar el tiempo de traslado", "message": err.Error()})
+		return nil, false
+	}
+	if len(conflicts) == 0 {
+		return conflicts, true
+	}
+
+	if override && c.GetString("userRole") == config.RoleAdmin {
+		return conflicts, true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error":         "Tiempo insuficiente entre citas en oficinas distintas",
+		"bufferMinutes": config.AppointmentOfficeBufferMinutes(),
+		"conflicts":     conflicts,
+	})
+	return nil, false
+}
@@ -0,0 +1,135 @@
+// api/handlers/appointment_conflicts.go
+// Double-booking protection: a staff member cannot hold two active appointments whose
+// [start, end) intervals overlap. Back-to-back appointments sharing an endpoint are fine.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errAppointmentConflict aborts a creation transaction after the 409 has been written.
+var errAppointmentConflict = errors.New("appointment overlaps an existing appointment")
+
+// appointmentConflict is an existing appointment overlapping a proposed one.
+type appointmentConflict struct {
+	AppointmentID uint      `json:"appointmentId"`
+	Title         string    `json:"title"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+	Status        string    `json:"status"`
+}
+
+// inactiveAppointmentStatuses do not occupy the staff member's time.
+var inactiveAppointmentStatuses = []string{string(config.StatusCancelled), string(config.StatusNoShow)}
+
+// appointmentsOverlap reports whether [aStart, aEnd) and [bStart, bEnd) intersect; it is the
+// rule the conflict query applies in SQL.
+func appointmentsOverlap(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// staffConflictQuery selects the staff member's active appointments overlapping [start, end),
+// optionally excluding one appointment (the one being updated).
+func staffConflictQuery(db *gorm.DB, staffID uint, start, end time.Time, excludeID uint) *gorm.DB {
+	query := db.Model(&models.Appointment{}).
+		Where("staff_id = ? AND start_time < ? AND end_time > ?", staffID, end, start).
+		Where("status NOT IN ?", inactiveAppointmentStatuses)
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	return query
+}
+
+// findStaffAppointmentConflicts returns the staff member's active appointments overlapping [start, end).
+func findStaffAppointmentConflicts(db *gorm.DB, staffID uint, start, end time.Time, excludeID uint) ([]appointmentConflict, error) {
+	var appointments []models.Appointment
+	if err := staffConflictQuery(db, staffID, start, end, excludeID).Order("start_time").Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	conflicts := make([]appointmentConflict, 0, len(appointments))
+	for _, appt := range appointments {
+		conflicts = append(conflicts, appointmentConflict{
+			AppointmentID: appt.ID,
+			Title:         appt.Title,
+			StartTime:     appt.StartTime,
+			EndTime:       appt.EndTime,
+			Status:        string(appt.Status),
+		})
+	}
+	return conflicts, nil
+}
+
+// hasStaffAppointmentOverlap reports whether the staff member has an active appointment overlapping [start, end).
+func hasStaffAppointmentOverlap(db *gorm.DB, staffID uint, start, end time.Time) (bool, error) {
+	var count int64
+	err := staffConflictQuery(db, staffID, start, end, 0).Count(&count).Error
+	return count > 0, err
+}
+
+// enforceStaffAvailability rejects a proposed appointment that overlaps one of the staff member's
+// active appointments, writing a 409 and returning false. tx must be the transaction that will
+// insert the appointment: the staff row is locked first, so concurrent bookings for the same
+// person are checked one after another. Admins may pass ?allowOverlap=true to book anyway; the
+// overlaps are then returned for recordForcedOverlap.
+func enforceStaffAvailability(c *gin.Context, tx *gorm.DB, staffID uint, start, end time.Time, excludeID uint) ([]appointmentConflict, bool) {
+	var staff models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", staffID).Find(&staff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la disponibilidad", "message": err.Error()})
+		return nil, false
+	}
+
+	conflicts, err := findStaffAppointmentConflicts(tx, staffID, start, end, excludeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la disponibilidad", "message": err.Error()})
+		return nil, false
+	}
+	if len(conflicts) == 0 {
+		return conflicts, true
+	}
+
+	if c.Query("allowOverlap") == "true" && c.GetString("userRole") == config.RoleAdmin {
+		return conflicts, true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"error":     "El personal ya tiene una cita en ese horario",
+		"conflict":  conflicts[0],
+		"conflicts": conflicts,
+	})
+	return nil, false
+}
+
+// recordForcedOverlap notes on the case timeline that an admin booked the appointment despite overlaps.
+// It runs in the booking's transaction; an error must roll the booking back, since a failed
+// statement leaves the transaction unable to commit.
+func recordForcedOverlap(tx *gorm.DB, c *gin.Context, appointment *models.Appointment, overlaps []appointmentConflict) error {
+	if len(overlaps) == 0 || appointment.CaseID == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(overlaps))
+	for _, overlap := range overlaps {
+		ids = append(ids, fmt.Sprintf("#%d", overlap.AppointmentID))
+	}
+	event := models.CaseEvent{
+		CaseID:    appointment.CaseID,
+		UserID:    extractUserIDUint(c),
+		EventType: "appointment_overlap",
+		Description: fmt.Sprintf("Cita #%d (%s) agendada con traslape autorizado con %s",
+			appointment.ID, appointment.StartTime.Format("2006-01-02 15:04"), strings.Join(ids, ", ")),
+		Visibility: "internal",
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("record forced overlap for appointment %d: %w", appointment.ID, err)
+	}
+	return nil
+}
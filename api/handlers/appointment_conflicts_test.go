@@ -0,0 +1,119 @@
+// api/handlers/appointment_conflicts_test.go
+// Unit tests for the staff double-booking check, and for forced overlaps whose timeline note
+// cannot be written.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestAppointmentsOverlap(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC)
+	}
+	existingStart, existingEnd := at(10, 0), at(11, 0)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       bool
+	}{
+		{"back-to-back before", at(9, 0), at(10, 0), false},
+		{"back-to-back after", at(11, 0), at(12, 0), false},
+		{"entirely before", at(8, 0), at(9, 30), false},
+		{"entirely after", at(11, 30), at(12, 0), false},
+		{"overlaps the start", at(9, 30), at(10, 30), true},
+		{"overlaps the end", at(10, 30), at(11, 30), true},
+		{"inside", at(10, 15), at(10, 45), true},
+		{"contains", at(9, 0), at(12, 0), true},
+		{"identical", at(10, 0), at(11, 0), true},
+		{"one minute into the end", at(10, 59), at(12, 0), true},
+	}
+	for _, tt := range tests {
+		if got := appointmentsOverlap(tt.start, tt.end, existingStart, existingEnd); got != tt.want {
+			t.Errorf("%s: appointmentsOverlap = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := appointmentsOverlap(existingStart, existingEnd, tt.start, tt.end); got != tt.want {
+			t.Errorf("%s (reversed): appointmentsOverlap = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStaffConflictQueryUsesHalfOpenIntervals(t *testing.T) {
+	db := dryRunDB(t)
+	start := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return staffConflictQuery(tx, 7, start, end, 42).Find(&[]models.Appointment{})
+	})
+	// Strict comparisons let an appointment ending at 10:00 or starting at 11:00 through
+	for _, fragment := range []string{
+		"staff_id = 7",
+		"start_time < '2025-06-02 11:00:00'",
+		"end_time > '2025-06-02 10:00:00'",
+		"status NOT IN ('cancelled','no_show')",
+		"id <> 42",
+		`"appointments"."deleted_at" IS NULL`,
+	} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("conflict query missing %q: %s", fragment, sql)
+		}
+	}
+	if strings.Contains(sql, "<=") || strings.Contains(sql, ">=") {
+		t.Errorf("conflict query must not use inclusive bounds: %s", sql)
+	}
+}
+
+func TestForcedOverlapRollsBackWhenNotRecorded(t *testing.T) {
+	t.Setenv("APPOINTMENT_MIN_DURATION_MINUTES", "15")
+	t.Setenv("APPOINTMENT_MAX_DURATION_MINUTES", "180")
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour).UTC()
+	// Staff member 4 already has appointment 9 at that time
+	script := staffMatchScript(2, "Familiar")
+	caseAndStaff := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, `FROM "appointments"`) && strings.Contains(query, "staff_id = ") {
+			return []string{"id", "title", "start_time", "end_time", "status"}, [][]driver.Value{{int64(9), "Consulta", start, start.Add(time.Hour), "confirmed"}}
+		}
+		return caseAndStaff(query, args)
+	}
+	script.fail = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "case_events"`) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"caseId": 7, "staffId": 4, "title": "Audiencia", "status": "confirmed",
+		"category": "General", "department": "Familiar", "overrideBuffer": true,
+		"startTime": start.Format(time.RFC3339), "endTime": start.Add(time.Hour).Format(time.RFC3339),
+	})
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/appointments?allowOverlap=true", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	CreateAppointmentSmart(scriptedDB(t, script))(c)
+
+	// The booking fails as a whole instead of hitting an aborted transaction on COMMIT
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body.String())
+	}
+	if len(script.ran(`INSERT INTO "case_events"`)) != 1 || len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
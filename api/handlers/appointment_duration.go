@@ -0,0 +1,74 @@
+// api/handlers/appointment_duration.go
+// Appointment length rules: an appointment must end after it starts, last between the
+// configured minimum and maximum, and not start in the past unless an admin overrides it.
+// A booking without an end time gets its category's default length.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Appointment time violations reported by enforceAppointmentDuration.
+const (
+	appointmentEndsBeforeStart = "end_before_start"
+	appointmentTooShort        = "too_short"
+	appointmentTooLong         = "too_long"
+	appointmentStartsInPast    = "start_in_past"
+)
+
+// appointmentEndTime returns end, or when it is zero the end of an appointment of the category
+// starting at start with its default length.
+func appointmentEndTime(start, end time.Time, category string) time.Time {
+	if end.IsZero() {
+		return start.Add(config.AppointmentDefaultDuration(category))
+	}
+	return end
+}
+
+// appointmentTimeViolation returns the rule an appointment from start to end breaks, or ""
+// when it breaks none. A minimum or maximum of 0 minutes is not checked.
+func appointmentTimeViolation(start, end, now time.Time, allowPast bool, minMinutes, maxMinutes int) string {
+	duration := end.Sub(start)
+	switch {
+	case duration <= 0:
+		return appointmentEndsBeforeStart
+	case minMinutes > 0 && duration < time.Duration(minMinutes)*time.Minute:
+		return appointmentTooShort
+	case maxMinutes > 0 && duration > time.Duration(maxMinutes)*time.Minute:
+		return appointmentTooLong
+	case !allowPast && start.Before(now):
+		return appointmentStartsInPast
+	}
+	return ""
+}
+
+// enforceAppointmentDuration validates a new appointment's times against the length rules. It
+// writes a 422 response naming the violation and returns false when one is broken. Admins may
+// pass overridePast to record an appointment that has already started.
+func enforceAppointmentDuration(c *gin.Context, start, end time.Time, overridePast bool) bool {
+	minMinutes, maxMinutes := config.AppointmentMinDurationMinutes(), config.AppointmentMaxDurationMinutes()
+	allowPast := overridePast && c.GetString("userRole") == config.RoleAdmin
+	violation := appointmentTimeViolation(start, end, time.Now(), allowPast, minMinutes, maxMinutes)
+	if violation == "" {
+		return true
+	}
+
+	messages := map[string]string{
+		appointmentEndsBeforeStart: "La hora de fin debe ser posterior a la de inicio",
+		appointmentTooShort:        "La cita es más corta que la duración mínima permitida",
+		appointmentTooLong:         "La cita es más larga que la duración máxima permitida",
+		appointmentStartsInPast:    "La cita no puede comenzar en el pasado",
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":           messages[violation],
+		"violation":       violation,
+		"durationMinutes": int(end.Sub(start) / time.Minute),
+		"minMinutes":      minMinutes,
+		"maxMinutes":      maxMinutes,
+	})
+	return false
+}
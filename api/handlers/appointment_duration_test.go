@@ -0,0 +1,134 @@
+// api/handlers/appointment_duration_test.go
+// Unit tests for the appointment length rules: each boundary of the minimum, maximum and
+// past-start checks, the default end time, and the 422 both creation handlers answer.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestAppointmentTimeViolationBoundaries(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	start := now.Add(24 * time.Hour)
+	tests := []struct {
+		name      string
+		start     time.Time
+		duration  time.Duration
+		allowPast bool
+		want      string
+	}{
+		{"ends before start", start, -time.Minute, false, appointmentEndsBeforeStart},
+		{"zero length", start, 0, false, appointmentEndsBeforeStart},
+		{"just under the minimum", start, 15*time.Minute - time.Second, false, appointmentTooShort},
+		{"minimum", start, 15 * time.Minute, false, ""},
+		{"maximum", start, 3 * time.Hour, false, ""},
+		{"just over the maximum", start, 3*time.Hour + time.Second, false, appointmentTooLong},
+		{"starts now", now, time.Hour, false, ""},
+		{"started a second ago", now.Add(-time.Second), time.Hour, false, appointmentStartsInPast},
+		{"past, overridden", now.Add(-24 * time.Hour), time.Hour, true, ""},
+		// The length rules apply to overridden appointments too
+		{"past and too long, overridden", now.Add(-24 * time.Hour), 4 * time.Hour, true, appointmentTooLong},
+	}
+	for _, tt := range tests {
+		if got := appointmentTimeViolation(tt.start, tt.start.Add(tt.duration), now, tt.allowPast, 15, 180); got != tt.want {
+			t.Errorf("%s: violation = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// A limit of 0 is not checked
+	if got := appointmentTimeViolation(start, start.Add(time.Minute), now, false, 0, 0); got != "" {
+		t.Errorf("disabled limits: violation = %q", got)
+	}
+	if got := appointmentTimeViolation(start, start.Add(12*time.Hour), now, false, 0, 0); got != "" {
+		t.Errorf("disabled limits: violation = %q", got)
+	}
+}
+
+func TestAppointmentEndTimeDefaultsByCategory(t *testing.T) {
+	t.Setenv("APPOINTMENT_CATEGORY_DURATIONS", "Trabajo Social=90")
+	t.Setenv("APPOINTMENT_DEFAULT_DURATION_MINUTES", "")
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		category string
+		want     time.Duration
+	}{
+		{"Consulta Legal", time.Hour},
+		{"sesion de psicologia", 50 * time.Minute},
+		{"Trabajo Social", 90 * time.Minute},
+		{"Otra", time.Hour},
+	}
+	for _, tt := range tests {
+		if got := appointmentEndTime(start, time.Time{}, tt.category).Sub(start); got != tt.want {
+			t.Errorf("%s: default length = %s, want %s", tt.category, got, tt.want)
+		}
+	}
+	end := start.Add(20 * time.Minute)
+	if got := appointmentEndTime(start, end, "Consulta Legal"); !got.Equal(end) {
+		t.Errorf("explicit end time replaced by %s", got)
+	}
+}
+
+// createAppointmentWith posts body to handler as a user of role.
+func createAppointmentWith(t *testing.T, handler func(*gorm.DB) gin.HandlerFunc, role, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	t.Setenv("APPOINTMENT_MIN_DURATION_MINUTES", "15")
+	t.Setenv("APPOINTMENT_MAX_DURATION_MINUTES", "180")
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/appointments", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", role)
+	c.Set("currentUser", models.User{ID: 1, Role: role})
+	handler(scriptedDB(t, &scriptedSQL{}))(c)
+	return w
+}
+
+func TestCreateAppointmentRejectsInvalidLength(t *testing.T) {
+	tomorrow := time.Now().Add(24 * time.Hour).Truncate(time.Hour).UTC()
+	at := func(offset time.Duration) string { return tomorrow.Add(offset).Format(time.RFC3339) }
+	past := time.Now().Add(-48 * time.Hour).Truncate(time.Hour).UTC()
+
+	creators := map[string]struct {
+		handler func(*gorm.DB) gin.HandlerFunc
+		fields  string
+	}{
+		"enhanced": {CreateAppointmentEnhanced, `"caseId":7,"staffId":4,"title":"Audiencia","category":"General","department":"Familiar"`},
+		"smart":    {CreateAppointmentSmart, `"caseId":7,"staffId":4,"title":"Audiencia","status":"confirmed","category":"General","department":"Familiar"`},
+	}
+	tests := []struct {
+		name  string
+		role  string
+		times string
+		want  string
+	}{
+		{"end before start", "admin", `"startTime":"` + at(time.Hour) + `","endTime":"` + at(0) + `"`, appointmentEndsBeforeStart},
+		{"too short", "admin", `"startTime":"` + at(0) + `","endTime":"` + at(10*time.Minute) + `"`, appointmentTooShort},
+		{"too long", "admin", `"startTime":"` + at(0) + `","endTime":"` + at(4*time.Hour) + `"`, appointmentTooLong},
+		{"past", "admin", `"startTime":"` + past.Format(time.RFC3339) + `"`, appointmentStartsInPast},
+		// Only admins may book in the past
+		{"past, overridden by staff", "office_manager", `"overridePast":true,"startTime":"` + past.Format(time.RFC3339) + `"`, appointmentStartsInPast},
+	}
+	for name, h := range creators {
+		for _, tt := range tests {
+			w := createAppointmentWith(t, h.handler, tt.role, `{`+h.fields+`,`+tt.times+`}`)
+			var body struct {
+				Violation string `json:"violation"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != http.StatusUnprocessableEntity || body.Violation != tt.want {
+				t.Errorf("%s, %s: status %d, body %s", name, tt.name, w.Code, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// api/handlers/appointment_heatmap.go
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// heatmapWeekdays labels the matrix rows; index matches PostgreSQL's EXTRACT(DOW), Sunday first.
+var heatmapWeekdays = []string{"Domingo", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"}
+
+// GetAppointmentHeatmap returns the office's appointment counts as a 7x24 weekday-by-hour matrix.
+// Cancelled appointments are excluded. Defaults to the last 30 days.
+func GetAppointmentHeatmap(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, from, to, ok := resolveOfficeRange(c, db, -29, 1)
+		if !ok {
+			return
+		}
+
+		var buckets []struct {
+			Weekday int
+			Hour    int
+			Count   int
+		}
+		err := db.Table("appointments").
+			Select("EXTRACT(DOW FROM appointments.start_time)::int AS weekday, EXTRACT(HOUR FROM appointments.start_time)::int AS hour, COUNT(*) AS count").
+			Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.office_id = ?", officeID).
+			Where("appointments.deleted_at IS NULL").
+			Where("appointments.status <> ?", config.StatusCancelled).
+			Where("appointments.start_time >= ? AND appointments.start_time < ?", from, to).
+			Group("weekday, hour").
+			Scan(&buckets).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular el mapa de calor", "message": err.Error()})
+			return
+		}
+
+		matrix := make([][]int, len(heatmapWeekdays))
+		for i := range matrix {
+			matrix[i] = make([]int, 24)
+		}
+		total, peak := 0, 0
+		for _, b := range buckets {
+			if b.Weekday < 0 || b.Weekday >= len(matrix) || b.Hour < 0 || b.Hour >= 24 {
+				continue
+			}
+			matrix[b.Weekday][b.Hour] = b.Count
+			total += b.Count
+			if b.Count > peak {
+				peak = b.Count
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"officeId": officeID,
+			"from":     from.Format("2006-01-02"),
+			"to":       to.Add(-24 * time.Hour).Format("2006-01-02"),
+			"weekdays": heatmapWeekdays,
+			"matrix":   matrix,
+			"total":    total,
+			"max":      peak,
+		})
+	}
+}
@@ -0,0 +1,596 @@
+// api/handlers/appointment_import.go
+// Appointment schedule import from CSV, for offices moving their calendars off
+// spreadsheets. Every row is validated (references, working hours, slot alignment,
+// overlaps) before anything is written; valid rows are then created in batches,
+// each batch in its own transaction. Rows that conflict are rejected, never overlapped.
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Import limits
+const (
+	maxAppointmentImportRows   = 2000
+	appointmentImportBatchSize = 50
+)
+
+// errImportRehearsal rolls back a validate-only import once every row has been tried.
+var errImportRehearsal = errors.New("validate-only import rolled back")
+
+// appointmentImportTimeLayouts are the accepted start/end formats; layouts without an
+// offset are read in the server's time zone.
+var appointmentImportTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// appointmentImportRow is one parsed CSV row and its outcome.
+type appointmentImportRow struct {
+	Row           int      `json:"row"`    // 1-based line number in the file, header included
+	Status        string   `json:"status"` // valid (dry run), created or rejected
+	Errors        []string `json:"errors,omitempty"`
+	AppointmentID uint     `json:"appointmentId,omitempty"`
+	CaseID        uint     `json:"caseId,omitempty"`
+	ClientID      uint     `json:"clientId,omitempty"`
+	CreatedClient bool     `json:"createdClient,omitempty"`
+	CreatedCase   bool     `json:"createdCase,omitempty"`
+
+	clientEmail, clientFirstName, clientLastName string
+	caseTitle, title                             string
+	caseID                                       uint
+	staff                                        models.User
+	start, end                                   time.Time
+	status                                       config.AppointmentStatus
+}
+
+// reject records a reason code for the row.
+func (r *appointmentImportRow) reject(reason string) {
+	r.Status = "rejected"
+	r.Errors = append(r.Errors, reason)
+}
+
+// rehearsed turns the outcome of a rolled-back import into a validation result: created rows
+// become valid, and ids of records that only existed in the rehearsal are cleared.
+// createdClient and createdCase are kept to show what the import would create.
+func (r *appointmentImportRow) rehearsed() {
+	if r.Status == "created" {
+		r.Status = "valid"
+	}
+	r.AppointmentID = 0
+	if r.CreatedClient {
+		r.ClientID = 0
+	}
+	if r.CreatedCase {
+		r.CaseID = 0
+	}
+}
+
+// ImportAppointments imports appointments from a CSV upload (multipart field "file") with the header
+// clientEmail, clientFirstName, clientLastName, caseId, caseTitle, staffEmail, start, end, status, title.
+// caseId or caseTitle is required; unknown clients (given a first name) and case titles are created.
+// With ?validateOnly=true (or the older ?dryRun=true) the import runs exactly as it would,
+// creation and in-transaction conflict checks included, inside a transaction that is rolled
+// back, so the report shows which rows a real import of the same file would create.
+func ImportAppointments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere un archivo CSV en el campo 'file'"})
+			return
+		}
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo", "message": err.Error()})
+			return
+		}
+		defer src.Close()
+
+		rows, err := parseAppointmentImportCSV(src)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV inválido", "message": err.Error()})
+			return
+		}
+		if len(rows) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo no contiene filas"})
+			return
+		}
+		if len(rows) > maxAppointmentImportRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El archivo excede el máximo de %d filas", maxAppointmentImportRows)})
+			return
+		}
+
+		if err := validateAppointmentImportRows(db, rows); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la importación", "message": err.Error()})
+			return
+		}
+
+		validateOnly := c.Query("validateOnly") == "true" || c.Query("dryRun") == "true"
+		createdBy := extractUserIDUint(c)
+		if validateOnly {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				importAppointmentRows(tx, rows, createdBy)
+				return errImportRehearsal
+			})
+			if !errors.Is(err, errImportRehearsal) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la importación", "message": err.Error()})
+				return
+			}
+			for _, row := range rows {
+				row.rehearsed()
+			}
+		} else {
+			importAppointmentRows(db, rows, createdBy)
+		}
+
+		counts := map[string]int{"valid": 0, "created": 0, "rejected": 0}
+		for _, row := range rows {
+			counts[row.Status]++
+		}
+		if !validateOnly {
+			recordAuditLog(db, c, "appointment", 0, "import", "", map[string]interface{}{
+				"file":     file.Filename,
+				"rows":     len(rows),
+				"created":  counts["created"],
+				"rejected": counts["rejected"],
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"validateOnly": validateOnly,
+			"dryRun":       validateOnly,
+			"total":        len(rows),
+			"valid":        counts["valid"],
+			"created":      counts["created"],
+			"rejected":     counts["rejected"],
+			"rows":         rows,
+		})
+	}
+}
+
+// parseAppointmentImportCSV reads the header and rows. Columns are matched by name,
+// case-insensitively; unknown columns are ignored. Field-level problems are recorded
+// on the row rather than failing the file.
+func parseAppointmentImportCSV(src io.Reader) ([]*appointmentImportRow, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer el encabezado: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"clientemail", "staffemail", "start", "end"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("falta la columna requerida %q", required)
+		}
+	}
+	if _, hasID := columns["caseid"]; !hasID {
+		if _, hasTitle := columns["casetitle"]; !hasTitle {
+			return nil, errors.New("se requiere la columna caseId o caseTitle")
+		}
+	}
+
+	rows := make([]*appointmentImportRow, 0)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %w", line, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue // Blank line
+		}
+
+		row := &appointmentImportRow{
+			Row:             line,
+			Status:          "valid",
+			clientEmail:     strings.ToLower(field("clientemail")),
+			clientFirstName: field("clientfirstname"),
+			clientLastName:  field("clientlastname"),
+			caseTitle:       field("casetitle"),
+			title:           field("title"),
+			status:          config.StatusConfirmed,
+		}
+		if v := field("caseid"); v != "" {
+			if id, err := strconv.ParseUint(v, 10, 32); err == nil && id > 0 {
+				row.caseID = uint(id)
+			} else {
+				row.reject("invalid_case_id")
+			}
+		} else if row.caseTitle == "" {
+			row.reject("missing_case")
+		}
+		if row.clientEmail == "" {
+			row.reject("missing_client_email")
+		}
+		if v := field("status"); v != "" {
+			if config.IsValidAppointmentStatus(strings.ToLower(v)) {
+				row.status = config.AppointmentStatus(strings.ToLower(v))
+			} else {
+				row.reject("invalid_status")
+			}
+		}
+
+		var startOK, endOK bool
+		row.start, startOK = parseAppointmentImportTime(field("start"))
+		row.end, endOK = parseAppointmentImportTime(field("end"))
+		if !startOK {
+			row.reject("invalid_start")
+		}
+		if !endOK {
+			row.reject("invalid_end")
+		}
+		if startOK && endOK && !row.end.After(row.start) {
+			row.reject("end_before_start")
+		}
+
+		// Stash the email in the staff record until references are resolved
+		row.staff.Email = strings.ToLower(field("staffemail"))
+		if row.staff.Email == "" {
+			row.reject("missing_staff_email")
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseAppointmentImportTime(value string) (time.Time, bool) {
+	for _, layout := range appointmentImportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// validateAppointmentImportRows resolves staff, clients and cases and rejects rows outside
+// working hours or on an office holiday, off the slot grid, or overlapping an existing appointment or an earlier row.
+func validateAppointmentImportRows(db *gorm.DB, rows []*appointmentImportRow) error {
+	workStart, workEnd := config.AppointmentWorkingHours()
+	workDays := config.AppointmentWorkingDays()
+	slots := loadSchedulingSettings(db)
+
+	staffByEmail := make(map[string]*models.User)
+	accepted := make(map[uint][]*appointmentImportRow) // Earlier valid rows per staff member
+	officeCategories := make(map[uint][]string)        // Enabled appointment categories per office, nil for all
+	officeCalendars := make(map[uint]officeCalendar)   // Business hours and holidays per office
+
+	for _, row := range rows {
+		// Staff
+		if row.staff.Email != "" {
+			staff, ok := staffByEmail[row.staff.Email]
+			if !ok {
+				var found models.User
+				err := db.Where("LOWER(email) = ?", row.staff.Email).First(&found).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err == nil {
+					staff = &found
+				}
+				staffByEmail[row.staff.Email] = staff
+			}
+			if staff == nil || !config.CanViewAppointments(staff.Role) {
+				row.reject("staff_not_found")
+			} else {
+				row.staff = *staff
+			}
+		}
+
+		// Client and case
+		var client models.User
+		clientFound := false
+		if row.clientEmail != "" {
+			err := db.Where("LOWER(email) = ?", row.clientEmail).First(&client).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			clientFound = err == nil
+			if clientFound && client.Role != "client" {
+				row.reject("client_email_not_client")
+			} else if !clientFound && row.clientFirstName == "" {
+				row.reject("client_not_found") // A first name is needed to create the client
+			}
+		}
+		if clientFound {
+			row.ClientID = client.ID
+		}
+
+		// Office and category the appointment will get, as in createImportedAppointment
+		_, _, category := importCategoriesForRole(row.staff.Role)
+		var officeID uint
+		if row.caseID != 0 {
+			var caseRecord models.Case
+			err := db.Select("id", "client_id", "office_id", "category").Where("id = ? AND deleted_at IS NULL", row.caseID).First(&caseRecord).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			switch {
+			case err != nil:
+				row.reject("case_not_found")
+			case caseRecord.ClientID == nil || !clientFound || *caseRecord.ClientID != client.ID:
+				row.reject("case_client_mismatch")
+			default:
+				row.CaseID = caseRecord.ID
+				officeID = caseRecord.OfficeID
+				if caseRecord.Category != "" {
+					category = caseRecord.Category
+				}
+			}
+		} else if row.staff.ID != 0 && (row.staff.OfficeID == nil || *row.staff.OfficeID == 0) {
+			row.reject("staff_without_office") // New cases are opened in the staff member's office
+		} else if row.staff.ID != 0 {
+			officeID = *row.staff.OfficeID
+		}
+		if officeID != 0 {
+			enabled, ok := officeCategories[officeID]
+			if !ok {
+				var err error
+				if enabled, err = officeAppointmentCategories(db, officeID); err != nil {
+					return err
+				}
+				officeCategories[officeID] = enabled
+			}
+			if !officeOffersAppointmentCategory(enabled, category) {
+				row.reject("category_not_offered")
+			}
+		}
+
+		// Working hours (the office's, once known) and slot grid
+		if !row.start.IsZero() && !row.end.IsZero() {
+			if officeID != 0 {
+				calendar, ok := officeCalendars[officeID]
+				if !ok {
+					var err error
+					if calendar, err = loadOfficeCalendar(db, officeID); err != nil {
+						return err
+					}
+					officeCalendars[officeID] = calendar
+				}
+				switch calendar.closure(row.start, row.end).Reason {
+				case "":
+				case officeClosedHoliday:
+					row.reject("office_holiday")
+				default:
+					row.reject("outside_working_hours")
+				}
+			} else {
+				startMinute := row.start.Hour()*60 + row.start.Minute()
+				endMinute := row.end.Hour()*60 + row.end.Minute()
+				sameDay := row.start.Year() == row.end.Year() && row.start.YearDay() == row.end.YearDay()
+				if !workDays[row.start.Weekday()] || !sameDay || startMinute < workStart || endMinute > workEnd {
+					row.reject("outside_working_hours")
+				}
+			}
+			if !alignedToSlot(row.start, slots.SlotMinutes) || (slots.AlignEndTime && !alignedToSlot(row.end, slots.SlotMinutes)) {
+				row.reject("misaligned_slot")
+			}
+		}
+
+		if row.Status == "rejected" {
+			continue
+		}
+
+		// Overlaps with earlier rows of this file, then with stored appointments
+		for _, other := range accepted[row.staff.ID] {
+			if row.start.Before(other.end) && other.start.Before(row.end) {
+				row.reject(fmt.Sprintf("conflict_with_row_%d", other.Row))
+				break
+			}
+		}
+		if row.Status == "rejected" {
+			continue
+		}
+		overlap, err := hasStaffAppointmentOverlap(db, row.staff.ID, row.start, row.end)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			row.reject("conflict")
+			continue
+		}
+		accepted[row.staff.ID] = append(accepted[row.staff.ID], row)
+	}
+	return nil
+}
+
+// importAppointmentRows creates the valid rows in batches of appointmentImportBatchSize.
+func importAppointmentRows(db *gorm.DB, rows []*appointmentImportRow, createdBy uint) {
+	for start := 0; start < len(rows); start += appointmentImportBatchSize {
+		end := start + appointmentImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		importAppointmentBatch(db, rows[start:end], createdBy)
+	}
+}
+
+// importAppointmentBatch creates the valid rows of one batch in a single transaction. If any
+// row fails, the batch is rolled back and all of its rows are reported as rejected.
+func importAppointmentBatch(db *gorm.DB, batch []*appointmentImportRow, createdBy uint) {
+	pending := make([]*appointmentImportRow, 0, len(batch))
+	for _, row := range batch {
+		if row.Status == "valid" {
+			pending = append(pending, row)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	var failed *appointmentImportRow
+	var failure string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range pending {
+			if reason, err := createImportedAppointment(tx, row, createdBy); err != nil || reason != "" {
+				failed, failure = row, reason
+				if failure == "" {
+					failure = "create_failed"
+				}
+				if err == nil {
+					err = errors.New(reason)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	for _, row := range pending {
+		if err == nil {
+			row.Status = "created"
+			continue
+		}
+		// Nothing from the batch was kept
+		if row.CreatedClient {
+			row.ClientID = 0
+		}
+		if row.CreatedCase {
+			row.CaseID = 0
+		}
+		row.AppointmentID, row.CreatedClient, row.CreatedCase = 0, false, false
+		if row == failed {
+			row.reject(failure)
+		} else {
+			row.reject("batch_rolled_back")
+		}
+	}
+}
+
+// createImportedAppointment finds or creates the row's client and case and creates the appointment.
+// It returns a reason code when the row can no longer be imported (e.g. a conflict that appeared
+// after validation), or an error for database failures.
+func createImportedAppointment(tx *gorm.DB, row *appointmentImportRow, createdBy uint) (string, error) {
+	// Re-check inside the transaction so concurrent bookings are not overlapped
+	overlap, err := hasStaffAppointmentOverlap(tx, row.staff.ID, row.start, row.end)
+	if err != nil {
+		return "", err
+	}
+	if overlap {
+		return "conflict", nil
+	}
+
+	var client models.User
+	err = tx.Where("LOWER(email) = ?", row.clientEmail).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost) // Placeholder password
+		if hashErr != nil {
+			return "", hashErr
+		}
+		client = models.User{
+			FirstName:          row.clientFirstName,
+			LastName:           row.clientLastName,
+			Email:              row.clientEmail,
+			Password:           string(hashedPassword),
+			Role:               "client",
+			OfficeID:           row.staff.OfficeID,
+			MustChangePassword: true,
+		}
+		if err := tx.Create(&client).Error; err != nil {
+			return "", err
+		}
+		row.CreatedClient = true
+	} else if err != nil {
+		return "", err
+	}
+	row.ClientID = client.ID
+
+	caseCategory, department, appointmentCategory := importCategoriesForRole(row.staff.Role)
+	var caseRecord models.Case
+	if row.caseID != 0 {
+		if err := tx.First(&caseRecord, row.caseID).Error; err != nil {
+			return "", err
+		}
+		if caseRecord.Category != "" {
+			appointmentCategory = caseRecord.Category
+		}
+	} else {
+		err := tx.Where("client_id = ? AND title = ? AND deleted_at IS NULL AND is_archived = ?", client.ID, row.caseTitle, false).
+			Order("id DESC").First(&caseRecord).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			caseRecord = models.Case{
+				ClientID:     &client.ID,
+				OfficeID:     *row.staff.OfficeID,
+				Title:        row.caseTitle,
+				Status:       "open",
+				Category:     caseCategory,
+				CurrentStage: "intake",
+				CreatedBy:    createdBy,
+			}
+			if caseCategory == "Familiar" || caseCategory == "Civil" {
+				caseRecord.CurrentStage = "etapa_inicial"
+			}
+			if err := tx.Create(&caseRecord).Error; err != nil {
+				return "", err
+			}
+			row.CreatedCase = true
+			if err := recordCaseStageChange(tx, &caseRecord, "", "", &createdBy); err != nil {
+				return "", err
+			}
+		} else if err != nil {
+			return "", err
+		}
+	}
+	row.CaseID = caseRecord.ID
+
+	title := row.title
+	if title == "" {
+		title = caseRecord.Title
+	}
+	appointment := models.Appointment{
+		CaseID:     caseRecord.ID,
+		StaffID:    row.staff.ID,
+		OfficeID:   caseRecord.OfficeID,
+		Title:      title,
+		StartTime:  row.start,
+		EndTime:    row.end,
+		Status:     row.status,
+		Category:   appointmentCategory,
+		Department: department,
+	}
+	if err := tx.Create(&appointment).Error; err != nil {
+		return "", err
+	}
+	row.AppointmentID = appointment.ID
+	if err := notifyAppointmentEvent(tx, models.WebhookEventAppointmentCreated, createdBy, appointment); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// importCategoriesForRole maps a staff role to the new case category, appointment department
+// and appointment category, following CreateAppointmentSmart.
+func importCategoriesForRole(role string) (caseCategory, department, appointmentCategory string) {
+	switch role {
+	case config.RoleLawyer:
+		return "Familiar", "Familiar", "Consulta Legal"
+	case config.RolePsychologist:
+		return "Psicologia", "Psicologia", "Sesion de Psicologia"
+	default:
+		return "General", "General", "General"
+	}
+}
@@ -0,0 +1,110 @@
+// api/handlers/appointment_month_summary.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// appointmentDaySummary is the appointment count for one calendar day, broken down by status.
+type appointmentDaySummary struct {
+	Date     string         `json:"date"`
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+// GetAppointmentMonthSummary returns per-day appointment counts for ?year=&month= (default: current month),
+// scoped like the appointment list, so month views can render badges without loading every appointment.
+func GetAppointmentMonthSummary(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		year, month := now.Year(), int(now.Month())
+		if v := c.Query("year"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 2000 || parsed > 2100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+				return
+			}
+			year = parsed
+		}
+		if v := c.Query("month"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > 12 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Mes inválido"})
+				return
+			}
+			month = parsed
+		}
+
+		from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location())
+		to := from.AddDate(0, 1, 0)
+
+		query, casesJoined := scopeAppointmentQuery(db, c, db.Table("appointments"))
+		if c.GetString("userRole") == "client" {
+			query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.client_id = ?", extractUserIDUint(c))
+			casesJoined = true
+		}
+		query = query.
+			Select("TO_CHAR(appointments.start_time, 'YYYY-MM-DD') AS day, appointments.status, COUNT(*) AS count").
+			Where("appointments.deleted_at IS NULL").
+			Where("appointments.start_time >= ? AND appointments.start_time < ?", from, to)
+
+		if status := c.Query("status"); status != "" {
+			query = query.Where("appointments.status = ?", status)
+		}
+		if department := c.Query("department"); department != "" {
+			query = query.Where("appointments.department = ?", department)
+		}
+		if category := c.Query("category"); category != "" {
+			if !casesJoined {
+				query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id")
+			}
+			query = query.Where("cases.category = ?", category)
+		}
+
+		var rows []struct {
+			Day    string
+			Status string
+			Count  int
+		}
+		if err := query.Group("day, appointments.status").Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el resumen mensual", "message": err.Error()})
+			return
+		}
+
+		byDay := make(map[string]*appointmentDaySummary)
+		total := 0
+		for _, row := range rows {
+			day, ok := byDay[row.Day]
+			if !ok {
+				day = &appointmentDaySummary{Date: row.Day, ByStatus: make(map[string]int)}
+				byDay[row.Day] = day
+			}
+			day.Total += row.Count
+			day.ByStatus[row.Status] += row.Count
+			total += row.Count
+		}
+
+		// One entry per day of the month, including empty days, in order
+		days := make([]appointmentDaySummary, 0, 31)
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			key := d.Format("2006-01-02")
+			if summary, ok := byDay[key]; ok {
+				days = append(days, *summary)
+			} else {
+				days = append(days, appointmentDaySummary{Date: key, ByStatus: map[string]int{}})
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"year":  year,
+			"month": month,
+			"days":  days,
+			"total": total,
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// api/handlers/appointment_reminders.go
+// Appointment reminders: a background worker that emails clients ahead of their confirmed
+// appointments, once per configured lead window (e.g. 24h and 1h before). The last reminder
+// sent is stored on the appointment, so restarts and other replicas never repeat one, and each
+// email is recorded in the client's communication log.
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/notifications"
+	"gorm.io/gorm"
+)
+
+// sendAppointmentReminder delivers one reminder; replaced in tests.
+var sendAppointmentReminder = notifications.SendAppointmentReminder
+
+// dueAppointmentReminder is an appointment whose reminder for stage is due.
+type dueAppointmentReminder struct {
+	Appointment models.Appointment
+	Stage       string
+}
+
+// StartAppointmentReminders sends due reminders every config.ReminderInterval until ctx is
+// cancelled. The returned channel is closed once the worker has stopped, after finishing the
+// batch in progress; it is closed immediately when reminders are disabled.
+func StartAppointmentReminders(ctx context.Context, db *gorm.DB) <-chan struct{} {
+	done := make(chan struct{})
+	interval := config.ReminderInterval()
+	leads := config.ReminderLeadTimes()
+	if interval <= 0 || len(leads) == 0 {
+		close(done)
+		return done
+	}
+	log.Printf("INFO: Appointment reminders every %s for lead times %v", interval, leads)
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if sent := sendDueAppointmentReminders(ctx, db, time.Now(), leads); sent > 0 {
+				log.Printf("INFO: Sent %d appointment reminder(s)", sent)
+			}
+			select {
+			case <-ctx.Done():
+				log.Println("INFO: Appointment reminder worker stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return done
+}
+
+// reminderStageLabel names the reminder for a lead time: "24h", "30m", or the full duration.
+func reminderStageLabel(lead time.Duration) string {
+	switch {
+	case lead%time.Hour == 0:
+		return fmt.Sprintf("%dh", lead/time.Hour)
+	case lead%time.Minute == 0:
+		return fmt.Sprintf("%dm", lead/time.Minute)
+	default:
+		return lead.String()
+	}
+}
+
+// reminderStageDue returns the stage of the reminder due at now for an appointment starting at
+// start, or "" when none is. The due stage is the shortest lead window (leads, longest first)
+// the appointment is already inside, so an appointment booked 30 minutes ahead only gets the
+// 1h reminder. Nothing is due when that stage, or a shorter one, was the last one sent.
+func reminderStageDue(start, now time.Time, sentStage string, leads []time.Duration) string {
+	remaining := start.Sub(now)
+	if remaining <= 0 {
+		return ""
+	}
+	var due time.Duration
+	for _, lead := range leads {
+		if remaining <= lead {
+			due = lead
+		}
+	}
+	if due == 0 {
+		return ""
+	}
+	if sentStage != "" {
+		// Stage labels are Go durations; an unparseable one counts as not sent
+		if sent, err := time.ParseDuration(sentStage); err == nil && sent <= due {
+			return ""
+		}
+	}
+	return reminderStageLabel(due)
+}
+
+// dueAppointmentReminders loads the confirmed appointments starting within the longest lead
+// window and returns those with a reminder due at now, soonest first.
+func dueAppointmentReminders(db *gorm.DB, now time.Time, leads []time.Duration) ([]dueAppointmentReminder, error) {
+	if len(leads) == 0 {
+		return nil, nil
+	}
+	shortest := reminderStageLabel(leads[len(leads)-1])
+
+	var appointments []models.Appointment
+	err := db.Preload("Case.Client").
+		Where("status = ? AND start_time > ? AND start_time <= ?", config.StatusConfirmed, now, now.Add(leads[0])).
+		Where("reminder_stage IS NULL OR reminder_stage <> ?", shortest).
+		Order("start_time").
+		Find(&appointments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]dueAppointmentReminder, 0, len(appointments))
+	for _, appointment := range appointments {
+		if stage := reminderStageDue(appointment.StartTime, now, appointment.ReminderStage, leads); stage != "" {
+			due = append(due, dueAppointmentReminder{Appointment: appointment, Stage: stage})
+		}
+	}
+	return due, nil
+}
+
+// claimAppointmentReminder records the reminder as sent before it goes out. The update only
+// matches while the appointment still has the stage and start time it was selected with, so a
+// reminder claimed concurrently by another replica, or an appointment rescheduled meanwhile,
+// is not sent twice.
+func claimAppointmentReminder(db *gorm.DB, reminder dueAppointmentReminder, now time.Time) (bool, error) {
+	appointment := reminder.Appointment
+	query := db.Model(&models.Appointment{}).
+		Where("id = ? AND status = ? AND start_time = ?", appointment.ID, config.StatusConfirmed, appointment.StartTime)
+	if appointment.ReminderStage == "" {
+		query = query.Where("reminder_stage IS NULL OR reminder_stage = ''")
+	} else {
+		query = query.Where("reminder_stage = ?", appointment.ReminderStage)
+	}
+	result := query.Updates(map[string]interface{}{"reminder_sent_at": now, "reminder_stage": reminder.Stage})
+	return result.RowsAffected == 1, result.Error
+}
+
+// sendDueAppointmentReminders claims and sends the reminders due at now in batches of
+// config.ReminderBatchSize, with up to config.ReminderConcurrency sends in flight and
+// config.ReminderBatchDelay between batches. Every send is recorded as a delivery to the
+// client. It stops between batches when ctx is cancelled and returns how many reminders were
+// sent.
+func sendDueAppointmentReminders(ctx context.Context, db *gorm.DB, now time.Time, leads []time.Duration) int {
+	due, err := dueAppointmentReminders(db, now, leads)
+	if err != nil {
+		log.Printf("WARNING: Failed to load appointments due a reminder: %v", err)
+		return 0
+	}
+
+	batchSize := config.ReminderBatchSize()
+	var sent int
+	var sentMutex sync.Mutex
+	for start := 0; start < len(due); start += batchSize {
+		if start > 0 {
+			select {
+			case <-ctx.Done():
+				return sent
+			case <-time.After(config.ReminderBatchDelay()):
+			}
+		}
+		if ctx.Err() != nil {
+			return sent
+		}
+
+		end := start + batchSize
+		if end > len(due) {
+			end = len(due)
+		}
+		slots := make(chan struct{}, config.ReminderConcurrency())
+		var wg sync.WaitGroup
+		for _, reminder := range due[start:end] {
+			claimed, err := claimAppointmentReminder(db, reminder, now)
+			if err != nil {
+				log.Printf("WARNING: Failed to record reminder for appointment %d: %v", reminder.Appointment.ID, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+			client := reminder.Appointment.Case.Client
+			if client == nil || client.Email == "" {
+				log.Printf("WARNING: Appointment %d has no client email; %s reminder skipped", reminder.Appointment.ID, reminder.Stage)
+				continue
+			}
+			if !notificationAllowed(db, client.ID, models.DeliveryChannelEmail, models.NotificationEventAppointments) {
+				continue // Opted out; the claim keeps the stage from coming due again
+			}
+
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(reminder dueAppointmentReminder, client models.User) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				err := sendAppointmentReminder(reminder.Appointment, client, reminder.Stage)
+				recordAppointmentEmail(db, reminder.Appointment, client, "appointment_reminder", "Recordatorio de su Cita en CAF", err)
+				if err != nil {
+					return
+				}
+				sentMutex.Lock()
+				sent++
+				sentMutex.Unlock()
+			}(reminder, *client)
+		}
+		wg.Wait()
+	}
+	return sent
+}
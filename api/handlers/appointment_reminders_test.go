@@ -0,0 +1,218 @@
+// api/handlers/appointment_reminders_test.go
+// Unit tests for appointment reminders: which lead window is due, the selection query, that a
+// reminder is never sent twice, and that every send is recorded in the communication log.
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+)
+
+var reminderLeads = []time.Duration{24 * time.Hour, time.Hour}
+
+func TestReminderStageDue(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		startsIn  time.Duration
+		sentStage string
+		want      string
+	}{
+		{"outside every window", 30 * time.Hour, "", ""},
+		{"inside the day window", 23 * time.Hour, "", "24h"},
+		{"day window boundary is inclusive", 24 * time.Hour, "", "24h"},
+		{"day reminder already sent", 23 * time.Hour, "24h", ""},
+		{"hour window after the day reminder", 50 * time.Minute, "24h", "1h"},
+		{"booked late only gets the hour reminder", 50 * time.Minute, "", "1h"},
+		{"hour reminder already sent", 50 * time.Minute, "1h", ""},
+		{"shorter reminder from an older config", 50 * time.Minute, "30m", ""},
+		{"longer reminder from an older config", 50 * time.Minute, "2h", "1h"},
+		{"unknown stage counts as not sent", 50 * time.Minute, "bogus", "1h"},
+		{"already started", -time.Minute, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reminderStageDue(now.Add(tt.startsIn), now, tt.sentStage, reminderLeads); got != tt.want {
+				t.Errorf("reminderStageDue(%s, %q) = %q, want %q", tt.startsIn, tt.sentStage, got, tt.want)
+			}
+		})
+	}
+}
+
+// reminderScript answers the appointment query with one confirmed appointment per entry of
+// startsIn (relative to now) and sentStages, each on a case whose client has an email.
+func reminderScript(now time.Time, startsIn []time.Duration, sentStages []string) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "appointments"`):
+				rows := make([][]driver.Value, 0, len(startsIn))
+				for i, startIn := range startsIn {
+					var stage driver.Value
+					if sentStages[i] != "" {
+						stage = sentStages[i]
+					}
+					rows = append(rows, []driver.Value{int64(i + 1), int64(7), "Consulta", now.Add(startIn), "confirmed", stage})
+				}
+				return []string{"id", "case_id", "title", "start_time", "status", "reminder_stage"}, rows
+			case strings.Contains(query, `FROM "cases"`):
+				return []string{"id", "client_id"}, [][]driver.Value{{int64(7), int64(3)}}
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "email", "first_name"}, [][]driver.Value{{int64(3), "ana@correo.mx", "Ana"}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// recordReminders replaces the sender for the rest of the test and returns the stages sent.
+func recordReminders(t *testing.T) func() []string {
+	t.Helper()
+	t.Setenv("REMINDER_BATCH_DELAY_MS", "0")
+	var mutex sync.Mutex
+	sent := make([]string, 0)
+	previous := sendAppointmentReminder
+	sendAppointmentReminder = func(appointment models.Appointment, client models.User, lead string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		sent = append(sent, lead)
+		return nil
+	}
+	t.Cleanup(func() { sendAppointmentReminder = previous })
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func TestDueAppointmentRemindersSelectsWindows(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	script := reminderScript(now,
+		[]time.Duration{20 * time.Minute, 5 * time.Hour, 12 * time.Hour},
+		[]string{"24h", "", "24h"})
+
+	due, err := dueAppointmentReminders(scriptedDB(t, script), now, reminderLeads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[0].Appointment.ID != 1 || due[0].Stage != "1h" || due[1].Appointment.ID != 2 || due[1].Stage != "24h" {
+		t.Fatalf("due = %+v, want appointment 1 (1h) and 2 (24h)", due)
+	}
+	if due[0].Appointment.Case.Client == nil || due[0].Appointment.Case.Client.Email != "ana@correo.mx" {
+		t.Errorf("client not loaded: %+v", due[0].Appointment.Case)
+	}
+
+	queries := script.ran(`FROM "appointments"`)
+	if len(queries) != 1 {
+		t.Fatalf("appointment queries = %v", queries)
+	}
+	for _, condition := range []string{"status = $1", "start_time > $2", "start_time <= $3", "reminder_stage IS NULL OR reminder_stage <> $4", `"appointments"."deleted_at" IS NULL`} {
+		if !strings.Contains(queries[0], condition) {
+			t.Errorf("selection query lacks %q: %s", condition, queries[0])
+		}
+	}
+}
+
+func TestSendDueAppointmentRemindersSkipsClaimedReminders(t *testing.T) {
+	sent := recordReminders(t)
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	script := reminderScript(now, []time.Duration{30 * time.Minute, 2 * time.Hour}, []string{"", ""})
+	// Another replica claimed the second reminder between the query and the claim
+	var claims int32
+	script.affected = func(query string) int64 {
+		if atomic.AddInt32(&claims, 1) == 1 {
+			return 1
+		}
+		return 0
+	}
+
+	if n := sendDueAppointmentReminders(context.Background(), scriptedDB(t, script), now, reminderLeads); n != 1 {
+		t.Fatalf("sent %d reminders, want 1", n)
+	}
+	if got := sent(); len(got) != 1 || got[0] != "1h" {
+		t.Errorf("sent stages = %v, want [1h]", got)
+	}
+	claimsRun := script.ran(`UPDATE "appointments" SET "reminder_sent_at"`)
+	if len(claimsRun) != 2 || !strings.Contains(claimsRun[0], "start_time = ") || !strings.Contains(claimsRun[0], "reminder_stage IS NULL OR reminder_stage = ''") {
+		t.Errorf("claims = %v", claimsRun)
+	}
+}
+
+func TestSendDueAppointmentRemindersAfterRestart(t *testing.T) {
+	sent := recordReminders(t)
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	// As stored by the previous process: both reminders already went out for their windows
+	script := reminderScript(now, []time.Duration{30 * time.Minute, 20 * time.Hour}, []string{"1h", "24h"})
+	script.affected = func(string) int64 { return 1 }
+
+	if n := sendDueAppointmentReminders(context.Background(), scriptedDB(t, script), now, reminderLeads); n != 0 {
+		t.Fatalf("sent %d reminders after restart, want 0", n)
+	}
+	if len(sent()) > 0 || len(script.ran("UPDATE")) > 0 {
+		t.Errorf("reminders resent: %v, updates %v", sent(), script.ran("UPDATE"))
+	}
+}
+
+func TestSendDueAppointmentRemindersStopsOnShutdown(t *testing.T) {
+	sent := recordReminders(t)
+	t.Setenv("REMINDER_BATCH_SIZE", "1")
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	script := reminderScript(now, []time.Duration{10 * time.Minute, 20 * time.Minute}, []string{"", ""})
+	script.affected = func(string) int64 { return 1 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n := sendDueAppointmentReminders(ctx, scriptedDB(t, script), now, reminderLeads); n != 0 || len(sent()) != 0 {
+		t.Errorf("sent %d reminders after shutdown", n)
+	}
+}
+
+func TestSendDueAppointmentRemindersRecordsDeliveries(t *testing.T) {
+	t.Setenv("REMINDER_BATCH_DELAY_MS", "0")
+	previous := sendAppointmentReminder
+	sendAppointmentReminder = func(appointment models.Appointment, client models.User, lead string) error {
+		if appointment.ID == 2 {
+			return errors.New("mailbox unavailable")
+		}
+		return nil
+	}
+	t.Cleanup(func() { sendAppointmentReminder = previous })
+
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	script := reminderScript(now, []time.Duration{30 * time.Minute, 20 * time.Hour}, []string{"", ""})
+	script.affected = func(string) int64 { return 1 }
+	var mutex sync.Mutex
+	statuses := map[string]bool{}
+	script.observe = func(query string, args []driver.Value) {
+		if !strings.HasPrefix(query, `INSERT INTO "notification_deliveries"`) {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, arg := range args {
+			if status, ok := arg.(string); ok && (status == models.DeliveryStatusSimulated || status == models.DeliveryStatusFailed) {
+				statuses[status] = true
+			}
+		}
+	}
+
+	if n := sendDueAppointmentReminders(context.Background(), scriptedDB(t, script), now, reminderLeads); n != 1 {
+		t.Fatalf("sent %d reminders, want 1", n)
+	}
+	if deliveries := script.ran(`INSERT INTO "notification_deliveries"`); len(deliveries) != 2 {
+		t.Fatalf("%d deliveries recorded, want 2", len(deliveries))
+	}
+	// Placeholders only log the email, and the failed send is recorded as such
+	if !statuses[models.DeliveryStatusSimulated] || !statuses[models.DeliveryStatusFailed] {
+		t.Errorf("delivery statuses = %v", statuses)
+	}
+}
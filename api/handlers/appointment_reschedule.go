@@ -0,0 +1,241 @@
+// api/handlers/appointment_reschedule.go
+// Appointment rescheduling: moving an appointment to new times is its own action, recorded in
+// appointment_reschedules and on the case timeline, and the client is told the old and new times.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/middleware"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// rescheduleTimeFormat renders appointment times in reschedule messages.
+const rescheduleTimeFormat = "02/01/2006 a las 15:04"
+
+// rescheduleAppointmentInput is the body of POST /appointments/:id/reschedule.
+type rescheduleAppointmentInput struct {
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Reason    string    `json:"reason"`
+
+	// OverrideBuffer lets admins reschedule despite an insufficient travel buffer between offices.
+	OverrideBuffer bool `json:"overrideBuffer,omitempty"`
+}
+
+// RescheduleAppointment moves an appointment to new start and end times. The new times go
+// through the same checks as a new booking (slot alignment, office hours, travel buffer and
+// the staff member's other appointments); cancelled and completed appointments cannot be moved.
+// The reminders are due again for the new time.
+func RescheduleAppointment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appointmentID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input rescheduleAppointmentInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !input.EndTime.After(input.StartTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La hora de fin debe ser posterior a la de inicio"})
+			return
+		}
+		if !enforceSlotAlignment(c, db, input.StartTime, input.EndTime) {
+			return
+		}
+
+		currentUser, _ := c.Get("currentUser")
+		user, _ := currentUser.(models.User)
+
+		var appointment models.Appointment
+		query := db.Preload("Case")
+		if middleware.IsStaffRole(user.Role) {
+			// Office managers can reschedule every appointment in their office, other staff
+			// those assigned to them or to their department
+			if user.Role == config.RoleOfficeManager && user.OfficeID != nil {
+				query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.office_id = ?", user.OfficeID)
+			} else if user.Department != nil {
+				query = query.Where("appointments.department = ? OR appointments.staff_id = ?", user.Department, user.ID)
+			} else {
+				query = query.Where("appointments.staff_id = ?", user.ID)
+			}
+		}
+		if err := query.First(&appointment, appointmentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Cita no encontrada o acceso denegado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la cita", "message": err.Error()})
+			return
+		}
+		if appointment.Status == config.StatusCancelled || appointment.Status == config.StatusCompleted {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  "No se puede reprogramar una cita cancelada o completada",
+				"status": appointment.Status,
+			})
+			return
+		}
+		if input.StartTime.Equal(appointment.StartTime) && input.EndTime.Equal(appointment.EndTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La cita ya está programada en ese horario"})
+			return
+		}
+
+		officeID := appointment.OfficeID
+		if officeID == 0 {
+			officeID = appointment.Case.OfficeID
+		}
+		if !enforceOfficeOpen(c, db, officeID, input.StartTime, input.EndTime) {
+			return
+		}
+		if _, ok := enforceOfficeBuffer(c, db, appointment.StaffID, officeID, input.StartTime, input.EndTime, appointment.ID, input.OverrideBuffer); !ok {
+			return
+		}
+
+		previousStart, previousEnd := appointment.StartTime, appointment.EndTime
+		rescheduledBy := extractUserID(c)
+		reschedule := models.AppointmentReschedule{
+			AppointmentID:     appointment.ID,
+			CaseID:            appointment.CaseID,
+			OfficeID:          officeID,
+			StaffID:           appointment.StaffID,
+			PreviousStartTime: previousStart,
+			PreviousEndTime:   previousEnd,
+			NewStartTime:      input.StartTime,
+			NewEndTime:        input.EndTime,
+			Reason:            strings.TrimSpace(input.Reason),
+			RescheduledBy:     rescheduledBy,
+			RescheduledAt:     time.Now(),
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			overlaps, ok := enforceStaffAvailability(c, tx, appointment.StaffID, input.StartTime, input.EndTime, appointment.ID)
+			if !ok {
+				return errAppointmentConflict
+			}
+			if err := tx.Model(&appointment).Updates(map[string]interface{}{
+				"start_time":       input.StartTime,
+				"end_time":         input.EndTime,
+				"reminder_sent_at": nil,
+				"reminder_stage":   "",
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&reschedule).Error; err != nil {
+				return err
+			}
+			appointment.StartTime, appointment.EndTime = input.StartTime, input.EndTime
+			if err := tx.Create(rescheduleCaseEvent(&appointment, reschedule)).Error; err != nil {
+				return err
+			}
+			return recordForcedOverlap(tx, c, &appointment, overlaps)
+		})
+		if errors.Is(err, errAppointmentConflict) {
+			return // Response already written
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reprogramar la cita", "message": err.Error()})
+			return
+		}
+		invalidateCache(strconv.FormatUint(uint64(appointment.CaseID), 10))
+
+		notifyClientOfReschedule(db, appointment, previousStart, previousEnd)
+		appointmentLink := "/app/appointments"
+		NotifyAdminsForAppointment(db, "reprogramada", appointment.ID, appointment.Title, string(appointment.Status), appointment.StartTime, &appointmentLink)
+		BroadcastNotification(gin.H{
+			"type": "appointment_rescheduled",
+			"appointment": gin.H{
+				"id":                appointment.ID,
+				"title":             appointment.Title,
+				"status":            appointment.Status,
+				"startTime":         appointment.StartTime,
+				"endTime":           appointment.EndTime,
+				"previousStartTime": previousStart,
+				"previousEndTime":   previousEnd,
+				"rescheduledBy": gin.H{
+					"id":   user.ID,
+					"name": fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+					"role": user.Role,
+				},
+				"rescheduledAt": reschedule.RescheduledAt,
+			},
+			"message":   fmt.Sprintf("Cita '%s' reprogramada por %s %s", appointment.Title, user.FirstName, user.LastName),
+			"timestamp": time.Now(),
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"appointment": appointment,
+			"reschedule":  reschedule,
+		})
+	}
+}
+
+// rescheduleCaseEvent builds the timeline entry of a reschedule. It is visible to the client,
+// who is notified of the new time anyway.
+func rescheduleCaseEvent(appointment *models.Appointment, reschedule models.AppointmentReschedule) *models.CaseEvent {
+	description := fmt.Sprintf("Cita #%d (%s) reprogramada del %s al %s", appointment.ID, appointment.Title,
+		reschedule.PreviousStartTime.Format(rescheduleTimeFormat), reschedule.NewStartTime.Format(rescheduleTimeFormat))
+	if reschedule.Reason != "" {
+		description += ": " + reschedule.Reason
+	}
+	var userID uint
+	if reschedule.RescheduledBy != nil {
+		userID = *reschedule.RescheduledBy
+	}
+	return &models.CaseEvent{
+		CaseID:      appointment.CaseID,
+		UserID:      userID,
+		EventType:   "appointment_rescheduled",
+		Visibility:  "client_visible",
+		Description: description,
+		Metadata: map[string]interface{}{
+			"appointmentId":     appointment.ID,
+			"previousStartTime": reschedule.PreviousStartTime,
+			"previousEndTime":   reschedule.PreviousEndTime,
+			"newStartTime":      reschedule.NewStartTime,
+			"newEndTime":        reschedule.NewEndTime,
+		},
+	}
+}
+
+// notifyClientOfReschedule stores a notification for the case's client with the old and new
+// times and pushes it over their WebSocket connections. Failures are logged; the reschedule
+// has already been saved.
+func notifyClientOfReschedule(db *gorm.DB, appointment models.Appointment, previousStart, previousEnd time.Time) {
+	if appointment.Case.ClientID == nil || *appointment.Case.ClientID == 0 {
+		return
+	}
+	clientID := *appointment.Case.ClientID
+	if !notificationAllowed(db, clientID, models.DeliveryChannelInApp, models.NotificationEventAppointments) {
+		return
+	}
+	message := fmt.Sprintf("Su cita '%s' fue reprogramada del %s al %s.", appointment.Title,
+		previousStart.Format(rescheduleTimeFormat), appointment.StartTime.Format(rescheduleTimeFormat))
+	link := "/app/cases/" + strconv.FormatUint(uint64(appointment.CaseID), 10)
+	entityID := appointment.ID
+	dedupKey := fmt.Sprintf("appointment-rescheduled:%d:%d", appointment.ID, appointment.StartTime.Unix())
+	if err := CreateNotificationWithMeta(db, clientID, message, "info", &link, "appointment", &entityID, dedupKey); err != nil {
+		log.Printf("WARNING: Failed to notify client %d of rescheduled appointment %d: %v", clientID, appointment.ID, err)
+		return
+	}
+	SendUserNotification(strconv.FormatUint(uint64(clientID), 10), map[string]interface{}{
+		"message":           message,
+		"type":              "info",
+		"link":              &link,
+		"entityType":        "appointment",
+		"entityId":          &entityID,
+		"previousStartTime": previousStart,
+		"previousEndTime":   previousEnd,
+		"startTime":         appointment.StartTime,
+		"endTime":           appointment.EndTime,
+	})
+}
@@ -0,0 +1,152 @@
+// api/handlers/appointment_reschedule_test.go
+// Unit tests for appointment rescheduling: the client is notified with the old and new times,
+// and a move onto the staff member's other appointments is rejected.
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// rescheduleScript serves appointment 5 ("Audiencia inicial", in the given status) of case 7,
+// whose client is user 30, booked with staff member 4 on 2 June 2025 from 9:00 to 10:00.
+// When busy is true, staff member 4 has appointment 6 from 10:30 to 11:30. values receives
+// the columns of each INSERT and UPDATE, keyed by table.
+func rescheduleScript(status string, busy bool, values map[string][]map[string]driver.Value) *scriptedSQL {
+	var mutex sync.Mutex
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	return &scriptedSQL{
+		observe: func(query string, args []driver.Value) {
+			if !strings.HasPrefix(query, "INSERT") && !strings.HasPrefix(query, "UPDATE") {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			table := strings.Fields(query)[2]
+			if strings.HasPrefix(query, "UPDATE") {
+				table = strings.Fields(query)[1]
+			}
+			values[strings.Trim(table, `"`)] = append(values[strings.Trim(table, `"`)], statementValues(query, args))
+		},
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "appointments"`) && strings.Contains(query, "staff_id = $1 AND start_time <"):
+				if !busy {
+					return nil, nil
+				}
+				return []string{"id", "title", "staff_id", "start_time", "end_time", "status"}, [][]driver.Value{
+					{int64(6), "Terapia", int64(4), start.Add(90 * time.Minute), start.Add(150 * time.Minute), "confirmed"},
+				}
+			case strings.HasPrefix(query, `SELECT * FROM "appointments"`):
+				return []string{"id", "case_id", "staff_id", "office_id", "title", "start_time", "end_time", "status"}, [][]driver.Value{
+					{int64(5), int64(7), int64(4), int64(0), "Audiencia inicial", start, start.Add(time.Hour), status},
+				}
+			case strings.HasPrefix(query, `SELECT * FROM "cases"`):
+				return []string{"id", "client_id", "office_id", "title"}, [][]driver.Value{{int64(7), int64(30), int64(0), "Divorcio"}}
+			case strings.HasPrefix(query, "INSERT"):
+				return []string{"id"}, [][]driver.Value{{int64(1)}}
+			}
+			return nil, nil
+		},
+		affected: func(string) int64 { return 1 },
+	}
+}
+
+// runReschedule posts body to the reschedule endpoint of appointment 5 as admin 1.
+func runReschedule(t *testing.T, script *scriptedSQL, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/appointments/5/reschedule", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	c.Set("currentUser", models.User{ID: 1, Role: "admin", FirstName: "Ana", LastName: "López"})
+	RescheduleAppointment(scriptedDB(t, script))(c)
+	return w
+}
+
+func TestRescheduleAppointmentNotifiesClient(t *testing.T) {
+	values := make(map[string][]map[string]driver.Value)
+	script := rescheduleScript("confirmed", false, values)
+	w := runReschedule(t, script, `{"startTime":"2025-06-03T11:00:00Z","endTime":"2025-06-03T12:00:00Z","reason":"Solicitud del cliente"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	// The times move and the reminders are due again; the status stays as it was
+	updates := values["appointments"]
+	if len(updates) != 1 {
+		t.Fatalf("appointment updates = %v", script.ran(`UPDATE "appointments"`))
+	}
+	if _, ok := updates[0]["status"]; ok || updates[0]["reminder_stage"] != "" || updates[0]["reminder_sent_at"] != nil {
+		t.Errorf("appointment update = %v", updates[0])
+	}
+
+	// The reschedule is logged apart from other edits, and on the case timeline
+	if logged := values["appointment_reschedules"]; len(logged) != 1 || intArg(logged[0]["appointment_id"]) != 5 || logged[0]["reason"] != "Solicitud del cliente" {
+		t.Errorf("reschedule rows = %v", logged)
+	}
+	events := values["case_events"]
+	if len(events) != 1 || events[0]["event_type"] != "appointment_rescheduled" {
+		t.Fatalf("case events = %v", events)
+	}
+	if description, _ := events[0]["description"].(string); !strings.Contains(description, "02/06/2025 a las 09:00") || !strings.Contains(description, "03/06/2025 a las 11:00") {
+		t.Errorf("case event description = %q", description)
+	}
+
+	// The client is told both times
+	var client map[string]driver.Value
+	for _, notification := range values["notifications"] {
+		if intArg(notification["user_id"]) == 30 {
+			client = notification
+		}
+	}
+	if client == nil {
+		t.Fatalf("client not notified: %v", values["notifications"])
+	}
+	message, _ := client["message"].(string)
+	if !strings.Contains(message, "02/06/2025 a las 09:00") || !strings.Contains(message, "03/06/2025 a las 11:00") {
+		t.Errorf("client notification = %q", message)
+	}
+	if client["entity_type"] != "appointment" || intArg(client["entity_id"]) != 5 {
+		t.Errorf("client notification entity = %v", client)
+	}
+}
+
+func TestRescheduleAppointmentRejectsConflict(t *testing.T) {
+	values := make(map[string][]map[string]driver.Value)
+	script := rescheduleScript("confirmed", true, values)
+	w := runReschedule(t, script, `{"startTime":"2025-06-02T10:00:00Z","endTime":"2025-06-02T11:00:00Z"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"appointmentId":6`) {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(values) != 0 {
+		t.Errorf("conflicting reschedule wrote %v", values)
+	}
+	if len(script.ran("ROLLBACK")) != 1 {
+		t.Errorf("transaction not rolled back: %v", script.ran(""))
+	}
+
+	// Cancelled and completed appointments stay where they are
+	for _, status := range []string{"cancelled", "completed"} {
+		script := rescheduleScript(status, false, values)
+		if w := runReschedule(t, script, `{"startTime":"2025-06-03T11:00:00Z","endTime":"2025-06-03T12:00:00Z"}`); w.Code != http.StatusConflict || len(values) != 0 {
+			t.Errorf("%s: status %d, writes %v", status, w.Code, values)
+		}
+	}
+	// End before start
+	if w := runReschedule(t, rescheduleScript("confirmed", false, values), `{"startTime":"2025-06-03T11:00:00Z","endTime":"2025-06-03T10:00:00Z"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("end before start: status %d", w.Code)
+	}
+}
@@ -0,0 +1,219 @@
+// api/handlers/appointment_series.go
+// Recurring appointments: expanding a recurrence into a series of slots and cancelling a
+// series as a whole. Series members share a series_id.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAppointmentSeriesOccurrences caps how many appointments a single recurrence may create.
+const maxAppointmentSeriesOccurrences = 52
+
+// Supported recurrence frequencies.
+const (
+	recurrenceWeekly   = "weekly"
+	recurrenceBiweekly = "biweekly"
+	recurrenceMonthly  = "monthly"
+)
+
+// AppointmentRecurrence repeats an appointment. Exactly one of Count (total appointments,
+// including the first) or Until (last date, inclusive, "YYYY-MM-DD") is required.
+type AppointmentRecurrence struct {
+	Frequency string `json:"frequency"`
+	Count     int    `json:"count"`
+	Until     string `json:"until"`
+}
+
+// appointmentSlot is the start and end of one appointment in a series.
+type appointmentSlot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// appointmentOccurrences expands a recurrence starting with the slot start–end. Dates are
+// stepped in loc so every appointment keeps the same wall-clock time across DST changes, and
+// each lasts as long as the first. Monthly series stay on the first appointment's day,
+// falling back to the last day of shorter months.
+func appointmentOccurrences(start, end time.Time, rec AppointmentRecurrence, loc *time.Location) ([]appointmentSlot, error) {
+	switch rec.Frequency {
+	case recurrenceWeekly, recurrenceBiweekly, recurrenceMonthly:
+	default:
+		return nil, errors.New("recurrence frequency must be weekly, biweekly or monthly")
+	}
+	if (rec.Count > 0) == (rec.Until != "") {
+		return nil, errors.New("recurrence requires either count or until")
+	}
+	if rec.Count < 0 {
+		return nil, errors.New("recurrence count must be positive")
+	}
+	if rec.Count > maxAppointmentSeriesOccurrences {
+		return nil, fmt.Errorf("a series is limited to %d appointments", maxAppointmentSeriesOccurrences)
+	}
+
+	first := start.In(loc)
+	var untilYear int
+	var untilMonth time.Month
+	var untilDay int
+	if rec.Until != "" {
+		until, err := time.ParseInLocation("2006-01-02", rec.Until, loc)
+		if err != nil {
+			return nil, errors.New("recurrence until must be a date in YYYY-MM-DD format")
+		}
+		untilYear, untilMonth, untilDay = until.Date()
+		if !onOrBeforeDate(first, untilYear, untilMonth, untilDay) {
+			return nil, errors.New("recurrence until is before the first appointment")
+		}
+	}
+
+	duration := end.Sub(start)
+	year, month, day := first.Date()
+	hour, minute, sec := first.Clock()
+	slots := make([]appointmentSlot, 0, rec.Count)
+	for i := 0; ; i++ {
+		var next time.Time
+		switch rec.Frequency {
+		case recurrenceWeekly:
+			next = wallClockTime(year, month, day+7*i, hour, minute, sec, first.Nanosecond(), loc)
+		case recurrenceBiweekly:
+			next = wallClockTime(year, month, day+14*i, hour, minute, sec, first.Nanosecond(), loc)
+		case recurrenceMonthly:
+			targetMonth := time.Date(year, month+time.Month(i), 1, 0, 0, 0, 0, loc)
+			targetDay := day
+			if last := daysInMonth(targetMonth.Year(), targetMonth.Month(), loc); targetDay > last {
+				targetDay = last
+			}
+			next = wallClockTime(targetMonth.Year(), targetMonth.Month(), targetDay, hour, minute, sec, first.Nanosecond(), loc)
+		}
+
+		if rec.Count > 0 && len(slots) == rec.Count {
+			break
+		}
+		if rec.Until != "" && !onOrBeforeDate(next, untilYear, untilMonth, untilDay) {
+			break
+		}
+		if len(slots) == maxAppointmentSeriesOccurrences {
+			return nil, fmt.Errorf("a series is limited to %d appointments", maxAppointmentSeriesOccurrences)
+		}
+		slots = append(slots, appointmentSlot{Start: next, End: next.Add(duration)})
+	}
+	return slots, nil
+}
+
+// wallClockTime is time.Date for a wall-clock time in loc, except that a time skipped when
+// clocks go forward is read with the offset in force before the change, so 02:30 on a night
+// that jumps from 02:00 to 03:00 becomes 03:30 rather than an hour earlier.
+func wallClockTime(year int, month time.Month, day, hour, minute, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, minute, sec, nsec, loc)
+	if t.Hour() == hour && t.Minute() == minute {
+		return t
+	}
+	_, offsetBefore := t.Add(-12 * time.Hour).Zone()
+	asUTC := time.Date(year, month, day, hour, minute, sec, nsec, time.UTC)
+	return asUTC.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+}
+
+// onOrBeforeDate reports whether t falls on or before the given calendar date in t's location.
+func onOrBeforeDate(t time.Time, year int, month time.Month, day int) bool {
+	y, m, d := t.Date()
+	if y != year {
+		return y < year
+	}
+	if m != month {
+		return m < month
+	}
+	return d <= day
+}
+
+// daysInMonth returns the number of days in the month.
+func daysInMonth(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+// CancelAppointmentSeries cancels the upcoming appointments of a series in a single update
+// and records a single audit entry for the series. Past and completed appointments are kept.
+func CancelAppointmentSeries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seriesID := c.Param("seriesId")
+		currentUser, _ := c.Get("currentUser")
+		user := currentUser.(models.User)
+
+		var appointments []models.Appointment
+		if err := db.Where("series_id = ?", seriesID).Order("start_time").Find(&appointments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al recuperar la serie", "message": err.Error()})
+			return
+		}
+		if len(appointments) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Serie de citas no encontrada"})
+			return
+		}
+		if !canCancelAppointmentSeries(user, appointments[0]) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Serie de citas no encontrada o acceso denegado"})
+			return
+		}
+
+		now := time.Now()
+		cancelledIDs := make([]uint, 0, len(appointments))
+		cancelled := make([]models.Appointment, 0, len(appointments))
+		for _, appointment := range appointments {
+			if appointment.StartTime.After(now) && appointment.Status != config.StatusCompleted && appointment.Status != config.StatusCancelled {
+				cancelledIDs = append(cancelledIDs, appointment.ID)
+				cancelled = append(cancelled, appointment)
+			}
+		}
+		if len(cancelledIDs) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "La serie no tiene citas próximas por cancelar", "seriesId": seriesID})
+			return
+		}
+
+		if err := db.Model(&models.Appointment{}).
+			Where("id IN ?", cancelledIDs).
+			Updates(map[string]interface{}{"status": config.StatusCancelled, "deleted_at": now}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la serie", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "appointment_series", appointments[0].ID, "cancel", "appointment_series", map[string]interface{}{
+			"seriesId":       seriesID,
+			"appointmentIds": cancelledIDs,
+			"cancelled":      len(cancelledIDs),
+		})
+
+		link := "/app/appointments"
+		first := appointments[0]
+		NotifyAdminsForAppointment(db, fmt.Sprintf("cancelada (serie de %d citas)", len(cancelledIDs)), first.ID, first.Title, string(config.StatusCancelled), first.StartTime, &link)
+		notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, user.ID, cancelled...)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Serie de citas cancelada exitosamente",
+			"seriesId":       seriesID,
+			"appointmentIds": cancelledIDs,
+			"cancelled":      len(cancelledIDs),
+			"kept":           len(appointments) - len(cancelledIDs),
+			"cancelledAt":    now,
+		})
+	}
+}
+
+// canCancelAppointmentSeries applies the single-appointment delete rules to a series, using
+// one of its appointments (they share staff, office and department): admins cancel any series,
+// office managers those in their office and other staff their own or their department's.
+func canCancelAppointmentSeries(user models.User, appointment models.Appointment) bool {
+	switch {
+	case user.Role == config.RoleAdmin:
+		return true
+	case user.Role == config.RoleOfficeManager:
+		return user.OfficeID == nil || appointment.OfficeID == *user.OfficeID
+	case appointment.StaffID == user.ID:
+		return true
+	default:
+		return user.Department != nil && *user.Department == appointment.Department
+	}
+}
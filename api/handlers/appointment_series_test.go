@@ -0,0 +1,177 @@
+// api/handlers/appointment_series_test.go
+// Unit tests for expanding recurring appointments, including until dates on DST changes and
+// series expanded in the case office's time zone.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+// assertSlotStarts checks the wall-clock starts of slots in loc and that each keeps duration.
+func assertSlotStarts(t *testing.T, slots []appointmentSlot, loc *time.Location, duration time.Duration, want ...string) {
+	t.Helper()
+	if len(slots) != len(want) {
+		t.Fatalf("got %d slots, want %d: %v", len(slots), len(want), slots)
+	}
+	for i, slot := range slots {
+		if got := slot.Start.In(loc).Format("2006-01-02 15:04 MST"); got != want[i] {
+			t.Errorf("slot %d starts %s, want %s", i, got, want[i])
+		}
+		if got := slot.End.Sub(slot.Start); got != duration {
+			t.Errorf("slot %d lasts %v, want %v", i, got, duration)
+		}
+	}
+}
+
+func TestAppointmentOccurrencesUntilSpringForward(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	// Clocks go forward on Sunday 2026-03-08; the series ends that same day
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, ny)
+	slots, err := appointmentOccurrences(start, start.Add(time.Hour), AppointmentRecurrence{Frequency: recurrenceWeekly, Until: "2026-03-08"}, ny)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, ny, time.Hour, "2026-03-01 10:00 EST", "2026-03-08 10:00 EDT")
+}
+
+func TestAppointmentOccurrencesUntilFallBack(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	// Clocks go back on Sunday 2026-11-01; the start is given in UTC, as clients usually send it
+	start := time.Date(2026, 10, 18, 13, 30, 0, 0, time.UTC) // 09:30 EDT
+	slots, err := appointmentOccurrences(start, start.Add(45*time.Minute), AppointmentRecurrence{Frequency: recurrenceBiweekly, Until: "2026-11-01"}, ny)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, ny, 45*time.Minute, "2026-10-18 09:30 EDT", "2026-11-01 09:30 EST")
+	if got := slots[1].Start.UTC().Hour(); got != 14 {
+		t.Errorf("after fall back the slot starts at %d:30 UTC, want 14:30", got)
+	}
+}
+
+func TestAppointmentOccurrencesUntilComparesLocalDate(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	// 23:30 EDT on the DST day is already the next day in UTC, but still on the until date locally
+	start := time.Date(2026, 3, 1, 23, 30, 0, 0, ny)
+	slots, err := appointmentOccurrences(start, start.Add(30*time.Minute), AppointmentRecurrence{Frequency: recurrenceWeekly, Until: "2026-03-08"}, ny)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, ny, 30*time.Minute, "2026-03-01 23:30 EST", "2026-03-08 23:30 EDT")
+
+	// An until date the day before fall back stops the series short of the transition
+	start = time.Date(2026, 10, 25, 0, 30, 0, 0, ny)
+	slots, err = appointmentOccurrences(start, start.Add(30*time.Minute), AppointmentRecurrence{Frequency: recurrenceWeekly, Until: "2026-10-31"}, ny)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, ny, 30*time.Minute, "2026-10-25 00:30 EDT")
+}
+
+func TestAppointmentOccurrencesSkippedHourStaysOnUntilDate(t *testing.T) {
+	ny := mustLoadLocation(t, "America/New_York")
+	// 02:30 does not exist on 2026-03-08; the slot moves to 03:30 EDT the same day
+	start := time.Date(2026, 3, 1, 2, 30, 0, 0, ny)
+	slots, err := appointmentOccurrences(start, start.Add(time.Hour), AppointmentRecurrence{Frequency: recurrenceWeekly, Until: "2026-03-08"}, ny)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, ny, time.Hour, "2026-03-01 02:30 EST", "2026-03-08 03:30 EDT")
+}
+
+func TestAppointmentOccurrencesMonthlyClampsToMonthEnd(t *testing.T) {
+	start := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	slots, err := appointmentOccurrences(start, start.Add(time.Hour), AppointmentRecurrence{Frequency: recurrenceMonthly, Count: 4}, time.UTC)
+	if err != nil {
+		t.Fatalf("appointmentOccurrences: %v", err)
+	}
+	assertSlotStarts(t, slots, time.UTC, time.Hour, "2026-01-31 09:00 UTC", "2026-02-28 09:00 UTC", "2026-03-31 09:00 UTC", "2026-04-30 09:00 UTC")
+}
+
+func TestAppointmentOccurrencesRejectsInvalidRecurrence(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tests := map[string]AppointmentRecurrence{
+		"unknown frequency":    {Frequency: "daily", Count: 3},
+		"count and until":      {Frequency: recurrenceWeekly, Count: 3, Until: "2026-04-01"},
+		"neither":              {Frequency: recurrenceWeekly},
+		"negative count":       {Frequency: recurrenceWeekly, Count: -1},
+		"count over limit":     {Frequency: recurrenceWeekly, Count: maxAppointmentSeriesOccurrences + 1},
+		"until over limit":     {Frequency: recurrenceWeekly, Until: "2028-03-02"},
+		"until before start":   {Frequency: recurrenceWeekly, Until: "2026-03-01"},
+		"until not a YYYYMMDD": {Frequency: recurrenceWeekly, Until: "03/30/2026"},
+	}
+	for name, rec := range tests {
+		if _, err := appointmentOccurrences(start, start.Add(time.Hour), rec, time.UTC); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCreateAppointmentSmartExpandsSeriesInOfficeTimezone(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Ciudad_Juarez")
+	t.Setenv("APPOINTMENT_MIN_DURATION_MINUTES", "15")
+	t.Setenv("APPOINTMENT_MAX_DURATION_MINUTES", "180")
+	// Case 7 is at office 2 in Ciudad Juárez, which leaves DST on November 2, 2025
+	script := staffMatchScript(2, "Familiar")
+	caseAndStaff := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, `FROM "offices"`) {
+			return []string{"id", "timezone"}, [][]driver.Value{{int64(2), "America/Ciudad_Juarez"}}
+		}
+		return caseAndStaff(query, args)
+	}
+	var starts []time.Time
+	script.observe = func(query string, args []driver.Value) {
+		if strings.HasPrefix(query, `INSERT INTO "appointments"`) {
+			for _, arg := range args {
+				if at, ok := arg.(time.Time); ok {
+					starts = append(starts, at)
+					break
+				}
+			}
+		}
+	}
+
+	start := time.Date(2025, 10, 28, 10, 0, 0, 0, loc)
+	body, _ := json.Marshal(map[string]interface{}{
+		"caseId": 7, "staffId": 4, "title": "Terapia", "status": "confirmed",
+		"category": "General", "department": "Familiar", "overridePast": true,
+		"startTime": start.UTC().Format(time.RFC3339), "endTime": start.Add(time.Hour).UTC().Format(time.RFC3339),
+		"recurrence": map[string]interface{}{"frequency": "weekly", "count": 2},
+	})
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/appointments", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	CreateAppointmentSmart(scriptedDB(t, script))(c)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	// Both keep 10:00 at the office, whatever the server's zone
+	slots := make([]appointmentSlot, 0, len(starts))
+	for _, at := range starts {
+		slots = append(slots, appointmentSlot{Start: at, End: at.Add(time.Hour)})
+	}
+	assertSlotStarts(t, slots, loc, time.Hour, "2025-10-28 10:00 MDT", "2025-11-04 10:00 MST")
+}
@@ -0,0 +1,115 @@
+// api/handlers/appointment_staff_match.go
+// Staff fit for an appointment: the staff member booked must work at the case's office and in
+// a department that handles the appointment's, unless an admin forces the booking.
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// staffAssignmentMismatch is a way the staff member does not fit the appointment.
+type staffAssignmentMismatch struct {
+	Field       string `json:"field"`       // office or department
+	Staff       string `json:"staff"`       // The staff member's office ID or department
+	Appointment string `json:"appointment"` // The case's office ID or the appointment's department
+}
+
+// staffAssignmentMismatches compares the staff member with an appointment at the office in the
+// department. Staff without an office or department, and roles that work in every office, are
+// not checked on that field; departments outside departmentStaffMatch are not checked either.
+func staffAssignmentMismatches(staff models.User, officeID uint, department string) []staffAssignmentMismatch {
+	mismatches := make([]staffAssignmentMismatch, 0, 2)
+	if officeID != 0 && staff.OfficeID != nil && *staff.OfficeID != officeID && !config.CanAccessAllOffices(staff.Role) {
+		mismatches = append(mismatches, staffAssignmentMismatch{
+			Field:       "office",
+			Staff:       fmt.Sprint(*staff.OfficeID),
+			Appointment: fmt.Sprint(officeID),
+		})
+	}
+	if staff.Department != nil && strings.TrimSpace(*staff.Department) != "" {
+		if _, known := departmentStaffMatch[caseDepartment(department)]; known && !staffDepartmentHandles(*staff.Department, department) {
+			mismatches = append(mismatches, staffAssignmentMismatch{
+				Field:       "department",
+				Staff:       *staff.Department,
+				Appointment: department,
+			})
+		}
+	}
+	return mismatches
+}
+
+// enforceStaffAssignment rejects booking the staff member for an appointment at the office in
+// the department when they work elsewhere or in another department, writing a 400 and
+// returning false. Admins may pass force to book anyway; the mismatches are then returned for
+// recordForcedStaffAssignment.
+func enforceStaffAssignment(c *gin.Context, tx *gorm.DB, staffID, officeID uint, department string, force bool) ([]staffAssignmentMismatch, bool) {
+	var staff models.User
+	if err := tx.Select("id", "role", "office_id", "department").Where("id = ? AND role <> ?", staffID, "client").Find(&staff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar el personal asignado", "message": err.Error()})
+		return nil, false
+	}
+	if staff.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Personal no encontrado"})
+		return nil, false
+	}
+
+	mismatches := staffAssignmentMismatches(staff, officeID, department)
+	if len(mismatches) == 0 {
+		return mismatches, true
+	}
+	if force && c.GetString("userRole") == config.RoleAdmin {
+		return mismatches, true
+	}
+
+	message := "El personal no pertenece a la oficina del caso"
+	if mismatches[0].Field == "department" {
+		message = fmt.Sprintf("El personal pertenece al departamento %s y la cita es de %s", mismatches[0].Staff, mismatches[0].Appointment)
+	} else if len(mismatches) > 1 {
+		message = "El personal no pertenece a la oficina del caso ni al departamento de la cita"
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message, "mismatches": mismatches})
+	return nil, false
+}
+
+// recordForcedStaffAssignment notes on the case timeline that an admin booked the staff member
+// despite the mismatches. Like recordForcedOverlap it runs in the booking's transaction, which
+// must be rolled back on error.
+func recordForcedStaffAssignment(tx *gorm.DB, c *gin.Context, appointment *models.Appointment, mismatches []staffAssignmentMismatch) error {
+	if len(mismatches) == 0 || appointment.CaseID == 0 {
+		return nil
+	}
+	details := make([]string, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		if mismatch.Field == "office" {
+			details = append(details, fmt.Sprintf("oficina %s en lugar de %s", mismatch.Staff, mismatch.Appointment))
+		} else {
+			details = append(details, fmt.Sprintf("departamento %s en lugar de %s", mismatch.Staff, mismatch.Appointment))
+		}
+	}
+	log.Printf("Appointment %d booked for staff %d despite mismatches: %s", appointment.ID, appointment.StaffID, strings.Join(details, "; "))
+	event := models.CaseEvent{
+		CaseID:    appointment.CaseID,
+		UserID:    extractUserIDUint(c),
+		EventType: "staff_assignment_override",
+		Description: fmt.Sprintf("Cita #%d (%s) asignada al personal #%d con %s",
+			appointment.ID, appointment.StartTime.Format("2006-01-02 15:04"), appointment.StaffID, strings.Join(details, ", ")),
+		Visibility: "internal",
+		Metadata: map[string]interface{}{
+			"appointmentId": appointment.ID,
+			"staffId":       appointment.StaffID,
+			"mismatches":    mismatches,
+		},
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("record forced staff assignment for appointment %d: %w", appointment.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// api/handlers/appointment_staff_match_test.go
+// Unit tests for staff fit on smart appointment creation: staff from another office or
+// department are refused with 400, unless an admin forces the booking, which is then noted on
+// the case timeline in the same transaction.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestStaffAssignmentMismatches(t *testing.T) {
+	office := func(id uint) *uint { return &id }
+	department := func(name string) *string { return &name }
+	tests := []struct {
+		name   string
+		staff  models.User
+		want   []string
+		dept   string
+		office uint
+	}{
+		{"same office and department", models.User{Role: "lawyer", OfficeID: office(2), Department: department("Familiar")}, nil, "Familiar", 2},
+		{"legal staff on a civil appointment", models.User{Role: "lawyer", OfficeID: office(2), Department: department("legal")}, nil, "Civil", 2},
+		{"case type of the department", models.User{Role: "psychologist", OfficeID: office(2), Department: department("Psicologia")}, nil, "Pareja", 2},
+		{"other office", models.User{Role: "lawyer", OfficeID: office(3), Department: department("Familiar")}, []string{"office"}, "Familiar", 2},
+		{"other department", models.User{Role: "psychologist", OfficeID: office(2), Department: department("Psicologia")}, []string{"department"}, "Familiar", 2},
+		{"both", models.User{Role: "psychologist", OfficeID: office(3), Department: department("Psicologia")}, []string{"office", "department"}, "Familiar", 2},
+		{"no office or department", models.User{Role: "lawyer"}, nil, "Familiar", 2},
+		{"admin from another office", models.User{Role: "admin", OfficeID: office(3)}, nil, "Familiar", 2},
+		{"unknown department", models.User{Role: "lawyer", OfficeID: office(2), Department: department("Familiar")}, nil, "General", 2},
+	}
+	for _, tt := range tests {
+		mismatches := staffAssignmentMismatches(tt.staff, tt.office, tt.dept)
+		fields := make([]string, 0, len(mismatches))
+		for _, mismatch := range mismatches {
+			fields = append(fields, mismatch.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: mismatches = %v, want %v", tt.name, fields, tt.want)
+		}
+	}
+}
+
+// staffMatchScript answers for case 7 at office 2 and staff member 4 of the office and
+// department.
+func staffMatchScript(staffOffice int64, staffDepartment string) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "cases"`):
+				return []string{"id", "office_id", "category", "status"}, [][]driver.Value{{int64(7), int64(2), "Divorcios", "open"}}
+			case strings.Contains(query, `FROM "users"`) && strings.Contains(query, `"department"`):
+				return []string{"id", "role", "office_id", "department"}, [][]driver.Value{{int64(4), "psychologist", staffOffice, staffDepartment}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// createSmartAppointment books staff member 4 for case 7's Familiar appointment as a user of
+// role, with force.
+func createSmartAppointment(t *testing.T, script *scriptedSQL, role string, force bool) (int, map[string]interface{}) {
+	t.Helper()
+	t.Setenv("APPOINTMENT_MIN_DURATION_MINUTES", "15")
+	t.Setenv("APPOINTMENT_MAX_DURATION_MINUTES", "180")
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour).UTC()
+	body, _ := json.Marshal(map[string]interface{}{
+		"caseId": 7, "staffId": 4, "title": "Audiencia", "status": "confirmed",
+		"category": "General", "department": "Familiar", "force": force,
+		"startTime": start.Format(time.RFC3339), "endTime": start.Add(time.Hour).Format(time.RFC3339),
+	})
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/appointments", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", role)
+	CreateAppointmentSmart(scriptedDB(t, script))(c)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestCreateAppointmentSmartRejectsStaffFromAnotherOffice(t *testing.T) {
+	script := staffMatchScript(3, "Familiar")
+	status, response := createSmartAppointment(t, script, "office_manager", false)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %v", status, response)
+	}
+	if !strings.Contains(response["error"].(string), "oficina") {
+		t.Errorf("error = %v", response["error"])
+	}
+	if len(script.ran(`INSERT INTO "appointments"`)) != 0 {
+		t.Error("appointment created")
+	}
+
+	// Only admins may force the booking
+	if status, _ := createSmartAppointment(t, staffMatchScript(3, "Familiar"), "office_manager", true); status != http.StatusBadRequest {
+		t.Errorf("forced by office manager: status = %d, want 400", status)
+	}
+}
+
+func TestCreateAppointmentSmartRejectsStaffFromAnotherDepartment(t *testing.T) {
+	script := staffMatchScript(2, "Psicologia")
+	status, response := createSmartAppointment(t, script, "admin", false)
+	if status != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %v", status, response)
+	}
+	if got := response["error"].(string); !strings.Contains(got, "Psicologia") || !strings.Contains(got, "Familiar") {
+		t.Errorf("error = %q", got)
+	}
+	if len(script.ran(`INSERT INTO "appointments"`)) != 0 {
+		t.Error("appointment created")
+	}
+}
+
+func TestCreateAppointmentSmartForcedByAdmin(t *testing.T) {
+	script := staffMatchScript(3, "Psicologia")
+	var override []driver.Value
+	script.observe = func(query string, args []driver.Value) {
+		if strings.HasPrefix(query, `INSERT INTO "case_events"`) {
+			override = args
+		}
+	}
+	status, response := createSmartAppointment(t, script, "admin", true)
+	if status != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %v", status, response)
+	}
+	if warnings, _ := response["staffWarnings"].([]interface{}); len(warnings) != 2 {
+		t.Errorf("staffWarnings = %v", response["staffWarnings"])
+	}
+	if len(script.ran(`INSERT INTO "appointments"`)) != 1 {
+		t.Error("appointment not created")
+	}
+	found := false
+	for _, arg := range override {
+		if arg == "staff_assignment_override" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("override not recorded on the case timeline: %v", override)
+	}
+}
+
+func TestCreateAppointmentSmartRollsBackWhenOverrideNotRecorded(t *testing.T) {
+	script := staffMatchScript(3, "Psicologia")
+	script.fail = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "case_events"`) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	status, response := createSmartAppointment(t, script, "admin", true)
+	if status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %v", status, response)
+	}
+	if len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BryanPMX/CAF/api/config"
@@ -16,54 +18,101 @@ import (
 )
 
 
-// GetAppointmentsEnhanced returns appointments based on user permissions and department
-func GetAppointmentsEnhanced(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Initialize with empty slice to prevent null JSON response
-		appointments := make([]models.Appointment, 0)
-		query := db.Order("start_time desc").Limit(100)
-
-		// Preload nested data with optimized queries
-		query = query.Preload("Staff", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id, first_name, last_name, role, department")
-		}).Preload("Case", func(db *gorm.DB) *gorm.DB {
-			return db.Preload("Client", func(db *gorm.DB) *gorm.DB {
-				return db.Select("id, first_name, last_name")
-			})
-		})
+// scopeAppointmentQuery restricts an appointments query to what the current user may see and
+// reports whether cases was joined. Admins see everything; office managers see their office;
+// staff see their own appointments plus their office/department's.
+func scopeAppointmentQuery(db *gorm.DB, c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	userRole, _ := c.Get("userRole")
+	userDepartment, _ := c.Get("userDepartment")
+	officeScopeID, _ := c.Get("officeScopeID")
 
-		// Apply access control based on user role and department
-		userRole, _ := c.Get("userRole")
-		userDepartment, _ := c.Get("userDepartment")
-		officeScopeID, _ := c.Get("officeScopeID")
+	// Admins see all appointments - no filtering needed
+	// Office managers see ALL appointments in their office (they manage the entire office)
+	if userRole == config.RoleOfficeManager && officeScopeID != nil {
+		return query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.office_id = ?", officeScopeID), true
+	}
+	if userRole == config.RoleAdmin || userRole == "client" {
+		return query, false
+	}
 
-		// Admins see all appointments - no filtering needed
-		// Office managers see ALL appointments in their office (they manage the entire office)
-		if userRole == config.RoleOfficeManager && officeScopeID != nil {
-			query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.office_id = ?", officeScopeID)
-		} else if userRole != config.RoleAdmin && userRole != "client" {
-			// Staff users: build a compound condition for access control
-			// They can see appointments they're assigned to OR appointments from their office/department
-			userID, _ := c.Get("userID")
-			userIDUint, _ := strconv.ParseUint(userID.(string), 10, 32)
+	// Staff users: build a compound condition for access control
+	// They can see appointments they're assigned to OR appointments from their office/department
+	userID, _ := c.Get("userID")
+	userIDUint, _ := strconv.ParseUint(userID.(string), 10, 32)
+
+	// Build access conditions as a group
+	accessConditions := db.Where("appointments.staff_id = ?", userIDUint)
+
+	// Add office/department scoped appointments
+	if officeScopeID != nil && userDepartment != nil {
+		accessConditions = accessConditions.Or(
+			db.Where("appointments.case_id IN (SELECT id FROM cases WHERE office_id = ?)", officeScopeID).
+				Where("appointments.department = ?", userDepartment),
+		)
+	} else if officeScopeID != nil {
+		accessConditions = accessConditions.Or("appointments.case_id IN (SELECT id FROM cases WHERE office_id = ?)", officeScopeID)
+	} else if userDepartment != nil {
+		accessConditions = accessConditions.Or("appointments.department = ?", userDepartment)
+	}
 
-			// Build access conditions as a group
-			accessConditions := db.Where("appointments.staff_id = ?", userIDUint)
+	return query.Where(accessConditions), false
+}
 
-			// Add office/department scoped appointments
-			if officeScopeID != nil && userDepartment != nil {
-				accessConditions = accessConditions.Or(
-					db.Where("appointments.case_id IN (SELECT id FROM cases WHERE office_id = ?)", officeScopeID).
-						Where("appointments.department = ?", userDepartment),
-				)
-			} else if officeScopeID != nil {
-				accessConditions = accessConditions.Or("appointments.case_id IN (SELECT id FROM cases WHERE office_id = ?)", officeScopeID)
-			} else if userDepartment != nil {
-				accessConditions = accessConditions.Or("appointments.department = ?", userDepartment)
-			}
+// appointmentListItem is the reduced appointment row returned by GetAppointmentsEnhanced?light=true.
+type appointmentListItem struct {
+	ID           uint       `json:"id"`
+	CaseID       uint       `json:"caseId"`
+	StaffID      uint       `json:"staffId"`
+	OfficeID     uint       `json:"officeId"`
+	Title        string     `json:"title"`
+	StartTime    time.Time  `json:"startTime"`
+	EndTime      time.Time  `json:"endTime"`
+	Status       string     `json:"status"`
+	Category     string     `json:"category"`
+	Department   string     `json:"department"`
+	StaffName    string     `json:"staffName"`
+	ClientName   string     `json:"clientName"`
+	DisplayColor string     `json:"displayColor,omitempty"`
+	RecordState  string     `json:"recordState,omitempty"`
+	DeletedAt    *time.Time `json:"-"`
+}
 
-			query = query.Where(accessConditions)
+// appointmentListItemColumns are the columns selected for appointmentListItem; the staff and
+// client names replace the Staff and Case.Client preloads
+const appointmentListItemColumns = `appointments.id, appointments.case_id, appointments.staff_id, appointments.office_id,
+	appointments.title, appointments.start_time, appointments.end_time, appointments.status,
+	appointments.category, appointments.department, appointments.deleted_at,
+	(SELECT TRIM(CONCAT(first_name, ' ', last_name)) FROM users WHERE users.id = appointments.staff_id) AS staff_name,
+	(SELECT TRIM(CONCAT(u.first_name, ' ', u.last_name)) FROM cases cc JOIN users u ON u.id = cc.client_id WHERE cc.id = appointments.case_id) AS client_name`
+
+// GetAppointmentsEnhanced returns appointments based on user permissions and department
+func GetAppointmentsEnhanced(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		visibility, ok := resolveRecordVisibility(c)
+		if !ok {
+			return
+		}
+
+		start := time.Now()
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 {
+			pageSize = 20
 		}
+		if maxPageSize := config.AppointmentsMaxPageSize(); pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+
+		// Initialize with empty slice to prevent null JSON response
+		appointments := make([]models.Appointment, 0)
+		query := applyAppointmentVisibility(db.Model(&models.Appointment{}), visibility)
+
+		// Apply access control based on user role and department.
+		// Track whether cases is already joined so later filters don't join it twice
+		query, casesJoined := scopeAppointmentQuery(db, c, query)
 
 		// Apply additional filters from query parameters
 		if status := c.Query("status"); status != "" {
@@ -71,7 +120,22 @@ func GetAppointmentsEnhanced(db *gorm.DB) gin.HandlerFunc {
 		}
 		if category := c.Query("category"); category != "" {
 			// Filter by case category since category equals type of case
-			query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.category = ?", category)
+			if casesJoined {
+				query = query.Where("cases.category = ?", category)
+			} else {
+				query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.category = ?", category)
+				casesJoined = true
+			}
+		}
+		if search := strings.TrimSpace(c.Query("search")); search != "" {
+			// Match client name or case title
+			searchTerm := "%" + search + "%"
+			if !casesJoined {
+				query = query.Joins("INNER JOIN cases ON cases.id = appointments.case_id")
+			}
+			query = query.Joins("LEFT JOIN users AS search_clients ON search_clients.id = cases.client_id").
+				Where("cases.title ILIKE ? OR search_clients.first_name ILIKE ? OR search_clients.last_name ILIKE ? OR CONCAT(search_clients.first_name, ' ', search_clients.last_name) ILIKE ?",
+					searchTerm, searchTerm, searchTerm, searchTerm)
 		}
 		if department := c.Query("department"); department != "" {
 			query = query.Where("appointments.department = ?", department)
@@ -108,38 +172,84 @@ func GetAppointmentsEnhanced(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
-		// Execute the final query
-		if err := query.Find(&appointments).Error; err != nil {
+		// Count the filtered set before paging so totals are accurate
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointments"})
 			return
 		}
 
-		// Debug logging
+		var data interface{}
+		var size int
+		if c.Query("light") == "true" {
+			items := make([]appointmentListItem, 0)
+			if err := query.Select(appointmentListItemColumns).
+				Order("appointments.start_time desc").
+				Limit(pageSize).
+				Offset((page - 1) * pageSize).
+				Scan(&items).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointments"})
+				return
+			}
+			departments, categories := calendarColorMaps(db)
+			for i := range items {
+				items[i].DisplayColor = calendarColor(departments, categories, items[i].Department, items[i].Category)
+				if items[i].DeletedAt != nil {
+					items[i].RecordState = "deleted"
+					if items[i].Status == string(config.StatusCompleted) {
+						items[i].RecordState = "archived"
+					}
+				}
+			}
+			data, size = items, len(items)
+		} else {
+			// Preload nested data with optimized queries
+			query = query.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+				return db.Select("id, first_name, last_name, role, department")
+			}).Preload("Case", func(db *gorm.DB) *gorm.DB {
+				return db.Preload("Client", func(db *gorm.DB) *gorm.DB {
+					return db.Select("id, first_name, last_name")
+				})
+			})
+
+			// Execute the final query
+			if err := query.Order("appointments.start_time desc").
+				Limit(pageSize).
+				Offset((page - 1) * pageSize).
+				Find(&appointments).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointments"})
+				return
+			}
+			applyCalendarColors(db, appointments)
+			if visibility.any() {
+				for i := range appointments {
+					flagAppointmentRecordState(&appointments[i])
+				}
+			}
+			data, size = appointments, len(appointments)
+		}
 
 		// Calculate pagination info
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-		total := int64(len(appointments))
-		totalPages := (total + int64(limit) - 1) / int64(limit)
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
 
 		// Disable caching for real-time appointment data
 		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
 		c.Header("Pragma", "no-cache")
 		c.Header("Expires", "0")
 		c.JSON(http.StatusOK, gin.H{
-			"data": appointments,
+			"data": data,
 			"pagination": gin.H{
 				"page":       page,
-				"pageSize":   limit,
+				"pageSize":   pageSize,
 				"total":      total,
 				"totalPages": totalPages,
 				"hasNext":    page < int(totalPages),
 				"hasPrev":    page > 1,
 			},
 			"performance": gin.H{
-				"queryTime":    "0ms",
+				"queryTime":    fmt.Sprintf("%dms", time.Since(start).Milliseconds()),
 				"cacheHit":     false,
-				"responseSize": len(appointments),
+				"responseSize": size,
 			},
 		})
 	}
@@ -151,7 +261,12 @@ func GetAppointmentByIDEnhanced(db *gorm.DB) gin.HandlerFunc {
 		appointmentID := c.Param("id")
 		var appointment models.Appointment
 
-		query := db.Preload("Staff").Preload("Case.Client").Preload("Case.Office")
+		visibility, ok := resolveRecordVisibility(c)
+		if !ok {
+			return
+		}
+
+		query := applyAppointmentVisibility(db.Preload("Staff").Preload("Case.Client").Preload("Case.Office"), visibility)
 
 		// Apply access control
 		userRole, _ := c.Get("userRole")
@@ -192,6 +307,7 @@ func GetAppointmentByIDEnhanced(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointment"})
 			return
 		}
+		flagAppointmentRecordState(&appointment)
 
 		c.JSON(http.StatusOK, appointment)
 	}
@@ -205,6 +321,13 @@ func CreateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		input.EndTime = appointmentEndTime(input.StartTime, input.EndTime, input.Category)
+		if !enforceAppointmentDuration(c, input.StartTime, input.EndTime, input.OverridePast) {
+			return
+		}
+		if !enforceSlotAlignment(c, db, input.StartTime, input.EndTime) {
+			return
+		}
 
 		// Get current user context
 		currentUser, _ := c.Get("currentUser")
@@ -228,6 +351,8 @@ func CreateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 				Password:  string(hashedPassword),
 				Role:      "client",
 				IsActive:  true,
+
+				MustChangePassword: true,
 			}
 
 			// Assign to same office as the creating user
@@ -282,10 +407,30 @@ func CreateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
+		// Only services the office offers can be booked there; an empty category is stored as the column default
+		category := input.Category
+		if category == "" {
+			category = "General"
+		}
+		if !enforceOfficeAppointmentCategory(c, db, caseRecord.OfficeID, category) {
+			return
+		}
+
+		// No bookings outside the office's business hours or on its holidays
+		if !enforceOfficeOpen(c, db, caseRecord.OfficeID, input.StartTime, input.EndTime) {
+			return
+		}
+
+		// Staff covering several offices need time to travel between them
+		if _, ok := enforceOfficeBuffer(c, db, input.StaffID, caseRecord.OfficeID, input.StartTime, input.EndTime, 0, input.OverrideBuffer); !ok {
+			return
+		}
+
 		// Create the appointment with centralized status
 		appointment := models.Appointment{
 			CaseID:     input.CaseID,
 			StaffID:    input.StaffID,
+			OfficeID:   caseRecord.OfficeID,
 			Title:      input.Title,
 			StartTime:  input.StartTime,
 			EndTime:    input.EndTime,
@@ -294,10 +439,25 @@ func CreateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			Department: input.Department,
 		}
 
-		if err := db.Create(&appointment).Error; err != nil {
+		// Check for double-booking and insert under the staff row lock
+		err := db.Transaction(func(tx *gorm.DB) error {
+			overlaps, ok := enforceStaffAvailability(c, tx, input.StaffID, input.StartTime, input.EndTime, 0)
+			if !ok {
+				return errAppointmentConflict
+			}
+			if err := tx.Create(&appointment).Error; err != nil {
+				return err
+			}
+			return recordForcedOverlap(tx, c, &appointment, overlaps)
+		})
+		if errors.Is(err, errAppointmentConflict) {
+			return // Response already written
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment"})
 			return
 		}
+		notifyAppointmentEvent(db, models.WebhookEventAppointmentCreated, extractUserIDUint(c), appointment)
 
 		// Load relationships for response
 		if err := db.Preload("Staff").Preload("Case.Client").First(&appointment, appointment.ID).Error; err != nil {
@@ -318,6 +478,9 @@ func UpdateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if !enforceSlotAlignment(c, db, input.StartTime, input.EndTime) {
+			return
+		}
 
 		// Get current user context
 		currentUser, _ := c.Get("currentUser")
@@ -356,6 +519,11 @@ func UpdateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 		}
 		if !input.StartTime.IsZero() {
 			updates["start_time"] = input.StartTime
+			if !input.StartTime.Equal(appointment.StartTime) {
+				// Rescheduled: the reminders are due again for the new time
+				updates["reminder_sent_at"] = nil
+				updates["reminder_stage"] = ""
+			}
 		}
 		if !input.EndTime.IsZero() {
 			updates["end_time"] = input.EndTime
@@ -378,12 +546,40 @@ func UpdateAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			updates["staff_id"] = input.StaffID
 		}
 
+		// Re-validate the travel buffer when the schedule or assignee changes
+		if !input.StartTime.IsZero() || !input.EndTime.IsZero() || input.StaffID != 0 {
+			staffID, start, end := appointment.StaffID, appointment.StartTime, appointment.EndTime
+			if input.StaffID != 0 {
+				staffID = input.StaffID
+			}
+			if !input.StartTime.IsZero() {
+				start = input.StartTime
+			}
+			if !input.EndTime.IsZero() {
+				end = input.EndTime
+			}
+			officeID := appointment.OfficeID
+			if officeID == 0 {
+				officeID = appointment.Case.OfficeID
+			}
+			if (!input.StartTime.IsZero() || !input.EndTime.IsZero()) && !enforceOfficeOpen(c, db, officeID, start, end) {
+				return
+			}
+			if _, ok := enforceOfficeBuffer(c, db, staffID, officeID, start, end, appointment.ID, input.OverrideBuffer); !ok {
+				return
+			}
+		}
+
+		previousStatus := appointment.Status
 		if err := db.Model(&appointment).Updates(updates).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment"})
 			return
 		}
 		// Reload to get updated fields for notifications
 		_ = db.First(&appointment, appointment.ID).Error
+		if appointment.Status == config.StatusCancelled && previousStatus != config.StatusCancelled {
+			notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, extractUserIDUint(c), appointment)
+		}
 
 		// Notify admins with full appointment details
 		appointmentLink := "/app/appointments"
@@ -498,6 +694,7 @@ func DeleteAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 			"deleted_at": time.Now(),
 		}
 
+		wasCancelled := appointment.Status == config.StatusCancelled
 		if err := db.Model(&appointment).Updates(updates).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar la cita"})
 			return
@@ -506,6 +703,9 @@ func DeleteAppointmentEnhanced(db *gorm.DB) gin.HandlerFunc {
 		// Notify admins of appointment deletion/cancellation
 		link := "/app/appointments"
 		NotifyAdminsForAppointment(db, "eliminada/cancelada", appointment.ID, appointment.Title, "cancelled", appointment.StartTime, &link)
+		if !wasCancelled {
+			notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, user.ID, appointment)
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message":     "Cita cancelada exitosamente",
@@ -580,6 +780,7 @@ func GetMyAppointments(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve appointments"})
 			return
 		}
+		applyCalendarColors(db, appointments)
 
 
 		// Calculate pagination info
@@ -613,11 +814,16 @@ type CreateAppointmentEnhancedInput struct {
 	StaffID    uint               `json:"staffId" binding:"required"`
 	Title      string             `json:"title" binding:"required"`
 	StartTime  time.Time          `json:"startTime" binding:"required"`
-	EndTime    time.Time          `json:"endTime" binding:"required"`
+	EndTime    time.Time          `json:"endTime"` // Omitted: the category's default duration
 	Category   string             `json:"category" binding:"required"`
 	Department string             `json:"department" binding:"required"`
 	NewClient  *CreateClientInput `json:"newClient,omitempty"`
 	ClientID   *uint              `json:"clientId,omitempty"`
+
+	// OverrideBuffer lets admins schedule despite an insufficient travel buffer between offices.
+	OverrideBuffer bool `json:"overrideBuffer,omitempty"`
+	// OverridePast lets admins record an appointment that has already started.
+	OverridePast bool `json:"overridePast,omitempty"`
 }
 
 // CreateClientInput defines the structure for creating a new client
@@ -636,4 +842,7 @@ type UpdateAppointmentInput struct {
 	Category   string    `json:"category,omitempty"`
 	Department string    `json:"department,omitempty"`
 	StaffID    uint      `json:"staffId,omitempty"`
+
+	// OverrideBuffer lets admins schedule despite an insufficient travel buffer between offices.
+	OverrideBuffer bool `json:"overrideBuffer,omitempty"`
 }
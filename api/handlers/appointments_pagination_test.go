@@ -0,0 +1,199 @@
+// api/handlers/appointments_pagination_test.go
+// Unit tests for appointment list pagination: real totals and pages drawn with LIMIT/OFFSET.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// appointmentTable serves stored appointments 1..rows (newest first) to GetAppointmentsEnhanced,
+// honoring the LIMIT and OFFSET bound to each page query and counting all of them.
+type appointmentTable struct {
+	*scriptedSQL
+	windows [][2]int // LIMIT and OFFSET of each page query
+}
+
+func newAppointmentTable(rows int) *appointmentTable {
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	table := &appointmentTable{}
+	var window [2]int
+	table.scriptedSQL = &scriptedSQL{
+		observe: func(query string, args []driver.Value) {
+			if !strings.Contains(query, `FROM "appointments"`) || !strings.Contains(query, " LIMIT $") {
+				return
+			}
+			window = [2]int{rows, 0}
+			if strings.Contains(query, " OFFSET $") && len(args) >= 2 {
+				window = [2]int{intArg(args[len(args)-2]), intArg(args[len(args)-1])}
+			} else if len(args) >= 1 {
+				window[0] = intArg(args[len(args)-1])
+			}
+			table.windows = append(table.windows, window)
+		},
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if !strings.Contains(query, `FROM "appointments"`) {
+				return nil, nil
+			}
+			if strings.Contains(query, "count(*)") {
+				return countRow(int64(rows))
+			}
+			limit, offset := window[0], window[1]
+			page := make([][]driver.Value, 0, limit)
+			for i := offset; i < offset+limit && i < rows; i++ {
+				id := int64(rows - i)
+				start := base.Add(time.Duration(id) * time.Hour)
+				page = append(page, []driver.Value{id, int64(7), int64(3), fmt.Sprintf("Cita %d", id), start, start.Add(time.Hour), "confirmed", "Familiar", "Familiar"})
+			}
+			return []string{"id", "case_id", "staff_id", "title", "start_time", "end_time", "status", "department", "category"}, page
+		},
+	}
+	return table
+}
+
+// appointmentPage is the part of the GetAppointmentsEnhanced response these tests read.
+type appointmentPage struct {
+	Data []struct {
+		ID uint `json:"id"`
+	} `json:"data"`
+	Pagination struct {
+		Page       int   `json:"page"`
+		PageSize   int   `json:"pageSize"`
+		Total      int64 `json:"total"`
+		TotalPages int64 `json:"totalPages"`
+		HasNext    bool  `json:"hasNext"`
+		HasPrev    bool  `json:"hasPrev"`
+	} `json:"pagination"`
+	Performance struct {
+		QueryTime    string `json:"queryTime"`
+		ResponseSize int    `json:"responseSize"`
+	} `json:"performance"`
+}
+
+// getAppointmentPage runs GetAppointmentsEnhanced as role with the query string.
+func getAppointmentPage(t *testing.T, table *appointmentTable, role, query string) appointmentPage {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/appointments?"+query, nil)
+	c.Set("userID", "5")
+	c.Set("userRole", role)
+	if role != "admin" {
+		c.Set("officeScopeID", uint(3))
+		c.Set("userDepartment", "Familiar")
+	}
+	GetAppointmentsEnhanced(scriptedDB(t, table.scriptedSQL))(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d: %s", query, w.Code, w.Body.String())
+	}
+	var page appointmentPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestGetAppointmentsPagesThroughAllRows(t *testing.T) {
+	for _, light := range []string{"", "&light=true"} {
+		first := getAppointmentPage(t, newAppointmentTable(45), "admin", "page=1&pageSize=20"+light)
+		second := getAppointmentPage(t, newAppointmentTable(45), "admin", "page=2&pageSize=20"+light)
+		last := getAppointmentPage(t, newAppointmentTable(45), "admin", "page=3&pageSize=20"+light)
+
+		if len(first.Data) != 20 || len(second.Data) != 20 || len(last.Data) != 5 {
+			t.Fatalf("%s: page sizes = %d, %d, %d", light, len(first.Data), len(second.Data), len(last.Data))
+		}
+		seen := make(map[uint]bool)
+		for _, page := range []appointmentPage{first, second, last} {
+			for _, row := range page.Data {
+				if seen[row.ID] {
+					t.Errorf("%s: appointment %d is on more than one page", light, row.ID)
+				}
+				seen[row.ID] = true
+			}
+		}
+		if first.Data[0].ID != 45 || second.Data[0].ID != 25 || last.Data[4].ID != 1 {
+			t.Errorf("%s: pages start at %d, %d and end at %d", light, first.Data[0].ID, second.Data[0].ID, last.Data[4].ID)
+		}
+
+		if first.Pagination.Total != 45 || first.Pagination.Total <= int64(first.Pagination.PageSize) || first.Pagination.TotalPages != 3 {
+			t.Errorf("%s: pagination = %+v", light, first.Pagination)
+		}
+		if !first.Pagination.HasNext || first.Pagination.HasPrev || !second.Pagination.HasNext || !second.Pagination.HasPrev || last.Pagination.HasNext {
+			t.Errorf("%s: hasNext/hasPrev = %+v, %+v, %+v", light, first.Pagination, second.Pagination, last.Pagination)
+		}
+		if second.Performance.ResponseSize != 20 || !strings.HasSuffix(second.Performance.QueryTime, "ms") {
+			t.Errorf("%s: performance = %+v", light, second.Performance)
+		}
+	}
+}
+
+func TestGetAppointmentsPageSizeLimits(t *testing.T) {
+	t.Setenv("APPOINTMENTS_MAX_PAGE_SIZE", "")
+	tests := []struct {
+		query  string
+		window [2]int
+	}{
+		{"", [2]int{20, 0}},
+		{"page=4&pageSize=25", [2]int{25, 75}},
+		{"pageSize=150", [2]int{150, 0}},
+		{"pageSize=5000", [2]int{1000, 0}},
+		{"page=0&pageSize=-3", [2]int{20, 0}},
+	}
+	for _, tt := range tests {
+		table := newAppointmentTable(1200)
+		page := getAppointmentPage(t, table, "admin", tt.query)
+		if len(table.windows) != 1 || table.windows[0] != tt.window {
+			t.Errorf("%q: LIMIT/OFFSET = %v, want %v", tt.query, table.windows, tt.window)
+		}
+		if page.Pagination.PageSize != tt.window[0] || page.Pagination.Total != 1200 || len(page.Data) != tt.window[0] {
+			t.Errorf("%q: pagination = %+v with %d rows", tt.query, page.Pagination, len(page.Data))
+		}
+	}
+}
+
+func TestGetAppointmentsCountUsesSameFilters(t *testing.T) {
+	queries := []string{
+		"status=confirmed&department=Familiar&date=2025-03-02",
+		"category=Familiar&search=ana&dateFrom=2025-03-01&dateTo=2025-03-31",
+	}
+	for _, role := range []string{"lawyer", "office_manager"} {
+		for _, query := range queries {
+			table := newAppointmentTable(45)
+			getAppointmentPage(t, table, role, query+"&light=true")
+
+			var count, page string
+			for _, statement := range table.ran(`FROM "appointments"`) {
+				if strings.Contains(statement, "count(*)") {
+					count = statement
+				} else if page == "" {
+					page = statement
+				}
+			}
+			if count == "" || page == "" {
+				t.Fatalf("%s %s: expected a count and a page query, got %q", role, query, table.ran(`FROM "appointments"`))
+			}
+			// Joins and conditions both come before WHERE; the count must repeat them all
+			from := func(statement string) string {
+				rest := statement[strings.Index(statement, `FROM "appointments"`):]
+				for _, keyword := range []string{" ORDER BY ", " LIMIT "} {
+					if i := strings.Index(rest, keyword); i >= 0 {
+						rest = rest[:i]
+					}
+				}
+				return rest
+			}
+			if from(count) != from(page) {
+				t.Errorf("%s %s: count and page differ:\n count: %s\n page:  %s", role, query, count, page)
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -63,7 +64,8 @@ func CompleteCase(db *gorm.DB) gin.HandlerFunc {
 
 		// Update case to completed status
 		now := time.Now()
-		caseRecord.Status = "closed"
+		previousStatus := caseRecord.Status
+		caseRecord.Status = string(config.CaseStatusClosed)
 		caseRecord.IsArchived = true
 		caseRecord.ArchiveReason = "completed"
 		caseRecord.ArchivedAt = &now
@@ -75,6 +77,7 @@ func CompleteCase(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al completar el caso"})
 			return
 		}
+		recordCaseStageChange(db, &caseRecord, caseRecord.CurrentStage, previousStatus, &user.ID)
 
 		// Create a case event for the completion
 		caseEvent := models.CaseEvent{
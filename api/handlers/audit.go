@@ -0,0 +1,112 @@
+// api/handlers/audit.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newAuditLog builds an AuditLog entry populated with the current request's user context.
+func newAuditLog(c *gin.Context, entityType string, entityID uint, action string, reason string, newValues map[string]interface{}) models.AuditLog {
+	entry := models.AuditLog{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Reason:     reason,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+
+	if currentUser, exists := c.Get("currentUser"); exists {
+		if user, ok := currentUser.(models.User); ok {
+			entry.UserID = user.ID
+			entry.UserRole = user.Role
+			entry.UserOfficeID = user.OfficeID
+			entry.UserDepartment = user.Department
+		}
+	}
+	if entry.UserID == 0 {
+		entry.UserID = extractUserIDUint(c)
+	}
+	if entry.UserRole == "" {
+		entry.UserRole = c.GetString("userRole")
+	}
+	if sessionID, exists := c.Get("sessionID"); exists {
+		entry.SessionID = fmt.Sprint(sessionID)
+	}
+	if _, exists := c.Get("serviceTokenID"); exists {
+		entry.Tags = []string{"service_token"}
+	}
+
+	if len(newValues) > 0 {
+		if encoded, err := json.Marshal(newValues); err == nil {
+			values := string(encoded)
+			entry.NewValues = &values
+		}
+	}
+
+	return entry
+}
+
+// newAuditChange builds an AuditLog entry for an update from the values of the entity before
+// and after it: OldValues and NewValues hold only the fields that differ, and ChangedFields
+// their sorted names. It returns false when nothing changed.
+func newAuditChange(c *gin.Context, entityType string, entityID uint, action string, reason string, oldValues, newValues map[string]interface{}) (models.AuditLog, bool) {
+	changedOld := make(map[string]interface{})
+	changedNew := make(map[string]interface{})
+	fields := make([]string, 0)
+	for field, value := range newValues {
+		before, _ := json.Marshal(oldValues[field])
+		after, _ := json.Marshal(value)
+		if string(before) != string(after) {
+			changedOld[field] = oldValues[field]
+			changedNew[field] = value
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return models.AuditLog{}, false
+	}
+	sort.Strings(fields)
+
+	entry := newAuditLog(c, entityType, entityID, action, reason, changedNew)
+	if encoded, err := json.Marshal(changedOld); err == nil {
+		values := string(encoded)
+		entry.OldValues = &values
+	}
+	entry.ChangedFields = fields
+	return entry, true
+}
+
+// saveAuditLog persists an AuditLog entry. Failures are logged but never interrupt the calling handler.
+func saveAuditLog(db *gorm.DB, entry models.AuditLog) {
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("WARNING: Failed to record audit log (%s %s %d): %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+}
+
+// recordAuditLog persists an AuditLog entry for the current request's user.
+func recordAuditLog(db *gorm.DB, c *gin.Context, entityType string, entityID uint, action string, reason string, newValues map[string]interface{}) {
+	saveAuditLog(db, newAuditLog(c, entityType, entityID, action, reason, newValues))
+}
+
+// recordSecurityAuditLog persists a warning-level AuditLog entry tagged "security".
+func recordSecurityAuditLog(db *gorm.DB, c *gin.Context, entityType string, entityID uint, action string, reason string, newValues map[string]interface{}) {
+	entry := newAuditLog(c, entityType, entityID, action, reason, newValues)
+	entry.Severity = "warning"
+	entry.Tags = append(entry.Tags, "security")
+	saveAuditLog(db, entry)
+}
+
+// recordDataAccessAuditLog persists an AuditLog entry tagged "data_access" for bulk reads and downloads.
+func recordDataAccessAuditLog(db *gorm.DB, c *gin.Context, entityType string, entityID uint, action string, reason string, newValues map[string]interface{}) {
+	entry := newAuditLog(c, entityType, entityID, action, reason, newValues)
+	entry.Tags = append(entry.Tags, "data_access")
+	saveAuditLog(db, entry)
+}
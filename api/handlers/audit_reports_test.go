@@ -0,0 +1,97 @@
+// api/handlers/audit_reports_test.go
+// Unit tests for audit reports: every report type describes entries from the stored row.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// auditReportScript answers with one stored audit log by user 5, Ana López, and that user.
+func auditReportScript() *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, "count(*)"):
+				return countRow(1)
+			case strings.Contains(query, `FROM "audit_logs"`):
+				return []string{"id", "entity_type", "entity_id", "action", "user_id", "user_role", "old_values", "new_values",
+						"ip_address", "user_agent", "session_id", "reason", "severity", "created_at"},
+					[][]driver.Value{{int64(41), "case", int64(12), "update", int64(5), "lawyer",
+						`{"status":"open","title":"Divorcio"}`, `{"status":"closed","title":"Divorcio","fee":1500}`,
+						"10.1.2.3", "Mozilla/5.0 (caf-test)", "sess-9", "cierre", "info", time.Date(2025, 2, 3, 10, 0, 0, 0, time.UTC)}}
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "first_name", "last_name", "email", "role"},
+					[][]driver.Value{{int64(5), "Ana", "López", "ana.lopez@caf.mx", "office_manager"}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestAuditReportEntriesComeFromStoredRow(t *testing.T) {
+	for reportType := range auditReportTypes {
+		t.Run(reportType, func(t *testing.T) {
+			script := auditReportScript()
+			db := scriptedDB(t, script)
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/reports/audit?type="+reportType+"&from=2025-02-01&to=2025-02-28", nil)
+			c.Set("currentUser", models.User{ID: 1, Role: "admin"})
+			GetAuditReport(db)(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			var body struct {
+				Data []auditReportEntry `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Data) != 1 {
+				t.Fatalf("data = %s", w.Body.String())
+			}
+			entry := body.Data[0]
+			if entry.User != "ana.lopez@caf.mx" || entry.UserName != "Ana López" || entry.UserRole != "lawyer" ||
+				entry.IPAddress != "10.1.2.3" || entry.UserAgent != "Mozilla/5.0 (caf-test)" || entry.SessionID != "sess-9" {
+				t.Errorf("entry = %+v", entry)
+			}
+			if entry.Description != "update case #12 (cierre)" {
+				t.Errorf("description = %q", entry.Description)
+			}
+			if len(entry.Changes) != 2 || entry.Changes["status"].Old != "open" || entry.Changes["status"].New != "closed" ||
+				entry.Changes["fee"].Old != nil || entry.Changes["fee"].New != float64(1500) {
+				t.Errorf("changes = %+v", entry.Changes)
+			}
+			if strings.Contains(w.Body.String(), "example.com") || strings.Contains(w.Body.String(), "127.0.0.1") {
+				t.Errorf("placeholder values in %s", w.Body.String())
+			}
+
+			queries := script.ran(`FROM "audit_logs"`)
+			if len(queries) != 2 || !strings.Contains(queries[1], "audit_logs.created_at >=") {
+				t.Errorf("audit log queries = %v", queries)
+			}
+		})
+	}
+}
+
+func TestGetAuditReportRejectsUnknownType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/reports/audit?type=everything", nil)
+	GetAuditReport(scriptedDB(t, auditReportScript()))(c)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"allowed"`) {
+		t.Errorf("status %d, body %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,170 @@
+// api/handlers/audit_retention.go
+// Audit log retention: the audit_retention maintenance task purges audit logs older
+// than config.AuditRetentionDays, first exporting them as NDJSON to file storage and
+// verifying the written row count so they are retained outside the hot database.
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"gorm.io/gorm"
+)
+
+// auditPurgeBatchSize is how many audit logs go into one archive file.
+const auditPurgeBatchSize = 10000
+
+// auditArchiveRow is one exported audit log: its ID, timestamp and full row as JSON.
+type auditArchiveRow struct {
+	ID        uint
+	CreatedAt time.Time
+	Line      string
+}
+
+// purgeAuditLogs archives and deletes expired audit logs batch by batch. A batch is only
+// deleted once its archive has been read back with the expected number of rows.
+func purgeAuditLogs(db *gorm.DB, dryRun bool, runID uint) (string, error) {
+	days := config.AuditRetentionDays()
+	if days == 0 {
+		return "Audit retention is disabled (AUDIT_RETENTION_DAYS=0)", errMaintenanceSkipped
+	}
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+	expired := db.Model(&models.AuditLog{}).Where("created_at < ? OR (expires_at IS NOT NULL AND expires_at < ?)", cutoff, now)
+
+	if dryRun {
+		var count int64
+		if err := expired.Count(&count).Error; err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d audit logs older than %d days would be purged (dry run)", count, days), nil
+	}
+
+	archive := config.AuditArchiveBeforePurge()
+	var writer storage.ObjectWriter
+	if archive {
+		var ok bool
+		if writer, ok = storage.GetActiveStorage().(storage.ObjectWriter); !ok {
+			return "Active storage backend cannot store audit archives; nothing was purged", errMaintenanceSkipped
+		}
+	}
+
+	purged, archives := 0, 0
+	for {
+		var rows []auditArchiveRow
+		if err := expired.Session(&gorm.Session{}).
+			Select("id, created_at, row_to_json(audit_logs)::text AS line").
+			Order("id").
+			Limit(auditPurgeBatchSize).
+			Scan(&rows).Error; err != nil {
+			return auditPurgeDetails(purged, archives, archive), err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+
+		var record *models.AuditArchive
+		if archive {
+			var err error
+			if record, err = writeAuditArchive(writer, rows); err != nil {
+				return auditPurgeDetails(purged, archives, archive), err
+			}
+			if runID != 0 {
+				record.MaintenanceRunID = &runID
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if record != nil {
+				if err := tx.Create(record).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Where("id IN ?", ids).Delete(&models.AuditLog{}).Error
+		})
+		if err != nil {
+			return auditPurgeDetails(purged, archives, archive), err
+		}
+
+		purged += len(rows)
+		if record != nil {
+			archives++
+		}
+		if len(rows) < auditPurgeBatchSize {
+			break
+		}
+	}
+
+	return auditPurgeDetails(purged, archives, archive), nil
+}
+
+// writeAuditArchive stores rows as NDJSON and reads the file back to verify its row count.
+func writeAuditArchive(writer storage.ObjectWriter, rows []auditArchiveRow) (*models.AuditArchive, error) {
+	var body bytes.Buffer
+	for _, row := range rows {
+		body.WriteString(row.Line)
+		body.WriteByte('\n')
+	}
+
+	first, last := rows[0], rows[len(rows)-1]
+	key := fmt.Sprintf("archives/audit/%s-%d-%d.ndjson", time.Now().UTC().Format("20060102T150405Z"), first.ID, last.ID)
+	location, err := writer.PutObject(key, body.Bytes(), "application/x-ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write audit archive: %w", err)
+	}
+
+	reader, _, err := storage.GetActiveStorage().Get(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back audit archive %s: %w", location, err)
+	}
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	written := 0
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			written++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to verify audit archive %s: %w", location, err)
+	}
+	if written != len(rows) {
+		return nil, fmt.Errorf("audit archive %s has %d rows, expected %d; nothing was purged", location, written, len(rows))
+	}
+
+	oldest, newest := first.CreatedAt, first.CreatedAt
+	for _, row := range rows {
+		if row.CreatedAt.Before(oldest) {
+			oldest = row.CreatedAt
+		}
+		if row.CreatedAt.After(newest) {
+			newest = row.CreatedAt
+		}
+	}
+	return &models.AuditArchive{
+		Location:   location,
+		RowCount:   written,
+		FirstLogID: first.ID,
+		LastLogID:  last.ID,
+		OldestAt:   oldest,
+		NewestAt:   newest,
+	}, nil
+}
+
+func auditPurgeDetails(purged, archives int, archived bool) string {
+	if !archived {
+		return fmt.Sprintf("Purged %d audit logs (archiving disabled)", purged)
+	}
+	return fmt.Sprintf("Archived and purged %d audit logs in %d files", purged, archives)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/interfaces"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -19,8 +22,39 @@ type EnhancedLoginInput struct {
 	DeviceID string `json:"deviceId,omitempty"` // Optional device identifier
 }
 
-// EnhancedLogin handles user authentication with stateless JWT tokens
-func EnhancedLogin(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
+// issueToken signs a 24-hour JWT for the user and, when session tracking is enabled,
+// records it as a session so it can later be revoked.
+func issueToken(c *gin.Context, sessions interfaces.SessionService, userID uint, jwtSecret string) (string, time.Time, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	expirationTime := time.Now().UTC().Add(24 * time.Hour)
+	claims := &jwt.RegisteredClaims{
+		ID:        hex.EncodeToString(tokenID),
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		ExpiresAt: jwt.NewNumericDate(expirationTime),
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(jwtSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if sessions != nil {
+		if _, err := sessions.CreateSession(c.Request.Context(), userID, tokenString, c.ClientIP(), c.Request.UserAgent(), expirationTime); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// EnhancedLogin handles user authentication with JWT tokens tracked as sessions
+func EnhancedLogin(db *gorm.DB, jwtSecret string, sessions interfaces.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input EnhancedLoginInput
 		var user models.User
@@ -43,26 +77,18 @@ func EnhancedLogin(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		// Step 4: Generate JWT Token with explicit UTC time (24-hour expiration)
-		expirationTime := time.Now().UTC().Add(24 * time.Hour)
-		claims := &jwt.RegisteredClaims{
-			Subject:   strconv.FormatUint(uint64(user.ID), 10),
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		}
-
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString([]byte(jwtSecret))
+		// Step 4: Generate JWT Token with explicit UTC time (24-hour expiration) and record its session
+		tokenString, expirationTime, err := issueToken(c, sessions, user.ID, jwtSecret)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error: could not create token"})
 			return
 		}
 
-		// Step 5: Mark last login (no session creation needed)
+		// Step 5: Mark last login
 		now := time.Now().UTC()
 		_ = db.Model(&user).Update("last_login", &now).Error
 
-		// Step 6: Return Token and User Info (stateless)
+		// Step 6: Return Token and User Info
 		c.JSON(http.StatusOK, gin.H{
 			"token":     tokenString,
 			"expiresAt": expirationTime,
@@ -116,8 +142,8 @@ type RefreshTokenInput struct {
 	// No session ID needed in stateless system
 }
 
-// RefreshToken generates a new token for the authenticated user (stateless)
-func RefreshToken(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
+// RefreshToken generates a new token for the authenticated user
+func RefreshToken(db *gorm.DB, jwtSecret string, sessions interfaces.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, _ := c.Get("userID")
 		userIDUint, _ := strconv.ParseUint(userID.(string), 10, 32)
@@ -130,15 +156,7 @@ func RefreshToken(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
 		}
 
 		// Generate new token with explicit UTC time (24-hour expiration)
-		expirationTime := time.Now().UTC().Add(24 * time.Hour)
-		claims := &jwt.RegisteredClaims{
-			Subject:   strconv.FormatUint(uint64(userIDUint), 10),
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		}
-
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString([]byte(jwtSecret))
+		tokenString, expirationTime, err := issueToken(c, sessions, user.ID, jwtSecret)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate new token"})
 			return
@@ -0,0 +1,484 @@
+// api/handlers/bulk_operations.go
+// Bulk case and appointment operations. Every ID is checked for existence and the
+// caller's office scope before anything runs, and destructive operations need an
+// explicit confirmation.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Supported bulk operations.
+const (
+	BulkDeleteCases        = "delete_cases"
+	BulkArchiveCases       = "archive_cases"
+	BulkUpdateCases        = "update_cases"
+	BulkDeleteAppointments = "delete_appointments"
+)
+
+// bulkOperations lists the supported operations, as reported when an unknown one is requested.
+var bulkOperations = []string{BulkDeleteCases, BulkArchiveCases, BulkUpdateCases, BulkDeleteAppointments}
+
+// bulkUpdateFields are the case columns update_cases may set.
+var bulkUpdateFields = map[string]bool{
+	"status":        true,
+	"current_stage": true,
+	"priority":      true,
+	"office_id":     true,
+}
+
+// errBulkItemsChanged aborts a bulk write when fewer rows match than were validated, i.e. some
+// items changed between validation and execution.
+var errBulkItemsChanged = errors.New("bulk items changed since validation")
+
+// maxBulkItems caps how many IDs a single bulk request may target.
+const maxBulkItems = 500
+
+// destructiveBulkOperations require Confirm on execution.
+var destructiveBulkOperations = map[string]bool{
+	BulkDeleteCases:        true,
+	BulkDeleteAppointments: true,
+}
+
+// BulkOperationInput names an operation and the IDs it targets.
+type BulkOperationInput struct {
+	Operation string `json:"operation" binding:"required"`
+	IDs       []uint `json:"ids" binding:"required"`
+	Confirm   bool   `json:"confirm"` // Required for destructive operations
+	Reason    string `json:"reason"`
+	// Force lets delete_cases include cases with upcoming appointments or open tasks, which are
+	// cancelled along with them, as ?force=true does for DeleteCase
+	Force bool `json:"force"`
+	// Columns and values for update_cases; keys must be in bulkUpdateFields
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// bulkItemResult reports whether one ID can be included in the operation.
+type bulkItemResult struct {
+	ID     uint   `json:"id"`
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"` // not_found, out_of_scope, already_archived, not_completed, invalid_stage, completed, past, pending_work
+
+	// Pending work of a case that delete_cases would drop, reported with pending_work
+	UpcomingAppointments int64 `json:"upcomingAppointments,omitempty"`
+	OpenTasks            int64 `json:"openTasks,omitempty"`
+}
+
+// bulkValidation is the per-item report for a bulk request.
+type bulkValidation struct {
+	Operation    string           `json:"operation"`
+	Destructive  bool             `json:"destructive"`
+	Valid        bool             `json:"valid"`
+	ValidCount   int              `json:"validCount"`
+	InvalidCount int              `json:"invalidCount"`
+	Items        []bulkItemResult `json:"items"`
+
+	targets map[uint]bulkTarget // State of each found item at validation
+}
+
+// bulkTarget is the subset of a case or appointment needed to validate it.
+type bulkTarget struct {
+	ID           uint
+	OfficeID     uint
+	Status       string
+	CurrentStage string
+	Category     string
+	IsCompleted  bool
+	IsArchived   bool
+	StartTime    *time.Time
+}
+
+// ValidateBulkOperation reports which IDs a bulk operation may act on, without changing anything.
+func ValidateBulkOperation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input, ok := bindBulkOperationInput(db, c)
+		if !ok {
+			return
+		}
+		report, err := validateBulkItems(db, c, input)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la operación masiva", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ExecuteBulkOperation re-validates the IDs and runs the operation only when every item is valid.
+// Destructive operations are rejected unless confirm is true. Deleting cases cancels their
+// upcoming appointments and open tasks in the same transaction, so none is left active on a
+// deleted case.
+func ExecuteBulkOperation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input, ok := bindBulkOperationInput(db, c)
+		if !ok {
+			return
+		}
+		report, err := validateBulkItems(db, c, input)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la operación masiva", "message": err.Error()})
+			return
+		}
+		if !report.Valid {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "Algunos elementos no son válidos o están fuera de su alcance; no se realizó ningún cambio",
+				"validation": report,
+			})
+			return
+		}
+		if report.Destructive && !input.Confirm {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":                "Esta operación elimina registros y requiere confirmación",
+				"confirmationRequired": true,
+				"validation":           report,
+			})
+			return
+		}
+
+		ids := make([]uint, 0, len(report.Items))
+		for _, item := range report.Items {
+			ids = append(ids, item.ID)
+		}
+		userID := extractUserIDUint(c)
+		now := time.Now()
+
+		reason := input.Reason
+		var affected, cancelledTasks int64
+		var cancelledAppointments []uint
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var result *gorm.DB
+			switch input.Operation {
+			case BulkDeleteCases:
+				if reason == "" {
+					reason = "Manual deletion"
+				}
+				var err error
+				if cancelledAppointments, cancelledTasks, err = cancelPendingCaseWork(tx, ids, now); err != nil {
+					return err
+				}
+				result = tx.Model(&models.Case{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+					"deleted_at":      now,
+					"deleted_by":      userID,
+					"deletion_reason": reason,
+				})
+			case BulkArchiveCases:
+				// Same transition as completing a single case: archived and closed
+				result = tx.Model(&models.Case{}).Where("id IN ? AND is_archived = ? AND deleted_at IS NULL", ids, false).
+					Updates(map[string]interface{}{
+						"status":         config.CaseStatusClosed,
+						"is_archived":    true,
+						"archived_at":    now,
+						"archived_by":    userID,
+						"archive_reason": "completed",
+						"updated_by":     userID,
+					})
+			case BulkUpdateCases:
+				updates := map[string]interface{}{"updated_by": userID}
+				for column, value := range input.Fields {
+					updates[column] = value
+				}
+				result = tx.Model(&models.Case{}).Where("id IN ? AND deleted_at IS NULL", ids).Updates(updates)
+			case BulkDeleteAppointments:
+				result = tx.Model(&models.Appointment{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+					"status":     config.StatusCancelled,
+					"deleted_at": now,
+				})
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			affected = result.RowsAffected
+			// Archive and update are all or nothing: an item archived or deleted since it was
+			// validated rolls the whole batch back
+			if (input.Operation == BulkArchiveCases || input.Operation == BulkUpdateCases) && affected != int64(len(ids)) {
+				return errBulkItemsChanged
+			}
+			return nil
+		})
+		if errors.Is(err, errBulkItemsChanged) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Algunos elementos cambiaron desde la validación; no se realizó ningún cambio",
+				"expected": len(ids),
+				"matched":  affected,
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al ejecutar la operación masiva", "message": err.Error()})
+			return
+		}
+
+		entityType, action := "case", "delete"
+		switch input.Operation {
+		case BulkArchiveCases:
+			action = "archive"
+		case BulkUpdateCases:
+			action = "update"
+		case BulkDeleteAppointments:
+			entityType = "appointment"
+		}
+		for _, id := range ids {
+			if entityType == "case" {
+				invalidateCache(strconv.FormatUint(uint64(id), 10))
+			}
+			values := map[string]interface{}{
+				"bulkOperation": input.Operation,
+				"batchSize":     len(ids),
+			}
+			if input.Operation == BulkUpdateCases {
+				values["fields"] = input.Fields
+			}
+			recordAuditLog(db, c, entityType, id, action, input.Reason, values)
+
+			if input.Operation == BulkArchiveCases || input.Operation == BulkUpdateCases {
+				previous := report.targets[id]
+				updated := models.Case{ID: id, OfficeID: previous.OfficeID, CurrentStage: previous.CurrentStage, Status: previous.Status}
+				if input.Operation == BulkArchiveCases {
+					updated.Status = string(config.CaseStatusClosed)
+				}
+				if status, ok := input.Fields["status"].(string); ok {
+					updated.Status = status
+				}
+				if stage, ok := input.Fields["current_stage"].(string); ok {
+					updated.CurrentStage = stage
+				}
+				if officeID, ok := input.Fields["office_id"].(uint); ok {
+					updated.OfficeID = officeID
+				}
+				recordCaseStageChange(db, &updated, previous.CurrentStage, previous.Status, &userID)
+			}
+		}
+		if input.Operation == BulkDeleteAppointments {
+			// Only appointments that were not already cancelled before the operation
+			newlyCancelled := make([]uint, 0, len(ids))
+			for _, id := range ids {
+				if report.targets[id].Status != string(config.StatusCancelled) {
+					newlyCancelled = append(newlyCancelled, id)
+				}
+			}
+			if len(newlyCancelled) > 0 {
+				var cancelled []models.Appointment
+				db.Unscoped().Where("id IN ?", newlyCancelled).Find(&cancelled)
+				notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, userID, cancelled...)
+			}
+		}
+		if len(cancelledAppointments) > 0 {
+			var cancelled []models.Appointment
+			db.Where("id IN ?", cancelledAppointments).Find(&cancelled)
+			notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, userID, cancelled...)
+		}
+
+		response := gin.H{
+			"operation": input.Operation,
+			"affected":  affected,
+			"ids":       ids,
+		}
+		if input.Operation == BulkDeleteCases {
+			response["cancelledAppointments"] = len(cancelledAppointments)
+			response["cancelledTasks"] = cancelledTasks
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// bindBulkOperationInput parses and sanity-checks the request body, writing a 400 on failure.
+// For update_cases the fields are checked and normalized in place.
+func bindBulkOperationInput(db *gorm.DB, c *gin.Context) (BulkOperationInput, bool) {
+	var input BulkOperationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "message": err.Error()})
+		return input, false
+	}
+	switch input.Operation {
+	case BulkDeleteCases, BulkArchiveCases, BulkUpdateCases, BulkDeleteAppointments:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Operación masiva no soportada",
+			"message":    input.Operation,
+			"operations": bulkOperations,
+		})
+		return input, false
+	}
+	if len(input.IDs) == 0 || len(input.IDs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar entre 1 y 500 IDs"})
+		return input, false
+	}
+	if input.Operation != BulkUpdateCases {
+		input.Fields = nil
+		return input, true
+	}
+	fields, ok := parseBulkUpdateFields(db, c, input.Fields)
+	input.Fields = fields
+	return input, ok
+}
+
+// parseBulkUpdateFields checks the update_cases fields against bulkUpdateFields and their
+// allowed values, writing a 400 (or 403 for another office) on failure. office_id is
+// returned as a uint.
+func parseBulkUpdateFields(db *gorm.DB, c *gin.Context, fields map[string]interface{}) (map[string]interface{}, bool) {
+	allowed := make([]string, 0, len(bulkUpdateFields))
+	for column := range bulkUpdateFields {
+		allowed = append(allowed, column)
+	}
+	sort.Strings(allowed)
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar los campos a actualizar", "allowed": allowed})
+		return nil, false
+	}
+	unknown := make([]string, 0)
+	for column := range fields {
+		if !bulkUpdateFields[column] {
+			unknown = append(unknown, column)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Campos no permitidos", "fields": unknown, "allowed": allowed})
+		return nil, false
+	}
+
+	invalid := func(column string) (map[string]interface{}, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valor inválido", "fields": []string{column}})
+		return nil, false
+	}
+	normalized := make(map[string]interface{}, len(fields))
+	for column, value := range fields {
+		switch column {
+		case "status":
+			raw, _ := value.(string)
+			status, ok := config.NormalizeCaseStatus(raw)
+			// Archiving goes through archive_cases so the archive columns are set
+			if !ok || status == config.CaseStatusArchived {
+				return invalid(column)
+			}
+			normalized[column] = string(status)
+		case "priority":
+			priority, ok := value.(string)
+			if _, known := config.PriorityLabels[priority]; !ok || !known {
+				return invalid(column)
+			}
+			normalized[column] = priority
+		case "current_stage":
+			// Checked against each case's category in validateBulkItems
+			stage, ok := value.(string)
+			if !ok || !config.IsValidStageLegacy(stage) {
+				return invalid(column)
+			}
+			normalized[column] = stage
+		case "office_id":
+			number, ok := value.(float64)
+			if !ok || number < 1 || number != float64(uint(number)) {
+				return invalid(column)
+			}
+			officeID := uint(number)
+			if !config.CanAccessAllOffices(c.GetString("userRole")) {
+				if scope, _ := c.Get("officeScopeID"); scope != officeID {
+					c.JSON(http.StatusForbidden, gin.H{"error": "Solo puede asignar casos a su oficina", "fields": []string{column}})
+					return nil, false
+				}
+			}
+			var office models.Office
+			if err := db.Select("id").First(&office, officeID).Error; err != nil {
+				return invalid(column)
+			}
+			normalized[column] = officeID
+		}
+	}
+	return normalized, true
+}
+
+// validateBulkItems checks each (deduplicated) ID exists, is within the caller's office scope
+// and is in a state the operation applies to. Without force, cases with pending work cannot be
+// deleted.
+func validateBulkItems(db *gorm.DB, c *gin.Context, input BulkOperationInput) (bulkValidation, error) {
+	operation, ids := input.Operation, input.IDs
+	report := bulkValidation{Operation: operation, Destructive: destructiveBulkOperations[operation]}
+
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var targets []bulkTarget
+	var err error
+	if operation == BulkDeleteAppointments {
+		err = db.Model(&models.Appointment{}).Select("id, office_id, status, start_time").Where("id IN ?", unique).Scan(&targets).Error
+	} else {
+		err = db.Model(&models.Case{}).Select("id, office_id, status, current_stage, category, is_completed, is_archived").
+			Where("id IN ? AND deleted_at IS NULL", unique).Scan(&targets).Error
+	}
+	if err != nil {
+		return report, err
+	}
+	byID := make(map[uint]bulkTarget, len(targets))
+	for _, t := range targets {
+		byID[t.ID] = t
+	}
+	report.targets = byID
+	stage, updatesStage := input.Fields["current_stage"].(string)
+
+	var pending map[uint]casePendingWork
+	if operation == BulkDeleteCases && !input.Force && len(targets) > 0 {
+		if pending, err = loadPendingCaseWork(db, unique, time.Now()); err != nil {
+			return report, err
+		}
+	}
+
+	role := c.GetString("userRole")
+	scopeOffice, scoped := uint(0), !config.CanAccessAllOffices(role)
+	if scoped {
+		if v, ok := c.Get("officeScopeID"); ok {
+			scopeOffice, _ = v.(uint)
+		}
+	}
+
+	report.Items = make([]bulkItemResult, 0, len(unique))
+	for _, id := range unique {
+		item := bulkItemResult{ID: id}
+		target, found := byID[id]
+		switch {
+		case !found:
+			item.Reason = "not_found"
+		case scoped && (scopeOffice == 0 || target.OfficeID != scopeOffice):
+			item.Reason = "out_of_scope"
+		case operation == BulkArchiveCases && target.IsArchived:
+			item.Reason = "already_archived"
+		case operation == BulkArchiveCases && !target.IsCompleted && !config.IsClosedCaseStatus(target.Status):
+			item.Reason = "not_completed"
+		case operation == BulkUpdateCases && updatesStage && !config.IsValidStage(stage, target.Category):
+			item.Reason = "invalid_stage"
+		// Mirrors DeleteAppointmentAdmin: only admins may remove completed or past appointments
+		case operation == BulkDeleteAppointments && role != config.RoleAdmin && target.Status == string(config.StatusCompleted):
+			item.Reason = "completed"
+		case operation == BulkDeleteAppointments && role != config.RoleAdmin && target.StartTime != nil && target.StartTime.Before(time.Now()):
+			item.Reason = "past"
+		// Mirrors DeleteCase: upcoming appointments or open tasks require force
+		case pending[id] != (casePendingWork{}):
+			item.Reason = "pending_work"
+			item.UpcomingAppointments, item.OpenTasks = pending[id].UpcomingAppointments, pending[id].OpenTasks
+		default:
+			item.Valid = true
+		}
+		if item.Valid {
+			report.ValidCount++
+		} else {
+			report.InvalidCount++
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.Valid = report.InvalidCount == 0
+	return report, nil
+}
@@ -0,0 +1,390 @@
+// api/handlers/bulk_operations_test.go
+// Unit tests for bulk case updates, archiving and deletion: against a scripted SQL driver where
+// transactions and affected row counts must be observed, and against an SQLite schema for the
+// rows each operation leaves behind.
+package handlers
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// bulkTargetColumns are the columns validateBulkItems selects for cases.
+var bulkTargetColumns = []string{"id", "office_id", "status", "current_stage", "category", "is_completed", "is_archived"}
+
+// casesScript answers the bulk validation query with the given case rows and reports
+// affected rows for every UPDATE.
+func casesScript(cases [][]driver.Value, affected int64) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "cases"`) {
+				return bulkTargetColumns, cases
+			}
+			if strings.Contains(query, `FROM "offices"`) {
+				return []string{"id"}, [][]driver.Value{{int64(2)}}
+			}
+			return nil, nil
+		},
+		affected: answerUpdates(affected),
+	}
+}
+
+// executeBulk posts body to ExecuteBulkOperation as the given role in office 2.
+func executeBulk(t *testing.T, db *gorm.DB, role string, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/bulk-operations/execute", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", role)
+	c.Set("officeScopeID", uint(2))
+	ExecuteBulkOperation(db)(c)
+	return w
+}
+
+func TestBulkUpdateCasesRejectsInvalidFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		fields map[string]interface{}
+		want   int
+	}{
+		{"no fields", "admin", map[string]interface{}{}, http.StatusBadRequest},
+		{"unknown field", "admin", map[string]interface{}{"status": "open", "title": "Nuevo"}, http.StatusBadRequest},
+		{"column injection", "admin", map[string]interface{}{"status = 'closed', fee": 0}, http.StatusBadRequest},
+		{"invalid status", "admin", map[string]interface{}{"status": "deleted"}, http.StatusBadRequest},
+		{"archived status", "admin", map[string]interface{}{"status": "archived"}, http.StatusBadRequest},
+		{"invalid priority", "admin", map[string]interface{}{"priority": "whenever"}, http.StatusBadRequest},
+		{"unknown stage", "admin", map[string]interface{}{"current_stage": "limbo"}, http.StatusBadRequest},
+		{"fractional office", "admin", map[string]interface{}{"office_id": 2.5}, http.StatusBadRequest},
+		{"another office", "office_manager", map[string]interface{}{"office_id": 3}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := casesScript(nil, 0)
+			db := scriptedDB(t, script)
+			w := executeBulk(t, db, tt.role, map[string]interface{}{
+				"operation": BulkUpdateCases,
+				"ids":       []uint{1, 2},
+				"fields":    tt.fields,
+			})
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if ran := script.ran("UPDATE"); len(ran) > 0 {
+				t.Errorf("rejected request ran %q", ran)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateCasesInvalidItemChangesNothing(t *testing.T) {
+	// Case 2 is Penal, whose stages do not include the legal "etapa_inicial"
+	script := casesScript([][]driver.Value{
+		{int64(1), int64(2), "open", "intake", "Familiar", false, false},
+		{int64(2), int64(2), "open", "intake", "Penal", false, false},
+	}, 2)
+	db := scriptedDB(t, script)
+	w := executeBulk(t, db, "admin", map[string]interface{}{
+		"operation": BulkUpdateCases,
+		"ids":       []uint{1, 2},
+		"fields":    map[string]interface{}{"current_stage": "etapa_inicial", "priority": "high"},
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "invalid_stage") {
+		t.Errorf("response does not flag the invalid stage: %s", w.Body.String())
+	}
+	if ran := script.ran("UPDATE"); len(ran) > 0 {
+		t.Errorf("invalid batch ran %q", ran)
+	}
+}
+
+func TestBulkOperationsRollBackPartialWrites(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		fields    map[string]interface{}
+	}{
+		{"update", BulkUpdateCases, map[string]interface{}{"status": "in_progress", "priority": "urgent"}},
+		{"archive", BulkArchiveCases, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Both cases validate, but only one still matches when the write runs
+			script := casesScript([][]driver.Value{
+				{int64(1), int64(2), "closed", "cerrado", "Familiar", true, false},
+				{int64(2), int64(2), "closed", "cerrado", "Familiar", true, false},
+			}, 1)
+			db := scriptedDB(t, script)
+			w := executeBulk(t, db, "admin", map[string]interface{}{
+				"operation": tt.operation,
+				"ids":       []uint{1, 2},
+				"fields":    tt.fields,
+			})
+			if w.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want 409: %s", w.Code, w.Body.String())
+			}
+			if len(script.ran("UPDATE")) != 1 || len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+				t.Errorf("expected one rolled back UPDATE, got %q", script.statements)
+			}
+			if ran := script.ran("audit_logs"); len(ran) > 0 {
+				t.Errorf("rolled back batch was audited: %q", ran)
+			}
+		})
+	}
+}
+
+func TestBulkOperationsReportAffectedRows(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		fields    map[string]interface{}
+		wantSet   []string
+	}{
+		{"update", BulkUpdateCases, map[string]interface{}{"status": "in_progress", "office_id": 2},
+			[]string{`"status"=$`, `"office_id"=$`, `"updated_by"=$`}},
+		{"archive", BulkArchiveCases, nil,
+			[]string{`"status"=$`, `"is_archived"=$`, `"archived_at"=$`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := casesScript([][]driver.Value{
+				{int64(1), int64(2), "pending", "cerrado", "Familiar", true, false},
+				{int64(2), int64(2), "pending", "cerrado", "Familiar", true, false},
+			}, 2)
+			db := scriptedDB(t, script)
+			w := executeBulk(t, db, "office_manager", map[string]interface{}{
+				"operation": tt.operation,
+				"ids":       []uint{1, 2, 2},
+				"fields":    tt.fields,
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			var response struct {
+				Affected int64 `json:"affected"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Affected != 2 {
+				t.Errorf("affected = %d, want 2", response.Affected)
+			}
+
+			updates := script.ran("UPDATE")
+			if len(updates) != 1 {
+				t.Fatalf("expected a single UPDATE, got %q", updates)
+			}
+			for _, set := range tt.wantSet {
+				if !strings.Contains(updates[0], set) {
+					t.Errorf("UPDATE does not set %s: %s", set, updates[0])
+				}
+			}
+			if audits := script.ran(`INSERT INTO "audit_logs"`); len(audits) != 2 {
+				t.Errorf("expected an audit entry per case, got %d", len(audits))
+			}
+			if history := script.ran(`INSERT INTO "case_status_history"`); len(history) != 2 {
+				t.Errorf("expected a status history entry per case, got %d", len(history))
+			}
+		})
+	}
+}
+
+// pendingWorkScript is casesScript for cases 1 and 2 of office 2, where case 1 has upcoming
+// appointment 7 and two open tasks.
+func pendingWorkScript() *scriptedSQL {
+	script := casesScript([][]driver.Value{
+		{int64(1), int64(2), "open", "etapa_inicial", "Familiar", false, false},
+		{int64(2), int64(2), "open", "etapa_inicial", "Familiar", false, false},
+	}, 2)
+	cases := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.HasPrefix(query, `SELECT case_id, COUNT(*) AS total FROM "appointments"`):
+			return []string{"case_id", "total"}, [][]driver.Value{{int64(1), int64(1)}}
+		case strings.HasPrefix(query, `SELECT case_id, COUNT(*) AS total FROM "tasks"`):
+			return []string{"case_id", "total"}, [][]driver.Value{{int64(1), int64(2)}}
+		case strings.HasPrefix(query, `SELECT "id" FROM "appointments"`):
+			return []string{"id"}, [][]driver.Value{{int64(7)}}
+		case strings.HasPrefix(query, `SELECT * FROM "appointments"`):
+			return []string{"id", "case_id", "status"}, [][]driver.Value{{int64(7), int64(1), "confirmed"}}
+		}
+		return cases(query, args)
+	}
+	return script
+}
+
+func TestBulkDeleteCasesWithPendingWorkNeedsForce(t *testing.T) {
+	script := pendingWorkScript()
+	w := executeBulk(t, scriptedDB(t, script), "admin", map[string]interface{}{
+		"operation": BulkDeleteCases,
+		"ids":       []uint{1, 2},
+		"confirm":   true,
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Validation bulkValidation `json:"validation"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	items := response.Validation.Items
+	if len(items) != 2 || items[0].Reason != "pending_work" || items[0].UpcomingAppointments != 1 || items[0].OpenTasks != 2 || !items[1].Valid {
+		t.Errorf("items = %+v", items)
+	}
+	if updates := script.ran("UPDATE"); len(updates) != 0 {
+		t.Errorf("rejected deletion wrote %q", updates)
+	}
+}
+
+func TestBulkDeleteCasesForceCancelsPendingWork(t *testing.T) {
+	script := pendingWorkScript()
+	w := executeBulk(t, scriptedDB(t, script), "admin", map[string]interface{}{
+		"operation": BulkDeleteCases,
+		"ids":       []uint{1, 2},
+		"confirm":   true,
+		"force":     true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Affected              int64 `json:"affected"`
+		CancelledAppointments int   `json:"cancelledAppointments"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Affected != 2 || response.CancelledAppointments != 1 {
+		t.Errorf("response = %s", w.Body.String())
+	}
+
+	// The appointments and tasks are cancelled and the cases deleted in one transaction
+	var statements []string
+	for _, statement := range script.ran("") {
+		if statement == "BEGIN" || statement == "COMMIT" || strings.HasPrefix(statement, "UPDATE") {
+			statements = append(statements, statement)
+		}
+	}
+	want := []string{"BEGIN", `UPDATE "appointments" SET "status"=`, `UPDATE "tasks" SET "status"=`, `UPDATE "cases" SET "deleted_at"=`, "COMMIT"}
+	if len(statements) < len(want) {
+		t.Fatalf("statements = %q", statements)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(statements[i], prefix) {
+			t.Errorf("statement %d = %q, want %s...", i, statements[i], prefix)
+		}
+	}
+	if !strings.Contains(statements[1], `WHERE id IN ($`) || !strings.Contains(statements[2], "case_id IN ($") {
+		t.Errorf("cancellations = %q", statements[1:3])
+	}
+}
+
+// bulkSchema has completed cases 1 and 2 of office 2, where case 1 has upcoming appointment 7 and an open
+// task, and case 3, which no operation targets.
+func bulkSchema(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := schemaDB(t, &models.User{}, &models.Case{}, &models.Appointment{}, &models.Task{}, &models.AuditLog{}, &models.CaseStatusHistory{},
+		&models.WebhookSubscription{})
+	upcoming := time.Now().Add(48 * time.Hour)
+	seedRows(t, db,
+		&models.User{ID: 1, FirstName: "Ana", LastName: "Admin", Email: "admin@caf.mx", Password: "x", Role: "admin", IsActive: true},
+		&models.Case{ID: 1, CaseNumber: "CAF-0001", OfficeID: 2, Title: "Divorcio", Status: "open", CurrentStage: "etapa_inicial", Category: "Familiar", IsCompleted: true},
+		&models.Case{ID: 2, CaseNumber: "CAF-0002", OfficeID: 2, Title: "Custodia", Status: "open", CurrentStage: "etapa_inicial", Category: "Familiar", IsCompleted: true},
+		&models.Case{ID: 3, CaseNumber: "CAF-0003", OfficeID: 2, Title: "Pensión alimenticia", Status: "open", CurrentStage: "etapa_inicial", Category: "Familiar"},
+		&models.Appointment{ID: 7, CaseID: 1, StaffID: 1, OfficeID: 2, Title: "Audiencia", Status: "confirmed", StartTime: upcoming, EndTime: upcoming.Add(time.Hour)},
+		&models.Task{ID: 8, CaseID: 1, Title: "Solicitar acta", Status: "pending"},
+	)
+	return db
+}
+
+func TestBulkOperationsWriteTargetRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  map[string]interface{}
+		check func(t *testing.T, changed models.Case)
+	}{
+		{"update", map[string]interface{}{"operation": BulkUpdateCases, "ids": []uint{1, 2}, "fields": map[string]interface{}{"status": "in_progress", "priority": "high"}},
+			func(t *testing.T, changed models.Case) {
+				if changed.Status != "in_progress" || changed.Priority != "high" || changed.UpdatedBy == nil || *changed.UpdatedBy != 1 {
+					t.Errorf("case %d = status %q, priority %q, updated by %v", changed.ID, changed.Status, changed.Priority, changed.UpdatedBy)
+				}
+			}},
+		{"archive", map[string]interface{}{"operation": BulkArchiveCases, "ids": []uint{1, 2}},
+			func(t *testing.T, changed models.Case) {
+				if !changed.IsArchived || changed.Status != "closed" || changed.ArchivedAt == nil || changed.ArchivedBy == nil || *changed.ArchivedBy != 1 {
+					t.Errorf("case %d = archived %v at %v by %v, status %q", changed.ID, changed.IsArchived, changed.ArchivedAt, changed.ArchivedBy, changed.Status)
+				}
+			}},
+		{"delete", map[string]interface{}{"operation": BulkDeleteCases, "ids": []uint{1, 2}, "confirm": true, "force": true},
+			func(t *testing.T, changed models.Case) {
+				if changed.DeletedAt == nil || changed.DeletedBy == nil || *changed.DeletedBy != 1 || changed.DeletionReason != "Manual deletion" {
+					t.Errorf("case %d = deleted at %v by %v: %q", changed.ID, changed.DeletedAt, changed.DeletedBy, changed.DeletionReason)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := bulkSchema(t)
+			if w := executeBulk(t, db, "admin", tt.body); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			var cases []models.Case
+			db.Order("id").Find(&cases)
+			if len(cases) != 3 {
+				t.Fatalf("cases = %+v", cases)
+			}
+			tt.check(t, cases[0])
+			tt.check(t, cases[1])
+			if untouched := cases[2]; untouched.Status != "open" || untouched.IsArchived || untouched.DeletedAt != nil || untouched.UpdatedBy != nil {
+				t.Errorf("case 3 changed: %+v", untouched)
+			}
+
+			var audited int64
+			db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id IN ?", "case", []uint{1, 2}).Count(&audited)
+			if audited != 2 {
+				t.Errorf("%d audit entries, want one per case", audited)
+			}
+		})
+	}
+}
+
+func TestBulkDeleteCasesForceCancelsPendingWorkRows(t *testing.T) {
+	db := bulkSchema(t)
+	w := executeBulk(t, db, "admin", map[string]interface{}{
+		"operation": BulkDeleteCases,
+		"ids":       []uint{1, 2},
+		"confirm":   true,
+		"force":     true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var appointment models.Appointment
+	db.Unscoped().First(&appointment, 7)
+	var task models.Task
+	db.Unscoped().First(&task, 8)
+	if appointment.Status != "cancelled" || task.Status != "cancelled" {
+		t.Errorf("appointment %q, task %q, want both cancelled", appointment.Status, task.Status)
+	}
+}
@@ -76,8 +76,13 @@ func setCache(caseID string, light bool, caseData *models.Case) {
 	}
 }
 
-// invalidateCache removes a case from cache
+// invalidateCache removes a case from cache on this and every other replica
 func invalidateCache(caseID string) {
+	invalidateResource("cases", caseID)
+}
+
+// invalidateLocalCase removes a case from this instance's cache only
+func invalidateLocalCase(caseID string) {
 	caseCache.mutex.Lock()
 	defer caseCache.mutex.Unlock()
 
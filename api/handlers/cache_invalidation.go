@@ -0,0 +1,297 @@
+// api/handlers/cache_invalidation.go
+// Cross-replica cache invalidation. Each instance keeps in-memory caches (caseCache and
+// CacheManager's memory tier); when Redis is configured, invalidations are broadcast on a
+// pub/sub channel so every replica clears its own copy.
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// CacheInvalidationChannel is the Redis pub/sub channel carrying invalidation events.
+const CacheInvalidationChannel = "caf:cache-invalidation"
+
+// cacheInvalidationEvent is broadcast to other replicas. An empty ID invalidates the
+// resource's list caches; a case ID also drops that case from caseCache.
+type cacheInvalidationEvent struct {
+	Origin   string `json:"origin"`
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+}
+
+// cacheBus holds the Redis client and the local caches to clear on invalidation.
+var cacheBus = struct {
+	mutex        sync.RWMutex
+	client       *redis.Client
+	origin       string
+	invalidators []func(resource, id string, local bool)
+}{
+	origin: fmt.Sprintf("%s-%d-%d", hostnameOrUnknown(), os.Getpid(), time.Now().UnixNano()),
+}
+
+// registerCacheInvalidator adds a local cache to clear whenever a resource is invalidated.
+// local is true on the replica where the write happened and false for events from other replicas.
+func registerCacheInvalidator(fn func(resource, id string, local bool)) {
+	cacheBus.mutex.Lock()
+	defer cacheBus.mutex.Unlock()
+	cacheBus.invalidators = append(cacheBus.invalidators, fn)
+}
+
+// StartCacheInvalidation invalidates list caches whenever cases or appointments are written
+// through db and, when redisClient is set, subscribes to invalidations from other replicas.
+// The subscriber stops when ctx is cancelled.
+func StartCacheInvalidation(ctx context.Context, db *gorm.DB, redisClient *redis.Client) error {
+	if err := registerCacheInvalidationCallbacks(db); err != nil {
+		return err
+	}
+	if redisClient == nil {
+		return nil
+	}
+
+	cacheBus.mutex.Lock()
+	cacheBus.client = redisClient
+	cacheBus.mutex.Unlock()
+
+	pubsub := redisClient.Subscribe(ctx, CacheInvalidationChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", CacheInvalidationChannel, err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			var event cacheInvalidationEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("WARNING: Ignoring malformed cache invalidation event: %v", err)
+				continue
+			}
+			if event.Origin == cacheBus.origin {
+				continue
+			}
+			applyLocalInvalidation(event.Resource, event.ID, false)
+		}
+	}()
+	return nil
+}
+
+// invalidateResource clears local caches for a resource (and optionally one ID) and
+// broadcasts the invalidation to other replicas.
+func invalidateResource(resource, id string) {
+	applyLocalInvalidation(resource, id, true)
+
+	cacheBus.mutex.RLock()
+	client := cacheBus.client
+	cacheBus.mutex.RUnlock()
+	if client == nil {
+		return
+	}
+
+	payload, err := json.Marshal(cacheInvalidationEvent{Origin: cacheBus.origin, Resource: resource, ID: id})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Publish(ctx, CacheInvalidationChannel, payload).Err(); err != nil {
+		log.Printf("WARNING: Failed to publish cache invalidation for %s %s: %v", resource, id, err)
+	}
+}
+
+// applyLocalInvalidation clears this instance's caches only.
+func applyLocalInvalidation(resource, id string, local bool) {
+	if resource == "cases" && id != "" {
+		invalidateLocalCase(id)
+	}
+
+	cacheBus.mutex.RLock()
+	invalidators := cacheBus.invalidators
+	cacheBus.mutex.RUnlock()
+	for _, fn := range invalidators {
+		fn(resource, id, local)
+	}
+}
+
+// cacheInvalidationDelay is how long written resources are collected before their list caches
+// are invalidated, so a transaction writing many rows invalidates each resource once.
+var cacheInvalidationDelay = 200 * time.Millisecond
+
+// pendingInvalidations holds the resources written since the last flush.
+var pendingInvalidations = struct {
+	mutex     sync.Mutex
+	resources map[string]struct{}
+	timer     *time.Timer
+}{}
+
+// queueInvalidation invalidates the resources' list caches after cacheInvalidationDelay, off
+// the writing goroutine.
+func queueInvalidation(resources ...string) {
+	if len(resources) == 0 {
+		return
+	}
+	pendingInvalidations.mutex.Lock()
+	defer pendingInvalidations.mutex.Unlock()
+	if pendingInvalidations.resources == nil {
+		pendingInvalidations.resources = map[string]struct{}{}
+	}
+	for _, resource := range resources {
+		pendingInvalidations.resources[resource] = struct{}{}
+	}
+	if pendingInvalidations.timer == nil {
+		pendingInvalidations.timer = time.AfterFunc(cacheInvalidationDelay, flushInvalidations)
+	}
+}
+
+// flushInvalidations invalidates every queued resource.
+func flushInvalidations() {
+	pendingInvalidations.mutex.Lock()
+	resources := make([]string, 0, len(pendingInvalidations.resources))
+	for resource := range pendingInvalidations.resources {
+		resources = append(resources, resource)
+	}
+	pendingInvalidations.resources = nil
+	if pendingInvalidations.timer != nil {
+		pendingInvalidations.timer.Stop()
+		pendingInvalidations.timer = nil
+	}
+	pendingInvalidations.mutex.Unlock()
+
+	sort.Strings(resources)
+	for _, resource := range resources {
+		invalidateResource(resource, "")
+	}
+}
+
+// invalidatingPool wraps the database's connection pool so transactions can hold back the
+// invalidations of their writes until they commit.
+type invalidatingPool struct {
+	gorm.ConnPool
+}
+
+func (p invalidatingPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	sqlTx, ok := tx.(gorm.Tx)
+	if !ok {
+		return tx, nil
+	}
+	return &invalidatingTx{Tx: sqlTx, pool: p.ConnPool}, nil
+}
+
+func (p invalidatingPool) GetDBConn() (*sql.DB, error) {
+	return poolDB(p.ConnPool)
+}
+
+// invalidatingTx is a transaction that invalidates the resources it wrote once it commits, and
+// forgets them if it rolls back.
+type invalidatingTx struct {
+	gorm.Tx
+	pool      gorm.ConnPool
+	mutex     sync.Mutex
+	resources []string
+}
+
+func (t *invalidatingTx) written(resource string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.resources = append(t.resources, resource)
+}
+
+func (t *invalidatingTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	resources := t.resources
+	t.resources = nil
+	t.mutex.Unlock()
+	queueInvalidation(resources...)
+	return nil
+}
+
+func (t *invalidatingTx) Rollback() error {
+	t.mutex.Lock()
+	t.resources = nil
+	t.mutex.Unlock()
+	return t.Tx.Rollback()
+}
+
+func (t *invalidatingTx) GetDBConn() (*sql.DB, error) {
+	return poolDB(t.pool)
+}
+
+// poolDB returns the *sql.DB behind a connection pool, for gorm.DB.DB().
+func poolDB(pool gorm.ConnPool) (*sql.DB, error) {
+	if connector, ok := pool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	if sqlDB, ok := pool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// registerCacheInvalidationCallbacks hooks GORM writes so any create, update or delete on
+// cases or appointments invalidates the matching list caches everywhere. The invalidation is
+// queued once the write is committed: writes in a transaction, including GORM's default
+// transaction around a single statement, wait for its commit, so no replica caches the rows
+// as they were before it.
+func registerCacheInvalidationCallbacks(db *gorm.DB) error {
+	if _, wrapped := db.ConnPool.(invalidatingPool); !wrapped {
+		db.ConnPool = invalidatingPool{db.ConnPool}
+		db.Statement.ConnPool = db.ConnPool
+	}
+
+	afterWrite := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement == nil {
+			return
+		}
+		switch table := tx.Statement.Table; table {
+		case "cases", "appointments":
+			if pending, ok := tx.Statement.ConnPool.(*invalidatingTx); ok {
+				pending.written(table)
+				return
+			}
+			queueInvalidation(table)
+		}
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("caf:cache_invalidation", afterWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("caf:cache_invalidation", afterWrite); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("caf:cache_invalidation", afterWrite)
+}
+
+func hostnameOrUnknown() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,104 @@
+// api/handlers/cache_invalidation_test.go
+// Unit tests for write-triggered cache invalidation: writes in a transaction invalidate once
+// it commits, once per resource, and not at all when it rolls back.
+package handlers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"gorm.io/gorm"
+)
+
+// recordInvalidations stops the delayed flush and returns the resources invalidated so far.
+func recordInvalidations(t *testing.T) func() []string {
+	t.Helper()
+	previous := cacheInvalidationDelay
+	cacheInvalidationDelay = time.Hour
+	t.Cleanup(func() {
+		cacheInvalidationDelay = previous
+		flushInvalidations()
+	})
+
+	var mutex sync.Mutex
+	var invalidated []string
+	enabled := true
+	registerCacheInvalidator(func(resource, id string, local bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if enabled && id == "" {
+			invalidated = append(invalidated, resource)
+		}
+	})
+	t.Cleanup(func() {
+		mutex.Lock()
+		enabled = false
+		mutex.Unlock()
+	})
+	return func() []string {
+		flushInvalidations()
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), invalidated...)
+	}
+}
+
+func TestCacheInvalidationWaitsForCommit(t *testing.T) {
+	script := &scriptedSQL{}
+	db := scriptedDB(t, script)
+	if err := registerCacheInvalidationCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+	invalidated := recordInvalidations(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < 3; i++ {
+			if err := tx.Create(&models.Appointment{CaseID: 7, StaffID: 4, Title: "Cita"}).Error; err != nil {
+				return err
+			}
+		}
+		if got := invalidated(); len(got) != 0 {
+			t.Errorf("invalidated %v before the commit", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := invalidated(); len(got) != 1 || got[0] != "appointments" {
+		t.Errorf("invalidated %v after the commit, want [appointments]", got)
+	}
+	if len(script.ran("COMMIT")) != 1 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
+
+func TestCacheInvalidationSkipsRolledBackWrites(t *testing.T) {
+	db := scriptedDB(t, &scriptedSQL{})
+	if err := registerCacheInvalidationCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+	invalidated := recordInvalidations(t)
+
+	db.Transaction(func(tx *gorm.DB) error {
+		tx.Create(&models.Appointment{CaseID: 7, StaffID: 4, Title: "Cita"})
+		return errors.New("conflict")
+	})
+	if got := invalidated(); len(got) != 0 {
+		t.Errorf("invalidated %v for a rolled back transaction", got)
+	}
+
+	// A single statement commits its own transaction
+	if err := db.Model(&models.Case{ID: 7}).Update("title", "Nuevo").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := invalidated(); len(got) != 1 || got[0] != "cases" {
+		t.Errorf("invalidated %v after a case update, want [cases]", got)
+	}
+	if _, err := db.DB(); err != nil {
+		t.Errorf("DB() = %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// api/handlers/calendar_colors.go
+// Display colors for appointment departments and categories, so calendar UIs
+// don't keep their own color maps. Defaults live in config; admins override them here.
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// hexColorPattern validates #RRGGBB colors
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// calendarColorMaps returns the effective department and category colors (defaults merged with admin overrides).
+func calendarColorMaps(db *gorm.DB) (departments map[string]string, categories map[string]string) {
+	departments = make(map[string]string, len(config.DefaultDepartmentColors))
+	for key, color := range config.DefaultDepartmentColors {
+		departments[key] = color
+	}
+	categories = make(map[string]string, len(config.DefaultCategoryColors))
+	for key, color := range config.DefaultCategoryColors {
+		categories[key] = color
+	}
+
+	var overrides []models.CalendarColor
+	if err := db.Find(&overrides).Error; err == nil {
+		for _, o := range overrides {
+			switch o.Kind {
+			case config.CalendarColorDepartment:
+				departments[o.Key] = o.Color
+			case config.CalendarColorCategory:
+				categories[o.Key] = o.Color
+			}
+		}
+	}
+	return departments, categories
+}
+
+// applyCalendarColors sets DisplayColor on each appointment: department color first, then category, then the default.
+func applyCalendarColors(db *gorm.DB, appointments []models.Appointment) {
+	if len(appointments) == 0 {
+		return
+	}
+	departments, categories := calendarColorMaps(db)
+	for i := range appointments {
+		appointments[i].DisplayColor = calendarColor(departments, categories, appointments[i].Department, appointments[i].Category)
+	}
+}
+
+// calendarColor picks the department color, then the category color, then the default.
+func calendarColor(departments, categories map[string]string, department, category string) string {
+	if color, ok := departments[department]; ok {
+		return color
+	}
+	if color, ok := categories[category]; ok {
+		return color
+	}
+	return config.DefaultCalendarColor
+}
+
+// GetCalendarColors returns the effective calendar colors and the admin overrides.
+func GetCalendarColors(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		departments, categories := calendarColorMaps(db)
+		overrides := make([]models.CalendarColor, 0)
+		db.Order("kind, key").Find(&overrides)
+		c.JSON(http.StatusOK, gin.H{
+			"departments":  departments,
+			"categories":   categories,
+			"defaultColor": config.DefaultCalendarColor,
+			"overrides":    overrides,
+		})
+	}
+}
+
+// UpsertCalendarColor sets the color for a department or category.
+func UpsertCalendarColor(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Kind  string `json:"kind" binding:"required"`
+			Key   string `json:"key" binding:"required"`
+			Color string `json:"color" binding:"required"`
+			Label string `json:"label"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.Kind != config.CalendarColorDepartment && input.Kind != config.CalendarColorCategory {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kind debe ser 'department' o 'category'"})
+			return
+		}
+		if !hexColorPattern.MatchString(input.Color) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El color debe tener formato #RRGGBB"})
+			return
+		}
+
+		key := strings.TrimSpace(input.Key)
+		var color models.CalendarColor
+		err := db.Where("kind = ? AND key = ?", input.Kind, key).First(&color).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el color"})
+			return
+		}
+
+		color.Kind = input.Kind
+		color.Key = key
+		color.Color = strings.ToLower(input.Color)
+		color.Label = input.Label
+		color.UpdatedBy = extractUserID(c)
+		if err := db.Save(&color).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar el color"})
+			return
+		}
+		c.JSON(http.StatusOK, color)
+	}
+}
+
+// DeleteCalendarColor removes an override so the default color applies again.
+func DeleteCalendarColor(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Delete(&models.CalendarColor{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el color"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Color no encontrado"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Color eliminado exitosamente"})
+	}
+}
@@ -0,0 +1,217 @@
+// api/handlers/case_assignment.go
+// Ranked staff suggestions for a new case, based on department match, specialty
+// and current workload. Optionally used to auto-assign cases created without staff.
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// caseTypeDepartments maps case types to the department (top-level category) they belong to.
+// Mirrors the case taxonomy used by the admin portal.
+var caseTypeDepartments = map[string]string{
+	"Divorcios":                 "Familiar",
+	"Guardia y Custodia":        "Familiar",
+	"Acto Prejudicial":          "Familiar",
+	"Adopcion":                  "Familiar",
+	"Pension Alimenticia":       "Familiar",
+	"Rectificacion de Actas":    "Familiar",
+	"Reclamacion de Paternidad": "Familiar",
+	"Prescripcion Positiva":     "Civil",
+	"Reinvindicatorio":          "Civil",
+	"Intestado":                 "Civil",
+	"Individual":                "Psicologia",
+	"Pareja":                    "Psicologia",
+	"Tutoria Escolar":           "Recursos",
+	"Asistencia Social":         "Recursos",
+}
+
+// departmentStaffMatch lists, per case department, the staff roles and user.department values that handle it.
+var departmentStaffMatch = map[string]struct {
+	roles       []string
+	departments []string
+}{
+	"Familiar":   {roles: []string{config.RoleLawyer}, departments: []string{"familiar", "legal"}},
+	"Civil":      {roles: []string{config.RoleLawyer}, departments: []string{"civil", "legal"}},
+	"Psicologia": {roles: []string{config.RolePsychologist}, departments: []string{"psicologia", "psychology"}},
+	"Recursos":   {departments: []string{"recursos", "social"}},
+}
+
+// Suggestion score weights: matches add points, each open case or upcoming appointment subtracts.
+const (
+	assignmentDepartmentWeight  = 50
+	assignmentSpecialtyWeight   = 20
+	assignmentCaseWeight        = 5
+	assignmentAppointmentWeight = 1
+)
+
+// staffActiveCasesSQL counts the open, unarchived cases of users.id, as primary staff or through
+// user_case_assignments.
+const staffActiveCasesSQL = `(SELECT COUNT(*) FROM cases WHERE cases.deleted_at IS NULL AND cases.is_archived = false
+				AND cases.status NOT IN ('closed', 'completed', 'archived')
+				AND (cases.primary_staff_id = users.id OR cases.id IN (SELECT case_id FROM user_case_assignments WHERE user_id = users.id)))`
+
+// assignmentSuggestion is one ranked candidate for a case.
+type assignmentSuggestion struct {
+	StaffID              uint    `json:"staffId"`
+	FirstName            string  `json:"firstName"`
+	LastName             string  `json:"lastName"`
+	Role                 string  `json:"role"`
+	Department           *string `json:"department,omitempty"`
+	Specialty            *string `json:"specialty,omitempty"`
+	ActiveCases          int64   `json:"activeCases"`
+	UpcomingAppointments int64   `json:"upcomingAppointments"` // Next 14 days
+	DepartmentMatch      bool    `json:"departmentMatch"`
+	SpecialtyMatch       bool    `json:"specialtyMatch"`
+	Score                int64   `json:"score"`
+}
+
+// caseDepartment resolves a category or case type to its department.
+func caseDepartment(category string) string {
+	if department, ok := caseTypeDepartments[category]; ok {
+		return department
+	}
+	return category
+}
+
+// staffDepartmentHandles reports whether a staff member whose user.department is
+// staffDepartment works cases of the category: the department is the case's department or one
+// of the departmentStaffMatch values for it.
+func staffDepartmentHandles(staffDepartment string, category string) bool {
+	staffDepartment = strings.ToLower(strings.TrimSpace(staffDepartment))
+	department := caseDepartment(category)
+	if staffDepartment == strings.ToLower(department) {
+		return true
+	}
+	for _, d := range departmentStaffMatch[department].departments {
+		if staffDepartment == d {
+			return true
+		}
+	}
+	return false
+}
+
+// rankAssignmentSuggestions returns active staff of the office ranked for a case of the given category.
+func rankAssignmentSuggestions(db *gorm.DB, category string, officeID uint) ([]assignmentSuggestion, error) {
+	now := time.Now()
+	candidates := make([]assignmentSuggestion, 0)
+	err := db.Table("users").
+		Select(`users.id AS staff_id, users.first_name, users.last_name, users.role, users.department, users.specialty,
+			`+staffActiveCasesSQL+` AS active_cases,
+			(SELECT COUNT(*) FROM appointments WHERE appointments.deleted_at IS NULL AND appointments.staff_id = users.id
+				AND appointments.status NOT IN (?, ?) AND appointments.start_time >= ? AND appointments.start_time < ?) AS upcoming_appointments`,
+			config.StatusCancelled, config.StatusCompleted, now, now.AddDate(0, 0, 14)).
+		Where("users.deleted_at IS NULL AND users.is_active = ? AND users.office_id = ?", true, officeID).
+		Where("users.role IN ?", []string{config.RoleLawyer, config.RolePsychologist, config.RoleOfficeManager}).
+		Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	department := caseDepartment(category)
+	match := departmentStaffMatch[department]
+	needle := strings.ToLower(strings.TrimSpace(category))
+	for i := range candidates {
+		s := &candidates[i]
+		for _, role := range match.roles {
+			if s.Role == role {
+				s.DepartmentMatch = true
+			}
+		}
+		if s.Department != nil && staffDepartmentHandles(*s.Department, category) {
+			s.DepartmentMatch = true
+		}
+		if s.Specialty != nil && needle != "" && strings.Contains(strings.ToLower(*s.Specialty), needle) {
+			s.SpecialtyMatch = true
+		}
+
+		if s.DepartmentMatch {
+			s.Score += assignmentDepartmentWeight
+		}
+		if s.SpecialtyMatch {
+			s.Score += assignmentSpecialtyWeight
+		}
+		s.Score -= s.ActiveCases*assignmentCaseWeight + s.UpcomingAppointments*assignmentAppointmentWeight
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].ActiveCases < candidates[j].ActiveCases
+	})
+	return candidates, nil
+}
+
+// autoAssignStaff returns the top suggestion's ID when auto-assignment is enabled and the
+// suggestion handles the case's department; otherwise nil.
+func autoAssignStaff(db *gorm.DB, category string, officeID uint) *uint {
+	if !config.CaseAutoAssignEnabled() || officeID == 0 {
+		return nil
+	}
+	suggestions, err := rankAssignmentSuggestions(db, category, officeID)
+	if err != nil || len(suggestions) == 0 || !suggestions[0].DepartmentMatch {
+		return nil
+	}
+	return &suggestions[0].StaffID
+}
+
+// GetCaseAssignmentSuggestions ranks staff for a new case (?category=&officeId=).
+// Non-admins only get suggestions for their own office.
+func GetCaseAssignmentSuggestions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		if role == "client" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado"})
+			return
+		}
+
+		var officeID uint
+		if v := c.Query("officeId"); v != "" {
+			parsed, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "officeId inválido"})
+				return
+			}
+			officeID = uint(parsed)
+		}
+		if !config.CanAccessAllOffices(role) {
+			scope, _ := c.Get("officeScopeID")
+			scopeID, _ := scope.(uint)
+			if officeID == 0 {
+				officeID = scopeID
+			}
+			if scopeID == 0 || officeID != scopeID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Solo puede consultar sugerencias de su oficina"})
+				return
+			}
+		}
+		if officeID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "officeId es requerido"})
+			return
+		}
+
+		category := strings.TrimSpace(c.Query("category"))
+		suggestions, err := rankAssignmentSuggestions(db, category, officeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener sugerencias de asignación", "message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"category":          category,
+			"department":        caseDepartment(category),
+			"officeId":          officeID,
+			"autoAssignEnabled": config.CaseAutoAssignEnabled(),
+			"suggestions":       suggestions,
+		})
+	}
+}
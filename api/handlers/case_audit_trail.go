@@ -0,0 +1,208 @@
+// api/handlers/case_audit_trail.go
+// Unified, chronological accountability record for a case, merging structured
+// AuditLog entries with CaseEvents (comments, uploads, stage changes).
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditFieldChange is one field that differs between an audit entry's old and new values.
+type auditFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// auditTrailEntry is one row of a case's unified audit trail.
+type auditTrailEntry struct {
+	Source     string             `json:"source"` // "audit_log" or "case_event"
+	ID         uint               `json:"id"`
+	Timestamp  time.Time          `json:"timestamp"`
+	ActorID    uint               `json:"actorId"`
+	ActorName  string             `json:"actorName"`
+	ActorRole  string             `json:"actorRole,omitempty"`
+	Action     string             `json:"action"`
+	EntityType string             `json:"entityType"`
+	EntityID   uint               `json:"entityId"`
+	Details    string             `json:"details,omitempty"`
+	Reason     string             `json:"reason,omitempty"`
+	Severity   string             `json:"severity,omitempty"`
+	Changes    []auditFieldChange `json:"changes,omitempty"`
+}
+
+// GetCaseAuditTrail merges the case's audit logs (including those of its appointments) and case events, newest first.
+func GetCaseAuditTrail(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseRecord models.Case
+		if err := db.Select("id, title").First(&caseRecord, caseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+			return
+		}
+
+		var appointmentIDs []uint
+		db.Unscoped().Model(&models.Appointment{}).Where("case_id = ?", caseRecord.ID).Pluck("id", &appointmentIDs)
+
+		logs := make([]models.AuditLog, 0)
+		logQuery := db.Where("entity_type = ? AND entity_id = ?", "case", caseRecord.ID)
+		if len(appointmentIDs) > 0 {
+			logQuery = logQuery.Or("entity_type = ? AND entity_id IN ?", "appointment", appointmentIDs)
+		}
+		if err := logQuery.Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la bitácora de auditoría", "message": err.Error()})
+			return
+		}
+
+		events := make([]models.CaseEvent, 0)
+		if err := db.Unscoped().Select("id, case_id, user_id, event_type, visibility, comment_text, description, file_name, created_at, deleted_at").
+			Where("case_id = ?", caseRecord.ID).Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener eventos del caso", "message": err.Error()})
+			return
+		}
+
+		// Resolve actor names with a single query
+		userIDs := make([]uint, 0, len(logs)+len(events))
+		for _, l := range logs {
+			userIDs = append(userIDs, l.UserID)
+		}
+		for _, e := range events {
+			userIDs = append(userIDs, e.UserID)
+		}
+		var users []models.User
+		if len(userIDs) > 0 {
+			db.Unscoped().Select("id, first_name, last_name, role").Where("id IN ?", userIDs).Find(&users)
+		}
+		usersByID := make(map[uint]models.User, len(users))
+		for _, u := range users {
+			usersByID[u.ID] = u
+		}
+		actorName := func(id uint) string {
+			if u, ok := usersByID[id]; ok {
+				return strings.TrimSpace(u.FirstName + " " + u.LastName)
+			}
+			return "Usuario desconocido"
+		}
+
+		trail := make([]auditTrailEntry, 0, len(logs)+len(events))
+		for _, l := range logs {
+			trail = append(trail, auditLogTrailEntry(l, actorName(l.UserID)))
+		}
+		for _, e := range events {
+			entry := caseEventTrailEntry(e, actorName(e.UserID))
+			if u, ok := usersByID[e.UserID]; ok {
+				entry.ActorRole = u.Role
+			}
+			trail = append(trail, entry)
+		}
+
+		sort.SliceStable(trail, func(i, j int) bool { return trail[i].Timestamp.After(trail[j].Timestamp) })
+
+		c.JSON(http.StatusOK, gin.H{
+			"caseId":    caseRecord.ID,
+			"caseTitle": caseRecord.Title,
+			"total":     len(trail),
+			"entries":   trail,
+		})
+	}
+}
+
+// auditLogTrailEntry converts an audit log entry into a trail entry attributed to actorName.
+func auditLogTrailEntry(l models.AuditLog, actorName string) auditTrailEntry {
+	return auditTrailEntry{
+		Source:     "audit_log",
+		ID:         l.ID,
+		Timestamp:  l.CreatedAt,
+		ActorID:    l.UserID,
+		ActorName:  actorName,
+		ActorRole:  l.UserRole,
+		Action:     l.Action,
+		EntityType: l.EntityType,
+		EntityID:   l.EntityID,
+		Reason:     l.Reason,
+		Severity:   l.Severity,
+		Changes:    diffAuditValues(l.OldValues, l.NewValues),
+	}
+}
+
+// caseEventTrailEntry converts a case event into a trail entry attributed to actorName. The
+// actor's role is left to the caller, since events do not store it.
+func caseEventTrailEntry(e models.CaseEvent, actorName string) auditTrailEntry {
+	details := e.CommentText
+	if details == "" {
+		details = e.Description
+	}
+	if details == "" && e.FileName != "" {
+		details = e.FileName
+	}
+	if e.DeletedAt.Valid {
+		details += " (eliminado)"
+	}
+	return auditTrailEntry{
+		Source:     "case_event",
+		ID:         e.ID,
+		Timestamp:  e.CreatedAt,
+		ActorID:    e.UserID,
+		ActorName:  actorName,
+		Action:     e.EventType,
+		EntityType: "case",
+		EntityID:   e.CaseID,
+		Details:    details,
+	}
+}
+
+// diffAuditValues returns the fields that differ between the stored old and new JSON values.
+// When only new values exist (e.g. creates/exports), every field is reported with a nil old value.
+func diffAuditValues(oldValues, newValues *string) []auditFieldChange {
+	parse := func(raw *string) map[string]interface{} {
+		values := map[string]interface{}{}
+		if raw != nil && *raw != "" {
+			_ = json.Unmarshal([]byte(*raw), &values)
+		}
+		return values
+	}
+	before, after := parse(oldValues), parse(newValues)
+	if len(before) == 0 && len(after) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	seen := map[string]bool{}
+	for field := range before {
+		fields = append(fields, field)
+		seen[field] = true
+	}
+	for field := range after {
+		if !seen[field] {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]auditFieldChange, 0, len(fields))
+	for _, field := range fields {
+		if reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		changes = append(changes, auditFieldChange{Field: field, Old: before[field], New: after[field]})
+	}
+	return changes
+}
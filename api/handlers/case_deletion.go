@@ -0,0 +1,163 @@
+// api/handlers/case_deletion.go
+// What deleting a case takes with it. Deleting a case that still has upcoming appointments
+// or open tasks requires ?force=true; the preview lets the UI warn before the attempt.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errCaseDeletionNeedsForce is returned when a case with pending work is deleted without force.
+var errCaseDeletionNeedsForce = errors.New("el caso tiene citas próximas o tareas abiertas; la eliminación requiere confirmación (force=true)")
+
+// finishedAppointmentStatuses and finishedTaskStatuses mark work that no longer needs doing;
+// any other appointment yet to start, or task, is pending work that deletion would drop.
+var (
+	finishedAppointmentStatuses = []config.AppointmentStatus{config.StatusCompleted, config.StatusCancelled, config.StatusNoShow}
+	finishedTaskStatuses        = []string{"completed", "cancelled"}
+)
+
+// caseDeletionImpact counts the records attached to a case.
+type caseDeletionImpact struct {
+	CaseID               uint  `json:"caseId"`
+	Appointments         int64 `json:"appointments"`
+	UpcomingAppointments int64 `json:"upcomingAppointments"` // Not yet started and not cancelled, completed or no-show
+	Tasks                int64 `json:"tasks"`
+	OpenTasks            int64 `json:"openTasks"` // Neither completed nor cancelled
+	Comments             int64 `json:"comments"`
+	Documents            int64 `json:"documents"`
+	ForceRequired        bool  `json:"forceRequired"`
+}
+
+// loadCaseDeletionImpact counts what deleting caseID would affect.
+func loadCaseDeletionImpact(db *gorm.DB, caseID uint) (caseDeletionImpact, error) {
+	impact := caseDeletionImpact{CaseID: caseID}
+
+	var appointments struct {
+		Total    int64
+		Upcoming int64
+	}
+	if err := db.Model(&models.Appointment{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE start_time >= ? AND status NOT IN ?) AS upcoming",
+			time.Now(), finishedAppointmentStatuses).
+		Where("case_id = ?", caseID).
+		Scan(&appointments).Error; err != nil {
+		return impact, err
+	}
+	impact.Appointments, impact.UpcomingAppointments = appointments.Total, appointments.Upcoming
+
+	var tasks struct {
+		Total int64
+		Open  int64
+	}
+	if err := db.Model(&models.Task{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status NOT IN ?) AS open", finishedTaskStatuses).
+		Where("case_id = ?", caseID).
+		Scan(&tasks).Error; err != nil {
+		return impact, err
+	}
+	impact.Tasks, impact.OpenTasks = tasks.Total, tasks.Open
+
+	var events struct {
+		Comments  int64
+		Documents int64
+	}
+	if err := db.Model(&models.CaseEvent{}).
+		Select("COUNT(*) FILTER (WHERE event_type = 'comment') AS comments, COUNT(*) FILTER (WHERE event_type = 'file_upload') AS documents").
+		Where("case_id = ?", caseID).
+		Scan(&events).Error; err != nil {
+		return impact, err
+	}
+	impact.Comments, impact.Documents = events.Comments, events.Documents
+
+	impact.ForceRequired = impact.UpcomingAppointments > 0 || impact.OpenTasks > 0
+	return impact, nil
+}
+
+// casePendingWork counts a case's upcoming appointments and open tasks, as in caseDeletionImpact.
+type casePendingWork struct {
+	UpcomingAppointments int64
+	OpenTasks            int64
+}
+
+// loadPendingCaseWork returns the pending work of each of caseIDs that has any.
+func loadPendingCaseWork(db *gorm.DB, caseIDs []uint, now time.Time) (map[uint]casePendingWork, error) {
+	pending := make(map[uint]casePendingWork)
+	var counts []struct {
+		CaseID uint
+		Total  int64
+	}
+	if err := db.Model(&models.Appointment{}).Select("case_id, COUNT(*) AS total").
+		Where("case_id IN ? AND start_time >= ? AND status NOT IN ?", caseIDs, now, finishedAppointmentStatuses).
+		Group("case_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range counts {
+		work := pending[row.CaseID]
+		work.UpcomingAppointments = row.Total
+		pending[row.CaseID] = work
+	}
+	counts = nil
+	if err := db.Model(&models.Task{}).Select("case_id, COUNT(*) AS total").
+		Where("case_id IN ? AND status NOT IN ?", caseIDs, finishedTaskStatuses).
+		Group("case_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range counts {
+		work := pending[row.CaseID]
+		work.OpenTasks = row.Total
+		pending[row.CaseID] = work
+	}
+	return pending, nil
+}
+
+// cancelPendingCaseWork cancels the upcoming appointments and open tasks of caseIDs, so no
+// active work is left on deleted cases. It returns the cancelled appointments' IDs and the
+// number of tasks cancelled.
+func cancelPendingCaseWork(tx *gorm.DB, caseIDs []uint, now time.Time) ([]uint, int64, error) {
+	var appointmentIDs []uint
+	if err := tx.Model(&models.Appointment{}).
+		Where("case_id IN ? AND start_time >= ? AND status NOT IN ?", caseIDs, now, finishedAppointmentStatuses).
+		Pluck("id", &appointmentIDs).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(appointmentIDs) > 0 {
+		if err := tx.Model(&models.Appointment{}).Where("id IN ?", appointmentIDs).
+			Update("status", config.StatusCancelled).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	result := tx.Model(&models.Task{}).Where("case_id IN ? AND status NOT IN ?", caseIDs, finishedTaskStatuses).
+		Update("status", "cancelled")
+	return appointmentIDs, result.RowsAffected, result.Error
+}
+
+// GetCaseDeletionImpact returns the counts of a case's appointments, tasks, comments and
+// documents, and whether deleting it will require force=true.
+func GetCaseDeletionImpact(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var caseData models.Case
+		if err := db.Select("id").Where("deleted_at IS NULL").First(&caseData, caseID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+			return
+		}
+
+		impact, err := loadCaseDeletionImpact(db, caseData.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular el impacto de la eliminación", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, impact)
+	}
+}
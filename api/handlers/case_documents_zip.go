@@ -0,0 +1,165 @@
+// api/handlers/case_documents_zip.go
+// Downloads every document of a case as one ZIP, streamed from storage file by file so memory
+// stays bounded regardless of the case's size.
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// missingDocumentsEntry lists, inside the archive, the documents storage could not return.
+const missingDocumentsEntry = "ARCHIVOS_FALTANTES.txt"
+
+// DownloadCaseDocuments streams a ZIP of the case's uploaded documents, named by the case
+// number. Clients may only download their own cases and only get the documents shared with
+// them; staff access is checked by CaseAccessControl. A document missing from storage is
+// skipped and listed in ARCHIVOS_FALTANTES.txt inside the archive rather than failing the
+// download. Each download is recorded as a data_access audit entry.
+func DownloadCaseDocuments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseRecord models.Case
+		if err := db.Select("id, case_number, client_id").First(&caseRecord, caseID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+			return
+		}
+
+		query := db.Select("id, event_type, visibility, file_url, file_name, created_at").
+			Where("case_id = ? AND event_type = ? AND file_url <> ''", caseID, "file_upload")
+		if c.GetString("userRole") == "client" {
+			if caseRecord.ClientID == nil || *caseRecord.ClientID != extractUserIDUint(c) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: el caso no le pertenece"})
+				return
+			}
+			query = query.Where("visibility <> ?", "internal")
+		}
+		var documents []models.CaseEvent
+		if err := query.Order("created_at, id").Find(&documents).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los documentos", "message": err.Error()})
+			return
+		}
+		if len(documents) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "El caso no tiene documentos"})
+			return
+		}
+
+		store := storage.GetActiveStorage()
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Almacenamiento no disponible"})
+			return
+		}
+
+		archiveName := caseRecord.CaseNumber
+		if archiveName == "" {
+			archiveName = fmt.Sprintf("caso-%d", caseRecord.ID)
+		}
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-documentos.zip\"", archiveName))
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+
+		archive := zip.NewWriter(c.Writer)
+		names := make(map[string]int, len(documents))
+		missing := make([]string, 0)
+		included := 0
+		interrupted := false
+		for _, document := range documents {
+			name := uniqueArchiveName(names, documentArchiveName(document))
+			if err := writeArchiveDocument(archive, store, document, name); err != nil {
+				var writeErr archiveWriteError
+				if errors.As(err, &writeErr) {
+					// The client went away or the stream broke; nothing more can be sent
+					log.Printf("ERROR: Case %d documents archive interrupted: %v", caseRecord.ID, err)
+					interrupted = true
+					break
+				}
+				log.Printf("WARN: Case %d document %d missing from storage: %v", caseRecord.ID, document.ID, err)
+				missing = append(missing, fmt.Sprintf("%s (documento #%d)", name, document.ID))
+				continue
+			}
+			included++
+		}
+		if !interrupted {
+			if len(missing) > 0 {
+				if entry, err := archive.Create(missingDocumentsEntry); err == nil {
+					fmt.Fprintf(entry, "No se pudieron obtener %d documento(s) del almacenamiento:\r\n\r\n%s\r\n", len(missing), strings.Join(missing, "\r\n"))
+				}
+			}
+			if err := archive.Close(); err != nil {
+				log.Printf("ERROR: Failed to finish case %d documents archive: %v", caseRecord.ID, err)
+			}
+		}
+
+		values := map[string]interface{}{
+			"documents": included,
+			"missing":   len(missing),
+		}
+		if interrupted {
+			values["interrupted"] = true
+		}
+		recordDataAccessAuditLog(db, c, "case", caseRecord.ID, "export", "documents_zip", values)
+	}
+}
+
+// archiveWriteError marks a failure writing the archive itself, as opposed to reading a document.
+type archiveWriteError struct{ err error }
+
+func (e archiveWriteError) Error() string { return e.err.Error() }
+
+// writeArchiveDocument copies one document from storage into the archive. The entry is only
+// created once storage has returned the file, so a missing file leaves no empty entry; an error
+// while copying (storage or client) is reported as an archiveWriteError since the entry is
+// already half written.
+func writeArchiveDocument(archive *zip.Writer, store storage.FileStorage, document models.CaseEvent, name string) error {
+	body, _, err := store.Get(document.FileUrl)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: document.CreatedAt})
+	if err != nil {
+		return archiveWriteError{err}
+	}
+	if _, err := io.Copy(entry, body); err != nil {
+		return archiveWriteError{err}
+	}
+	return nil
+}
+
+// documentArchiveName is the document's file name without any directory parts.
+func documentArchiveName(document models.CaseEvent) string {
+	name := filepath.Base(strings.ReplaceAll(document.FileName, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return fmt.Sprintf("documento-%d", document.ID)
+	}
+	return name
+}
+
+// uniqueArchiveName numbers repeated names ("acta.pdf", "acta (2).pdf") so no entry is hidden.
+func uniqueArchiveName(seen map[string]int, name string) string {
+	key := strings.ToLower(name)
+	seen[key]++
+	if seen[key] == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	candidate := fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), seen[key], ext)
+	return uniqueArchiveName(seen, candidate)
+}
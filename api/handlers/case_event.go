@@ -11,10 +11,12 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/BryanPMX/CAF/api/storage"
@@ -224,17 +226,32 @@ func UploadDocument(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		limitDocumentUploadBody(c)
 		file, err := c.FormFile("file")
 		if err != nil {
+			if isBodyTooLarge(err) {
+				documentTooLarge(c, 0)
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
 			return
 		}
+		detectedType, ok := enforceDocumentUpload(c, file)
+		if !ok {
+			return
+		}
 
 		visibility := c.PostForm("visibility")
 		if visibility == "" {
 			visibility = "internal"
 		}
 
+		// Optional checklist tag (e.g. "acta_matrimonio") used by the document checklist
+		var documentType *string
+		if tag := strings.TrimSpace(c.PostForm("documentType")); tag != "" {
+			documentType = &tag
+		}
+
 		// Use the active storage provider (Strategy Pattern)
 		store := storage.GetActiveStorage()
 		if store == nil {
@@ -242,24 +259,13 @@ func UploadDocument(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		fileURL, err := store.Upload(file, caseIDStr)
-		if err != nil {
-			log.Printf("ERROR: Document upload failed: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al subir el archivo"})
+		fileURL, ok := storeDocumentFile(c, store, file, caseIDStr)
+		if !ok {
 			return
 		}
 
 		// Create CaseEvent record in the database
-		event := models.CaseEvent{
-			CaseID:     uint(caseID),
-			UserID:     uint(userIDUint),
-			EventType:  "file_upload",
-			Visibility: visibility,
-			FileName:   file.Filename,
-			FileUrl:    fileURL,
-			FileType:   file.Header.Get("Content-Type"),
-		}
-
+		event := newDocumentEvent(uint(caseID), uint(userIDUint), file, fileURL, detectedType, visibility, documentType)
 		if err := db.Create(&event).Error; err != nil {
 			// Attempt to clean up the uploaded file if DB insert fails
 			if deleteErr := store.Delete(fileURL); deleteErr != nil {
@@ -273,7 +279,45 @@ func UploadDocument(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// UpdateDocument updates document metadata (visibility, filename)
+// newDocumentEvent is the file_upload event recording a stored document file.
+func newDocumentEvent(caseID, userID uint, file *multipart.FileHeader, fileURL, detectedType, visibility string, documentType *string) models.CaseEvent {
+	return models.CaseEvent{
+		CaseID:     caseID,
+		UserID:     userID,
+		EventType:  "file_upload",
+		Visibility: visibility,
+		FileName:   file.Filename,
+		FileUrl:    fileURL,
+		FileType:   detectedType,
+		Metadata: map[string]interface{}{
+			"detectedContentType": detectedType,
+			"declaredContentType": file.Header.Get("Content-Type"),
+			"size":                file.Size,
+		},
+
+		DocumentType: documentType,
+	}
+}
+
+// storeDocumentFile uploads a validated document file for the case. It writes the error
+// response and returns false when storage fails.
+func storeDocumentFile(c *gin.Context, store storage.FileStorage, file *multipart.FileHeader, caseID string) (string, bool) {
+	fileURL, err := store.Upload(file, caseID)
+	if err != nil {
+		log.Printf("ERROR: Document upload failed: %v", err)
+		if errors.Is(err, storage.ErrStorageUnavailable) {
+			c.Header("Retry-After", "30")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "El almacenamiento no responde. Intente de nuevo en unos momentos."})
+			return "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al subir el archivo"})
+		return "", false
+	}
+	return fileURL, true
+}
+
+// UpdateDocument updates document metadata (visibility, filename). A multipart request
+// with a "file" field uploads a new version of the document instead; see replaceDocumentFile.
 func UpdateDocument(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		eventIDStr := c.Param("eventId")
@@ -307,9 +351,15 @@ func UpdateDocument(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			replaceDocumentFile(c, db, event, user.ID)
+			return
+		}
+
 		var input struct {
-			FileName   string `json:"fileName"`
-			Visibility string `json:"visibility"`
+			FileName     string  `json:"fileName"`
+			Visibility   string  `json:"visibility"`
+			DocumentType *string `json:"documentType"`
 		}
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -323,6 +373,14 @@ func UpdateDocument(db *gorm.DB) gin.HandlerFunc {
 		if input.Visibility != "" {
 			updates["visibility"] = input.Visibility
 		}
+		if input.DocumentType != nil {
+			// An empty string clears the checklist tag
+			if tag := strings.TrimSpace(*input.DocumentType); tag != "" {
+				updates["document_type"] = tag
+			} else {
+				updates["document_type"] = nil
+			}
+		}
 
 		if len(updates) == 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "No se proporcionaron campos para actualizar"})
@@ -388,6 +446,8 @@ func DeleteDocument(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
+		deleteDocumentVersions(db, storage.GetActiveStorage(), event)
+
 		// Soft delete the document record
 		if err := db.Delete(&event).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el documento"})
@@ -398,121 +458,192 @@ func DeleteDocument(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// loadAccessibleDocument loads the file_upload event named by :eventId and checks that the
+// current user may read it: clients only see shared documents on their own cases. It writes the
+// error response and returns false otherwise.
+func loadAccessibleDocument(c *gin.Context, db *gorm.DB) (models.CaseEvent, bool) {
+	eventIDStr := c.Param("eventId")
+	var event models.CaseEvent
+
+	// Check if user is authenticated
+	_, exists := c.Get("currentUser")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return event, false
+	}
+
+	if c.Request.Header.Get("User-Agent") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User-Agent header required"})
+		return event, false
+	}
+
+	eventID, err := strconv.ParseUint(eventIDStr, 10, 32)
+	if err != nil || eventID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de evento inválido"})
+		return event, false
+	}
+
+	// Get the case event
+	if err := db.Select("id, case_id, user_id, event_type, visibility, file_url, file_name, file_type, created_at, updated_at").First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
+		}
+		return event, false
+	}
+
+	if event.EventType != "file_upload" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Evento no es un documento"})
+		return event, false
+	}
+
+	// Check access permissions based on visibility
+	userRole, _ := c.Get("userRole")
+	if event.Visibility == "internal" && userRole == "client" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: documento interno"})
+		return event, false
+	}
+	if userRole == "client" {
+		userID, _ := c.Get("userID")
+		var count int64
+		if err := db.Model(&models.Case{}).
+			Where("id = ? AND client_id = ?", event.CaseID, userID).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar acceso al documento"})
+			return event, false
+		}
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: documento no pertenece a su caso"})
+			return event, false
+		}
+	}
+	return event, true
+}
+
 // GetDocument retrieves a document for viewing/downloading using the
 // active storage provider.
 func GetDocument(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		eventIDStr := c.Param("eventId")
-		mode := c.Query("mode") // "preview" or "download"
-
-		// Check if user is authenticated
-		_, exists := c.Get("currentUser")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		event, ok := loadAccessibleDocument(c, db)
+		if !ok {
 			return
 		}
 
-		if c.Request.Header.Get("User-Agent") == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "User-Agent header required"})
-			return
-		}
+		streamDocumentFile(c, event.FileUrl, event.FileName, event.FileType, fmt.Sprintf("\"%d-%s\"", event.ID, event.UpdatedAt.Format("20060102150405")))
+	}
+}
 
-		eventID, err := strconv.ParseUint(eventIDStr, 10, 32)
-		if err != nil || eventID == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de evento inválido"})
-			return
-		}
+// streamDocumentFile writes a stored document file to the response. mode=download (or a file
+// type the browser cannot preview) serves it as an attachment, otherwise inline; previews are
+// cacheable under etag.
+func streamDocumentFile(c *gin.Context, fileURL, fileName, fileType, etag string) {
+	mode := c.Query("mode") // "preview" or "download"
+
+	// Use the active storage provider to retrieve the file
+	store := storage.GetActiveStorage()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Almacenamiento no disponible"})
+		return
+	}
 
-		// Get the case event
-		var event models.CaseEvent
-		if err := db.Select("id, case_id, event_type, visibility, file_url, file_name, file_type, updated_at").First(&event, eventID).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Documento no encontrado"})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
-			}
+	body, contentType, err := store.Get(fileURL)
+	if err != nil {
+		log.Printf("ERROR: Failed to retrieve document: %v", err)
+		if errors.Is(err, storage.ErrStorageUnavailable) {
+			c.Header("Retry-After", "30")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "El almacenamiento no responde. Intente de nuevo en unos momentos."})
 			return
 		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archivo no encontrado en almacenamiento"})
+		return
+	}
+	defer body.Close()
 
-		if event.EventType != "file_upload" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Evento no es un documento"})
-			return
-		}
+	// Use stored content type if available, fall back to detected type
+	if fileType != "" {
+		contentType = fileType
+	}
+	c.Header("Content-Type", contentType)
 
-		// Check access permissions based on visibility
-		userRole, _ := c.Get("userRole")
-		if event.Visibility == "internal" && userRole == "client" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: documento interno"})
+	// Determine content disposition based on mode and file type
+	fileExt := strings.ToLower(filepath.Ext(fileName))
+	canPreview := isPreviewableFile(fileExt)
+
+	if mode == "download" || !canPreview {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	} else {
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
+	}
+
+	// Security headers
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("X-XSS-Protection", "1; mode=block")
+	c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
+
+	// Caching headers
+	if mode == "preview" && canPreview {
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.Header("ETag", etag)
+	} else {
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.Header("Pragma", "no-cache")
+		c.Header("Expires", "0")
+	}
+
+	// Stream the file content to the response
+	if _, err = io.Copy(c.Writer, body); err != nil {
+		log.Printf("ERROR: Failed to stream document: %v", err)
+		// Don't try to send JSON here — headers are already written
+		return
+	}
+}
+
+// GetDocumentURL returns a short-lived presigned S3 URL for a document, after the same access
+// checks as GetDocument, so the browser downloads it from S3 directly. Pass ?mode=download for
+// an attachment; previewable files are otherwise served inline. Each URL handed out is
+// recorded as a download in the audit log. When S3 is not the active storage (it failed to
+// initialize at startup) it answers 503 with the path that streams the file instead.
+func GetDocumentURL(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, ok := loadAccessibleDocument(c, db)
+		if !ok {
 			return
 		}
-		if userRole == "client" {
-			userID, _ := c.Get("userID")
-			var count int64
-			if err := db.Model(&models.Case{}).
-				Where("id = ? AND client_id = ?", event.CaseID, userID).
-				Count(&count).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar acceso al documento"})
-				return
-			}
-			if count == 0 {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: documento no pertenece a su caso"})
-				return
-			}
-		}
 
-		// Use the active storage provider to retrieve the file
-		store := storage.GetActiveStorage()
-		if store == nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Almacenamiento no disponible"})
+		signer, ok := storage.GetActiveStorage().(storage.URLSigner)
+		if !ok {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":        "Las descargas directas no están disponibles: S3 no se inicializó",
+				"downloadPath": strings.TrimSuffix(c.Request.URL.Path, "/url"),
+			})
 			return
 		}
 
-		body, contentType, err := store.Get(event.FileUrl)
+		download := c.Query("mode") == "download" || !isPreviewableFile(strings.ToLower(filepath.Ext(event.FileName)))
+		expiry := storage.PresignExpiry()
+		url, err := signer.PresignGetURL(event.FileUrl, event.FileName, event.FileType, download, expiry)
 		if err != nil {
-			log.Printf("ERROR: Failed to retrieve document: %v", err)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Archivo no encontrado en almacenamiento"})
+			log.Printf("ERROR: Failed to presign document %d: %v", event.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el enlace de descarga"})
 			return
 		}
-		defer body.Close()
+		expiresAt := time.Now().Add(expiry)
 
-		// Use stored content type if available, fall back to detected type
-		if event.FileType != "" {
-			contentType = event.FileType
-		}
-		c.Header("Content-Type", contentType)
-
-		// Determine content disposition based on mode and file type
-		fileExt := strings.ToLower(filepath.Ext(event.FileName))
-		canPreview := isPreviewableFile(fileExt)
-
-		if mode == "download" || !canPreview {
-			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", event.FileName))
-		} else {
-			c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", event.FileName))
-		}
-
-		// Security headers
-		c.Header("X-Content-Type-Options", "nosniff")
-		c.Header("X-Frame-Options", "DENY")
-		c.Header("X-XSS-Protection", "1; mode=block")
-		c.Header("Referrer-Policy", "strict-origin-when-cross-origin")
+		recordDataAccessAuditLog(db, c, "document", event.ID, "download", "presigned_url", map[string]interface{}{
+			"caseId":    event.CaseID,
+			"fileName":  event.FileName,
+			"expiresAt": expiresAt,
+		})
 
-		// Caching headers
-		if mode == "preview" && canPreview {
-			c.Header("Cache-Control", "public, max-age=3600")
-			c.Header("ETag", fmt.Sprintf("\"%d-%s\"", event.ID, event.UpdatedAt.Format("20060102150405")))
-		} else {
-			c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
-			c.Header("Pragma", "no-cache")
-			c.Header("Expires", "0")
-		}
-
-		// Stream the file content to the response
-		if _, err = io.Copy(c.Writer, body); err != nil {
-			log.Printf("ERROR: Failed to stream document: %v", err)
-			// Don't try to send JSON here — headers are already written
-			return
-		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{
+			"url":              url,
+			"expiresAt":        expiresAt,
+			"expiresInSeconds": int(expiry.Seconds()),
+			"fileName":         event.FileName,
+		})
 	}
 }
 
@@ -0,0 +1,209 @@
+// api/handlers/case_funnel.go
+// Case stage funnel: every stage or status change is appended to case_status_history,
+// and the funnel report counts, per stage, how many cases entered it and how many moved
+// on during a period, so managers can spot the stages where cases stall.
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultFunnelDays is the report period when ?from= is not given.
+const defaultFunnelDays = 90
+
+// caseFunnelStage is one row of the funnel report.
+type caseFunnelStage struct {
+	Stage          string  `json:"stage"`
+	Label          string  `json:"label"`
+	Entered        int64   `json:"entered"`        // Distinct cases that moved into the stage during the period
+	Advanced       int64   `json:"advanced"`       // Distinct cases that moved out of the stage during the period
+	Current        int64   `json:"current"`        // Open cases in the stage right now
+	ConversionRate float64 `json:"conversionRate"` // Advanced / Entered, as a percentage
+}
+
+// recordCaseStageChange appends a history row when a case's stage or status differs from
+// fromStage/fromStatus, and fires the case.created or case.stage_changed webhook. Pass empty
+// values for a newly created case. Failures are logged and returned: callers writing in a
+// transaction roll it back, the others carry on.
+func recordCaseStageChange(db *gorm.DB, caseData *models.Case, fromStage, fromStatus string, changedBy *uint) error {
+	if caseData.ID == 0 || (caseData.CurrentStage == fromStage && caseData.Status == fromStatus) {
+		return nil
+	}
+	entry := models.CaseStatusHistory{
+		CaseID:     caseData.ID,
+		OfficeID:   caseData.OfficeID,
+		FromStage:  fromStage,
+		ToStage:    caseData.CurrentStage,
+		FromStatus: fromStatus,
+		ToStatus:   caseData.Status,
+		ChangedBy:  changedBy,
+		ChangedAt:  time.Now(),
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("WARNING: Failed to record stage history for case %d: %v", caseData.ID, err)
+		return err
+	}
+
+	var by uint
+	if changedBy != nil {
+		by = *changedBy
+	}
+	if fromStage == "" && fromStatus == "" {
+		return notifyCaseCreated(db, caseData, by)
+	}
+	return notifyCaseStageChanged(db, caseData, fromStage, fromStatus, by)
+}
+
+// GetCaseFunnel returns per-stage entered/advanced counts for ?from=&to= (YYYY-MM-DD, to inclusive;
+// default: the last 90 days), scoped to the manager's office. Admins may pass ?officeId=, and
+// ?category= restricts the report to one case category and its stage list.
+func GetCaseFunnel(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		to := today.AddDate(0, 0, 1)
+		if v := c.Query("to"); v != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'to' inválida, use YYYY-MM-DD"})
+				return
+			}
+			to = parsed.AddDate(0, 0, 1)
+		}
+		from := to.AddDate(0, 0, -defaultFunnelDays)
+		if v := c.Query("from"); v != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'from' inválida, use YYYY-MM-DD"})
+				return
+			}
+			from = parsed
+		}
+		if !from.Before(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'from' debe ser anterior a 'to'"})
+			return
+		}
+
+		var officeID uint
+		if config.CanAccessAllOffices(c.GetString("userRole")) {
+			if v := c.Query("officeId"); v != "" {
+				parsed, err := strconv.ParseUint(v, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "officeId inválido"})
+					return
+				}
+				officeID = uint(parsed)
+			}
+		} else {
+			if v, ok := c.Get("officeScopeID"); ok {
+				officeID, _ = v.(uint)
+			}
+			if officeID == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: no tiene una oficina asignada"})
+				return
+			}
+		}
+		category := c.Query("category")
+
+		history := db.Table("case_status_history h").
+			Joins("INNER JOIN cases ON cases.id = h.case_id AND cases.deleted_at IS NULL").
+			Where("h.changed_at >= ? AND h.changed_at < ?", from, to)
+		current := db.Model(&models.Case{}).
+			Where("deleted_at IS NULL AND is_archived = ? AND status NOT IN ?", false, []string{"closed", "completed", "cancelled"})
+		if officeID != 0 {
+			history = history.Where("h.office_id = ?", officeID)
+			current = current.Where("office_id = ?", officeID)
+		}
+		if category != "" {
+			history = history.Where("cases.category = ?", category)
+			current = current.Where("category = ?", category)
+		}
+
+		type stageCount struct {
+			Stage string
+			Count int64
+		}
+		var entered, advanced, inStage []stageCount
+		if err := history.Session(&gorm.Session{}).
+			Select("h.to_stage AS stage, COUNT(DISTINCT h.case_id) AS count").
+			Where("h.from_stage IS NULL OR h.from_stage <> h.to_stage").
+			Group("h.to_stage").
+			Scan(&entered).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el embudo de casos", "message": err.Error()})
+			return
+		}
+		if err := history.Session(&gorm.Session{}).
+			Select("h.from_stage AS stage, COUNT(DISTINCT h.case_id) AS count").
+			Where("h.from_stage IS NOT NULL AND h.from_stage <> '' AND h.from_stage <> h.to_stage").
+			Group("h.from_stage").
+			Scan(&advanced).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el embudo de casos", "message": err.Error()})
+			return
+		}
+		if err := current.Select("current_stage AS stage, COUNT(*) AS count").
+			Group("current_stage").
+			Scan(&inStage).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el embudo de casos", "message": err.Error()})
+			return
+		}
+
+		// Canonical stage order first, then any legacy stages found in the data
+		var order []string
+		if category != "" {
+			order = append(order, config.GetCaseStages(category)...)
+		} else {
+			order = append(append(order, config.LegalCaseStages...), config.CaseStages...)
+		}
+		byStage := make(map[string]*caseFunnelStage)
+		stageRow := func(stage string) *caseFunnelStage {
+			row, ok := byStage[stage]
+			if !ok {
+				row = &caseFunnelStage{Stage: stage, Label: config.GetStageLabel(stage)}
+				byStage[stage] = row
+				order = append(order, stage)
+			}
+			return row
+		}
+		for _, stage := range order {
+			byStage[stage] = &caseFunnelStage{Stage: stage, Label: config.GetStageLabel(stage)}
+		}
+		for _, row := range entered {
+			if row.Stage != "" {
+				stageRow(row.Stage).Entered = row.Count
+			}
+		}
+		for _, row := range advanced {
+			stageRow(row.Stage).Advanced = row.Count
+		}
+		for _, row := range inStage {
+			if row.Stage != "" {
+				stageRow(row.Stage).Current = row.Count
+			}
+		}
+
+		stages := make([]caseFunnelStage, 0, len(order))
+		for _, stage := range order {
+			row := byStage[stage]
+			if row.Entered > 0 {
+				row.ConversionRate = float64(row.Advanced) * 100 / float64(row.Entered)
+			}
+			stages = append(stages, *row)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":     from.Format("2006-01-02"),
+			"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+			"officeId": officeID,
+			"category": category,
+			"stages":   stages,
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// api/handlers/case_overdue.go
+// Overdue cases: open cases past their due date, bucketed by how long they have been overdue.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// caseAgingBucket is a range of whole days overdue; MaxDays 0 means no upper bound.
+type caseAgingBucket struct {
+	Key     string
+	MinDays int
+	MaxDays int
+}
+
+// caseAgingBuckets are the overdue ranges reported, in order
+var caseAgingBuckets = []caseAgingBucket{
+	{Key: "0-7", MinDays: 0, MaxDays: 7},
+	{Key: "8-30", MinDays: 8, MaxDays: 30},
+	{Key: "31+", MinDays: 31},
+}
+
+// caseDaysOverdueExpr is the number of whole days a case is past its due date at the bound time.
+const caseDaysOverdueExpr = "FLOOR(EXTRACT(EPOCH FROM (CAST(? AS TIMESTAMP) - cases.due_date)) / 86400)"
+
+// condition returns the SQL condition selecting cases in the bucket and its arguments.
+func (b caseAgingBucket) condition(now time.Time) (string, []interface{}) {
+	if b.MaxDays == 0 {
+		return caseDaysOverdueExpr + " >= ?", []interface{}{now, b.MinDays}
+	}
+	return caseDaysOverdueExpr + " BETWEEN ? AND ?", []interface{}{now, b.MinDays, b.MaxDays}
+}
+
+// contains reports whether a case overdue by the given whole days falls in the bucket.
+func (b caseAgingBucket) contains(days int) bool {
+	return days >= b.MinDays && (b.MaxDays == 0 || days <= b.MaxDays)
+}
+
+// caseAgingBucketExpr labels each case with the key of its bucket.
+func caseAgingBucketExpr(now time.Time) (string, []interface{}) {
+	var expr strings.Builder
+	var args []interface{}
+	expr.WriteString("CASE")
+	for _, bucket := range caseAgingBuckets {
+		condition, conditionArgs := bucket.condition(now)
+		expr.WriteString(" WHEN " + condition + " THEN ?")
+		args = append(args, append(conditionArgs, bucket.Key)...)
+	}
+	expr.WriteString(" END")
+	return expr.String(), args
+}
+
+// scopeOverdueCases narrows a case query to cases that are still open and past their due date.
+// It is shared by the dashboard count and the overdue report so both agree.
+func scopeOverdueCases(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("cases.due_date IS NOT NULL AND cases.due_date < ?", now).
+		Where("cases.is_completed = ? AND cases.status NOT IN ?", false,
+			[]config.CaseStatus{config.CaseStatusClosed, config.CaseStatusArchived})
+}
+
+// parseCaseDueDate reads a dueDate request value: an RFC 3339 timestamp, a YYYY-MM-DD date, or null to clear it.
+func parseCaseDueDate(value interface{}) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid dueDate: expected a date string")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid dueDate %q: use YYYY-MM-DD or RFC 3339", text)
+}
+
+// overdueCaseItem is a case row in the overdue report.
+type overdueCaseItem struct {
+	CaseListItem
+	DueDate     time.Time `json:"dueDate"`
+	DaysOverdue int       `json:"daysOverdue"`
+	Bucket      string    `json:"bucket"`
+}
+
+// overdueCaseGroup counts the overdue cases of one office and department per bucket.
+type overdueCaseGroup struct {
+	OfficeID   uint             `json:"officeId"`
+	OfficeName string           `json:"officeName"`
+	Department string           `json:"department"`
+	Total      int64            `json:"total"`
+	Buckets    map[string]int64 `json:"buckets"`
+}
+
+// GetOverdueCases lists open cases past their due date, most overdue first, with counts per
+// aging bucket overall and per office and department. Optional filters: officeId, department
+// (case category) and bucket (one of caseAgingBuckets); page and limit paginate the cases,
+// the counts cover every matching case.
+func GetOverdueCases(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		service := NewCaseService(db)
+
+		scoped := func() *gorm.DB {
+			query := service.NewCaseListQueryBuilder().ExcludeArchived().ApplyAccessControl(c).query
+			query = scopeOverdueCases(query, now)
+			if officeID := c.Query("officeId"); officeID != "" {
+				query = query.Where("cases.office_id = ?", officeID)
+			}
+			if department := c.Query("department"); department != "" {
+				query = query.Where("cases.category = ?", department)
+			}
+			return query
+		}
+
+		// Counts per office, department and bucket
+		bucketExpr, bucketArgs := caseAgingBucketExpr(now)
+		var rows []struct {
+			OfficeID   uint
+			OfficeName string
+			Department string
+			Bucket     string
+			Count      int64
+		}
+		if err := scoped().
+			Select("cases.office_id, COALESCE((SELECT name FROM offices WHERE offices.id = cases.office_id), '') AS office_name, "+
+				"cases.category AS department, "+bucketExpr+" AS bucket, COUNT(*) AS count", bucketArgs...).
+			Group("cases.office_id, cases.category, bucket").
+			Order("cases.office_id, cases.category").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los casos vencidos", "message": err.Error()})
+			return
+		}
+
+		emptyBuckets := func() map[string]int64 {
+			counts := make(map[string]int64, len(caseAgingBuckets))
+			for _, bucket := range caseAgingBuckets {
+				counts[bucket.Key] = 0
+			}
+			return counts
+		}
+		totals := emptyBuckets()
+		groups := make([]overdueCaseGroup, 0)
+		var total int64
+		for _, row := range rows {
+			last := len(groups) - 1
+			if last < 0 || groups[last].OfficeID != row.OfficeID || groups[last].Department != row.Department {
+				groups = append(groups, overdueCaseGroup{
+					OfficeID:   row.OfficeID,
+					OfficeName: row.OfficeName,
+					Department: row.Department,
+					Buckets:    emptyBuckets(),
+				})
+				last++
+			}
+			groups[last].Buckets[row.Bucket] += row.Count
+			groups[last].Total += row.Count
+			totals[row.Bucket] += row.Count
+			total += row.Count
+		}
+
+		// The page of cases, optionally limited to one bucket
+		query := scoped()
+		listTotal := total
+		if key := c.Query("bucket"); key != "" {
+			found := false
+			allowed := make([]string, 0, len(caseAgingBuckets))
+			for _, bucket := range caseAgingBuckets {
+				allowed = append(allowed, bucket.Key)
+				if bucket.Key == key {
+					condition, conditionArgs := bucket.condition(now)
+					query = query.Where(condition, conditionArgs...)
+					listTotal, found = totals[key], true
+				}
+			}
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Rango de antigüedad inválido", "allowed": allowed})
+				return
+			}
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		cases := make([]overdueCaseItem, 0, limit)
+		if err := query.Select(caseListItemColumns + ", cases.due_date").
+			Order("cases.due_date ASC, cases.id ASC").
+			Offset((page - 1) * limit).Limit(limit).
+			Scan(&cases).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los casos vencidos", "message": err.Error()})
+			return
+		}
+		for i := range cases {
+			cases[i].DaysOverdue = int(now.Sub(cases[i].DueDate).Hours() / 24)
+			for _, bucket := range caseAgingBuckets {
+				if bucket.contains(cases[i].DaysOverdue) {
+					cases[i].Bucket = bucket.Key
+					break
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"total":      total,
+			"buckets":    totals,
+			"groups":     groups,
+			"data":       cases,
+			"page":       page,
+			"limit":      limit,
+			"totalPages": (listTotal + int64(limit) - 1) / int64(limit),
+		})
+	}
+}
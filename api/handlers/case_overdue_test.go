@@ -0,0 +1,95 @@
+// api/handlers/case_overdue_test.go
+// Unit tests for the overdue cases endpoint: days overdue and access scoping.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// overdueScript answers with one case of office 2, category Familiar, due dueDaysAgo days ago.
+func overdueScript(dueDaysAgo int) *scriptedSQL {
+	due := time.Now().Add(-time.Duration(dueDaysAgo)*24*time.Hour - time.Hour)
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if !strings.Contains(query, `FROM "cases"`) {
+				return nil, nil
+			}
+			if strings.Contains(query, "GROUP BY") {
+				return []string{"office_id", "office_name", "department", "bucket", "count"},
+					[][]driver.Value{{int64(2), "Centro", "Familiar", "8-30", int64(1)}}
+			}
+			return []string{"id", "title", "office_id", "category", "status", "due_date"},
+				[][]driver.Value{{int64(7), "Divorcio", int64(2), "Familiar", "open", due}}
+		},
+	}
+}
+
+func TestGetOverdueCasesScopesToUser(t *testing.T) {
+	tests := []struct {
+		role     string
+		setup    func(c *gin.Context)
+		contains []string
+	}{
+		{"admin", func(c *gin.Context) {}, nil},
+		{"lawyer", func(c *gin.Context) {
+			office, department := uint(2), "Familiar"
+			c.Set("officeScopeID", office)
+			c.Set("userDepartment", department)
+		}, []string{"(office_id = $", "primary_staff_id = $", "assigned_to_id = $"}},
+		{"client", func(c *gin.Context) {}, []string{"client_id = $"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			script := overdueScript(12)
+			db := scriptedDB(t, script)
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cases/overdue", nil)
+			c.Set("userID", "3")
+			c.Set("userRole", tt.role)
+			tt.setup(c)
+			GetOverdueCases(db)(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			var body struct {
+				Total int64             `json:"total"`
+				Data  []overdueCaseItem `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Total != 1 || len(body.Data) != 1 || body.Data[0].DaysOverdue != 12 || body.Data[0].Bucket != "8-30" {
+				t.Errorf("body = %s", w.Body.String())
+			}
+
+			queries := script.ran(`FROM "cases"`)
+			if len(queries) != 2 {
+				t.Fatalf("case queries = %v", queries)
+			}
+			for _, query := range queries {
+				if !strings.Contains(query, "cases.due_date IS NOT NULL AND cases.due_date <") {
+					t.Errorf("query is not limited to overdue cases: %s", query)
+				}
+				for _, fragment := range tt.contains {
+					if !strings.Contains(query, fragment) {
+						t.Errorf("query is missing %q: %s", fragment, query)
+					}
+				}
+				if tt.role == "admin" && (strings.Contains(query, "client_id = $") || strings.Contains(query, "primary_staff_id = $")) {
+					t.Errorf("admin query is scoped: %s", query)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// api/handlers/case_search.go
+// Free-text case search over title, description, docket number and client name, ranked by relevance.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// caseSearchDocument is the text searched for each case. The client name comes from a subquery
+// rather than a join so the unqualified columns of the access control stay unambiguous.
+const caseSearchDocument = `COALESCE(cases.title, '') || ' ' || COALESCE(cases.description, '') || ' ' ||
+	COALESCE(cases.docket_number, '') || ' ' ||
+	COALESCE((SELECT CONCAT(first_name, ' ', last_name) FROM users WHERE users.id = cases.client_id), '')`
+
+const (
+	// caseSearchMinFullTextLength is the shortest query searched with full-text; shorter queries
+	// rarely form a useful lexeme and use trigram similarity instead
+	caseSearchMinFullTextLength = 4
+	// caseSearchMinSimilarity is the word similarity a trigram match needs when the query is not
+	// a plain substring of the case
+	caseSearchMinSimilarity = 0.3
+	// caseSearchHeadlineOptions configures ts_headline; matches are wrapped in <mark>
+	caseSearchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=8, MaxFragments=1"
+	// caseSearchSnippetRadius is how many characters of context surround a trigram match
+	caseSearchSnippetRadius = 60
+)
+
+// caseSearchResult is a case matching a search, with its relevance and the highlighted match.
+type caseSearchResult struct {
+	CaseListItem
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"`
+	Document string  `json:"-"`
+}
+
+// SearchCases finds cases by free text (q) across title, description, docket number and client
+// name. Queries of caseSearchMinFullTextLength characters or more use Spanish full-text search
+// ordered by ts_rank; shorter ones use pg_trgm word similarity. Results follow the same access
+// rules as the case list, exclude archived and deleted cases, and are paginated with page and
+// pageSize.
+func SearchCases(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro q es requerido"})
+			return
+		}
+		page, pageSize := parseCasePagination(c)
+		fullText := len([]rune(q)) >= caseSearchMinFullTextLength
+
+		query := NewCaseService(db).NewCaseListQueryBuilder().ExcludeArchived().ApplyAccessControl(c).query
+		if fullText {
+			query = query.Where("to_tsvector('spanish', "+caseSearchDocument+") @@ plainto_tsquery('spanish', ?)", q)
+		} else {
+			query = query.Where("("+caseSearchDocument+") ILIKE ? OR word_similarity(?, "+caseSearchDocument+") >= ?",
+				"%"+q+"%", q, caseSearchMinSimilarity)
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar casos", "message": err.Error()})
+			return
+		}
+
+		results := make([]caseSearchResult, 0, pageSize)
+		if fullText {
+			query = query.Select(caseListItemColumns+
+				", ts_rank(to_tsvector('spanish', "+caseSearchDocument+"), plainto_tsquery('spanish', ?)) AS rank"+
+				", ts_headline('spanish', "+caseSearchDocument+", plainto_tsquery('spanish', ?), ?) AS headline",
+				q, q, caseSearchHeadlineOptions)
+		} else {
+			query = query.Select(caseListItemColumns+
+				", word_similarity(?, "+caseSearchDocument+") AS rank, "+caseSearchDocument+" AS document", q)
+		}
+		if err := query.Order("rank DESC, cases.updated_at DESC").
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Scan(&results).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar casos", "message": err.Error()})
+			return
+		}
+		if !fullText {
+			for i := range results {
+				results[i].Headline = caseSearchSnippet(results[i].Document, q)
+			}
+		}
+
+		mode := "trigram"
+		if fullText {
+			mode = "fulltext"
+		}
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data":  results,
+			"query": q,
+			"mode":  mode,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
+
+// caseSearchSnippet returns the text around the first case-insensitive occurrence of q with the
+// match wrapped in <mark>, like ts_headline does for full-text results. When q only matched by
+// similarity, it returns the start of the text.
+func caseSearchSnippet(text, q string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := []rune(strings.ToLower(string(runes)))
+	needle := []rune(strings.ToLower(q))
+
+	start := -1
+	if len(lower) == len(runes) {
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) == string(needle) {
+				start = i
+				break
+			}
+		}
+	}
+	if start < 0 {
+		if len(runes) > 2*caseSearchSnippetRadius {
+			return string(runes[:2*caseSearchSnippetRadius]) + "…"
+		}
+		return string(runes)
+	}
+
+	end := start + len(needle)
+	from, to := start-caseSearchSnippetRadius, end+caseSearchSnippetRadius
+	prefix, suffix := "…", "…"
+	if from <= 0 {
+		from, prefix = 0, ""
+	}
+	if to >= len(runes) {
+		to, suffix = len(runes), ""
+	}
+	return prefix + string(runes[from:start]) + "<mark>" + string(runes[start:end]) + "</mark>" +
+		string(runes[end:to]) + suffix
+}
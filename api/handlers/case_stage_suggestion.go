@@ -0,0 +1,120 @@
+// api/handlers/case_stage_suggestion.go
+// Advisory next-stage suggestions based on a case's appointments, tasks and documents.
+// Nothing is changed here; staff confirm the move through UpdateCaseStage.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetSuggestedCaseStage evaluates the category's progression rule for the case's current stage.
+func GetSuggestedCaseStage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseRecord models.Case
+		if err := db.Select("id, category, current_stage").First(&caseRecord, caseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"caseId":            caseRecord.ID,
+			"category":          caseRecord.Category,
+			"currentStage":      caseRecord.CurrentStage,
+			"currentStageLabel": config.GetStageLabel(caseRecord.CurrentStage),
+			"suggestedStage":    nil,
+			"ready":             false,
+		}
+
+		rule, ok := config.GetStageProgressionRule(caseRecord.Category, caseRecord.CurrentStage)
+		if !ok {
+			response["reasons"] = []string{"No hay una regla de avance configurada para la etapa actual"}
+			response["pending"] = []string{}
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		var completedAppointments int64
+		if err := db.Model(&models.Appointment{}).
+			Where("case_id = ? AND status = ?", caseRecord.ID, config.StatusCompleted).
+			Count(&completedAppointments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al contar citas del caso", "message": err.Error()})
+			return
+		}
+
+		var openTasks int64
+		if err := db.Model(&models.Task{}).
+			Where("case_id = ? AND status NOT IN ?", caseRecord.ID, []string{"completed", "cancelled"}).
+			Count(&openTasks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al contar tareas del caso", "message": err.Error()})
+			return
+		}
+
+		missingDocuments := make([]string, 0)
+		if rule.RequireDocuments {
+			if missingDocuments, err = missingRequiredDocuments(db, caseRecord.ID, caseRecord.Category); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revisar documentos del caso", "message": err.Error()})
+				return
+			}
+		}
+
+		reasons := make([]string, 0)
+		pending := make([]string, 0)
+		if rule.MinCompletedAppointments > 0 {
+			if completedAppointments >= int64(rule.MinCompletedAppointments) {
+				reasons = append(reasons, fmt.Sprintf("%d cita(s) completada(s) de %d requerida(s)", completedAppointments, rule.MinCompletedAppointments))
+			} else {
+				pending = append(pending, fmt.Sprintf("Faltan %d cita(s) completada(s)", int64(rule.MinCompletedAppointments)-completedAppointments))
+			}
+		}
+		if rule.RequireTasksCompleted {
+			if openTasks == 0 {
+				reasons = append(reasons, "Todas las tareas del caso están completadas")
+			} else {
+				pending = append(pending, fmt.Sprintf("%d tarea(s) pendiente(s)", openTasks))
+			}
+		}
+		if rule.RequireDocuments {
+			if len(missingDocuments) == 0 {
+				reasons = append(reasons, "Todos los documentos requeridos fueron cargados")
+			} else {
+				for _, label := range missingDocuments {
+					pending = append(pending, "Documento faltante: "+label)
+				}
+			}
+		}
+
+		ready := len(pending) == 0
+		if ready {
+			response["suggestedStage"] = rule.ToStage
+			response["suggestedStageLabel"] = config.GetStageLabel(rule.ToStage)
+		}
+		response["ready"] = ready
+		response["nextStage"] = rule.ToStage
+		response["nextStageLabel"] = config.GetStageLabel(rule.ToStage)
+		response["reasons"] = reasons
+		response["pending"] = pending
+		response["metrics"] = gin.H{
+			"completedAppointments": completedAppointments,
+			"openTasks":             openTasks,
+			"missingDocuments":      len(missingDocuments),
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,165 @@
+// api/handlers/case_status_test.go
+// Unit tests for case status validation and the status UpdateCaseStage derives from the stage,
+// for legal (Familiar, Civil) and default categories.
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// caseStatusScript answers with case 7 of office 2 and records the status the case is saved with.
+type caseStatusScript struct {
+	*scriptedSQL
+	mutex sync.Mutex
+	saved []driver.Value
+}
+
+func newCaseStatusScript(category, stage, status string) *caseStatusScript {
+	script := &caseStatusScript{}
+	script.scriptedSQL = &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "cases"`) {
+				return []string{"id", "title", "category", "office_id", "current_stage", "status", "created_at"},
+					[][]driver.Value{{int64(7), "Divorcio", category, int64(2), stage, status, time.Now()}}
+			}
+			return nil, nil
+		},
+		affected: func(query string) int64 { return 1 },
+		observe: func(query string, args []driver.Value) {
+			if strings.HasPrefix(query, `UPDATE "cases"`) {
+				if value, ok := statementValues(query, args)["status"]; ok {
+					script.mutex.Lock()
+					script.saved = append(script.saved, value)
+					script.mutex.Unlock()
+				}
+			}
+		},
+	}
+	return script
+}
+
+// runCaseRequest runs handler for case 7 as user 3 with the JSON body.
+func runCaseRequest(t *testing.T, handler gin.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/admin/cases/7", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set("userID", "3")
+	c.Set("userRole", "admin")
+	handler(c)
+	return w
+}
+
+func TestUpdateCaseStageDerivesStatus(t *testing.T) {
+	t.Setenv("CASE_STAGE_STATUS_SYNC", "")
+	t.Setenv("CASE_STAGE_STATUS_SYNC_MANUAL_OFFICES", "")
+	tests := []struct {
+		category string
+		from     string
+		to       string
+		want     string
+	}{
+		{"Familiar", "notificacion", "etapa_inicial", "open"},
+		{"Familiar", "etapa_inicial", "audiencia_preliminar", "in_progress"},
+		{"Civil", "audiencia_juicio", "sentencia", "closed"},
+		{"Psicologia", "initial_consultation", "intake", "open"},
+		{"Recursos", "intake", "resolution", "in_progress"},
+		{"General", "resolution", "closed", "closed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.category+"/"+tt.to, func(t *testing.T) {
+			script := newCaseStatusScript(tt.category, tt.from, "pending")
+			db := scriptedDB(t, script.scriptedSQL)
+			w := runCaseRequest(t, UpdateCaseStage(db), http.MethodPatch, `{"stage":"`+tt.to+`"}`)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if len(script.saved) != 1 || script.saved[0] != tt.want {
+				t.Errorf("saved status = %v, want %s", script.saved, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCaseStageRejectsStageOfOtherCategory(t *testing.T) {
+	script := newCaseStatusScript("Familiar", "etapa_inicial", "open")
+	w := runCaseRequest(t, UpdateCaseStage(scriptedDB(t, script.scriptedSQL)), http.MethodPatch, `{"stage":"resolution"}`)
+	if w.Code != http.StatusBadRequest || len(script.ran(`UPDATE "cases"`)) != 0 {
+		t.Errorf("status %d, body %s, updates %v", w.Code, w.Body.String(), script.ran(`UPDATE "cases"`))
+	}
+}
+
+func TestUpdateCaseStageStatusForManualOffices(t *testing.T) {
+	t.Setenv("CASE_STAGE_STATUS_SYNC", "")
+	t.Setenv("CASE_STAGE_STATUS_SYNC_MANUAL_OFFICES", "2")
+	tests := []struct {
+		body string
+		code int
+		want []driver.Value
+	}{
+		{`{"stage":"sentencia"}`, http.StatusOK, []driver.Value{"pending"}},
+		{`{"stage":"sentencia","status":"in_progress"}`, http.StatusOK, []driver.Value{"in_progress"}},
+		{`{"stage":"sentencia","status":"resolved"}`, http.StatusOK, []driver.Value{"closed"}},
+		{`{"stage":"sentencia","status":"finished"}`, http.StatusBadRequest, nil},
+		{`{"stage":"sentencia","status":"archived"}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			script := newCaseStatusScript("Civil", "audiencia_juicio", "pending")
+			w := runCaseRequest(t, UpdateCaseStage(scriptedDB(t, script.scriptedSQL)), http.MethodPatch, tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if len(script.saved) != len(tt.want) || (len(tt.want) == 1 && script.saved[0] != tt.want[0]) {
+				t.Errorf("saved status = %v, want %v", script.saved, tt.want)
+			}
+			if tt.code == http.StatusBadRequest && !strings.Contains(w.Body.String(), `"allowed":["open","in_progress","closed","pending"]`) {
+				t.Errorf("body = %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateCaseValidatesStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		code   int
+		want   string
+	}{
+		{"closed", http.StatusOK, "closed"},
+		{"completed", http.StatusOK, "closed"},
+		{"active", http.StatusOK, "in_progress"},
+		{"deleted", http.StatusBadRequest, ""},
+		{"archived", http.StatusBadRequest, ""},
+		{"", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			script := newCaseStatusScript("Familiar", "notificacion", "open")
+			w := runCaseRequest(t, UpdateCase(scriptedDB(t, script.scriptedSQL)), http.MethodPut, `{"status":"`+tt.status+`"}`)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if tt.code == http.StatusBadRequest {
+				if len(script.ran(`UPDATE "cases"`)) != 0 || !strings.Contains(w.Body.String(), `"allowed"`) {
+					t.Errorf("body %s, updates %v", w.Body.String(), script.ran(`UPDATE "cases"`))
+				}
+				return
+			}
+			if len(script.saved) != 1 || script.saved[0] != tt.want {
+				t.Errorf("saved status = %v, want %s", script.saved, tt.want)
+			}
+		})
+	}
+}
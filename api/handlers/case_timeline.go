@@ -0,0 +1,149 @@
+// api/handlers/case_timeline.go
+// Case activity timeline: a case's events (comments, stage changes, document uploads...) with
+// their authors, newest first and paginated. Clients only ever see client-visible events of
+// their own cases.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// caseTimelineAuthor is who created a timeline event.
+type caseTimelineAuthor struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Role      string `json:"role"`
+}
+
+// caseTimelineEvent is one event of a case timeline.
+type caseTimelineEvent struct {
+	ID           uint                   `json:"id"`
+	EventType    string                 `json:"eventType"`
+	Visibility   string                 `json:"visibility"`
+	CommentText  string                 `json:"commentText,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	FileName     string                 `json:"fileName,omitempty"`
+	FileType     string                 `json:"fileType,omitempty"`
+	DocumentType *string                `json:"documentType,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"` // Staff only
+	Author       *caseTimelineAuthor    `json:"author,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+}
+
+// newCaseTimelineEvent converts a case event, with its User preloaded, for the timeline. Event
+// metadata is left out for clients.
+func newCaseTimelineEvent(event models.CaseEvent, forClient bool) caseTimelineEvent {
+	entry := caseTimelineEvent{
+		ID:           event.ID,
+		EventType:    event.EventType,
+		Visibility:   event.Visibility,
+		CommentText:  event.CommentText,
+		Description:  event.Description,
+		FileName:     event.FileName,
+		FileType:     event.FileType,
+		DocumentType: event.DocumentType,
+		CreatedAt:    event.CreatedAt,
+	}
+	if !forClient {
+		entry.Metadata = event.Metadata
+	}
+	if event.User.ID != 0 {
+		entry.Author = &caseTimelineAuthor{ID: event.User.ID, FirstName: event.User.FirstName, LastName: event.User.LastName, Role: event.User.Role}
+	}
+	return entry
+}
+
+// GetCaseEvents lists a case's events newest first. ?type= keeps the given event types
+// (comma-separated, e.g. comment,stage_change,file_upload) and ?visibility= internal or
+// client_visible events; page and pageSize paginate. Staff access is checked by
+// CaseAccessControl; a client gets 404 for a case that is not theirs and only its
+// client-visible events.
+func GetCaseEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		forClient := c.GetString("userRole") == "client"
+
+		caseQuery := db.Model(&models.Case{}).Where("id = ?", caseID)
+		if forClient {
+			caseQuery = caseQuery.Where("client_id = ?", extractUserIDUint(c))
+		}
+		var cases int64
+		if err := caseQuery.Count(&cases).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los eventos del caso", "message": err.Error()})
+			return
+		}
+		if cases == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+			return
+		}
+
+		query := db.Model(&models.CaseEvent{}).Where("case_id = ?", caseID)
+		visibility := c.Query("visibility")
+		if forClient {
+			visibility = "client_visible"
+		}
+		switch visibility {
+		case "":
+		case "internal", "client_visible":
+			query = query.Where("visibility = ?", visibility)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Visibilidad inválida, use internal o client_visible"})
+			return
+		}
+		if types := c.Query("type"); types != "" {
+			eventTypes := make([]string, 0)
+			for _, eventType := range strings.Split(types, ",") {
+				if eventType = strings.TrimSpace(eventType); eventType != "" {
+					eventTypes = append(eventTypes, eventType)
+				}
+			}
+			if len(eventTypes) > 0 {
+				query = query.Where("event_type IN ?", eventTypes)
+			}
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los eventos del caso", "message": err.Error()})
+			return
+		}
+		page, pageSize := parseCasePagination(c)
+		events := make([]models.CaseEvent, 0, pageSize)
+		if err := query.Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id, first_name, last_name, role")
+		}).
+			Order("created_at DESC, id DESC").
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los eventos del caso", "message": err.Error()})
+			return
+		}
+
+		data := make([]caseTimelineEvent, 0, len(events))
+		for _, event := range events {
+			data = append(data, newCaseTimelineEvent(event, forClient))
+		}
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data": data,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// api/handlers/case_timeline_test.go
+// Unit tests for the case timeline: clients never see internal events, not even when they ask
+// for them, nor the events of other clients' cases; staff can filter by event type and
+// visibility and get author names.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timelineScript answers for case 7 of client 5, whose events are filtered by the visibility and
+// event types bound to each query.
+func timelineScript() *scriptedSQL {
+	created := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	events := [][]driver.Value{
+		{int64(4), int64(7), int64(3), "stage_change", "client_visible", "", "Etapa: audiencia", created.Add(3 * time.Hour)},
+		{int64(3), int64(7), int64(3), "file_upload", "internal", "", "Dictamen interno", created.Add(2 * time.Hour)},
+		{int64(2), int64(7), int64(3), "comment", "client_visible", "Su audiencia fue programada", "", created.Add(time.Hour)},
+		{int64(1), int64(7), int64(3), "comment", "internal", "Cliente difícil, revisar pagos", "", created},
+	}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "cases"`):
+				// Only client 5 owns case 7
+				if strings.Contains(query, "client_id") {
+					if intArg(args[len(args)-1]) != 5 {
+						return countRow(0)
+					}
+				}
+				return countRow(1)
+			case strings.Contains(query, `FROM "case_events"`):
+				matching := make([][]driver.Value, 0)
+				for _, event := range events {
+					if strings.Contains(query, "visibility = ") && !boundArg(args, event[4]) {
+						continue
+					}
+					if strings.Contains(query, "event_type IN") && !boundArg(args, event[3]) {
+						continue
+					}
+					matching = append(matching, event)
+				}
+				if strings.Contains(query, "count(*)") {
+					return countRow(int64(len(matching)))
+				}
+				return []string{"id", "case_id", "user_id", "event_type", "visibility", "comment_text", "description", "created_at"}, matching
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "first_name", "last_name", "role"}, [][]driver.Value{{int64(3), "Laura", "Méndez", "lawyer"}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// runTimeline lists the events of case 7 as the user of the role, with the query string.
+func runTimeline(t *testing.T, userID, role, query string) (int, []caseTimelineEvent) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cases/7/events?"+query, nil)
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set("userID", userID)
+	c.Set("userRole", role)
+	GetCaseEvents(scriptedDB(t, timelineScript()))(c)
+
+	var body struct {
+		Data []caseTimelineEvent `json:"data"`
+	}
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, body.Data
+}
+
+// timelineIDs returns the IDs of the events, in order.
+func timelineIDs(events []caseTimelineEvent) []uint {
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestCaseTimelineHidesInternalEventsFromClients(t *testing.T) {
+	for _, query := range []string{"", "visibility=internal"} {
+		status, events := runTimeline(t, "5", "client", query)
+		if status != http.StatusOK {
+			t.Fatalf("%q: status = %d", query, status)
+		}
+		if ids := timelineIDs(events); len(ids) != 2 || ids[0] != 4 || ids[1] != 2 {
+			t.Errorf("%q: client sees events %v, want [4 2]", query, ids)
+		}
+		for _, event := range events {
+			if event.Visibility != "client_visible" {
+				t.Errorf("%q: client sees %s event %d", query, event.Visibility, event.ID)
+			}
+		}
+	}
+
+	// Another client's case is not found
+	if status, _ := runTimeline(t, "6", "client", ""); status != http.StatusNotFound {
+		t.Errorf("other client: status = %d, want 404", status)
+	}
+}
+
+func TestCaseTimelineFiltersByType(t *testing.T) {
+	status, events := runTimeline(t, "3", "lawyer", "type=comment")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if ids := timelineIDs(events); len(ids) != 2 || ids[0] != 2 || ids[1] != 1 {
+		t.Errorf("comments = %v, want [2 1]", ids)
+	}
+	if events[0].Author == nil || events[0].Author.FirstName != "Laura" || events[0].Author.LastName != "Méndez" {
+		t.Errorf("author = %+v", events[0].Author)
+	}
+
+	_, events = runTimeline(t, "3", "lawyer", "type=file_upload,stage_change&visibility=internal")
+	if ids := timelineIDs(events); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("internal uploads and stage changes = %v, want [3]", ids)
+	}
+
+	if status, _ := runTimeline(t, "3", "lawyer", "visibility=public"); status != http.StatusBadRequest {
+		t.Errorf("invalid visibility: status = %d, want 400", status)
+	}
+}
@@ -2,20 +2,40 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// GetCasesEnhanced returns cases based on user permissions and assignments
+// GetCasesEnhanced returns cases based on user permissions and assignments.
+// With ?light=true it returns minimal rows (CaseListItem) without preloads, for mobile lists.
 func GetCasesEnhanced(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := resolveRecordVisibility(c); !ok {
+			return
+		}
+
 		caseService := NewCaseService(db)
+		start := time.Now()
 
-		cases, total, err := caseService.GetCases(c)
+		var cases interface{}
+		var count int
+		var total int64
+		var err error
+		if c.Query("light") == "true" {
+			var rows []CaseListItem
+			rows, total, err = caseService.GetCasesLight(c)
+			cases, count = rows, len(rows)
+		} else {
+			var rows []models.Case
+			rows, total, err = caseService.GetCases(c)
+			cases, count = rows, len(rows)
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Failed to retrieve cases",
@@ -24,25 +44,24 @@ func GetCasesEnhanced(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Calculate pagination info
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-		totalPages := (total + int64(limit) - 1) / int64(limit)
+		// Calculate pagination info from the same clamped values the query used
+		page, pageSize := parseCasePagination(c)
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
 
 		c.JSON(http.StatusOK, gin.H{
 			"data": cases,
 			"pagination": gin.H{
 				"page":       page,
-				"pageSize":   limit,
+				"pageSize":   pageSize,
 				"total":      total,
 				"totalPages": totalPages,
 				"hasNext":    page < int(totalPages),
 				"hasPrev":    page > 1,
 			},
 			"performance": gin.H{
-				"queryTime":    "0ms",
+				"queryTime":    time.Since(start).String(),
 				"cacheHit":     false,
-				"responseSize": len(cases),
+				"responseSize": count,
 			},
 		})
 	}
@@ -56,6 +75,11 @@ func GetCaseByIDEnhanced(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Case ID is required"})
 			return
 		}
+		// Case detail already returns archived/deleted cases (flagged by isArchived/deletedAt);
+		// the flags are still validated so non-admins get a consistent 403
+		if _, ok := resolveRecordVisibility(c); !ok {
+			return
+		}
 
 		caseService := NewCaseService(db)
 
@@ -83,8 +107,27 @@ func CreateCaseEnhanced(db *gorm.DB) gin.HandlerFunc {
 		caseService := NewCaseService(db)
 
 		caseData, err := caseService.CreateCase(c)
+		var inUse *clientEmailInUseError
+		if errors.As(err, &inUse) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Failed to create case",
+				"message": err.Error(),
+				"existingUser": gin.H{
+					"id":        inUse.User.ID,
+					"email":     inUse.User.Email,
+					"role":      inUse.User.Role,
+					"firstName": inUse.User.FirstName,
+					"lastName":  inUse.User.LastName,
+				},
+			})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
+			status := http.StatusBadRequest
+			if errors.Is(err, errCaseDepartmentMismatch) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{
 				"error":   "Failed to create case",
 				"message": err.Error(),
 			})
@@ -109,8 +152,20 @@ func UpdateCase(db *gorm.DB) gin.HandlerFunc {
 		caseService := NewCaseService(db)
 
 		caseData, err := caseService.UpdateCase(caseID, c)
-		if err != nil {
+		if errors.Is(err, errInvalidCaseStatus) {
 			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Estado de caso no válido",
+				"message": err.Error(),
+				"allowed": editableCaseStatuses(),
+			})
+			return
+		}
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, errCaseDepartmentMismatch) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{
 				"error":   "Failed to update case",
 				"message": err.Error(),
 			})
@@ -136,6 +191,19 @@ func DeleteCase(db *gorm.DB) gin.HandlerFunc {
 		_ = db.First(&caseData, caseID).Error // best-effort for notification payload
 		caseService := NewCaseService(db)
 		err := caseService.DeleteCase(caseID, c)
+		if errors.Is(err, errCaseDeletionNeedsForce) {
+			impact, _ := loadCaseDeletionImpact(db, caseData.ID)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":         err.Error(),
+				"forceRequired": true,
+				"impact":        impact,
+				"details": gin.H{
+					"activeAppointments": impact.UpcomingAppointments,
+					"pendingTasks":       impact.OpenTasks,
+				},
+			})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Failed to delete case",
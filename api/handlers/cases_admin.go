@@ -4,14 +4,18 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/middleware"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// UpdateCaseStage updates the stage of a case
+// UpdateCaseStage updates the stage of a case. When stage/status sync is enabled for the case's
+// office (see config.CaseStageStatusSyncEnabled) the status follows the stage as well;
+// otherwise an optional status is applied with it. Unknown statuses are rejected with 400.
 func UpdateCaseStage(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		caseID := c.Param("id")
@@ -21,13 +25,23 @@ func UpdateCaseStage(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var request struct {
-			Stage string `json:"stage" binding:"required"`
+			Stage  string `json:"stage" binding:"required"`
+			Status string `json:"status"` // Used only when the office manages status by hand
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
 			return
 		}
+		var requestedStatus config.CaseStatus
+		if request.Status != "" {
+			status, ok := config.NormalizeCaseStatus(request.Status)
+			if !ok || status == config.CaseStatusArchived {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case status", "status": request.Status, "allowed": editableCaseStatuses()})
+				return
+			}
+			requestedStatus = status
+		}
 
 		// Find the case first to get its category for stage validation
 		var caseData models.Case
@@ -82,13 +96,49 @@ func UpdateCaseStage(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		previousStage, previousStatus := caseData.CurrentStage, caseData.Status
 		caseData.CurrentStage = request.Stage
 		caseData.UpdatedBy = &userIDUint
 
-		if err := db.Save(&caseData).Error; err != nil {
+		// Unless the office manages status by hand, the stage decides the status, and closing
+		// the case cancels its upcoming appointments
+		statusSynced := config.CaseStageStatusSyncEnabled(caseData.OfficeID)
+		if statusSynced {
+			if status := config.DeriveCaseStatus(caseData.Category, request.Stage); status != "" {
+				caseData.Status = status
+			}
+		} else if requestedStatus != "" {
+			caseData.Status = string(requestedStatus)
+		}
+
+		var cancelledAppointments int64
+		var cancelled []models.Appointment
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&caseData).Error; err != nil {
+				return err
+			}
+			if !statusSynced || caseData.Status != string(config.CaseStatusClosed) || previousStatus == caseData.Status {
+				return nil
+			}
+			if err := tx.Where("case_id = ? AND start_time >= ? AND status IN ?", caseData.ID, time.Now(),
+				[]config.AppointmentStatus{config.StatusPending, config.StatusConfirmed}).
+				Find(&cancelled).Error; err != nil || len(cancelled) == 0 {
+				return err
+			}
+			ids := make([]uint, 0, len(cancelled))
+			for _, appointment := range cancelled {
+				ids = append(ids, appointment.ID)
+			}
+			result := tx.Model(&models.Appointment{}).Where("id IN ?", ids).Update("status", config.StatusCancelled)
+			cancelledAppointments = result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update case stage"})
 			return
 		}
+		recordCaseStageChange(db, &caseData, previousStage, previousStatus, &userIDUint)
+		notifyAppointmentEvent(db, models.WebhookEventAppointmentCancelled, userIDUint, cancelled...)
 
 		// Invalidate cache after successful update
 		invalidateCache(caseID)
@@ -100,8 +150,10 @@ func UpdateCaseStage(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Case stage updated successfully",
-			"data":    caseData,
+			"message":               "Case stage updated successfully",
+			"data":                  caseData,
+			"statusSynced":          statusSynced,
+			"cancelledAppointments": cancelledAppointments,
 		})
 	}
 }
@@ -0,0 +1,131 @@
+// api/handlers/cases_pagination_test.go
+// Unit tests for case list pagination.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordQueries captures the SQL of every query and row statement run on db.
+func recordQueries(t *testing.T, db *gorm.DB) *[]string {
+	t.Helper()
+	statements := &[]string{}
+	record := func(tx *gorm.DB) {
+		*statements = append(*statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:record_query", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:record_row", record); err != nil {
+		t.Fatal(err)
+	}
+	return statements
+}
+
+// getCases runs GetCasesEnhanced for a lawyer of office 3 in the Familiar department.
+func getCases(t *testing.T, db *gorm.DB, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/staff/cases?"+query, nil)
+	c.Set("userID", "5")
+	c.Set("userRole", "lawyer")
+	c.Set("officeScopeID", uint(3))
+	c.Set("userDepartment", "Familiar")
+	GetCasesEnhanced(db)(c)
+	return w
+}
+
+// whereClause returns the WHERE conditions of a statement, without ordering and paging.
+func whereClause(statement string) string {
+	where := statement[strings.Index(statement, " WHERE "):]
+	for _, keyword := range []string{" ORDER BY ", " LIMIT ", " OFFSET "} {
+		if i := strings.Index(where, keyword); i >= 0 {
+			where = where[:i]
+		}
+	}
+	return where
+}
+
+func TestGetCasesCountUsesAccessScope(t *testing.T) {
+	for _, query := range []string{"page=2&pageSize=10&status=open&stage=notificacion", "category=Familiar&search=ana"} {
+		db := dryRunDB(t)
+		statements := recordQueries(t, db)
+		if w := getCases(t, db, query); w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", query, w.Code, w.Body.String())
+		}
+
+		var count, page string
+		for _, statement := range *statements {
+			if strings.HasPrefix(statement, "SELECT count(*)") {
+				count = statement
+			} else if strings.Contains(statement, `FROM "cases"`) && page == "" {
+				page = statement
+			}
+		}
+		if count == "" || page == "" {
+			t.Fatalf("%s: expected a count and a page query, got %q", query, *statements)
+		}
+
+		// Totals are counted over exactly the rows the page is drawn from
+		if whereClause(count) != whereClause(page) {
+			t.Errorf("%s: count and page are filtered differently:\n count: %s\n page:  %s", query, count, page)
+		}
+		for _, scope := range []string{"(office_id = 3 AND category = 'Familiar')", "primary_staff_id = '5'", "assigned_to_id = '5'"} {
+			if !strings.Contains(count, scope) {
+				t.Errorf("%s: count query is missing the access condition %q: %s", query, scope, count)
+			}
+		}
+	}
+}
+
+func TestGetCasesPageSize(t *testing.T) {
+	tests := []struct {
+		query            string
+		wantLimit        string
+		wantPage, wantPS int
+	}{
+		{"", "LIMIT 20", 1, 20},
+		{"page=3&pageSize=50", "LIMIT 50 OFFSET 100", 3, 50},
+		{"pageSize=500", "LIMIT 100", 1, 100},
+		{"limit=30", "LIMIT 30", 1, 30}, // Older clients send limit
+		{"pageSize=0&page=-1", "LIMIT 20", 1, 20},
+	}
+	for _, tt := range tests {
+		db := dryRunDB(t)
+		statements := recordQueries(t, db)
+		w := getCases(t, db, tt.query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d: %s", tt.query, w.Code, w.Body.String())
+		}
+
+		found := false
+		for _, statement := range *statements {
+			found = found || strings.HasSuffix(statement, tt.wantLimit)
+		}
+		if !found {
+			t.Errorf("%q: no query ends with %q: %q", tt.query, tt.wantLimit, *statements)
+		}
+
+		var response struct {
+			Pagination struct {
+				Page     int `json:"page"`
+				PageSize int `json:"pageSize"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Pagination.Page != tt.wantPage || response.Pagination.PageSize != tt.wantPS {
+			t.Errorf("%q: pagination = %+v, want page %d pageSize %d", tt.query, response.Pagination, tt.wantPage, tt.wantPS)
+		}
+	}
+}
@@ -2,18 +2,40 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/middleware"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// errCaseDepartmentMismatch is returned when department-bound staff create a case in, or move
+// a case into or out of, a department other than their own.
+var errCaseDepartmentMismatch = errors.New("case category must match your department")
+
+// errInvalidCaseStatus is returned when an update sets a status that is not a config.CaseStatus.
+var errInvalidCaseStatus = errors.New("invalid case status")
+
+// editableCaseStatuses lists the statuses a case update may set: every config.CaseStatus
+// except archived.
+func editableCaseStatuses() []string {
+	statuses := make([]string, 0, len(config.GetValidCaseStatuses()))
+	for _, status := range config.GetValidCaseStatuses() {
+		if status != config.CaseStatusArchived {
+			statuses = append(statuses, string(status))
+		}
+	}
+	return statuses
+}
+
 // CaseService handles case-related business logic
 type CaseService struct {
 	db *gorm.DB
@@ -36,9 +58,26 @@ func (s *CaseService) NewCaseQueryBuilder() *CaseQueryBuilder {
 	}
 }
 
+// NewCaseListQueryBuilder creates a query builder without preloads, for light list rows
+func (s *CaseService) NewCaseListQueryBuilder() *CaseQueryBuilder {
+	return &CaseQueryBuilder{
+		query: s.db.Model(&models.Case{}),
+	}
+}
+
 // ExcludeArchived excludes archived and soft-deleted cases from the query
 func (qb *CaseQueryBuilder) ExcludeArchived() *CaseQueryBuilder {
-	qb.query = qb.query.Where("is_archived = ? AND deleted_at IS NULL", false)
+	return qb.ApplyVisibility(recordVisibility{})
+}
+
+// ApplyVisibility excludes archived and soft-deleted cases unless the (admin) caller asked to include them
+func (qb *CaseQueryBuilder) ApplyVisibility(visibility recordVisibility) *CaseQueryBuilder {
+	if !visibility.IncludeArchived {
+		qb.query = qb.query.Where("is_archived = ?", false)
+	}
+	if !visibility.IncludeDeleted {
+		qb.query = qb.query.Where("deleted_at IS NULL")
+	}
 	return qb
 }
 
@@ -110,12 +149,12 @@ func (qb *CaseQueryBuilder) ApplyAccessControl(c *gin.Context) *CaseQueryBuilder
 
 // ApplyFilters applies search and filter parameters
 func (qb *CaseQueryBuilder) ApplyFilters(c *gin.Context) *CaseQueryBuilder {
-	// Search parameter - searches in client names using subquery to avoid JOIN conflicts
+	// Search parameter - searches the case number and client names, using a subquery to avoid JOIN conflicts
 	if search := c.Query("search"); search != "" {
 		searchTerm := "%" + search + "%"
 		qb.query = qb.query.Where(
-			"client_id IN (SELECT id FROM users WHERE first_name ILIKE ? OR last_name ILIKE ? OR CONCAT(first_name, ' ', last_name) ILIKE ?)",
-			searchTerm, searchTerm, searchTerm,
+			"(cases.case_number ILIKE ? OR client_id IN (SELECT id FROM users WHERE first_name ILIKE ? OR last_name ILIKE ? OR CONCAT(first_name, ' ', last_name) ILIKE ?))",
+			searchTerm, searchTerm, searchTerm, searchTerm,
 		)
 	}
 
@@ -129,6 +168,11 @@ func (qb *CaseQueryBuilder) ApplyFilters(c *gin.Context) *CaseQueryBuilder {
 		qb.query = qb.query.Where("cases.category = ?", category)
 	}
 
+	// Stage filter
+	if stage := c.Query("stage"); stage != "" {
+		qb.query = qb.query.Where("cases.current_stage = ?", stage)
+	}
+
 	// Title filter (Case Type - exact match for specific case types)
 	if title := c.Query("title"); title != "" {
 		qb.query = qb.query.Where("cases.title = ?", title)
@@ -157,48 +201,39 @@ func (qb *CaseQueryBuilder) ApplyFilters(c *gin.Context) *CaseQueryBuilder {
 
 // ApplySorting applies sorting parameters
 func (qb *CaseQueryBuilder) ApplySorting(c *gin.Context) *CaseQueryBuilder {
+	// Sort field is validated against the central allowlist (query_fields.go)
 	sortBy := c.DefaultQuery("sortBy", "created_at")
 	sortOrder := c.DefaultQuery("sortOrder", "desc")
-
-	// Validate sort order
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "desc"
-	}
-
-	// Validate sort field
-	allowedSortFields := map[string]bool{
-		"created_at":    true,
-		"updated_at":    true,
-		"title":         true,
-		"status":        true,
-		"priority":      true,
-		"category":      true,
-		"docket_number": true,
-	}
-
-	if !allowedSortFields[sortBy] {
-		sortBy = "created_at"
-	}
-
-	qb.query = qb.query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
+	qb.query = qb.query.Order(sortClause("cases", sortBy, sortOrder, "created_at DESC"))
 	return qb
 }
 
-// ApplyPagination applies pagination parameters
-func (qb *CaseQueryBuilder) ApplyPagination(c *gin.Context) *CaseQueryBuilder {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+// caseMaxPageSize is the largest page of cases a list request may ask for.
+const caseMaxPageSize = 100
+
+// parseCasePagination reads page and pageSize (or the older limit) from the request, in the
+// same way as parsePaginationParams, except that page sizes above caseMaxPageSize are clamped.
+func parseCasePagination(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ = strconv.Atoi(c.DefaultQuery("pageSize", c.DefaultQuery("limit", "20")))
 
 	// Validate pagination parameters
 	if page < 1 {
 		page = 1
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > caseMaxPageSize {
+		pageSize = caseMaxPageSize
 	}
+	return page, pageSize
+}
 
-	offset := (page - 1) * limit
-	qb.query = qb.query.Offset(offset).Limit(limit)
+// ApplyPagination applies pagination parameters
+func (qb *CaseQueryBuilder) ApplyPagination(c *gin.Context) *CaseQueryBuilder {
+	page, pageSize := parseCasePagination(c)
+	qb.query = qb.query.Offset((page - 1) * pageSize).Limit(pageSize)
 	return qb
 }
 
@@ -211,10 +246,11 @@ func (qb *CaseQueryBuilder) Execute() (*gorm.DB, error) {
 func (s *CaseService) GetCases(c *gin.Context) ([]models.Case, int64, error) {
 	var cases []models.Case
 	var total int64
+	visibility := requestedRecordVisibility(c)
 
 	// Build and execute count query
 	countQuery := s.NewCaseQueryBuilder().
-		ExcludeArchived().
+		ApplyVisibility(visibility).
 		ApplyAccessControl(c).
 		ApplyFilters(c)
 
@@ -224,7 +260,7 @@ func (s *CaseService) GetCases(c *gin.Context) ([]models.Case, int64, error) {
 
 	// Build and execute main query
 	query := s.NewCaseQueryBuilder().
-		ExcludeArchived().
+		ApplyVisibility(visibility).
 		ApplyAccessControl(c).
 		ApplyFilters(c).
 		ApplySorting(c).
@@ -237,6 +273,57 @@ func (s *CaseService) GetCases(c *gin.Context) ([]models.Case, int64, error) {
 	return cases, total, nil
 }
 
+// CaseListItem is the reduced case row returned by the light case list.
+type CaseListItem struct {
+	ID             uint      `json:"id"`
+	CaseNumber     string    `json:"caseNumber"`
+	Title          string    `json:"title"`
+	Status         string    `json:"status"`
+	CurrentStage   string    `json:"currentStage"`
+	Category       string    `json:"category"`
+	Priority       string    `json:"priority"`
+	OfficeID       uint      `json:"officeId"`
+	ClientID       *uint     `json:"clientId"`
+	ClientName     string    `json:"clientName"`
+	PrimaryStaffID *uint     `json:"primaryStaffId"`
+	IsArchived     bool      `json:"isArchived"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// caseListItemColumns are the columns selected for CaseListItem; the client name replaces the Client preload
+const caseListItemColumns = `cases.id, cases.case_number, cases.title, cases.status, cases.current_stage, cases.category, cases.priority,
+	cases.office_id, cases.client_id, cases.primary_staff_id, cases.is_archived, cases.updated_at,
+	(SELECT TRIM(CONCAT(first_name, ' ', last_name)) FROM users WHERE users.id = cases.client_id) AS client_name`
+
+// GetCasesLight retrieves the same cases as GetCases as minimal rows, without preloads
+func (s *CaseService) GetCasesLight(c *gin.Context) ([]CaseListItem, int64, error) {
+	cases := make([]CaseListItem, 0)
+	var total int64
+	visibility := requestedRecordVisibility(c)
+
+	countQuery := s.NewCaseListQueryBuilder().
+		ApplyVisibility(visibility).
+		ApplyAccessControl(c).
+		ApplyFilters(c)
+
+	if err := countQuery.query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := s.NewCaseListQueryBuilder().
+		ApplyVisibility(visibility).
+		ApplyAccessControl(c).
+		ApplyFilters(c).
+		ApplySorting(c).
+		ApplyPagination(c)
+
+	if err := query.query.Select(caseListItemColumns).Scan(&cases).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return cases, total, nil
+}
+
 // GetCaseByID retrieves a single case by ID
 func (s *CaseService) GetCaseByID(caseID string, light bool) (*models.Case, error) {
 	var caseData models.Case
@@ -298,39 +385,12 @@ func (s *CaseService) CreateCase(c *gin.Context) (*models.Case, error) {
 		}
 	}
 
-	// 2) If no client yet and we have new-client fields, find existing by email or create
-	if clientID == nil && hasFirstName && hasLastName && hasEmail {
-		emailTrim := strings.TrimSpace(email)
-		if emailTrim != "" {
-			var existingUser models.User
-			if err := s.db.Where("email = ? AND role = ?", emailTrim, "client").First(&existingUser).Error; err == nil {
-				// Use existing client with this email
-				clientID = &existingUser.ID
-				requestData["clientId"] = float64(existingUser.ID)
-			} else {
-				// Create new client user
-				hashedPassword, err := bcrypt.GenerateFromPassword([]byte("TempPassword123!"), bcrypt.DefaultCost)
-				if err != nil {
-					return nil, fmt.Errorf("failed to hash password: %v", err)
-				}
-				newClient := models.User{
-					FirstName: strings.TrimSpace(firstName),
-					LastName:  strings.TrimSpace(lastName),
-					Email:     emailTrim,
-					Password:  string(hashedPassword),
-					Role:      "client",
-					IsActive:  true,
-				}
-				if officeID, ok := requestData["officeId"].(float64); ok {
-					officeIDUint := uint(officeID)
-					newClient.OfficeID = &officeIDUint
-				}
-				if err := s.db.Create(&newClient).Error; err != nil {
-					return nil, fmt.Errorf("failed to create client: %v", err)
-				}
-				clientID = &newClient.ID
-				requestData["clientId"] = float64(newClient.ID)
-			}
+	// 2) If no client yet and we have new-client fields, create the client with the case below
+	emailTrim := strings.TrimSpace(email)
+	createClient := clientID == nil && hasFirstName && hasLastName && hasEmail && emailTrim != ""
+	if createClient {
+		if err := validateClientEmail(emailTrim); err != nil {
+			return nil, err
 		}
 	}
 
@@ -347,6 +407,9 @@ func (s *CaseService) CreateCase(c *gin.Context) (*models.Case, error) {
 	if category, ok := requestData["category"].(string); ok {
 		caseData.Category = category
 	}
+	if user := departmentBoundUser(c); user != nil && caseData.Category != *user.Department {
+		return nil, fmt.Errorf("%w (%s)", errCaseDepartmentMismatch, *user.Department)
+	}
 	if officeID, ok := requestData["officeId"].(float64); ok {
 		caseData.OfficeID = uint(officeID)
 	}
@@ -354,18 +417,31 @@ func (s *CaseService) CreateCase(c *gin.Context) (*models.Case, error) {
 		caseData.DocketNumber = docketNumber
 	}
 	if court, ok := requestData["court"].(string); ok {
-		caseData.Court = court
+		caseData.Court = normalizeCourt(s.db, court)
 	}
 	if fee, ok := requestData["fee"].(float64); ok {
 		caseData.Fee = fee
 	}
+	if value, ok := requestData["dueDate"]; ok {
+		dueDate, err := parseCaseDueDate(value)
+		if err != nil {
+			return nil, err
+		}
+		caseData.DueDate = dueDate
+	}
+	if staffID, ok := requestData["primaryStaffId"].(float64); ok && staffID > 0 {
+		primaryStaffID := uint(staffID)
+		caseData.PrimaryStaffID = &primaryStaffID
+	}
+	if caseData.PrimaryStaffID == nil {
+		caseData.PrimaryStaffID = autoAssignStaff(s.db, caseData.Category, caseData.OfficeID)
+	}
 
-	// Set client ID
 	caseData.ClientID = clientID
 
 	// Set default values
 	if caseData.Status == "" {
-		caseData.Status = "open"
+		caseData.Status = string(config.CaseStatusOpen)
 	}
 	if caseData.Priority == "" {
 		caseData.Priority = "medium"
@@ -388,10 +464,48 @@ func (s *CaseService) CreateCase(c *gin.Context) (*models.Case, error) {
 	caseData.CreatedBy = uint(userIDUint)
 	caseData.UpdatedBy = &[]uint{uint(userIDUint)}[0]
 
-	// Create the case
-	if err := s.db.Create(&caseData).Error; err != nil {
-		return nil, fmt.Errorf("failed to create case: %v", err)
+	// Create the new client, if any, and the case together so a rejected case leaves no client behind
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if createClient {
+			client, err := restoreClientByEmail(tx, emailTrim, strings.TrimSpace(firstName), strings.TrimSpace(lastName))
+			if err != nil {
+				return err
+			}
+			if client == nil {
+				hashedPassword, err := bcrypt.GenerateFromPassword([]byte("TempPassword123!"), bcrypt.DefaultCost)
+				if err != nil {
+					return fmt.Errorf("failed to hash password: %v", err)
+				}
+				client = &models.User{
+					FirstName: strings.TrimSpace(firstName),
+					LastName:  strings.TrimSpace(lastName),
+					Email:     emailTrim,
+					Password:  string(hashedPassword),
+					Role:      "client",
+					IsActive:  true,
+
+					MustChangePassword: true,
+				}
+				if caseData.OfficeID != 0 {
+					officeID := caseData.OfficeID
+					client.OfficeID = &officeID
+				}
+				if err := tx.Create(client).Error; err != nil {
+					return fmt.Errorf("failed to create client: %v", err)
+				}
+			}
+			caseData.ClientID = &client.ID
+		}
+
+		if err := tx.Create(&caseData).Error; err != nil {
+			return fmt.Errorf("failed to create case: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	recordCaseStageChange(s.db, &caseData, "", "", caseData.UpdatedBy)
 
 	// Load relationships
 	if err := s.db.Preload("Client").Preload("Office").Preload("PrimaryStaff").First(&caseData, caseData.ID).Error; err != nil {
@@ -416,6 +530,11 @@ func (s *CaseService) UpdateCase(caseID string, c *gin.Context) (*models.Case, e
 		return nil, fmt.Errorf("invalid request data: %v", err)
 	}
 
+	// Case numbers are assigned on creation and never change
+	for _, key := range []string{"caseNumber", "case_number", "CaseNumber"} {
+		delete(updateData, key)
+	}
+
 	// Set audit fields
 	userID, _ := c.Get("userID")
 	userIDUint, err := strconv.ParseUint(userID.(string), 10, 32)
@@ -424,14 +543,44 @@ func (s *CaseService) UpdateCase(caseID string, c *gin.Context) (*models.Case, e
 	}
 	updateData["updatedBy"] = uint(userIDUint)
 
+	// Moving a case between departments: staff may neither move a case out of their
+	// department nor pull another department's case into it
+	previousCategory := caseData.Category
+	if category, ok := updateData["category"].(string); ok && category != caseData.Category {
+		if user := departmentBoundUser(c); user != nil && (category != *user.Department || caseData.Category != *user.Department) {
+			return nil, fmt.Errorf("%w (%s)", errCaseDepartmentMismatch, *user.Department)
+		}
+	}
+
 	// Map frontend field names to database column names
 	// GORM's Updates() with map doesn't automatically apply column mappings from struct tags
 	columnMapping := map[string]string{
 		"docketNumber": "docket_number", // docketNumber -> docket_number
 		"updatedBy":    "updated_by",    // updatedBy -> updated_by
+		"dueDate":      "due_date",      // dueDate -> due_date
 		// Add other mappings as needed
 	}
 
+	if court, ok := updateData["court"].(string); ok {
+		updateData["court"] = normalizeCourt(s.db, court)
+	}
+	if value, ok := updateData["status"]; ok {
+		// Archiving goes through the archive endpoints so the archive columns are set
+		raw, _ := value.(string)
+		status, valid := config.NormalizeCaseStatus(raw)
+		if !valid || status == config.CaseStatusArchived {
+			return nil, fmt.Errorf("%w: %q", errInvalidCaseStatus, raw)
+		}
+		updateData["status"] = string(status)
+	}
+	if value, ok := updateData["dueDate"]; ok {
+		dueDate, err := parseCaseDueDate(value)
+		if err != nil {
+			return nil, err
+		}
+		updateData["dueDate"] = dueDate
+	}
+
 	// Create a new map with correct column names
 	mappedUpdateData := make(map[string]interface{})
 	for key, value := range updateData {
@@ -443,6 +592,7 @@ func (s *CaseService) UpdateCase(caseID string, c *gin.Context) (*models.Case, e
 	}
 
 	// Update only the provided fields with correct column names
+	previousStage, previousStatus := caseData.CurrentStage, caseData.Status
 	if err := s.db.Model(&caseData).Updates(mappedUpdateData).Error; err != nil {
 		return nil, fmt.Errorf("failed to update case: %v", err)
 	}
@@ -454,9 +604,45 @@ func (s *CaseService) UpdateCase(caseID string, c *gin.Context) (*models.Case, e
 	if err := s.db.Preload("Client").Preload("Office").Preload("PrimaryStaff").First(&caseData, caseData.ID).Error; err != nil {
 		return nil, fmt.Errorf("failed to load case relationships: %v", err)
 	}
+	updatedBy := uint(userIDUint)
+	recordCaseStageChange(s.db, &caseData, previousStage, previousStatus, &updatedBy)
+	recordCaseDepartmentChange(s.db, &caseData, previousCategory, updatedBy)
 	return &caseData, nil
 }
 
+// departmentBoundUser returns the current user when they may only work on cases in their own
+// department, or nil when they are exempt (admins, office managers, staff without a department)
+// or enforcement is disabled. The rule matches the department check on appointment creation.
+func departmentBoundUser(c *gin.Context) *models.User {
+	if !config.CaseDepartmentEnforcementEnabled() {
+		return nil
+	}
+	value, _ := c.Get("currentUser")
+	user, ok := value.(models.User)
+	if !ok || !middleware.IsStaffRole(user.Role) || user.Role == config.RoleOfficeManager || user.Department == nil {
+		return nil
+	}
+	return &user
+}
+
+// recordCaseDepartmentChange adds an internal case event when an update moved the case to
+// another category, since that changes which department's staff can see it.
+func recordCaseDepartmentChange(db *gorm.DB, caseData *models.Case, fromCategory string, changedBy uint) {
+	if caseData.ID == 0 || caseData.Category == fromCategory {
+		return
+	}
+	event := models.CaseEvent{
+		CaseID:      caseData.ID,
+		UserID:      changedBy,
+		EventType:   "department_change",
+		Description: fmt.Sprintf("Departamento cambiado de %s a %s", fromCategory, caseData.Category),
+		Visibility:  "internal",
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("WARNING: Failed to record department change for case %d: %v", caseData.ID, err)
+	}
+}
+
 // DeleteCase soft deletes a case
 func (s *CaseService) DeleteCase(caseID string, c *gin.Context) error {
 	var caseData models.Case
@@ -466,6 +652,17 @@ func (s *CaseService) DeleteCase(caseID string, c *gin.Context) error {
 		return fmt.Errorf("case not found: %v", err)
 	}
 
+	// Pending work is only dropped on purpose
+	if c.Query("force") != "true" {
+		impact, err := loadCaseDeletionImpact(s.db, caseData.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check case dependencies: %v", err)
+		}
+		if impact.ForceRequired {
+			return errCaseDeletionNeedsForce
+		}
+	}
+
 	// Set deletion fields
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -480,6 +677,9 @@ func (s *CaseService) DeleteCase(caseID string, c *gin.Context) error {
 		return fmt.Errorf("invalid user ID format: %v", err)
 	}
 	deletionReason := c.PostForm("reason")
+	if deletionReason == "" {
+		deletionReason = c.Query("reason")
+	}
 	if deletionReason == "" {
 		deletionReason = "Manual deletion"
 	}
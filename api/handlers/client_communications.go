@@ -0,0 +1,124 @@
+// api/handlers/client_communications.go
+// Communication log for a client: in-app notifications plus outbound email/SMS deliveries.
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// communicationLogEntry is one row of the client communication log.
+type communicationLogEntry struct {
+	Source     string    `json:"source"` // "notification" or "delivery"
+	ID         uint      `json:"id"`
+	Channel    string    `json:"channel"`
+	Type       string    `json:"type"`
+	Summary    string    `json:"summary"`
+	Recipient  *string   `json:"recipient,omitempty"`
+	Status     string    `json:"status"`
+	Error      *string   `json:"error,omitempty"`
+	EntityType *string   `json:"entityType,omitempty"`
+	EntityID   *uint     `json:"entityId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+const communicationLogSQL = `
+	SELECT 'notification' AS source, id, 'in_app' AS channel, type, message AS summary,
+		NULL AS recipient, CASE WHEN is_read THEN 'read' ELSE 'delivered' END AS status,
+		NULL AS error, entity_type, entity_id, created_at
+	FROM notifications
+	WHERE user_id = @user AND deleted_at IS NULL
+	UNION ALL
+	SELECT 'delivery' AS source, id, channel, type, subject AS summary,
+		recipient, status, error, entity_type, entity_id, created_at
+	FROM notification_deliveries
+	WHERE user_id = @user`
+
+// GetClientCommunications lists notifications and emails/SMS sent to a client, newest first.
+// Non-admins only see clients that belong to, or have cases in, their office.
+func GetClientCommunications(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var client models.User
+		if err := db.Select("id, role, office_id").Where("role = ?", "client").First(&client, clientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Cliente no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el cliente", "message": err.Error()})
+			return
+		}
+
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			officeID, ok := c.Get("officeScopeID")
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado"})
+				return
+			}
+			var inOffice int64
+			db.Model(&models.User{}).
+				Where("id = ?", client.ID).
+				Where("office_id = ? OR EXISTS (SELECT 1 FROM cases WHERE cases.client_id = users.id AND cases.office_id = ? AND cases.deleted_at IS NULL)", officeID, officeID).
+				Count(&inOffice)
+			if inOffice == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: el cliente no pertenece a su oficina"})
+				return
+			}
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		args := map[string]interface{}{"user": client.ID, "channel": c.Query("channel")}
+		filter := ""
+		if args["channel"] != "" {
+			filter = " WHERE channel = @channel"
+		}
+
+		var total int64
+		if err := db.Raw("SELECT COUNT(*) FROM ("+communicationLogSQL+") log"+filter, args).Scan(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener comunicaciones", "message": err.Error()})
+			return
+		}
+
+		args["limit"] = pageSize
+		args["offset"] = (page - 1) * pageSize
+		entries := make([]communicationLogEntry, 0)
+		if err := db.Raw("SELECT * FROM ("+communicationLogSQL+") log"+filter+" ORDER BY created_at DESC, id DESC LIMIT @limit OFFSET @offset", args).
+			Scan(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener comunicaciones", "message": err.Error()})
+			return
+		}
+
+		totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+		c.JSON(http.StatusOK, gin.H{
+			"data": entries,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < totalPages,
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// api/handlers/client_email.go
+// Checks shared by the flows that create a client from a name and email (appointments, cases):
+// the email must be real, and must not belong to another user.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"gorm.io/gorm"
+)
+
+// errInvalidClientEmail is returned when a new client's email is malformed or a placeholder.
+var errInvalidClientEmail = errors.New("invalid client email")
+
+// clientEmailInUseError is returned when a new client's email belongs to an active user.
+type clientEmailInUseError struct {
+	User models.User
+}
+
+func (e *clientEmailInUseError) Error() string {
+	return fmt.Sprintf("a user with the email %s already exists (%s); use the existing client or choose a different email", e.User.Email, e.User.Role)
+}
+
+// validateClientEmail checks that email is a bare address with a dotted domain that is not
+// blocked by CLIENT_EMAIL_BLOCKED_DOMAINS. Disabled with CLIENT_EMAIL_VALIDATION=false.
+func validateClientEmail(email string) error {
+	if !config.ClientEmailValidation() {
+		return nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return fmt.Errorf("%w: %q is not a valid address", errInvalidClientEmail, email)
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return fmt.Errorf("%w: %q has no valid domain", errInvalidClientEmail, email)
+	}
+	if config.ClientEmailBlockedDomains()[domain] {
+		return fmt.Errorf("%w: %s is a placeholder domain", errInvalidClientEmail, domain)
+	}
+	return nil
+}
+
+// restoreClientByEmail looks for any user with the email, soft-deleted ones included. A
+// soft-deleted client is restored under the given name and returned; an active user, or a
+// deleted staff account (which must not come back with its login), yields a
+// *clientEmailInUseError. It returns nil, nil when the email is free.
+func restoreClientByEmail(tx *gorm.DB, email, firstName, lastName string) (*models.User, error) {
+	var existingUser models.User
+	err := tx.Unscoped().Where("email = ?", email).First(&existingUser).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing user: %w", err)
+	}
+	if !existingUser.DeletedAt.Valid || existingUser.Role != "client" {
+		return nil, &clientEmailInUseError{User: existingUser}
+	}
+
+	existingUser.DeletedAt = gorm.DeletedAt{}
+	existingUser.FirstName = firstName
+	existingUser.LastName = lastName
+	if err := tx.Unscoped().Save(&existingUser).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore existing user: %w", err)
+	}
+	return &existingUser, nil
+}
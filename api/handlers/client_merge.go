@@ -0,0 +1,134 @@
+// api/handlers/client_merge.go
+// Merging of duplicate client accounts created on the fly by case/appointment flows.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/interfaces"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MergeClients moves the source client's cases (and with them their appointments), payment records
+// and ratings to the target client, then deactivates and soft-deletes the source. Runs in one transaction;
+// the source's sessions are revoked once it commits.
+func MergeClients(db *gorm.DB, sessions interfaces.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sourceID64, err := strconv.ParseUint(c.Param("clientId"), 10, 32)
+		if err != nil || sourceID64 == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de cliente inválido"})
+			return
+		}
+		sourceID := uint(sourceID64)
+
+		var input struct {
+			TargetClientID uint   `json:"targetClientId" binding:"required"`
+			Reason         string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetClientId es requerido", "details": err.Error()})
+			return
+		}
+		if input.TargetClientID == sourceID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El cliente destino debe ser distinto del cliente origen"})
+			return
+		}
+
+		var source, target models.User
+		if err := db.Where("role = ?", "client").First(&source, sourceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Cliente origen no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el cliente origen", "message": err.Error()})
+			return
+		}
+		if err := db.Where("role = ?", "client").First(&target, input.TargetClientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Cliente destino no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el cliente destino", "message": err.Error()})
+			return
+		}
+
+		reason := input.Reason
+		if reason == "" {
+			reason = "duplicate_client"
+		}
+
+		var caseIDs []uint
+		var movedCases, movedAppointments, movedPayments, movedRatings int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Case{}).Where("client_id = ?", source.ID).Pluck("id", &caseIDs).Error; err != nil {
+				return err
+			}
+			if len(caseIDs) > 0 {
+				if err := tx.Model(&models.Appointment{}).Where("case_id IN ?", caseIDs).Count(&movedAppointments).Error; err != nil {
+					return err
+				}
+			}
+
+			result := tx.Model(&models.Case{}).Where("client_id = ?", source.ID).UpdateColumn("client_id", target.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			movedCases = result.RowsAffected
+
+			result = tx.Model(&models.PaymentRecord{}).Where("user_id = ?", source.ID).UpdateColumn("user_id", target.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			movedPayments = result.RowsAffected
+
+			// Ratings are kept per client, so they follow the cases they were given on
+			result = tx.Model(&models.ClientRating{}).Where("client_id = ?", source.ID).UpdateColumn("client_id", target.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			movedRatings = result.RowsAffected
+
+			if err := tx.Model(&source).UpdateColumns(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&source).Error; err != nil {
+				return err
+			}
+			entry := newAuditLog(c, "user", source.ID, "merge", reason, map[string]interface{}{
+				"targetClientId": target.ID,
+				"sourceEmail":    source.Email,
+				"cases":          movedCases,
+				"appointments":   movedAppointments,
+				"payments":       movedPayments,
+				"ratings":        movedRatings,
+			})
+			return tx.Create(&entry).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al fusionar clientes", "message": err.Error()})
+			return
+		}
+
+		revokeRemovedUserSessions(c, sessions, source.ID)
+		for _, caseID := range caseIDs {
+			invalidateCache(strconv.FormatUint(uint64(caseID), 10))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Clientes fusionados exitosamente",
+			"sourceClientId": source.ID,
+			"targetClientId": target.ID,
+			"merged": gin.H{
+				"cases":        movedCases,
+				"appointments": movedAppointments,
+				"payments":     movedPayments,
+				"ratings":      movedRatings,
+			},
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// api/handlers/client_merge_test.go
+// Unit tests for client merges, against an SQLite schema: the source client's rows move to the
+// target, the audit entry is written in the merge's transaction, and the source's sessions are
+// revoked through the session service, so their copies in Redis stop validating too.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// mergeSchema has source client 5, with case 7 and its appointment, a payment, a rating of the
+// case and an active session, and target client 6 with case 8.
+func mergeSchema(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := schemaDB(t, &models.User{}, &models.Case{}, &models.Appointment{}, &models.PaymentRecord{}, &models.ClientRating{},
+		&models.Session{}, &models.AuditLog{})
+	source, target := uint(5), uint(6)
+	now := time.Now()
+	seedRows(t, db,
+		&models.User{ID: 1, FirstName: "Ana", LastName: "Admin", Email: "admin@caf.mx", Password: "x", Role: "admin", IsActive: true},
+		&models.User{ID: 5, FirstName: "María", LastName: "López", Email: "cliente@example.com", Password: "x", Role: "client", IsActive: true},
+		&models.User{ID: 6, FirstName: "María", LastName: "López", Email: "maria.lopez@example.com", Password: "x", Role: "client", IsActive: true},
+		&models.Case{ID: 7, CaseNumber: "CAF-0007", ClientID: &source, OfficeID: 2, Title: "Divorcio"},
+		&models.Case{ID: 8, CaseNumber: "CAF-0008", ClientID: &target, OfficeID: 2, Title: "Pensión alimenticia"},
+		&models.Appointment{ID: 11, CaseID: 7, StaffID: 1, OfficeID: 2, Title: "Primera cita", StartTime: now, EndTime: now.Add(time.Hour)},
+		&models.PaymentRecord{ID: 21, UserID: &source, StripeCheckoutSessionID: "cs_test_21", AmountCents: 50000},
+		&models.ClientRating{ID: 41, ClientID: 5, EntityType: models.RatingEntityCase, CaseID: 7, Rating: 5},
+		&models.Session{ID: 31, UserID: 5, TokenHash: "hash-31", LastActivity: now, ExpiresAt: now.Add(time.Hour), IsActive: true},
+	)
+	return db
+}
+
+// mergeClients merges client 5 into client 6 as admin 1.
+func mergeClients(t *testing.T, db *gorm.DB, sessions *revocableSessions) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/clients/5/merge", strings.NewReader(`{"targetClientId": 6}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "clientId", Value: "5"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	MergeClients(db, sessions)(c)
+	return w
+}
+
+func TestMergeClientsMovesRowsToTarget(t *testing.T) {
+	db := mergeSchema(t)
+	w := mergeClients(t, db, &revocableSessions{})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Merged map[string]int64 `json:"merged"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"cases": 1, "appointments": 1, "payments": 1, "ratings": 1}
+	if !reflect.DeepEqual(response.Merged, want) {
+		t.Errorf("merged = %v, want %v", response.Merged, want)
+	}
+
+	var cases []models.Case
+	db.Order("id").Find(&cases)
+	for _, moved := range cases {
+		if moved.ClientID == nil || *moved.ClientID != 6 {
+			t.Errorf("case %d belongs to client %v, want 6", moved.ID, moved.ClientID)
+		}
+	}
+	var payment models.PaymentRecord
+	db.First(&payment, 21)
+	if payment.UserID == nil || *payment.UserID != 6 {
+		t.Errorf("payment belongs to user %v, want 6", payment.UserID)
+	}
+	var rating models.ClientRating
+	db.First(&rating, 41)
+	if rating.ClientID != 6 {
+		t.Errorf("rating belongs to client %d, want 6", rating.ClientID)
+	}
+
+	var source models.User
+	db.Unscoped().First(&source, 5)
+	if source.IsActive || !source.DeletedAt.Valid {
+		t.Errorf("source client active = %v, deleted = %v", source.IsActive, source.DeletedAt.Valid)
+	}
+	var target models.User
+	if err := db.First(&target, 6).Error; err != nil || !target.IsActive {
+		t.Errorf("target client: %+v, %v", target, err)
+	}
+
+	// The audit entry committed with the merge
+	var entries []models.AuditLog
+	db.Where("entity_type = ? AND entity_id = ? AND action = ?", "user", 5, "merge").Find(&entries)
+	if len(entries) != 1 || entries[0].UserID != 1 {
+		t.Errorf("audit entries = %+v", entries)
+	}
+}
+
+func TestMergeClientsRevokesSourceSessions(t *testing.T) {
+	db := mergeSchema(t)
+	sessions := &revocableSessions{}
+	if w := mergeClients(t, db, sessions); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(sessions.revokedUsers) != 1 || sessions.revokedUsers[0] != 5 {
+		t.Errorf("revoked sessions of %v, want [5]", sessions.revokedUsers)
+	}
+	// Not behind the session service's back, which would leave the Redis copies valid
+	var session models.Session
+	db.First(&session, 31)
+	if !session.IsActive {
+		t.Error("session deactivated directly instead of through the session service")
+	}
+}
+
+func TestMergeClientsRollsBackWhenAuditFails(t *testing.T) {
+	db := mergeSchema(t)
+	if err := db.Migrator().DropTable(&models.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+	sessions := &revocableSessions{}
+	if w := mergeClients(t, db, sessions); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body.String())
+	}
+
+	var moved int64
+	db.Model(&models.Case{}).Where("client_id = ?", 6).Count(&moved)
+	var payment models.PaymentRecord
+	db.First(&payment, 21)
+	var rating models.ClientRating
+	db.First(&rating, 41)
+	var source models.User
+	if moved != 1 || payment.UserID == nil || *payment.UserID != 5 || rating.ClientID != 5 || db.First(&source, 5).Error != nil || !source.IsActive {
+		t.Errorf("merge not rolled back: %d cases moved, payment of %v, rating of %d, source %+v", moved, payment.UserID, rating.ClientID, source)
+	}
+	if len(sessions.revokedUsers) != 0 {
+		t.Errorf("sessions revoked for a merge that rolled back: %v", sessions.revokedUsers)
+	}
+}
@@ -10,8 +10,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// clientCaseDetail is a portal case with its appointments, as the client app's case screen
+// shows it.
+type clientCaseDetail struct {
+	portalCase
+	Appointments []portalAppointment `json:"appointments"`
+}
+
 // GetClientCaseByID returns a client-safe case detail payload for the authenticated client.
-// It enforces ownership and filters timeline events to client-visible items only.
+// It enforces ownership and maps the case to the portal shapes, so only client-visible events
+// and no staff-only fields are sent.
 func GetClientCaseByID(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		caseID := c.Param("id")
@@ -33,20 +41,18 @@ func GetClientCaseByID(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		var caseData models.Case
-		query := db.
-			Preload("Office").
-			Preload("PrimaryStaff").
+		query := portalCaseQuery(db, uint(userID)).
 			Preload("Appointments", func(tx *gorm.DB) *gorm.DB {
 				return tx.Order("start_time DESC")
 			}).
-			Preload("Appointments.Staff").
-			Preload("Appointments.Office").
-			Preload("CaseEvents", func(tx *gorm.DB) *gorm.DB {
-				return tx.Where("visibility = ?", "client_visible").Order("created_at DESC").Limit(100)
+			Preload("Appointments.Staff", func(tx *gorm.DB) *gorm.DB {
+				return tx.Unscoped().Select("id, first_name, last_name")
 			}).
-			Preload("CaseEvents.User")
+			Preload("Appointments.Office", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, name, address, phone_office")
+			})
 
-		if err := query.Where("id = ? AND client_id = ?", caseID, uint(userID)).First(&caseData).Error; err != nil {
+		if err := query.Where("cases.id = ?", caseID).First(&caseData).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
 				return
@@ -55,19 +61,22 @@ func GetClientCaseByID(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if caseData.Appointments == nil {
-			caseData.Appointments = []models.Appointment{}
+		detail := clientCaseDetail{
+			portalCase:   newPortalCase(caseData),
+			Appointments: make([]portalAppointment, 0, len(caseData.Appointments)),
 		}
-		if caseData.CaseEvents == nil {
-			caseData.CaseEvents = []models.CaseEvent{}
+		for _, appointment := range caseData.Appointments {
+			item := newPortalAppointment(appointment)
+			item.CaseTitle = caseData.Title
+			detail.Appointments = append(detail.Appointments, item)
 		}
 
-		c.JSON(http.StatusOK, caseData)
+		c.JSON(http.StatusOK, detail)
 	}
 }
 
 // GetClientAppointments returns appointments for the authenticated client.
-// It joins through cases to guarantee ownership.
+// It joins through cases to guarantee ownership and answers in the portal appointment shape.
 func GetClientAppointments(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDRaw, ok := c.Get("userID")
@@ -132,10 +141,14 @@ func GetClientAppointments(db *gorm.DB) gin.HandlerFunc {
 
 		appointments := make([]models.Appointment, 0)
 		query := db.
-			Preload("Staff").
-			Preload("Office").
+			Preload("Staff", func(tx *gorm.DB) *gorm.DB {
+				return tx.Unscoped().Select("id, first_name, last_name")
+			}).
+			Preload("Office", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, name, address, phone_office")
+			}).
 			Preload("Case", func(tx *gorm.DB) *gorm.DB {
-				return tx.Preload("Office")
+				return tx.Select("id, title")
 			}).
 			Joins("INNER JOIN cases ON cases.id = appointments.case_id").
 			Where("cases.client_id = ?", uint(userID)).
@@ -175,9 +188,13 @@ func GetClientAppointments(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		data := make([]portalAppointment, 0, len(appointments))
+		for _, appointment := range appointments {
+			data = append(data, newPortalAppointment(appointment))
+		}
 		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
 		c.JSON(http.StatusOK, gin.H{
-			"data": appointments,
+			"data": data,
 			"pagination": gin.H{
 				"page":       page,
 				"pageSize":   pageSize,
@@ -0,0 +1,295 @@
+// api/handlers/client_ratings.go
+// Client satisfaction ratings for completed appointments and cases, and the
+// averages derived from them for the admin dashboard.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// completedCaseStatuses are the case statuses that can be rated.
+var completedCaseStatuses = []string{"completed", "closed"}
+
+// ClientRatingInput is the payload for rating an appointment or a case.
+// Exactly one of AppointmentID or CaseID must be set.
+type ClientRatingInput struct {
+	AppointmentID *uint  `json:"appointmentId"`
+	CaseID        *uint  `json:"caseId"`
+	Rating        int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment       string `json:"comment" binding:"max=2000"`
+}
+
+// CreateClientRating lets a client rate one of their own completed appointments or cases.
+// Rating the same item again replaces the previous score.
+func CreateClientRating(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUserRaw, exists := c.Get("currentUser")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		currentUser := currentUserRaw.(models.User)
+		if currentUser.Role != "client" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Solo clientes pueden calificar"})
+			return
+		}
+
+		var input ClientRatingInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Calificación inválida", "message": err.Error()})
+			return
+		}
+		if (input.AppointmentID == nil) == (input.CaseID == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar una cita o un caso a calificar"})
+			return
+		}
+
+		rating := models.ClientRating{
+			ClientID: currentUser.ID,
+			Rating:   input.Rating,
+			Comment:  strings.TrimSpace(input.Comment),
+		}
+
+		if input.AppointmentID != nil {
+			var appointment models.Appointment
+			err := db.Select("appointments.id, appointments.case_id, appointments.staff_id, appointments.office_id, appointments.status").
+				Joins("INNER JOIN cases ON cases.id = appointments.case_id").
+				Where("appointments.id = ? AND cases.client_id = ?", *input.AppointmentID, currentUser.ID).
+				First(&appointment).Error
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Cita no encontrada"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la cita", "message": err.Error()})
+				return
+			}
+			if appointment.Status != config.StatusCompleted {
+				c.JSON(http.StatusConflict, gin.H{"error": "Solo se pueden calificar citas completadas"})
+				return
+			}
+			rating.EntityType = models.RatingEntityAppointment
+			rating.AppointmentID = &appointment.ID
+			rating.CaseID = appointment.CaseID
+			rating.StaffID = &appointment.StaffID
+			rating.OfficeID = &appointment.OfficeID
+		} else {
+			var caseRecord models.Case
+			err := db.Select("id, status, office_id, primary_staff_id").
+				Where("id = ? AND client_id = ?", *input.CaseID, currentUser.ID).
+				First(&caseRecord).Error
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+				return
+			}
+			if !slices.Contains(completedCaseStatuses, caseRecord.Status) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Solo se pueden calificar casos concluidos"})
+				return
+			}
+			rating.EntityType = models.RatingEntityCase
+			rating.CaseID = caseRecord.ID
+			rating.StaffID = caseRecord.PrimaryStaffID
+			rating.OfficeID = &caseRecord.OfficeID
+		}
+
+		existingQuery := db.Where("client_id = ? AND entity_type = ?", currentUser.ID, rating.EntityType)
+		if rating.AppointmentID != nil {
+			existingQuery = existingQuery.Where("appointment_id = ?", *rating.AppointmentID)
+		} else {
+			existingQuery = existingQuery.Where("case_id = ?", rating.CaseID)
+		}
+
+		var existing models.ClientRating
+		err := existingQuery.First(&existing).Error
+		switch {
+		case err == nil:
+			if err := db.Model(&existing).Updates(map[string]interface{}{
+				"rating":  rating.Rating,
+				"comment": rating.Comment,
+			}).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar la calificación", "message": err.Error()})
+				return
+			}
+			db.First(&existing, existing.ID)
+			c.JSON(http.StatusOK, existing)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := db.Create(&rating).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la calificación", "message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, rating)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la calificación", "message": err.Error()})
+		}
+	}
+}
+
+// GetMyClientRatings returns the ratings the current client has submitted.
+func GetMyClientRatings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := extractUserID(c)
+		if clientID == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+
+		ratings := make([]models.ClientRating, 0)
+		if err := db.Where("client_id = ?", *clientID).Order("created_at DESC").Find(&ratings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener calificaciones", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ratings": ratings})
+	}
+}
+
+// GetClientRatings lists client ratings for admins, filterable by staffId, officeId and entityType,
+// together with their count and average.
+func GetClientRatings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.Model(&models.ClientRating{})
+		if staffID, err := strconv.ParseUint(c.Query("staffId"), 10, 32); err == nil {
+			q = q.Where("staff_id = ?", staffID)
+		}
+		if officeID, err := strconv.ParseUint(c.Query("officeId"), 10, 32); err == nil {
+			q = q.Where("office_id = ?", officeID)
+		}
+		if entityType := c.Query("entityType"); entityType != "" {
+			q = q.Where("entity_type = ?", entityType)
+		}
+
+		var summary struct {
+			Count   int64
+			Average float64
+		}
+		if err := q.Session(&gorm.Session{}).Select("COUNT(*) AS count, COALESCE(AVG(rating), 0) AS average").Scan(&summary).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener calificaciones", "message": err.Error()})
+			return
+		}
+
+		limit := 50
+		if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 200 {
+			limit = v
+		}
+		ratings := make([]models.ClientRating, 0)
+		if err := q.Preload("Client").Preload("Staff").Order("created_at DESC").Limit(limit).Find(&ratings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener calificaciones", "message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ratings":       ratings,
+			"count":         summary.Count,
+			"averageRating": summary.Average,
+		})
+	}
+}
+
+// averageClientSatisfaction returns the mean of all client ratings, or of those db is narrowed
+// to (see dashboardScope.ratings), or 0 when there are none.
+func averageClientSatisfaction(db *gorm.DB) float64 {
+	var average float64
+	db.Model(&models.ClientRating{}).Select("COALESCE(AVG(rating), 0)").Scan(&average)
+	return average
+}
+
+// staffRatingSummary is a staff member's client rating average and sample size.
+type staffRatingSummary struct {
+	StaffID       uint    `json:"staffId"`
+	RatingCount   int64   `json:"ratingCount"`
+	AverageRating float64 `json:"averageRating"`
+	Rated         bool    `json:"rated"` // False until RatingCount reaches the minimum sample size
+}
+
+// staffRatingsSubquery aggregates client ratings per staff member; average_rating is 0
+// for staff with fewer than config.StaffRatingMinSamples ratings.
+func staffRatingsSubquery(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.ClientRating{}).
+		Select("staff_id, COUNT(*) AS rating_count, CASE WHEN COUNT(*) >= ? THEN AVG(rating) ELSE 0 END AS average_rating",
+			config.StaffRatingMinSamples()).
+		Where("staff_id IS NOT NULL").
+		Group("staff_id")
+}
+
+// calculateStaffRating returns the guarded average client rating for one staff member.
+func calculateStaffRating(db *gorm.DB, staffID uint) (staffRatingSummary, error) {
+	summary := staffRatingSummary{StaffID: staffID}
+	err := db.Table("(?) AS sr", staffRatingsSubquery(db)).
+		Select("rating_count, average_rating").
+		Where("staff_id = ?", staffID).
+		Scan(&summary).Error
+	summary.Rated = summary.RatingCount >= int64(config.StaffRatingMinSamples())
+	return summary, err
+}
+
+// GetStaffRating returns a staff member's average client rating and number of ratings.
+func GetStaffRating(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staffID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var staff models.User
+		if err := db.Select("id, first_name, last_name, role").First(&staff, staffID).Error; err != nil || staff.Role == "client" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personal no encontrado"})
+			return
+		}
+
+		summary, err := calculateStaffRating(db, staff.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la calificación", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"staffId":       staff.ID,
+			"firstName":     staff.FirstName,
+			"lastName":      staff.LastName,
+			"ratingCount":   summary.RatingCount,
+			"averageRating": summary.AverageRating,
+			"rated":         summary.Rated,
+			"minSamples":    config.StaffRatingMinSamples(),
+		})
+	}
+}
+
+// topStaffPerformance ranks staff with cases by their average client rating, then by completed
+// cases. Only staff in scope are ranked: those of the scope's office, or the scoped staff member.
+func topStaffPerformance(db *gorm.DB, limit int, scope dashboardScope) []StaffPerformance {
+	performance := make([]StaffPerformance, 0, limit)
+	scope.staff(db.Table("users")).
+		Select(`users.id AS user_id, users.first_name, users.last_name, users.role,
+			COUNT(cases.id) FILTER (WHERE cases.status IN ?) AS completed_cases,
+			COUNT(cases.id) FILTER (WHERE cases.status NOT IN ? AND cases.is_archived = false) AS active_cases,
+			COALESCE(MAX(sr.rating_count), 0) AS rating_count,
+			COALESCE(MAX(sr.average_rating), 0) AS average_rating`,
+			completedCaseStatuses, completedCaseStatuses).
+		Joins("LEFT JOIN cases ON cases.primary_staff_id = users.id AND cases.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) AS sr ON sr.staff_id = users.id", staffRatingsSubquery(db)).
+		Where("users.role <> ? AND users.deleted_at IS NULL", "client").
+		Group("users.id, users.first_name, users.last_name, users.role").
+		Having("COUNT(cases.id) > 0").
+		Order("average_rating DESC, completed_cases DESC").
+		Limit(limit).
+		Scan(&performance)
+
+	for i := range performance {
+		total := performance[i].CompletedCases + performance[i].ActiveCases
+		if total > 0 {
+			performance[i].SuccessRate = float64(performance[i].CompletedCases) / float64(total) * 100
+		}
+	}
+	return performance
+}
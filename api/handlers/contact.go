@@ -72,6 +72,8 @@ func findOrCreateClientUser(db *gorm.DB, name, email, phone string, officeID *ui
 		OfficeID:  officeID,
 		Phone:     phone,
 		IsActive:  true,
+
+		MustChangePassword: true,
 	}
 	if err := db.Create(&user).Error; err != nil {
 		return 0, err
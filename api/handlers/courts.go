@@ -0,0 +1,273 @@
+// api/handlers/courts.go
+// Court names for case autocomplete and court-based reporting. Admins maintain a
+// list of canonical names; free entry is still allowed, but values matching a
+// known court are saved with its exact spelling.
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCourtLength matches the size of cases.court.
+const maxCourtLength = 50
+
+// CourtInput is the payload for creating or updating a maintained court.
+type CourtInput struct {
+	Name     string `json:"name" binding:"required"`
+	IsActive *bool  `json:"isActive"`
+}
+
+// courtSuggestion is one autocomplete entry.
+type courtSuggestion struct {
+	Name           string   `json:"name"`
+	Maintained     bool     `json:"maintained"` // In the admin-maintained list
+	CaseCount      int64    `json:"caseCount"`
+	DocketPatterns []string `json:"docketPatterns"` // Most common docket formats, digits shown as "#"
+}
+
+// cleanCourtName trims and collapses internal whitespace.
+func cleanCourtName(raw string) string {
+	return strings.Join(strings.Fields(raw), " ")
+}
+
+// normalizeCourt returns the canonical spelling of a court: a maintained court first,
+// then the most used existing value, matched case-insensitively. Unknown values are
+// kept as entered (cleaned).
+func normalizeCourt(db *gorm.DB, raw string) string {
+	court := cleanCourtName(raw)
+	if court == "" {
+		return ""
+	}
+
+	var maintained models.Court
+	if err := db.Where("LOWER(name) = LOWER(?) AND is_active = ?", court, true).First(&maintained).Error; err == nil {
+		return maintained.Name
+	}
+
+	var existing []string
+	if err := db.Model(&models.Case{}).
+		Where("LOWER(TRIM(court)) = LOWER(?) AND deleted_at IS NULL", court).
+		Group("court").
+		Order("COUNT(*) DESC").
+		Limit(1).
+		Pluck("court", &existing).Error; err == nil && len(existing) > 0 {
+		return cleanCourtName(existing[0])
+	}
+
+	if runes := []rune(court); len(runes) > maxCourtLength {
+		court = string(runes[:maxCourtLength])
+	}
+	return court
+}
+
+// GetCaseCourts returns court names for autocomplete (?search=): maintained courts plus distinct
+// values used by cases in the caller's scope, with usage counts and docket-number patterns.
+func GetCaseCourts(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("userRole") == "client" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado"})
+			return
+		}
+		search := cleanCourtName(c.Query("search"))
+
+		caseQuery := db.Model(&models.Case{}).Where("court IS NOT NULL AND TRIM(court) <> '' AND deleted_at IS NULL")
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			if officeID, ok := c.Get("officeScopeID"); ok {
+				caseQuery = caseQuery.Where("office_id = ?", officeID)
+			}
+		}
+		if search != "" {
+			caseQuery = caseQuery.Where("court ILIKE ?", "%"+search+"%")
+		}
+
+		var used []struct {
+			Court string
+			Count int64
+		}
+		if err := caseQuery.Session(&gorm.Session{}).Select("TRIM(court) AS court, COUNT(*) AS count").
+			Group("TRIM(court)").
+			Scan(&used).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener juzgados", "message": err.Error()})
+			return
+		}
+
+		var patterns []struct {
+			Court   string
+			Pattern string
+			Count   int64
+		}
+		if err := caseQuery.Session(&gorm.Session{}).
+			Select("TRIM(court) AS court, REGEXP_REPLACE(TRIM(docket_number), '[0-9]', '#', 'g') AS pattern, COUNT(*) AS count").
+			Where("docket_number IS NOT NULL AND TRIM(docket_number) <> ''").
+			Group("TRIM(court), pattern").
+			Order("count DESC").
+			Scan(&patterns).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener juzgados", "message": err.Error()})
+			return
+		}
+
+		maintainedQuery := db.Model(&models.Court{}).Where("is_active = ?", true)
+		if search != "" {
+			maintainedQuery = maintainedQuery.Where("name ILIKE ?", "%"+search+"%")
+		}
+		var maintained []models.Court
+		if err := maintainedQuery.Find(&maintained).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener juzgados", "message": err.Error()})
+			return
+		}
+
+		// Merge by lower-cased name so differently-cased free entries fold into one suggestion
+		byKey := make(map[string]*courtSuggestion)
+		for _, court := range maintained {
+			byKey[strings.ToLower(court.Name)] = &courtSuggestion{Name: court.Name, Maintained: true, DocketPatterns: []string{}}
+		}
+		for _, row := range used {
+			key := strings.ToLower(row.Court)
+			suggestion, ok := byKey[key]
+			if !ok {
+				suggestion = &courtSuggestion{Name: row.Court, DocketPatterns: []string{}}
+				byKey[key] = suggestion
+			}
+			suggestion.CaseCount += row.Count
+		}
+		for _, row := range patterns {
+			if suggestion, ok := byKey[strings.ToLower(row.Court)]; ok && len(suggestion.DocketPatterns) < 3 && !slices.Contains(suggestion.DocketPatterns, row.Pattern) {
+				suggestion.DocketPatterns = append(suggestion.DocketPatterns, row.Pattern)
+			}
+		}
+
+		courts := make([]courtSuggestion, 0, len(byKey))
+		for _, suggestion := range byKey {
+			courts = append(courts, *suggestion)
+		}
+		sort.Slice(courts, func(i, j int) bool {
+			if courts[i].Maintained != courts[j].Maintained {
+				return courts[i].Maintained
+			}
+			if courts[i].CaseCount != courts[j].CaseCount {
+				return courts[i].CaseCount > courts[j].CaseCount
+			}
+			return courts[i].Name < courts[j].Name
+		})
+
+		c.JSON(http.StatusOK, gin.H{"courts": courts})
+	}
+}
+
+// GetCourts lists the maintained courts, including inactive ones.
+func GetCourts(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		courts := make([]models.Court, 0)
+		if err := db.Order("name").Find(&courts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener juzgados", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"courts": courts})
+	}
+}
+
+// CreateCourt adds a court to the maintained list.
+func CreateCourt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input CourtInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		court := models.Court{
+			Name:      cleanCourtName(input.Name),
+			IsActive:  input.IsActive == nil || *input.IsActive,
+			UpdatedBy: extractUserID(c),
+		}
+		if !validCourtName(c, db, court.Name, 0) {
+			return
+		}
+		if err := db.Create(&court).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el juzgado"})
+			return
+		}
+		c.JSON(http.StatusCreated, court)
+	}
+}
+
+// UpdateCourt renames or (de)activates a maintained court. Renaming does not rewrite existing cases.
+func UpdateCourt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var court models.Court
+		if err := db.First(&court, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Juzgado no encontrado"})
+			return
+		}
+
+		var input CourtInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name := cleanCourtName(input.Name)
+		if !validCourtName(c, db, name, court.ID) {
+			return
+		}
+
+		updates := map[string]interface{}{
+			"name":       name,
+			"updated_by": extractUserID(c),
+		}
+		if input.IsActive != nil {
+			updates["is_active"] = *input.IsActive
+		}
+		if err := db.Model(&court).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el juzgado"})
+			return
+		}
+		db.First(&court, court.ID)
+		c.JSON(http.StatusOK, court)
+	}
+}
+
+// DeleteCourt removes a court from the maintained list. Cases keep their court value.
+func DeleteCourt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Delete(&models.Court{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el juzgado"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Juzgado no encontrado"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Juzgado eliminado exitosamente"})
+	}
+}
+
+// validCourtName checks length and case-insensitive uniqueness, writing the error response.
+func validCourtName(c *gin.Context, db *gorm.DB, name string, excludeID uint) bool {
+	if name == "" || len([]rune(name)) > maxCourtLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre del juzgado debe tener entre 1 y 50 caracteres"})
+		return false
+	}
+	var existing int64
+	db.Model(&models.Court{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Ya existe un juzgado con ese nombre"})
+		return false
+	}
+	return true
+}
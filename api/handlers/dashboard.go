@@ -13,6 +13,7 @@ import (
 )
 
 // GetStaffDashboardSummary provides limited metrics for staff roles (lawyers, psychologists, etc.)
+// Today and this month are taken in the ?tz= zone, else the staff member's own or office's zone.
 func GetStaffDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDVal, _ := c.Get("userID")
@@ -21,6 +22,11 @@ func GetStaffDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "User ID not found"})
 			return
 		}
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		now := time.Now().In(loc)
 
 		// Get cases assigned to this staff member
 		var myCases int64
@@ -39,8 +45,8 @@ func GetStaffDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 		db.Model(&models.Appointment{}).Where("case_id IN (SELECT case_id FROM user_case_assignments WHERE user_id = ?) AND status = ?", userIDStr, "pending").Count(&myPendingAppointments)
 
 		// Today's appointments for this staff member
-		todayStart := time.Now().Truncate(24 * time.Hour)
-		todayEnd := todayStart.Add(24 * time.Hour)
+		todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		todayEnd := todayStart.AddDate(0, 0, 1)
 		var myAppointmentsToday int64
 		db.Model(&models.Appointment{}).Where(
 			"staff_id = ? AND start_time >= ? AND start_time < ? AND deleted_at IS NULL",
@@ -55,7 +61,7 @@ func GetStaffDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 		).Count(&myPendingTasks)
 
 		// Completed cases this month
-		currentMonthStart := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.Now().Location())
+		currentMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 		var myCompletedCases int64
 		db.Model(&models.Case{}).Where(
 			"id IN (SELECT case_id FROM user_case_assignments WHERE user_id = ?) AND status IN (?) AND updated_at >= ?",
@@ -76,8 +82,15 @@ func GetStaffDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 
 // GetDashboardSummary provides key metrics for the admin dashboard.
 // Office filter: use query param "officeId" when provided (admin); otherwise use context officeScopeID (office_manager).
+// Today and this month are taken in the ?tz= zone, else the caller's own or office's zone.
 func GetDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		now := time.Now().In(loc)
+
 		var officeFilter uint
 		roleVal, _ := c.Get("userRole")
 		role, _ := roleVal.(string)
@@ -102,6 +115,12 @@ func GetDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 			}
 		}
 
+		cacheKey := analyticsCacheKey("dashboard-summary", officeFilter, loc)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+
 		// Get all required metrics for admin/office manager dashboard
 		var totalCases int64
 		totalCasesQuery := db.Model(&models.Case{}).Where("is_archived = ? AND deleted_at IS NULL", false)
@@ -125,8 +144,7 @@ func GetDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 		completedCasesQuery.Count(&completedCases)
 
 		var casesThisMonth int64
-		now := time.Now()
-		currentMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		currentMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 		casesMonthQuery := db.Model(&models.Case{}).Where("created_at >= ? AND is_archived = ? AND deleted_at IS NULL", currentMonthStart, false)
 		if officeFilter != 0 {
 			casesMonthQuery = casesMonthQuery.Where("office_id = ?", officeFilter)
@@ -155,8 +173,8 @@ func GetDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 		completedApptQuery.Count(&completedAppointments)
 
 		var appointmentsToday int64
-		todayStart := time.Now().Truncate(24 * time.Hour)
-		todayEnd := todayStart.Add(24 * time.Hour)
+		todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		todayEnd := todayStart.AddDate(0, 0, 1)
 		apptTodayQuery := db.Model(&models.Appointment{}).Where("start_time >= ? AND start_time < ? AND deleted_at IS NULL", todayStart, todayEnd)
 		if officeFilter != 0 {
 			apptTodayQuery = apptTodayQuery.Where("office_id = ?", officeFilter)
@@ -205,8 +223,10 @@ func GetDashboardSummary(db *gorm.DB) gin.HandlerFunc {
 			"totalClients":          totalClients,
 			"pendingTasks":          pendingTasks,
 			"offices":               offices,
+			"asOf":                  time.Now(),
 		}
 
+		analyticsCache.set(cacheKey, summary, summary["asOf"].(time.Time))
 		c.JSON(http.StatusOK, summary)
 	}
 }
@@ -0,0 +1,185 @@
+// api/handlers/dashboard_drilldown.go
+// Dashboard drill-downs: the admin dashboard statistics for a single office or a single staff
+// member, computed by the same aggregations as GetDashboardStats.
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dashboardScope narrows the dashboard aggregations to one office or one staff member; the
+// zero value covers the whole system.
+//
+// An office's users are its staff and the clients with a case there. A staff member's cases
+// are those they are primary on or assigned to, their users the clients of those cases and
+// their office the one they belong to.
+type dashboardScope struct {
+	OfficeID uint
+	StaffID  uint
+}
+
+// cases selects the cases in scope.
+func (s dashboardScope) cases(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.Case{})
+	if s.OfficeID != 0 {
+		query = query.Where("office_id = ?", s.OfficeID)
+	}
+	if s.StaffID != 0 {
+		assigned := db.Model(&models.UserCaseAssignment{}).Select("case_id").Where("user_id = ?", s.StaffID)
+		query = query.Where("(primary_staff_id = ? OR id IN (?))", s.StaffID, assigned)
+	}
+	return query
+}
+
+// users selects the users in scope.
+func (s dashboardScope) users(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.User{})
+	if s.OfficeID != 0 {
+		query = query.Where("(office_id = ? OR id IN (?))", s.OfficeID, s.cases(db).Select("client_id"))
+	}
+	if s.StaffID != 0 {
+		query = query.Where("id IN (?)", s.cases(db).Select("client_id"))
+	}
+	return query
+}
+
+// appointments selects the appointments in scope.
+func (s dashboardScope) appointments(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.Appointment{})
+	if s.OfficeID != 0 {
+		query = query.Where("office_id = ?", s.OfficeID)
+	}
+	if s.StaffID != 0 {
+		query = query.Where("staff_id = ?", s.StaffID)
+	}
+	return query
+}
+
+// offices selects the offices in scope.
+func (s dashboardScope) offices(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.Office{})
+	if s.OfficeID != 0 {
+		query = query.Where("id = ?", s.OfficeID)
+	}
+	if s.StaffID != 0 {
+		query = query.Where("id IN (?)", db.Model(&models.User{}).Select("office_id").Where("id = ?", s.StaffID))
+	}
+	return query
+}
+
+// payments selects the payment records of the cases in scope. It is a new session, so the
+// financial metrics can add their own conditions query by query.
+func (s dashboardScope) payments(db *gorm.DB) *gorm.DB {
+	if s == (dashboardScope{}) {
+		return db
+	}
+	return db.Model(&models.PaymentRecord{}).Where("case_id IN (?)", s.cases(db).Select("id")).Session(&gorm.Session{})
+}
+
+// ratings selects the client ratings in scope, as a new session.
+func (s dashboardScope) ratings(db *gorm.DB) *gorm.DB {
+	if s == (dashboardScope{}) {
+		return db
+	}
+	query := db.Model(&models.ClientRating{})
+	if s.OfficeID != 0 {
+		query = query.Where("office_id = ?", s.OfficeID)
+	}
+	if s.StaffID != 0 {
+		query = query.Where("staff_id = ?", s.StaffID)
+	}
+	return query.Session(&gorm.Session{})
+}
+
+// staff narrows a query on users to the staff in scope.
+func (s dashboardScope) staff(query *gorm.DB) *gorm.DB {
+	if s.OfficeID != 0 {
+		query = query.Where("users.office_id = ?", s.OfficeID)
+	}
+	if s.StaffID != 0 {
+		query = query.Where("users.id = ?", s.StaffID)
+	}
+	return query
+}
+
+// GetOfficeDashboardStats returns the dashboard statistics of one office, in the shape of
+// GetDashboardStats. Users without access to every office may only query their own.
+func GetOfficeDashboardStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := strconv.ParseUint(c.Param("officeId"), 10, 32)
+		if err != nil || officeID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de oficina inválido"})
+			return
+		}
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			scope, ok := c.Get("officeScopeID")
+			if !ok || scope.(uint) != uint(officeID) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Solo puede consultar las estadísticas de su oficina"})
+				return
+			}
+		}
+		var office models.Office
+		if err := db.Select("id").First(&office, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		cacheKey := analyticsCacheKey("dashboard-stats", "office", office.ID, loc)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+		stats := computeDashboardStats(db, dashboardScope{OfficeID: office.ID}, time.Now().In(loc))
+		analyticsCache.set(cacheKey, stats, stats.AsOf)
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// GetStaffDashboardStats returns the dashboard statistics of one staff member's cases and
+// appointments, in the shape of GetDashboardStats. Users without access to every office may
+// only query the staff of their own office.
+func GetStaffDashboardStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staffID, err := strconv.ParseUint(c.Param("staffId"), 10, 32)
+		if err != nil || staffID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de personal inválido"})
+			return
+		}
+		var staff models.User
+		if err := db.Select("id", "office_id").Where("id = ? AND role <> ?", staffID, "client").First(&staff).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personal no encontrado"})
+			return
+		}
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			officeID, ok := c.Get("officeScopeID")
+			if !ok || staff.OfficeID == nil || *staff.OfficeID != officeID.(uint) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Solo puede consultar al personal de su oficina"})
+				return
+			}
+		}
+
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		cacheKey := analyticsCacheKey("dashboard-stats", "staff", staff.ID, loc)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+		stats := computeDashboardStats(db, dashboardScope{StaffID: staff.ID}, time.Now().In(loc))
+		analyticsCache.set(cacheKey, stats, stats.AsOf)
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,235 @@
+// api/handlers/dashboard_drilldown_test.go
+// Unit tests for the office and staff dashboard drill-downs: counts over a small fixture match
+// the hand-computed figures, and office managers are kept to their own office.
+package handlers
+
+import (
+	"database/sql/driver"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dashboardFixture is a small organization: staff 10 and 11 work at office 1, staff 12 at
+// office 2.
+var dashboardFixture = struct {
+	appointments []map[string]interface{}
+	cases        []map[string]interface{}
+	assignments  map[int][]int // staff -> cases assigned to them
+	staffOffice  map[int]int
+}{
+	appointments: []map[string]interface{}{
+		{"id": 1, "office_id": 1, "staff_id": 10, "status": "pending"},
+		{"id": 2, "office_id": 1, "staff_id": 10, "status": "completed"},
+		{"id": 3, "office_id": 1, "staff_id": 11, "status": "completed"},
+		{"id": 4, "office_id": 1, "staff_id": 11, "status": "cancelled"},
+		{"id": 5, "office_id": 2, "staff_id": 12, "status": "completed"},
+		{"id": 6, "office_id": 2, "staff_id": 10, "status": "pending"},
+	},
+	cases: []map[string]interface{}{
+		{"id": 1, "office_id": 1, "primary_staff_id": 10, "status": "open", "is_archived": false},
+		{"id": 2, "office_id": 1, "primary_staff_id": 11, "status": "closed", "is_archived": false},
+		{"id": 3, "office_id": 1, "primary_staff_id": 11, "status": "open", "is_archived": false},
+		{"id": 4, "office_id": 1, "primary_staff_id": 10, "status": "open", "is_archived": true},
+		{"id": 5, "office_id": 2, "primary_staff_id": 12, "status": "closed", "is_archived": false},
+		{"id": 6, "office_id": 2, "primary_staff_id": 12, "status": "open", "is_archived": false},
+	},
+	assignments: map[int][]int{10: {3, 5}},
+	staffOffice: map[int]int{10: 1, 11: 1, 12: 2},
+}
+
+var (
+	equalityCondition = regexp.MustCompile(`\b(office_id|staff_id|status|is_archived) = \$(\d+)`)
+	staffCasesClause  = regexp.MustCompile(`primary_staff_id = \$(\d+) OR id IN`)
+)
+
+// countFixture counts the fixture rows matching the equality conditions of a count query.
+// Queries with other row conditions (dates, subqueries on users) are not counted.
+func countFixture(query string, args []driver.Value) (int64, bool) {
+	var rows []map[string]interface{}
+	switch {
+	case strings.HasPrefix(query, `SELECT count(*) FROM "appointments"`):
+		rows = dashboardFixture.appointments
+	case strings.HasPrefix(query, `SELECT count(*) FROM "cases"`):
+		rows = dashboardFixture.cases
+	default:
+		return 0, false
+	}
+	if strings.Contains(query, "start_time") || strings.Contains(query, "created_at") || strings.Contains(query, "due_date") {
+		return 0, false
+	}
+
+	staffCases := -1
+	if match := staffCasesClause.FindStringSubmatch(query); match != nil {
+		staffCases = intArg(args[intArg(match[1])-1])
+		query = strings.Replace(query, match[0], "", 1)
+	}
+	var count int64
+	for _, row := range rows {
+		matches := true
+		for _, condition := range equalityCondition.FindAllStringSubmatch(query, -1) {
+			value := args[intArg(condition[2])-1]
+			if want, ok := value.(bool); ok {
+				matches = matches && row[condition[1]] == want
+			} else if text, ok := value.(string); ok {
+				matches = matches && row[condition[1]] == text
+			} else {
+				matches = matches && row[condition[1]] == intArg(value)
+			}
+		}
+		if staffCases != -1 {
+			assigned := row["primary_staff_id"] == staffCases
+			for _, id := range dashboardFixture.assignments[staffCases] {
+				assigned = assigned || row["id"] == id
+			}
+			matches = matches && assigned
+		}
+		if matches {
+			count++
+		}
+	}
+	return count, true
+}
+
+// dashboardScript answers the dashboard's count queries from dashboardFixture.
+func dashboardScript() *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if count, ok := countFixture(query, args); ok {
+				return countRow(count)
+			}
+			switch {
+			case strings.HasPrefix(query, `SELECT "id","office_id" FROM "users"`):
+				office, ok := dashboardFixture.staffOffice[intArg(args[0])]
+				if !ok {
+					return nil, nil
+				}
+				return []string{"id", "office_id"}, [][]driver.Value{{args[0], int64(office)}}
+			case strings.HasPrefix(query, `SELECT "id" FROM "offices"`):
+				if id := intArg(args[0]); id == 1 || id == 2 {
+					return []string{"id"}, [][]driver.Value{{int64(id)}}
+				}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestDashboardStatsScopedCounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope dashboardScope
+		// Appointments: total, pending, completed, cancelled; cases: total, open, closed
+		want [7]int64
+	}{
+		{"system", dashboardScope{}, [7]int64{6, 2, 3, 1, 5, 3, 2}},
+		{"office 1", dashboardScope{OfficeID: 1}, [7]int64{4, 1, 2, 1, 3, 2, 1}},
+		{"office 2", dashboardScope{OfficeID: 2}, [7]int64{2, 1, 1, 0, 2, 1, 1}},
+		// Staff 10's appointments at both offices, and cases 1, 3 and 5 (4 is archived)
+		{"staff 10", dashboardScope{StaffID: 10}, [7]int64{3, 2, 1, 0, 3, 2, 1}},
+		{"staff 11", dashboardScope{StaffID: 11}, [7]int64{2, 0, 1, 1, 2, 1, 1}},
+	}
+	for _, tt := range tests {
+		stats := computeDashboardStats(scriptedDB(t, dashboardScript()), tt.scope, time.Now())
+		got := [7]int64{
+			stats.TotalAppointments, stats.PendingAppointments, stats.CompletedAppointments, stats.CancelledAppointments,
+			stats.TotalCases, stats.ActiveCases, stats.CompletedCases,
+		}
+		if got != tt.want {
+			t.Errorf("%s: counts = %v, want %v", tt.name, got, tt.want)
+		}
+		wantSuccess := float64(tt.want[2]) / float64(tt.want[0]) * 100
+		wantCompletion := float64(tt.want[6]) / float64(tt.want[4]) * 100
+		if math.Abs(stats.AppointmentSuccessRate-wantSuccess) > 1e-9 || math.Abs(stats.CaseCompletionRate-wantCompletion) > 1e-9 {
+			t.Errorf("%s: rates = %.2f%% and %.2f%%, want %.2f%% and %.2f%%", tt.name,
+				stats.AppointmentSuccessRate, stats.CaseCompletionRate, wantSuccess, wantCompletion)
+		}
+	}
+}
+
+func TestDashboardScopeQueries(t *testing.T) {
+	script := dashboardScript()
+	computeDashboardStats(scriptedDB(t, script), dashboardScope{OfficeID: 1}, time.Now())
+	// Every user, office, payment and rating query is narrowed to the office, and only the
+	// office's staff are ranked
+	scoped := []struct {
+		kind     string
+		selects  func(query string) bool
+		fragment string
+	}{
+		{"staff ranking", func(q string) bool { return strings.Contains(q, "AS user_id") }, "users.office_id = "},
+		{"users", func(q string) bool { return strings.Contains(q, ` FROM "users" WHERE`) }, `(office_id = $1 OR id IN (SELECT "client_id" FROM "cases" WHERE office_id = $2)`},
+		{"offices", func(q string) bool { return strings.Contains(q, ` FROM "offices"`) }, "id = $1"},
+		{"payments", func(q string) bool { return strings.Contains(q, ` FROM "payment_records"`) }, `case_id IN (SELECT "id" FROM "cases" WHERE office_id = $1)`},
+		{"ratings", func(q string) bool { return strings.HasPrefix(q, "SELECT COALESCE(AVG(rating)") }, "office_id = $1"},
+	}
+	for _, kind := range scoped {
+		seen := 0
+		for _, query := range script.ran("SELECT") {
+			if !kind.selects(query) || (kind.kind != "staff ranking" && strings.Contains(query, "AS user_id")) {
+				continue
+			}
+			seen++
+			if !strings.Contains(query, kind.fragment) {
+				t.Errorf("%s query not scoped to the office: %s", kind.kind, query)
+			}
+		}
+		if seen == 0 {
+			t.Errorf("no %s query", kind.kind)
+		}
+	}
+}
+
+// runDashboardDrilldown runs handler for param=id as a user of role, scoped to officeID when not 0.
+func runDashboardDrilldown(t *testing.T, handler func(*gorm.DB) gin.HandlerFunc, param, id, role string, officeID uint) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/manager/dashboard/"+id+"/stats", nil)
+	c.Params = gin.Params{{Key: param, Value: id}}
+	c.Set("userID", "1")
+	c.Set("userRole", role)
+	c.Set("currentUser", models.User{ID: 1, Role: role})
+	if officeID != 0 {
+		c.Set("officeScopeID", officeID)
+	}
+	handler(scriptedDB(t, dashboardScript()))(c)
+	return w.Code
+}
+
+func TestDashboardDrilldownOfficeManagerScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(*gorm.DB) gin.HandlerFunc
+		param   string
+		id      string
+		role    string
+		office  uint
+		want    int
+	}{
+		{"own office", GetOfficeDashboardStats, "officeId", "1", "office_manager", 1, http.StatusOK},
+		{"other office", GetOfficeDashboardStats, "officeId", "2", "office_manager", 1, http.StatusForbidden},
+		{"no office scope", GetOfficeDashboardStats, "officeId", "1", "office_manager", 0, http.StatusForbidden},
+		{"admin, any office", GetOfficeDashboardStats, "officeId", "2", "admin", 0, http.StatusOK},
+		{"unknown office", GetOfficeDashboardStats, "officeId", "9", "admin", 0, http.StatusNotFound},
+		{"bad office id", GetOfficeDashboardStats, "officeId", "x", "admin", 0, http.StatusBadRequest},
+		{"staff of own office", GetStaffDashboardStats, "staffId", "11", "office_manager", 1, http.StatusOK},
+		{"staff of other office", GetStaffDashboardStats, "staffId", "12", "office_manager", 1, http.StatusForbidden},
+		{"admin, any staff", GetStaffDashboardStats, "staffId", "12", "admin", 0, http.StatusOK},
+		{"unknown staff", GetStaffDashboardStats, "staffId", "99", "admin", 0, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if got := runDashboardDrilldown(t, tt.handler, tt.param, tt.id, tt.role, tt.office); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
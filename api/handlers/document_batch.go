@@ -0,0 +1,163 @@
+// api/handlers/document_batch.go
+// Batch document upload: several files posted to a case in one request, such as the scans
+// of an intake. Each file is validated and stored on its own, the documents of all stored
+// files are recorded in one transaction, and the stored files are deleted again when that
+// fails so no orphaned objects are left in storage.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDocumentBatchFiles is the most files one batch upload may hold.
+const maxDocumentBatchFiles = 10
+
+// documentBatchResult is the outcome of one file of a batch upload.
+type documentBatchResult struct {
+	FileName string            `json:"fileName"`
+	Status   string            `json:"status"` // uploaded or rejected
+	Error    string            `json:"error,omitempty"`
+	Document *models.CaseEvent `json:"document,omitempty"`
+
+	fileURL string // Stored file, removed again if the documents cannot be recorded
+}
+
+// storeDocumentBatchFile validates and stores one file of a batch, recording the outcome on
+// result. It returns whether the file reached storage.
+func storeDocumentBatchFile(store storage.FileStorage, file *multipart.FileHeader, caseID string, result *documentBatchResult) (string, bool) {
+	detectedType, _, response := checkDocumentUpload(file)
+	if response != nil {
+		result.Status, result.Error = "rejected", response["error"].(string)
+		return "", false
+	}
+	fileURL, err := store.Upload(file, caseID)
+	if err != nil {
+		log.Printf("ERROR: Batch document upload of %s failed: %v", file.Filename, err)
+		result.Status, result.Error = "rejected", "Error al subir el archivo"
+		if errors.Is(err, storage.ErrStorageUnavailable) {
+			result.Error = "El almacenamiento no responde. Intente de nuevo en unos momentos."
+		}
+		return "", false
+	}
+	result.fileURL = fileURL
+	return detectedType, true
+}
+
+// UploadDocumentBatch uploads every file of the multipart "files" field to a case, with the
+// size and type checks of UploadDocument and its visibility and documentType fields applied
+// to all of them. Files that fail the checks or storage are rejected without affecting the
+// others; their outcome is reported per file in "results". The documents of the stored files
+// are created together, and if that fails the stored files are deleted and the request
+// answers 500.
+func UploadDocumentBatch(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseIDStr := c.Param("id")
+		caseID, err := strconv.ParseUint(caseIDStr, 10, 32)
+		if err != nil || caseID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Caso inválido"})
+			return
+		}
+		userID := extractUserIDUint(c)
+
+		var caseRecord models.Case
+		if err := db.First(&caseRecord, uint(caseID)).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+			return
+		}
+
+		maxBytes := config.DocumentUploadMaxBytes()
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentBatchFiles*maxBytes+multipartOverheadBytes)
+		form, err := c.MultipartForm()
+		if err != nil {
+			if isBodyTooLarge(err) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error":    fmt.Sprintf("La carga excede el máximo de %d archivos de %d MB", maxDocumentBatchFiles, maxBytes>>20),
+					"maxFiles": maxDocumentBatchFiles,
+					"maxBytes": maxBytes,
+				})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requieren archivos en el campo 'files'"})
+			return
+		}
+		files := form.File["files"]
+		if len(files) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requieren archivos en el campo 'files'"})
+			return
+		}
+		if len(files) > maxDocumentBatchFiles {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Se permiten como máximo %d archivos por carga", maxDocumentBatchFiles)})
+			return
+		}
+
+		visibility := c.PostForm("visibility")
+		if visibility == "" {
+			visibility = "internal"
+		}
+		var documentType *string
+		if tag := strings.TrimSpace(c.PostForm("documentType")); tag != "" {
+			documentType = &tag
+		}
+
+		store := storage.GetActiveStorage()
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Almacenamiento no disponible. Contacte al administrador."})
+			return
+		}
+
+		results := make([]documentBatchResult, len(files))
+		stored := make([]*documentBatchResult, 0, len(files))
+		for i, file := range files {
+			results[i].FileName = file.Filename
+			if detectedType, ok := storeDocumentBatchFile(store, file, caseIDStr, &results[i]); ok {
+				event := newDocumentEvent(uint(caseID), userID, file, results[i].fileURL, detectedType, visibility, documentType)
+				results[i].Status, results[i].Document = "uploaded", &event
+				stored = append(stored, &results[i])
+			}
+		}
+
+		if len(stored) > 0 {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				for _, result := range stored {
+					if err := tx.Create(result.Document).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				for _, result := range stored {
+					if deleteErr := store.Delete(result.fileURL); deleteErr != nil {
+						log.Printf("WARN: Failed to clean up %s after batch upload DB error: %v", result.fileURL, deleteErr)
+					}
+					result.Status, result.Error, result.Document = "rejected", "Error al guardar el registro del documento", nil
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "Error al guardar los documentos",
+					"message": err.Error(),
+					"results": results,
+				})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"total":    len(files),
+			"uploaded": len(stored),
+			"rejected": len(files) - len(stored),
+			"results":  results,
+		})
+	}
+}
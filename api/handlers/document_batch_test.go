@@ -0,0 +1,173 @@
+// api/handlers/document_batch_test.go
+// Unit tests for batch document uploads: every file is checked and reported on its own, the
+// documents are recorded in one transaction, and no stored file is left behind when that fails.
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// batchStorage keeps the stored files and fails uploads of the file names in unavailable.
+type batchStorage struct {
+	streamingStorage
+	mutex       sync.Mutex
+	files       map[string]bool
+	unavailable map[string]bool
+}
+
+func (b *batchStorage) Upload(file *multipart.FileHeader, caseID string) (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.unavailable[file.Filename] {
+		return "", fmt.Errorf("upload %s: %w", file.Filename, storage.ErrStorageUnavailable)
+	}
+	key := "cases/" + caseID + "/" + file.Filename
+	b.files[key] = true
+	return key, nil
+}
+
+func (b *batchStorage) Delete(fileURL string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.files, fileURL)
+	return nil
+}
+
+// batchFile is a file of a batch upload, declared as PDF.
+type batchFile struct {
+	name    string
+	content string
+}
+
+// uploadBatch posts the files to UploadDocumentBatch for case 7.
+func uploadBatch(t *testing.T, script *scriptedSQL, files ...batchFile) (int, []documentBatchResult) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="files"; filename="`+file.name+`"`)
+		header.Set("Content-Type", "application/pdf")
+		part, err := form.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(file.content))
+	}
+	form.WriteField("visibility", "client_visible")
+	form.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/cases/7/documents/batch", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set("userID", "3")
+	UploadDocumentBatch(scriptedDB(t, script))(c)
+
+	var response struct {
+		Results []documentBatchResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	return w.Code, response.Results
+}
+
+// batchStatuses returns the status of each result, in order.
+func batchStatuses(results []documentBatchResult) []string {
+	statuses := make([]string, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	return statuses
+}
+
+func TestUploadDocumentBatchReportsEachFile(t *testing.T) {
+	store := &batchStorage{files: map[string]bool{}, unavailable: map[string]bool{"ine.pdf": true}}
+	useStorage(t, store)
+	script := uploadScript()
+
+	status, results := uploadBatch(t, script,
+		batchFile{"acta.pdf", "%PDF-1.4 acta"},
+		batchFile{"virus.pdf", "MZ\x90\x00 not a pdf"},
+		batchFile{"ine.pdf", "%PDF-1.4 ine"},
+		batchFile{"curp.pdf", "%PDF-1.4 curp"},
+	)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if got := strings.Join(batchStatuses(results), ","); got != "uploaded,rejected,rejected,uploaded" {
+		t.Fatalf("statuses = %s", got)
+	}
+	if !strings.Contains(results[1].Error, "no permitido") || results[2].Error == "" {
+		t.Errorf("errors = %q, %q", results[1].Error, results[2].Error)
+	}
+	if doc := results[0].Document; doc == nil || doc.FileUrl != "cases/7/acta.pdf" || doc.Visibility != "client_visible" || doc.FileType != "application/pdf" {
+		t.Errorf("document = %+v", results[0].Document)
+	}
+
+	// Both documents are recorded in one transaction, and only their files were stored
+	if inserts := script.ran(`INSERT INTO "case_events"`); len(inserts) != 2 {
+		t.Errorf("%d documents recorded, want 2", len(inserts))
+	}
+	if len(script.ran("BEGIN")) != 1 || len(script.ran("COMMIT")) != 1 {
+		t.Errorf("statements = %v", script.statements)
+	}
+	if len(store.files) != 2 || !store.files["cases/7/acta.pdf"] || !store.files["cases/7/curp.pdf"] {
+		t.Errorf("stored files = %v", store.files)
+	}
+}
+
+func TestUploadDocumentBatchRemovesStoredFilesWhenRecordingFails(t *testing.T) {
+	store := &batchStorage{files: map[string]bool{}}
+	useStorage(t, store)
+	script := uploadScript()
+	// The first document is recorded, the second fails
+	inserts := 0
+	script.fail = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "case_events"`) {
+			if inserts++; inserts == 2 {
+				return errors.New("connection reset")
+			}
+		}
+		return nil
+	}
+
+	status, results := uploadBatch(t, script,
+		batchFile{"acta.pdf", "%PDF-1.4 acta"},
+		batchFile{"curp.pdf", "%PDF-1.4 curp"},
+		batchFile{"virus.pdf", "MZ\x90\x00 not a pdf"},
+	)
+	if status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", status)
+	}
+	if got := strings.Join(batchStatuses(results), ","); got != "rejected,rejected,rejected" {
+		t.Errorf("statuses = %s", got)
+	}
+	for _, result := range results {
+		if result.Document != nil {
+			t.Errorf("%s reported as recorded", result.FileName)
+		}
+	}
+	if len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+		t.Errorf("statements = %v", script.statements)
+	}
+	if len(store.files) != 0 {
+		t.Errorf("orphaned files left in storage: %v", store.files)
+	}
+}
@@ -0,0 +1,246 @@
+// api/handlers/document_checklists.go
+// Per-category document checklists: which documents a case file must contain
+// and how complete a given case's file is. Checklists are editable by admins.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DocumentChecklistItemInput is the payload for creating or updating a checklist item.
+type DocumentChecklistItemInput struct {
+	Category     string `json:"category" binding:"required"`
+	DocumentType string `json:"documentType" binding:"required"`
+	Label        string `json:"label" binding:"required"`
+	Description  string `json:"description"`
+	Required     *bool  `json:"required"`
+	SortOrder    int    `json:"sortOrder"`
+}
+
+// GetCaseDocumentChecklist compares a case's uploaded documents against its category checklist.
+func GetCaseDocumentChecklist(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseRecord models.Case
+		if err := db.Select("id, category").First(&caseRecord, caseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+			return
+		}
+
+		items := make([]models.DocumentChecklistItem, 0)
+		if err := db.Where("category = ?", caseRecord.Category).Order("sort_order, id").Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la lista de documentos", "message": err.Error()})
+			return
+		}
+
+		documents := make([]models.CaseEvent, 0)
+		if err := db.Select("id, file_name, document_type, created_at").
+			Where("case_id = ? AND event_type = ?", caseRecord.ID, "file_upload").
+			Order("created_at ASC").
+			Find(&documents).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener documentos del caso", "message": err.Error()})
+			return
+		}
+
+		documentsByType := make(map[string][]gin.H)
+		untagged := 0
+		for _, doc := range documents {
+			if doc.DocumentType == nil || *doc.DocumentType == "" {
+				untagged++
+				continue
+			}
+			documentsByType[*doc.DocumentType] = append(documentsByType[*doc.DocumentType], gin.H{
+				"id":        doc.ID,
+				"fileName":  doc.FileName,
+				"createdAt": doc.CreatedAt,
+			})
+		}
+
+		requiredCount, requiredUploaded := 0, 0
+		checklist := make([]gin.H, 0, len(items))
+		missing := make([]string, 0)
+		for _, item := range items {
+			uploaded := documentsByType[item.DocumentType]
+			if uploaded == nil {
+				uploaded = []gin.H{}
+			}
+			if item.Required {
+				requiredCount++
+				if len(uploaded) > 0 {
+					requiredUploaded++
+				} else {
+					missing = append(missing, item.Label)
+				}
+			}
+			checklist = append(checklist, gin.H{
+				"id":           item.ID,
+				"documentType": item.DocumentType,
+				"label":        item.Label,
+				"description":  item.Description,
+				"required":     item.Required,
+				"uploaded":     len(uploaded) > 0,
+				"documents":    uploaded,
+			})
+		}
+
+		completeness := 100.0
+		if requiredCount > 0 {
+			completeness = float64(requiredUploaded) / float64(requiredCount) * 100
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"caseId":            caseRecord.ID,
+			"category":          caseRecord.Category,
+			"items":             checklist,
+			"requiredCount":     requiredCount,
+			"requiredUploaded":  requiredUploaded,
+			"missingRequired":   missing,
+			"untaggedDocuments": untagged,
+			"completeness":      completeness,
+			"isComplete":        requiredUploaded == requiredCount,
+		})
+	}
+}
+
+// GetDocumentChecklists returns checklist items for admin editing, optionally filtered by category.
+func GetDocumentChecklists(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items := make([]models.DocumentChecklistItem, 0)
+		q := db.Order("category, sort_order, id")
+		if category := c.Query("category"); category != "" {
+			q = q.Where("category = ?", category)
+		}
+		if err := q.Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener listas de documentos"})
+			return
+		}
+
+		grouped := make(map[string][]models.DocumentChecklistItem)
+		for _, item := range items {
+			grouped[item.Category] = append(grouped[item.Category], item)
+		}
+		c.JSON(http.StatusOK, gin.H{"checklists": grouped, "items": items})
+	}
+}
+
+// CreateDocumentChecklistItem adds a document requirement to a category checklist.
+func CreateDocumentChecklistItem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input DocumentChecklistItemInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item := models.DocumentChecklistItem{
+			Category:     strings.TrimSpace(input.Category),
+			DocumentType: strings.TrimSpace(input.DocumentType),
+			Label:        strings.TrimSpace(input.Label),
+			Description:  input.Description,
+			Required:     input.Required == nil || *input.Required,
+			SortOrder:    input.SortOrder,
+			UpdatedBy:    extractUserID(c),
+		}
+
+		var existing int64
+		db.Model(&models.DocumentChecklistItem{}).
+			Where("category = ? AND document_type = ?", item.Category, item.DocumentType).
+			Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ya existe ese tipo de documento en la lista de la categoría"})
+			return
+		}
+
+		if err := db.Create(&item).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el documento requerido"})
+			return
+		}
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+// UpdateDocumentChecklistItem edits an existing checklist item.
+func UpdateDocumentChecklistItem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var item models.DocumentChecklistItem
+		if err := db.First(&item, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento requerido no encontrado"})
+			return
+		}
+
+		var input DocumentChecklistItemInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{
+			"category":      strings.TrimSpace(input.Category),
+			"document_type": strings.TrimSpace(input.DocumentType),
+			"label":         strings.TrimSpace(input.Label),
+			"description":   input.Description,
+			"sort_order":    input.SortOrder,
+			"updated_by":    extractUserID(c),
+		}
+		if input.Required != nil {
+			updates["required"] = *input.Required
+		}
+
+		if err := db.Model(&item).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el documento requerido"})
+			return
+		}
+		db.First(&item, item.ID)
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// DeleteDocumentChecklistItem removes a document requirement from a checklist.
+func DeleteDocumentChecklistItem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Delete(&models.DocumentChecklistItem{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el documento requerido"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Documento requerido no encontrado"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Documento requerido eliminado exitosamente"})
+	}
+}
+
+// missingRequiredDocuments returns the labels of required checklist items with no tagged upload on the case.
+func missingRequiredDocuments(db *gorm.DB, caseID uint, category string) ([]string, error) {
+	missing := make([]string, 0)
+	err := db.Model(&models.DocumentChecklistItem{}).
+		Where("category = ? AND required = ?", category, true).
+		Where("NOT EXISTS (SELECT 1 FROM case_events e WHERE e.case_id = ? AND e.event_type = ? AND e.document_type = document_checklist_items.document_type AND e.deleted_at IS NULL)", caseID, "file_upload").
+		Order("sort_order, id").
+		Pluck("label", &missing).Error
+	return missing, err
+}
@@ -0,0 +1,178 @@
+// api/handlers/document_url_test.go
+// Unit tests for presigned document download URLs, using a mocked S3 presigner.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// streamingStorage is a backend without presigned URLs, like local storage after S3 failed.
+type streamingStorage struct{}
+
+func (streamingStorage) Upload(*multipart.FileHeader, string) (string, error)       { return "", nil }
+func (streamingStorage) UploadAvatar(*multipart.FileHeader, string) (string, error) { return "", nil }
+func (streamingStorage) Get(string) (io.ReadCloser, string, error)                  { return nil, "", nil }
+func (streamingStorage) Delete(string) error                                        { return nil }
+func (streamingStorage) HealthCheck() error                                         { return nil }
+
+// presigningStorage mocks the S3 backend's presigner and records what it was asked to sign.
+type presigningStorage struct {
+	streamingStorage
+	err      error
+	signed   []string
+	download bool
+	expiry   time.Duration
+}
+
+func (p *presigningStorage) PresignGetURL(fileURL, fileName, contentType string, download bool, expiry time.Duration) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.signed = append(p.signed, fileURL)
+	p.download, p.expiry = download, expiry
+	return "https://caf-docs.s3.amazonaws.com/cases/7/abc.pdf?X-Amz-Signature=sig", nil
+}
+
+// useStorage makes store the active backend for the rest of the test.
+func useStorage(t *testing.T, store storage.FileStorage) {
+	t.Helper()
+	previous := storage.GetActiveStorage()
+	storage.SetActiveStorage(store)
+	t.Cleanup(func() { storage.SetActiveStorage(previous) })
+}
+
+// documentScript answers the document lookup with one file_upload event of the given
+// visibility, and the client ownership check with owned.
+func documentScript(visibility string, owned bool) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "case_events"`) {
+				return []string{"id", "case_id", "event_type", "visibility", "file_url", "file_name", "file_type", "updated_at"},
+					[][]driver.Value{{int64(9), int64(7), "file_upload", visibility, "https://caf-docs.s3.amazonaws.com/cases/7/abc.pdf", "informe.pdf", "application/pdf", time.Now()}}
+			}
+			if strings.Contains(query, `FROM "cases"`) {
+				count := int64(0)
+				if owned {
+					count = 1
+				}
+				return countRow(count)
+			}
+			return nil, nil
+		},
+	}
+}
+
+// requestDocumentURL calls GetDocumentURL for document 9 as the given role.
+func requestDocumentURL(t *testing.T, db *gorm.DB, role, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/documents/9/url"+query, nil)
+	c.Request.Header.Set("User-Agent", "test-browser")
+	c.Request.RemoteAddr = "203.0.113.5:4321"
+	c.Params = gin.Params{{Key: "eventId", Value: "9"}}
+	c.Set("currentUser", models.User{ID: 3, Role: role})
+	c.Set("userID", "3")
+	c.Set("userRole", role)
+	GetDocumentURL(db)(c)
+	return w
+}
+
+func TestGetDocumentURLReturnsPresignedURL(t *testing.T) {
+	t.Setenv("S3_PRESIGN_EXPIRY_MINUTES", "5")
+	signer := &presigningStorage{}
+	useStorage(t, signer)
+	script := documentScript("shared", true)
+
+	w := requestDocumentURL(t, scriptedDB(t, script), "lawyer", "?mode=download")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		URL              string `json:"url"`
+		ExpiresInSeconds int    `json:"expiresInSeconds"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body.URL, "X-Amz-Signature") || body.ExpiresInSeconds != 300 {
+		t.Errorf("response = %+v, want a signed URL valid for 300s", body)
+	}
+	if len(signer.signed) != 1 || !signer.download || signer.expiry != 5*time.Minute {
+		t.Errorf("signed %v (download=%v, expiry=%v)", signer.signed, signer.download, signer.expiry)
+	}
+
+	audits := script.ran(`INSERT INTO "audit_logs"`)
+	if len(audits) != 1 {
+		t.Fatalf("audit inserts = %d, want 1", len(audits))
+	}
+	for _, column := range []string{`"ip_address"`, `"user_id"`, `"action"`} {
+		if !strings.Contains(audits[0], column) {
+			t.Errorf("audit insert lacks %s: %s", column, audits[0])
+		}
+	}
+}
+
+func TestGetDocumentURLAppliesDocumentAccessChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		owned      bool
+	}{
+		{"internal document", "internal", true},
+		{"another client's case", "shared", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &presigningStorage{}
+			useStorage(t, signer)
+			script := documentScript(tt.visibility, tt.owned)
+
+			w := requestDocumentURL(t, scriptedDB(t, script), "client", "")
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403: %s", w.Code, w.Body.String())
+			}
+			if len(signer.signed) > 0 || len(script.ran(`INSERT INTO "audit_logs"`)) > 0 {
+				t.Errorf("denied request signed %v and wrote an audit entry", signer.signed)
+			}
+		})
+	}
+}
+
+func TestGetDocumentURLWithoutS3FallsBack(t *testing.T) {
+	useStorage(t, streamingStorage{})
+	w := requestDocumentURL(t, scriptedDB(t, documentScript("shared", true)), "lawyer", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"downloadPath":"/api/v1/documents/9"`) {
+		t.Errorf("response does not point to the streaming download: %s", w.Body.String())
+	}
+}
+
+func TestGetDocumentURLReportsSigningFailure(t *testing.T) {
+	useStorage(t, &presigningStorage{err: errors.New("no credentials")})
+	script := documentScript("shared", true)
+	w := requestDocumentURL(t, scriptedDB(t, script), "lawyer", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body.String())
+	}
+	if len(script.ran(`INSERT INTO "audit_logs"`)) > 0 {
+		t.Error("failed signing wrote an audit entry")
+	}
+}
@@ -0,0 +1,129 @@
+// api/handlers/document_validation.go
+// Server-side checks on case document uploads: a size limit and an allow-list of content
+// types detected from the file itself rather than the client's Content-Type header.
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverheadBytes is allowed on top of the document size for the rest of the form.
+const multipartOverheadBytes = 1 << 20
+
+// Office Open XML formats are ZIP archives, told apart by the part they contain.
+var officeDocumentTypes = map[string]string{
+	"word/document.xml":    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xl/workbook.xml":      "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ppt/presentation.xml": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// limitDocumentUploadBody caps the request body at the document size limit plus form
+// overhead, so an oversized upload is cut off while it is read instead of spooled to disk.
+func limitDocumentUploadBody(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.DocumentUploadMaxBytes()+multipartOverheadBytes)
+}
+
+// documentTooLarge writes the 413 for an upload over DOCUMENT_UPLOAD_MAX_MB.
+func documentTooLarge(c *gin.Context, size int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, documentTooLargeResponse(size))
+}
+
+// documentTooLargeResponse is the body of the 413 for a file of size bytes (0 when unknown).
+func documentTooLargeResponse(size int64) gin.H {
+	maxBytes := config.DocumentUploadMaxBytes()
+	response := gin.H{
+		"error":    fmt.Sprintf("El archivo excede el tamaño máximo permitido de %d MB", maxBytes>>20),
+		"maxBytes": maxBytes,
+	}
+	if size > 0 {
+		response["size"] = size
+	}
+	return response
+}
+
+// isBodyTooLarge reports whether reading the form failed because the body limit was hit.
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// detectDocumentType sniffs the content type from the first 512 bytes of the file. ZIP
+// archives are opened to recognise Word, Excel and PowerPoint documents.
+func detectDocumentType(file multipart.File, size int64) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	detected := http.DetectContentType(head[:n])
+	if mediaType, _, err := mime.ParseMediaType(detected); err == nil {
+		detected = mediaType
+	}
+	if detected != "application/zip" {
+		return detected, nil
+	}
+
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return detected, nil
+	}
+	for _, entry := range archive.File {
+		if officeType, ok := officeDocumentTypes[entry.Name]; ok {
+			return officeType, nil
+		}
+	}
+	return detected, nil
+}
+
+// enforceDocumentUpload checks the uploaded file against DOCUMENT_UPLOAD_MAX_MB and
+// DOCUMENT_UPLOAD_ALLOWED_TYPES before it reaches storage. It writes a 413 or 415 and returns
+// false on a violation; otherwise it returns the detected content type.
+func enforceDocumentUpload(c *gin.Context, file *multipart.FileHeader) (string, bool) {
+	detected, status, response := checkDocumentUpload(file)
+	if response != nil {
+		c.JSON(status, response)
+		return "", false
+	}
+	return detected, true
+}
+
+// checkDocumentUpload is enforceDocumentUpload without writing the response: on a violation
+// it returns the status and body of the error, otherwise the detected content type.
+func checkDocumentUpload(file *multipart.FileHeader) (string, int, gin.H) {
+	if file.Size > config.DocumentUploadMaxBytes() {
+		return "", http.StatusRequestEntityTooLarge, documentTooLargeResponse(file.Size)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo", "message": err.Error()}
+	}
+	defer src.Close()
+	detected, err := detectDocumentType(src, file.Size)
+	if err != nil {
+		return "", http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo", "message": err.Error()}
+	}
+
+	allowed := config.DocumentUploadAllowedTypes()
+	for _, contentType := range allowed {
+		if strings.EqualFold(contentType, detected) {
+			return detected, 0, nil
+		}
+	}
+	return "", http.StatusUnsupportedMediaType, gin.H{
+		"error":        fmt.Sprintf("Tipo de archivo no permitido: %s", detected),
+		"detectedType": detected,
+		"declaredType": file.Header.Get("Content-Type"),
+		"allowedTypes": allowed,
+	}
+}
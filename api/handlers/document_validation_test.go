@@ -0,0 +1,163 @@
+// api/handlers/document_validation_test.go
+// Unit tests for document upload validation: size limits and content types sniffed from the
+// file instead of trusted from the client.
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql/driver"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadRecordingStorage records the files that reach storage.
+type uploadRecordingStorage struct {
+	streamingStorage
+	uploaded []string
+}
+
+func (u *uploadRecordingStorage) Upload(file *multipart.FileHeader, caseID string) (string, error) {
+	u.uploaded = append(u.uploaded, file.Filename)
+	return "https://caf-docs.s3.amazonaws.com/cases/" + caseID + "/" + file.Filename, nil
+}
+
+// uploadScript answers the case lookup for case 7.
+func uploadScript() *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "cases"`) {
+				return []string{"id", "office_id"}, [][]driver.Value{{int64(7), int64(2)}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// uploadDocument posts content as filename, declared as contentType, to UploadDocument for case 7.
+func uploadDocument(t *testing.T, script *scriptedSQL, filename, contentType string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.WriteField("visibility", "internal")
+	form.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/cases/7/documents", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	c.Params = gin.Params{{Key: "id", Value: "7"}}
+	c.Set("userID", "3")
+	UploadDocument(scriptedDB(t, script))(c)
+	return w
+}
+
+// docxContent is a minimal Word document: a ZIP holding word/document.xml.
+func docxContent(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml"} {
+		entry, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte("<xml/>"))
+	}
+	archive.Close()
+	return buf.Bytes()
+}
+
+func TestUploadDocumentRejectsSpoofedExtension(t *testing.T) {
+	store := &uploadRecordingStorage{}
+	useStorage(t, store)
+	script := uploadScript()
+
+	// A Windows executable renamed to .pdf and declared as a PDF
+	executable := append([]byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff"), make([]byte, 600)...)
+	w := uploadDocument(t, script, "contrato.pdf", "application/pdf", executable)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"detectedType":"application/octet-stream"`) {
+		t.Errorf("response does not report the sniffed type: %s", w.Body.String())
+	}
+	if len(store.uploaded) > 0 || len(script.ran("INSERT")) > 0 {
+		t.Errorf("rejected file reached storage %v or the database", store.uploaded)
+	}
+}
+
+func TestUploadDocumentRejectsOversizedFile(t *testing.T) {
+	t.Setenv("DOCUMENT_UPLOAD_MAX_MB", "1")
+	pdf := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("0"), 1<<20)...)
+
+	tests := map[string][]byte{
+		// Over the limit but within the form allowance: rejected on the file size
+		"file over limit": pdf,
+		// Far over: the body limit cuts the upload off while it is read
+		"body over limit": append(pdf, bytes.Repeat([]byte("0"), 2<<20)...),
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := &uploadRecordingStorage{}
+			useStorage(t, store)
+			w := uploadDocument(t, uploadScript(), "informe.pdf", "application/pdf", content)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want 413: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), `"maxBytes":1048576`) {
+				t.Errorf("response does not state the limit: %s", w.Body.String())
+			}
+			if len(store.uploaded) > 0 {
+				t.Errorf("oversized file reached storage: %v", store.uploaded)
+			}
+		})
+	}
+}
+
+func TestUploadDocumentStoresDetectedType(t *testing.T) {
+	store := &uploadRecordingStorage{}
+	useStorage(t, store)
+	script := uploadScript()
+
+	// Browsers often declare .docx files as application/octet-stream
+	w := uploadDocument(t, script, "demanda.docx", "application/octet-stream", docxContent(t))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	const docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	if !strings.Contains(w.Body.String(), `"fileType":"`+docx+`"`) || !strings.Contains(w.Body.String(), `"detectedContentType":"`+docx+`"`) {
+		t.Errorf("event does not carry the detected type: %s", w.Body.String())
+	}
+	if len(store.uploaded) != 1 || len(script.ran(`INSERT INTO "case_events"`)) != 1 {
+		t.Errorf("uploaded %v, inserts %v", store.uploaded, script.ran("INSERT"))
+	}
+}
+
+func TestDocumentUploadAllowedTypesFromEnv(t *testing.T) {
+	t.Setenv("DOCUMENT_UPLOAD_ALLOWED_TYPES", "text/plain")
+	store := &uploadRecordingStorage{}
+	useStorage(t, store)
+
+	if w := uploadDocument(t, uploadScript(), "notas.txt", "text/plain", []byte("Notas de la audiencia")); w.Code != http.StatusCreated {
+		t.Fatalf("text/plain allowed: status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := uploadDocument(t, uploadScript(), "informe.pdf", "application/pdf", []byte("%PDF-1.7\n")); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("pdf not allowed: status = %d: %s", w.Code, w.Body.String())
+	}
+}
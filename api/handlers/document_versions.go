@@ -0,0 +1,225 @@
+// api/handlers/document_versions.go
+// Document versions: re-uploading a document keeps the earlier files. Every upload of a
+// document is a numbered version in document_versions, the event's file_url points at the
+// newest, and earlier versions can still be listed and downloaded with the same access rules
+// as the document itself.
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// documentVersionResponse is one entry of the version list.
+type documentVersionResponse struct {
+	Version    int       `json:"version"`
+	FileName   string    `json:"fileName"`
+	FileType   string    `json:"fileType"`
+	UploadedBy *uint     `json:"uploadedBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Current    bool      `json:"current"`
+}
+
+// originalDocumentVersion describes the file a document was created with, before any
+// re-upload recorded versions for it.
+func originalDocumentVersion(event models.CaseEvent) models.DocumentVersion {
+	uploadedBy := event.UserID
+	return models.DocumentVersion{
+		DocumentEventID: event.ID,
+		Version:         1,
+		S3Key:           event.FileUrl,
+		FileName:        event.FileName,
+		FileType:        event.FileType,
+		UploadedBy:      &uploadedBy,
+		CreatedAt:       event.CreatedAt,
+	}
+}
+
+// documentVersions returns the document's versions, oldest first. A document never
+// re-uploaded has a single version, its original file.
+func documentVersions(db *gorm.DB, event models.CaseEvent) ([]models.DocumentVersion, error) {
+	versions := make([]models.DocumentVersion, 0)
+	if err := db.Where("document_event_id = ?", event.ID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		versions = append(versions, originalDocumentVersion(event))
+	}
+	return versions, nil
+}
+
+// replaceDocumentFile uploads the request's "file" as the newest version of the document
+// and points the event at it. The previous file stays in storage as an earlier version.
+// Optional form fields fileName and visibility update the event as well.
+func replaceDocumentFile(c *gin.Context, db *gorm.DB, event models.CaseEvent, userID uint) {
+	limitDocumentUploadBody(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		if isBodyTooLarge(err) {
+			documentTooLarge(c, 0)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	detectedType, ok := enforceDocumentUpload(c, file)
+	if !ok {
+		return
+	}
+
+	store := storage.GetActiveStorage()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Almacenamiento no disponible. Contacte al administrador."})
+		return
+	}
+	fileURL, ok := storeDocumentFile(c, store, file, strconv.FormatUint(uint64(event.CaseID), 10))
+	if !ok {
+		return
+	}
+
+	fileName := strings.TrimSpace(c.PostForm("fileName"))
+	if fileName == "" {
+		fileName = file.Filename
+	}
+	var version models.DocumentVersion
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Lock the document so concurrent re-uploads get consecutive version numbers
+		var current models.CaseEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, event.ID).Error; err != nil {
+			return err
+		}
+		var latest int
+		if err := tx.Model(&models.DocumentVersion{}).Where("document_event_id = ?", current.ID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		if latest == 0 {
+			original := originalDocumentVersion(current)
+			if err := tx.Create(&original).Error; err != nil {
+				return err
+			}
+			latest = 1
+		}
+
+		version = models.DocumentVersion{
+			DocumentEventID: current.ID,
+			Version:         latest + 1,
+			S3Key:           fileURL,
+			FileName:        fileName,
+			FileType:        detectedType,
+			UploadedBy:      &userID,
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"file_url": fileURL, "file_name": fileName, "file_type": detectedType}
+		if visibility := c.PostForm("visibility"); visibility != "" {
+			updates["visibility"] = visibility
+		}
+		return tx.Model(&current).Updates(updates).Error
+	})
+	if err != nil {
+		if deleteErr := store.Delete(fileURL); deleteErr != nil {
+			log.Printf("WARN: Failed to clean up file after DB error: %v", deleteErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la nueva versión del documento"})
+		return
+	}
+
+	db.Preload("User").First(&event, event.ID)
+	invalidateCache(strconv.FormatUint(uint64(event.CaseID), 10))
+	c.JSON(http.StatusOK, gin.H{"data": event, "version": version.Version})
+}
+
+// GetDocumentVersions lists every version of a document, newest first.
+func GetDocumentVersions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, ok := loadAccessibleDocument(c, db)
+		if !ok {
+			return
+		}
+		versions, err := documentVersions(db, event)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las versiones del documento", "message": err.Error()})
+			return
+		}
+
+		data := make([]documentVersionResponse, 0, len(versions))
+		for i := len(versions) - 1; i >= 0; i-- {
+			version := versions[i]
+			data = append(data, documentVersionResponse{
+				Version:    version.Version,
+				FileName:   version.FileName,
+				FileType:   version.FileType,
+				UploadedBy: version.UploadedBy,
+				CreatedAt:  version.CreatedAt,
+				Current:    version.S3Key == event.FileUrl,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"documentId": event.ID, "versions": data, "total": len(data)})
+	}
+}
+
+// GetDocumentVersion streams one version of a document, like GetDocument does for the
+// current one.
+func GetDocumentVersion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, ok := loadAccessibleDocument(c, db)
+		if !ok {
+			return
+		}
+		number, err := strconv.Atoi(c.Param("version"))
+		if err != nil || number <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Versión inválida"})
+			return
+		}
+
+		versions, err := documentVersions(db, event)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la versión del documento", "message": err.Error()})
+			return
+		}
+		for _, version := range versions {
+			if version.Version == number {
+				streamDocumentFile(c, version.S3Key, version.FileName, version.FileType, fmt.Sprintf("\"%d-v%d\"", event.ID, version.Version))
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Versión no encontrada"})
+	}
+}
+
+// deleteDocumentVersions removes a deleted document's earlier versions from storage and the
+// database. The current file is deleted by the caller.
+func deleteDocumentVersions(db *gorm.DB, store storage.FileStorage, event models.CaseEvent) {
+	var versions []models.DocumentVersion
+	if err := db.Where("document_event_id = ?", event.ID).Find(&versions).Error; err != nil {
+		log.Printf("WARN: Failed to load versions of document %d: %v", event.ID, err)
+		return
+	}
+	if len(versions) == 0 {
+		return
+	}
+	for _, version := range versions {
+		if version.S3Key == event.FileUrl || store == nil {
+			continue
+		}
+		if err := store.Delete(version.S3Key); err != nil {
+			log.Printf("WARN: Failed to delete version %d of document %d from storage: %v", version.Version, event.ID, err)
+		}
+	}
+	if err := db.Where("document_event_id = ?", event.ID).Delete(&models.DocumentVersion{}).Error; err != nil {
+		log.Printf("WARN: Failed to delete versions of document %d: %v", event.ID, err)
+	}
+}
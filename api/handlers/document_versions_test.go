@@ -0,0 +1,246 @@
+// api/handlers/document_versions_test.go
+// Unit tests for document versioning: re-uploads keep earlier files retrievable, and versions
+// follow the document's access rules.
+package handlers
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// memoryStorage keeps uploaded files in memory, keyed by the returned file URL.
+type memoryStorage struct {
+	streamingStorage
+	mutex sync.Mutex
+	files map[string]string
+}
+
+func (m *memoryStorage) Upload(file *multipart.FileHeader, caseID string) (string, error) {
+	opened, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer opened.Close()
+	content, err := io.ReadAll(opened)
+	if err != nil {
+		return "", err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := "cases/" + caseID + "/" + file.Filename
+	m.files[key] = string(content)
+	return key, nil
+}
+
+func (m *memoryStorage) Get(fileURL string) (io.ReadCloser, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	content, ok := m.files[fileURL]
+	if !ok {
+		return nil, "", fmt.Errorf("%s not found", fileURL)
+	}
+	return io.NopCloser(strings.NewReader(content)), "application/octet-stream", nil
+}
+
+// versionedDocument is the state behind its script: document 9 of case 7, uploaded by user
+// 3, and its recorded versions.
+type versionedDocument struct {
+	mutex    sync.Mutex
+	event    map[string]driver.Value
+	versions []map[string]driver.Value
+}
+
+var versionColumns = []string{"id", "document_event_id", "version", "s3_key", "file_name", "file_type", "uploaded_by", "created_at"}
+
+func newVersionedDocument(visibility string) *versionedDocument {
+	return &versionedDocument{
+		event: map[string]driver.Value{
+			"id": int64(9), "case_id": int64(7), "user_id": int64(3), "event_type": "file_upload",
+			"visibility": visibility, "file_url": "cases/7/contrato.pdf", "file_name": "contrato.pdf",
+			"file_type": "application/pdf", "created_at": time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC), "updated_at": time.Now(),
+		},
+	}
+}
+
+// script answers case_events and document_versions queries from the state and applies the
+// INSERTs and UPDATEs run against them.
+func (d *versionedDocument) script() *scriptedSQL {
+	return &scriptedSQL{
+		observe: func(query string, args []driver.Value) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			switch {
+			case strings.HasPrefix(query, `INSERT INTO "document_versions"`):
+				row := statementValues(query, args)
+				row["id"] = int64(len(d.versions) + 1)
+				d.versions = append(d.versions, row)
+			case strings.HasPrefix(query, `UPDATE "case_events"`):
+				for column, value := range statementValues(query, args) {
+					d.event[column] = value
+				}
+			}
+		},
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			switch {
+			case strings.Contains(query, `FROM "case_events"`):
+				columns := make([]string, 0, len(d.event))
+				row := make([]driver.Value, 0, len(d.event))
+				for column, value := range d.event {
+					columns = append(columns, column)
+					row = append(row, value)
+				}
+				return columns, [][]driver.Value{row}
+			case strings.Contains(query, "MAX(version)"):
+				return []string{"max"}, [][]driver.Value{{int64(len(d.versions))}}
+			case strings.Contains(query, `FROM "document_versions"`):
+				rows := make([][]driver.Value, 0, len(d.versions))
+				for _, version := range d.versions {
+					row := make([]driver.Value, 0, len(versionColumns))
+					for _, column := range versionColumns {
+						row = append(row, version[column])
+					}
+					rows = append(rows, row)
+				}
+				return versionColumns, rows
+			}
+			return nil, nil
+		},
+	}
+}
+
+// documentRequest runs handler for document 9 as user 3 with the given role.
+func documentRequest(t *testing.T, handler gin.HandlerFunc, role, method, path string, body io.Reader, contentType string, params gin.Params) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, path, body)
+	c.Request.Header.Set("User-Agent", "test-browser")
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	c.Params = append(gin.Params{{Key: "eventId", Value: "9"}}, params...)
+	c.Set("currentUser", models.User{ID: 3, Role: role})
+	c.Set("userID", "3")
+	c.Set("userRole", role)
+	handler(c)
+	return w
+}
+
+// reuploadDocument sends content as a new version of document 9.
+func reuploadDocument(t *testing.T, db *gorm.DB, filename, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", "application/pdf")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+	return documentRequest(t, UpdateDocument(db), "lawyer", http.MethodPut, "/api/v1/cases/documents/9", &body, form.FormDataContentType(), nil)
+}
+
+func TestDocumentVersionsRetrievableAfterTwoUpdates(t *testing.T) {
+	store := &memoryStorage{files: map[string]string{"cases/7/contrato.pdf": "%PDF-1.4 original"}}
+	useStorage(t, store)
+	document := newVersionedDocument("internal")
+	db := scriptedDB(t, document.script())
+
+	for i, upload := range []struct{ name, content string }{
+		{"contrato-firmado.pdf", "%PDF-1.4 firmado"},
+		{"contrato-final.pdf", "%PDF-1.4 final"},
+	} {
+		w := reuploadDocument(t, db, upload.name, upload.content)
+		if w.Code != http.StatusOK {
+			t.Fatalf("update %d: status = %d: %s", i+1, w.Code, w.Body.String())
+		}
+		var body struct {
+			Version int `json:"version"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Version != i+2 {
+			t.Errorf("update %d created version %d, want %d", i+1, body.Version, i+2)
+		}
+	}
+	if document.event["file_url"] != "cases/7/contrato-final.pdf" {
+		t.Errorf("current file = %v, want the newest upload", document.event["file_url"])
+	}
+
+	w := documentRequest(t, GetDocumentVersions(db), "lawyer", http.MethodGet, "/api/v1/documents/9/versions", nil, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("versions: status = %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Versions []documentVersionResponse `json:"versions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Versions) != 3 || list.Versions[0].Version != 3 || !list.Versions[0].Current || list.Versions[1].Current || list.Versions[2].Current {
+		t.Fatalf("versions = %+v, want 3, 2, 1 with only 3 current", list.Versions)
+	}
+	if list.Versions[2].FileName != "contrato.pdf" || list.Versions[2].UploadedBy == nil || *list.Versions[2].UploadedBy != 3 {
+		t.Errorf("original version = %+v", list.Versions[2])
+	}
+
+	for version, want := range map[string]string{"1": "%PDF-1.4 original", "2": "%PDF-1.4 firmado", "3": "%PDF-1.4 final"} {
+		w := documentRequest(t, GetDocumentVersion(db), "lawyer", http.MethodGet, "/api/v1/documents/9/versions/"+version+"?mode=download", nil, "", gin.Params{{Key: "version", Value: version}})
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("version %s: status %d, body %q, want %q", version, w.Code, w.Body.String(), want)
+		}
+	}
+	if len(store.files) != 3 {
+		t.Errorf("stored files = %v, earlier versions were removed", store.files)
+	}
+}
+
+func TestDocumentVersionsOfNeverUpdatedDocument(t *testing.T) {
+	useStorage(t, &memoryStorage{files: map[string]string{"cases/7/contrato.pdf": "%PDF-1.4 original"}})
+	db := scriptedDB(t, newVersionedDocument("client_visible").script())
+
+	w := documentRequest(t, GetDocumentVersions(db), "lawyer", http.MethodGet, "/api/v1/documents/9/versions", nil, "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"total":1`) || !strings.Contains(w.Body.String(), `"current":true`) {
+		t.Errorf("versions: status %d, body %s", w.Code, w.Body.String())
+	}
+	w = documentRequest(t, GetDocumentVersion(db), "lawyer", http.MethodGet, "/api/v1/documents/9/versions/1", nil, "", gin.Params{{Key: "version", Value: "1"}})
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.4 original" {
+		t.Errorf("version 1: status %d, body %q", w.Code, w.Body.String())
+	}
+	w = documentRequest(t, GetDocumentVersion(db), "lawyer", http.MethodGet, "/api/v1/documents/9/versions/2", nil, "", gin.Params{{Key: "version", Value: "2"}})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing version: status %d", w.Code)
+	}
+}
+
+func TestDocumentVersionsEnforceDocumentAccess(t *testing.T) {
+	useStorage(t, &memoryStorage{files: map[string]string{"cases/7/contrato.pdf": "%PDF-1.4 original"}})
+	db := scriptedDB(t, newVersionedDocument("internal").script())
+
+	for _, handler := range []gin.HandlerFunc{GetDocumentVersions(db), GetDocumentVersion(db)} {
+		w := documentRequest(t, handler, "client", http.MethodGet, "/api/v1/client/documents/9/versions/1", nil, "", gin.Params{{Key: "version", Value: "1"}})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("client reading an internal document's versions: status %d", w.Code)
+		}
+	}
+}
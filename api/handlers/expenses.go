@@ -0,0 +1,176 @@
+// api/handlers/expenses.go
+// Operating expenses recorded by admins. Financial metrics report them per period next to the
+// paid revenue from payment_records (see computeFinancialPeriod).
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExpenseInput is the payload for recording an expense. IncurredAt is YYYY-MM-DD or RFC3339;
+// Currency defaults to STRIPE_CURRENCY (MXN).
+type ExpenseInput struct {
+	OfficeID    *uint  `json:"officeId"`
+	CaseID      *uint  `json:"caseId"`
+	Category    string `json:"category" binding:"required"`
+	Description string `json:"description"`
+	AmountCents int64  `json:"amountCents" binding:"required,gt=0"`
+	Currency    string `json:"currency"`
+	IncurredAt  string `json:"incurredAt" binding:"required"`
+}
+
+// GetExpenses lists expenses, latest first. ?from= and ?to= (YYYY-MM-DD, both inclusive, or
+// RFC3339) bound incurredAt; ?officeId= and ?category= filter. The totals cover every match.
+func GetExpenses(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := parseCasePagination(c)
+		query := db.Model(&models.Expense{})
+		if value := c.Query("from"); value != "" {
+			from, _, err := parseExportDate(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inicial inválida", "message": err.Error()})
+				return
+			}
+			query = query.Where("incurred_at >= ?", from)
+		}
+		if value := c.Query("to"); value != "" {
+			to, dateOnly, err := parseExportDate(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha final inválida", "message": err.Error()})
+				return
+			}
+			if dateOnly {
+				query = query.Where("incurred_at < ?", to.AddDate(0, 0, 1))
+			} else {
+				query = query.Where("incurred_at <= ?", to)
+			}
+		}
+		if officeID := c.Query("officeId"); officeID != "" {
+			query = query.Where("office_id = ?", officeID)
+		}
+		if category := strings.TrimSpace(c.Query("category")); category != "" {
+			query = query.Where("category = ?", category)
+		}
+
+		var totals struct {
+			Count int64
+			Total int64
+		}
+		if err := query.Session(&gorm.Session{}).Select("COUNT(*) AS count, COALESCE(SUM(amount_cents), 0) AS total").Scan(&totals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los gastos", "message": err.Error()})
+			return
+		}
+		expenses := make([]models.Expense, 0, pageSize)
+		if err := query.Session(&gorm.Session{}).Order("incurred_at DESC, id DESC").
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&expenses).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los gastos", "message": err.Error()})
+			return
+		}
+
+		totalPages := (totals.Count + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data":        expenses,
+			"totalAmount": *centsAmount(totals.Total),
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      totals.Count,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
+
+// CreateExpense records an expense.
+func CreateExpense(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input ExpenseInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de gasto inválidos", "message": err.Error()})
+			return
+		}
+		category := strings.TrimSpace(input.Category)
+		if category == "" || len([]rune(category)) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría debe tener entre 1 y 50 caracteres"})
+			return
+		}
+		incurredAt, _, err := parseExportDate(strings.TrimSpace(input.IncurredAt))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha del gasto inválida", "message": err.Error()})
+			return
+		}
+		if incurredAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha del gasto no puede ser futura"})
+			return
+		}
+		currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+		if currency == "" {
+			currency = defaultDashboardCurrency()
+		}
+
+		expense := models.Expense{
+			OfficeID:    input.OfficeID,
+			CaseID:      input.CaseID,
+			Category:    category,
+			Description: strings.TrimSpace(input.Description),
+			AmountCents: input.AmountCents,
+			Currency:    currency,
+			IncurredAt:  incurredAt,
+			CreatedBy:   extractUserID(c),
+		}
+		if err := db.Create(&expense).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al registrar el gasto", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, expense)
+	}
+}
+
+// DeleteExpense soft-deletes an expense, removing it from financial metrics.
+func DeleteExpense(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Delete(&models.Expense{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el gasto", "message": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Gasto no encontrado"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Gasto eliminado exitosamente"})
+	}
+}
+
+// sumExpenseCents sums the expenses incurred in [from, to); nil bounds are open. count tells an
+// empty period from no expenses at all.
+func sumExpenseCents(db *gorm.DB, from *time.Time, to *time.Time) (total int64, count int64) {
+	query := db.Model(&models.Expense{}).Select("COALESCE(SUM(amount_cents), 0) AS total, COUNT(*) AS count")
+	if from != nil {
+		query = query.Where("incurred_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("incurred_at < ?", *to)
+	}
+	var row struct {
+		Total int64
+		Count int64
+	}
+	if err := query.Scan(&row).Error; err != nil {
+		return 0, 0
+	}
+	return row.Total, row.Count
+}
@@ -0,0 +1,130 @@
+// api/handlers/export_stream.go
+// Streaming exports for very large datasets: records are written as JSON Lines while the
+// database is read in batches, so memory stays flat however many rows match.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportStreamBatchSize is how many records one query of a streamed export loads, as in the
+// reports' CSV exports.
+const exportStreamBatchSize = 1000
+
+// exportStreamTypes are the data types a streamed export supports.
+var exportStreamTypes = []string{"cases", "appointments"}
+
+// StreamExport writes ?type=cases|appointments as newline-delimited JSON, one record per line.
+// It takes ExportData's filters as query parameters (filters[column]=value, dateField, dateFrom,
+// dateTo); users not allowed every office only get their office's records.
+func StreamExport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dataType := c.Query("type")
+		if dataType != "cases" && dataType != "appointments" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data type", "allowed": exportStreamTypes})
+			return
+		}
+		input := ExportDataInput{
+			Type:      dataType,
+			DateField: c.Query("dateField"),
+			DateFrom:  c.Query("dateFrom"),
+			DateTo:    c.Query("dateTo"),
+		}
+		if filters := c.QueryMap("filters"); len(filters) > 0 {
+			input.Filters = make(map[string]interface{}, len(filters))
+			for key, value := range filters {
+				input.Filters[key] = value
+			}
+		}
+		query, ok := exportQuery(c, db, dataType, input)
+		if !ok {
+			return
+		}
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			scope, _ := c.Get("officeScopeID")
+			officeID, _ := scope.(uint)
+			if officeID == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Office scope required"})
+				return
+			}
+			query = query.Where("office_id = ?", officeID)
+		}
+
+		// The first batch is loaded before the response starts, so a failing query still gets a 500
+		batch, lastID, err := exportStreamBatch(query, dataType, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data", "message": err.Error()})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_export_%s.jsonl", dataType, time.Now().Format("2006-01-02")))
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		rows := 0
+		c.Stream(func(w io.Writer) bool {
+			encoder := json.NewEncoder(w)
+			for _, record := range batch {
+				if err := encoder.Encode(record); err != nil {
+					log.Printf("Streamed %s export stopped after %d rows: %v", dataType, rows, err)
+					return false
+				}
+				rows++
+			}
+			if len(batch) < exportStreamBatchSize {
+				return false
+			}
+			if batch, lastID, err = exportStreamBatch(query, dataType, lastID); err != nil {
+				log.Printf("Streamed %s export stopped after %d rows: %v", dataType, rows, err)
+				return false
+			}
+			return len(batch) > 0
+		})
+
+		recordDataAccessAuditLog(db, c, dataType[:len(dataType)-1], 0, "export", "jsonl_stream", map[string]interface{}{
+			"filters":  input.Filters,
+			"dateFrom": input.DateFrom,
+			"dateTo":   input.DateTo,
+			"rows":     rows,
+		})
+	}
+}
+
+// exportStreamBatch loads the next batch of query's records with an id above afterID, in id
+// order, and returns them with the last id loaded.
+func exportStreamBatch(query *gorm.DB, dataType string, afterID uint) ([]interface{}, uint, error) {
+	page := query.Session(&gorm.Session{}).Where("id > ?", afterID).Order("id").Limit(exportStreamBatchSize)
+	var records []interface{}
+	switch dataType {
+	case "cases":
+		var batch []models.Case
+		if err := page.Preload("Client").Find(&batch).Error; err != nil {
+			return nil, afterID, err
+		}
+		records = make([]interface{}, 0, len(batch))
+		for _, caseItem := range batch {
+			records = append(records, caseItem)
+			afterID = caseItem.ID
+		}
+	default:
+		var batch []models.Appointment
+		if err := page.Preload("Staff").Find(&batch).Error; err != nil {
+			return nil, afterID, err
+		}
+		records = make([]interface{}, 0, len(batch))
+		for _, appointment := range batch {
+			records = append(records, appointment)
+			afterID = appointment.ID
+		}
+	}
+	return records, afterID, nil
+}
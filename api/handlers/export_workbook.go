@@ -0,0 +1,290 @@
+// api/handlers/export_workbook.go
+// Excel (.xlsx) variant of the admin data export: one workbook with a sheet per
+// resource, written row by row so large exports stay out of memory.
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// xlsxContentType is the MIME type of an .xlsx workbook.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// exportBatchSize is how many records are loaded per query while writing a sheet.
+const exportBatchSize = 500
+
+// Export column headers, shared by the CSV and workbook exports
+var (
+	usersExportHeader        = []string{"ID", "First Name", "Last Name", "Email", "Role", "Created At"}
+	casesExportHeader        = []string{"ID", "Title", "Category", "Status", "Client", "Created At"}
+	appointmentsExportHeader = []string{"ID", "Title", "Case ID", "Staff", "Department", "Status", "Start Time", "End Time", "Created At"}
+	officesExportHeader      = []string{"ID", "Name", "Code", "Address", "Created At"}
+)
+
+// exportSheet is one worksheet of the export workbook. Rows calls emit once per data row,
+// in order; values keep their Go types (ints, time.Time) so cells are typed.
+type exportSheet struct {
+	Name   string
+	Header []string
+	Rows   func(emit func(row []interface{}) error) error
+}
+
+// exportWorkbookTypes are the workbook sheets in order, keyed by data type.
+var exportWorkbookTypes = []string{"users", "cases", "appointments", "offices"}
+
+func userExportRow(user models.User) []interface{} {
+	return []interface{}{user.ID, user.FirstName, user.LastName, user.Email, user.Role, user.CreatedAt}
+}
+
+func caseExportRow(caseItem models.Case) []interface{} {
+	clientName := ""
+	if caseItem.Client != nil {
+		clientName = caseItem.Client.FirstName + " " + caseItem.Client.LastName
+	}
+	return []interface{}{caseItem.ID, caseItem.Title, caseItem.Category, caseItem.Status, clientName, caseItem.CreatedAt}
+}
+
+func appointmentExportRow(appointment models.Appointment) []interface{} {
+	staffName := ""
+	if appointment.Staff.ID != 0 {
+		staffName = appointment.Staff.FirstName + " " + appointment.Staff.LastName
+	}
+	return []interface{}{
+		appointment.ID, appointment.Title, appointment.CaseID, staffName, appointment.Department,
+		string(appointment.Status), appointment.StartTime, appointment.EndTime, appointment.CreatedAt,
+	}
+}
+
+func officeExportRow(office models.Office) []interface{} {
+	return []interface{}{office.ID, office.Name, office.Code, office.Address, office.CreatedAt}
+}
+
+// exportSheetFor returns the worksheet for dataType, reading query in batches.
+func exportSheetFor(dataType string, query *gorm.DB) exportSheet {
+	switch dataType {
+	case "users":
+		return exportSheet{Name: "Users", Header: usersExportHeader, Rows: func(emit func([]interface{}) error) error {
+			var batch []models.User
+			return exportBatches(query, &batch, func() error {
+				for _, user := range batch {
+					if err := emit(userExportRow(user)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}}
+	case "cases":
+		return exportSheet{Name: "Cases", Header: casesExportHeader, Rows: func(emit func([]interface{}) error) error {
+			var batch []models.Case
+			return exportBatches(query.Preload("Client"), &batch, func() error {
+				for _, caseItem := range batch {
+					if err := emit(caseExportRow(caseItem)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}}
+	case "appointments":
+		return exportSheet{Name: "Appointments", Header: appointmentsExportHeader, Rows: func(emit func([]interface{}) error) error {
+			var batch []models.Appointment
+			return exportBatches(query.Preload("Staff"), &batch, func() error {
+				for _, appointment := range batch {
+					if err := emit(appointmentExportRow(appointment)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}}
+	default:
+		return exportSheet{Name: "Offices", Header: officesExportHeader, Rows: func(emit func([]interface{}) error) error {
+			var batch []models.Office
+			return exportBatches(query, &batch, func() error {
+				for _, office := range batch {
+					if err := emit(officeExportRow(office)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}}
+	}
+}
+
+// exportBatches loads query into dest exportBatchSize records at a time, calling fn after each batch.
+func exportBatches(query *gorm.DB, dest interface{}, fn func() error) error {
+	return query.FindInBatches(dest, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		return fn()
+	}).Error
+}
+
+// writeExportWorkbook writes sheets as an .xlsx workbook to w. Each sheet starts with its
+// header row; time values get a date-time format, numbers stay numeric.
+func writeExportWorkbook(w io.Writer, sheets []exportSheet) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return err
+	}
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	defaultSheet := f.GetSheetName(0)
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName(defaultSheet, sheet.Name); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(sheet.Name); err != nil {
+			return err
+		}
+
+		stream, err := f.NewStreamWriter(sheet.Name)
+		if err != nil {
+			return err
+		}
+		header := make([]interface{}, len(sheet.Header))
+		for j, title := range sheet.Header {
+			header[j] = title
+		}
+		if err := stream.SetRow("A1", header, excelize.RowOpts{StyleID: headerStyle}); err != nil {
+			return err
+		}
+
+		rowNumber := 1
+		err = sheet.Rows(func(row []interface{}) error {
+			rowNumber++
+			for j, value := range row {
+				if t, ok := value.(time.Time); ok {
+					row[j] = excelize.Cell{StyleID: dateStyle, Value: t}
+				}
+			}
+			cell, err := excelize.CoordinatesToCellName(1, rowNumber)
+			if err != nil {
+				return err
+			}
+			return stream.SetRow(cell, row)
+		})
+		if err != nil {
+			return fmt.Errorf("%s sheet: %w", sheet.Name, err)
+		}
+		if err := stream.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// exportWorkbookQueries builds the filtered query for every workbook sheet. Each filter applies
+// to the sheets whose allowlist has it and must be known to at least one; the date range uses
+// dateField on sheets that have it and created_at elsewhere. Writes a 400 on invalid input.
+func exportWorkbookQueries(c *gin.Context, db *gorm.DB, input ExportDataInput) (map[string]*gorm.DB, bool) {
+	unknown := make([]string, 0)
+	for key := range input.Filters {
+		known := false
+		for _, dataType := range exportWorkbookTypes {
+			known = known || isColumnFilter(dataType, key)
+		}
+		if !known {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		allowed := make(map[string][]string, len(exportWorkbookTypes))
+		for _, dataType := range exportWorkbookTypes {
+			allowed[dataType] = columnFilters(dataType)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown filter fields", "fields": unknown, "allowed": allowed})
+		return nil, false
+	}
+
+	if input.DateField != "" {
+		known := false
+		for _, dataType := range exportWorkbookTypes {
+			known = known || isDateField(dataType, input.DateField)
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown date field", "fields": []string{input.DateField}})
+			return nil, false
+		}
+	}
+
+	queries := make(map[string]*gorm.DB, len(exportWorkbookTypes))
+	for _, dataType := range exportWorkbookTypes {
+		sheetInput := input
+		sheetInput.Filters = map[string]interface{}{}
+		for key, value := range input.Filters {
+			if isColumnFilter(dataType, key) {
+				sheetInput.Filters[key] = value
+			}
+		}
+		if !isDateField(dataType, sheetInput.DateField) {
+			sheetInput.DateField = "created_at"
+		}
+		query, ok := exportQuery(c, db, dataType, sheetInput)
+		if !ok {
+			return nil, false
+		}
+		queries[dataType] = query
+	}
+	return queries, true
+}
+
+// exportWorkbook answers an export with format "excel" with a workbook holding the Users, Cases,
+// Appointments and Offices sheets. The file is assembled in a temporary file and streamed from
+// there, so the Content-Length is exact without holding the workbook in memory.
+func exportWorkbook(c *gin.Context, db *gorm.DB, input ExportDataInput) {
+	queries, ok := exportWorkbookQueries(c, db, input)
+	if !ok {
+		return
+	}
+	sheets := make([]exportSheet, 0, len(exportWorkbookTypes))
+	for _, dataType := range exportWorkbookTypes {
+		sheets = append(sheets, exportSheetFor(dataType, queries[dataType]))
+	}
+
+	tmp, err := os.CreateTemp("", "caf-export-*.xlsx")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data", "message": err.Error()})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := writeExportWorkbook(tmp, sheets); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data", "message": err.Error()})
+		return
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data", "message": err.Error()})
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, size, xlsxContentType, tmp, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=export_%s.xlsx", time.Now().Format("2006-01-02")),
+	})
+}
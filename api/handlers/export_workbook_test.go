@@ -0,0 +1,131 @@
+// api/handlers/export_workbook_test.go
+// Unit tests for the .xlsx data export.
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// staticSheet returns an export sheet that emits rows as given.
+func staticSheet(name string, header []string, rows ...[]interface{}) exportSheet {
+	return exportSheet{Name: name, Header: header, Rows: func(emit func([]interface{}) error) error {
+		for _, row := range rows {
+			if err := emit(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+}
+
+func TestWriteExportWorkbook(t *testing.T) {
+	created := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+	client := models.User{FirstName: "Luis", LastName: "Pérez"}
+	sheets := []exportSheet{
+		staticSheet("Users", usersExportHeader,
+			userExportRow(models.User{ID: 7, FirstName: "Ana", LastName: "López", Email: "ana@example.com", Role: "lawyer", CreatedAt: created})),
+		staticSheet("Cases", casesExportHeader,
+			caseExportRow(models.Case{ID: 12, Title: "Divorcio", Category: "Familiar", Status: "open", Client: &client, CreatedAt: created}),
+			caseExportRow(models.Case{ID: 13, Title: "Sin cliente", Category: "Civil", Status: "closed", CreatedAt: created})),
+		staticSheet("Appointments", appointmentsExportHeader),
+		staticSheet("Offices", officesExportHeader,
+			officeExportRow(models.Office{ID: 1, Name: "Centro", Code: "CEN", Address: "Av. Juárez 100", CreatedAt: created})),
+	}
+
+	var buf bytes.Buffer
+	if err := writeExportWorkbook(&buf, sheets); err != nil {
+		t.Fatalf("writeExportWorkbook: %v", err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("produced bytes are not a workbook: %v", err)
+	}
+	defer f.Close()
+
+	if got, want := f.GetSheetList(), []string{"Users", "Cases", "Appointments", "Offices"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sheets = %v, want %v", got, want)
+	}
+
+	rows, err := f.GetRows("Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{usersExportHeader, {"7", "Ana", "López", "ana@example.com", "lawyer", "2025-03-04 09:30:00"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Users rows = %q, want %q", rows, want)
+	}
+
+	rows, err = f.GetRows("Cases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[1][:5], []string{"12", "Divorcio", "Familiar", "open", "Luis Pérez"}) || rows[2][4] != "" {
+		t.Errorf("Cases rows = %q", rows)
+	}
+
+	if rows, _ := f.GetRows("Appointments"); len(rows) != 1 || !reflect.DeepEqual(rows[0], appointmentsExportHeader) {
+		t.Errorf("Appointments rows = %q, want only the header", rows)
+	}
+
+	// IDs and dates are numeric cells, not strings
+	if cellType, _ := f.GetCellType("Users", "A2"); cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+		t.Errorf("ID cell type = %v, want a number", cellType)
+	}
+	raw, err := f.GetCellValue("Users", "F2", excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial, err := strconv.ParseFloat(raw, 64); err != nil || int(serial) != 45720 {
+		t.Errorf("Created At raw value = %q, want the Excel serial date 45720.x", raw)
+	}
+}
+
+func TestExportDataExcelFormat(t *testing.T) {
+	db := dryRunDB(t)
+	w := postExport(t, db, `{"format": "excel", "filters": {"status": "open", "role": "lawyer"}, "dateFrom": "2024-01-01", "dateTo": "2024-01-31"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != xlsxContentType {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := w.Header().Get("Content-Length"); got != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("Content-Length = %q, body is %d bytes", got, w.Body.Len())
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("response is not a workbook: %v", err)
+	}
+	defer f.Close()
+	if got, want := f.GetSheetList(), []string{"Users", "Cases", "Appointments", "Offices"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %v, want %v", got, want)
+	}
+	if rows, _ := f.GetRows("Cases"); len(rows) == 0 || !reflect.DeepEqual(rows[0], casesExportHeader) {
+		t.Errorf("Cases header = %q, want %q", rows, casesExportHeader)
+	}
+}
+
+func TestExportDataExcelRejectsUnknownFields(t *testing.T) {
+	db := dryRunDB(t)
+	for _, key := range injectionKeys {
+		body := `{"format": "excel", "filters": {` + strconv.Quote(key) + `: "1"}}`
+		if w := postExport(t, db, body); w.Code != http.StatusBadRequest {
+			t.Errorf("filter %q: status = %d, want 400", key, w.Code)
+		}
+	}
+	if w := postExport(t, db, `{"format": "excel", "dateField": "password", "dateFrom": "2024-01-01"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown dateField: status = %d, want 400", w.Code)
+	}
+	if w := postExport(t, db, `{"type": "users", "format": "pdf"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,211 @@
+// api/handlers/financial_metrics_test.go
+// Unit tests for financial metrics: calendar period boundaries, period-over-period revenue and
+// expenses, and null figures when nothing has been recorded.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFinancialPeriodBounds(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		period   string
+		now      time.Time
+		start    time.Time
+		end      time.Time
+		previous time.Time
+	}{
+		{"month", day(2025, 3, 31).Add(23*time.Hour + 59*time.Minute), day(2025, 3, 1), day(2025, 4, 1), day(2025, 2, 1)},
+		{"month", day(2025, 1, 1), day(2025, 1, 1), day(2025, 2, 1), day(2024, 12, 1)},
+		{"quarter", day(2025, 1, 1), day(2025, 1, 1), day(2025, 4, 1), day(2024, 10, 1)},
+		{"quarter", day(2025, 3, 31).Add(23*time.Hour + 59*time.Minute), day(2025, 1, 1), day(2025, 4, 1), day(2024, 10, 1)},
+		{"quarter", day(2025, 4, 1), day(2025, 4, 1), day(2025, 7, 1), day(2025, 1, 1)},
+		{"quarter", day(2025, 9, 30), day(2025, 7, 1), day(2025, 10, 1), day(2025, 4, 1)},
+		{"quarter", day(2025, 12, 31).Add(23 * time.Hour), day(2025, 10, 1), day(2026, 1, 1), day(2025, 7, 1)},
+		{"year", day(2025, 1, 1), day(2025, 1, 1), day(2026, 1, 1), day(2024, 1, 1)},
+		{"year", day(2024, 12, 31).Add(23*time.Hour + 59*time.Minute), day(2024, 1, 1), day(2025, 1, 1), day(2023, 1, 1)},
+		{"year", day(2024, 2, 29), day(2024, 1, 1), day(2025, 1, 1), day(2023, 1, 1)},
+	}
+	for _, tt := range tests {
+		start, end, previous := financialPeriodBounds(tt.now, financialPeriodMonths[tt.period])
+		if !start.Equal(tt.start) || !end.Equal(tt.end) || !previous.Equal(tt.previous) {
+			t.Errorf("%s at %s = [%s, %s) after %s; want [%s, %s) after %s", tt.period, tt.now,
+				start.Format("2006-01-02"), end.Format("2006-01-02"), previous.Format("2006-01-02"),
+				tt.start.Format("2006-01-02"), tt.end.Format("2006-01-02"), tt.previous.Format("2006-01-02"))
+		}
+	}
+}
+
+// ledgerEntry is a stored payment (net of refunds) or expense.
+type ledgerEntry struct {
+	at    time.Time
+	cents int64
+}
+
+// financialScript serves payment_records and expenses sums over the given entries, honoring
+// the time bounds each query is given.
+func financialScript(payments, expenses []ledgerEntry) *scriptedSQL {
+	sum := func(entries []ledgerEntry, bounds []time.Time) (int64, int64) {
+		var total, count int64
+		for _, entry := range entries {
+			if len(bounds) >= 1 && entry.at.Before(bounds[0]) {
+				continue
+			}
+			if len(bounds) >= 2 && !entry.at.Before(bounds[1]) {
+				continue
+			}
+			total += entry.cents
+			count++
+		}
+		return total, count
+	}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			queryBounds := timeArgs(args)
+			switch {
+			case strings.Contains(query, `FROM "payment_records"`) && strings.Contains(query, "AVG("):
+				total, count := sum(payments, nil)
+				if count == 0 {
+					return []string{"avg", "count"}, [][]driver.Value{{0.0, int64(0)}}
+				}
+				return []string{"avg", "count"}, [][]driver.Value{{float64(total) / float64(count), count}}
+			case strings.Contains(query, `FROM "payment_records"`):
+				total, count := sum(payments, queryBounds)
+				currencies := int64(0)
+				if count > 0 {
+					currencies = 1
+				}
+				return []string{"total", "count", "currency_count", "currency"}, [][]driver.Value{{total, count, currencies, "MXN"}}
+			case strings.Contains(query, `FROM "expenses"`):
+				total, count := sum(expenses, queryBounds)
+				return []string{"total", "count"}, [][]driver.Value{{total, count}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestComputeFinancialPeriodAcrossBoundaries(t *testing.T) {
+	at := func(year int, month time.Month, d, hour int) time.Time {
+		return time.Date(year, month, d, hour, 0, 0, 0, time.Local)
+	}
+	payments := []ledgerEntry{
+		{at(2024, 12, 31, 23), 50000}, // Q4 2024, year 2024
+		{at(2025, 1, 1, 0), 100000},   // Q1 2025
+		{at(2025, 3, 31, 23), 100000}, // Q1 2025, last hour
+		{at(2025, 4, 1, 0), 300000},   // Q2 2025, first hour
+		{at(2025, 5, 15, 12), 60000},  // Q2 2025
+	}
+	expenses := []ledgerEntry{
+		{at(2025, 2, 10, 9), 40000},
+		{at(2025, 4, 2, 9), 25000},
+	}
+
+	tests := []struct {
+		period   string
+		now      time.Time
+		revenue  float64
+		previous float64
+		growth   *float64
+		expenses float64
+		net      float64
+	}{
+		// Q2 2025 against Q1 2025: 3600 vs 2000
+		{"quarter", at(2025, 5, 20, 10), 3600, 2000, floatPtr(80), 250, 3350},
+		// Q1 2025 against Q4 2024: 2000 vs 500
+		{"quarter", at(2025, 3, 31, 23), 2000, 500, floatPtr(300), 400, 1600},
+		// 2025 against 2024
+		{"year", at(2025, 6, 1, 0), 5600, 500, floatPtr(1020), 650, 4950},
+		// 2024 against 2023, which has no revenue: growth is unknown, not 0
+		{"year", at(2024, 12, 31, 23), 500, 0, nil, 0, 500},
+		// April 2025 against March 2025
+		{"month", at(2025, 4, 30, 23), 3000, 1000, floatPtr(200), 250, 2750},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period+"/"+tt.now.Format("2006-01-02"), func(t *testing.T) {
+			db := scriptedDB(t, financialScript(payments, expenses))
+			period := computeFinancialPeriod(db, tt.now, tt.period, true)
+			if period.Revenue == nil || *period.Revenue != tt.revenue || period.PreviousRevenue == nil || *period.PreviousRevenue != tt.previous {
+				t.Errorf("revenue = %v, previous = %v; want %v, %v", deref(period.Revenue), deref(period.PreviousRevenue), tt.revenue, tt.previous)
+			}
+			if (period.GrowthRate == nil) != (tt.growth == nil) || (tt.growth != nil && *period.GrowthRate != *tt.growth) {
+				t.Errorf("growth = %v, want %v", deref(period.GrowthRate), deref(tt.growth))
+			}
+			if !period.ExpensesAvailable || period.Expenses == nil || *period.Expenses != tt.expenses {
+				t.Errorf("expenses = %v (available %v), want %v", deref(period.Expenses), period.ExpensesAvailable, tt.expenses)
+			}
+			if period.NetIncome == nil || *period.NetIncome != tt.net {
+				t.Errorf("net income = %v, want %v", deref(period.NetIncome), tt.net)
+			}
+		})
+	}
+}
+
+func TestGetFinancialMetricsWithoutDataIsUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/metrics/financial?period=year", nil)
+	GetFinancialMetrics(scriptedDB(t, financialScript(nil, nil)))(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["available"] != false {
+		t.Errorf("available = %v", body["available"])
+	}
+	for _, field := range []string{"revenue", "revenueThisMonth", "revenueThisYear", "growthRate", "averageCaseValue", "outstandingInvoices"} {
+		if value, ok := body[field]; !ok || value != nil {
+			t.Errorf("%s = %v, want null", field, value)
+		}
+	}
+	period, _ := body["period"].(map[string]interface{})
+	if period["name"] != "year" || period["expensesAvailable"] != false {
+		t.Errorf("period = %v", period)
+	}
+	for _, field := range []string{"revenue", "previousRevenue", "growthRate", "expenses", "previousExpenses", "netIncome"} {
+		if value, ok := period[field]; !ok || value != nil {
+			t.Errorf("period.%s = %v, want null", field, value)
+		}
+	}
+}
+
+func TestGetFinancialMetricsRejectsUnknownPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/metrics/financial?period=week", nil)
+	script := financialScript(nil, nil)
+	GetFinancialMetrics(scriptedDB(t, script))(c)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"allowed":["month","quarter","year"]`) {
+		t.Errorf("status %d, body %s", w.Code, w.Body.String())
+	}
+	if len(script.ran("SELECT")) != 0 {
+		t.Errorf("queries ran for an invalid period: %v", script.ran("SELECT"))
+	}
+}
+
+func floatPtr(value float64) *float64 { return &value }
+
+// deref formats an optional amount for failure messages.
+func deref(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,26 @@
+// api/handlers/health.go
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetHealth answers the public liveness check with the service identity and how long this
+// process has been running.
+func GetHealth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":        "healthy",
+			"service":       "CAF API",
+			"timestamp":     time.Now().UTC(),
+			"version":       "1.2.0",
+			"deployment":    "production-ready-https-enabled",
+			"startedAt":     services.ProcessStart().UTC(),
+			"uptimeSeconds": services.Uptime().Seconds(),
+		})
+	}
+}
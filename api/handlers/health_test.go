@@ -0,0 +1,67 @@
+// api/handlers/health_test.go
+// Unit tests for the uptime reported by the public health check.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// healthUptime calls GET /health and returns its uptimeSeconds.
+func healthUptime(t *testing.T, router *gin.Engine) float64 {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		UptimeSeconds *float64 `json:"uptimeSeconds"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.UptimeSeconds == nil {
+		t.Fatalf("response has no uptimeSeconds: %s", w.Body.String())
+	}
+	return *body.UptimeSeconds
+}
+
+func TestHealthUptimeIncreases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := services.ProcessStart()
+	t.Cleanup(func() { services.SetProcessStart(previous) })
+	services.SetProcessStart(time.Now().Add(-90 * time.Second))
+
+	router := gin.New()
+	router.GET("/health", GetHealth())
+
+	first := healthUptime(t, router)
+	if first < 90 || first > 120 {
+		t.Errorf("first uptimeSeconds = %v, want about 90 since the recorded start", first)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if second := healthUptime(t, router); second <= first {
+		t.Errorf("uptimeSeconds did not increase: %v then %v", first, second)
+	}
+}
+
+func TestUptimePercentOverWindow(t *testing.T) {
+	previous := services.ProcessStart()
+	t.Cleanup(func() { services.SetProcessStart(previous) })
+
+	services.SetProcessStart(time.Now().Add(-6 * time.Hour))
+	if got := services.UptimePercent(24 * time.Hour); got < 24.9 || got > 25.1 {
+		t.Errorf("6h into a 24h window: uptime = %v%%, want 25%%", got)
+	}
+	services.SetProcessStart(time.Now().Add(-48 * time.Hour))
+	if got := services.UptimePercent(24 * time.Hour); got != 100 {
+		t.Errorf("48h into a 24h window: uptime = %v%%, want 100%%", got)
+	}
+}
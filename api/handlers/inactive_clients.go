@@ -0,0 +1,97 @@
+// api/handlers/inactive_clients.go
+// Clients with no active case, for outreach or cleanup of stub accounts created
+// by the appointment flows.
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// clientHasActiveCaseSQL matches users with at least one case that is not closed, completed or archived.
+const clientHasActiveCaseSQL = `EXISTS (SELECT 1 FROM cases WHERE cases.client_id = users.id
+	AND cases.deleted_at IS NULL AND cases.is_archived = false
+	AND cases.status NOT IN ('closed', 'completed', 'archived'))`
+
+// inactiveClient is a client row with a summary of their (inactive) cases.
+type inactiveClient struct {
+	ID               uint       `json:"id"`
+	FirstName        string     `json:"firstName"`
+	LastName         string     `json:"lastName"`
+	Email            string     `json:"email"`
+	Phone            string     `json:"phone"`
+	OfficeID         *uint      `json:"officeId,omitempty"`
+	LastLogin        *time.Time `json:"lastLogin,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	TotalCases       int64      `json:"totalCases"`
+	LastCaseActivity *time.Time `json:"lastCaseActivity,omitempty"`
+}
+
+// GetInactiveClients lists the office's clients whose cases are all closed/archived, or who have none.
+// Clients belong to the office by home office or by having any case there.
+func GetInactiveClients(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.Table("users").
+			Where("users.role = ? AND users.deleted_at IS NULL", "client").
+			Where("NOT " + clientHasActiveCaseSQL)
+
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			officeID, ok := c.Get("officeScopeID")
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado"})
+				return
+			}
+			q = q.Where("users.office_id = ? OR EXISTS (SELECT 1 FROM cases WHERE cases.client_id = users.id AND cases.office_id = ? AND cases.deleted_at IS NULL)", officeID, officeID)
+		}
+		if c.Query("withoutCases") == "true" {
+			q = q.Where("NOT EXISTS (SELECT 1 FROM cases WHERE cases.client_id = users.id AND cases.deleted_at IS NULL)")
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		var total int64
+		if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener clientes inactivos", "message": err.Error()})
+			return
+		}
+
+		clients := make([]inactiveClient, 0)
+		if err := q.Select(`users.id, users.first_name, users.last_name, users.email, users.phone, users.office_id,
+				users.last_login, users.created_at,
+				(SELECT COUNT(*) FROM cases WHERE cases.client_id = users.id AND cases.deleted_at IS NULL) AS total_cases,
+				(SELECT MAX(cases.updated_at) FROM cases WHERE cases.client_id = users.id AND cases.deleted_at IS NULL) AS last_case_activity`).
+			Order("users.created_at DESC, users.id DESC").
+			Limit(pageSize).
+			Offset((page - 1) * pageSize).
+			Scan(&clients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener clientes inactivos", "message": err.Error()})
+			return
+		}
+
+		totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+		c.JSON(http.StatusOK, gin.H{
+			"data": clients,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < totalPages,
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// api/handlers/integrity.go
+// Referential integrity report: appointments, tasks and case events whose case no longer
+// exists, and appointments and tasks still pending on a deleted case.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxOrphanedRecordsListed caps how many records of each kind the report lists; the counts
+// are always exact.
+const maxOrphanedRecordsListed = 500
+
+// orphanedRecord is one record referencing a missing or deleted case.
+type orphanedRecord struct {
+	ID     uint   `json:"id"`
+	CaseID uint   `json:"caseId"`
+	Status string `json:"status,omitempty"`
+	Issue  string `json:"issue"` // missing_case or deleted_case
+}
+
+// orphanedRecords is the report for one kind of record.
+type orphanedRecords struct {
+	Count int64            `json:"count"`
+	Items []orphanedRecord `json:"items"`
+}
+
+// findOrphanedRecords reports the rows of table whose case is missing, including rows that
+// are themselves soft-deleted, and the rows not deleted that match pending while their case
+// is soft-deleted. An empty pending skips deleted cases.
+func findOrphanedRecords(db *gorm.DB, table, columns, pending string, args ...interface{}) (orphanedRecords, error) {
+	report := orphanedRecords{Items: make([]orphanedRecord, 0)}
+	condition := "cases.id IS NULL"
+	if pending != "" {
+		condition = "(cases.id IS NULL OR (cases.deleted_at IS NOT NULL AND " + table + ".deleted_at IS NULL AND " + pending + "))"
+	}
+	query := func() *gorm.DB {
+		return db.Unscoped().Table(table).
+			Joins("LEFT JOIN cases ON cases.id = "+table+".case_id").
+			Where(condition, args...)
+	}
+	if err := query().Count(&report.Count).Error; err != nil {
+		return report, err
+	}
+	if report.Count == 0 {
+		return report, nil
+	}
+	err := query().
+		Select(columns + ", CASE WHEN cases.id IS NULL THEN 'missing_case' ELSE 'deleted_case' END AS issue").
+		Order(table + ".id").Limit(maxOrphanedRecordsListed).
+		Scan(&report.Items).Error
+	return report, err
+}
+
+// GetOrphanedRecords reports appointments, tasks and case events referencing cases that no
+// longer exist, and upcoming appointments and open tasks left on deleted cases, which case
+// deletion cancels or refuses to leave behind. Nothing is changed.
+func GetOrphanedRecords(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		appointments, err := findOrphanedRecords(db, "appointments",
+			"appointments.id, appointments.case_id, appointments.status",
+			"appointments.start_time >= ? AND appointments.status NOT IN ?", now, finishedAppointmentStatuses)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revisar las citas", "message": err.Error()})
+			return
+		}
+		tasks, err := findOrphanedRecords(db, "tasks",
+			"tasks.id, tasks.case_id, tasks.status",
+			"tasks.status NOT IN ?", finishedTaskStatuses)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revisar las tareas", "message": err.Error()})
+			return
+		}
+		// Events are the history of a case and stay with it when it is deleted
+		caseEvents, err := findOrphanedRecords(db, "case_events", "case_events.id, case_events.case_id", "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revisar los eventos de casos", "message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"appointments": appointments,
+			"tasks":        tasks,
+			"caseEvents":   caseEvents,
+			"total":        appointments.Count + tasks.Count + caseEvents.Count,
+			"limit":        maxOrphanedRecordsListed,
+			"checkedAt":    now,
+		})
+	}
+}
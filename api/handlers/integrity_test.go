@@ -0,0 +1,79 @@
+// api/handlers/integrity_test.go
+// Unit tests for the orphaned record report: records of missing cases are reported whatever
+// their state, and only pending appointments and tasks of deleted cases.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetOrphanedRecords(t *testing.T) {
+	script := &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.HasPrefix(query, `SELECT count(*) FROM "appointments"`):
+				return countRow(2)
+			case strings.HasPrefix(query, `SELECT appointments.id`):
+				return []string{"id", "case_id", "status", "issue"}, [][]driver.Value{
+					{int64(3), int64(40), "confirmed", "missing_case"},
+					{int64(8), int64(41), "pending", "deleted_case"},
+				}
+			case strings.HasPrefix(query, `SELECT count(*)`):
+				return countRow(0)
+			}
+			return nil, nil
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/integrity/orphans", nil)
+	GetOrphanedRecords(scriptedDB(t, script))(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var report struct {
+		Appointments orphanedRecords `json:"appointments"`
+		Tasks        orphanedRecords `json:"tasks"`
+		CaseEvents   orphanedRecords `json:"caseEvents"`
+		Total        int64           `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 2 || report.Appointments.Count != 2 || len(report.Appointments.Items) != 2 ||
+		report.Appointments.Items[1] != (orphanedRecord{ID: 8, CaseID: 41, Status: "pending", Issue: "deleted_case"}) {
+		t.Errorf("report = %s", w.Body.String())
+	}
+	if report.Tasks.Items == nil || report.CaseEvents.Items == nil {
+		t.Errorf("empty kinds should list no items, not null: %s", w.Body.String())
+	}
+
+	// Soft-deleted records count against missing cases; deleted cases only with pending work
+	for table, fragments := range map[string][]string{
+		"appointments": {"cases.id IS NULL OR (cases.deleted_at IS NOT NULL AND appointments.deleted_at IS NULL AND appointments.start_time >= $1"},
+		"tasks":        {"cases.id IS NULL OR (cases.deleted_at IS NOT NULL AND tasks.deleted_at IS NULL AND tasks.status NOT IN"},
+		"case_events":  {"WHERE cases.id IS NULL"},
+	} {
+		counts := script.ran(`SELECT count(*) FROM "` + table + `" LEFT JOIN cases ON cases.id = ` + table + `.case_id`)
+		if len(counts) != 1 {
+			t.Fatalf("%s: count queries = %q", table, script.ran(table))
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(counts[0], fragment) {
+				t.Errorf("%s: count query = %s", table, counts[0])
+			}
+		}
+		if strings.Contains(counts[0], table+`"."deleted_at" IS NULL AND`) {
+			t.Errorf("%s: count query skips soft-deleted records: %s", table, counts[0])
+		}
+	}
+}
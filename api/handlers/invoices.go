@@ -0,0 +1,183 @@
+// api/handlers/invoices.go
+// Case invoice generation: renders the case fee, recorded payments and the
+// outstanding balance as a PDF with the CAF letterhead.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/pdf"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// cafLetterheadName is the legal name printed on generated documents.
+const cafLetterheadName = "Centro de Apoyo para la Familia A.C."
+
+// paidPaymentStatuses lists payment_records statuses that represent money received.
+var paidPaymentStatuses = []string{"succeeded", "partially_refunded", "refunded"}
+
+// GetCaseInvoicePDF generates an invoice PDF for a case and records the generation in the audit log.
+func GetCaseInvoicePDF(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseRecord models.Case
+		if err := db.Preload("Client").Preload("Office").First(&caseRecord, caseID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+			return
+		}
+
+		payments := make([]models.PaymentRecord, 0)
+		if err := db.Where("case_id = ?", caseRecord.ID).
+			Where("paid_at IS NOT NULL").
+			Where("status IN ?", paidPaymentStatuses).
+			Order("paid_at ASC").
+			Find(&payments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener pagos del caso", "message": err.Error()})
+			return
+		}
+
+		var sequence int64
+		if err := db.Raw("SELECT nextval('invoice_number_seq')").Scan(&sequence).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar número de factura", "message": err.Error()})
+			return
+		}
+
+		issuedAt := time.Now()
+		invoiceNumber := fmt.Sprintf("CAF-%d-%06d", issuedAt.Year(), sequence)
+		currency := defaultDashboardCurrency()
+
+		feeCents := int64(math.Round(caseRecord.Fee * 100))
+		var paidCents int64
+		for _, payment := range payments {
+			paidCents += payment.AmountCents - payment.RefundedCents
+		}
+		balanceCents := feeCents - paidCents
+
+		doc := pdf.NewWithHeader(func(d *pdf.Document) {
+			writeCAFLetterhead(d, caseRecord.Office)
+		})
+
+		doc.Line(16, true, "FACTURA / RECIBO DE HONORARIOS")
+		doc.Line(10, false, "Número de factura: "+invoiceNumber)
+		doc.Line(10, false, "Fecha de emisión: "+issuedAt.Format("02/01/2006 15:04"))
+		doc.Space(10)
+
+		doc.Line(12, true, "Cliente")
+		if caseRecord.Client != nil {
+			doc.Line(10, false, strings.TrimSpace(caseRecord.Client.FirstName+" "+caseRecord.Client.LastName))
+			doc.Line(10, false, caseRecord.Client.Email)
+		} else {
+			doc.Line(10, false, "Sin cliente asignado")
+		}
+		doc.Space(6)
+
+		doc.Line(12, true, "Caso")
+		doc.Line(10, false, fmt.Sprintf("#%d - %s", caseRecord.ID, caseRecord.Title))
+		doc.Line(10, false, "Departamento: "+caseRecord.Category)
+		if caseRecord.DocketNumber != "" {
+			doc.Line(10, false, "N° Expediente: "+caseRecord.DocketNumber)
+		}
+		if caseRecord.Court != "" {
+			doc.Line(10, false, "Juzgado: "+caseRecord.Court)
+		}
+		doc.Space(10)
+
+		columns := []float64{0, 110, 300, 420}
+		doc.Line(12, true, "Pagos registrados")
+		doc.Columns(10, true, columns, []string{"Fecha", "Referencia", "Estado", "Monto"})
+		doc.Rule()
+		if len(payments) == 0 {
+			doc.Line(10, false, "No hay pagos registrados para este caso.")
+		}
+		for _, payment := range payments {
+			paidAt := ""
+			if payment.PaidAt != nil {
+				paidAt = payment.PaidAt.Format("02/01/2006")
+			}
+			reference := payment.StripeChargeID
+			if reference == "" {
+				reference = payment.StripePaymentIntentID
+			}
+			if reference == "" {
+				reference = fmt.Sprintf("PAGO-%d", payment.ID)
+			}
+			doc.Columns(10, false, columns, []string{
+				paidAt,
+				reference,
+				payment.Status,
+				formatInvoiceAmount(payment.AmountCents-payment.RefundedCents, currency),
+			})
+		}
+		doc.Rule()
+
+		doc.Columns(11, false, []float64{300, 420}, []string{"Honorarios", formatInvoiceAmount(feeCents, currency)})
+		doc.Columns(11, false, []float64{300, 420}, []string{"Pagado", formatInvoiceAmount(paidCents, currency)})
+		doc.Columns(11, true, []float64{300, 420}, []string{"Saldo", formatInvoiceAmount(balanceCents, currency)})
+		doc.Space(20)
+		doc.Paragraph(8, "Este documento es un comprobante de honorarios emitido por "+cafLetterheadName+". Conserve este documento para sus registros.")
+
+		content := doc.Bytes()
+
+		recordAuditLog(db, c, "case", caseRecord.ID, "export", "invoice_generated", map[string]interface{}{
+			"invoiceNumber": invoiceNumber,
+			"feeCents":      feeCents,
+			"paidCents":     paidCents,
+			"balanceCents":  balanceCents,
+			"currency":      currency,
+		})
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"factura-%s.pdf\"", invoiceNumber))
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		c.Header("X-Invoice-Number", invoiceNumber)
+		c.Data(http.StatusOK, "application/pdf", content)
+	}
+}
+
+// writeCAFLetterhead draws the CAF header block at the top of a PDF page.
+func writeCAFLetterhead(d *pdf.Document, office *models.Office) {
+	d.Line(14, true, cafLetterheadName)
+	if office != nil {
+		d.Line(9, false, office.Name)
+		if office.Address != "" {
+			d.Line(9, false, office.Address)
+		}
+		if office.PhoneOffice != "" {
+			d.Line(9, false, "Tel. "+office.PhoneOffice)
+		}
+	}
+	d.Rule()
+	d.Space(10)
+}
+
+// formatInvoiceAmount renders cents as a currency amount, e.g. "$1,250.00 MXN".
+func formatInvoiceAmount(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	whole := fmt.Sprintf("%d", cents/100)
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	return fmt.Sprintf("%s$%s.%02d %s", sign, grouped.String(), cents%100, currency)
+}
@@ -0,0 +1,314 @@
+// api/handlers/maintenance.go
+// Database and storage maintenance: audit log and deleted record retention, VACUUM ANALYZE and cleanup of
+// uploaded case files no longer referenced by any case event or document version. Runs are recorded in maintenance_runs.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maintenanceTasks lists the supported tasks in the order they run.
+var maintenanceTasks = []string{models.MaintenanceTaskAuditPurge, models.MaintenanceTaskRecordPurge, models.MaintenanceTaskVacuumAnalyze, models.MaintenanceTaskOrphanFiles}
+
+// maintenanceMutex prevents manual and scheduled runs from overlapping.
+var maintenanceMutex sync.Mutex
+
+// maintenanceSchedule tracks the scheduler's next tick for status reporting.
+var maintenanceSchedule struct {
+	mutex sync.Mutex
+	next  time.Time
+}
+
+// RunMaintenanceInput selects which tasks to run; empty means all of them.
+type RunMaintenanceInput struct {
+	Tasks  []string `json:"tasks"`
+	DryRun bool     `json:"dryRun"` // Orphan cleanup and the retention tasks only report what they would delete
+}
+
+// RunMaintenance runs the requested maintenance tasks synchronously and returns their records.
+func RunMaintenance(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input RunMaintenanceInput
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance request", "message": err.Error()})
+				return
+			}
+		}
+
+		tasks := input.Tasks
+		if len(tasks) == 0 {
+			tasks = maintenanceTasks
+		}
+		for _, task := range tasks {
+			if !isMaintenanceTask(task) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown maintenance task", "message": task, "tasks": maintenanceTasks})
+				return
+			}
+		}
+
+		if !maintenanceMutex.TryLock() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Maintenance is already running"})
+			return
+		}
+		defer maintenanceMutex.Unlock()
+
+		runs := make([]models.MaintenanceRun, 0, len(tasks))
+		for _, task := range tasks {
+			runs = append(runs, runMaintenanceTask(db, task, extractUserID(c), input.DryRun))
+		}
+		c.JSON(http.StatusOK, gin.H{"runs": runs})
+	}
+}
+
+// GetMaintenanceStatus returns the last run of each task, recent history and the schedule.
+func GetMaintenanceStatus(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 20
+		if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 100 {
+			limit = v
+		}
+
+		history := make([]models.MaintenanceRun, 0)
+		if err := db.Order("started_at DESC").Limit(limit).Find(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load maintenance history", "message": err.Error()})
+			return
+		}
+
+		lastRuns := make(map[string]*models.MaintenanceRun, len(maintenanceTasks))
+		for _, task := range maintenanceTasks {
+			lastRuns[task] = lastMaintenanceRun(db, task)
+		}
+
+		archives := make([]models.AuditArchive, 0)
+		db.Order("created_at DESC").Limit(limit).Find(&archives)
+
+		interval := config.MaintenanceInterval()
+		c.JSON(http.StatusOK, gin.H{
+			"lastRuns":      lastRuns,
+			"history":       history,
+			"auditArchives": archives,
+			"scheduled":     interval > 0,
+			"intervalHours": interval.Hours(),
+			"nextRun":       nextMaintenanceRun(),
+		})
+	}
+}
+
+// StartMaintenanceScheduler runs all maintenance tasks every config.MaintenanceInterval.
+// It does nothing when the interval is 0.
+func StartMaintenanceScheduler(db *gorm.DB) {
+	interval := config.MaintenanceInterval()
+	if interval <= 0 {
+		return
+	}
+	log.Printf("INFO: Scheduled maintenance every %s", interval)
+
+	setNext := func() {
+		maintenanceSchedule.mutex.Lock()
+		maintenanceSchedule.next = time.Now().Add(interval)
+		maintenanceSchedule.mutex.Unlock()
+	}
+	setNext()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			setNext()
+			if !maintenanceMutex.TryLock() {
+				log.Println("WARNING: Skipping scheduled maintenance; a run is already in progress")
+				continue
+			}
+			for _, task := range maintenanceTasks {
+				run := runMaintenanceTask(db, task, nil, false)
+				log.Printf("INFO: Scheduled maintenance %s finished with status %s", task, run.Status)
+			}
+			maintenanceMutex.Unlock()
+		}
+	}()
+}
+
+// runMaintenanceTask executes one task and records its outcome.
+func runMaintenanceTask(db *gorm.DB, task string, triggeredBy *uint, dryRun bool) models.MaintenanceRun {
+	run := models.MaintenanceRun{
+		Task:        task,
+		Status:      models.MaintenanceStatusRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	if err := db.Create(&run).Error; err != nil {
+		log.Printf("Failed to record maintenance run %s: %v", task, err)
+	}
+
+	var details string
+	var err error
+	switch task {
+	case models.MaintenanceTaskVacuumAnalyze:
+		details, err = vacuumAnalyze(db)
+	case models.MaintenanceTaskOrphanFiles:
+		details, err = cleanupOrphanFiles(db, dryRun)
+	case models.MaintenanceTaskAuditPurge:
+		details, err = purgeAuditLogs(db, dryRun, run.ID)
+	case models.MaintenanceTaskRecordPurge:
+		details, err = purgeDeletedRecords(db, dryRun, run.ID, triggeredBy)
+	}
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Details = details
+	switch {
+	case errors.Is(err, errMaintenanceSkipped):
+		run.Status = models.MaintenanceStatusSkipped
+	case err != nil:
+		run.Status = models.MaintenanceStatusFailed
+		run.Error = err.Error()
+	default:
+		run.Status = models.MaintenanceStatusSucceeded
+	}
+
+	if run.ID != 0 {
+		if err := db.Model(&run).Updates(map[string]interface{}{
+			"status":      run.Status,
+			"details":     run.Details,
+			"error":       run.Error,
+			"finished_at": run.FinishedAt,
+		}).Error; err != nil {
+			log.Printf("Failed to update maintenance run %d: %v", run.ID, err)
+		}
+	}
+	return run
+}
+
+// errMaintenanceSkipped marks a task that could not apply to the current setup.
+var errMaintenanceSkipped = errors.New("maintenance task skipped")
+
+// vacuumAnalyze reclaims dead tuples and refreshes planner statistics for the whole database.
+func vacuumAnalyze(db *gorm.DB) (string, error) {
+	if db.Dialector.Name() != "postgres" {
+		return "VACUUM ANALYZE is only supported on Postgres", errMaintenanceSkipped
+	}
+	start := time.Now()
+	// VACUUM cannot run inside a transaction block
+	if err := db.Session(&gorm.Session{SkipDefaultTransaction: true}).Exec("VACUUM ANALYZE").Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("VACUUM ANALYZE completed in %s", time.Since(start).Round(time.Millisecond)), nil
+}
+
+// cleanupOrphanFiles deletes uploaded case files that no case event (including soft-deleted ones)
+// or document version references and that are older than config.OrphanFileGracePeriod.
+func cleanupOrphanFiles(db *gorm.DB, dryRun bool) (string, error) {
+	lister, ok := storage.GetActiveStorage().(storage.FileLister)
+	if !ok {
+		return "Active storage backend does not support listing files", errMaintenanceSkipped
+	}
+
+	files, err := lister.ListFiles("cases/")
+	if err != nil {
+		return "", err
+	}
+
+	var referenced []string
+	if err := db.Unscoped().Model(&models.CaseEvent{}).
+		Where("file_url IS NOT NULL AND file_url <> ''").
+		Pluck("file_url", &referenced).Error; err != nil {
+		return "", err
+	}
+	// Earlier versions of a document keep their own files, which the event no longer points to
+	var versionKeys []string
+	if err := db.Model(&models.DocumentVersion{}).
+		Where("s3_key <> ''").
+		Pluck("s3_key", &versionKeys).Error; err != nil {
+		return "", err
+	}
+	referenced = append(referenced, versionKeys...)
+	referencedSet := make(map[string]struct{}, len(referenced))
+	for _, url := range referenced {
+		referencedSet[url] = struct{}{}
+	}
+
+	cutoff := time.Now().Add(-config.OrphanFileGracePeriod())
+	orphans, deleted, failed := 0, 0, 0
+	for _, file := range files {
+		if !isCaseDocumentURL(file.URL) || file.ModTime.After(cutoff) {
+			continue
+		}
+		if _, ok := referencedSet[file.URL]; ok {
+			continue
+		}
+		orphans++
+		if dryRun {
+			continue
+		}
+		if err := storage.GetActiveStorage().Delete(file.URL); err != nil {
+			log.Printf("Failed to delete orphan file %s: %v", file.URL, err)
+			failed++
+			continue
+		}
+		deleted++
+	}
+
+	if dryRun {
+		return fmt.Sprintf("Scanned %d files; %d orphaned (dry run, nothing deleted)", len(files), orphans), nil
+	}
+	details := fmt.Sprintf("Scanned %d files; deleted %d of %d orphaned", len(files), deleted, orphans)
+	if failed > 0 {
+		return details, fmt.Errorf("failed to delete %d orphaned files", failed)
+	}
+	return details, nil
+}
+
+// isCaseDocumentURL reports whether a stored URL is a case upload (cases/{caseID}/...).
+// Site content images share the cases/ prefix under non-numeric folders and are left alone.
+func isCaseDocumentURL(url string) bool {
+	parts := strings.Split(strings.TrimPrefix(url, storage.LocalURLPrefix), "/")
+	if len(parts) < 3 || parts[0] != "cases" {
+		return false
+	}
+	_, err := strconv.ParseUint(parts[1], 10, 64)
+	return err == nil
+}
+
+func isMaintenanceTask(task string) bool {
+	for _, t := range maintenanceTasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// lastMaintenanceRun returns the most recent finished run of a task, or nil.
+func lastMaintenanceRun(db *gorm.DB, task string) *models.MaintenanceRun {
+	var run models.MaintenanceRun
+	if err := db.Where("task = ? AND finished_at IS NOT NULL", task).Order("started_at DESC").First(&run).Error; err != nil {
+		return nil
+	}
+	return &run
+}
+
+// nextMaintenanceRun returns when the scheduler will next run, or nil when unscheduled.
+func nextMaintenanceRun() *time.Time {
+	maintenanceSchedule.mutex.Lock()
+	defer maintenanceSchedule.mutex.Unlock()
+	if maintenanceSchedule.next.IsZero() {
+		return nil
+	}
+	next := maintenanceSchedule.next
+	return &next
+}
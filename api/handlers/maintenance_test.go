@@ -0,0 +1,56 @@
+// api/handlers/maintenance_test.go
+// Unit tests for the orphan file sweep: files of earlier document versions are kept even
+// though no case event points to them any more.
+package handlers
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/storage"
+)
+
+// listingStorage lists the stored files and records the deleted ones.
+type listingStorage struct {
+	streamingStorage
+	files   []storage.StoredFile
+	deleted []string
+}
+
+func (l *listingStorage) ListFiles(prefix string) ([]storage.StoredFile, error) {
+	return l.files, nil
+}
+
+func (l *listingStorage) Delete(fileURL string) error {
+	l.deleted = append(l.deleted, fileURL)
+	return nil
+}
+
+func TestCleanupOrphanFilesKeepsDocumentVersions(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	store := &listingStorage{files: []storage.StoredFile{
+		{URL: "cases/7/contrato-v2.pdf", ModTime: old},
+		{URL: "cases/7/contrato-v1.pdf", ModTime: old},
+		{URL: "cases/7/huerfano.pdf", ModTime: old},
+	}}
+	useStorage(t, store)
+	script := &scriptedSQL{rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, `FROM "case_events"`):
+			return []string{"file_url"}, [][]driver.Value{{"cases/7/contrato-v2.pdf"}}
+		case strings.Contains(query, `FROM "document_versions"`):
+			return []string{"s3_key"}, [][]driver.Value{{"cases/7/contrato-v1.pdf"}, {"cases/7/contrato-v2.pdf"}}
+		}
+		return nil, nil
+	}}
+
+	details, err := cleanupOrphanFiles(scriptedDB(t, script), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "cases/7/huerfano.pdf" {
+		t.Errorf("deleted %v, want only cases/7/huerfano.pdf (%s)", store.deleted, details)
+	}
+}
@@ -0,0 +1,125 @@
+// api/handlers/metrics.go
+// Financial and system metrics for roles granted the matching capability (see
+// config.CapabilityRoles), so e.g. a finance role can read revenue without being admin.
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// financialMetricsResponse is FinancialMetrics with the selected period and the time it was computed.
+type financialMetricsResponse struct {
+	FinancialMetrics
+	Period financialPeriodMetrics `json:"period"`
+	AsOf   time.Time              `json:"asOf"`
+}
+
+// financialPeriodMetrics compares the calendar period containing now with the one before it.
+// Revenue figures are null while no payment is recorded, expense figures while no expense is.
+type financialPeriodMetrics struct {
+	Name              string    `json:"name"` // month, quarter or year
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"` // Exclusive
+	PreviousStart     time.Time `json:"previousStart"`
+	Revenue           *float64  `json:"revenue"`
+	PreviousRevenue   *float64  `json:"previousRevenue"`
+	GrowthRate        *float64  `json:"growthRate"` // Null when the previous period had no revenue
+	ExpensesAvailable bool      `json:"expensesAvailable"`
+	Expenses          *float64  `json:"expenses"`
+	PreviousExpenses  *float64  `json:"previousExpenses"`
+	NetIncome         *float64  `json:"netIncome"` // Revenue minus expenses; null unless both are available
+}
+
+// financialPeriodMonths is the length in months of each ?period= GetFinancialMetrics accepts.
+var financialPeriodMonths = map[string]int{"month": 1, "quarter": 3, "year": 12}
+
+// financialPeriodBounds returns the calendar period of months months containing now as
+// [start, end), and the start of the period before it.
+func financialPeriodBounds(now time.Time, months int) (start, end, previousStart time.Time) {
+	month := time.Month((int(now.Month())-1)/months*months + 1)
+	start = time.Date(now.Year(), month, 1, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, months, 0), start.AddDate(0, -months, 0)
+}
+
+// computeFinancialPeriod sums revenue and expenses for the named period containing now and the
+// previous one.
+func computeFinancialPeriod(db *gorm.DB, now time.Time, name string, revenueAvailable bool) financialPeriodMetrics {
+	start, end, previousStart := financialPeriodBounds(now, financialPeriodMonths[name])
+	period := financialPeriodMetrics{Name: name, Start: start, End: end, PreviousStart: previousStart}
+
+	if revenueAvailable {
+		revenue := sumNetPaidSummary(db, &start, &end)
+		previous := sumNetPaidSummary(db, &previousStart, &start)
+		period.Revenue = centsAmount(revenue.Total)
+		period.PreviousRevenue = centsAmount(previous.Total)
+		period.GrowthRate = growthRate(revenue.Total, previous.Total)
+	}
+
+	if _, count := sumExpenseCents(db, nil, nil); count > 0 {
+		period.ExpensesAvailable = true
+		expenses, _ := sumExpenseCents(db, &start, &end)
+		previous, _ := sumExpenseCents(db, &previousStart, &start)
+		period.Expenses = centsAmount(expenses)
+		period.PreviousExpenses = centsAmount(previous)
+		if period.Revenue != nil {
+			net := *period.Revenue - *period.Expenses
+			period.NetIncome = &net
+		}
+	}
+	return period
+}
+
+// GetFinancialMetrics returns the revenue figures from the admin dashboard on their own, plus
+// revenue, expenses and growth for ?period=month (default), quarter or year.
+// Requires config.CapabilityFinancialMetrics.
+func GetFinancialMetrics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.DefaultQuery("period", "month")
+		if _, ok := financialPeriodMonths[name]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Periodo inválido", "allowed": []string{"month", "quarter", "year"}})
+			return
+		}
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+		cacheKey := analyticsCacheKey("financial-metrics", "all", name, loc)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+
+		now := time.Now().In(loc)
+		metrics := computeFinancialMetrics(db, now)
+		response := financialMetricsResponse{
+			FinancialMetrics: metrics,
+			Period:           computeFinancialPeriod(db, now, name, metrics.Available),
+			AsOf:             now,
+		}
+		analyticsCache.set(cacheKey, response, now)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetSystemMetrics returns system health plus this instance's Go runtime figures.
+// Requires config.CapabilitySystemMetrics.
+func GetSystemMetrics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		c.JSON(http.StatusOK, gin.H{
+			"health": collectSystemHealth(db),
+			"runtime": gin.H{
+				"goroutines":     runtime.NumGoroutine(),
+				"heapAllocBytes": mem.HeapAlloc,
+				"sysBytes":       mem.Sys,
+				"numGC":          mem.NumGC,
+			},
+		})
+	}
+}
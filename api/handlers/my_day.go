@@ -0,0 +1,209 @@
+// api/handlers/my_day.go
+// Landing-page view for staff: the day's appointments, open tasks due by that day, recently
+// assigned cases and cases with recent activity in a single response, with "today" taken in
+// the staff member's own or their office's time zone unless ?tz= is given.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// My day limits
+const (
+	myDayRecentCaseDays   = 7  // How far back a case assignment counts as recent
+	myDayMaxCases         = 20 // Most recently assigned cases returned
+	myDayMaxTasks         = 50 // Tasks returned, earliest due first
+	myDayActivityHours    = 24 // How far back case events count as recent activity
+	myDayMaxActivityCases = 20 // Most recently active cases returned
+)
+
+// myDayTask is a task row in the my day view.
+type myDayTask struct {
+	ID        uint       `json:"id"`
+	CaseID    uint       `json:"caseId"`
+	CaseTitle string     `json:"caseTitle"`
+	Title     string     `json:"title"`
+	Priority  string     `json:"priority"`
+	Status    string     `json:"status"`
+	DueDate   *time.Time `json:"dueDate"`
+	Overdue   bool       `json:"overdue"` // Due before the requested day
+}
+
+// myDayCase is a recently assigned case in the my day view.
+type myDayCase struct {
+	CaseListItem
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+// myDayActiveCase is a case of the staff member with events in the activity window.
+type myDayActiveCase struct {
+	CaseListItem
+	LastActivityAt time.Time `json:"lastActivityAt"`
+	ActivityCount  int64     `json:"activityCount"`
+}
+
+// myDayCounts summarizes the my day view.
+type myDayCounts struct {
+	Appointments   int `json:"appointments"`
+	Tasks          int `json:"tasks"`
+	OverdueTasks   int `json:"overdueTasks"`
+	RecentCases    int `json:"recentCases"`
+	RecentActivity int `json:"recentActivity"`
+}
+
+// myDay is the my day view of one staff member.
+type myDay struct {
+	Date            string                `json:"date"`
+	Timezone        string                `json:"timezone"`
+	Appointments    []appointmentListItem `json:"appointments"`
+	NextAppointment *appointmentListItem  `json:"nextAppointment"`
+	Tasks           []myDayTask           `json:"tasks"`
+	RecentCases     []myDayCase           `json:"recentCases"`
+	RecentActivity  []myDayActiveCase     `json:"recentActivity"`
+	Counts          myDayCounts           `json:"counts"`
+}
+
+// myDayDate returns the start of the ?date= day (YYYY-MM-DD), or of today at now when date is
+// empty, in loc.
+func myDayDate(date string, now time.Time, loc *time.Location) (time.Time, error) {
+	if date != "" {
+		return time.ParseInLocation("2006-01-02", date, loc)
+	}
+	now = now.In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// myDayStaffCases keeps the unarchived cases the user is assigned to or primary staff of,
+// joining their latest assignment as "a".
+func myDayStaffCases(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("cases").
+		Joins(`LEFT JOIN (SELECT case_id, MAX(assigned_at) AS assigned_at FROM user_case_assignments
+				WHERE user_id = ? AND deleted_at IS NULL GROUP BY case_id) a ON a.case_id = cases.id`, userID).
+		Where("cases.deleted_at IS NULL AND cases.is_archived = ?", false).
+		Where("a.case_id IS NOT NULL OR cases.primary_staff_id = ?", userID)
+}
+
+// loadMyDay builds the my day view of the user for the day starting at dayStart. Upcoming
+// appointments and recent activity are measured from now, or from the end of the day when it
+// is already over.
+func loadMyDay(db *gorm.DB, userID uint, dayStart, now time.Time) (myDay, error) {
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	reference := now
+	if dayEnd.Before(now) {
+		reference = dayEnd
+	}
+	view := myDay{Date: dayStart.Format("2006-01-02"), Timezone: dayStart.Location().String()}
+
+	view.Appointments = make([]appointmentListItem, 0)
+	if err := db.Model(&models.Appointment{}).
+		Select(appointmentListItemColumns).
+		Where("appointments.staff_id = ? AND appointments.start_time >= ? AND appointments.start_time < ?", userID, dayStart, dayEnd).
+		Order("appointments.start_time ASC").
+		Scan(&view.Appointments).Error; err != nil {
+		return view, err
+	}
+	next := make([]appointmentListItem, 0, 1)
+	if err := db.Model(&models.Appointment{}).
+		Select(appointmentListItemColumns).
+		Where("appointments.staff_id = ? AND appointments.start_time >= ? AND appointments.status NOT IN ?", userID, reference, finishedAppointmentStatuses).
+		Order("appointments.start_time ASC").
+		Limit(1).
+		Scan(&next).Error; err != nil {
+		return view, err
+	}
+	departments, categories := calendarColorMaps(db)
+	for i := range view.Appointments {
+		view.Appointments[i].DisplayColor = calendarColor(departments, categories, view.Appointments[i].Department, view.Appointments[i].Category)
+	}
+	if len(next) > 0 {
+		next[0].DisplayColor = calendarColor(departments, categories, next[0].Department, next[0].Category)
+		view.NextAppointment = &next[0]
+	}
+
+	view.Tasks = make([]myDayTask, 0)
+	if err := db.Model(&models.Task{}).
+		Select("tasks.id, tasks.case_id, cases.title AS case_title, tasks.title, tasks.priority, tasks.status, tasks.due_date").
+		Joins("JOIN cases ON cases.id = tasks.case_id AND cases.deleted_at IS NULL").
+		Where("tasks.assigned_to_id = ? AND tasks.status NOT IN ? AND tasks.due_date IS NOT NULL AND tasks.due_date < ?",
+			userID, finishedTaskStatuses, dayEnd).
+		Order("tasks.due_date ASC").
+		Limit(myDayMaxTasks).
+		Scan(&view.Tasks).Error; err != nil {
+		return view, err
+	}
+	for i := range view.Tasks {
+		view.Tasks[i].Overdue = view.Tasks[i].DueDate.Before(dayStart)
+		if view.Tasks[i].Overdue {
+			view.Counts.OverdueTasks++
+		}
+	}
+
+	// A case counts from its assignment row, or from creation when the member is only
+	// set as primary staff
+	view.RecentCases = make([]myDayCase, 0)
+	if err := myDayStaffCases(db, userID).
+		Select(caseListItemColumns+", COALESCE(a.assigned_at, cases.created_at) AS assigned_at").
+		Where("COALESCE(a.assigned_at, cases.created_at) >= ? AND COALESCE(a.assigned_at, cases.created_at) < ?",
+			dayStart.AddDate(0, 0, 1-myDayRecentCaseDays), dayEnd).
+		Order("assigned_at DESC").
+		Limit(myDayMaxCases).
+		Scan(&view.RecentCases).Error; err != nil {
+		return view, err
+	}
+
+	view.RecentActivity = make([]myDayActiveCase, 0)
+	if err := myDayStaffCases(db, userID).
+		Select(caseListItemColumns+", e.last_activity_at, e.activity_count").
+		Joins(`JOIN (SELECT case_id, MAX(created_at) AS last_activity_at, COUNT(*) AS activity_count FROM case_events
+				WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ? GROUP BY case_id) e ON e.case_id = cases.id`,
+			reference.Add(-myDayActivityHours*time.Hour), reference).
+		Order("e.last_activity_at DESC").
+		Limit(myDayMaxActivityCases).
+		Scan(&view.RecentActivity).Error; err != nil {
+		return view, err
+	}
+
+	view.Counts.Appointments = len(view.Appointments)
+	view.Counts.Tasks = len(view.Tasks)
+	view.Counts.RecentCases = len(view.RecentCases)
+	view.Counts.RecentActivity = len(view.RecentActivity)
+	return view, nil
+}
+
+// GetMyDay returns the authenticated staff member's appointments for ?date= (YYYY-MM-DD,
+// default today in the ?tz= zone or else their own or their office's time zone) by start time and their next upcoming
+// appointment, their unfinished tasks due by the end of that day (earliest first, flagged when
+// overdue), cases assigned to them in the week up to that day (newest first), and their cases
+// with events in the last 24 hours (most recently active first), with counts of each.
+func GetMyDay(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := extractUserIDUint(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		loc, ok := requestLocation(c, db)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		dayStart, err := myDayDate(c.Query("date"), now, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida, use YYYY-MM-DD"})
+			return
+		}
+
+		view, err := loadMyDay(db, userID, dayStart, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el resumen del día", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, view)
+	}
+}
@@ -0,0 +1,146 @@
+// api/handlers/my_day_test.go
+// Unit tests for the my day view: it only holds the caller's appointments, tasks and cases,
+// and "today" is the day in the caller's own or their office's time zone.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// myDayScript answers for staff member 3 of an office in Ciudad Juárez: one appointment today,
+// one next week, a task, an assigned case and a case with activity. Queries for anyone else get
+// no rows. bounds receives the start and end of the day appointments were looked up for.
+func myDayScript(t *testing.T, bounds *[]time.Time) *scriptedSQL {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Ciudad_Juarez")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	today := time.Date(2025, 3, 10, 10, 0, 0, 0, loc)
+	appointmentColumns := []string{"id", "case_id", "staff_id", "office_id", "title", "start_time", "end_time", "status", "category", "department"}
+	caseColumns := []string{"id", "case_number", "title", "status", "office_id", "primary_staff_id"}
+
+	return &scriptedSQL{
+		observe: func(query string, args []driver.Value) {
+			if strings.Contains(query, `FROM "appointments"`) && strings.Contains(query, "start_time <") {
+				*bounds = append(*bounds, timeArgs(args)...)
+			}
+		},
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, "office_timezone"):
+				return []string{"user_timezone", "office_timezone"}, [][]driver.Value{{"", "America/Ciudad_Juarez"}}
+			case !boundArg(args, int64(3)):
+				return nil, nil
+			case strings.Contains(query, `FROM "appointments"`) && strings.Contains(query, "NOT IN"):
+				return appointmentColumns, [][]driver.Value{{int64(12), int64(7), int64(3), int64(2), "Audiencia", today.AddDate(0, 0, 7), today.AddDate(0, 0, 7).Add(time.Hour), "confirmed", "Familiar", "Familiar"}}
+			case strings.Contains(query, `FROM "appointments"`):
+				return appointmentColumns, [][]driver.Value{{int64(11), int64(7), int64(3), int64(2), "Consulta", today, today.Add(time.Hour), "completed", "Familiar", "Familiar"}}
+			case strings.Contains(query, `FROM "tasks"`):
+				return []string{"id", "case_id", "case_title", "title", "priority", "status", "due_date"},
+					[][]driver.Value{{int64(21), int64(7), "Divorcio", "Preparar demanda", "high", "pending", today.AddDate(0, 0, -1)}}
+			case strings.Contains(query, "last_activity_at"):
+				return append(caseColumns, "last_activity_at", "activity_count"), [][]driver.Value{{int64(8), "CAF-JRZ-2025-000008", "Pensión", "open", int64(2), int64(3), today, int64(2)}}
+			case strings.Contains(query, `FROM "cases"`) || strings.Contains(query, "FROM cases"):
+				return append(caseColumns, "assigned_at"), [][]driver.Value{{int64(7), "CAF-JRZ-2025-000007", "Divorcio", "open", int64(2), int64(3), today.AddDate(0, 0, -2)}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestMyDayTodayInOfficeTimezone(t *testing.T) {
+	var bounds []time.Time
+	db := scriptedDB(t, myDayScript(t, &bounds))
+	loc, err := userLocation(db, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.String() != "America/Ciudad_Juarez" {
+		t.Fatalf("location = %s", loc)
+	}
+
+	// 22:00 on March 10 in Ciudad Juárez is already March 11 in UTC
+	now := time.Date(2025, 3, 11, 4, 0, 0, 0, time.UTC)
+	dayStart, err := myDayDate("", now, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := loadMyDay(db, 3, dayStart, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Date != "2025-03-10" || view.Timezone != "America/Ciudad_Juarez" {
+		t.Errorf("date = %s %s, want 2025-03-10 America/Ciudad_Juarez", view.Date, view.Timezone)
+	}
+	want := []time.Time{time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)}
+	if len(bounds) != 2 || !bounds[0].Equal(want[0]) || !bounds[1].Equal(want[1]) {
+		t.Errorf("appointments looked up for %v, want %v", bounds, want)
+	}
+}
+
+func TestMyDayOnlyIncludesCallersItems(t *testing.T) {
+	var bounds []time.Time
+	db := scriptedDB(t, myDayScript(t, &bounds))
+	loc, _ := time.LoadLocation("America/Ciudad_Juarez")
+	dayStart := time.Date(2025, 3, 10, 0, 0, 0, 0, loc)
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, loc)
+
+	view, err := loadMyDay(db, 3, dayStart, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (myDayCounts{Appointments: 1, Tasks: 1, OverdueTasks: 1, RecentCases: 1, RecentActivity: 1}); view.Counts != want {
+		t.Errorf("counts = %+v, want %+v", view.Counts, want)
+	}
+	if view.NextAppointment == nil || view.NextAppointment.ID != 12 {
+		t.Errorf("next appointment = %+v, want 12", view.NextAppointment)
+	}
+	if len(view.RecentActivity) != 1 || view.RecentActivity[0].ID != 8 || view.RecentActivity[0].ActivityCount != 2 {
+		t.Errorf("recent activity = %+v", view.RecentActivity)
+	}
+
+	// Another staff member sees none of it
+	other, err := loadMyDay(db, 4, dayStart, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Counts != (myDayCounts{}) || other.NextAppointment != nil {
+		t.Errorf("other staff member's view = %+v", other)
+	}
+}
+
+func TestGetMyDayRejectsInvalidDate(t *testing.T) {
+	var bounds []time.Time
+	db := scriptedDB(t, myDayScript(t, &bounds))
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/staff/my-day?date=10/03/2025", nil)
+	c.Set("userID", "3")
+	GetMyDay(db)(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/staff/my-day?date=2025-03-10", nil)
+	c.Set("userID", "3")
+	GetMyDay(db)(c)
+	var body myDay
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if body.Date != "2025-03-10" || body.Counts.Appointments != 1 {
+		t.Errorf("view = %+v", body)
+	}
+}
@@ -4,11 +4,13 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/notifications"
 	"gorm.io/gorm"
 )
 
@@ -19,10 +21,14 @@ var (
 
 // CreateNotificationWithMeta creates a notification with optional entity context and dedup key.
 // If dedupKey is non-empty, creation is guarded so the same (userID, dedupKey) is not inserted twice within 1 minute.
+// Users who turned in-app notifications, or the entity's event type, off are skipped without error.
 func CreateNotificationWithMeta(db *gorm.DB, userID uint, message, notifType string, link *string, entityType string, entityID *uint, dedupKey string) error {
 	if message == "" {
 		return gorm.ErrInvalidData
 	}
+	if !notificationAllowed(db, userID, models.DeliveryChannelInApp, notificationEventForEntity(entityType)) {
+		return nil
+	}
 	n := models.Notification{
 		UserID:     userID,
 		Message:    message,
@@ -70,7 +76,11 @@ func NotifyAdmins(db *gorm.DB, message, notifType string, link *string, entityTy
 	if err != nil || len(adminIDs) == 0 {
 		return
 	}
+	event := notificationEventForEntity(entityType)
 	for _, id := range adminIDs {
+		if !notificationAllowed(db, id, models.DeliveryChannelInApp, event) {
+			continue
+		}
 		_ = CreateNotificationWithMeta(db, id, message, notifType, link, entityType, entityID, dedupKey)
 		SendUserNotification(strconv.FormatUint(uint64(id), 10), map[string]interface{}{
 			"message": message, "type": notifType, "link": link, "entityType": entityType, "entityId": entityID,
@@ -106,7 +116,11 @@ func NotifyAdminsAndOfficeManagersForContact(db *gorm.DB, message, notifType str
 		}
 	}
 
+	event := notificationEventForEntity(entityType)
 	for _, id := range recipientIDs {
+		if !notificationAllowed(db, id, models.DeliveryChannelInApp, event) {
+			continue
+		}
 		_ = CreateNotificationWithMeta(db, id, message, notifType, link, entityType, entityID, dedupKey)
 		SendUserNotification(strconv.FormatUint(uint64(id), 10), map[string]interface{}{
 			"message": message, "type": notifType, "link": link, "entityType": entityType, "entityId": entityID,
@@ -129,3 +143,49 @@ func NotifyAdminsForAppointment(db *gorm.DB, action string, appointmentID uint,
 	dedup := fmt.Sprintf("appointment:%d:%s", appointmentID, action)
 	NotifyAdmins(db, msg, "info", link, "appointment", &eid, dedup)
 }
+
+// deliverAppointmentConfirmation emails an appointment confirmation; replaced in tests.
+var deliverAppointmentConfirmation = notifications.SendAppointmentConfirmation
+
+// sendAppointmentConfirmation emails the client a confirmation of the appointment and records
+// the delivery, unless they turned off email or appointment notifications. It reports whether
+// the email was sent.
+func sendAppointmentConfirmation(db *gorm.DB, appointment models.Appointment, client models.User) bool {
+	if !notificationAllowed(db, client.ID, models.DeliveryChannelEmail, models.NotificationEventAppointments) {
+		return false
+	}
+	err := deliverAppointmentConfirmation(appointment, client)
+	recordAppointmentEmail(db, appointment, client, "appointment_confirmation", "Confirmación de su Cita en CAF", err)
+	return err == nil
+}
+
+// recordAppointmentEmail records an email about the appointment sent to the client with the
+// outcome of the send: failed with err, otherwise sent, or simulated while no provider is
+// configured.
+func recordAppointmentEmail(db *gorm.DB, appointment models.Appointment, client models.User, deliveryType, subject string, err error) {
+	delivery := models.NotificationDelivery{
+		UserID:     client.ID,
+		Channel:    models.DeliveryChannelEmail,
+		Type:       deliveryType,
+		Recipient:  client.Email,
+		Subject:    subject,
+		Status:     models.DeliveryStatusSent,
+		EntityType: "appointment",
+		EntityID:   &appointment.ID,
+	}
+	if err != nil {
+		log.Printf("WARNING: Failed to send %s for appointment %d: %v", deliveryType, appointment.ID, err)
+		delivery.Status, delivery.Error = models.DeliveryStatusFailed, err.Error()
+	} else if notifications.Simulated {
+		delivery.Status = models.DeliveryStatusSimulated
+	}
+	RecordNotificationDelivery(db, delivery)
+}
+
+// RecordNotificationDelivery logs an outbound email/SMS for the recipient's communication log.
+// Failures are logged and never interrupt the caller.
+func RecordNotificationDelivery(db *gorm.DB, delivery models.NotificationDelivery) {
+	if err := db.Create(&delivery).Error; err != nil {
+		log.Printf("WARNING: Failed to record %s delivery to user %d: %v", delivery.Channel, delivery.UserID, err)
+	}
+}
@@ -0,0 +1,115 @@
+// api/handlers/notification_preferences.go
+// Notification preferences: users opt out of email or in-app notifications, or of an event
+// type on every channel. Senders consult them through notificationAllowed; users who never
+// set any receive everything.
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadNotificationPreference returns the user's preferences, or the all-on defaults when they
+// have none or they cannot be read, so a lookup failure never silences notifications.
+func loadNotificationPreference(db *gorm.DB, userID uint) models.NotificationPreference {
+	var preference models.NotificationPreference
+	err := db.Where("user_id = ?", userID).First(&preference).Error
+	if err == nil {
+		return preference
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("WARNING: Failed to load notification preferences of user %d: %v", userID, err)
+	}
+	return models.DefaultNotificationPreference(userID)
+}
+
+// notificationAllowed reports whether the user receives notifications of the event type
+// (one of the models.NotificationEvent constants, or "" for any) on the channel.
+func notificationAllowed(db *gorm.DB, userID uint, channel, event string) bool {
+	return loadNotificationPreference(db, userID).Allows(channel, event)
+}
+
+// notificationEventForEntity maps a notification's entity type to the event type its
+// recipients can opt out of; entities without one map to "".
+func notificationEventForEntity(entityType string) string {
+	switch entityType {
+	case "appointment":
+		return models.NotificationEventAppointments
+	case "case":
+		return models.NotificationEventCases
+	case "task":
+		return models.NotificationEventTasks
+	case "payment":
+		return models.NotificationEventPayments
+	}
+	return ""
+}
+
+// notificationPreferenceInput is the body of PATCH /notifications/preferences; omitted fields
+// keep their current value.
+type notificationPreferenceInput struct {
+	EmailEnabled *bool `json:"emailEnabled"`
+	InAppEnabled *bool `json:"inAppEnabled"`
+	Appointments *bool `json:"appointments"`
+	Cases        *bool `json:"cases"`
+	Tasks        *bool `json:"tasks"`
+	Payments     *bool `json:"payments"`
+}
+
+// GetNotificationPreferences returns the current user's notification preferences.
+func GetNotificationPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := extractUserIDUint(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		c.JSON(http.StatusOK, loadNotificationPreference(db, userID))
+	}
+}
+
+// UpdateNotificationPreferences changes the current user's notification preferences.
+func UpdateNotificationPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := extractUserIDUint(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		var input notificationPreferenceInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		preference := loadNotificationPreference(db, userID)
+		if input.EmailEnabled != nil {
+			preference.EmailEnabled = *input.EmailEnabled
+		}
+		if input.InAppEnabled != nil {
+			preference.InAppEnabled = *input.InAppEnabled
+		}
+		if input.Appointments != nil {
+			preference.Appointments = *input.Appointments
+		}
+		if input.Cases != nil {
+			preference.Cases = *input.Cases
+		}
+		if input.Tasks != nil {
+			preference.Tasks = *input.Tasks
+		}
+		if input.Payments != nil {
+			preference.Payments = *input.Payments
+		}
+		if err := db.Save(&preference).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar las preferencias de notificación", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, preference)
+	}
+}
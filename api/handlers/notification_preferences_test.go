@@ -0,0 +1,150 @@
+// api/handlers/notification_preferences_test.go
+// Unit tests for notification preferences: a user who turned email off still gets in-app
+// notifications but no confirmation or reminder emails, users without preferences get
+// everything, and updates keep the fields they omit.
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// notificationPreferenceColumns are the columns of notification_preferences, in the order
+// preferenceRow takes them.
+var notificationPreferenceColumns = []string{"user_id", "email_enabled", "in_app_enabled", "appointments", "cases", "tasks", "payments"}
+
+// preferenceRow answers preference queries with the given row, leaving other queries to rows.
+func preferenceRow(row []driver.Value, rows func(string, []driver.Value) ([]string, [][]driver.Value)) func(string, []driver.Value) ([]string, [][]driver.Value) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, `FROM "notification_preferences"`) {
+			return notificationPreferenceColumns, [][]driver.Value{row}
+		}
+		if rows == nil {
+			return nil, nil
+		}
+		return rows(query, args)
+	}
+}
+
+// emailOff is the preference row of user 3 with email turned off.
+var emailOff = []driver.Value{int64(3), false, true, true, true, true, true}
+
+func TestEmailOptOutKeepsInAppNotifications(t *testing.T) {
+	script := &scriptedSQL{rows: preferenceRow(emailOff, nil)}
+	db := scriptedDB(t, script)
+
+	// No confirmation email and no delivery record
+	previous := deliverAppointmentConfirmation
+	emailed := 0
+	deliverAppointmentConfirmation = func(models.Appointment, models.User) error { emailed++; return nil }
+	t.Cleanup(func() { deliverAppointmentConfirmation = previous })
+	if sendAppointmentConfirmation(db, models.Appointment{ID: 5, Title: "Consulta"}, models.User{ID: 3, Email: "ana@correo.mx"}) || emailed != 0 {
+		t.Errorf("confirmation emailed to a user who turned email off")
+	}
+	if deliveries := script.ran(`INSERT INTO "notification_deliveries"`); len(deliveries) != 0 {
+		t.Errorf("delivery recorded: %v", deliveries)
+	}
+
+	// In-app notifications still arrive, with and without an entity
+	link := "/app/appointments"
+	if err := CreateNotification(db, 3, "Su cita ha sido confirmada.", "success", &link); err != nil {
+		t.Fatal(err)
+	}
+	entityID := uint(5)
+	if err := CreateNotificationWithMeta(db, 3, "Su cita fue reprogramada.", "info", &link, "appointment", &entityID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if inserts := script.ran(`INSERT INTO "notifications"`); len(inserts) != 2 {
+		t.Errorf("in-app notifications = %v, want 2", inserts)
+	}
+}
+
+func TestEmailOptOutSkipsReminders(t *testing.T) {
+	sent := recordReminders(t)
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	script := reminderScript(now, []time.Duration{30 * time.Minute}, []string{""})
+	script.rows = preferenceRow(emailOff, script.rows)
+	script.affected = func(string) int64 { return 1 }
+
+	if n := sendDueAppointmentReminders(context.Background(), scriptedDB(t, script), now, reminderLeads); n != 0 || len(sent()) != 0 {
+		t.Errorf("sent %d reminders (%v) to a user who turned email off", n, sent())
+	}
+	// The stage is still claimed, so the reminder does not come due again
+	if claims := script.ran(`UPDATE "appointments"`); len(claims) != 1 {
+		t.Errorf("claims = %v", claims)
+	}
+}
+
+func TestNotificationPreferencesDefaultToAllOn(t *testing.T) {
+	script := &scriptedSQL{}
+	db := scriptedDB(t, script)
+	previous := deliverAppointmentConfirmation
+	emailed := 0
+	deliverAppointmentConfirmation = func(models.Appointment, models.User) error { emailed++; return nil }
+	t.Cleanup(func() { deliverAppointmentConfirmation = previous })
+
+	if !sendAppointmentConfirmation(db, models.Appointment{ID: 5}, models.User{ID: 3, Email: "ana@correo.mx"}) || emailed != 1 {
+		t.Errorf("user without preferences not emailed")
+	}
+	if err := CreateNotification(db, 3, "Nueva tarea asignada", "info", nil); err != nil {
+		t.Fatal(err)
+	}
+	if inserts := script.ran(`INSERT INTO "notifications"`); len(inserts) != 1 {
+		t.Errorf("in-app notifications = %v, want 1", inserts)
+	}
+
+	// Turning an event type off silences it on every channel, and only it
+	tasksOff := models.DefaultNotificationPreference(3)
+	tasksOff.Tasks = false
+	if tasksOff.Allows(models.DeliveryChannelInApp, models.NotificationEventTasks) || tasksOff.Allows(models.DeliveryChannelEmail, models.NotificationEventTasks) {
+		t.Errorf("task notifications allowed after opting out")
+	}
+	if !tasksOff.Allows(models.DeliveryChannelInApp, models.NotificationEventCases) || !tasksOff.Allows(models.DeliveryChannelInApp, "") {
+		t.Errorf("other notifications blocked by the task opt-out")
+	}
+}
+
+func TestUpdateNotificationPreferencesKeepsOmittedFields(t *testing.T) {
+	values := make(map[string]driver.Value)
+	script := &scriptedSQL{
+		rows: preferenceRow([]driver.Value{int64(3), true, true, true, false, true, true}, nil),
+		observe: func(query string, args []driver.Value) {
+			if strings.HasPrefix(query, `UPDATE "notification_preferences"`) {
+				for column, value := range statementValues(query, args) {
+					values[column] = value
+				}
+			}
+		},
+		affected: func(string) int64 { return 1 },
+	}
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/notifications/preferences", strings.NewReader(`{"emailEnabled":false}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "3")
+	UpdateNotificationPreferences(scriptedDB(t, script))(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var preference models.NotificationPreference
+	if err := json.Unmarshal(w.Body.Bytes(), &preference); err != nil {
+		t.Fatal(err)
+	}
+	if preference.EmailEnabled || !preference.InAppEnabled || preference.Cases || !preference.Appointments {
+		t.Errorf("preference = %+v", preference)
+	}
+	if values["email_enabled"] != false || values["cases"] != false || values["in_app_enabled"] != true {
+		t.Errorf("saved values = %v", values)
+	}
+}
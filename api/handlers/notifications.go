@@ -107,7 +107,11 @@ func MarkNotificationsAsRead(db *gorm.DB) gin.HandlerFunc {
 
 // CreateNotification creates a new notification for a user
 // This is an internal function used by other handlers
+// Users who turned in-app notifications off are skipped without error
 func CreateNotification(db *gorm.DB, userID uint, message string, notificationType string, link *string) error {
+	if !notificationAllowed(db, userID, models.DeliveryChannelInApp, "") {
+		return nil
+	}
 	notification := models.Notification{
 		UserID:  userID,
 		Message: message,
@@ -1,14 +1,32 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/BryanPMX/CAF/api/interfaces"
+	"github.com/BryanPMX/CAF/api/middleware"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/net/websocket"
 )
 
+// SocketCloseChannel is the Redis pub/sub channel carrying the IDs of users whose sockets must
+// be closed on every replica.
+const SocketCloseChannel = "caf:socket-close"
+
+// socketCloseClient publishes socket closes once StartSocketCloseBroadcast has subscribed.
+var socketCloseClient = struct {
+	mutex  sync.RWMutex
+	client *redis.Client
+}{}
+
 // Simple in-memory subscription registry: userID -> set of connections
 var (
 	UserConnMu sync.RWMutex
@@ -16,8 +34,8 @@ var (
 )
 
 // NotificationsWebSocket handles per-user WebSocket connections.
-// Auth via JWT token passed as query param `token` (stateless).
-func NotificationsWebSocket(jwtSecret string) gin.HandlerFunc {
+// Auth via JWT token passed as query param `token`, whose session must still be active.
+func NotificationsWebSocket(jwtSecret string, sessions interfaces.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Validate JWT from query param
 		tokenStr := c.Query("token")
@@ -40,14 +58,25 @@ func NotificationsWebSocket(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// Service tokens are for API calls, not notification sockets
+		if middleware.IsServiceToken(claims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "service tokens cannot open notification sockets"})
+			return
+		}
+
 		userID, _ := claims["sub"].(string)
 		if userID == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid subject"})
 			return
 		}
 
-		// In stateless JWT system, token validation is sufficient
-		// No additional session validation needed
+		// Revoked or expired sessions cannot open sockets, like with EnhancedJWTAuth
+		if sessions != nil {
+			if _, err := sessions.ValidateSession(c.Request.Context(), tokenStr); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked or expired"})
+				return
+			}
+		}
 
 		handler := websocket.Handler(func(conn *websocket.Conn) {
 			RegisterConn(userID, conn)
@@ -89,6 +118,61 @@ func UnregisterConn(userID string, conn *websocket.Conn) {
 	}
 }
 
+// CloseUserConns closes every open socket of the user, such as when their sessions are revoked:
+// this replica's right away and, once StartSocketCloseBroadcast has run, every other replica's
+// through SocketCloseChannel. Each socket's handler then unregisters it.
+func CloseUserConns(userID uint) {
+	id := strconv.FormatUint(uint64(userID), 10)
+	closeLocalUserConns(id)
+
+	socketCloseClient.mutex.RLock()
+	client := socketCloseClient.client
+	socketCloseClient.mutex.RUnlock()
+	if client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Publish(ctx, SocketCloseChannel, id).Err(); err != nil {
+		log.Printf("WARNING: Failed to publish socket close for user %s: %v", id, err)
+	}
+}
+
+// closeLocalUserConns closes the user's sockets open on this replica.
+func closeLocalUserConns(userID string) {
+	UserConnMu.RLock()
+	defer UserConnMu.RUnlock()
+	for conn := range UserConns[userID] {
+		_ = conn.Close()
+	}
+}
+
+// StartSocketCloseBroadcast subscribes to SocketCloseChannel, closing this replica's sockets of
+// every user published there, and makes CloseUserConns publish. Without redisClient sockets are
+// only closed on the replica revoking the sessions. The subscriber stops when ctx is cancelled.
+func StartSocketCloseBroadcast(ctx context.Context, redisClient *redis.Client) error {
+	if redisClient == nil {
+		return nil
+	}
+	pubsub := redisClient.Subscribe(ctx, SocketCloseChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", SocketCloseChannel, err)
+	}
+
+	socketCloseClient.mutex.Lock()
+	socketCloseClient.client = redisClient
+	socketCloseClient.mutex.Unlock()
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			closeLocalUserConns(msg.Payload)
+		}
+	}()
+	return nil
+}
+
 // SendUserNotification allows other handlers to push a notification to a user.
 func SendUserNotification(userID string, payload any) {
 	UserConnMu.RLock()
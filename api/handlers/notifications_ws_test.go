@@ -0,0 +1,177 @@
+// api/handlers/notifications_ws_test.go
+// Unit tests for notification sockets: tokens of revoked sessions cannot connect, and revoking
+// a user's sessions closes the sockets they already have open, on every replica.
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/net/websocket"
+)
+
+const testSocketSecret = "socket-test-secret"
+
+// revocableSessions validates every token except the revoked ones, and records the users whose
+// sessions were revoked.
+type revocableSessions struct {
+	revoked      map[string]bool
+	revokedUsers []uint
+}
+
+func (r *revocableSessions) CreateSession(context.Context, uint, string, string, string, time.Time) (*models.Session, error) {
+	return &models.Session{}, nil
+}
+
+func (r *revocableSessions) ValidateSession(_ context.Context, token string) (*models.Session, error) {
+	if r.revoked[token] {
+		return nil, errors.New("session revoked or expired")
+	}
+	return &models.Session{ID: 1, IsActive: true}, nil
+}
+
+func (r *revocableSessions) ListUserSessions(context.Context, uint) ([]models.Session, error) {
+	return nil, nil
+}
+
+func (r *revocableSessions) RevokeUserSessions(_ context.Context, userID uint) (int64, error) {
+	r.revokedUsers = append(r.revokedUsers, userID)
+	return 1, nil
+}
+
+// socketToken signs a token for the user.
+func socketToken(t *testing.T, userID string) string {
+	t.Helper()
+	claims := jwt.RegisteredClaims{Subject: userID, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSocketSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// socketServer serves /ws with the sessions.
+func socketServer(t *testing.T, sessions *revocableSessions) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", NotificationsWebSocket(testSocketSecret, sessions))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNotificationsWebSocketRejectsRevokedSession(t *testing.T) {
+	token := socketToken(t, "3")
+	server := socketServer(t, &revocableSessions{revoked: map[string]bool{token: true}})
+
+	response, err := http.Get(server.URL + "/ws?token=" + token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", response.StatusCode)
+	}
+}
+
+func TestCloseUserConnsDropsOpenSockets(t *testing.T) {
+	server := socketServer(t, &revocableSessions{})
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + socketToken(t, "3")
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// The server has registered the socket once it answers
+	if err := websocket.JSON.Send(conn, map[string]string{"type": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	var ack map[string]interface{}
+	if err := websocket.JSON.Receive(conn, &ack); err != nil {
+		t.Fatal(err)
+	}
+
+	CloseUserConns(3)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := websocket.JSON.Receive(conn, &ack); err == nil {
+		t.Errorf("socket still open after revocation, received %v", ack)
+	}
+}
+
+// openUserSocket connects a socket for the user and waits until the server has registered it.
+func openUserSocket(t *testing.T, server *httptest.Server, userID string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + socketToken(t, userID)
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := websocket.JSON.Send(conn, map[string]string{"type": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	var ack map[string]interface{}
+	if err := websocket.JSON.Receive(conn, &ack); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestCloseUserConnsReachesOtherReplicas(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	if err := StartSocketCloseBroadcast(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		socketCloseClient.mutex.Lock()
+		socketCloseClient.client = nil
+		socketCloseClient.mutex.Unlock()
+	})
+	server := socketServer(t, &revocableSessions{})
+	revoked, other := openUserSocket(t, server, "3"), openUserSocket(t, server, "4")
+
+	// Another replica revoked user 3's sessions and published the close
+	otherReplica := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	defer otherReplica.Close()
+	if err := otherReplica.Publish(ctx, SocketCloseChannel, "3").Err(); err != nil {
+		t.Fatal(err)
+	}
+	var ack map[string]interface{}
+	revoked.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := websocket.JSON.Receive(revoked, &ack); err == nil {
+		t.Errorf("socket still open after another replica revoked it, received %v", ack)
+	}
+	if err := websocket.JSON.Send(other, map[string]string{"type": "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	other.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := websocket.JSON.Receive(other, &ack); err != nil {
+		t.Errorf("another user's socket was closed: %v", err)
+	}
+
+	// Closing here is published for the other replicas
+	subscriber := otherReplica.Subscribe(ctx, SocketCloseChannel)
+	defer subscriber.Close()
+	if _, err := subscriber.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+	CloseUserConns(4)
+	msg, err := subscriber.ReceiveMessage(ctx)
+	if err != nil || msg.Payload != "4" {
+		t.Errorf("published %v, %v; want user 4", msg, err)
+	}
+}
@@ -0,0 +1,178 @@
+// api/handlers/office_appointment_categories.go
+// Appointment categories each office offers, so offices are only booked for services they
+// provide. Offices without a list offer every category.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAppointmentCategoryLength matches the size of Appointment.Category.
+const maxAppointmentCategoryLength = 100
+
+// officeAppointmentCategories returns the categories enabled at an office, sorted, or nil when
+// the office has no list and offers every category.
+func officeAppointmentCategories(db *gorm.DB, officeID uint) ([]string, error) {
+	var categories []string
+	if err := db.Model(&models.OfficeAppointmentCategory{}).
+		Where("office_id = ?", officeID).
+		Order("category").
+		Pluck("category", &categories).Error; err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, nil
+	}
+	return categories, nil
+}
+
+// officeOffersAppointmentCategory reports whether category is among enabled, ignoring case.
+// A nil list means the office offers every category.
+func officeOffersAppointmentCategory(enabled []string, category string) bool {
+	if enabled == nil {
+		return true
+	}
+	category = strings.TrimSpace(category)
+	for _, candidate := range enabled {
+		if strings.EqualFold(candidate, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceOfficeAppointmentCategory checks that the office offers the appointment's category.
+// It writes a 422 listing the office's categories and returns false when it does not.
+func enforceOfficeAppointmentCategory(c *gin.Context, db *gorm.DB, officeID uint, category string) bool {
+	enabled, err := officeAppointmentCategories(db, officeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar la categoría de la cita", "message": err.Error()})
+		return false
+	}
+	if officeOffersAppointmentCategory(enabled, category) {
+		return true
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":             "La oficina no ofrece esta categoría de cita",
+		"category":          category,
+		"officeId":          officeID,
+		"enabledCategories": enabled,
+	})
+	return false
+}
+
+// normalizeOfficeAppointmentCategories trims the categories, drops blanks and case-insensitive
+// duplicates (keeping the first spelling) and sorts the rest.
+func normalizeOfficeAppointmentCategories(input []string) ([]string, error) {
+	categories := make([]string, 0, len(input))
+	seen := make(map[string]bool, len(input))
+	for _, category := range input {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[strings.ToLower(category)] {
+			continue
+		}
+		if utf8.RuneCountInString(category) > maxAppointmentCategoryLength {
+			return nil, errors.New("category names are limited to 100 characters")
+		}
+		seen[strings.ToLower(category)] = true
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+// GetOfficeAppointmentCategories returns the categories an office offers; restricted is false,
+// and categories empty, when the office offers every category.
+func GetOfficeAppointmentCategories(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		if err := db.Select("id").First(&models.Office{}, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+		categories, err := officeAppointmentCategories(db, officeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las categorías", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"officeId":   officeID,
+			"restricted": categories != nil,
+			"categories": nonNilCategories(categories),
+		})
+	}
+}
+
+// SetOfficeAppointmentCategories replaces the categories an office offers with
+// {"categories": [...]}. An empty list lifts the restriction.
+func SetOfficeAppointmentCategories(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input struct {
+			Categories *[]string `json:"categories"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || input.Categories == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere la lista 'categories' (vacía para permitir todas)"})
+			return
+		}
+		categories, err := normalizeOfficeAppointmentCategories(*input.Categories)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Categoría inválida", "message": err.Error()})
+			return
+		}
+		if err := db.Select("id").First(&models.Office{}, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+
+		userID := extractUserID(c)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("office_id = ?", officeID).Delete(&models.OfficeAppointmentCategory{}).Error; err != nil {
+				return err
+			}
+			if len(categories) == 0 {
+				return nil
+			}
+			rows := make([]models.OfficeAppointmentCategory, 0, len(categories))
+			for _, category := range categories {
+				rows = append(rows, models.OfficeAppointmentCategory{OfficeID: officeID, Category: category, UpdatedBy: userID})
+			}
+			return tx.Create(&rows).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar las categorías", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "office", officeID, "update", "appointment_categories", map[string]interface{}{
+			"appointmentCategories": categories,
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"officeId":   officeID,
+			"restricted": len(categories) > 0,
+			"categories": categories,
+		})
+	}
+}
+
+// nonNilCategories renders an unrestricted (nil) list as [] in JSON.
+func nonNilCategories(categories []string) []string {
+	if categories == nil {
+		return []string{}
+	}
+	return categories
+}
@@ -0,0 +1,423 @@
+// api/handlers/office_hours.go
+// Office business hours and holidays: appointments can only be booked while the office is
+// open, in its own time zone. Offices without configured hours follow
+// APPOINTMENT_WORKING_HOURS and APPOINTMENT_WORKING_DAYS.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Reasons a slot is closed, returned with 422 responses
+const (
+	officeClosedHoliday      = "holiday"
+	officeClosedDay          = "closed_day"
+	officeClosedOutsideHours = "outside_business_hours"
+)
+
+// maxOfficeHolidayNameChars matches the size of office_holidays.name.
+const maxOfficeHolidayNameChars = 100
+
+// officeOpenHours is an open window as minutes from midnight.
+type officeOpenHours struct {
+	Open  int
+	Close int
+}
+
+func (h officeOpenHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", h.Open/60, h.Open%60, h.Close/60, h.Close%60)
+}
+
+// officeCalendar is when an office takes appointments.
+type officeCalendar struct {
+	Location   *time.Location
+	Configured bool // Hours come from office_business_hours rather than the configured defaults
+	Hours      map[time.Weekday]officeOpenHours
+	Holidays   map[string]string // YYYY-MM-DD to name
+}
+
+// officeClosure explains why a slot cannot be booked; Reason is empty when the office is open.
+type officeClosure struct {
+	Reason  string
+	Date    string
+	Holiday string
+	Hours   *officeOpenHours // The office's hours that day, nil when closed all day
+}
+
+// parseClock parses HH:MM into minutes from midnight.
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// defaultOfficeHours lays APPOINTMENT_WORKING_HOURS over APPOINTMENT_WORKING_DAYS.
+func defaultOfficeHours() map[time.Weekday]officeOpenHours {
+	start, end := config.AppointmentWorkingHours()
+	hours := make(map[time.Weekday]officeOpenHours)
+	for day := range config.AppointmentWorkingDays() {
+		hours[day] = officeOpenHours{Open: start, Close: end}
+	}
+	return hours
+}
+
+// loadOfficeCalendar returns the hours and holidays of an office in its time zone. An office
+// that does not exist gets the defaults in the server's zone.
+func loadOfficeCalendar(db *gorm.DB, officeID uint) (officeCalendar, error) {
+	var office models.Office
+	if err := db.Select("id", "timezone").First(&office, officeID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return officeCalendar{}, err
+	}
+	office.ID = officeID
+	return officeCalendarFor(db, office)
+}
+
+// officeCalendarFor is loadOfficeCalendar for an office already loaded with its timezone.
+func officeCalendarFor(db *gorm.DB, office models.Office) (officeCalendar, error) {
+	calendar := officeCalendar{Location: officeLocation(office), Hours: defaultOfficeHours(), Holidays: map[string]string{}}
+	if office.ID == 0 {
+		return calendar, nil
+	}
+
+	var hours []models.OfficeBusinessHours
+	if err := db.Where("office_id = ?", office.ID).Find(&hours).Error; err != nil {
+		return calendar, err
+	}
+	if len(hours) > 0 {
+		calendar.Configured = true
+		calendar.Hours = make(map[time.Weekday]officeOpenHours, len(hours))
+		for _, row := range hours {
+			open, openErr := parseClock(row.OpenTime)
+			closing, closeErr := parseClock(row.CloseTime)
+			if openErr == nil && closeErr == nil && open < closing {
+				calendar.Hours[time.Weekday(row.Weekday)] = officeOpenHours{Open: open, Close: closing}
+			}
+		}
+	}
+
+	var holidays []models.OfficeHoliday
+	if err := db.Where("office_id = ?", office.ID).Find(&holidays).Error; err != nil {
+		return calendar, err
+	}
+	for _, holiday := range holidays {
+		calendar.Holidays[holiday.Date] = holiday.Name
+	}
+	return calendar, nil
+}
+
+// dayHours returns the office's open window on day (in the office's zone), and the holiday
+// name when day is one. ok is false when the office is closed all day.
+func (cal officeCalendar) dayHours(day time.Time) (hours officeOpenHours, holiday string, ok bool) {
+	local := day.In(cal.Location)
+	if name, isHoliday := cal.Holidays[local.Format("2006-01-02")]; isHoliday {
+		return officeOpenHours{}, name, false
+	}
+	hours, ok = cal.Hours[local.Weekday()]
+	return hours, "", ok
+}
+
+// closure reports whether [start, end) falls within one day's open window.
+func (cal officeCalendar) closure(start, end time.Time) officeClosure {
+	localStart, localEnd := start.In(cal.Location), end.In(cal.Location)
+	result := officeClosure{Date: localStart.Format("2006-01-02")}
+	hours, holiday, open := cal.dayHours(localStart)
+	switch {
+	case holiday != "":
+		result.Reason, result.Holiday = officeClosedHoliday, holiday
+		return result
+	case !open:
+		result.Reason = officeClosedDay
+		return result
+	}
+	result.Hours = &hours
+
+	startMinute := localStart.Hour()*60 + localStart.Minute()
+	endMinute := localEnd.Hour()*60 + localEnd.Minute()
+	if localEnd.Second() > 0 || localEnd.Nanosecond() > 0 {
+		endMinute++
+	}
+	if localEnd.Format("2006-01-02") != result.Date || startMinute < hours.Open || endMinute > hours.Close {
+		result.Reason = officeClosedOutsideHours
+	}
+	return result
+}
+
+// allow writes a 422 with the reason and returns false when the office is closed for any
+// part of [start, end).
+func (cal officeCalendar) allow(c *gin.Context, officeID uint, start, end time.Time) bool {
+	closure := cal.closure(start, end)
+	if closure.Reason == "" {
+		return true
+	}
+	message := "La oficina no atiende ese día"
+	switch closure.Reason {
+	case officeClosedHoliday:
+		message = "La oficina está cerrada por día festivo: " + closure.Holiday
+	case officeClosedOutsideHours:
+		message = "El horario está fuera del horario de atención de la oficina (" + closure.Hours.String() + ")"
+	}
+	body := gin.H{
+		"error":    message,
+		"reason":   closure.Reason,
+		"officeId": officeID,
+		"date":     closure.Date,
+		"timezone": cal.Location.String(),
+	}
+	if closure.Holiday != "" {
+		body["holiday"] = closure.Holiday
+	}
+	if closure.Hours != nil {
+		body["businessHours"] = closure.Hours.String()
+	}
+	c.JSON(http.StatusUnprocessableEntity, body)
+	return false
+}
+
+// enforceOfficeOpen checks that the office is open for the whole proposed appointment. It
+// writes a 422 with the reason (holiday, closed_day or outside_business_hours) and returns
+// false when it is not. Appointments without an office are not checked.
+func enforceOfficeOpen(c *gin.Context, db *gorm.DB, officeID uint, start, end time.Time) bool {
+	if officeID == 0 {
+		return true
+	}
+	calendar, err := loadOfficeCalendar(db, officeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al validar el horario de la oficina", "message": err.Error()})
+		return false
+	}
+	return calendar.allow(c, officeID, start, end)
+}
+
+// officeBusinessHoursInput is one weekday of a PUT /offices/:id/business-hours body.
+type officeBusinessHoursInput struct {
+	Weekday int    `json:"weekday"`
+	Open    string `json:"open"`
+	Close   string `json:"close"`
+}
+
+// officeBusinessDay is one open weekday in responses.
+type officeBusinessDay struct {
+	Weekday int    `json:"weekday"`
+	Open    string `json:"open"`
+	Close   string `json:"close"`
+}
+
+// officeHoursResponse renders the effective hours of an office with its holidays.
+func officeHoursResponse(officeID uint, calendar officeCalendar, holidays []models.OfficeHoliday) gin.H {
+	days := make([]officeBusinessDay, 0, len(calendar.Hours))
+	for weekday, hours := range calendar.Hours {
+		open, closing, _ := strings.Cut(hours.String(), "-")
+		days = append(days, officeBusinessDay{Weekday: int(weekday), Open: open, Close: closing})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Weekday < days[j].Weekday })
+	return gin.H{
+		"officeId":      officeID,
+		"timezone":      calendar.Location.String(),
+		"configured":    calendar.Configured,
+		"businessHours": days,
+		"holidays":      holidays,
+	}
+}
+
+// officeHolidays lists an office's holidays by date.
+func officeHolidays(db *gorm.DB, officeID uint) ([]models.OfficeHoliday, error) {
+	holidays := make([]models.OfficeHoliday, 0)
+	err := db.Where("office_id = ?", officeID).Order("date").Find(&holidays).Error
+	return holidays, err
+}
+
+// GetOfficeBusinessHours returns an office's effective business hours (configured is false
+// while it follows the defaults) and its holidays.
+func GetOfficeBusinessHours(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var office models.Office
+		if err := db.Select("id", "timezone").First(&office, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+		calendar, err := officeCalendarFor(db, office)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el horario", "message": err.Error()})
+			return
+		}
+		holidays, err := officeHolidays(db, officeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los días festivos", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, officeHoursResponse(officeID, calendar, holidays))
+	}
+}
+
+// SetOfficeBusinessHours replaces an office's hours with {"businessHours": [{"weekday": 1,
+// "open": "09:00", "close": "18:00"}, ...]}. Weekdays left out are closed; an empty list
+// returns the office to the defaults.
+func SetOfficeBusinessHours(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input struct {
+			BusinessHours *[]officeBusinessHoursInput `json:"businessHours"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || input.BusinessHours == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere la lista 'businessHours' (vacía para usar el horario predeterminado)"})
+			return
+		}
+
+		userID := extractUserID(c)
+		rows := make([]models.OfficeBusinessHours, 0, len(*input.BusinessHours))
+		seen := make(map[int]bool)
+		for _, day := range *input.BusinessHours {
+			open, openErr := parseClock(day.Open)
+			closing, closeErr := parseClock(day.Close)
+			switch {
+			case day.Weekday < 0 || day.Weekday > 6:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "weekday debe estar entre 0 (domingo) y 6 (sábado)", "weekday": day.Weekday})
+				return
+			case seen[day.Weekday]:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Cada día de la semana puede aparecer una sola vez", "weekday": day.Weekday})
+				return
+			case openErr != nil || closeErr != nil || open >= closing:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "open y close deben tener el formato HH:MM y open debe ser anterior a close", "weekday": day.Weekday})
+				return
+			}
+			seen[day.Weekday] = true
+			hours := officeOpenHours{Open: open, Close: closing}
+			openText, closeText, _ := strings.Cut(hours.String(), "-")
+			rows = append(rows, models.OfficeBusinessHours{OfficeID: officeID, Weekday: day.Weekday, OpenTime: openText, CloseTime: closeText, UpdatedBy: userID})
+		}
+
+		var office models.Office
+		if err := db.Select("id", "timezone").First(&office, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("office_id = ?", officeID).Delete(&models.OfficeBusinessHours{}).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.Create(&rows).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar el horario", "message": err.Error()})
+			return
+		}
+
+		calendar, err := officeCalendarFor(db, office)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el horario", "message": err.Error()})
+			return
+		}
+		holidays, err := officeHolidays(db, officeID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los días festivos", "message": err.Error()})
+			return
+		}
+		response := officeHoursResponse(officeID, calendar, holidays)
+		recordAuditLog(db, c, "office", officeID, "update", "business_hours", map[string]interface{}{
+			"businessHours": response["businessHours"],
+		})
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AddOfficeHoliday closes an office on {"date": "2025-09-16", "name": "Día de la Independencia"}.
+// Appointments already booked that day are left as they are.
+func AddOfficeHoliday(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input struct {
+			Date string `json:"date" binding:"required"`
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Se requieren 'date' (AAAA-MM-DD) y 'name'"})
+			return
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(input.Date))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida, use AAAA-MM-DD"})
+			return
+		}
+		name := strings.TrimSpace(input.Name)
+		if name == "" || len([]rune(name)) > maxOfficeHolidayNameChars {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El nombre debe tener entre 1 y 100 caracteres"})
+			return
+		}
+		if err := db.Select("id").First(&models.Office{}, officeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+			return
+		}
+
+		holiday := models.OfficeHoliday{OfficeID: officeID, Date: date.Format("2006-01-02"), Name: name, UpdatedBy: extractUserID(c)}
+		var existing int64
+		db.Model(&models.OfficeHoliday{}).Where("office_id = ? AND date = ?", officeID, holiday.Date).Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "La oficina ya tiene un día festivo en esa fecha", "date": holiday.Date})
+			return
+		}
+		if err := db.Create(&holiday).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar el día festivo", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "office", officeID, "update", "holiday_added", map[string]interface{}{
+			"date": holiday.Date,
+			"name": holiday.Name,
+		})
+		c.JSON(http.StatusCreated, holiday)
+	}
+}
+
+// DeleteOfficeHoliday reopens an office on a holiday (DELETE /offices/:id/holidays/:holidayId).
+func DeleteOfficeHoliday(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		holidayID, err := strconv.ParseUint(c.Param("holidayId"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de día festivo inválido"})
+			return
+		}
+		var holiday models.OfficeHoliday
+		if err := db.Where("id = ? AND office_id = ?", holidayID, officeID).First(&holiday).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Día festivo no encontrado"})
+			return
+		}
+		if err := db.Delete(&holiday).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el día festivo", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "office", officeID, "update", "holiday_removed", map[string]interface{}{
+			"date": holiday.Date,
+			"name": holiday.Name,
+		})
+		c.JSON(http.StatusOK, gin.H{"message": "Día festivo eliminado exitosamente"})
+	}
+}
@@ -0,0 +1,175 @@
+// api/handlers/office_hours_test.go
+// Unit tests for office business hours and holidays: which slots are closed and why, and
+// bookings rejected with 422 on a holiday.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOfficeCalendarClosure(t *testing.T) {
+	loc, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	calendar := officeCalendar{
+		Location: loc,
+		Hours: map[time.Weekday]officeOpenHours{
+			time.Monday: {9 * 60, 18 * 60}, time.Tuesday: {9 * 60, 18 * 60}, time.Wednesday: {9 * 60, 18 * 60},
+			time.Thursday: {9 * 60, 18 * 60}, time.Friday: {9 * 60, 14 * 60},
+		},
+		Holidays: map[string]string{"2025-09-16": "Día de la Independencia"},
+	}
+	at := func(clock string) time.Time {
+		parsed, _ := time.ParseInLocation("2006-01-02 15:04", clock, loc)
+		return parsed.UTC() // Stored times are UTC; the calendar reads them in the office's zone
+	}
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		reason string
+	}{
+		{"open", "2025-09-17 10:00", "2025-09-17 11:00", ""},
+		{"first and last minute", "2025-09-17 09:00", "2025-09-17 18:00", ""},
+		{"holiday", "2025-09-16 10:00", "2025-09-16 11:00", officeClosedHoliday},
+		{"saturday", "2025-09-20 10:00", "2025-09-20 11:00", officeClosedDay},
+		{"before opening", "2025-09-17 08:30", "2025-09-17 09:30", officeClosedOutsideHours},
+		{"past closing", "2025-09-17 17:30", "2025-09-17 18:30", officeClosedOutsideHours},
+		{"short friday", "2025-09-19 13:30", "2025-09-19 14:30", officeClosedOutsideHours},
+		{"over midnight", "2025-09-17 17:00", "2025-09-18 09:30", officeClosedOutsideHours},
+	}
+	for _, tt := range tests {
+		closure := calendar.closure(at(tt.start), at(tt.end))
+		if closure.Reason != tt.reason {
+			t.Errorf("%s: reason = %q, want %q", tt.name, closure.Reason, tt.reason)
+		}
+		if tt.reason == officeClosedHoliday && closure.Holiday != "Día de la Independencia" {
+			t.Errorf("%s: holiday = %q", tt.name, closure.Holiday)
+		}
+	}
+}
+
+// officeHoursScript serves case 7 at office 2 (Mexico City), open Monday to Friday 09:00-18:00
+// and closed on 2025-09-16.
+func officeHoursScript() *scriptedSQL {
+	created := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "cases"`):
+				return []string{"id", "client_id", "office_id", "category", "title", "created_at"}, [][]driver.Value{{int64(7), int64(5), int64(2), "Familiar", "Divorcio", created}}
+			case strings.Contains(query, `FROM "offices"`):
+				return []string{"id", "name", "timezone"}, [][]driver.Value{{int64(2), "Centro", "America/Mexico_City"}}
+			case strings.Contains(query, `FROM "office_business_hours"`):
+				rows := make([][]driver.Value, 0, 5)
+				for weekday := 1; weekday <= 5; weekday++ {
+					rows = append(rows, []driver.Value{int64(weekday), int64(2), int64(weekday), "09:00", "18:00"})
+				}
+				return []string{"id", "office_id", "weekday", "open_time", "close_time"}, rows
+			case strings.Contains(query, `FROM "office_holidays"`):
+				return []string{"id", "office_id", "date", "name"}, [][]driver.Value{{int64(1), int64(2), "2025-09-16", "Día de la Independencia"}}
+			case strings.HasPrefix(query, `INSERT INTO "appointments"`):
+				return []string{"id"}, [][]driver.Value{{int64(40)}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// createAppointmentAt runs CreateAppointmentEnhanced as an admin for case 7 between start and
+// end (Mexico City time).
+func createAppointmentAt(t *testing.T, script *scriptedSQL, start, end string) *httptest.ResponseRecorder {
+	t.Helper()
+	t.Setenv("APPOINTMENT_SLOT_MINUTES", "15")
+	t.Setenv("APPOINTMENT_OFFICE_BUFFER_MINUTES", "0")
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	// The fixture dates have passed, which admins may override
+	body := `{"caseId":7,"staffId":4,"title":"Audiencia","category":"Familiar","department":"Familiar","overridePast":true,` +
+		`"startTime":"` + start + `-06:00","endTime":"` + end + `-06:00"}`
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/appointments", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	c.Set("currentUser", models.User{ID: 1, Role: "admin"})
+	CreateAppointmentEnhanced(scriptedDB(t, script))(c)
+	return w
+}
+
+func TestCreateAppointmentOnHolidayIsRejected(t *testing.T) {
+	if _, err := time.LoadLocation("America/Mexico_City"); err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	script := officeHoursScript()
+	w := createAppointmentAt(t, script, "2025-09-16T10:00:00", "2025-09-16T11:00:00")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["reason"] != "holiday" || body["holiday"] != "Día de la Independencia" || body["date"] != "2025-09-16" {
+		t.Errorf("body = %v", body)
+	}
+	if inserts := script.ran(`INSERT INTO "appointments"`); len(inserts) != 0 {
+		t.Errorf("appointment created on a holiday: %v", inserts)
+	}
+}
+
+func TestCreateAppointmentOutsideBusinessHours(t *testing.T) {
+	if _, err := time.LoadLocation("America/Mexico_City"); err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	tests := []struct {
+		start  string
+		end    string
+		reason string
+	}{
+		{"2025-09-20T10:00:00", "2025-09-20T11:00:00", "closed_day"},
+		{"2025-09-17T17:30:00", "2025-09-17T18:30:00", "outside_business_hours"},
+	}
+	for _, tt := range tests {
+		script := officeHoursScript()
+		w := createAppointmentAt(t, script, tt.start, tt.end)
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"reason":"`+tt.reason+`"`) {
+			t.Errorf("%s: status %d, body %s", tt.start, w.Code, w.Body.String())
+		}
+	}
+
+	// The next day is open: the appointment is created
+	script := officeHoursScript()
+	w := createAppointmentAt(t, script, "2025-09-17T10:00:00", "2025-09-17T11:00:00")
+	if w.Code == http.StatusUnprocessableEntity || len(script.ran(`INSERT INTO "appointments"`)) != 1 {
+		t.Errorf("open day: status %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetStaffAvailabilityOnHoliday(t *testing.T) {
+	if _, err := time.LoadLocation("America/Mexico_City"); err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	script := officeHoursScript()
+	base := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, `FROM "users"`) {
+			return []string{"id", "role", "office_id"}, [][]driver.Value{{int64(4), "lawyer", int64(2)}}
+		}
+		return base(query, args)
+	}
+	code, body := getAvailability(t, scriptedDB(t, script), "staffId=4&date=2025-09-16")
+	if code != http.StatusOK || body["workingDay"] != false || body["holiday"] != "Día de la Independencia" || len(slotStarts(t, body)) != 0 {
+		t.Errorf("holiday: status %d, body %v", code, body)
+	}
+}
@@ -0,0 +1,402 @@
+// api/handlers/office_monthly_report.go
+// Office monthly report: case and appointment statistics, staff workload and
+// double-booked staff for one office and month, as a PDF or a spreadsheet (CSV).
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/pdf"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// monthlyReportLimit caps the conflicts listed in one report.
+const monthlyReportLimit = 200
+
+// monthlyCount is one labelled count in the report.
+type monthlyCount struct {
+	Label string
+	Count int64
+}
+
+// monthlyStaffWorkload is one staff member's load for the month.
+type monthlyStaffWorkload struct {
+	StaffID               uint
+	Name                  string
+	Role                  string
+	OpenCases             int64
+	Appointments          int64
+	CompletedAppointments int64
+}
+
+// monthlyConflict is a pair of overlapping appointments for the same staff member.
+type monthlyConflict struct {
+	StaffName   string
+	FirstID     uint
+	FirstTitle  string
+	FirstStart  time.Time
+	SecondID    uint
+	SecondTitle string
+	SecondStart time.Time
+}
+
+// officeMonthlyReport is everything the PDF and CSV renderings show.
+type officeMonthlyReport struct {
+	Office               models.Office
+	From, To             time.Time
+	NewCases             int64
+	ClosedCases          int64
+	OpenCases            int64
+	NewCasesByCategory   []monthlyCount
+	OpenCasesByStage     []monthlyCount
+	Appointments         int64
+	AppointmentsByStatus []monthlyCount
+	Staff                []monthlyStaffWorkload
+	Conflicts            []monthlyConflict
+	ConflictsTruncated   bool
+	GeneratedAt          time.Time
+}
+
+// GetOfficeMonthlyReport downloads the office's monthly report for ?month=YYYY-MM (default: the
+// previous month) as ?format=pdf (default) or csv. Office managers get their own office; admins
+// must pass ?officeId=. Each download is recorded as a data_access audit entry.
+func GetOfficeMonthlyReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+		if v := c.Query("month"); v != "" {
+			parsed, err := time.ParseInLocation("2006-01", v, now.Location())
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Mes inválido, use YYYY-MM"})
+				return
+			}
+			from = parsed
+		}
+		to := from.AddDate(0, 1, 0)
+
+		format := strings.ToLower(c.DefaultQuery("format", "pdf"))
+		if format != "pdf" && format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato no válido, use pdf o csv"})
+			return
+		}
+
+		var officeID uint
+		if config.CanAccessAllOffices(c.GetString("userRole")) {
+			parsed, err := strconv.ParseUint(c.Query("officeId"), 10, 32)
+			if err != nil || parsed == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "officeId es requerido"})
+				return
+			}
+			officeID = uint(parsed)
+		} else {
+			if v, ok := c.Get("officeScopeID"); ok {
+				officeID, _ = v.(uint)
+			}
+			if officeID == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acceso denegado: no tiene una oficina asignada"})
+				return
+			}
+		}
+
+		report := officeMonthlyReport{From: from, To: to, GeneratedAt: now}
+		if err := db.First(&report.Office, officeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Oficina no encontrada"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la oficina", "message": err.Error()})
+			return
+		}
+		if err := collectOfficeMonthlyReport(db, &report); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el reporte mensual", "message": err.Error()})
+			return
+		}
+
+		recordDataAccessAuditLog(db, c, "office", officeID, "export", "monthly_report", map[string]interface{}{
+			"month":        from.Format("2006-01"),
+			"format":       format,
+			"newCases":     report.NewCases,
+			"appointments": report.Appointments,
+			"conflicts":    len(report.Conflicts),
+		})
+
+		filename := fmt.Sprintf("reporte-mensual-oficina-%d-%s", officeID, from.Format("2006-01"))
+		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+		if format == "csv" {
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", filename))
+			c.Data(http.StatusOK, "text/csv; charset=utf-8", renderOfficeMonthlyCSV(report))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", filename))
+		c.Data(http.StatusOK, "application/pdf", renderOfficeMonthlyPDF(report))
+	}
+}
+
+// collectOfficeMonthlyReport fills report with the office's figures for [report.From, report.To).
+func collectOfficeMonthlyReport(db *gorm.DB, report *officeMonthlyReport) error {
+	officeID := report.Office.ID
+	cases := func() *gorm.DB {
+		return db.Model(&models.Case{}).Where("office_id = ? AND deleted_at IS NULL", officeID)
+	}
+	appointments := func() *gorm.DB {
+		return db.Model(&models.Appointment{}).
+			Where("office_id = ? AND start_time >= ? AND start_time < ?", officeID, report.From, report.To)
+	}
+	closedStatuses := []string{"closed", "completed", "cancelled"}
+
+	if err := cases().Where("created_at >= ? AND created_at < ?", report.From, report.To).Count(&report.NewCases).Error; err != nil {
+		return err
+	}
+	if err := db.Table("case_status_history").
+		Where("office_id = ? AND changed_at >= ? AND changed_at < ?", officeID, report.From, report.To).
+		Where("to_status IN ? AND (from_status IS NULL OR from_status NOT IN ?)", closedStatuses, closedStatuses).
+		Distinct("case_id").
+		Count(&report.ClosedCases).Error; err != nil {
+		return err
+	}
+	if err := cases().Where("status NOT IN ? AND is_archived = ?", closedStatuses, false).Count(&report.OpenCases).Error; err != nil {
+		return err
+	}
+	if err := cases().Select("category AS label, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", report.From, report.To).
+		Group("category").Order("count DESC").
+		Scan(&report.NewCasesByCategory).Error; err != nil {
+		return err
+	}
+	if err := cases().Select("current_stage AS label, COUNT(*) AS count").
+		Where("status NOT IN ? AND is_archived = ?", closedStatuses, false).
+		Group("current_stage").Order("count DESC").
+		Scan(&report.OpenCasesByStage).Error; err != nil {
+		return err
+	}
+	for i := range report.OpenCasesByStage {
+		report.OpenCasesByStage[i].Label = config.GetStageLabel(report.OpenCasesByStage[i].Label)
+	}
+
+	if err := appointments().Count(&report.Appointments).Error; err != nil {
+		return err
+	}
+	if err := appointments().Select("status AS label, COUNT(*) AS count").
+		Group("status").Order("count DESC").
+		Scan(&report.AppointmentsByStatus).Error; err != nil {
+		return err
+	}
+	for i := range report.AppointmentsByStatus {
+		if label, ok := config.AppointmentStatusLabels[report.AppointmentsByStatus[i].Label]; ok {
+			report.AppointmentsByStatus[i].Label = label
+		}
+	}
+
+	var staff []models.User
+	if err := db.Select("id", "first_name", "last_name", "role").
+		Where("office_id = ? AND deleted_at IS NULL AND role IN ?", officeID,
+			[]string{config.RoleOfficeManager, config.RoleLawyer, config.RolePsychologist, config.RoleReceptionist}).
+		Order("last_name, first_name").
+		Find(&staff).Error; err != nil {
+		return err
+	}
+	for _, member := range staff {
+		workload := monthlyStaffWorkload{
+			StaffID: member.ID,
+			Name:    strings.TrimSpace(member.FirstName + " " + member.LastName),
+			Role:    member.Role,
+		}
+		if info, err := config.GetRoleInfo(member.Role); err == nil {
+			workload.Role = info.SpanishName
+		}
+		if err := cases().Where("primary_staff_id = ? AND status NOT IN ? AND is_archived = ?", member.ID, closedStatuses, false).
+			Count(&workload.OpenCases).Error; err != nil {
+			return err
+		}
+		if err := appointments().Where("staff_id = ?", member.ID).Count(&workload.Appointments).Error; err != nil {
+			return err
+		}
+		if err := appointments().Where("staff_id = ? AND status = ?", member.ID, config.StatusCompleted).
+			Count(&workload.CompletedAppointments).Error; err != nil {
+			return err
+		}
+		report.Staff = append(report.Staff, workload)
+	}
+	sort.SliceStable(report.Staff, func(i, j int) bool {
+		return report.Staff[i].Appointments > report.Staff[j].Appointments
+	})
+
+	// Overlapping, non-cancelled appointments of the same staff member
+	if err := db.Raw(`SELECT TRIM(u.first_name || ' ' || u.last_name) AS staff_name,
+			a.id AS first_id, a.title AS first_title, a.start_time AS first_start,
+			b.id AS second_id, b.title AS second_title, b.start_time AS second_start
+		FROM appointments a
+		JOIN appointments b ON b.staff_id = a.staff_id AND b.id > a.id
+			AND b.start_time < a.end_time AND a.start_time < b.end_time
+			AND b.deleted_at IS NULL AND b.status <> ?
+		JOIN users u ON u.id = a.staff_id
+		WHERE a.office_id = ? AND a.start_time >= ? AND a.start_time < ?
+			AND a.deleted_at IS NULL AND a.status <> ?
+		ORDER BY a.start_time
+		LIMIT ?`, config.StatusCancelled, officeID, report.From, report.To, config.StatusCancelled, monthlyReportLimit+1).
+		Scan(&report.Conflicts).Error; err != nil {
+		return err
+	}
+	if len(report.Conflicts) > monthlyReportLimit {
+		report.Conflicts = report.Conflicts[:monthlyReportLimit]
+		report.ConflictsTruncated = true
+	}
+	return nil
+}
+
+// renderOfficeMonthlyPDF lays the report out with the CAF letterhead.
+func renderOfficeMonthlyPDF(report officeMonthlyReport) []byte {
+	doc := pdf.NewWithHeader(func(d *pdf.Document) {
+		writeCAFLetterhead(d, &report.Office)
+	})
+
+	doc.Line(16, true, "REPORTE MENSUAL DE OFICINA")
+	doc.Line(10, false, "Periodo: "+report.From.Format("01/2006"))
+	doc.Line(10, false, "Generado: "+report.GeneratedAt.Format("02/01/2006 15:04"))
+	doc.Space(10)
+
+	doc.Line(12, true, "Casos")
+	summary := []float64{0, 300}
+	doc.Columns(10, false, summary, []string{"Casos nuevos", strconv.FormatInt(report.NewCases, 10)})
+	doc.Columns(10, false, summary, []string{"Casos cerrados", strconv.FormatInt(report.ClosedCases, 10)})
+	doc.Columns(10, false, summary, []string{"Casos abiertos al cierre", strconv.FormatInt(report.OpenCases, 10)})
+	writeMonthlyCounts(doc, "Casos nuevos por departamento", report.NewCasesByCategory)
+	writeMonthlyCounts(doc, "Casos abiertos por etapa", report.OpenCasesByStage)
+	doc.Space(10)
+
+	doc.Line(12, true, "Citas")
+	doc.Columns(10, false, summary, []string{"Citas en el mes", strconv.FormatInt(report.Appointments, 10)})
+	writeMonthlyCounts(doc, "Citas por estado", report.AppointmentsByStatus)
+	doc.Space(10)
+
+	staffColumns := []float64{0, 190, 320, 400, 470}
+	doc.Line(12, true, "Carga de trabajo del personal")
+	doc.Columns(9, true, staffColumns, []string{"Nombre", "Rol", "Casos abiertos", "Citas", "Completadas"})
+	doc.Rule()
+	if len(report.Staff) == 0 {
+		doc.Line(10, false, "No hay personal asignado a esta oficina.")
+	}
+	for _, staff := range report.Staff {
+		doc.Columns(9, false, staffColumns, []string{
+			staff.Name,
+			staff.Role,
+			strconv.FormatInt(staff.OpenCases, 10),
+			strconv.FormatInt(staff.Appointments, 10),
+			strconv.FormatInt(staff.CompletedAppointments, 10),
+		})
+	}
+	doc.Space(10)
+
+	conflictColumns := []float64{0, 130, 300}
+	doc.Line(12, true, "Conflictos de agenda")
+	if len(report.Conflicts) == 0 {
+		doc.Line(10, false, "No se detectaron citas traslapadas.")
+	} else {
+		doc.Columns(9, true, conflictColumns, []string{"Personal", "Cita", "Traslapa con"})
+		doc.Rule()
+	}
+	for _, conflict := range report.Conflicts {
+		doc.Columns(9, false, conflictColumns, []string{
+			conflict.StaffName,
+			fmt.Sprintf("#%d %s", conflict.FirstID, conflict.FirstStart.Format("02/01 15:04")),
+			fmt.Sprintf("#%d %s", conflict.SecondID, conflict.SecondStart.Format("02/01 15:04")),
+		})
+	}
+	if report.ConflictsTruncated {
+		doc.Line(9, false, fmt.Sprintf("Se muestran los primeros %d conflictos.", monthlyReportLimit))
+	}
+
+	return doc.Bytes()
+}
+
+func writeMonthlyCounts(doc *pdf.Document, title string, counts []monthlyCount) {
+	if len(counts) == 0 {
+		return
+	}
+	doc.Space(4)
+	doc.Line(10, true, title)
+	for _, count := range counts {
+		doc.Columns(10, false, []float64{20, 300}, []string{monthlyLabel(count.Label), strconv.FormatInt(count.Count, 10)})
+	}
+}
+
+func monthlyLabel(label string) string {
+	if label == "" {
+		return "Sin especificar"
+	}
+	return label
+}
+
+// renderOfficeMonthlyCSV writes the report as sections of a single CSV sheet.
+func renderOfficeMonthlyCSV(report officeMonthlyReport) []byte {
+	var out bytes.Buffer
+	out.WriteString("\xef\xbb\xbf") // UTF-8 BOM so spreadsheet apps keep accents
+	writer := csv.NewWriter(&out)
+
+	writer.Write([]string{"Reporte mensual de oficina", report.Office.Name})
+	writer.Write([]string{"Periodo", report.From.Format("2006-01")})
+	writer.Write([]string{"Generado", report.GeneratedAt.Format("2006-01-02 15:04")})
+	writer.Write(nil)
+
+	writer.Write([]string{"Métrica", "Valor"})
+	writer.Write([]string{"Casos nuevos", strconv.FormatInt(report.NewCases, 10)})
+	writer.Write([]string{"Casos cerrados", strconv.FormatInt(report.ClosedCases, 10)})
+	writer.Write([]string{"Casos abiertos al cierre", strconv.FormatInt(report.OpenCases, 10)})
+	writer.Write([]string{"Citas en el mes", strconv.FormatInt(report.Appointments, 10)})
+	writer.Write(nil)
+
+	for _, section := range []struct {
+		title  string
+		counts []monthlyCount
+	}{
+		{"Casos nuevos por departamento", report.NewCasesByCategory},
+		{"Casos abiertos por etapa", report.OpenCasesByStage},
+		{"Citas por estado", report.AppointmentsByStatus},
+	} {
+		writer.Write([]string{section.title, "Total"})
+		for _, count := range section.counts {
+			writer.Write([]string{monthlyLabel(count.Label), strconv.FormatInt(count.Count, 10)})
+		}
+		writer.Write(nil)
+	}
+
+	writer.Write([]string{"ID Personal", "Nombre", "Rol", "Casos abiertos", "Citas", "Citas completadas"})
+	for _, staff := range report.Staff {
+		writer.Write([]string{
+			strconv.FormatUint(uint64(staff.StaffID), 10),
+			staff.Name,
+			staff.Role,
+			strconv.FormatInt(staff.OpenCases, 10),
+			strconv.FormatInt(staff.Appointments, 10),
+			strconv.FormatInt(staff.CompletedAppointments, 10),
+		})
+	}
+	writer.Write(nil)
+
+	writer.Write([]string{"Personal", "Cita", "Título", "Inicio", "Traslapa con", "Título", "Inicio"})
+	for _, conflict := range report.Conflicts {
+		writer.Write([]string{
+			conflict.StaffName,
+			strconv.FormatUint(uint64(conflict.FirstID), 10),
+			conflict.FirstTitle,
+			conflict.FirstStart.Format("2006-01-02 15:04"),
+			strconv.FormatUint(uint64(conflict.SecondID), 10),
+			conflict.SecondTitle,
+			conflict.SecondStart.Format("2006-01-02 15:04"),
+		})
+	}
+
+	writer.Flush()
+	return out.Bytes()
+}
@@ -0,0 +1,214 @@
+// api/handlers/office_schedule.go
+// Office-wide staff schedule for managers, as JSON grouped by staff or as an ICS feed.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/ics"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxScheduleRangeDays bounds the date range of a single schedule request.
+const maxScheduleRangeDays = 92
+
+// resolveOfficeScope returns the office scope of the request, or for users who can access all
+// offices the officeId query parameter; 0 when there is neither.
+func resolveOfficeScope(c *gin.Context) uint {
+	var officeID uint
+	if scope, exists := c.Get("officeScopeID"); exists {
+		officeID, _ = scope.(uint)
+	}
+	if officeID == 0 && config.CanAccessAllOffices(c.GetString("userRole")) {
+		if parsed, err := strconv.ParseUint(c.Query("officeId"), 10, 32); err == nil {
+			officeID = uint(parsed)
+		}
+	}
+	return officeID
+}
+
+// resolveOfficeRange reads the office scope and the from/to dates (YYYY-MM-DD, to inclusive) from the request.
+// Dates are midnights in the office's time zone; without from/to the range runs from defaultFromDays to
+// defaultToDays days after today there. It writes the error response itself and returns ok=false on failure.
+func resolveOfficeRange(c *gin.Context, db *gorm.DB, defaultFromDays, defaultToDays int) (officeID uint, from, to time.Time, ok bool) {
+	officeID = resolveOfficeScope(c)
+	if officeID == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Se requiere una oficina asignada para ver el calendario"})
+		return 0, from, to, false
+	}
+	var office models.Office
+	if err := db.Select("id", "timezone").First(&office, officeID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la oficina", "message": err.Error()})
+		return 0, from, to, false
+	}
+	loc := officeLocation(office)
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from, to = today.AddDate(0, 0, defaultFromDays), today.AddDate(0, 0, defaultToDays)
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'from' inválida, use AAAA-MM-DD"})
+			return 0, from, to, false
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'to' inválida, use AAAA-MM-DD"})
+			return 0, from, to, false
+		}
+		to = parsed.AddDate(0, 0, 1) // Inclusive end date
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La fecha 'to' debe ser posterior a 'from'"})
+		return 0, from, to, false
+	}
+	if to.After(from.AddDate(0, 0, maxScheduleRangeDays)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El rango máximo es de %d días", maxScheduleRangeDays)})
+		return 0, from, to, false
+	}
+	return officeID, from, to, true
+}
+
+// loadOfficeSchedule resolves the office and date range from the request and loads its appointments.
+// It writes the error response itself and returns ok=false on failure.
+func loadOfficeSchedule(c *gin.Context, db *gorm.DB) (appointments []models.Appointment, officeID uint, from, to time.Time, ok bool) {
+	officeID, from, to, ok = resolveOfficeRange(c, db, 0, 7)
+	if !ok {
+		return nil, 0, from, to, false
+	}
+
+	appointments = make([]models.Appointment, 0)
+	err := db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, first_name, last_name, role, department")
+	}).Preload("Case", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, title, client_id").Preload("Client", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, first_name, last_name")
+		})
+	}).Preload("Office", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, name, address")
+	}).
+		Joins("INNER JOIN cases ON cases.id = appointments.case_id AND cases.office_id = ? AND cases.deleted_at IS NULL", officeID).
+		Where("appointments.start_time >= ? AND appointments.start_time < ?", from, to).
+		Order("appointments.start_time ASC").
+		Find(&appointments).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el calendario de la oficina", "message": err.Error()})
+		return nil, 0, from, to, false
+	}
+	return appointments, officeID, from, to, true
+}
+
+// GetOfficeSchedule returns the office's appointments in the date range grouped by staff member.
+func GetOfficeSchedule(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appointments, officeID, from, to, ok := loadOfficeSchedule(c, db)
+		if !ok {
+			return
+		}
+
+		type staffSchedule struct {
+			StaffID      uint                 `json:"staffId"`
+			StaffName    string               `json:"staffName"`
+			Role         string               `json:"role"`
+			Department   *string              `json:"department,omitempty"`
+			Appointments []models.Appointment `json:"appointments"`
+		}
+		byStaff := make(map[uint]*staffSchedule)
+		for _, appt := range appointments {
+			entry, exists := byStaff[appt.StaffID]
+			if !exists {
+				entry = &staffSchedule{
+					StaffID:      appt.StaffID,
+					StaffName:    appt.Staff.FirstName + " " + appt.Staff.LastName,
+					Role:         appt.Staff.Role,
+					Department:   appt.Staff.Department,
+					Appointments: make([]models.Appointment, 0),
+				}
+				byStaff[appt.StaffID] = entry
+			}
+			entry.Appointments = append(entry.Appointments, appt)
+		}
+
+		staff := make([]*staffSchedule, 0, len(byStaff))
+		for _, entry := range byStaff {
+			staff = append(staff, entry)
+		}
+		sort.Slice(staff, func(i, j int) bool { return staff[i].StaffName < staff[j].StaffName })
+
+		c.JSON(http.StatusOK, gin.H{
+			"officeId":          officeID,
+			"from":              from.Format("2006-01-02"),
+			"to":                to.AddDate(0, 0, -1).Format("2006-01-02"),
+			"totalAppointments": len(appointments),
+			"staff":             staff,
+		})
+	}
+}
+
+// GetOfficeScheduleICS returns the office's appointments in the date range as an iCalendar feed.
+func GetOfficeScheduleICS(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appointments, officeID, _, _, ok := loadOfficeSchedule(c, db)
+		if !ok {
+			return
+		}
+
+		cal := ics.NewCalendar(fmt.Sprintf("CAF - Oficina %d", officeID))
+		for _, appt := range appointments {
+			cal.AddEvent(appointmentICSEvent(appt, true))
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=office-%d-schedule.ics", officeID))
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", cal.Bytes())
+	}
+}
+
+// appointmentICSEvent converts an appointment (with Case, Client, Staff and Office preloaded) to a VEVENT.
+func appointmentICSEvent(appt models.Appointment, includeStaff bool) ics.Event {
+	summary := appt.Title
+	if includeStaff && appt.Staff.ID != 0 {
+		summary = fmt.Sprintf("%s (%s %s)", appt.Title, appt.Staff.FirstName, appt.Staff.LastName)
+	}
+
+	description := "Caso: " + appt.Case.Title
+	if appt.Case.Client != nil {
+		description += "\nCliente: " + appt.Case.Client.FirstName + " " + appt.Case.Client.LastName
+	}
+
+	event := ics.Event{
+		UID:         fmt.Sprintf("appointment-%d@caf", appt.ID),
+		Start:       appt.StartTime,
+		End:         appt.EndTime,
+		Summary:     summary,
+		Description: description,
+		Updated:     appt.UpdatedAt,
+	}
+	if appt.Office != nil {
+		event.Location = appt.Office.Name
+		if appt.Office.Address != "" {
+			event.Location += ", " + appt.Office.Address
+		}
+	}
+
+	switch appt.Status {
+	case config.StatusCancelled:
+		event.Status = ics.StatusCancelled
+	case config.StatusConfirmed, config.StatusCompleted:
+		event.Status = ics.StatusConfirmed
+	default:
+		event.Status = ics.StatusTentative
+	}
+	return event
+}
@@ -0,0 +1,82 @@
+// api/handlers/office_schedule_test.go
+// Unit tests for the office schedule: days start at midnight in the office's time zone, and
+// appointments of deleted cases are left out.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// officeScheduleScript answers for office 2 in Ciudad Juárez and records the start time bounds
+// of the appointment query.
+func officeScheduleScript(bounds *[]time.Time) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "offices"`) {
+				return []string{"id", "timezone"}, [][]driver.Value{{int64(2), "America/Ciudad_Juarez"}}
+			}
+			return nil, nil
+		},
+		observe: func(query string, args []driver.Value) {
+			if strings.Contains(query, "appointments.start_time >= ") {
+				*bounds = append(*bounds, timeArgs(args)...)
+			}
+		},
+	}
+}
+
+// getOfficeSchedule requests office 2's schedule with the query string.
+func getOfficeSchedule(t *testing.T, script *scriptedSQL, query string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/manager/schedule?"+query, nil)
+	c.Set("userRole", "office_manager")
+	c.Set("officeScopeID", uint(2))
+	GetOfficeSchedule(scriptedDB(t, script))(c)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestOfficeScheduleDaysInOfficeTimezone(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Ciudad_Juarez")
+	var bounds []time.Time
+	script := officeScheduleScript(&bounds)
+	status, response := getOfficeSchedule(t, script, "from=2025-03-10&to=2025-03-10")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	// Midnight in Ciudad Juárez (UTC-6 after March 9) is 06:00 UTC
+	want := []time.Time{time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)}
+	if len(bounds) != 2 || !bounds[0].Equal(want[0]) || !bounds[1].Equal(want[1]) {
+		t.Errorf("appointments loaded over %v, want %v", bounds, want)
+	}
+	if response["from"] != "2025-03-10" || response["to"] != "2025-03-10" {
+		t.Errorf("range = %v to %v", response["from"], response["to"])
+	}
+	if joins := script.ran("INNER JOIN cases"); len(joins) != 1 || !strings.Contains(joins[0], "cases.deleted_at IS NULL") {
+		t.Errorf("appointments of deleted cases included: %v", joins)
+	}
+
+	// Without a range the week starts at today's midnight at the office
+	bounds = nil
+	if status, response := getOfficeSchedule(t, officeScheduleScript(&bounds), ""); status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if len(bounds) != 2 || !bounds[0].Equal(today) || !bounds[1].Equal(today.AddDate(0, 0, 7)) {
+		t.Errorf("default range %v, want the week from %s", bounds, today)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/interfaces"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
@@ -24,6 +26,8 @@ type OfficeInput struct {
 	PhoneCell   string   `json:"phoneCell"`
 	Latitude    *float64 `json:"latitude"`
 	Longitude   *float64 `json:"longitude"`
+	Timezone    string   `json:"timezone"`
+	IsActive    *bool    `json:"isActive"` // Omitted keeps the office's current state; new offices are active
 }
 
 // GetOfficeByID retrieves a single office by its ID.
@@ -73,6 +77,10 @@ func CreateOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 			return
 		}
+		if msg := validateOfficeTimezone(input.Timezone); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
 		office := &models.Office{
 			Name:        input.Name,
 			Address:     input.Address,
@@ -80,7 +88,9 @@ func CreateOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 			PhoneCell:   strings.TrimSpace(input.PhoneCell),
 			Latitude:    input.Latitude,
 			Longitude:   input.Longitude,
+			Timezone:    strings.TrimSpace(input.Timezone),
 			Code:        repo.GenerateUniqueCode(c.Request.Context(), input.Name, 0),
+			IsActive:    true,
 		}
 		if err := repo.Create(c.Request.Context(), office); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create office."})
@@ -160,6 +170,10 @@ func UpdateOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 			return
 		}
+		if msg := validateOfficeTimezone(input.Timezone); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
 		office.Name = input.Name
 		office.Address = input.Address
 		office.PhoneOffice = strings.TrimSpace(input.PhoneOffice)
@@ -167,6 +181,10 @@ func UpdateOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 		office.Code = repo.GenerateUniqueCode(c.Request.Context(), input.Name, office.ID)
 		office.Latitude = input.Latitude
 		office.Longitude = input.Longitude
+		office.Timezone = strings.TrimSpace(input.Timezone)
+		if input.IsActive != nil {
+			office.IsActive = *input.IsActive
+		}
 		if err := repo.Update(c.Request.Context(), office); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update office."})
 			return
@@ -200,7 +218,52 @@ func DeleteOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 			return
 		}
 		if blockReason != "" {
-			c.JSON(http.StatusConflict, gin.H{"error": blockReason})
+			// Admins may force the delete by reassigning every dependent record to another office
+			if c.Query("force") == "true" && c.GetString("userRole") == config.RoleAdmin {
+				targetID, err := parseOfficeID(c.Query("reassignTo"))
+				if err != nil || targetID == id {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "reassignTo must be a different, valid office ID when force=true"})
+					return
+				}
+				target, err := repo.GetByID(c.Request.Context(), targetID)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Target office not found."})
+					return
+				}
+				if !target.IsActive {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Target office is inactive."})
+					return
+				}
+				reassigned, err := repo.ReassignAndDelete(c.Request.Context(), id, targetID, func(counts *interfaces.OfficeDependentCounts) models.AuditLog {
+					return newAuditLog(c, "office", id, "delete", "office_reassigned", map[string]interface{}{
+						"reassignedTo": targetID,
+						"users":        counts.Users,
+						"openCases":    counts.OpenCases,
+						"appointments": counts.Appointments,
+						"capacities":   counts.Capacities,
+					})
+				})
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reassign and delete office."})
+					return
+				}
+				for _, caseID := range reassigned.CaseIDs {
+					invalidateCache(strconv.FormatUint(uint64(caseID), 10))
+				}
+				c.JSON(http.StatusOK, gin.H{"message": "Office deleted", "reassignedTo": targetID, "reassigned": reassigned})
+				return
+			}
+
+			counts, err := repo.GetDependentCounts(c.Request.Context(), id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check office dependencies."})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{
+				"error":      blockReason,
+				"dependents": counts,
+				"suggestion": "Transfer the office's records first, or retry with force=true&reassignTo=<officeId>.",
+			})
 			return
 		}
 		if err := repo.Delete(c.Request.Context(), id); err != nil {
@@ -211,6 +274,75 @@ func DeleteOffice(repo interfaces.OfficeRepository) gin.HandlerFunc {
 	}
 }
 
+// TransferOfficeRecords moves an office's users, cases, appointments and other references to another active
+// office, typically before closing it, so the office can then be deleted without force. The move and its audit
+// summary are written in one transaction.
+func TransferOfficeRecords(repo interfaces.OfficeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseOfficeID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid office ID"})
+			return
+		}
+
+		var input struct {
+			TargetOfficeID uint   `json:"targetOfficeId" binding:"required"`
+			Reason         string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetOfficeId is required", "details": err.Error()})
+			return
+		}
+		if input.TargetOfficeID == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target office must be different from the source office."})
+			return
+		}
+
+		source, err := repo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Office not found."})
+			return
+		}
+		target, err := repo.GetByID(c.Request.Context(), input.TargetOfficeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target office not found."})
+			return
+		}
+		if !target.IsActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target office is inactive."})
+			return
+		}
+
+		reason := input.Reason
+		if reason == "" {
+			reason = "office_transfer"
+		}
+		summary, err := repo.TransferRecords(c.Request.Context(), source.ID, target.ID, func(summary *interfaces.OfficeTransferSummary) models.AuditLog {
+			return newAuditLog(c, "office", source.ID, "transfer", reason, map[string]interface{}{
+				"targetOfficeId": target.ID,
+				"users":          summary.Users,
+				"cases":          summary.Cases,
+				"appointments":   summary.Appointments,
+				"capacities":     summary.Capacities,
+			})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer office records.", "message": err.Error()})
+			return
+		}
+		for _, caseID := range summary.CaseIDs {
+			invalidateCache(strconv.FormatUint(uint64(caseID), 10))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Office records transferred",
+			"sourceOfficeId": source.ID,
+			"targetOfficeId": target.ID,
+			"transferred":    summary,
+		})
+	}
+}
+
 // GetOfficeDetailWithStaff retrieves an office along with its staff members and stats.
 func GetOfficeDetailWithStaff(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -247,6 +379,13 @@ func GetOfficeDetailWithStaff(db *gorm.DB) gin.HandlerFunc {
 		var clientCount int64
 		db.Raw("SELECT COUNT(DISTINCT client_id) FROM cases WHERE office_id = ? AND deleted_at IS NULL AND client_id IS NOT NULL", id).Scan(&clientCount)
 
+		// Appointment categories the office offers; empty when it offers every category
+		appointmentCategories, err := officeAppointmentCategories(db, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointment categories"})
+			return
+		}
+
 		// Transform staff for response
 		staffList := make([]gin.H, 0, len(staff))
 		for _, s := range staff {
@@ -268,6 +407,9 @@ func GetOfficeDetailWithStaff(db *gorm.DB) gin.HandlerFunc {
 			"totalAppointments": totalAppointments,
 			"staffCount":        len(staffList),
 			"clientCount":       clientCount,
+
+			"appointmentCategories":           nonNilCategories(appointmentCategories),
+			"appointmentCategoriesRestricted": appointmentCategories != nil,
 		})
 	}
 }
@@ -291,3 +433,25 @@ func validateOfficePhone(phone string) string {
 	}
 	return ""
 }
+
+// validateOfficeTimezone returns an error message if the timezone is non-empty and not a known IANA zone; empty is OK
+func validateOfficeTimezone(timezone string) string {
+	trimmed := strings.TrimSpace(timezone)
+	if trimmed == "" {
+		return ""
+	}
+	if _, err := time.LoadLocation(trimmed); err != nil {
+		return "Zona horaria inválida. Use un nombre IANA (ej: America/Ciudad_Juarez)"
+	}
+	return ""
+}
+
+// officeLocation returns the office's time zone, falling back to the server's when unset or unknown
+func officeLocation(office models.Office) *time.Location {
+	if office.Timezone != "" {
+		if loc, err := time.LoadLocation(office.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
@@ -0,0 +1,211 @@
+// api/handlers/offices_test.go
+// Unit tests for office transfers and forced deletes: everything that blocks deleting the office
+// moves, only to an active office, and the audit entry is written in the same transaction.
+package handlers
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// officeTransferScript answers for office 1 with cases 7 (open) and 8 (closed), and office 2,
+// active unless targetActive is false.
+func officeTransferScript(targetActive bool) *scriptedSQL {
+	script := &scriptedSQL{}
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, `FROM "offices"`) && len(args) > 0:
+			office := int64(intArg(args[0]))
+			active := office != 2 || targetActive
+			return []string{"id", "name", "code", "is_active"}, [][]driver.Value{{office, "Oficina", "OF", active}}
+		case strings.HasPrefix(query, `SELECT "id" FROM "cases"`):
+			return []string{"id"}, [][]driver.Value{{int64(7)}, {int64(8)}}
+		}
+		return nil, nil
+	}
+	script.affected = func(query string) int64 { return 2 }
+	return script
+}
+
+// transferOffice posts a transfer of office 1's records to office 2.
+func transferOffice(t *testing.T, script *scriptedSQL) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/offices/1/transfer", strings.NewReader(`{"targetOfficeId": 2}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	TransferOfficeRecords(repositories.NewOfficeRepository(scriptedDB(t, script)))(c)
+	return w.Code, w.Body.String()
+}
+
+func TestTransferOfficeRecordsMovesEverythingWithItsAudit(t *testing.T) {
+	script := officeTransferScript(true)
+	status, body := transferOffice(t, script)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+
+	// Closed cases and past appointments move too, so nothing is left blocking the delete
+	if plucks := script.ran(`SELECT "id" FROM "cases"`); len(plucks) != 1 || strings.Contains(plucks[0], "status") {
+		t.Errorf("cases selected with %v", plucks)
+	}
+	for _, fragment := range []string{`UPDATE "appointments"`, `UPDATE "cases"`, `UPDATE "users"`, `UPDATE "contact_submissions"`, "DELETE FROM therapist_office_capacities"} {
+		if len(script.ran(fragment)) != 1 {
+			t.Errorf("%s not run: %v", fragment, script.statements)
+		}
+	}
+	if appointments := script.ran(`UPDATE "appointments"`); len(appointments) == 1 && !strings.Contains(appointments[0], "WHERE (office_id = $2 OR (case_id IN") {
+		t.Errorf("appointments filtered by status: %s", appointments[0])
+	}
+
+	// The audit entry is written before COMMIT, and its office reference moves with the rest
+	begin, insert, move, commit := statementIndex(script, "BEGIN"), statementIndex(script, `INSERT INTO "audit_logs"`), statementIndex(script, `UPDATE "audit_logs"`), statementIndex(script, "COMMIT")
+	if begin < 0 || !(begin < insert && insert < move && move < commit) {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
+
+func TestTransferOfficeRecordsRollsBackWhenAuditFails(t *testing.T) {
+	script := officeTransferScript(true)
+	script.fail = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "audit_logs"`) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	if status, body := transferOffice(t, script); status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", status, body)
+	}
+	if len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
+
+func TestTransferOfficeRecordsRejectsInactiveTarget(t *testing.T) {
+	script := officeTransferScript(false)
+	status, body := transferOffice(t, script)
+	if status != http.StatusBadRequest || !strings.Contains(body, "inactive") {
+		t.Fatalf("status = %d, want 400: %s", status, body)
+	}
+	if len(script.ran("BEGIN")) != 0 {
+		t.Errorf("transfer started: %v", script.statements)
+	}
+}
+
+// deleteOfficeForced deletes office 1 as an admin, reassigning its records to office 2.
+func deleteOfficeForced(t *testing.T, script *scriptedSQL) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/offices/1?force=true&reassignTo=2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	DeleteOffice(repositories.NewOfficeRepository(scriptedDB(t, script)))(c)
+	return w.Code, w.Body.String()
+}
+
+func TestDeleteOfficeForcedRecordsAuditInTransaction(t *testing.T) {
+	script := officeTransferScript(true)
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		switch {
+		case strings.Contains(query, `FROM "offices"`):
+			return []string{"id", "name", "code", "is_active"}, [][]driver.Value{{int64(1), "Oficina", "OF", true}}
+		case strings.Contains(query, "count(*)"):
+			return countRow(1)
+		}
+		return nil, nil
+	}
+	var openStatuses []driver.Value
+	script.observe = func(query string, args []driver.Value) {
+		if strings.Contains(query, `count(*) FROM "cases"`) {
+			openStatuses = args[1:]
+		}
+	}
+	status, body := deleteOfficeForced(t, script)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	insert, move, remove, commit := statementIndex(script, `INSERT INTO "audit_logs"`), statementIndex(script, `UPDATE "audit_logs"`), statementIndex(script, `DELETE FROM "offices"`), statementIndex(script, "COMMIT")
+	if insert < 0 || !(insert < move && move < remove && remove < commit) {
+		t.Errorf("statements = %v", script.statements)
+	}
+	for _, status := range openStatuses {
+		if status == "archived" {
+			t.Errorf("archived cases counted as open: %v", openStatuses)
+		}
+	}
+	if len(openStatuses) == 0 {
+		t.Error("open cases not counted")
+	}
+}
+
+func TestDeleteOfficeForcedRollsBackWhenAuditFails(t *testing.T) {
+	script := forcedDeleteScript(true)
+	script.fail = func(query string) error {
+		if strings.HasPrefix(query, `INSERT INTO "audit_logs"`) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	if status, body := deleteOfficeForced(t, script); status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", status, body)
+	}
+	if len(script.ran(`DELETE FROM "offices"`)) != 0 || len(script.ran("ROLLBACK")) != 1 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
+
+// forcedDeleteScript is officeTransferScript with one dependent record of each kind, so the
+// delete is blocked unless forced.
+func forcedDeleteScript(targetActive bool) *scriptedSQL {
+	script := officeTransferScript(targetActive)
+	rows := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "count(*)") {
+			return countRow(1)
+		}
+		return rows(query, args)
+	}
+	return script
+}
+
+func TestDeleteOfficeForcedInvalidatesMovedCases(t *testing.T) {
+	setCache("7", false, &models.Case{ID: 7, OfficeID: 1})
+	setCache("8", true, &models.Case{ID: 8, OfficeID: 1})
+	script := forcedDeleteScript(true)
+	status, body := deleteOfficeForced(t, script)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %s", status, body)
+	}
+	if _, found := getFromCache("7", false); found {
+		t.Error("moved case 7 is still cached with its old office")
+	}
+	if _, found := getFromCache("8", true); found {
+		t.Error("moved closed case 8 is still cached with its old office")
+	}
+}
+
+func TestDeleteOfficeForcedRejectsInactiveTarget(t *testing.T) {
+	script := forcedDeleteScript(false)
+	status, body := deleteOfficeForced(t, script)
+	if status != http.StatusBadRequest || !strings.Contains(body, "inactive") {
+		t.Fatalf("status = %d, want 400: %s", status, body)
+	}
+	if len(script.ran("BEGIN")) != 0 || len(script.ran(`DELETE FROM "offices"`)) != 0 {
+		t.Errorf("office deleted: %v", script.statements)
+	}
+}
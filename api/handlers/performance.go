@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,10 +10,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/middleware"
 	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/services"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
@@ -25,12 +29,20 @@ type PerformanceOptimizedHandler struct {
 	cache *CacheManager
 }
 
-// CacheManager handles multi-level caching with TTL and invalidation
+// CacheManager handles multi-level caching with TTL and invalidation. The memory tier holds at
+// most maxEntries items and evicts the least recently used; list pages and single items each
+// have their own TTL.
 type CacheManager struct {
 	memoryCache map[string]*CacheEntry
+	recency     *list.List // Memory tier keys, most recently used first
 	redis       *redis.Client
-	mutex       sync.RWMutex
-	ttl         time.Duration
+	mutex       sync.Mutex
+	maxEntries  int
+	listTTL     time.Duration
+	itemTTL     time.Duration
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
 }
 
 // CacheEntry represents a cached item with metadata
@@ -38,6 +50,19 @@ type CacheEntry struct {
 	Data      interface{}
 	CreatedAt time.Time
 	TTL       time.Duration
+	element   *list.Element // Position in CacheManager.recency
+}
+
+// newCacheManager creates a cache whose memory tier holds up to maxEntries items.
+func newCacheManager(redisClient *redis.Client, maxEntries int, listTTL, itemTTL time.Duration) *CacheManager {
+	return &CacheManager{
+		memoryCache: make(map[string]*CacheEntry),
+		recency:     list.New(),
+		redis:       redisClient,
+		maxEntries:  maxEntries,
+		listTTL:     listTTL,
+		itemTTL:     itemTTL,
+	}
 }
 
 // PaginationParams handles pagination and filtering
@@ -70,15 +95,29 @@ type PaginatedResponse struct {
 
 // NewPerformanceOptimizedHandler creates a new optimized handler
 func NewPerformanceOptimizedHandler(db *gorm.DB, redisClient *redis.Client) *PerformanceOptimizedHandler {
-	return &PerformanceOptimizedHandler{
+	h := &PerformanceOptimizedHandler{
 		db:    db,
 		redis: redisClient,
-		cache: &CacheManager{
-			memoryCache: make(map[string]*CacheEntry),
-			redis:       redisClient,
-			ttl:         5 * time.Minute,
-		},
+		cache: newCacheManager(redisClient, config.CacheMaxEntries(), config.CacheListTTL(), config.CacheItemTTL()),
 	}
+	// Writes here clear both tiers; events from other replicas only clear the memory tier,
+	// since the Redis tier is shared and was already cleared by the writer. An ID also drops
+	// the single-item entry, which list invalidation does not reach.
+	registerCacheInvalidator(func(resource, id string, local bool) {
+		itemKey := cacheItemKey(resource, id)
+		if local {
+			h.cache.InvalidateByResource(resource)
+			if itemKey != "" {
+				h.cache.InvalidateKey(itemKey)
+			}
+			return
+		}
+		h.cache.invalidateMemory(resource + "|")
+		if itemKey != "" {
+			h.cache.invalidateMemoryKey(itemKey)
+		}
+	})
+	return h
 }
 
 // GetOptimizedCases returns cases with advanced caching, pagination, and query optimization
@@ -91,7 +130,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedCases() gin.HandlerFunc {
 		logger.Log(LogLevelInfo, "Starting optimized cases retrieval")
 
 		// Parse pagination parameters with validation
-		params := h.parsePaginationParams(c)
+		params := h.parsePaginationParams(c, "cases")
 		params.Page, params.PageSize, _ = ValidatePaginationParams(params.Page, params.PageSize)
 
 		// Generate cache key
@@ -135,7 +174,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedCases() gin.HandlerFunc {
 		response := h.buildPaginatedResponse(cases, params, total, time.Now(), false)
 
 		// Cache the result
-		h.cache.Set(cacheKey, response, h.cache.ttl)
+		h.cache.Set(cacheKey, response, h.cache.listTTL)
 
 		logger.Log(LogLevelInfo, "Cases retrieved successfully", map[string]interface{}{
 			"total":    total,
@@ -153,7 +192,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedAppointments() gin.HandlerFunc
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
-		params := h.parsePaginationParams(c)
+		params := h.parsePaginationParams(c, "appointments")
 		cacheKey := h.generateCacheKey("appointments", params, c)
 
 		if cached, found := h.cache.Get(cacheKey); found {
@@ -184,7 +223,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedAppointments() gin.HandlerFunc
 		}
 
 		response := h.buildPaginatedResponse(appointments, params, total, startTime, false)
-		h.cache.Set(cacheKey, response, h.cache.ttl)
+		h.cache.Set(cacheKey, response, h.cache.listTTL)
 
 		c.JSON(http.StatusOK, response)
 	}
@@ -195,7 +234,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedUsers() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
-		params := h.parsePaginationParams(c)
+		params := h.parsePaginationParams(c, "users")
 		cacheKey := h.generateCacheKey("users", params, c)
 
 		if cached, found := h.cache.Get(cacheKey); found {
@@ -226,7 +265,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedUsers() gin.HandlerFunc {
 		}
 
 		response := h.buildPaginatedResponse(users, params, total, startTime, false)
-		h.cache.Set(cacheKey, response, h.cache.ttl)
+		h.cache.Set(cacheKey, response, h.cache.listTTL)
 
 		c.JSON(http.StatusOK, response)
 	}
@@ -235,15 +274,14 @@ func (h *PerformanceOptimizedHandler) GetOptimizedUsers() gin.HandlerFunc {
 // GetOptimizedCaseByID returns a single case with optimized loading
 func (h *PerformanceOptimizedHandler) GetOptimizedCaseByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		startTime := time.Now()
 		caseID := c.Param("id")
 
 		// Generate cache key for single case
-		cacheKey := fmt.Sprintf("case:%s", caseID)
+		cacheKey := cacheItemKey("cases", caseID)
 
-		// Try to get from cache first
+		// Try to get from cache first; single items are cached as-is, not as PaginatedResponse
 		if cached, found := h.cache.Get(cacheKey); found {
-			h.sendCachedResponse(c, cached, startTime, true)
+			c.JSON(http.StatusOK, cached)
 			return
 		}
 
@@ -252,10 +290,8 @@ func (h *PerformanceOptimizedHandler) GetOptimizedCaseByID() gin.HandlerFunc {
 			Preload("Client").
 			Preload("Office").
 			Preload("PrimaryStaff").
-			Preload("SecondaryStaff").
-			Preload("Documents").
+			Preload("AssignedStaff").
 			Preload("Appointments").
-			Preload("Notes").
 			Where("id = ? AND deleted_at IS NULL", caseID)
 
 		// Apply access control
@@ -272,7 +308,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedCaseByID() gin.HandlerFunc {
 		}
 
 		// Cache the result with longer TTL for single items
-		h.cache.Set(cacheKey, caseItem, 10*time.Minute)
+		h.cache.Set(cacheKey, caseItem, h.cache.itemTTL)
 
 		c.JSON(http.StatusOK, caseItem)
 	}
@@ -281,13 +317,12 @@ func (h *PerformanceOptimizedHandler) GetOptimizedCaseByID() gin.HandlerFunc {
 // GetOptimizedAppointmentByID returns a single appointment with optimized loading
 func (h *PerformanceOptimizedHandler) GetOptimizedAppointmentByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		startTime := time.Now()
 		appointmentID := c.Param("id")
 
-		cacheKey := fmt.Sprintf("appointment:%s", appointmentID)
+		cacheKey := cacheItemKey("appointments", appointmentID)
 
 		if cached, found := h.cache.Get(cacheKey); found {
-			h.sendCachedResponse(c, cached, startTime, true)
+			c.JSON(http.StatusOK, cached)
 			return
 		}
 
@@ -311,7 +346,7 @@ func (h *PerformanceOptimizedHandler) GetOptimizedAppointmentByID() gin.HandlerF
 			return
 		}
 
-		h.cache.Set(cacheKey, appointment, 10*time.Minute)
+		h.cache.Set(cacheKey, appointment, h.cache.itemTTL)
 		c.JSON(http.StatusOK, appointment)
 	}
 }
@@ -337,8 +372,8 @@ func (h *PerformanceOptimizedHandler) buildOptimizedCasesQuery(params Pagination
 	if params.Search != "" {
 		searchTerm := "%" + params.Search + "%"
 		query = query.Where(
-			"title ILIKE ? OR docket_number ILIKE ? OR court ILIKE ? OR description ILIKE ?",
-			searchTerm, searchTerm, searchTerm, searchTerm,
+			"title ILIKE ? OR case_number ILIKE ? OR docket_number ILIKE ? OR court ILIKE ? OR description ILIKE ?",
+			searchTerm, searchTerm, searchTerm, searchTerm, searchTerm,
 		)
 	}
 
@@ -377,23 +412,8 @@ func (h *PerformanceOptimizedHandler) buildOptimizedCasesQuery(params Pagination
 		}
 	}
 
-	// Apply sorting with validation
-	if params.SortBy != "" {
-		// Validate sort field to prevent SQL injection
-		allowedSortFields := map[string]bool{
-			"created_at": true, "updated_at": true, "title": true, "status": true,
-			"priority": true, "current_stage": true, "docket_number": true,
-		}
-		if allowedSortFields[params.SortBy] {
-			order := "ASC"
-			if params.SortOrder == "desc" {
-				order = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, order))
-		}
-	} else {
-		query = query.Order("created_at DESC")
-	}
+	// Apply sorting validated against the central allowlist (query_fields.go)
+	query = query.Order(sortClause("cases", params.SortBy, params.SortOrder, "created_at DESC"))
 
 	return query
 }
@@ -451,22 +471,8 @@ func (h *PerformanceOptimizedHandler) buildOptimizedAppointmentsQuery(params Pag
 		}
 	}
 
-	// Apply sorting
-	if params.SortBy != "" {
-		allowedSortFields := map[string]bool{
-			"start_time": true, "end_time": true, "title": true, "status": true,
-			"created_at": true, "updated_at": true,
-		}
-		if allowedSortFields[params.SortBy] {
-			order := "ASC"
-			if params.SortOrder == "desc" {
-				order = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, order))
-		}
-	} else {
-		query = query.Order("start_time DESC")
-	}
+	// Apply sorting validated against the central allowlist (query_fields.go)
+	query = query.Order(sortClause("appointments", params.SortBy, params.SortOrder, "start_time DESC"))
 
 	return query
 }
@@ -510,29 +516,11 @@ func (h *PerformanceOptimizedHandler) buildOptimizedUsersQuery(params Pagination
 			if isActive, ok := value.(bool); ok {
 				query = query.Where("is_active = ?", isActive)
 			}
-		case "status":
-			if status, ok := value.(string); ok && status != "" {
-				query = query.Where("status = ?", status)
-			}
 		}
 	}
 
-	// Apply sorting
-	if params.SortBy != "" {
-		allowedSortFields := map[string]bool{
-			"first_name": true, "last_name": true, "email": true, "role": true,
-			"created_at": true, "updated_at": true, "last_login": true,
-		}
-		if allowedSortFields[params.SortBy] {
-			order := "ASC"
-			if params.SortOrder == "desc" {
-				order = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, order))
-		}
-	} else {
-		query = query.Order("created_at DESC")
-	}
+	// Apply sorting validated against the central allowlist (query_fields.go)
+	query = query.Order(sortClause("users", params.SortBy, params.SortOrder, "created_at DESC"))
 
 	return query
 }
@@ -591,8 +579,9 @@ func (h *PerformanceOptimizedHandler) applyAccessControl(query *gorm.DB, c *gin.
 	return query
 }
 
-// parsePaginationParams extracts pagination parameters from request
-func (h *PerformanceOptimizedHandler) parsePaginationParams(c *gin.Context) PaginationParams {
+// parsePaginationParams extracts pagination parameters from request. Query parameters
+// other than paging, search and sort are kept as filters only if resource allows them.
+func (h *PerformanceOptimizedHandler) parsePaginationParams(c *gin.Context, resource string) PaginationParams {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
 	search := c.Query("search")
@@ -636,10 +625,25 @@ func (h *PerformanceOptimizedHandler) parsePaginationParams(c *gin.Context) Pagi
 		Search:    search,
 		SortBy:    sortBy,
 		SortOrder: sortOrder,
-		Filters:   filters,
+		Filters:   allowedFilters(resource, filters),
 	}
 }
 
+// cacheItemKey is the key of a single cached case or appointment, or "" for other resources
+// or an empty ID.
+func cacheItemKey(resource, id string) string {
+	if id == "" {
+		return ""
+	}
+	switch resource {
+	case "cases":
+		return "case:" + id
+	case "appointments":
+		return "appointment:" + id
+	}
+	return ""
+}
+
 // generateCacheKey creates a unique cache key based on parameters
 func (h *PerformanceOptimizedHandler) generateCacheKey(resource string, params PaginationParams, c *gin.Context) string {
 	userRole, _ := c.Get("userRole")
@@ -723,16 +727,18 @@ func (h *PerformanceOptimizedHandler) sendCachedResponse(c *gin.Context, cached
 // CacheManager methods
 func (cm *CacheManager) Get(key string) (interface{}, bool) {
 	// Try memory cache first
-	cm.mutex.RLock()
+	cm.mutex.Lock()
 	if entry, exists := cm.memoryCache[key]; exists {
 		if time.Since(entry.CreatedAt) < entry.TTL {
-			cm.mutex.RUnlock()
+			cm.recency.MoveToFront(entry.element)
+			cm.mutex.Unlock()
+			cm.hits.Add(1)
 			return entry.Data, true
 		}
 		// Remove expired entry
-		delete(cm.memoryCache, key)
+		cm.removeLocked(key)
 	}
-	cm.mutex.RUnlock()
+	cm.mutex.Unlock()
 
 	// Try Redis cache
 	if cm.redis != nil {
@@ -742,28 +748,22 @@ func (cm *CacheManager) Get(key string) (interface{}, bool) {
 			if json.Unmarshal([]byte(data), &result) == nil {
 				// Also store in memory cache for faster subsequent access
 				cm.mutex.Lock()
-				cm.memoryCache[key] = &CacheEntry{
-					Data:      result,
-					CreatedAt: time.Now(),
-					TTL:       cm.ttl,
-				}
+				cm.storeLocked(key, result, cm.ttlFor(key))
 				cm.mutex.Unlock()
+				cm.hits.Add(1)
 				return result, true
 			}
 		}
 	}
 
+	cm.misses.Add(1)
 	return nil, false
 }
 
 func (cm *CacheManager) Set(key string, data interface{}, ttl time.Duration) {
 	// Set in memory cache
 	cm.mutex.Lock()
-	cm.memoryCache[key] = &CacheEntry{
-		Data:      data,
-		CreatedAt: time.Now(),
-		TTL:       ttl,
-	}
+	cm.storeLocked(key, data, ttl)
 	cm.mutex.Unlock()
 
 	// Set in Redis cache
@@ -780,7 +780,7 @@ func (cm *CacheManager) Invalidate(pattern string) {
 	cm.mutex.Lock()
 	for key := range cm.memoryCache {
 		if strings.Contains(key, pattern) {
-			delete(cm.memoryCache, key)
+			cm.removeLocked(key)
 		}
 	}
 	cm.mutex.Unlock()
@@ -795,20 +795,105 @@ func (cm *CacheManager) Invalidate(pattern string) {
 	}
 }
 
-// InvalidateByResource invalidates cache for a specific resource type
+// InvalidateByResource invalidates cache for a specific resource type.
+// Keys are built as "resource|page:..." by generateCacheKey.
 func (cm *CacheManager) InvalidateByResource(resource string) {
-	cm.Invalidate(resource + ":")
+	cm.Invalidate(resource + "|")
+}
+
+// InvalidateKey removes a single entry from both tiers. Unlike Invalidate it matches the key
+// exactly, so clearing "case:7" leaves "case:70" alone.
+func (cm *CacheManager) InvalidateKey(key string) {
+	cm.invalidateMemoryKey(key)
+	if cm.redis != nil {
+		cm.redis.Del(context.Background(), key)
+	}
+}
+
+// invalidateMemoryKey removes a single entry from the memory tier only.
+func (cm *CacheManager) invalidateMemoryKey(key string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.removeLocked(key)
+}
+
+// invalidateMemory clears memory-tier entries with the given prefix; the Redis tier is shared
+// across replicas and is left to Invalidate.
+func (cm *CacheManager) invalidateMemory(prefix string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	for key := range cm.memoryCache {
+		if strings.HasPrefix(key, prefix) {
+			cm.removeLocked(key)
+		}
+	}
+}
+
+// clearMemory empties the memory tier.
+func (cm *CacheManager) clearMemory() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.memoryCache = make(map[string]*CacheEntry)
+	cm.recency.Init()
+}
+
+// storeLocked adds or replaces a memory-tier entry as the most recently used, then evicts the
+// least recently used entries beyond maxEntries. cm.mutex must be held.
+func (cm *CacheManager) storeLocked(key string, data interface{}, ttl time.Duration) {
+	if entry, exists := cm.memoryCache[key]; exists {
+		entry.Data, entry.CreatedAt, entry.TTL = data, time.Now(), ttl
+		cm.recency.MoveToFront(entry.element)
+		return
+	}
+	cm.memoryCache[key] = &CacheEntry{
+		Data:      data,
+		CreatedAt: time.Now(),
+		TTL:       ttl,
+		element:   cm.recency.PushFront(key),
+	}
+	for cm.maxEntries > 0 && len(cm.memoryCache) > cm.maxEntries {
+		cm.removeLocked(cm.recency.Back().Value.(string))
+		cm.evictions.Add(1)
+	}
+}
+
+// removeLocked drops a memory-tier entry. cm.mutex must be held.
+func (cm *CacheManager) removeLocked(key string) {
+	if entry, exists := cm.memoryCache[key]; exists {
+		cm.recency.Remove(entry.element)
+		delete(cm.memoryCache, key)
+	}
+}
+
+// ttlFor returns the TTL of key when copied from Redis: single items (see cacheItemKey) get
+// itemTTL, list pages listTTL.
+func (cm *CacheManager) ttlFor(key string) time.Duration {
+	if strings.HasPrefix(key, "case:") || strings.HasPrefix(key, "appointment:") {
+		return cm.itemTTL
+	}
+	return cm.listTTL
 }
 
 // GetCacheStats returns cache statistics
 func (cm *CacheManager) GetCacheStats() map[string]interface{} {
-	cm.mutex.RLock()
-	defer cm.mutex.RUnlock()
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
 
+	hits, misses := cm.hits.Load(), cm.misses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
 	stats := map[string]interface{}{
 		"memoryEntries":  len(cm.memoryCache),
+		"maxEntries":     cm.maxEntries,
 		"redisConnected": cm.redis != nil,
-		"ttl":            cm.ttl.String(),
+		"listTtl":        cm.listTTL.String(),
+		"itemTtl":        cm.itemTTL.String(),
+		"hits":           hits,
+		"misses":         misses,
+		"hitRate":        hitRate,
+		"evictions":      cm.evictions.Load(),
 	}
 
 	// Calculate memory usage
@@ -830,8 +915,10 @@ func (h *PerformanceOptimizedHandler) GetPerformanceMetrics() gin.HandlerFunc {
 			"cache":    h.cache.GetCacheStats(),
 			"database": h.getConnectionPoolStats(),
 			"system": map[string]interface{}{
-				"timestamp": time.Now(),
-				"uptime":    h.getUptime(),
+				"timestamp":     time.Now(),
+				"uptime":        services.Uptime(),
+				"uptimeSeconds": services.Uptime().Seconds(),
+				"startedAt":     services.ProcessStart(),
 			},
 		}
 
@@ -858,12 +945,6 @@ func (h *PerformanceOptimizedHandler) getConnectionPoolStats() map[string]interf
 	}
 }
 
-func (h *PerformanceOptimizedHandler) getUptime() time.Duration {
-	// This would typically be stored in a global variable or retrieved from a service
-	// For now, return a placeholder
-	return time.Since(time.Now().Add(-24 * time.Hour)) // Placeholder: 24 hours
-}
-
 // HealthCheck provides a comprehensive health check endpoint
 func (h *PerformanceOptimizedHandler) HealthCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -948,9 +1029,7 @@ func (h *PerformanceOptimizedHandler) checkRedisHealth() map[string]interface{}
 func (h *PerformanceOptimizedHandler) ClearCache() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Clear memory cache
-		h.cache.mutex.Lock()
-		h.cache.memoryCache = make(map[string]*CacheEntry)
-		h.cache.mutex.Unlock()
+		h.cache.clearMemory()
 
 		// Clear Redis cache if available
 		if h.cache.redis != nil {
@@ -968,12 +1047,12 @@ func (h *PerformanceOptimizedHandler) ClearCache() gin.HandlerFunc {
 // GetCacheKeys provides an endpoint to list cache keys (for debugging)
 func (h *PerformanceOptimizedHandler) GetCacheKeys() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		h.cache.mutex.RLock()
+		h.cache.mutex.Lock()
 		memoryKeys := make([]string, 0, len(h.cache.memoryCache))
 		for key := range h.cache.memoryCache {
 			memoryKeys = append(memoryKeys, key)
 		}
-		h.cache.mutex.RUnlock()
+		h.cache.mutex.Unlock()
 
 		var redisKeys []string
 		if h.cache.redis != nil {
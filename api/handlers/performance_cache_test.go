@@ -0,0 +1,186 @@
+// api/handlers/performance_cache_test.go
+// Unit tests for the performance handler cache: invalidation on case writes, least recently
+// used eviction and per-resource TTLs.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// optimizedFetch runs an optimized handler as an admin and returns the response.
+func optimizedFetch(t *testing.T, handler gin.HandlerFunc, target, id string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	if id != "" {
+		c.Params = gin.Params{{Key: "id", Value: id}}
+	}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	handler(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d: %s", target, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func TestCaseUpdateInvalidatesOptimizedCache(t *testing.T) {
+	db := dryRunDB(t)
+	if err := registerCacheInvalidationCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+	h := NewPerformanceOptimizedHandler(db, nil)
+
+	cacheHit := func() bool {
+		var response PaginatedResponse
+		w := optimizedFetch(t, h.GetOptimizedCases(), "/api/v1/admin/optimized/cases", "")
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response.Performance.CacheHit
+	}
+	if cacheHit() {
+		t.Fatal("first fetch was served from cache")
+	}
+	if !cacheHit() {
+		t.Fatal("second fetch was not served from cache")
+	}
+	h.cache.Set("case:7", models.Case{Title: "Stale"}, 10*time.Minute)
+	h.cache.Set("case:70", models.Case{Title: "Other"}, 10*time.Minute)
+
+	// What UpdateCase does: write the case, then invalidate it by ID. The dry-run
+	// database has no connection to open the default transaction on.
+	write := db.Session(&gorm.Session{SkipDefaultTransaction: true})
+	if err := write.Model(&models.Case{ID: 7}).Updates(map[string]interface{}{"title": "Fresh"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	invalidateCache("7")
+
+	if cacheHit() {
+		t.Error("case list was served from cache after the case was updated")
+	}
+	if _, found := h.cache.Get("case:7"); found {
+		t.Error("updated case is still cached")
+	}
+	if _, found := h.cache.Get("case:70"); !found {
+		t.Error("invalidating case 7 also dropped case 70")
+	}
+
+	var caseItem models.Case
+	w := optimizedFetch(t, h.GetOptimizedCaseByID(), "/api/v1/admin/optimized/cases/7", "7")
+	if err := json.Unmarshal(w.Body.Bytes(), &caseItem); err != nil {
+		t.Fatal(err)
+	}
+	if caseItem.Title == "Stale" {
+		t.Error("case fetch returned the stale cached copy")
+	}
+}
+
+// cachedKeys returns the memory tier's keys, most recently used first.
+func cachedKeys(cm *CacheManager) []string {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	keys := make([]string, 0, cm.recency.Len())
+	for element := cm.recency.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(string))
+	}
+	return keys
+}
+
+func TestCacheManagerEvictsLeastRecentlyUsed(t *testing.T) {
+	cm := newCacheManager(nil, 3, time.Minute, time.Hour)
+	for _, key := range []string{"cases|page:1", "cases|page:2", "case:7"} {
+		cm.Set(key, key, cm.ttlFor(key))
+	}
+	// Reading page 1 makes page 2 the least recently used
+	if _, found := cm.Get("cases|page:1"); !found {
+		t.Fatal("page 1 not cached")
+	}
+	cm.Set("appointment:3", "appointment", cm.itemTTL)
+	if got, want := cachedKeys(cm), []string{"appointment:3", "cases|page:1", "case:7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after one eviction: %v, want %v", got, want)
+	}
+
+	// Replacing an entry refreshes it instead of adding one
+	cm.Set("case:7", "updated", cm.itemTTL)
+	cm.Set("users|page:1", "users", cm.listTTL)
+	if got, want := cachedKeys(cm), []string{"users|page:1", "case:7", "appointment:3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after two evictions: %v, want %v", got, want)
+	}
+	if _, found := cm.Get("cases|page:2"); found {
+		t.Error("evicted entry still served")
+	}
+
+	stats := cm.GetCacheStats()
+	if stats["evictions"] != uint64(2) || stats["hits"] != uint64(1) || stats["misses"] != uint64(1) || stats["memoryEntries"] != 3 || stats["maxEntries"] != 3 {
+		t.Errorf("stats = %v", stats)
+	}
+	if stats["hitRate"] != 0.5 {
+		t.Errorf("hitRate = %v", stats["hitRate"])
+	}
+}
+
+func TestCacheManagerTTLExpiry(t *testing.T) {
+	cm := newCacheManager(nil, 10, 5*time.Minute, 10*time.Minute)
+	if cm.ttlFor("cases|page:1") != 5*time.Minute || cm.ttlFor("case:7") != 10*time.Minute || cm.ttlFor("appointment:3") != 10*time.Minute {
+		t.Fatalf("ttlFor: list %s, case %s", cm.ttlFor("cases|page:1"), cm.ttlFor("case:7"))
+	}
+	cm.Set("cases|page:1", "list", cm.listTTL)
+	cm.Set("case:7", "item", cm.itemTTL)
+
+	// Seven minutes later the list page has expired and the case has not
+	cm.mutex.Lock()
+	for _, entry := range cm.memoryCache {
+		entry.CreatedAt = entry.CreatedAt.Add(-7 * time.Minute)
+	}
+	cm.mutex.Unlock()
+	if _, found := cm.Get("cases|page:1"); found {
+		t.Error("list page served past its TTL")
+	}
+	if data, found := cm.Get("case:7"); !found || data != "item" {
+		t.Errorf("case expired before its TTL: %v, %v", data, found)
+	}
+	// The expired entry is gone, not just skipped
+	if got := cachedKeys(cm); !reflect.DeepEqual(got, []string{"case:7"}) {
+		t.Errorf("memory tier = %v", got)
+	}
+}
+
+func TestCacheManagerRedisCopyUsesResourceTTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	cm := newCacheManager(client, 10, 5*time.Minute, 10*time.Minute)
+
+	// Written by another replica: only in Redis
+	ctx := context.Background()
+	client.Set(ctx, "case:7", `{"id":7}`, time.Hour)
+	client.Set(ctx, "cases|page:1", `{"data":[]}`, time.Hour)
+	for _, key := range []string{"case:7", "cases|page:1"} {
+		if _, found := cm.Get(key); !found {
+			t.Fatalf("%s not read from Redis", key)
+		}
+	}
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	if ttl := cm.memoryCache["case:7"].TTL; ttl != 10*time.Minute {
+		t.Errorf("case copied with TTL %s", ttl)
+	}
+	if ttl := cm.memoryCache["cases|page:1"].TTL; ttl != 5*time.Minute {
+		t.Errorf("list page copied with TTL %s", ttl)
+	}
+}
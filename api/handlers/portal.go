@@ -0,0 +1,317 @@
+// api/handlers/portal.go
+// Client self-service portal: the signed-in client's own cases and appointments, in shapes that
+// leave out staff-only data (fees, internal comments, audit and archive columns, staff contact).
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// portalPerson names a staff member or the author of an event.
+type portalPerson struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+// portalOffice is where the client is attended.
+type portalOffice struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	PhoneOffice string `json:"phoneOffice,omitempty"`
+}
+
+// portalCaseEvent is a client-visible timeline event.
+type portalCaseEvent struct {
+	ID          uint          `json:"id"`
+	EventType   string        `json:"eventType"`
+	CommentText string        `json:"commentText,omitempty"`
+	Description string        `json:"description,omitempty"`
+	FileName    string        `json:"fileName,omitempty"`
+	FileType    string        `json:"fileType,omitempty"`
+	Author      *portalPerson `json:"author,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+// portalCase is a case as its client sees it.
+type portalCase struct {
+	ID           uint              `json:"id"`
+	CaseNumber   string            `json:"caseNumber"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	Category     string            `json:"category"`
+	CurrentStage string            `json:"currentStage"`
+	StageLabel   string            `json:"stageLabel"`
+	Status       string            `json:"status"`
+	StatusLabel  string            `json:"statusLabel"`
+	Court        string            `json:"court,omitempty"`
+	DocketNumber string            `json:"docketNumber,omitempty"`
+	Office       *portalOffice     `json:"office"`
+	PrimaryStaff *portalPerson     `json:"primaryStaff"`
+	Events       []portalCaseEvent `json:"events"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+// portalAppointment is an appointment on one of the client's cases.
+type portalAppointment struct {
+	ID          uint          `json:"id"`
+	CaseID      uint          `json:"caseId"`
+	CaseTitle   string        `json:"caseTitle"`
+	Title       string        `json:"title"`
+	StartTime   time.Time     `json:"startTime"`
+	EndTime     time.Time     `json:"endTime"`
+	Status      string        `json:"status"`
+	StatusLabel string        `json:"statusLabel"`
+	Category    string        `json:"category"`
+	Department  string        `json:"department"`
+	Office      *portalOffice `json:"office"`
+	Staff       *portalPerson `json:"staff"`
+}
+
+func newPortalPerson(user *models.User) *portalPerson {
+	if user == nil || user.ID == 0 {
+		return nil
+	}
+	return &portalPerson{ID: user.ID, FirstName: user.FirstName, LastName: user.LastName}
+}
+
+func newPortalOffice(office *models.Office) *portalOffice {
+	if office == nil || office.ID == 0 {
+		return nil
+	}
+	return &portalOffice{ID: office.ID, Name: office.Name, Address: office.Address, PhoneOffice: office.PhoneOffice}
+}
+
+// newPortalCase maps a case with its preloads; events that are not client_visible are dropped
+// even if they were loaded.
+func newPortalCase(caseData models.Case) portalCase {
+	item := portalCase{
+		ID:           caseData.ID,
+		CaseNumber:   caseData.CaseNumber,
+		Title:        caseData.Title,
+		Description:  caseData.Description,
+		Category:     caseData.Category,
+		CurrentStage: caseData.CurrentStage,
+		StageLabel:   config.GetStageLabel(caseData.CurrentStage),
+		Status:       caseData.Status,
+		StatusLabel:  config.GetStatusLabel(caseData.Status),
+		Court:        caseData.Court,
+		DocketNumber: caseData.DocketNumber,
+		Office:       newPortalOffice(caseData.Office),
+		PrimaryStaff: newPortalPerson(caseData.PrimaryStaff),
+		Events:       make([]portalCaseEvent, 0, len(caseData.CaseEvents)),
+		CreatedAt:    caseData.CreatedAt,
+		UpdatedAt:    caseData.UpdatedAt,
+	}
+	for _, event := range caseData.CaseEvents {
+		if event.Visibility != "client_visible" {
+			continue
+		}
+		item.Events = append(item.Events, portalCaseEvent{
+			ID:          event.ID,
+			EventType:   event.EventType,
+			CommentText: event.CommentText,
+			Description: event.Description,
+			FileName:    event.FileName,
+			FileType:    event.FileType,
+			Author:      newPortalPerson(&event.User),
+			CreatedAt:   event.CreatedAt,
+		})
+	}
+	return item
+}
+
+func newPortalAppointment(appointment models.Appointment) portalAppointment {
+	return portalAppointment{
+		ID:          appointment.ID,
+		CaseID:      appointment.CaseID,
+		CaseTitle:   appointment.Case.Title,
+		Title:       appointment.Title,
+		StartTime:   appointment.StartTime,
+		EndTime:     appointment.EndTime,
+		Status:      string(appointment.Status),
+		StatusLabel: config.GetAppointmentStatusLabel(string(appointment.Status)),
+		Category:    appointment.Category,
+		Department:  appointment.Department,
+		Office:      newPortalOffice(appointment.Office),
+		Staff:       newPortalPerson(&appointment.Staff),
+	}
+}
+
+// portalCaseQuery selects the client's own, not deleted, cases with the preloads newPortalCase
+// maps: office, primary staff and the client-visible events with their authors.
+func portalCaseQuery(db *gorm.DB, clientID uint) *gorm.DB {
+	return db.Model(&models.Case{}).
+		Where("cases.client_id = ? AND cases.deleted_at IS NULL", clientID).
+		Preload("Office", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id, name, address, phone_office")
+		}).
+		Preload("PrimaryStaff", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select("id, first_name, last_name")
+		}).
+		Preload("CaseEvents", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("visibility = ?", "client_visible").Order("created_at DESC")
+		}).
+		Preload("CaseEvents.User", func(tx *gorm.DB) *gorm.DB {
+			return tx.Unscoped().Select("id, first_name, last_name")
+		})
+}
+
+// GetPortalCases lists the signed-in client's cases, most recently updated first, with their
+// client-visible events. ?status= filters by case status.
+func GetPortalCases(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := extractUserIDUint(c)
+		if clientID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		page, pageSize := parseCasePagination(c)
+
+		query := db.Model(&models.Case{}).Where("cases.client_id = ? AND cases.deleted_at IS NULL", clientID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("cases.status = ?", status)
+		}
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los casos", "message": err.Error()})
+			return
+		}
+
+		cases := make([]models.Case, 0, pageSize)
+		list := portalCaseQuery(db, clientID)
+		if status := c.Query("status"); status != "" {
+			list = list.Where("cases.status = ?", status)
+		}
+		if err := list.Order("cases.updated_at DESC, cases.id DESC").
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&cases).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los casos", "message": err.Error()})
+			return
+		}
+
+		data := make([]portalCase, 0, len(cases))
+		for _, caseData := range cases {
+			data = append(data, newPortalCase(caseData))
+		}
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data": data,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
+
+// GetPortalCase returns one of the signed-in client's cases. A case of another client answers
+// 404, the same as one that does not exist.
+func GetPortalCase(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := extractUserIDUint(c)
+		if clientID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		caseID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var caseData models.Case
+		if err := portalCaseQuery(db, clientID).Where("cases.id = ?", caseID).First(&caseData).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Caso no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el caso", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": newPortalCase(caseData)})
+	}
+}
+
+// GetPortalAppointments lists the appointments on the signed-in client's cases, by start time
+// (latest first). ?status= filters by status and ?upcoming=true keeps those not yet started,
+// soonest first.
+func GetPortalAppointments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := extractUserIDUint(c)
+		if clientID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		page, pageSize := parseCasePagination(c)
+		upcoming := c.Query("upcoming") == "true"
+
+		scoped := func() *gorm.DB {
+			query := db.Model(&models.Appointment{}).
+				Joins("INNER JOIN cases ON cases.id = appointments.case_id").
+				Where("cases.client_id = ? AND cases.deleted_at IS NULL", clientID)
+			if status := c.Query("status"); status != "" {
+				query = query.Where("appointments.status = ?", status)
+			}
+			if upcoming {
+				query = query.Where("appointments.start_time >= ?", time.Now())
+			}
+			return query
+		}
+
+		var total int64
+		if err := scoped().Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las citas", "message": err.Error()})
+			return
+		}
+		order := "appointments.start_time DESC"
+		if upcoming {
+			order = "appointments.start_time ASC"
+		}
+		appointments := make([]models.Appointment, 0, pageSize)
+		if err := scoped().
+			Preload("Case", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, title")
+			}).
+			Preload("Office", func(tx *gorm.DB) *gorm.DB {
+				return tx.Select("id, name, address, phone_office")
+			}).
+			Preload("Staff", func(tx *gorm.DB) *gorm.DB {
+				return tx.Unscoped().Select("id, first_name, last_name")
+			}).
+			Order(order).
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&appointments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las citas", "message": err.Error()})
+			return
+		}
+
+		data := make([]portalAppointment, 0, len(appointments))
+		for _, appointment := range appointments {
+			data = append(data, newPortalAppointment(appointment))
+		}
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data": data,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// api/handlers/portal_test.go
+// Unit tests for the client portal: clients only reach their own cases and appointments, and
+// never see internal events or staff-only fields.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// portalScript serves case 7 of client 5 and case 9 of client 6, each with one internal and
+// one client-visible event. Case queries are answered by the client_id (and case id) they
+// are bound to, like the database would; events are returned whatever the query asks, so the
+// handler's own filtering is exercised too.
+func portalScript() *scriptedSQL {
+	created := time.Date(2025, 2, 3, 10, 0, 0, 0, time.UTC)
+	cases := [][]driver.Value{
+		{int64(7), "CAF-CEN-2025-000007", int64(5), int64(2), "Divorcio", "Familiar", "notificacion", "in_progress", 12500.0, "Nota de cierre", created},
+		{int64(9), "CAF-CEN-2025-000009", int64(6), int64(2), "Pensión", "Familiar", "etapa_inicial", "open", 3000.0, "", created},
+	}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "cases"`) && strings.Contains(query, "cases.client_id = $1"):
+				matching := make([][]driver.Value, 0)
+				for _, row := range cases {
+					if fmt.Sprint(row[2]) != fmt.Sprint(args[0]) {
+						continue
+					}
+					if strings.Contains(query, "cases.id = $2") && fmt.Sprint(row[0]) != fmt.Sprint(args[1]) {
+						continue
+					}
+					matching = append(matching, row)
+				}
+				if strings.Contains(query, "count(*)") {
+					return countRow(int64(len(matching)))
+				}
+				return []string{"id", "case_number", "client_id", "office_id", "title", "category", "current_stage", "status", "fee", "completion_note", "created_at"}, matching
+			case strings.Contains(query, `FROM "case_events"`):
+				return []string{"id", "case_id", "user_id", "event_type", "visibility", "comment_text", "created_at"}, [][]driver.Value{
+					{int64(1), int64(7), int64(3), "comment", "internal", "Cliente difícil, revisar pagos", created},
+					{int64(2), int64(7), int64(3), "comment", "client_visible", "Su audiencia fue programada", created},
+				}
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "first_name", "last_name"}, [][]driver.Value{{int64(3), "Laura", "Méndez"}}
+			case strings.Contains(query, `FROM "appointments"`):
+				if strings.Contains(query, "count(*)") {
+					return countRow(1)
+				}
+				return []string{"id", "case_id", "staff_id", "office_id", "title", "start_time", "end_time", "status", "category", "department", "reminder_stage"},
+					[][]driver.Value{{int64(11), int64(7), int64(3), int64(2), "Audiencia", created, created.Add(time.Hour), "confirmed", "Familiar", "Familiar", "24h"}}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// runPortal runs handler as client 5 for path, with the :id parameter when given.
+func runPortal(t *testing.T, db *gorm.DB, handler func(*gorm.DB) gin.HandlerFunc, path, id string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+	if id != "" {
+		c.Params = gin.Params{{Key: "id", Value: id}}
+	}
+	c.Set("userID", "5")
+	c.Set("userRole", "client")
+	handler(db)(c)
+	return w
+}
+
+func TestPortalCaseOfAnotherClientIsNotFound(t *testing.T) {
+	script := portalScript()
+	w := runPortal(t, scriptedDB(t, script), GetPortalCase, "/api/v1/portal/cases/9", "9")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "Pensión") {
+		t.Errorf("another client's case leaked: %s", w.Body.String())
+	}
+	for _, query := range script.ran(`FROM "cases"`) {
+		if !strings.Contains(query, "cases.client_id = $1") {
+			t.Errorf("case query is not limited to the client: %s", query)
+		}
+	}
+
+	w = runPortal(t, scriptedDB(t, portalScript()), GetPortalCase, "/api/v1/portal/cases/7", "7")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"caseNumber":"CAF-CEN-2025-000007"`) {
+		t.Errorf("own case: status %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPortalCasesListOnlyOwnCases(t *testing.T) {
+	script := portalScript()
+	w := runPortal(t, scriptedDB(t, script), GetPortalCases, "/api/v1/portal/cases", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data       []portalCase `json:"data"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != 7 || body.Pagination.Total != 1 {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
+
+func TestPortalCaseFiltersInternalEvents(t *testing.T) {
+	for _, tt := range []struct {
+		handler func(*gorm.DB) gin.HandlerFunc
+		path    string
+		id      string
+	}{
+		{GetPortalCase, "/api/v1/portal/cases/7", "7"},
+		{GetPortalCases, "/api/v1/portal/cases", ""},
+	} {
+		script := portalScript()
+		w := runPortal(t, scriptedDB(t, script), tt.handler, tt.path, tt.id)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.path, w.Code, w.Body.String())
+		}
+		body := w.Body.String()
+		if strings.Contains(body, "revisar pagos") || strings.Contains(body, `"internal"`) {
+			t.Errorf("%s: internal event in %s", tt.path, body)
+		}
+		if !strings.Contains(body, "Su audiencia fue programada") || !strings.Contains(body, `"firstName":"Laura"`) {
+			t.Errorf("%s: client-visible event missing from %s", tt.path, body)
+		}
+		// Staff-only case fields never reach the client
+		for _, field := range []string{`"fee"`, "12500", "Nota de cierre", `"completionNote"`, `"archiveReason"`, `"createdBy"`, `"tasks"`, `"email"`} {
+			if strings.Contains(body, field) {
+				t.Errorf("%s: response contains %s: %s", tt.path, field, body)
+			}
+		}
+		events := script.ran(`FROM "case_events"`)
+		if len(events) != 1 || !strings.Contains(events[0], "visibility = $1") {
+			t.Errorf("%s: events query = %v", tt.path, events)
+		}
+	}
+}
+
+func TestPortalAppointmentsOnlyOwnCases(t *testing.T) {
+	script := portalScript()
+	w := runPortal(t, scriptedDB(t, script), GetPortalAppointments, "/api/v1/portal/appointments?upcoming=true", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	queries := script.ran(`FROM "appointments"`)
+	if len(queries) != 2 {
+		t.Fatalf("appointment queries = %v", queries)
+	}
+	for _, query := range queries {
+		if !strings.Contains(query, "INNER JOIN cases ON cases.id = appointments.case_id") || !strings.Contains(query, "cases.client_id = $1") ||
+			!strings.Contains(query, "appointments.start_time >=") {
+			t.Errorf("appointment query is not limited to the client's upcoming appointments: %s", query)
+		}
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"title":"Audiencia"`) || !strings.Contains(body, `"statusLabel":"Confirmada"`) {
+		t.Errorf("body = %s", body)
+	}
+	for _, field := range []string{"reminderStage", `"email"`, `"role"`} {
+		if strings.Contains(body, field) {
+			t.Errorf("response contains %s: %s", field, body)
+		}
+	}
+}
+
+func TestClientEndpointsUsePortalShapes(t *testing.T) {
+	w := runPortal(t, scriptedDB(t, portalScript()), GetClientCaseByID, "/api/v1/client/cases/7", "7")
+	if w.Code != http.StatusOK {
+		t.Fatalf("case: status = %d: %s", w.Code, w.Body.String())
+	}
+	var detail clientCaseDetail
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.ID != 7 || len(detail.Events) != 1 || len(detail.Appointments) != 1 || detail.Appointments[0].CaseTitle != "Divorcio" {
+		t.Errorf("case detail = %s", w.Body.String())
+	}
+	if w := runPortal(t, scriptedDB(t, portalScript()), GetClientCaseByID, "/api/v1/client/cases/9", "9"); w.Code != http.StatusNotFound {
+		t.Errorf("another client's case: status = %d", w.Code)
+	}
+
+	appointments := runPortal(t, scriptedDB(t, portalScript()), GetClientAppointments, "/api/v1/client/appointments", "")
+	if appointments.Code != http.StatusOK || !strings.Contains(appointments.Body.String(), `"statusLabel":"Confirmada"`) {
+		t.Fatalf("appointments: status = %d: %s", appointments.Code, appointments.Body.String())
+	}
+	for _, body := range []string{w.Body.String(), appointments.Body.String()} {
+		for _, field := range []string{`"fee"`, "12500", "Nota de cierre", "revisar pagos", `"internal"`, "reminderStage", `"email"`, `"role"`} {
+			if strings.Contains(body, field) {
+				t.Errorf("response contains %s: %s", field, body)
+			}
+		}
+	}
+}
@@ -27,9 +27,10 @@ func isSafeAvatarURL(s string) bool {
 // ProfileUpdateInput is the body for PATCH /profile (optional fields).
 type ProfileUpdateInput struct {
 	AvatarURL *string `json:"avatarUrl"` // set URL (external or clear with empty string)
+	Timezone  *string `json:"timezone"`  // IANA zone for report and dashboard periods; empty uses the office's
 }
 
-// UpdateProfile updates the current user's profile (e.g. avatar URL). PATCH /profile
+// UpdateProfile updates the current user's profile (avatar URL, time zone). PATCH /profile
 func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("userID")
@@ -68,7 +69,17 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 				user.AvatarURL = &s
 			}
 		}
-		if err := db.Model(&user).Update("avatar_url", user.AvatarURL).Error; err != nil {
+		if input.Timezone != nil {
+			if msg := validateOfficeTimezone(*input.Timezone); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			user.Timezone = strings.TrimSpace(*input.Timezone)
+		}
+		if err := db.Model(&user).Updates(map[string]interface{}{
+			"avatar_url": user.AvatarURL,
+			"timezone":   user.Timezone,
+		}).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar perfil"})
 			return
 		}
@@ -76,6 +87,7 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{
 			"message":   "Perfil actualizado",
 			"avatarUrl": user.AvatarURL,
+			"timezone":  user.Timezone,
 		})
 	}
 }
@@ -0,0 +1,118 @@
+// api/handlers/query_fields.go
+// Central allowlists of the columns each resource may be sorted or filtered by. Any
+// client-supplied field name that ends up in SQL (ORDER BY, dynamic WHERE columns)
+// must be checked here first; values are always passed as bind parameters.
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// queryFieldSet lists the sortable and filterable columns of one resource.
+type queryFieldSet struct {
+	Sort   map[string]bool
+	Filter map[string]bool
+	Range  map[string]bool // Filter keys holding a {from, to} range rather than a column value
+	Date   map[string]bool // Date columns a date range may be applied to
+}
+
+// queryFieldSets maps a resource name to its allowlist.
+var queryFieldSets = map[string]queryFieldSet{
+	"cases": {
+		Sort:   fieldSet("created_at", "updated_at", "title", "status", "priority", "category", "current_stage", "docket_number"),
+		Filter: fieldSet("status", "category", "current_stage", "office_id", "priority", "date_range"),
+		Range:  fieldSet("date_range"), // Expanded to created_at bounds
+		Date:   fieldSet("created_at", "updated_at"),
+	},
+	"appointments": {
+		Sort:   fieldSet("start_time", "end_time", "title", "status", "created_at", "updated_at"),
+		Filter: fieldSet("status", "department", "staff_id", "start_time", "appointment_type"),
+		Range:  fieldSet("start_time"),
+		Date:   fieldSet("start_time", "end_time", "created_at", "updated_at"),
+	},
+	"users": {
+		Sort:   fieldSet("first_name", "last_name", "email", "role", "created_at", "updated_at", "last_login"),
+		Filter: fieldSet("role", "department", "office_id", "is_active"),
+		Date:   fieldSet("created_at", "updated_at", "last_login"),
+	},
+	"offices": {
+		Sort:   fieldSet("name", "code", "created_at"),
+		Filter: fieldSet("code"),
+		Date:   fieldSet("created_at", "updated_at"),
+	},
+}
+
+func fieldSet(fields ...string) map[string]bool {
+	set := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		set[field] = true
+	}
+	return set
+}
+
+// sortClause returns "column ASC|DESC" for field when resource allows sorting by it,
+// otherwise fallback. Any order other than "asc" (case-insensitive) sorts descending.
+func sortClause(resource, field, order, fallback string) string {
+	if !queryFieldSets[resource].Sort[field] {
+		return fallback
+	}
+	direction := "DESC"
+	if strings.EqualFold(order, "asc") {
+		direction = "ASC"
+	}
+	return fmt.Sprintf("%s %s", field, direction)
+}
+
+// isFilterField reports whether resource may be filtered by field.
+func isFilterField(resource, field string) bool {
+	return queryFieldSets[resource].Filter[field]
+}
+
+// allowedFilters returns only the filters resource may be filtered by, so unknown
+// keys never reach a query or a cache key.
+func allowedFilters(resource string, filters map[string]interface{}) map[string]interface{} {
+	allowed := make(map[string]interface{}, len(filters))
+	for key, value := range filters {
+		if isFilterField(resource, key) {
+			allowed[key] = value
+		}
+	}
+	return allowed
+}
+
+// isColumnFilter reports whether field is a plain column resource may be filtered by with "column = value".
+func isColumnFilter(resource, field string) bool {
+	set := queryFieldSets[resource]
+	return set.Filter[field] && !set.Range[field]
+}
+
+// isDateField reports whether resource may be filtered by a date range on field.
+func isDateField(resource, field string) bool {
+	return queryFieldSets[resource].Date[field]
+}
+
+// unknownColumnFilters returns, sorted, the filter keys that are not plain filter columns of resource.
+func unknownColumnFilters(resource string, filters map[string]interface{}) []string {
+	unknown := make([]string, 0)
+	for key := range filters {
+		if !isColumnFilter(resource, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// columnFilters returns, sorted, the plain filter columns of resource.
+func columnFilters(resource string) []string {
+	columns := make([]string, 0)
+	for field := range queryFieldSets[resource].Filter {
+		if isColumnFilter(resource, field) {
+			columns = append(columns, field)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
@@ -0,0 +1,191 @@
+// api/handlers/query_fields_test.go
+// Unit tests for the central sort/filter allowlists and the endpoints that use them.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// injectionKeys are field names an attacker might send as sort or filter keys.
+var injectionKeys = []string{
+	"id=1 OR 1=1 --",
+	"created_at; DROP TABLE users; --",
+	"status) OR (1=1",
+	"title DESC, (SELECT password FROM users LIMIT 1)",
+	`"status"`,
+	"STATUS",
+}
+
+func TestSortClause(t *testing.T) {
+	tests := []struct {
+		resource, field, order, want string
+	}{
+		{"cases", "title", "asc", "title ASC"},
+		{"cases", "title", "ASC", "title ASC"},
+		{"cases", "title", "desc", "title DESC"},
+		{"cases", "title", "sideways", "title DESC"},
+		{"appointments", "start_time", "asc", "start_time ASC"},
+		{"users", "last_login", "desc", "last_login DESC"},
+		{"users", "password", "asc", "created_at DESC"},        // Not sortable
+		{"appointments", "priority", "asc", "created_at DESC"}, // Sortable for cases only
+		{"unknown", "title", "asc", "created_at DESC"},
+	}
+	for _, tt := range tests {
+		if got := sortClause(tt.resource, tt.field, tt.order, "created_at DESC"); got != tt.want {
+			t.Errorf("sortClause(%q, %q, %q) = %q, want %q", tt.resource, tt.field, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortClauseRejectsInjection(t *testing.T) {
+	for resource := range queryFieldSets {
+		for _, key := range injectionKeys {
+			if got := sortClause(resource, key, "asc", "created_at DESC"); got != "created_at DESC" {
+				t.Errorf("sortClause(%q, %q) = %q, want fallback", resource, key, got)
+			}
+		}
+	}
+}
+
+func TestAllowedFiltersDropsInjectedKeys(t *testing.T) {
+	for resource := range queryFieldSets {
+		filters := map[string]interface{}{}
+		for _, key := range injectionKeys {
+			filters[key] = "x"
+		}
+		if got := allowedFilters(resource, filters); len(got) != 0 {
+			t.Errorf("allowedFilters(%q) kept %v, want none", resource, got)
+		}
+	}
+
+	got := allowedFilters("cases", map[string]interface{}{"status": "open", "id=1 OR 1=1 --": "x"})
+	if len(got) != 1 || got["status"] != "open" {
+		t.Errorf("allowedFilters kept %v, want only status", got)
+	}
+}
+
+func TestOptimizedQueriesIgnoreInjectedKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &PerformanceOptimizedHandler{db: dryRunDB(t)}
+
+	values := url.Values{}
+	values.Set("status", "open")
+	values.Set("role", "lawyer")
+	values.Set("sortBy", injectionKeys[1])
+	for _, key := range injectionKeys {
+		values.Set(key, "1")
+	}
+
+	builders := map[string]func(PaginationParams, *gin.Context) *gorm.DB{
+		"cases":        h.buildOptimizedCasesQuery,
+		"appointments": h.buildOptimizedAppointmentsQuery,
+		"users":        h.buildOptimizedUsersQuery,
+	}
+	allowedFilter := map[string]string{"cases": "status = $", "appointments": "status = $", "users": "role = $"}
+	for resource, build := range builders {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/"+resource+"?"+values.Encode(), nil)
+		c.Set("userRole", "admin")
+
+		params := h.parsePaginationParams(c, resource)
+		for key := range params.Filters {
+			if !isFilterField(resource, key) {
+				t.Errorf("%s: filter %q was not dropped", resource, key)
+			}
+		}
+
+		var sql string
+		switch resource {
+		case "cases":
+			sql = build(params, c).Find(&[]models.Case{}).Statement.SQL.String()
+		case "appointments":
+			sql = build(params, c).Find(&[]models.Appointment{}).Statement.SQL.String()
+		default:
+			sql = build(params, c).Find(&[]models.User{}).Statement.SQL.String()
+		}
+		for _, fragment := range []string{"1=1", "DROP", "password", "--"} {
+			if strings.Contains(sql, fragment) {
+				t.Errorf("%s: generated SQL contains %q: %s", resource, fragment, sql)
+			}
+		}
+		if !strings.Contains(sql, allowedFilter[resource]) {
+			t.Errorf("%s: allowed filter %q missing from SQL: %s", resource, allowedFilter[resource], sql)
+		}
+	}
+}
+
+// postExport sends body to ExportData and returns the recorded response.
+func postExport(t *testing.T, db *gorm.DB, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/export", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	ExportData(db)(c)
+	return w
+}
+
+func TestExportDataRejectsMaliciousFilterKeys(t *testing.T) {
+	db := dryRunDB(t)
+	for _, dataType := range []string{"users", "cases"} {
+		for _, key := range injectionKeys {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"type":    dataType,
+				"filters": map[string]interface{}{key: "1"},
+			})
+			w := postExport(t, db, string(payload))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s filter %q: status = %d, want 400", dataType, key, w.Code)
+				continue
+			}
+			var response struct {
+				Fields []string `json:"fields"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Fields) != 1 || response.Fields[0] != key {
+				t.Errorf("%s filter %q: body %s does not name the rejected key", dataType, key, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestExportDataRejectsMaliciousDateField(t *testing.T) {
+	db := dryRunDB(t)
+	for _, key := range append(injectionKeys, "password") {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":      "users",
+			"dateField": key,
+			"dateFrom":  "2024-01-01",
+		})
+		if w := postExport(t, db, string(payload)); w.Code != http.StatusBadRequest {
+			t.Errorf("dateField %q: status = %d, want 400", key, w.Code)
+		}
+	}
+
+	if w := postExport(t, db, `{"type": "users", "dateFrom": "2024-01-01 OR 1=1"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed dateFrom: status = %d, want 400", w.Code)
+	}
+	if w := postExport(t, db, `{"type": "users", "filters": {"role": ["admin", "lawyer"]}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("non-scalar filter value: status = %d, want 400", w.Code)
+	}
+}
+
+func TestExportDataAcceptsAllowedFilters(t *testing.T) {
+	db := dryRunDB(t)
+	w := postExport(t, db, `{"type": "cases", "filters": {"status": "open", "office_id": 2}, "dateFrom": "2024-01-01", "dateTo": "2024-01-31"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Body.String(), "ID,Title,Category,Status,Client,Created At") {
+		t.Errorf("unexpected CSV: %q", w.Body.String())
+	}
+}
@@ -0,0 +1,292 @@
+// api/handlers/record_retention.go
+// Deleted record retention: the record_retention maintenance task permanently purges cases,
+// appointments and documents soft-deleted more than config.DeletedRecordRetentionDays ago,
+// together with their files in storage. Every purged record gets a critical audit entry.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordPurgePlan lists the soft-deleted records past the retention window and their files.
+// Appointments and documents of a purged case go with it and are only listed under CaseIDs.
+type recordPurgePlan struct {
+	RetentionDays  int       `json:"retentionDays"`
+	Cutoff         time.Time `json:"cutoff"`
+	CaseIDs        []uint    `json:"caseIds"`
+	AppointmentIDs []uint    `json:"appointmentIds"`
+	DocumentIDs    []uint    `json:"documentIds"`
+	Files          []string  `json:"files"`
+}
+
+func (p *recordPurgePlan) empty() bool {
+	return len(p.CaseIDs) == 0 && len(p.AppointmentIDs) == 0 && len(p.DocumentIDs) == 0
+}
+
+// recordRetentionCutoff returns the deletion time before which records are purged.
+func recordRetentionCutoff(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
+
+// planRecordPurge selects the records deleted before the cutoff and the stored files they
+// reference: every upload of a purged case, and each purged document's current file and
+// earlier versions.
+func planRecordPurge(db *gorm.DB, days int, now time.Time) (*recordPurgePlan, error) {
+	cutoff := recordRetentionCutoff(now, days)
+	plan := &recordPurgePlan{
+		RetentionDays:  days,
+		Cutoff:         cutoff,
+		CaseIDs:        make([]uint, 0),
+		AppointmentIDs: make([]uint, 0),
+		DocumentIDs:    make([]uint, 0),
+		Files:          make([]string, 0),
+	}
+
+	if err := db.Model(&models.Case{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("id").Pluck("id", &plan.CaseIDs).Error; err != nil {
+		return nil, err
+	}
+	expiredCases := db.Model(&models.Case{}).Select("id").Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	if err := db.Unscoped().Model(&models.Appointment{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND case_id NOT IN (?)", cutoff, expiredCases).
+		Order("id").Pluck("id", &plan.AppointmentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Unscoped().Model(&models.CaseEvent{}).
+		Where("file_url IS NOT NULL AND file_url <> ''").
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND case_id NOT IN (?)", cutoff, expiredCases).
+		Order("id").Pluck("id", &plan.DocumentIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(plan.CaseIDs) == 0 && len(plan.DocumentIDs) == 0 {
+		return plan, nil
+	}
+
+	var uploads []struct {
+		ID      uint
+		FileUrl string
+	}
+	if err := db.Unscoped().Model(&models.CaseEvent{}).
+		Select("id", "file_url").
+		Where("file_url IS NOT NULL AND file_url <> ''").
+		Where("case_id IN ? OR id IN ?", plan.CaseIDs, plan.DocumentIDs).
+		Scan(&uploads).Error; err != nil {
+		return nil, err
+	}
+	files := make(map[string]struct{}, len(uploads))
+	eventIDs := make([]uint, 0, len(uploads))
+	for _, upload := range uploads {
+		files[upload.FileUrl] = struct{}{}
+		eventIDs = append(eventIDs, upload.ID)
+	}
+	if len(eventIDs) > 0 {
+		var versions []string
+		if err := db.Model(&models.DocumentVersion{}).
+			Where("document_event_id IN ?", eventIDs).
+			Pluck("s3_key", &versions).Error; err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			files[version] = struct{}{}
+		}
+	}
+	for file := range files {
+		plan.Files = append(plan.Files, file)
+	}
+	sort.Strings(plan.Files)
+	return plan, nil
+}
+
+// purgeDeletedRecords permanently deletes the records of planRecordPurge in one transaction,
+// with a critical audit entry each, then removes their files from storage. A file that cannot
+// be deleted is no longer referenced and is left to orphan cleanup.
+func purgeDeletedRecords(db *gorm.DB, dryRun bool, runID uint, triggeredBy *uint) (string, error) {
+	days := config.DeletedRecordRetentionDays()
+	if days == 0 {
+		return "Deleted record retention is disabled (DELETED_RECORD_RETENTION_DAYS=0)", errMaintenanceSkipped
+	}
+	plan, err := planRecordPurge(db, days, time.Now())
+	if err != nil {
+		return "", err
+	}
+	summary := fmt.Sprintf("%d cases, %d appointments and %d documents deleted before %s",
+		len(plan.CaseIDs), len(plan.AppointmentIDs), len(plan.DocumentIDs), plan.Cutoff.Format("2006-01-02"))
+	if dryRun {
+		return fmt.Sprintf("%s would be purged with %d files (dry run)", summary, len(plan.Files)), nil
+	}
+	if plan.empty() {
+		return fmt.Sprintf("No records deleted before %s to purge", plan.Cutoff.Format("2006-01-02")), nil
+	}
+	store := storage.GetActiveStorage()
+	if store == nil && len(plan.Files) > 0 {
+		return "No storage backend to delete files from; nothing was purged", errMaintenanceSkipped
+	}
+	actor, err := retentionActor(db, triggeredBy)
+	if err != nil {
+		return "No user to attribute the purge to; nothing was purged", errMaintenanceSkipped
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(plan.CaseIDs) > 0 {
+			if err := purgeCaseRecords(tx, plan.CaseIDs); err != nil {
+				return err
+			}
+		}
+		if len(plan.AppointmentIDs) > 0 {
+			if err := tx.Unscoped().Where("id IN ?", plan.AppointmentIDs).Delete(&models.Appointment{}).Error; err != nil {
+				return fmt.Errorf("failed to delete appointments: %w", err)
+			}
+		}
+		if len(plan.DocumentIDs) > 0 {
+			if err := tx.Where("document_event_id IN ?", plan.DocumentIDs).Delete(&models.DocumentVersion{}).Error; err != nil {
+				return fmt.Errorf("failed to delete document versions: %w", err)
+			}
+			if err := tx.Unscoped().Where("id IN ?", plan.DocumentIDs).Delete(&models.CaseEvent{}).Error; err != nil {
+				return fmt.Errorf("failed to delete documents: %w", err)
+			}
+		}
+
+		for _, purged := range []struct {
+			entityType string
+			ids        []uint
+		}{{"case", plan.CaseIDs}, {"appointment", plan.AppointmentIDs}, {"document", plan.DocumentIDs}} {
+			for _, id := range purged.ids {
+				entry := recordPurgeAuditLog(actor, triggeredBy == nil, purged.entityType, id, plan, runID)
+				if err := tx.Create(&entry).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	deleted, failed := 0, 0
+	for _, file := range plan.Files {
+		if err := store.Delete(file); err != nil {
+			log.Printf("Failed to delete purged file %s: %v", file, err)
+			failed++
+			continue
+		}
+		deleted++
+	}
+	details := fmt.Sprintf("Purged %s; deleted %d of %d files", summary, deleted, len(plan.Files))
+	if failed > 0 {
+		return details, fmt.Errorf("failed to delete %d files of purged records", failed)
+	}
+	return details, nil
+}
+
+// purgeCaseRecords permanently deletes cases and their events, appointments, tasks, admin notes and
+// assignments, in the order of PermanentlyDeleteCase. Audit logs are kept; they follow
+// audit_retention.
+func purgeCaseRecords(tx *gorm.DB, caseIDs []uint) error {
+	if err := tx.Where("document_event_id IN (SELECT id FROM case_events WHERE case_id IN ?)", caseIDs).Delete(&models.DocumentVersion{}).Error; err != nil {
+		return fmt.Errorf("failed to delete document versions: %w", err)
+	}
+	if err := tx.Unscoped().Where("case_id IN ?", caseIDs).Delete(&models.CaseEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to delete case events: %w", err)
+	}
+	if err := tx.Unscoped().Where("case_id IN ?", caseIDs).Delete(&models.Appointment{}).Error; err != nil {
+		return fmt.Errorf("failed to delete case appointments: %w", err)
+	}
+	if err := tx.Unscoped().Where("task_id IN (SELECT id FROM tasks WHERE case_id IN ?)", caseIDs).Delete(&models.TaskComment{}).Error; err != nil {
+		return fmt.Errorf("failed to delete task comments: %w", err)
+	}
+	if err := tx.Unscoped().Where("case_id IN ?", caseIDs).Delete(&models.Task{}).Error; err != nil {
+		return fmt.Errorf("failed to delete case tasks: %w", err)
+	}
+	if err := tx.Unscoped().Where("case_id IN ?", caseIDs).Delete(&models.UserCaseAssignment{}).Error; err != nil {
+		return fmt.Errorf("failed to delete user case assignments: %w", err)
+	}
+	// Databases created before migration 0043 still tie admin notes to cases, without cascade
+	if tx.Migrator().HasColumn(&models.AdminNote{}, "case_id") {
+		if err := tx.Unscoped().Where("case_id IN ?", caseIDs).Delete(&models.AdminNote{}).Error; err != nil {
+			return fmt.Errorf("failed to delete case admin notes: %w", err)
+		}
+	}
+	if err := tx.Where("id IN ?", caseIDs).Delete(&models.Case{}).Error; err != nil {
+		return fmt.Errorf("failed to delete cases: %w", err)
+	}
+	return nil
+}
+
+// retentionActor returns the user purges are attributed to, since audit_logs.user_id must
+// reference a user: whoever triggered the run or, for scheduled runs, the first active admin.
+func retentionActor(db *gorm.DB, triggeredBy *uint) (models.User, error) {
+	var user models.User
+	query := db.Select("id", "role", "office_id", "department")
+	if triggeredBy != nil {
+		return user, query.First(&user, *triggeredBy).Error
+	}
+	return user, query.Where("role = ? AND is_active = ?", config.RoleAdmin, true).Order("id").First(&user).Error
+}
+
+// recordPurgeAuditLog builds the critical audit entry for one purged record.
+func recordPurgeAuditLog(actor models.User, scheduled bool, entityType string, entityID uint, plan *recordPurgePlan, runID uint) models.AuditLog {
+	entry := models.AuditLog{
+		EntityType:     entityType,
+		EntityID:       entityID,
+		Action:         "purge",
+		Reason:         fmt.Sprintf("Deleted more than %d days ago", plan.RetentionDays),
+		UserID:         actor.ID,
+		UserRole:       actor.Role,
+		UserOfficeID:   actor.OfficeID,
+		UserDepartment: actor.Department,
+		Severity:       "critical",
+		Tags:           []string{"retention"},
+	}
+	if scheduled {
+		entry.Tags = append(entry.Tags, "scheduled")
+	}
+	values := map[string]interface{}{"retentionDays": plan.RetentionDays, "deletedBefore": plan.Cutoff}
+	if runID != 0 {
+		values["maintenanceRunId"] = runID
+	}
+	if encoded, err := json.Marshal(values); err == nil {
+		newValues := string(encoded)
+		entry.NewValues = &newValues
+	}
+	return entry
+}
+
+// GetRecordRetentionPreview reports what the record_retention task would purge now, without
+// deleting anything.
+func GetRecordRetentionPreview(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := config.DeletedRecordRetentionDays()
+		if days == 0 {
+			c.JSON(http.StatusOK, gin.H{"enabled": false, "retentionDays": 0})
+			return
+		}
+		plan, err := planRecordPurge(db, days, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to select records to purge", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"enabled": true,
+			"plan":    plan,
+			"counts": gin.H{
+				"cases":        len(plan.CaseIDs),
+				"appointments": len(plan.AppointmentIDs),
+				"documents":    len(plan.DocumentIDs),
+				"files":        len(plan.Files),
+			},
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// api/handlers/record_retention_test.go
+// Unit tests for deleted record retention: which records fall past the window, and that purging
+// them deletes their stored files and writes critical audit entries.
+package handlers
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"gorm.io/gorm"
+)
+
+// deleteRecordingStorage records the files deleted from it and fails on those in failing.
+type deleteRecordingStorage struct {
+	streamingStorage
+	mutex   sync.Mutex
+	deleted []string
+	failing map[string]bool
+}
+
+func (s *deleteRecordingStorage) Delete(fileURL string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failing[fileURL] {
+		return errors.New("access denied")
+	}
+	s.deleted = append(s.deleted, fileURL)
+	return nil
+}
+
+// retentionScript serves case 7, deleted with two uploads, appointment 20 of a case that
+// stays and document 12 of case 9 with an earlier version, all deleted before the cutoff.
+// cutoffs receives the cutoff of each selection query.
+func retentionScript(cutoffs *[]time.Time) *scriptedSQL {
+	var mutex sync.Mutex
+	return &scriptedSQL{
+		observe: func(query string, args []driver.Value) {
+			if !strings.HasPrefix(query, "SELECT") || !strings.Contains(query, "deleted_at < $1") {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if cutoff, ok := args[0].(time.Time); ok {
+				*cutoffs = append(*cutoffs, cutoff)
+			}
+		},
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.HasPrefix(query, `SELECT "id" FROM "appointments"`):
+				return []string{"id"}, [][]driver.Value{{int64(20)}}
+			case strings.HasPrefix(query, `SELECT "id" FROM "case_events"`):
+				return []string{"id"}, [][]driver.Value{{int64(12)}}
+			case strings.HasPrefix(query, `SELECT "id" FROM "cases"`):
+				return []string{"id"}, [][]driver.Value{{int64(7)}}
+			case strings.HasPrefix(query, `SELECT "id","file_url" FROM "case_events"`):
+				return []string{"id", "file_url"}, [][]driver.Value{
+					{int64(10), "cases/7/acta.pdf"},
+					{int64(11), "cases/7/identificacion.pdf"},
+					{int64(12), "cases/9/sentencia-v2.pdf"},
+				}
+			case strings.HasPrefix(query, `SELECT "s3_key" FROM "document_versions"`):
+				return []string{"s3_key"}, [][]driver.Value{{"cases/9/sentencia.pdf"}, {"cases/9/sentencia-v2.pdf"}}
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "role"}, [][]driver.Value{{int64(1), "admin"}}
+			}
+			return nil, nil
+		},
+		affected: func(string) int64 { return 1 },
+	}
+}
+
+func TestPlanRecordPurgeCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	if got, want := recordRetentionCutoff(now, 30), time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("recordRetentionCutoff = %s, want %s", got, want)
+	}
+
+	var cutoffs []time.Time
+	script := retentionScript(&cutoffs)
+	plan, err := planRecordPurge(scriptedDB(t, script), 30, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cases, appointments and documents are all selected against the same cutoff
+	if len(cutoffs) != 3 {
+		t.Fatalf("selection queries = %v", script.ran("SELECT"))
+	}
+	for _, cutoff := range cutoffs {
+		if !cutoff.Equal(plan.Cutoff) || !cutoff.Equal(recordRetentionCutoff(now, 30)) {
+			t.Errorf("selected against %s, want %s", cutoff, plan.Cutoff)
+		}
+	}
+	// Appointments and documents of purged cases are left to the case
+	for _, query := range script.ran(`deleted_at < $1 AND case_id NOT IN (SELECT "id" FROM "cases"`) {
+		if !strings.Contains(query, "deleted_at IS NOT NULL AND deleted_at < $2") {
+			t.Errorf("purged case subquery missing its cutoff: %s", query)
+		}
+	}
+	if len(script.ran(`case_id NOT IN (SELECT "id" FROM "cases"`)) != 2 {
+		t.Errorf("appointments and documents not excluding purged cases: %v", script.ran("SELECT"))
+	}
+
+	want := &recordPurgePlan{
+		RetentionDays:  30,
+		Cutoff:         recordRetentionCutoff(now, 30),
+		CaseIDs:        []uint{7},
+		AppointmentIDs: []uint{20},
+		DocumentIDs:    []uint{12},
+		Files:          []string{"cases/7/acta.pdf", "cases/7/identificacion.pdf", "cases/9/sentencia-v2.pdf", "cases/9/sentencia.pdf"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("plan = %+v, want %+v", plan, want)
+	}
+}
+
+func TestPurgeDeletedRecordsDeletesStoredFiles(t *testing.T) {
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "30")
+	store := &deleteRecordingStorage{}
+	useStorage(t, store)
+	var cutoffs []time.Time
+	script := retentionScript(&cutoffs)
+	adminID := uint(1)
+
+	details, err := purgeDeletedRecords(scriptedDB(t, script), false, 5, &adminID)
+	if err != nil {
+		t.Fatalf("purge failed: %v (%s)", err, details)
+	}
+	wantFiles := []string{"cases/7/acta.pdf", "cases/7/identificacion.pdf", "cases/9/sentencia-v2.pdf", "cases/9/sentencia.pdf"}
+	if !reflect.DeepEqual(store.deleted, wantFiles) {
+		t.Errorf("deleted files = %v, want %v", store.deleted, wantFiles)
+	}
+	if len(script.ran("BEGIN")) != 1 || len(script.ran("COMMIT")) != 1 {
+		t.Errorf("purge did not run in one transaction: %v", script.ran(""))
+	}
+	for _, statement := range []string{`DELETE FROM "cases"`, `DELETE FROM "appointments"`, `DELETE FROM "case_events"`, `DELETE FROM "document_versions"`, `DELETE FROM "tasks"`} {
+		if len(script.ran(statement)) == 0 {
+			t.Errorf("%s did not run", statement)
+		}
+	}
+	if soft := script.ran(`UPDATE "appointments" SET "deleted_at"`); len(soft) != 0 {
+		t.Errorf("appointments soft-deleted instead of purged: %v", soft)
+	}
+	if dropped := script.ran(`DELETE FROM "audit_logs"`); len(dropped) != 0 {
+		t.Errorf("audit logs deleted with the case: %v", dropped)
+	}
+	entries := script.ran(`INSERT INTO "audit_logs"`)
+	if len(entries) != 3 {
+		t.Fatalf("audit entries = %v", entries)
+	}
+
+	// Files left behind fail the run once the records are gone
+	store = &deleteRecordingStorage{failing: map[string]bool{"cases/7/acta.pdf": true}}
+	useStorage(t, store)
+	script = retentionScript(&cutoffs)
+	if _, err := purgeDeletedRecords(scriptedDB(t, script), false, 6, &adminID); err == nil || len(store.deleted) != 3 {
+		t.Errorf("failed delete: err %v, deleted %v", err, store.deleted)
+	}
+	if len(script.ran("COMMIT")) != 1 {
+		t.Errorf("records not purged: %v", script.ran(""))
+	}
+}
+
+func TestPurgeDeletedRecordsAuditsCritical(t *testing.T) {
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "30")
+	useStorage(t, &deleteRecordingStorage{})
+	var mutex sync.Mutex
+	severities := make([]interface{}, 0)
+	var cutoffs []time.Time
+	script := retentionScript(&cutoffs)
+	observe := script.observe
+	script.observe = func(query string, args []driver.Value) {
+		observe(query, args)
+		if strings.HasPrefix(query, `INSERT INTO "audit_logs"`) {
+			mutex.Lock()
+			severities = append(severities, statementValues(query, args)["severity"])
+			mutex.Unlock()
+		}
+	}
+
+	if _, err := purgeDeletedRecords(scriptedDB(t, script), false, 5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(severities, []interface{}{"critical", "critical", "critical"}) {
+		t.Errorf("audit severities = %v", severities)
+	}
+	// Scheduled runs are attributed to the first active admin
+	if lookups := script.ran(`FROM "users" WHERE (role = $1 AND is_active = $2)`); len(lookups) != 1 {
+		t.Errorf("admin lookups = %v", script.ran(`FROM "users"`))
+	}
+}
+
+func TestPurgeDeletedRecordsDryRun(t *testing.T) {
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "30")
+	store := &deleteRecordingStorage{}
+	useStorage(t, store)
+	var cutoffs []time.Time
+	script := retentionScript(&cutoffs)
+
+	details, err := purgeDeletedRecords(scriptedDB(t, script), true, 0, nil)
+	if err != nil || !strings.Contains(details, "1 cases, 1 appointments and 1 documents") || !strings.Contains(details, "4 files (dry run)") {
+		t.Errorf("details = %q, err %v", details, err)
+	}
+	if len(store.deleted) != 0 || len(script.ran("DELETE")) != 0 || len(script.ran("INSERT")) != 0 {
+		t.Errorf("dry run changed something: files %v, statements %v", store.deleted, script.ran(""))
+	}
+
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "0")
+	if _, err := purgeDeletedRecords(scriptedDB(t, script), false, 0, nil); !errors.Is(err, errMaintenanceSkipped) {
+		t.Errorf("disabled retention: err %v", err)
+	}
+}
+
+func TestPurgeDeletedRecordsDeletesCaseAdminNotes(t *testing.T) {
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "30")
+	useStorage(t, &deleteRecordingStorage{})
+	var cutoffs []time.Time
+	script := retentionScript(&cutoffs)
+	rows := script.rows
+	// A database from before migration 0043, where the purged case 7 still has admin notes
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "INFORMATION_SCHEMA.columns") {
+			return countRow(1)
+		}
+		return rows(query, args)
+	}
+	adminID := uint(1)
+
+	if _, err := purgeDeletedRecords(scriptedDB(t, script), false, 5, &adminID); err != nil {
+		t.Fatal(err)
+	}
+	notes, cases := statementIndex(script, `DELETE FROM "admin_notes" WHERE case_id IN`), statementIndex(script, `DELETE FROM "cases"`)
+	if notes < 0 || notes > cases {
+		t.Errorf("admin notes not deleted before the case: %v", script.statements)
+	}
+
+	// Without the column there is nothing tying notes to cases
+	script = retentionScript(&cutoffs)
+	if _, err := purgeDeletedRecords(scriptedDB(t, script), false, 6, &adminID); err != nil {
+		t.Fatal(err)
+	}
+	if deleted := script.ran(`DELETE FROM "admin_notes"`); len(deleted) != 0 {
+		t.Errorf("admin notes deleted by case: %v", deleted)
+	}
+}
+
+func TestPurgeDeletedRecordsRemovesExpiredRows(t *testing.T) {
+	t.Setenv("DELETED_RECORD_RETENTION_DAYS", "30")
+	store := &deleteRecordingStorage{}
+	useStorage(t, store)
+	db := schemaDB(t, &models.User{}, &models.Case{}, &models.CaseEvent{}, &models.DocumentVersion{}, &models.Appointment{},
+		&models.Task{}, &models.TaskComment{}, &models.AuditLog{})
+	expired := gorm.DeletedAt{Time: time.Now().AddDate(0, 0, -40), Valid: true}
+	recent := gorm.DeletedAt{Time: time.Now().AddDate(0, 0, -5), Valid: true}
+	now := time.Now()
+	// Case 7 and everything on it, appointment 20 and document 12 of case 9 are past the window;
+	// case 8, appointment 21 and document 13 were deleted within it
+	seedRows(t, db,
+		&models.User{ID: 1, FirstName: "Ana", LastName: "Admin", Email: "admin@caf.mx", Password: "x", Role: "admin", IsActive: true},
+		&models.Case{ID: 7, CaseNumber: "CAF-0007", OfficeID: 2, Title: "Divorcio", DeletedAt: &expired.Time},
+		&models.Case{ID: 8, CaseNumber: "CAF-0008", OfficeID: 2, Title: "Pensión alimenticia", DeletedAt: &recent.Time},
+		&models.Case{ID: 9, CaseNumber: "CAF-0009", OfficeID: 2, Title: "Custodia"},
+		&models.CaseEvent{ID: 10, CaseID: 7, UserID: 1, EventType: "file_upload", FileUrl: "cases/7/acta.pdf"},
+		&models.CaseEvent{ID: 11, CaseID: 7, UserID: 1, EventType: "comment", CommentText: "Documentos recibidos"},
+		&models.CaseEvent{ID: 12, CaseID: 9, UserID: 1, EventType: "file_upload", FileUrl: "cases/9/sentencia-v2.pdf", DeletedAt: expired},
+		&models.CaseEvent{ID: 13, CaseID: 9, UserID: 1, EventType: "file_upload", FileUrl: "cases/9/poder.pdf", DeletedAt: recent},
+		&models.DocumentVersion{ID: 1, DocumentEventID: 12, Version: 1, S3Key: "cases/9/sentencia.pdf"},
+		&models.Appointment{ID: 19, CaseID: 7, StaffID: 1, OfficeID: 2, Title: "Audiencia", StartTime: now, EndTime: now},
+		&models.Appointment{ID: 20, CaseID: 9, StaffID: 1, OfficeID: 2, Title: "Primera cita", StartTime: now, EndTime: now, DeletedAt: expired},
+		&models.Appointment{ID: 21, CaseID: 9, StaffID: 1, OfficeID: 2, Title: "Seguimiento", StartTime: now, EndTime: now, DeletedAt: recent},
+		&models.Task{ID: 30, CaseID: 7, Title: "Solicitar acta"},
+		&models.TaskComment{ID: 31, TaskID: 30, UserID: 1, Comment: "Pendiente"},
+		&models.UserCaseAssignment{UserID: 1, CaseID: 7, Role: "primary"},
+	)
+	adminID := uint(1)
+
+	if details, err := purgeDeletedRecords(db, false, 5, &adminID); err != nil {
+		t.Fatalf("purge failed: %v (%s)", err, details)
+	}
+	remaining := func(model interface{}) []uint {
+		ids := make([]uint, 0)
+		db.Unscoped().Model(model).Order("id").Pluck("id", &ids)
+		return ids
+	}
+	for _, table := range []struct {
+		model interface{}
+		want  []uint
+	}{
+		{&models.Case{}, []uint{8, 9}},
+		{&models.CaseEvent{}, []uint{13}},
+		{&models.DocumentVersion{}, []uint{}},
+		{&models.Appointment{}, []uint{21}},
+		{&models.Task{}, []uint{}},
+		{&models.TaskComment{}, []uint{}},
+		{&models.UserCaseAssignment{}, []uint{}},
+	} {
+		if ids := remaining(table.model); !reflect.DeepEqual(ids, table.want) {
+			t.Errorf("%T rows left = %v, want %v", table.model, ids, table.want)
+		}
+	}
+	wantFiles := []string{"cases/7/acta.pdf", "cases/9/sentencia-v2.pdf", "cases/9/sentencia.pdf"}
+	if !reflect.DeepEqual(store.deleted, wantFiles) {
+		t.Errorf("deleted files = %v, want %v", store.deleted, wantFiles)
+	}
+	var purged []models.AuditLog
+	db.Where("action = ?", "purge").Order("id").Find(&purged)
+	if len(purged) != 3 || purged[0].EntityID != 7 || purged[1].EntityID != 20 || purged[2].EntityID != 12 {
+		t.Errorf("purge audit entries = %+v", purged)
+	}
+}
@@ -0,0 +1,68 @@
+// api/handlers/record_visibility.go
+// Admin-only opt-in to see soft-deleted and archived records in case/appointment
+// list and detail endpoints, for support investigations.
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordVisibility holds the includeDeleted/includeArchived query flags.
+type recordVisibility struct {
+	IncludeDeleted  bool
+	IncludeArchived bool
+}
+
+// any reports whether any hidden records were requested.
+func (v recordVisibility) any() bool {
+	return v.IncludeDeleted || v.IncludeArchived
+}
+
+// requestedRecordVisibility parses the flags without checking the caller's role.
+func requestedRecordVisibility(c *gin.Context) recordVisibility {
+	return recordVisibility{
+		IncludeDeleted:  c.Query("includeDeleted") == "true",
+		IncludeArchived: c.Query("includeArchived") == "true",
+	}
+}
+
+// resolveRecordVisibility parses the flags and rejects them with 403 for non-admins.
+func resolveRecordVisibility(c *gin.Context) (recordVisibility, bool) {
+	visibility := requestedRecordVisibility(c)
+	if visibility.any() && c.GetString("userRole") != config.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "includeDeleted/includeArchived are only available to administrators"})
+		return recordVisibility{}, false
+	}
+	return visibility, true
+}
+
+// applyAppointmentVisibility widens an appointment query to soft-deleted rows when requested.
+// Archived appointments are soft-deleted completed ones; deleted appointments are the rest.
+func applyAppointmentVisibility(query *gorm.DB, visibility recordVisibility) *gorm.DB {
+	switch {
+	case visibility.IncludeDeleted && visibility.IncludeArchived:
+		return query.Unscoped()
+	case visibility.IncludeDeleted:
+		return query.Unscoped().Where("(appointments.deleted_at IS NULL OR appointments.status <> ?)", config.StatusCompleted)
+	case visibility.IncludeArchived:
+		return query.Unscoped().Where("(appointments.deleted_at IS NULL OR appointments.status = ?)", config.StatusCompleted)
+	}
+	return query
+}
+
+// flagAppointmentRecordState marks a soft-deleted appointment as "archived" or "deleted".
+func flagAppointmentRecordState(appointment *models.Appointment) {
+	if !appointment.DeletedAt.Valid {
+		return
+	}
+	if appointment.Status == config.StatusCompleted {
+		appointment.RecordState = "archived"
+	} else {
+		appointment.RecordState = "deleted"
+	}
+}
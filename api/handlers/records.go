@@ -36,8 +36,8 @@ func GetRecordsArchivedCases(db *gorm.DB) gin.HandlerFunc {
 
 		// Apply search filter
 		if search != "" {
-			query = query.Where("(title ILIKE ? OR docket_number ILIKE ?)",
-				"%"+search+"%", "%"+search+"%")
+			query = query.Where("(title ILIKE ? OR case_number ILIKE ? OR docket_number ILIKE ?)",
+				"%"+search+"%", "%"+search+"%", "%"+search+"%")
 		}
 
 		// Apply archive type filter
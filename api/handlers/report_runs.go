@@ -0,0 +1,200 @@
+// api/handlers/report_runs.go
+// Report runs: every report and export stores its parameters under a report ID so admins
+// can reproduce it later with POST /admin/reports/:id/rerun against current data.
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reportRunContextKey carries the run being re-executed, so the report reuses its ID.
+const reportRunContextKey = "reportRunID"
+
+// RerunReportInput configures a re-run. By default the stored date range is reused;
+// RelativePeriod re-resolves a named period (e.g. "month") against today instead.
+type RerunReportInput struct {
+	RelativePeriod bool `json:"relativePeriod"`
+}
+
+// recordReportRun stores the report's parameters and returns its report ID, also sent as the
+// X-Report-ID header. During a re-run it returns the original ID instead of creating a new run.
+// Returns 0 if the run could not be stored; the report itself is still served.
+func (rh *ReportsHandler) recordReportRun(c *gin.Context, reportType string, query QueryParams) uint {
+	if id, ok := c.Get(reportRunContextKey); ok {
+		runID := id.(uint)
+		c.Header("X-Report-ID", strconv.FormatUint(uint64(runID), 10))
+		return runID
+	}
+
+	run := models.ReportRun{
+		ReportType: reportType,
+		Query:      c.Request.URL.RawQuery,
+		DateFrom:   *query.DateFrom,
+		DateTo:     *query.DateTo,
+		RunByID:    extractUserIDUint(c),
+		RunByRole:  c.GetString("userRole"),
+	}
+	if !config.CanAccessAllOffices(run.RunByRole) {
+		if officeID, ok := c.Get("officeScopeID"); ok {
+			if id, ok := officeID.(uint); ok && id != 0 {
+				run.OfficeScopeID = &id
+			}
+		}
+	}
+	if err := rh.db.Create(&run).Error; err != nil {
+		log.Printf("WARNING: Failed to store %s report run: %v", reportType, err)
+		return 0
+	}
+	c.Header("X-Report-ID", strconv.FormatUint(uint64(run.ID), 10))
+	return run.ID
+}
+
+// withReportID copies a (possibly cached) report and adds its ID.
+func withReportID(report interface{}, reportID uint) interface{} {
+	data, ok := report.(gin.H)
+	if !ok {
+		return report
+	}
+	copied := make(gin.H, len(data)+1)
+	for key, value := range data {
+		copied[key] = value
+	}
+	copied["reportId"] = reportID
+	return copied
+}
+
+// GetReportRuns lists stored report runs, newest first, with ?reportType= and ?runById= filters.
+func (rh *ReportsHandler) GetReportRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := rh.db.Model(&models.ReportRun{})
+		if reportType := c.Query("reportType"); reportType != "" {
+			q = q.Where("report_type = ?", reportType)
+		}
+		if runBy := c.Query("runById"); runBy != "" {
+			q = q.Where("run_by_id = ?", runBy)
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+		if page < 1 {
+			page = 1
+		}
+		if pageSize < 1 || pageSize > 100 {
+			pageSize = 20
+		}
+
+		var total int64
+		if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener reportes", "message": err.Error()})
+			return
+		}
+
+		runs := make([]models.ReportRun, 0)
+		if err := q.Preload("RunBy", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "email", "role")
+		}).Order("created_at DESC, id DESC").
+			Limit(pageSize).
+			Offset((page - 1) * pageSize).
+			Find(&runs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener reportes", "message": err.Error()})
+			return
+		}
+
+		totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+		c.JSON(http.StatusOK, gin.H{
+			"data": runs,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < totalPages,
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
+
+// RerunReport re-executes a stored report with its original filters, office scope and
+// date range against current data, and responds exactly like the original report.
+func (rh *ReportsHandler) RerunReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var run models.ReportRun
+		if err := rh.db.First(&run, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reporte no encontrado"})
+			return
+		}
+
+		var input RerunReportInput
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "message": err.Error()})
+				return
+			}
+		}
+
+		params, err := url.ParseQuery(run.Query)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Los parámetros guardados del reporte no son válidos", "message": err.Error()})
+			return
+		}
+		if input.RelativePeriod && params.Get("period") != "" {
+			params.Del("dateFrom")
+			params.Del("dateTo")
+		} else {
+			params.Set("dateFrom", run.DateFrom.Format(time.RFC3339))
+			params.Set("dateTo", run.DateTo.Format(time.RFC3339))
+		}
+		c.Request.URL.RawQuery = params.Encode()
+
+		var report gin.HandlerFunc
+		switch run.ReportType {
+		case "summary":
+			report = rh.GetSummaryReport()
+		case "cases":
+			report = rh.GetCasesReport()
+		case "appointments":
+			report = rh.GetAppointmentsReport()
+		case "export":
+			report = rh.ExportReport()
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Tipo de reporte no válido", "message": run.ReportType})
+			return
+		}
+
+		now := time.Now()
+		if err := rh.db.Model(&run).Updates(map[string]interface{}{
+			"rerun_count":   gorm.Expr("rerun_count + 1"),
+			"last_rerun_at": now,
+		}).Error; err != nil {
+			log.Printf("WARNING: Failed to update report run %d: %v", run.ID, err)
+		}
+		recordAuditLog(rh.db, c, "report", run.ID, "rerun", "", map[string]interface{}{
+			"reportType":     run.ReportType,
+			"originalRunBy":  run.RunByID,
+			"relativePeriod": input.RelativePeriod,
+		})
+
+		// Reproduce the original caller's office restriction
+		if run.OfficeScopeID != nil {
+			c.Set("userRole", run.RunByRole)
+			c.Set("officeScopeID", *run.OfficeScopeID)
+		}
+		c.Set(reportRunContextKey, run.ID)
+		report(c)
+	}
+}
@@ -46,25 +46,49 @@ func (rh *ReportsHandler) GetSummaryReport() gin.HandlerFunc {
 			return
 		}
 
+		loc, ok := requestLocation(c, rh.db)
+		if !ok {
+			return
+		}
+
+		// Period key for the analytics cache; default ranges are keyed by period name and time zone
+		periodKey := "period=" + query.Period + "@" + loc.String()
+		if query.DateFrom != nil && query.DateTo != nil {
+			periodKey = query.DateFrom.Format(time.RFC3339) + "/" + query.DateTo.Format(time.RFC3339)
+		}
+
 		// Set default date range if not provided
 		if query.DateFrom == nil || query.DateTo == nil {
-			start, end := getPeriodRange(query.Period)
+			start, end := getPeriodRange(query.Period, loc)
 			query.DateFrom = &start
 			query.DateTo = &end
 		}
 
 		// Base query with office scoping for non-admin roles
 		dbq := rh.db.Model(&models.Case{})
+		var scopeOfficeID uint
 		if roleVal, exists := c.Get("userRole"); exists {
 			if role, ok := roleVal.(string); ok && !config.CanAccessAllOffices(role) {
 				if officeScopeVal, ok2 := c.Get("officeScopeID"); ok2 {
 					if officeID, ok3 := officeScopeVal.(uint); ok3 {
 						dbq = dbq.Where("office_id = ?", officeID)
+						scopeOfficeID = officeID
 					}
 				}
 			}
 		}
 
+		officeKey := "all"
+		if query.OfficeID != nil {
+			officeKey = fmt.Sprint(*query.OfficeID)
+		}
+		cacheKey := analyticsCacheKey("summary-report", scopeOfficeID, officeKey, query.Department, periodKey)
+		reportID := rh.recordReportRun(c, "summary", query)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, withReportID(cached, reportID))
+			return
+		}
+
 		// Apply filters
 		if query.Department != "" {
 			dbq = dbq.Where("category = ?", query.Department)
@@ -168,7 +192,8 @@ func (rh *ReportsHandler) GetSummaryReport() gin.HandlerFunc {
 			}, 0)
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		asOf := time.Now()
+		report := gin.H{
 			"totalCases":               totalCases,
 			"totalAppointments":        totalAppointments,
 			"casesByStatus":            casesByStatus,
@@ -178,7 +203,10 @@ func (rh *ReportsHandler) GetSummaryReport() gin.HandlerFunc {
 			"appointmentsByDepartment": appointmentsByDepartment,
 			"period":                   query.Period,
 			"dateRange":                []string{query.DateFrom.Format("2006-01-02"), query.DateTo.Format("2006-01-02")},
-		})
+			"asOf":                     asOf,
+		}
+		analyticsCache.set(cacheKey, report, asOf)
+		c.JSON(http.StatusOK, withReportID(report, reportID))
 	}
 }
 
@@ -194,7 +222,11 @@ func (rh *ReportsHandler) GetCasesReport() gin.HandlerFunc {
 
 		// Set default date range if not provided
 		if query.DateFrom == nil || query.DateTo == nil {
-			start, end := getPeriodRange(query.Period)
+			loc, ok := requestLocation(c, rh.db)
+			if !ok {
+				return
+			}
+			start, end := getPeriodRange(query.Period, loc)
 			query.DateFrom = &start
 			query.DateTo = &end
 		}
@@ -278,6 +310,7 @@ func (rh *ReportsHandler) GetCasesReport() gin.HandlerFunc {
 			"total":     len(caseReports),
 			"period":    query.Period,
 			"dateRange": []string{query.DateFrom.Format("2006-01-02"), query.DateTo.Format("2006-01-02")},
+			"reportId":  rh.recordReportRun(c, "cases", query),
 		})
 	}
 }
@@ -294,7 +327,11 @@ func (rh *ReportsHandler) GetAppointmentsReport() gin.HandlerFunc {
 
 		// Set default date range if not provided
 		if query.DateFrom == nil || query.DateTo == nil {
-			start, end := getPeriodRange(query.Period)
+			loc, ok := requestLocation(c, rh.db)
+			if !ok {
+				return
+			}
+			start, end := getPeriodRange(query.Period, loc)
 			query.DateFrom = &start
 			query.DateTo = &end
 		}
@@ -377,6 +414,7 @@ func (rh *ReportsHandler) GetAppointmentsReport() gin.HandlerFunc {
 			"total":     len(appointmentReports),
 			"period":    query.Period,
 			"dateRange": []string{query.DateFrom.Format("2006-01-02"), query.DateTo.Format("2006-01-02")},
+			"reportId":  rh.recordReportRun(c, "appointments", query),
 		})
 	}
 }
@@ -393,7 +431,11 @@ func (rh *ReportsHandler) ExportReport() gin.HandlerFunc {
 
 		// Set default date range if not provided
 		if query.DateFrom == nil || query.DateTo == nil {
-			start, end := getPeriodRange(query.Period)
+			loc, ok := requestLocation(c, rh.db)
+			if !ok {
+				return
+			}
+			start, end := getPeriodRange(query.Period, loc)
 			query.DateFrom = &start
 			query.DateTo = &end
 		}
@@ -418,6 +460,7 @@ func (rh *ReportsHandler) ExportReport() gin.HandlerFunc {
 			return
 		}
 
+		rh.recordReportRun(c, "export", query)
 		c.String(http.StatusOK, csvContent)
 	}
 }
@@ -482,9 +525,14 @@ func (rh *ReportsHandler) logExportActivity(userID string, query QueryParams, co
 		userID, query.ReportType, query.Format, contentSize)
 }
 
-// getPeriodRange returns the start and end dates for the specified period with enhanced logic
-func getPeriodRange(period string) (time.Time, time.Time) {
-	now := time.Now()
+// getPeriodRange returns the start and end dates for the specified period containing the
+// current time, with days starting at midnight in loc
+func getPeriodRange(period string, loc *time.Location) (time.Time, time.Time) {
+	return periodRange(period, time.Now().In(loc))
+}
+
+// periodRange returns the start and end dates for the specified period containing now, in now's location
+func periodRange(period string, now time.Time) (time.Time, time.Time) {
 	var start, end time.Time
 
 	switch period {
@@ -0,0 +1,63 @@
+// api/handlers/request_timezone.go
+// Time zone of report and dashboard periods: "today", "this week" and "this month" start at
+// midnight in the ?tz= zone, or else in the caller's own time zone or their office's, rather
+// than in the server's or the database's.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// userLocation returns the user's time zone, else their office's, else the server's. Unset or
+// unknown zones are skipped.
+func userLocation(db *gorm.DB, userID uint) (*time.Location, error) {
+	var zones struct {
+		UserTimezone   string
+		OfficeTimezone string
+	}
+	if err := db.Table("users").
+		Select("COALESCE(users.timezone, '') AS user_timezone, COALESCE(offices.timezone, '') AS office_timezone").
+		Joins("LEFT JOIN offices ON offices.id = users.office_id").
+		Where("users.id = ?", userID).
+		Scan(&zones).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range []string{zones.UserTimezone, zones.OfficeTimezone} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+	}
+	return time.Local, nil
+}
+
+// requestLocation returns the time zone to compute the request's periods in: ?tz= (an IANA
+// name), else the authenticated user's as by userLocation. It writes the error response and
+// returns false for an unknown ?tz= or when the user's zone cannot be read.
+func requestLocation(c *gin.Context, db *gorm.DB) (*time.Location, bool) {
+	if tz := strings.TrimSpace(c.Query("tz")); tz != "" {
+		if msg := validateOfficeTimezone(tz); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return nil, false
+		}
+		loc, _ := time.LoadLocation(tz)
+		return loc, true
+	}
+	userID := extractUserIDUint(c)
+	if userID == 0 {
+		return time.Local, true
+	}
+	loc, err := userLocation(db, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la zona horaria", "message": err.Error()})
+		return nil, false
+	}
+	return loc, true
+}
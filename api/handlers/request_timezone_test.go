@@ -0,0 +1,129 @@
+// api/handlers/request_timezone_test.go
+// Unit tests for report and dashboard time zones: periods start at midnight in the requested
+// zone even when that is already the next day in UTC, and the zone comes from ?tz=, the user
+// or their office.
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPeriodRangeCrossingMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("America/Ciudad_Juarez")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	utc := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	// 22:30 on Monday March 10 in Ciudad Juárez (UTC-6) is already March 11 in UTC
+	now := time.Date(2025, 3, 11, 4, 30, 0, 0, time.UTC).In(loc)
+	tests := []struct {
+		period     string
+		start, end time.Time // end is exclusive
+	}{
+		{"daily", utc(3, 10, 6), utc(3, 11, 6)},
+		{"weekly", utc(3, 10, 6), utc(3, 17, 6)},
+		// March 1 is still on standard time (UTC-7)
+		{"monthly", utc(3, 1, 7), utc(4, 1, 6)},
+		{"yearly", utc(1, 1, 7), time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)},
+		{"", utc(3, 10, 6), utc(3, 11, 6)},
+	}
+	for _, tt := range tests {
+		start, end := periodRange(tt.period, now)
+		if !start.Equal(tt.start) || !end.Equal(tt.end.Add(-time.Nanosecond)) {
+			t.Errorf("%q = [%s, %s], want [%s, %s)", tt.period, start.UTC(), end.UTC(), tt.start, tt.end)
+		}
+		if start.Location() != loc {
+			t.Errorf("%q starts in %s, want %s", tt.period, start.Location(), loc)
+		}
+	}
+
+	// The same instant in UTC is a day later
+	if start, _ := periodRange("daily", now.UTC()); !start.Equal(utc(3, 11, 0)) {
+		t.Errorf("daily in UTC starts %s, want 2025-03-11", start)
+	}
+}
+
+func TestDashboardTodayInRequestedTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Ciudad_Juarez")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	var bounds []time.Time
+	script := &scriptedSQL{observe: func(query string, args []driver.Value) {
+		if strings.Contains(query, `FROM "appointments"`) && strings.Contains(query, "start_time >= ") && strings.Contains(query, "start_time < ") {
+			for _, arg := range args {
+				if at, ok := arg.(time.Time); ok {
+					bounds = append(bounds, at)
+				}
+			}
+		}
+	}}
+	computeDashboardStats(scriptedDB(t, script), dashboardScope{}, time.Date(2025, 3, 11, 4, 30, 0, 0, time.UTC).In(loc))
+
+	want := []time.Time{time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 6, 0, 0, 0, time.UTC)}
+	if len(bounds) != 2 || !bounds[0].Equal(want[0]) || !bounds[1].Equal(want[1]) {
+		t.Errorf("today's appointments counted over %v, want %v", bounds, want)
+	}
+}
+
+func TestRequestLocation(t *testing.T) {
+	if _, err := time.LoadLocation("America/Ciudad_Juarez"); err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	// User 3 has no time zone of their own and works at an office in Ciudad Juárez; user 4
+	// set America/Mexico_City
+	script := &scriptedSQL{}
+	var userArg driver.Value
+	script.observe = func(_ string, args []driver.Value) {
+		if len(args) > 0 {
+			userArg, _ = driver.DefaultParameterConverter.ConvertValue(args[0])
+		}
+	}
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if !strings.Contains(query, "office_timezone") {
+			return nil, nil
+		}
+		if userArg == int64(4) {
+			return []string{"user_timezone", "office_timezone"}, [][]driver.Value{{"America/Mexico_City", "America/Ciudad_Juarez"}}
+		}
+		return []string{"user_timezone", "office_timezone"}, [][]driver.Value{{"", "America/Ciudad_Juarez"}}
+	}
+	db := scriptedDB(t, script)
+
+	tests := []struct {
+		userID, query string
+		status        int
+		want          string
+	}{
+		{"3", "", http.StatusOK, "America/Ciudad_Juarez"},
+		{"4", "", http.StatusOK, "America/Mexico_City"},
+		{"4", "tz=America/Tijuana", http.StatusOK, "America/Tijuana"},
+		{"3", "tz=Mars/Olympus", http.StatusBadRequest, ""},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/admin/dashboard/stats?"+tt.query, nil)
+		c.Set("userID", tt.userID)
+		loc, ok := requestLocation(c, db)
+		if tt.status != http.StatusOK {
+			if ok || w.Code != tt.status {
+				t.Errorf("user %s %q: ok = %v, status = %d, want %d", tt.userID, tt.query, ok, w.Code, tt.status)
+			}
+			continue
+		}
+		if !ok || loc.String() != tt.want {
+			t.Errorf("user %s %q: location = %v (ok = %v), want %s", tt.userID, tt.query, loc, ok, tt.want)
+		}
+	}
+}
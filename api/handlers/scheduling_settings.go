@@ -0,0 +1,191 @@
+// api/handlers/scheduling_settings.go
+// Appointment slot granularity: start times (and optionally end times) must fall on
+// fixed increments from midnight so calendars and availability stay on a clean grid.
+// Defaults live in config; admins override them here.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// System setting keys for the scheduling overrides
+const (
+	settingSlotMinutes  = "appointment_slot_minutes"
+	settingSlotAlignEnd = "appointment_slot_align_end"
+)
+
+// maxSlotMinutes is the largest granularity an admin may set.
+const maxSlotMinutes = 240
+
+// schedulingSettings is the effective slot configuration.
+type schedulingSettings struct {
+	SlotMinutes  int  `json:"slotMinutes"`  // 0 disables the alignment check
+	AlignEndTime bool `json:"alignEndTime"` // End times must align as well
+	Overridden   bool `json:"overridden"`   // At least one value comes from an admin override
+}
+
+// loadSchedulingSettings returns the config defaults merged with admin overrides.
+func loadSchedulingSettings(db *gorm.DB) schedulingSettings {
+	settings := schedulingSettings{
+		SlotMinutes:  config.AppointmentSlotMinutes(),
+		AlignEndTime: config.AppointmentSlotAlignEnd(),
+	}
+
+	var overrides []models.SystemSetting
+	if err := db.Where("key IN ?", []string{settingSlotMinutes, settingSlotAlignEnd}).Find(&overrides).Error; err == nil {
+		for _, o := range overrides {
+			switch o.Key {
+			case settingSlotMinutes:
+				if parsed, err := strconv.Atoi(o.Value); err == nil && parsed >= 0 {
+					settings.SlotMinutes = parsed
+					settings.Overridden = true
+				}
+			case settingSlotAlignEnd:
+				if parsed, err := strconv.ParseBool(o.Value); err == nil {
+					settings.AlignEndTime = parsed
+					settings.Overridden = true
+				}
+			}
+		}
+	}
+	return settings
+}
+
+// alignedToSlot reports whether t falls exactly on a slot boundary of its own clock.
+func alignedToSlot(t time.Time, slotMinutes int) bool {
+	if slotMinutes <= 0 {
+		return true
+	}
+	if t.Second() != 0 || t.Nanosecond() != 0 {
+		return false
+	}
+	return (t.Hour()*60+t.Minute())%slotMinutes == 0
+}
+
+// enforceSlotAlignment validates a proposed appointment's times against the slot granularity.
+// It writes a 400 response and returns false when a time is misaligned. A zero time is not
+// checked, so partial updates only validate the times they change.
+func enforceSlotAlignment(c *gin.Context, db *gorm.DB, start, end time.Time) bool {
+	settings := loadSchedulingSettings(db)
+	if settings.SlotMinutes == 0 {
+		return true
+	}
+
+	fields := make([]string, 0, 2)
+	if !start.IsZero() && !alignedToSlot(start, settings.SlotMinutes) {
+		fields = append(fields, "startTime")
+	}
+	if settings.AlignEndTime && !end.IsZero() && !alignedToSlot(end, settings.SlotMinutes) {
+		fields = append(fields, "endTime")
+	}
+	if len(fields) == 0 {
+		return true
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":        fmt.Sprintf("El horario debe coincidir con intervalos de %d minutos", settings.SlotMinutes),
+		"fields":       fields,
+		"slotMinutes":  settings.SlotMinutes,
+		"alignEndTime": settings.AlignEndTime,
+	})
+	return false
+}
+
+// GetSchedulingSettings returns the effective slot granularity for scheduling pickers.
+func GetSchedulingSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := loadSchedulingSettings(db)
+		c.JSON(http.StatusOK, gin.H{
+			"slotMinutes":         settings.SlotMinutes,
+			"alignEndTime":        settings.AlignEndTime,
+			"overridden":          settings.Overridden,
+			"defaultSlotMinutes":  config.AppointmentSlotMinutes(),
+			"defaultAlignEndTime": config.AppointmentSlotAlignEnd(),
+		})
+	}
+}
+
+// UpdateSchedulingSettings overrides the slot granularity and/or end time alignment.
+// Omitted fields keep their current value.
+func UpdateSchedulingSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			SlotMinutes  *int  `json:"slotMinutes"`
+			AlignEndTime *bool `json:"alignEndTime"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.SlotMinutes == nil && input.AlignEndTime == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Indique slotMinutes o alignEndTime"})
+			return
+		}
+		if input.SlotMinutes != nil {
+			slot := *input.SlotMinutes
+			// The slot must tile the day evenly so every day starts on the same grid
+			if slot < 0 || slot > maxSlotMinutes || (slot > 0 && (24*60)%slot != 0) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("slotMinutes debe ser 0 o un divisor de 1440 no mayor a %d", maxSlotMinutes)})
+				return
+			}
+		}
+
+		before := loadSchedulingSettings(db)
+		values := map[string]string{}
+		if input.SlotMinutes != nil {
+			values[settingSlotMinutes] = strconv.Itoa(*input.SlotMinutes)
+		}
+		if input.AlignEndTime != nil {
+			values[settingSlotAlignEnd] = strconv.FormatBool(*input.AlignEndTime)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for key, value := range values {
+				var setting models.SystemSetting
+				if err := tx.Where("key = ?", key).First(&setting).Error; err != nil && err != gorm.ErrRecordNotFound {
+					return err
+				}
+				setting.Key = key
+				setting.Value = value
+				setting.UpdatedBy = extractUserID(c)
+				if err := tx.Save(&setting).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar la configuración", "message": err.Error()})
+			return
+		}
+
+		settings := loadSchedulingSettings(db)
+		recordAuditLog(db, c, "system_setting", 0, "update", "scheduling", map[string]interface{}{
+			"slotMinutes":      settings.SlotMinutes,
+			"alignEndTime":     settings.AlignEndTime,
+			"previousSlot":     before.SlotMinutes,
+			"previousAlignEnd": before.AlignEndTime,
+		})
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// ResetSchedulingSettings removes the overrides so the configured defaults apply again.
+func ResetSchedulingSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := db.Where("key IN ?", []string{settingSlotMinutes, settingSlotAlignEnd}).Delete(&models.SystemSetting{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al restablecer la configuración", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "system_setting", 0, "reset", "scheduling", nil)
+		c.JSON(http.StatusOK, loadSchedulingSettings(db))
+	}
+}
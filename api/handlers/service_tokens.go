@@ -0,0 +1,190 @@
+// api/handlers/service_tokens.go
+// Service tokens: long-lived API tokens for scheduled jobs and integrations. Admins issue them
+// for an existing account with limited scopes; they skip user-oriented rate limits and every
+// request made with one is identifiable in the audit log.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/middleware"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// serviceTokenResourcePattern matches the resource part of a scope such as "appointment:read".
+var serviceTokenResourcePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// serviceTokenInput is the body of the create and update endpoints. userId and expiresInDays
+// are only read on create.
+type serviceTokenInput struct {
+	Name          string   `json:"name"`
+	Description   *string  `json:"description"`
+	UserID        uint     `json:"userId"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expiresInDays"`
+}
+
+// apply validates the input and copies it onto token. On update, omitted fields keep their values.
+func (input serviceTokenInput) apply(token *models.ServiceToken) error {
+	if name := strings.TrimSpace(input.Name); name != "" {
+		token.Name = name
+	}
+	if input.Description != nil {
+		token.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Scopes != nil {
+		scopes := make([]string, 0, len(input.Scopes))
+		for _, scope := range input.Scopes {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			resource, access, narrowed := strings.Cut(scope, ":")
+			if !narrowed {
+				access = resource
+			}
+			if (access != models.ServiceTokenScopeRead && access != models.ServiceTokenScopeWrite) || (narrowed && !serviceTokenResourcePattern.MatchString(resource)) {
+				return fmt.Errorf("alcance inválido %q; use read, write, <recurso>:read o <recurso>:write", scope)
+			}
+			scopes = append(scopes, scope)
+		}
+		token.Scopes = strings.Join(scopes, ",")
+	}
+	if token.Name == "" || token.Scopes == "" {
+		return fmt.Errorf("name y scopes son obligatorios")
+	}
+	return nil
+}
+
+// GetServiceTokens lists the service tokens, including revoked and expired ones. The tokens
+// themselves are never returned.
+func GetServiceTokens(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokens := make([]models.ServiceToken, 0)
+		if err := db.Order("id").Find(&tokens).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los tokens de servicio", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": tokens, "rateLimitPerMinute": config.ServiceTokenRequestsPerMinute()})
+	}
+}
+
+// CreateServiceToken issues a service token acting as an existing, active staff or admin
+// account. The signed token is shown only in this response.
+func CreateServiceToken(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input serviceTokenInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos", "details": err.Error()})
+			return
+		}
+		token := models.ServiceToken{TokenID: uuid.NewString(), UserID: input.UserID, CreatedBy: extractUserID(c)}
+		if err := input.apply(&token); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id, role, is_active").First(&user, input.UserID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "userId debe ser un usuario existente"})
+			return
+		}
+		if !user.IsActive || user.Role == "client" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El token de servicio debe actuar como un usuario activo del personal"})
+			return
+		}
+
+		now := time.Now().UTC()
+		claims := jwt.MapClaims{
+			"sub":                        strconv.FormatUint(uint64(user.ID), 10),
+			"jti":                        token.TokenID,
+			"iat":                        now.Unix(),
+			middleware.ServiceTokenClaim: middleware.ServiceTokenType,
+		}
+		if input.ExpiresInDays != nil {
+			if *input.ExpiresInDays <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays debe ser mayor que 0"})
+				return
+			}
+			expiresAt := now.AddDate(0, 0, *input.ExpiresInDays)
+			token.ExpiresAt = &expiresAt
+			claims["exp"] = expiresAt.Unix()
+		}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al firmar el token de servicio", "message": err.Error()})
+			return
+		}
+
+		if err := db.Create(&token).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el token de servicio", "message": err.Error()})
+			return
+		}
+		recordSecurityAuditLog(db, c, "service_token", token.ID, "create", "", map[string]interface{}{
+			"name": token.Name, "userId": token.UserID, "scopes": token.Scopes, "expiresAt": token.ExpiresAt,
+		})
+		c.JSON(http.StatusCreated, gin.H{"data": token, "token": signed})
+	}
+}
+
+// UpdateServiceToken changes a token's name, description or scopes. Scopes are read from the
+// record on every request, so the change applies to the token already issued.
+func UpdateServiceToken(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var token models.ServiceToken
+		if err := db.First(&token, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token de servicio no encontrado"})
+			return
+		}
+		var input serviceTokenInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos", "details": err.Error()})
+			return
+		}
+		if err := input.apply(&token); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Save(&token).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el token de servicio", "message": err.Error()})
+			return
+		}
+		recordSecurityAuditLog(db, c, "service_token", token.ID, "update", "", map[string]interface{}{
+			"name": token.Name, "scopes": token.Scopes,
+		})
+		c.JSON(http.StatusOK, gin.H{"data": token})
+	}
+}
+
+// RevokeServiceToken revokes a token; requests made with it are rejected from then on. The
+// record is kept so audit entries still resolve to it.
+func RevokeServiceToken(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Model(&models.ServiceToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now().UTC())
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revocar el token de servicio"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token de servicio no encontrado o ya revocado"})
+			return
+		}
+		recordSecurityAuditLog(db, c, "service_token", id, "revoke", "", nil)
+		c.JSON(http.StatusOK, gin.H{"message": "Token de servicio revocado exitosamente"})
+	}
+}
@@ -0,0 +1,145 @@
+// api/handlers/sessions.go
+// Session management: admins list and force-revoke a user's login sessions, and any user can
+// log out of every device at once.
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BryanPMX/CAF/api/interfaces"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetUserSessionsAdmin lists the active sessions of a user.
+func GetUserSessionsAdmin(db *gorm.DB, sessions interfaces.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el usuario", "message": err.Error()})
+			return
+		}
+
+		activeSessions, err := sessions.ListUserSessions(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener sesiones", "message": err.Error()})
+			return
+		}
+
+		data := make([]gin.H, 0, len(activeSessions))
+		for _, session := range activeSessions {
+			data = append(data, gin.H{
+				"id":           session.ID,
+				"ipAddress":    session.IPAddress,
+				"userAgent":    session.UserAgent,
+				"lastActivity": session.LastActivity,
+				"expiresAt":    session.ExpiresAt,
+				"createdAt":    session.CreatedAt,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"userId":   user.ID,
+			"sessions": data,
+			"total":    len(data),
+		})
+	}
+}
+
+// RevokeUserSessionsAdmin revokes every active session of a user, forcing a new login.
+// Revoked tokens are rejected by EnhancedJWTAuth on their next request.
+func RevokeUserSessionsAdmin(db *gorm.DB, sessions interfaces.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id, email").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el usuario", "message": err.Error()})
+			return
+		}
+
+		revoked, err := sessions.RevokeUserSessions(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revocar sesiones", "message": err.Error()})
+			return
+		}
+
+		reason := c.Query("reason")
+		if reason == "" {
+			reason = "admin_forced_logout"
+		}
+		recordSecurityAuditLog(db, c, "user", user.ID, "revoke_sessions", reason, map[string]interface{}{
+			"email":           user.Email,
+			"revokedSessions": revoked,
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Sesiones revocadas exitosamente",
+			"userId":          user.ID,
+			"revokedSessions": revoked,
+		})
+	}
+}
+
+// RevokeMySessions logs the current user out everywhere by revoking all of their sessions,
+// including the one making the request.
+func RevokeMySessions(db *gorm.DB, sessions interfaces.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isServiceToken := c.Get("serviceTokenID"); isServiceToken {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Los tokens de servicio no tienen sesiones"})
+			return
+		}
+		userID := extractUserIDUint(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+
+		revoked, err := sessions.RevokeUserSessions(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al revocar sesiones", "message": err.Error()})
+			return
+		}
+		recordSecurityAuditLog(db, c, "user", userID, "revoke_sessions", "user_logout_everywhere", map[string]interface{}{
+			"revokedSessions": revoked,
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Se cerró la sesión en todos los dispositivos",
+			"revokedSessions": revoked,
+		})
+	}
+}
+
+// revokeRemovedUserSessions revokes the sessions of users that were just deactivated and
+// deleted, so their sessions cached in Redis stop validating and their sockets close.
+// Failures are only logged: DataAccessControl already rejects deleted users.
+func revokeRemovedUserSessions(c *gin.Context, sessions interfaces.SessionService, userIDs ...uint) {
+	if sessions == nil {
+		return
+	}
+	for _, userID := range userIDs {
+		if _, err := sessions.RevokeUserSessions(c.Request.Context(), userID); err != nil {
+			log.Printf("WARNING: Failed to revoke sessions of removed user %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// api/handlers/staff_calendar.go
+// Subscribable iCalendar feed of the appointments a staff member can see.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/ics"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Window of the staff calendar feed around the time it is fetched
+const (
+	staffCalendarPastDays   = 30
+	staffCalendarFutureDays = 180
+)
+
+// GetStaffAppointmentsICS returns the appointments visible to the current user, under the same
+// access rules as GetAppointmentsEnhanced, as an iCalendar feed. Cancelled appointments stay in
+// the feed with STATUS:CANCELLED so subscribed calendars drop them. Appointments of other staff
+// members carry their name in the summary.
+func GetStaffAppointmentsICS(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := extractUserIDUint(c)
+		now := time.Now()
+		from, to := now.AddDate(0, 0, -staffCalendarPastDays), now.AddDate(0, 0, staffCalendarFutureDays)
+
+		query, _ := scopeAppointmentQuery(db, c, db.Model(&models.Appointment{}))
+		appointments := make([]models.Appointment, 0)
+		err := query.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, first_name, last_name")
+		}).Preload("Case", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, title, client_id").Preload("Client", func(db *gorm.DB) *gorm.DB {
+				return db.Select("id, first_name, last_name")
+			})
+		}).Preload("Office", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, name, address")
+		}).
+			Where("appointments.start_time >= ? AND appointments.start_time < ?", from, to).
+			Order("appointments.start_time ASC").
+			Find(&appointments).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el calendario", "message": err.Error()})
+			return
+		}
+
+		cal := ics.NewCalendar("CAF - Mis citas")
+		for _, appt := range appointments {
+			cal.AddEvent(appointmentICSEvent(appt, appt.StaffID != userID))
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=staff-%d-calendar.ics", userID))
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", cal.Bytes())
+	}
+}
@@ -0,0 +1,203 @@
+// api/handlers/staff_reassignment.go
+// Moving a staff member's caseload to a colleague, e.g. when a lawyer leaves.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/middleware"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reassignedCase is an open case of the source staff member.
+type reassignedCase struct {
+	ID             uint   `json:"caseId"`
+	CaseNumber     string `json:"caseNumber,omitempty"`
+	Title          string `json:"title"`
+	Category       string `json:"category"`
+	PrimaryStaffID *uint  `json:"-"`
+}
+
+// ReassignStaffCases moves the open cases of staff member :id, as primary staff or through
+// user_case_assignments, to targetUserId, along with their upcoming appointments. ?category= (or
+// "category") limits it to one department. Cases outside the target's department are refused
+// with 409 unless force=true. Runs in one transaction with an audit entry and an internal case
+// event per case.
+func ReassignStaffCases(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sourceID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input struct {
+			TargetUserID uint   `json:"targetUserId" binding:"required"`
+			Category     string `json:"category"`
+			Force        bool   `json:"force"`
+			Reason       string `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetUserId es requerido", "details": err.Error()})
+			return
+		}
+		if input.TargetUserID == sourceID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El usuario destino debe ser distinto del usuario origen"})
+			return
+		}
+		category := strings.TrimSpace(input.Category)
+		if category == "" {
+			category = strings.TrimSpace(c.Query("category"))
+		}
+		force := input.Force || c.Query("force") == "true"
+
+		var source, target models.User
+		if err := db.First(&source, sourceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuario origen no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el usuario origen", "message": err.Error()})
+			return
+		}
+		if err := db.First(&target, input.TargetUserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuario destino no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el usuario destino", "message": err.Error()})
+			return
+		}
+		if !target.IsActive || (!middleware.IsStaffRole(target.Role) && target.Role != config.RoleOfficeManager && target.Role != config.RoleAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El usuario destino no puede recibir casos"})
+			return
+		}
+
+		query := db.Model(&models.Case{}).
+			Select("id", "case_number", "title", "category", "primary_staff_id").
+			Where("is_archived = ? AND status NOT IN ?", false, []string{string(config.CaseStatusClosed), string(config.CaseStatusArchived)}).
+			Where("primary_staff_id = ? OR id IN (SELECT case_id FROM user_case_assignments WHERE user_id = ? AND deleted_at IS NULL)", source.ID, source.ID)
+		if category != "" {
+			query = query.Where("category = ?", category)
+		}
+		cases := make([]reassignedCase, 0)
+		if err := query.Order("id").Scan(&cases).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los casos a reasignar", "message": err.Error()})
+			return
+		}
+
+		mismatched := make([]reassignedCase, 0)
+		for _, caseData := range cases {
+			if !userHandlesCategory(target, caseData.Category) {
+				mismatched = append(mismatched, caseData)
+			}
+		}
+		if len(mismatched) > 0 && !force {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":            "Hay casos fuera del departamento del usuario destino; la reasignación requiere confirmación (force=true)",
+				"forceRequired":    true,
+				"targetDepartment": *target.Department,
+				"mismatchedCases":  mismatched,
+			})
+			return
+		}
+
+		caseIDs := make([]uint, 0, len(cases))
+		for _, caseData := range cases {
+			caseIDs = append(caseIDs, caseData.ID)
+		}
+		changedBy := extractUserIDUint(c)
+		now := time.Now().UTC()
+		var movedAppointments, movedAssignments int64
+		if len(caseIDs) > 0 {
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&models.Case{}).Where("id IN ? AND primary_staff_id = ?", caseIDs, source.ID).
+					UpdateColumns(map[string]interface{}{"primary_staff_id": target.ID, "updated_by": changedBy, "updated_at": now}).Error; err != nil {
+					return err
+				}
+
+				// The target keeps their own assignment where they already had one
+				if err := tx.Where("user_id = ? AND case_id IN ? AND case_id IN (SELECT case_id FROM user_case_assignments WHERE user_id = ? AND deleted_at IS NULL)",
+					source.ID, caseIDs, target.ID).Delete(&models.UserCaseAssignment{}).Error; err != nil {
+					return err
+				}
+				result := tx.Model(&models.UserCaseAssignment{}).Where("user_id = ? AND case_id IN ?", source.ID, caseIDs).
+					UpdateColumns(map[string]interface{}{"user_id": target.ID, "updated_at": now})
+				if result.Error != nil {
+					return result.Error
+				}
+				movedAssignments = result.RowsAffected
+
+				result = tx.Model(&models.Appointment{}).
+					Where("case_id IN ? AND staff_id = ? AND start_time >= ? AND status NOT IN ?", caseIDs, source.ID, now,
+						[]config.AppointmentStatus{config.StatusCancelled, config.StatusCompleted, config.StatusNoShow}).
+					UpdateColumns(map[string]interface{}{"staff_id": target.ID, "updated_at": now})
+				if result.Error != nil {
+					return result.Error
+				}
+				movedAppointments = result.RowsAffected
+
+				reason := strings.TrimSpace(input.Reason)
+				if reason == "" {
+					reason = "staff_reassignment"
+				}
+				for _, caseData := range cases {
+					event := models.CaseEvent{
+						CaseID:      caseData.ID,
+						UserID:      changedBy,
+						EventType:   "staff_reassignment",
+						Description: fmt.Sprintf("Caso reasignado de %s %s a %s %s", source.FirstName, source.LastName, target.FirstName, target.LastName),
+						Visibility:  "internal",
+					}
+					if err := tx.Create(&event).Error; err != nil {
+						return err
+					}
+					entry := newAuditLog(c, "case", caseData.ID, "reassign", reason, map[string]interface{}{
+						"fromUserId": source.ID,
+						"toUserId":   target.ID,
+						"primary":    caseData.PrimaryStaffID != nil && *caseData.PrimaryStaffID == source.ID,
+						"forced":     !userHandlesCategory(target, caseData.Category),
+					})
+					if err := tx.Create(&entry).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reasignar los casos", "message": err.Error()})
+				return
+			}
+		}
+
+		for _, caseID := range caseIDs {
+			invalidateCache(strconv.FormatUint(uint64(caseID), 10))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Casos reasignados exitosamente",
+			"sourceUserId": source.ID,
+			"targetUserId": target.ID,
+			"category":     category,
+			"caseIds":      caseIDs,
+			"forced":       len(mismatched) > 0,
+			"moved": gin.H{
+				"cases":        len(caseIDs),
+				"appointments": movedAppointments,
+				"assignments":  movedAssignments,
+			},
+		})
+	}
+}
+
+// userHandlesCategory reports whether the user may take cases of the category without force.
+// Users without a department take any case, as on case creation.
+func userHandlesCategory(user models.User, category string) bool {
+	return user.Department == nil || strings.TrimSpace(*user.Department) == "" || staffDepartmentHandles(*user.Department, category)
+}
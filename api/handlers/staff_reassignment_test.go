@@ -0,0 +1,171 @@
+// api/handlers/staff_reassignment_test.go
+// Unit tests for caseload reassignment: the department guard and what a reassignment writes.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// reassignmentScript serves lawyer 3 (leaving), civil lawyer 4 and family lawyer 6, and the open
+// cases of lawyer 3: case 7 (Familiar, primary staff) and case 9 (Civil, through an assignment).
+func reassignmentScript() *scriptedSQL {
+	users := map[string][]driver.Value{
+		"3": {int64(3), "Laura", "Méndez", "lawyer", "Familiar", true},
+		"4": {int64(4), "Raúl", "Ortiz", "lawyer", "Civil", true},
+		"6": {int64(6), "Ana", "Ruiz", "lawyer", "Familiar", true},
+	}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "users"`) && len(args) > 0:
+				if row, ok := users[fmt.Sprint(args[0])]; ok {
+					return []string{"id", "first_name", "last_name", "role", "department", "is_active"}, [][]driver.Value{row}
+				}
+			case strings.Contains(query, `FROM "cases"`):
+				return []string{"id", "case_number", "title", "category", "primary_staff_id"}, [][]driver.Value{
+					{int64(7), "CAF-CEN-2025-000007", "Divorcio", "Familiar", int64(3)},
+					{int64(9), "CAF-CEN-2025-000009", "Intestado", "Civil", int64(5)},
+				}
+			}
+			return nil, nil
+		},
+		affected: func(query string) int64 {
+			if strings.HasPrefix(query, `UPDATE "appointments"`) {
+				return 2
+			}
+			return 1
+		},
+	}
+}
+
+// reassignCases posts body to the reassignment of staff member 3 as admin 1.
+func reassignCases(t *testing.T, script *scriptedSQL, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/staff/3/reassign-cases", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	c.Set("currentUser", models.User{ID: 1, Role: "admin"})
+	ReassignStaffCases(scriptedDB(t, script))(c)
+	return w
+}
+
+func TestReassignStaffCasesRefusesDepartmentMismatch(t *testing.T) {
+	script := reassignmentScript()
+	w := reassignCases(t, script, `{"targetUserId":6}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		ForceRequired    bool             `json:"forceRequired"`
+		TargetDepartment string           `json:"targetDepartment"`
+		MismatchedCases  []reassignedCase `json:"mismatchedCases"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	// Only the civil case is outside the family lawyer's department
+	if !body.ForceRequired || body.TargetDepartment != "Familiar" || len(body.MismatchedCases) != 1 || body.MismatchedCases[0].ID != 9 {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	for _, statement := range []string{"BEGIN", "UPDATE", `INSERT INTO "case_events"`, `INSERT INTO "audit_logs"`} {
+		if ran := script.ran(statement); len(ran) != 0 {
+			t.Errorf("%s ran although the reassignment was refused: %v", statement, ran)
+		}
+	}
+}
+
+func TestReassignStaffCasesForcedAcrossDepartments(t *testing.T) {
+	script := reassignmentScript()
+	w := reassignCases(t, script, `{"targetUserId":6,"force":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Forced bool `json:"forced"`
+		Moved  struct {
+			Cases        int   `json:"cases"`
+			Appointments int64 `json:"appointments"`
+			Assignments  int64 `json:"assignments"`
+		} `json:"moved"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.Forced || body.Moved.Cases != 2 || body.Moved.Appointments != 2 || body.Moved.Assignments != 1 {
+		t.Errorf("body = %s", w.Body.String())
+	}
+
+	if len(script.ran("BEGIN")) != 1 || len(script.ran("COMMIT")) != 1 {
+		t.Errorf("reassignment did not run in one transaction: %v", script.ran(""))
+	}
+	if updates := script.ran(`UPDATE "cases" SET`); len(updates) != 1 || !strings.Contains(updates[0], "primary_staff_id") {
+		t.Errorf("case updates = %v", updates)
+	}
+	// The source's assignment is dropped where the target already has one, and handed over elsewhere
+	if updates := script.ran(`UPDATE "user_case_assignments" SET "deleted_at"`); len(updates) != 1 {
+		t.Errorf("assignment deletes = %v", updates)
+	}
+	if updates := script.ran(`"user_id"=`); len(updates) != 1 || !strings.HasPrefix(updates[0], `UPDATE "user_case_assignments"`) {
+		t.Errorf("assignment updates = %v", updates)
+	}
+	if events := script.ran(`INSERT INTO "case_events"`); len(events) != 2 {
+		t.Errorf("case events = %v", events)
+	}
+	if entries := script.ran(`INSERT INTO "audit_logs"`); len(entries) != 2 {
+		t.Errorf("audit entries = %v", entries)
+	}
+}
+
+func TestReassignStaffCasesWithinDepartment(t *testing.T) {
+	// Only the family case moves, to the family lawyer, so no force is needed
+	script := reassignmentScript()
+	base := script.rows
+	script.rows = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		columns, rows := base(query, args)
+		if strings.Contains(query, `FROM "cases"`) {
+			if !strings.Contains(query, "category = $") {
+				t.Errorf("category filter missing: %s", query)
+			}
+			return columns, rows[:1]
+		}
+		return columns, rows
+	}
+	w := reassignCases(t, script, `{"targetUserId":6,"category":"Familiar"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"forced":false`) || !strings.Contains(w.Body.String(), `"caseIds":[7]`) {
+		t.Errorf("status %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStaffDepartmentHandles(t *testing.T) {
+	tests := []struct {
+		department string
+		category   string
+		want       bool
+	}{
+		{"Familiar", "Familiar", true},
+		{"legal", "Civil", true},
+		{"Legal", "Divorcios", true},
+		{"Psychology", "Psicologia", true},
+		{"Civil", "Familiar", false},
+		{"Psicologia", "Civil", false},
+	}
+	for _, tt := range tests {
+		if got := staffDepartmentHandles(tt.department, tt.category); got != tt.want {
+			t.Errorf("staffDepartmentHandles(%q, %q) = %v, want %v", tt.department, tt.category, got, tt.want)
+		}
+	}
+}
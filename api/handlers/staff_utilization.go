@@ -0,0 +1,141 @@
+// api/handlers/staff_utilization.go
+// Staff utilization: the share of a staff member's working hours booked with appointments,
+// compared against their target.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// staffUtilizationBasis describes the working hours utilization is measured against.
+type staffUtilizationBasis struct {
+	WorkingHours string  `json:"workingHours"` // Daily window, HH:MM-HH:MM
+	WorkingDays  int     `json:"workingDays"`  // Working days in the period
+	HoursPerDay  float64 `json:"hoursPerDay"`
+	TotalHours   float64 `json:"totalHours"`
+}
+
+// staffUtilization is the booked time of one staff member over a period.
+type staffUtilization struct {
+	Basis        staffUtilizationBasis `json:"basis"`
+	BookedHours  float64               `json:"bookedHours"`
+	Appointments int64                 `json:"appointments"`
+	Utilization  float64               `json:"utilization"` // BookedHours / Basis.TotalHours, as a percentage
+}
+
+// staffUtilizationBasisFor returns the working hours between from and to under the configured
+// APPOINTMENT_WORKING_HOURS and APPOINTMENT_WORKING_DAYS.
+func staffUtilizationBasisFor(from, to time.Time) staffUtilizationBasis {
+	start, end := config.AppointmentWorkingHours()
+	workingDays := config.AppointmentWorkingDays()
+	basis := staffUtilizationBasis{
+		WorkingHours: fmt.Sprintf("%02d:%02d-%02d:%02d", start/60, start%60, end/60, end%60),
+		HoursPerDay:  float64(end-start) / 60,
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if workingDays[day.Weekday()] {
+			basis.WorkingDays++
+		}
+	}
+	basis.TotalHours = float64(basis.WorkingDays) * basis.HoursPerDay
+	return basis
+}
+
+// computeStaffUtilization sums the time of a staff member's appointments between from and to,
+// clipped to the period, against the working hours of the period. Cancelled appointments free
+// the time and are not counted; no-shows are, since the slot was held.
+func computeStaffUtilization(db *gorm.DB, staffID uint, from, to time.Time) (staffUtilization, error) {
+	result := staffUtilization{Basis: staffUtilizationBasisFor(from, to)}
+	var booked struct {
+		Minutes float64
+		Count   int64
+	}
+	if err := db.Model(&models.Appointment{}).
+		Select("COALESCE(SUM(EXTRACT(EPOCH FROM (LEAST(end_time, ?) - GREATEST(start_time, ?)))), 0) / 60 AS minutes, COUNT(*) AS count", to, from).
+		Where("staff_id = ? AND status <> ?", staffID, config.StatusCancelled).
+		Where("start_time < ? AND end_time > ?", to, from).
+		Scan(&booked).Error; err != nil {
+		return result, err
+	}
+	result.BookedHours = booked.Minutes / 60
+	result.Appointments = booked.Count
+	if result.Basis.TotalHours > 0 {
+		result.Utilization = result.BookedHours / result.Basis.TotalHours * 100
+	}
+	return result, nil
+}
+
+// GetStaffUtilization returns a staff member's utilization for the current week or month
+// (?period=week|month, default month) next to their target, with the variance in percentage
+// points and whether that is over, under or on target within STAFF_UTILIZATION_TOLERANCE.
+// The period covers future appointments already booked. Managers only see staff of their office.
+func GetStaffUtilization(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staffID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		period := c.DefaultQuery("period", "month")
+		if period != "week" && period != "month" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period debe ser 'week' o 'month'"})
+			return
+		}
+
+		var staff models.User
+		if err := db.Where("id = ? AND role <> ?", staffID, "client").First(&staff).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Personal no encontrado"})
+			return
+		}
+		if !config.CanAccessAllOffices(c.GetString("userRole")) {
+			officeID, ok := c.Get("officeScopeID")
+			if !ok || staff.OfficeID == nil || *staff.OfficeID != officeID.(uint) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Solo puede consultar al personal de su oficina"})
+				return
+			}
+		}
+
+		from := trendBucketStart(time.Now(), period)
+		to := trendBucketNext(from, period)
+		utilization, err := computeStaffUtilization(db, staff.ID, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la utilización", "message": err.Error()})
+			return
+		}
+
+		target, targetSource := config.StaffTargetUtilization(), "default"
+		if staff.TargetUtilization != nil {
+			target, targetSource = *staff.TargetUtilization, "staff"
+		}
+		variance := utilization.Utilization - target
+		signal := "on_target"
+		if tolerance := config.StaffUtilizationTolerance(); variance > tolerance {
+			signal = "over"
+		} else if variance < -tolerance {
+			signal = "under"
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"staffId":      staff.ID,
+			"name":         staff.FirstName + " " + staff.LastName,
+			"officeId":     staff.OfficeID,
+			"period":       period,
+			"start":        from.Format("2006-01-02"),
+			"end":          to.AddDate(0, 0, -1).Format("2006-01-02"),
+			"basis":        utilization.Basis,
+			"bookedHours":  utilization.BookedHours,
+			"appointments": utilization.Appointments,
+			"utilization":  utilization.Utilization,
+			"target":       target,
+			"targetSource": targetSource,
+			"variance":     variance,
+			"signal":       signal,
+		})
+	}
+}
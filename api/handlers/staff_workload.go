@@ -0,0 +1,101 @@
+// api/handlers/staff_workload.go
+// Office staff workload for managers: each staff member's active cases, open tasks and
+// upcoming appointments, heaviest first, flagged when they reach the active case limit.
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// workloadAppointmentDays is how far ahead appointments count towards a staff member's workload.
+const workloadAppointmentDays = 14
+
+// staffWorkload is one staff member's current load.
+type staffWorkload struct {
+	StaffID              uint    `json:"staffId"`
+	FirstName            string  `json:"firstName"`
+	LastName             string  `json:"lastName"`
+	Role                 string  `json:"role"`
+	Department           *string `json:"department,omitempty"`
+	ActiveCases          int64   `json:"activeCases"`
+	OpenTasks            int64   `json:"openTasks"`
+	UpcomingAppointments int64   `json:"upcomingAppointments"` // Next workloadAppointmentDays days
+	AtCapacity           bool    `json:"atCapacity"`
+}
+
+// loadStaffWorkload returns the workload of the office's active staff, heaviest first: by
+// active cases, then open tasks, then upcoming appointments. Staff with maxActiveCases or more
+// active cases are at capacity; a limit of 0 flags nobody.
+func loadStaffWorkload(db *gorm.DB, officeID uint, now time.Time, maxActiveCases int) ([]staffWorkload, error) {
+	workload := make([]staffWorkload, 0)
+	err := db.Table("users").
+		Select(`users.id AS staff_id, users.first_name, users.last_name, users.role, users.department,
+			`+staffActiveCasesSQL+` AS active_cases,
+			(SELECT COUNT(*) FROM tasks WHERE tasks.deleted_at IS NULL AND tasks.assigned_to_id = users.id
+				AND tasks.status NOT IN ?) AS open_tasks,
+			(SELECT COUNT(*) FROM appointments WHERE appointments.deleted_at IS NULL AND appointments.staff_id = users.id
+				AND appointments.status NOT IN ? AND appointments.start_time >= ? AND appointments.start_time < ?) AS upcoming_appointments`,
+			finishedTaskStatuses, finishedAppointmentStatuses, now, now.AddDate(0, 0, workloadAppointmentDays)).
+		Where("users.deleted_at IS NULL AND users.is_active = ? AND users.office_id = ?", true, officeID).
+		Where("users.role NOT IN ?", []string{"client", config.RoleAdmin}).
+		Scan(&workload).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range workload {
+		workload[i].AtCapacity = maxActiveCases > 0 && workload[i].ActiveCases >= int64(maxActiveCases)
+	}
+	sort.SliceStable(workload, func(i, j int) bool {
+		a, b := workload[i], workload[j]
+		switch {
+		case a.ActiveCases != b.ActiveCases:
+			return a.ActiveCases > b.ActiveCases
+		case a.OpenTasks != b.OpenTasks:
+			return a.OpenTasks > b.OpenTasks
+		case a.UpcomingAppointments != b.UpcomingAppointments:
+			return a.UpcomingAppointments > b.UpcomingAppointments
+		}
+		return a.LastName+" "+a.FirstName < b.LastName+" "+b.FirstName
+	})
+	return workload, nil
+}
+
+// GetStaffWorkload returns the workload of the staff of the manager's office, so new cases can
+// go to whoever has room.
+func GetStaffWorkload(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		officeID := resolveOfficeScope(c)
+		if officeID == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Se requiere una oficina asignada para ver la carga de trabajo"})
+			return
+		}
+
+		maxActiveCases := config.StaffMaxActiveCases()
+		workload, err := loadStaffWorkload(db, officeID, time.Now(), maxActiveCases)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular la carga de trabajo", "message": err.Error()})
+			return
+		}
+		atCapacity := 0
+		for _, s := range workload {
+			if s.AtCapacity {
+				atCapacity++
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"officeId":        officeID,
+			"maxActiveCases":  maxActiveCases,
+			"appointmentDays": workloadAppointmentDays,
+			"atCapacity":      atCapacity,
+			"staff":           workload,
+		})
+	}
+}
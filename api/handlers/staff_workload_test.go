@@ -0,0 +1,132 @@
+// api/handlers/staff_workload_test.go
+// Unit tests for the staff workload report: staff are ordered by active cases, then open
+// tasks, then upcoming appointments, flagged at the active case limit, and scoped to the
+// manager's office.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workloadScript answers the workload query with five staff members in no particular order and
+// records the arguments it was run with.
+func workloadScript(args *[]driver.Value) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if !strings.Contains(query, `FROM "users"`) {
+				return nil, nil
+			}
+			return []string{"staff_id", "first_name", "last_name", "role", "department", "active_cases", "open_tasks", "upcoming_appointments"},
+				[][]driver.Value{
+					{int64(4), "Luis", "Soto", "lawyer", "Familiar", int64(3), int64(1), int64(0)},
+					{int64(5), "Marta", "Vega", "psychologist", nil, int64(6), int64(0), int64(2)},
+					{int64(6), "Ana", "Ruiz", "lawyer", "Civil", int64(3), int64(4), int64(1)},
+					{int64(7), "Eva", "Luna", "receptionist", nil, int64(0), int64(0), int64(0)},
+					{int64(8), "Raúl", "Cano", "lawyer", "Civil", int64(5), int64(0), int64(0)},
+				}
+		},
+		observe: func(query string, queryArgs []driver.Value) {
+			if strings.Contains(query, `FROM "users"`) {
+				*args = queryArgs
+			}
+		},
+	}
+}
+
+// runWorkload runs GetStaffWorkload as a manager of the given office (0 for none).
+func runWorkload(t *testing.T, script *scriptedSQL, officeID uint) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/manager/workload", nil)
+	c.Set("userID", "2")
+	c.Set("userRole", "office_manager")
+	if officeID != 0 {
+		c.Set("officeScopeID", officeID)
+	}
+	GetStaffWorkload(scriptedDB(t, script))(c)
+	return w
+}
+
+func TestStaffWorkloadOrdersByLoad(t *testing.T) {
+	t.Setenv("STAFF_MAX_ACTIVE_CASES", "5")
+	var args []driver.Value
+	w := runWorkload(t, workloadScript(&args), 2)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		OfficeID       uint            `json:"officeId"`
+		MaxActiveCases int             `json:"maxActiveCases"`
+		AtCapacity     int             `json:"atCapacity"`
+		Staff          []staffWorkload `json:"staff"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.OfficeID != 2 || body.MaxActiveCases != 5 || body.AtCapacity != 2 {
+		t.Errorf("summary = %+v", body)
+	}
+
+	// Ties on active cases go to open tasks; Marta and Raúl reach the limit of 5
+	want := []struct {
+		id         uint
+		atCapacity bool
+	}{{5, true}, {8, true}, {6, false}, {4, false}, {7, false}}
+	if len(body.Staff) != len(want) {
+		t.Fatalf("staff = %+v", body.Staff)
+	}
+	for i, s := range body.Staff {
+		if s.StaffID != want[i].id || s.AtCapacity != want[i].atCapacity {
+			t.Errorf("staff[%d] = %d (at capacity %v), want %d (%v)", i, s.StaffID, s.AtCapacity, want[i].id, want[i].atCapacity)
+		}
+	}
+
+	// Only the manager's office is counted
+	scoped := false
+	for _, arg := range args {
+		if value, _ := driver.DefaultParameterConverter.ConvertValue(arg); value == int64(2) {
+			scoped = true
+		}
+	}
+	if !scoped {
+		t.Errorf("workload query not scoped to office 2: %v", args)
+	}
+}
+
+func TestStaffWorkloadCapacityFlagDisabled(t *testing.T) {
+	t.Setenv("STAFF_MAX_ACTIVE_CASES", "0")
+	var args []driver.Value
+	w := runWorkload(t, workloadScript(&args), 2)
+	var body struct {
+		AtCapacity int             `json:"atCapacity"`
+		Staff      []staffWorkload `json:"staff"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range body.Staff {
+		if s.AtCapacity {
+			t.Errorf("staff %d flagged with the limit disabled", s.StaffID)
+		}
+	}
+	if body.AtCapacity != 0 {
+		t.Errorf("atCapacity = %d", body.AtCapacity)
+	}
+}
+
+func TestStaffWorkloadRequiresOffice(t *testing.T) {
+	var args []driver.Value
+	if w := runWorkload(t, workloadScript(&args), 0); w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+// api/handlers/stub_clients.go
+// Report and cleanup of stub clients: accounts auto-created with a placeholder
+// password that never logged in and have no active case.
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/interfaces"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CleanupStubClientsInput controls a stub client cleanup run.
+type CleanupStubClientsInput struct {
+	DryRun        bool   `json:"dryRun"`
+	OlderThanDays int    `json:"olderThanDays"` // Defaults to config.StubClientMaxAgeDays
+	Reason        string `json:"reason"`
+}
+
+// stubClientsQuery selects stub clients created before cutoff.
+func stubClientsQuery(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Table("users").
+		Where("users.role = ? AND users.deleted_at IS NULL", "client").
+		Where("users.must_change_password = ? AND users.last_login IS NULL", true).
+		Where("users.created_at < ?", cutoff).
+		Where("NOT " + clientHasActiveCaseSQL)
+}
+
+// stubClientCutoff resolves the age threshold in days and the matching creation cutoff.
+func stubClientCutoff(olderThanDays int) (int, time.Time) {
+	if olderThanDays <= 0 {
+		olderThanDays = config.StubClientMaxAgeDays()
+	}
+	return olderThanDays, time.Now().AddDate(0, 0, -olderThanDays)
+}
+
+// GetStubClientsReport lists stub clients older than the threshold (?olderThanDays=).
+func GetStubClientsReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		olderThan, _ := strconv.Atoi(c.Query("olderThanDays"))
+		days, cutoff := stubClientCutoff(olderThan)
+
+		q := stubClientsQuery(db, cutoff)
+		var total int64
+		if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener clientes temporales", "message": err.Error()})
+			return
+		}
+
+		limit := 100
+		if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= 500 {
+			limit = v
+		}
+		clients := make([]inactiveClient, 0)
+		if err := q.Select(`users.id, users.first_name, users.last_name, users.email, users.phone, users.office_id,
+				users.last_login, users.created_at,
+				(SELECT COUNT(*) FROM cases WHERE cases.client_id = users.id AND cases.deleted_at IS NULL) AS total_cases,
+				(SELECT MAX(cases.updated_at) FROM cases WHERE cases.client_id = users.id AND cases.deleted_at IS NULL) AS last_case_activity`).
+			Order("users.created_at ASC").
+			Limit(limit).
+			Scan(&clients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener clientes temporales", "message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"olderThanDays": days,
+			"cutoff":        cutoff,
+			"total":         total,
+			"clients":       clients,
+		})
+	}
+}
+
+// CleanupStubClients deactivates and soft-deletes stub clients older than the threshold,
+// writing an audit entry for each and revoking their sessions. With dryRun it only reports what
+// would be removed.
+func CleanupStubClients(db *gorm.DB, sessions interfaces.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input CleanupStubClientsInput
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "message": err.Error()})
+				return
+			}
+		}
+		days, cutoff := stubClientCutoff(input.OlderThanDays)
+
+		var stubs []models.User
+		if err := stubClientsQuery(db, cutoff).Select("users.id, users.email, users.first_name, users.last_name, users.created_at").
+			Order("users.created_at ASC").
+			Scan(&stubs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener clientes temporales", "message": err.Error()})
+			return
+		}
+
+		ids := make([]uint, 0, len(stubs))
+		for _, stub := range stubs {
+			ids = append(ids, stub.ID)
+		}
+		if input.DryRun || len(ids) == 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"dryRun":        input.DryRun,
+				"olderThanDays": days,
+				"matched":       len(ids),
+				"deleted":       0,
+				"clientIds":     ids,
+			})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.User{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{"is_active": false, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", ids).Delete(&models.User{}).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar clientes temporales", "message": err.Error()})
+			return
+		}
+		revokeRemovedUserSessions(c, sessions, ids...)
+
+		reason := input.Reason
+		if reason == "" {
+			reason = "stub_client_cleanup"
+		}
+		for _, stub := range stubs {
+			recordAuditLog(db, c, "user", stub.ID, "delete", reason, map[string]interface{}{
+				"email":         stub.Email,
+				"createdAt":     stub.CreatedAt,
+				"olderThanDays": days,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"dryRun":        false,
+			"olderThanDays": days,
+			"matched":       len(ids),
+			"deleted":       len(ids),
+			"clientIds":     ids,
+		})
+	}
+}
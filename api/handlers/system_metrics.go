@@ -0,0 +1,135 @@
+// api/handlers/system_metrics.go
+// Host CPU, memory and disk usage for the system health panel, read with gopsutil.
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// cpuSampleInterval is how long CPU usage is sampled for. Readings are cached with the
+// database probe, so at most one request per SYSTEM_HEALTH_CACHE_TTL_SECONDS waits on it.
+const cpuSampleInterval = 200 * time.Millisecond
+
+// Host metric states, from least to most severe; unavailable means the reading failed.
+const (
+	hostMetricHealthy     = "healthy"
+	hostMetricWarning     = "warning"
+	hostMetricCritical    = "critical"
+	hostMetricUnavailable = "unavailable"
+)
+
+// hostMetricsProvider reads host resource usage as percentages. A reading that cannot be
+// taken (e.g. in a container without access to /proc) returns an error.
+type hostMetricsProvider interface {
+	CPUPercent() (float64, error)
+	MemoryPercent() (float64, error)
+	DiskPercent(path string) (float64, error)
+}
+
+// hostMetrics is the provider behind the health panel; tests replace it.
+var hostMetrics hostMetricsProvider = gopsutilMetrics{}
+
+// gopsutilMetrics reads the host through gopsutil.
+type gopsutilMetrics struct{}
+
+func (gopsutilMetrics) CPUPercent() (float64, error) {
+	percents, err := cpu.Percent(cpuSampleInterval, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(percents) == 0 {
+		return 0, errors.New("no CPU reading")
+	}
+	return percents[0], nil
+}
+
+func (gopsutilMetrics) MemoryPercent() (float64, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, err
+	}
+	return vm.UsedPercent, nil
+}
+
+func (gopsutilMetrics) DiskPercent(path string) (float64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	return usage.UsedPercent, nil
+}
+
+// hostMetricReading is one usage figure and the state it puts the host in.
+type hostMetricReading struct {
+	Percent *float64 // nil when unavailable
+	Status  string
+}
+
+// hostMetricsSnapshot holds the CPU, memory and disk readings taken together.
+type hostMetricsSnapshot struct {
+	CPU      hostMetricReading
+	Memory   hostMetricReading
+	Disk     hostMetricReading
+	DiskPath string
+}
+
+// hostMetricStatus classifies a usage percentage against SYSTEM_METRICS_WARNING_PERCENT and
+// SYSTEM_METRICS_CRITICAL_PERCENT.
+func hostMetricStatus(percent float64) string {
+	warning, critical := config.SystemMetricsThresholds()
+	switch {
+	case percent >= critical:
+		return hostMetricCritical
+	case percent >= warning:
+		return hostMetricWarning
+	default:
+		return hostMetricHealthy
+	}
+}
+
+// newHostMetricReading turns a provider result into a reading, marking failures unavailable.
+func newHostMetricReading(percent float64, err error) hostMetricReading {
+	if err != nil {
+		return hostMetricReading{Status: hostMetricUnavailable}
+	}
+	return hostMetricReading{Percent: &percent, Status: hostMetricStatus(percent)}
+}
+
+// readHostMetrics takes every reading from provider, measuring the disk that holds diskPath.
+func readHostMetrics(provider hostMetricsProvider, diskPath string) hostMetricsSnapshot {
+	return hostMetricsSnapshot{
+		CPU:      newHostMetricReading(provider.CPUPercent()),
+		Memory:   newHostMetricReading(provider.MemoryPercent()),
+		Disk:     newHostMetricReading(provider.DiskPercent(diskPath)),
+		DiskPath: diskPath,
+	}
+}
+
+// getHostMetrics returns the cached host readings, taking new ones once the TTL has passed.
+func getHostMetrics() hostMetricsSnapshot {
+	cacheKey := analyticsCacheKey("system-health", "host")
+	if cached, ok := analyticsCache.get(cacheKey); ok {
+		return cached.(hostMetricsSnapshot)
+	}
+	snapshot := readHostMetrics(hostMetrics, config.SystemMetricsDiskPath())
+	analyticsCache.setFor(cacheKey, snapshot, time.Now(), config.SystemHealthCacheTTL())
+	return snapshot
+}
+
+// applyHostMetrics copies the readings into health. Storage reflects the disk state unless the
+// disk could not be read.
+func applyHostMetrics(health *SystemHealth, snapshot hostMetricsSnapshot) {
+	health.CPUUsage, health.CPUStatus = snapshot.CPU.Percent, snapshot.CPU.Status
+	health.MemoryUsage, health.MemoryStatus = snapshot.Memory.Percent, snapshot.Memory.Status
+	health.DiskUsage, health.DiskStatus = snapshot.Disk.Percent, snapshot.Disk.Status
+	health.DiskPath = snapshot.DiskPath
+	if snapshot.Disk.Status != hostMetricUnavailable {
+		health.Storage = snapshot.Disk.Status
+	}
+}
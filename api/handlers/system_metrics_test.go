@@ -0,0 +1,91 @@
+// api/handlers/system_metrics_test.go
+// Unit tests for host metric readings and their warning/critical states.
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeHostMetrics returns fixed readings; a nil error field means the reading succeeds.
+type fakeHostMetrics struct {
+	cpu, memory, disk          float64
+	cpuErr, memoryErr, diskErr error
+	diskPath                   string
+}
+
+func (f *fakeHostMetrics) CPUPercent() (float64, error)    { return f.cpu, f.cpuErr }
+func (f *fakeHostMetrics) MemoryPercent() (float64, error) { return f.memory, f.memoryErr }
+func (f *fakeHostMetrics) DiskPercent(path string) (float64, error) {
+	f.diskPath = path
+	return f.disk, f.diskErr
+}
+
+func TestReadHostMetricsFlagsThresholds(t *testing.T) {
+	provider := &fakeHostMetrics{cpu: 35.5, memory: 82, disk: 97.1}
+	snapshot := readHostMetrics(provider, "/srv/uploads")
+
+	if provider.diskPath != "/srv/uploads" {
+		t.Errorf("disk measured at %q, want /srv/uploads", provider.diskPath)
+	}
+	tests := []struct {
+		name    string
+		reading hostMetricReading
+		percent float64
+		status  string
+	}{
+		{"cpu", snapshot.CPU, 35.5, hostMetricHealthy},
+		{"memory", snapshot.Memory, 82, hostMetricWarning},
+		{"disk", snapshot.Disk, 97.1, hostMetricCritical},
+	}
+	for _, tt := range tests {
+		if tt.reading.Percent == nil || *tt.reading.Percent != tt.percent {
+			t.Errorf("%s percent = %v, want %v", tt.name, tt.reading.Percent, tt.percent)
+		}
+		if tt.reading.Status != tt.status {
+			t.Errorf("%s status = %q, want %q", tt.name, tt.reading.Status, tt.status)
+		}
+	}
+}
+
+func TestReadHostMetricsMarksFailedReadingsUnavailable(t *testing.T) {
+	restricted := errors.New("open /proc/stat: permission denied")
+	snapshot := readHostMetrics(&fakeHostMetrics{cpuErr: restricted, memory: 40, diskErr: restricted}, "/")
+
+	for name, reading := range map[string]hostMetricReading{"cpu": snapshot.CPU, "disk": snapshot.Disk} {
+		if reading.Percent != nil || reading.Status != hostMetricUnavailable {
+			t.Errorf("%s = (%v, %q), want no percent and unavailable", name, reading.Percent, reading.Status)
+		}
+	}
+	if snapshot.Memory.Status != hostMetricHealthy {
+		t.Errorf("memory status = %q, want healthy", snapshot.Memory.Status)
+	}
+}
+
+func TestHostMetricStatusUsesConfiguredThresholds(t *testing.T) {
+	t.Setenv("SYSTEM_METRICS_WARNING_PERCENT", "60")
+	t.Setenv("SYSTEM_METRICS_CRITICAL_PERCENT", "75")
+	for percent, want := range map[float64]string{59.9: hostMetricHealthy, 60: hostMetricWarning, 75: hostMetricCritical} {
+		if got := hostMetricStatus(percent); got != want {
+			t.Errorf("hostMetricStatus(%v) = %q, want %q", percent, got, want)
+		}
+	}
+}
+
+func TestApplyHostMetricsSetsStorageFromDisk(t *testing.T) {
+	health := SystemHealth{Storage: "healthy"}
+	applyHostMetrics(&health, readHostMetrics(&fakeHostMetrics{cpu: 10, memory: 20, disk: 91}, "/data"))
+	if health.Storage != hostMetricCritical || health.DiskStatus != hostMetricCritical || health.DiskPath != "/data" {
+		t.Errorf("storage = %q, disk = %q at %q; want critical at /data", health.Storage, health.DiskStatus, health.DiskPath)
+	}
+	if health.CPUUsage == nil || *health.CPUUsage != 10 || health.MemoryStatus != hostMetricHealthy {
+		t.Errorf("cpu = %v, memory status = %q", health.CPUUsage, health.MemoryStatus)
+	}
+
+	// An unreadable disk leaves the storage state alone rather than reporting it healthy or not
+	health = SystemHealth{Storage: "healthy"}
+	applyHostMetrics(&health, readHostMetrics(&fakeHostMetrics{diskErr: errors.New("no such file")}, "/data"))
+	if health.Storage != "healthy" || health.DiskUsage != nil || health.DiskStatus != hostMetricUnavailable {
+		t.Errorf("storage = %q, disk = (%v, %q)", health.Storage, health.DiskUsage, health.DiskStatus)
+	}
+}
@@ -0,0 +1,244 @@
+// api/handlers/task_dependencies.go
+// Ordering between tasks of a case ("file motion" before "attend hearing"): a task cannot be
+// completed while a task it depends on is still open, and dependencies may not form a cycle.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dependencyTask is a task on either side of a dependency.
+type dependencyTask struct {
+	ID           uint    `json:"id"`
+	Title        string  `json:"title"`
+	Status       string  `json:"status"`
+	AssignedToID *uint   `json:"assignedToId"`
+	DueDate      *string `json:"dueDate"`
+	Completed    bool    `json:"completed"`
+}
+
+// taskDependencyDone reports whether a dependency in status no longer blocks: it is completed,
+// or cancelled and so never will be.
+func taskDependencyDone(status string) bool {
+	return status == string(config.TaskStatusCompleted) || status == string(config.TaskStatusCancelled)
+}
+
+// loadDependencyTasks returns the tasks the task depends on (dependsOn) or, with dependents,
+// the tasks that depend on it, ordered by id.
+func loadDependencyTasks(db *gorm.DB, taskID uint, dependents bool) ([]dependencyTask, error) {
+	join, column := "tasks.id = task_dependencies.depends_on_task_id", "task_dependencies.task_id"
+	if dependents {
+		join, column = "tasks.id = task_dependencies.task_id", "task_dependencies.depends_on_task_id"
+	}
+	tasks := make([]dependencyTask, 0)
+	err := db.Table("task_dependencies").
+		Select("tasks.id, tasks.title, tasks.status, tasks.assigned_to_id, TO_CHAR(tasks.due_date, 'YYYY-MM-DD') AS due_date").
+		Joins("INNER JOIN tasks ON "+join).
+		Where(column+" = ? AND tasks.deleted_at IS NULL", taskID).
+		Order("tasks.id").
+		Scan(&tasks).Error
+	for i := range tasks {
+		tasks[i].Completed = taskDependencyDone(tasks[i].Status)
+	}
+	return tasks, err
+}
+
+// blockingDependency returns the first task the task depends on that is not done yet, or nil.
+func blockingDependency(db *gorm.DB, taskID uint) (*dependencyTask, error) {
+	dependencies, err := loadDependencyTasks(db, taskID, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range dependencies {
+		if !dependencies[i].Completed {
+			return &dependencies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// dependencyPath returns the tasks from one task to another following edges (task -> the tasks
+// it depends on), both ends included, or nil when to cannot be reached.
+func dependencyPath(edges map[uint][]uint, from uint, to uint) []uint {
+	previous := map[uint]uint{from: from}
+	queue := []uint{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []uint{to}
+			for current != from {
+				current = previous[current]
+				path = append([]uint{current}, path...)
+			}
+			return path
+		}
+		for _, next := range edges[current] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// dependencyCycle returns the cycle that making taskID depend on dependsOnID would close, as
+// task ids starting and ending with taskID, or nil when there is none.
+func dependencyCycle(db *gorm.DB, caseID uint, taskID uint, dependsOnID uint) ([]uint, error) {
+	var rows []models.TaskDependency
+	err := db.Table("task_dependencies").
+		Select("task_dependencies.task_id, task_dependencies.depends_on_task_id").
+		Joins("INNER JOIN tasks ON tasks.id = task_dependencies.task_id").
+		Where("tasks.case_id = ? AND tasks.deleted_at IS NULL", caseID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	edges := make(map[uint][]uint, len(rows))
+	for _, row := range rows {
+		edges[row.TaskID] = append(edges[row.TaskID], row.DependsOnTaskID)
+	}
+	if path := dependencyPath(edges, dependsOnID, taskID); path != nil {
+		return append([]uint{taskID}, path...), nil
+	}
+	return nil, nil
+}
+
+// GetTaskDependencies lists the tasks a task depends on and the tasks depending on it. blocked
+// tells whether a dependency still keeps it from being completed.
+func GetTaskDependencies(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var task models.Task
+		if err := db.Select("id", "case_id").First(&task, taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+			return
+		}
+
+		dependsOn, err := loadDependencyTasks(db, task.ID, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task dependencies", "message": err.Error()})
+			return
+		}
+		dependents, err := loadDependencyTasks(db, task.ID, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task dependencies", "message": err.Error()})
+			return
+		}
+		blocked := false
+		for _, dependency := range dependsOn {
+			blocked = blocked || !dependency.Completed
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"taskId":     task.ID,
+			"caseId":     task.CaseID,
+			"dependsOn":  dependsOn,
+			"dependents": dependents,
+			"blocked":    blocked,
+		})
+	}
+}
+
+// AddTaskDependency makes the task depend on dependsOnTaskId, another task of the same case.
+// A dependency that would close a cycle is rejected with 409 and the cycle's task ids.
+func AddTaskDependency(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var input struct {
+			DependsOnTaskID uint `json:"dependsOnTaskId" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dependsOnTaskId is required", "details": err.Error()})
+			return
+		}
+		if input.DependsOnTaskID == taskID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A task cannot depend on itself"})
+			return
+		}
+
+		var task, dependsOn models.Task
+		if err := db.Select("id", "case_id").First(&task, taskID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if err := db.Select("id", "case_id").First(&dependsOn, input.DependsOnTaskID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dependency task not found"})
+			return
+		}
+		if dependsOn.CaseID != task.CaseID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tasks can only depend on tasks of the same case"})
+			return
+		}
+
+		var existing int64
+		if err := db.Model(&models.TaskDependency{}).Where("task_id = ? AND depends_on_task_id = ?", task.ID, dependsOn.ID).Count(&existing).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check task dependencies", "message": err.Error()})
+			return
+		}
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "The task already depends on this task"})
+			return
+		}
+		cycle, err := dependencyCycle(db, task.CaseID, task.ID, dependsOn.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check task dependencies", "message": err.Error()})
+			return
+		}
+		if cycle != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "The dependency would create a cycle", "cycle": cycle})
+			return
+		}
+
+		dependency := models.TaskDependency{TaskID: task.ID, DependsOnTaskID: dependsOn.ID, CreatedBy: extractUserID(c)}
+		if err := db.Create(&dependency).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task dependency", "message": err.Error()})
+			return
+		}
+		invalidateCache(strconv.FormatUint(uint64(task.CaseID), 10))
+		c.JSON(http.StatusCreated, dependency)
+	}
+}
+
+// RemoveTaskDependency removes the task's dependency on :dependsOnId.
+func RemoveTaskDependency(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		dependsOnID, err := strconv.ParseUint(c.Param("dependsOnId"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependency task ID"})
+			return
+		}
+		result := db.Where("task_id = ? AND depends_on_task_id = ?", taskID, dependsOnID).Delete(&models.TaskDependency{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task dependency", "message": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task dependency not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Task dependency deleted successfully"})
+	}
+}
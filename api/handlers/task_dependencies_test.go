@@ -0,0 +1,174 @@
+// api/handlers/task_dependencies_test.go
+// Unit tests for task dependencies: cycles are rejected, and a task cannot be completed while
+// a task it depends on is open.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDependencyPath(t *testing.T) {
+	// 3 depends on 2, which depends on 1; 4 depends on 1
+	edges := map[uint][]uint{3: {2}, 2: {1}, 4: {1}}
+	tests := []struct {
+		from uint
+		to   uint
+		want []uint
+	}{
+		{3, 1, []uint{3, 2, 1}},
+		{4, 1, []uint{4, 1}},
+		{1, 3, nil},
+		{4, 3, nil},
+		{2, 2, []uint{2}},
+	}
+	for _, tt := range tests {
+		if got := dependencyPath(edges, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("dependencyPath(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// taskScript serves tasks 1 ("Presentar demanda"), 2 ("Asistir a audiencia") and 3 of case 7,
+// in the given statuses, with the dependencies listed as task -> tasks it depends on.
+func taskScript(statuses map[uint]string, dependencies map[uint][]uint) *scriptedSQL {
+	titles := map[uint]string{1: "Presentar demanda", 2: "Asistir a audiencia", 3: "Notificar al cliente"}
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "task_dependencies" INNER JOIN tasks ON tasks.id = task_dependencies.depends_on_task_id`):
+				rows := make([][]driver.Value, 0)
+				for _, id := range dependencies[uint(intArg(args[0]))] {
+					rows = append(rows, []driver.Value{int64(id), titles[id], statuses[id], nil, nil})
+				}
+				return []string{"id", "title", "status", "assigned_to_id", "due_date"}, rows
+			case strings.Contains(query, `FROM "task_dependencies" INNER JOIN tasks ON tasks.id = task_dependencies.task_id`) && strings.Contains(query, "tasks.case_id"):
+				rows := make([][]driver.Value, 0)
+				for task, dependsOn := range dependencies {
+					for _, id := range dependsOn {
+						rows = append(rows, []driver.Value{int64(task), int64(id)})
+					}
+				}
+				return []string{"task_id", "depends_on_task_id"}, rows
+			case strings.Contains(query, `FROM "task_dependencies"`) && strings.Contains(query, "count("):
+				return countRow(0)
+			case strings.Contains(query, `FROM "tasks"`):
+				id := uint(intArg(args[0]))
+				if _, ok := titles[id]; !ok {
+					return nil, nil
+				}
+				return []string{"id", "case_id", "title", "status"}, [][]driver.Value{{int64(id), int64(7), titles[id], statuses[id]}}
+			}
+			return nil, nil
+		},
+		affected: func(string) int64 { return 1 },
+	}
+}
+
+// runTaskHandler runs handler as admin 1 on task id with body.
+func runTaskHandler(t *testing.T, script *scriptedSQL, handler gin.HandlerFunc, method, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/admin/tasks/"+id, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	c.Set("currentUser", models.User{ID: 1, Role: "admin"})
+	handler(c)
+	return w
+}
+
+func TestAddTaskDependencyRejectsCycle(t *testing.T) {
+	statuses := map[uint]string{1: "pending", 2: "pending", 3: "pending"}
+	// 3 depends on 2, which depends on 1: making 1 depend on 3 closes 1 -> 3 -> 2 -> 1
+	script := taskScript(statuses, map[uint][]uint{3: {2}, 2: {1}})
+	w := runTaskHandler(t, script, AddTaskDependency(scriptedDB(t, script)), http.MethodPost, "1", `{"dependsOnTaskId":3}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Cycle []uint `json:"cycle"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(body.Cycle, []uint{1, 3, 2, 1}) {
+		t.Errorf("cycle = %v", body.Cycle)
+	}
+	if inserts := script.ran(`INSERT INTO "task_dependencies"`); len(inserts) != 0 {
+		t.Errorf("cyclic dependency stored: %v", inserts)
+	}
+
+	// Without the cycle the dependency is stored
+	script = taskScript(statuses, map[uint][]uint{2: {1}})
+	w = runTaskHandler(t, script, AddTaskDependency(scriptedDB(t, script)), http.MethodPost, "3", `{"dependsOnTaskId":2}`)
+	if w.Code != http.StatusCreated || len(script.ran(`INSERT INTO "task_dependencies"`)) != 1 {
+		t.Errorf("status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// Nor may a task depend on itself
+	w = runTaskHandler(t, script, AddTaskDependency(scriptedDB(t, script)), http.MethodPost, "3", `{"dependsOnTaskId":3}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("self dependency: status %d", w.Code)
+	}
+}
+
+func TestCompletingTaskBlockedByOpenDependency(t *testing.T) {
+	// 2 ("Asistir a audiencia") depends on 1 ("Presentar demanda") and on 3, which is done
+	dependencies := map[uint][]uint{2: {3, 1}}
+	script := taskScript(map[uint]string{1: "in_progress", 2: "pending", 3: "completed"}, dependencies)
+	w := runTaskHandler(t, script, UpdateTaskEnhanced(scriptedDB(t, script)), http.MethodPatch, "2", `{"status":"completed"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"blockingTaskId":1`) || !strings.Contains(w.Body.String(), "Presentar demanda") {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if updates := script.ran(`UPDATE "tasks"`); len(updates) != 0 {
+		t.Errorf("blocked task updated: %v", updates)
+	}
+
+	// Other changes to the blocked task still go through
+	w = runTaskHandler(t, script, UpdateTaskEnhanced(scriptedDB(t, script)), http.MethodPatch, "2", `{"status":"in_progress"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("in_progress: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// Once every dependency is completed or cancelled, the task can be completed
+	for _, status := range []string{"completed", "cancelled"} {
+		script := taskScript(map[uint]string{1: status, 2: "pending", 3: "completed"}, dependencies)
+		w := runTaskHandler(t, script, UpdateTaskEnhanced(scriptedDB(t, script)), http.MethodPatch, "2", `{"status":"completed"}`)
+		if w.Code != http.StatusOK || len(script.ran(`UPDATE "tasks"`)) != 1 {
+			t.Errorf("dependency %s: status %d, body %s", status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetTaskDependencies(t *testing.T) {
+	script := taskScript(map[uint]string{1: "in_progress", 2: "pending", 3: "completed"}, map[uint][]uint{2: {1, 3}})
+	w := runTaskHandler(t, script, GetTaskDependencies(scriptedDB(t, script)), http.MethodGet, "2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		DependsOn []dependencyTask `json:"dependsOn"`
+		Blocked   bool             `json:"blocked"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.Blocked || len(body.DependsOn) != 2 || body.DependsOn[0].Completed || !body.DependsOn[1].Completed {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
@@ -356,6 +356,22 @@ func UpdateTaskEnhanced(db *gorm.DB) gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task status specified. Allowed values: pending, in_progress, completed"})
 				return
 			}
+			// A task waits for the tasks it depends on
+			if input.Status == "completed" && task.Status != "completed" {
+				blocking, err := blockingDependency(db, task.ID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check task dependencies"})
+					return
+				}
+				if blocking != nil {
+					c.JSON(http.StatusConflict, gin.H{
+						"error":          "Task cannot be completed before the tasks it depends on",
+						"blockingTaskId": blocking.ID,
+						"blockingTask":   blocking,
+					})
+					return
+				}
+			}
 			updates["status"] = input.Status
 
 			// Set completed_at timestamp when status changes to completed
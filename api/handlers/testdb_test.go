@@ -0,0 +1,354 @@
+// api/handlers/testdb_test.go
+// Databases for the handler tests: a scripted SQL driver, for asserting the statements a handler
+// sends (transactions, ordering, failures), and an SQLite schema, for asserting the rows it leaves
+// behind.
+package handlers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// scriptedSQL answers the queries of one test database and records every statement, plus
+// BEGIN, COMMIT and ROLLBACK.
+type scriptedSQL struct {
+	mutex      sync.Mutex
+	statements []string
+	// rows answers a query, given its arguments, with its columns and rows; nil means no rows
+	rows func(query string, args []driver.Value) ([]string, [][]driver.Value)
+	// affected is the row count reported for an UPDATE or DELETE
+	affected func(query string) int64
+	// observe, when set, sees every statement with its arguments before it is answered
+	observe func(query string, args []driver.Value)
+	// fail, when set, returns the error a statement fails with; nil lets it run
+	fail func(query string) error
+}
+
+func (s *scriptedSQL) record(statement string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.statements = append(s.statements, statement)
+}
+
+// ran returns the recorded statements containing fragment.
+func (s *scriptedSQL) ran(fragment string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	matches := make([]string, 0)
+	for _, statement := range s.statements {
+		if strings.Contains(statement, fragment) {
+			matches = append(matches, statement)
+		}
+	}
+	return matches
+}
+
+// statementIndex returns the position of the first recorded statement containing fragment, or -1.
+func statementIndex(script *scriptedSQL, fragment string) int {
+	script.mutex.Lock()
+	defer script.mutex.Unlock()
+	for i, statement := range script.statements {
+		if strings.Contains(statement, fragment) {
+			return i
+		}
+	}
+	return -1
+}
+
+// answerUpdates reports affected rows for every UPDATE and none for other statements.
+func answerUpdates(affected int64) func(string) int64 {
+	return func(query string) int64 {
+		if strings.HasPrefix(query, "UPDATE") {
+			return affected
+		}
+		return 0
+	}
+}
+
+// countRow answers a count(*) query.
+func countRow(count int64) ([]string, [][]driver.Value) {
+	return []string{"count"}, [][]driver.Value{{count}}
+}
+
+// intArg reads an integer statement argument, which may arrive as int or int64.
+func intArg(value driver.Value) int {
+	n, _ := strconv.Atoi(fmt.Sprint(value))
+	return n
+}
+
+// boundArg reports whether value is among the bound arguments, comparing them as the driver
+// would receive them.
+func boundArg(args []driver.Value, value driver.Value) bool {
+	for _, arg := range args {
+		if converted, _ := driver.DefaultParameterConverter.ConvertValue(arg); converted == value {
+			return true
+		}
+	}
+	return false
+}
+
+// timeArgs returns the bound time arguments, in order.
+func timeArgs(args []driver.Value) []time.Time {
+	times := make([]time.Time, 0, len(args))
+	for _, arg := range args {
+		if at, ok := arg.(time.Time); ok {
+			times = append(times, at)
+		}
+	}
+	return times
+}
+
+// statementValues maps the columns of a single-row INSERT or of an UPDATE's SET clause to the
+// statement's arguments.
+func statementValues(query string, args []driver.Value) map[string]driver.Value {
+	values := make(map[string]driver.Value)
+	var columns []string
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		list := query[strings.Index(query, "(")+1 : strings.Index(query, ")")]
+		for _, column := range strings.Split(list, ",") {
+			columns = append(columns, strings.Trim(column, `" `))
+		}
+	case strings.HasPrefix(query, "UPDATE"):
+		set := query[strings.Index(query, " SET ")+5 : strings.Index(query, " WHERE ")]
+		for _, assignment := range strings.Split(set, ",") {
+			column, _, _ := strings.Cut(assignment, "=")
+			columns = append(columns, strings.Trim(column, `" `))
+		}
+	}
+	for i, column := range columns {
+		if i < len(args) {
+			value, err := driver.DefaultParameterConverter.ConvertValue(args[i])
+			if err != nil {
+				value = args[i]
+			}
+			values[column] = value
+		}
+	}
+	return values
+}
+
+var (
+	scriptedDrivers     = map[string]*scriptedSQL{}
+	scriptedDriversLock sync.Mutex
+	registerScripted    sync.Once
+)
+
+// scriptedDB opens a Postgres-dialect *gorm.DB backed by script.
+func scriptedDB(t *testing.T, script *scriptedSQL) *gorm.DB {
+	t.Helper()
+	registerScripted.Do(func() { sql.Register("caf-scripted", scriptedDriver{}) })
+	scriptedDriversLock.Lock()
+	scriptedDrivers[t.Name()] = script
+	scriptedDriversLock.Unlock()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "caf-scripted", DSN: t.Name()}), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open scripted database: %v", err)
+	}
+	return db
+}
+
+// dryRunDB returns a Postgres-dialect *gorm.DB that builds SQL without connecting.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=caf_test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run database: %v", err)
+	}
+	return db
+}
+
+type scriptedDriver struct{}
+
+func (scriptedDriver) Open(name string) (driver.Conn, error) {
+	scriptedDriversLock.Lock()
+	defer scriptedDriversLock.Unlock()
+	script, ok := scriptedDrivers[name]
+	if !ok {
+		return nil, fmt.Errorf("no script for %q", name)
+	}
+	return scriptedConn{script}, nil
+}
+
+type scriptedConn struct{ script *scriptedSQL }
+
+func (c scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return scriptedStmt{c.script, query}, nil
+}
+func (c scriptedConn) Close() error { return nil }
+
+// CheckNamedValue accepts every argument as-is, as pgx does for maps stored in jsonb columns.
+func (c scriptedConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+func (c scriptedConn) Begin() (driver.Tx, error) {
+	c.script.record("BEGIN")
+	return scriptedTx(c), nil
+}
+
+type scriptedTx scriptedConn
+
+func (tx scriptedTx) Commit() error   { tx.script.record("COMMIT"); return nil }
+func (tx scriptedTx) Rollback() error { tx.script.record("ROLLBACK"); return nil }
+
+type scriptedStmt struct {
+	script *scriptedSQL
+	query  string
+}
+
+func (s scriptedStmt) Close() error  { return nil }
+func (s scriptedStmt) NumInput() int { return -1 }
+
+func (s scriptedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.script.record(s.query)
+	if s.script.observe != nil {
+		s.script.observe(s.query, args)
+	}
+	if s.script.fail != nil {
+		if err := s.script.fail(s.query); err != nil {
+			return nil, err
+		}
+	}
+	var affected int64
+	if s.script.affected != nil {
+		affected = s.script.affected(s.query)
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (s scriptedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.script.record(s.query)
+	if s.script.observe != nil {
+		s.script.observe(s.query, args)
+	}
+	if s.script.fail != nil {
+		if err := s.script.fail(s.query); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(s.query, "INSERT") {
+		return &scriptedRows{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}, nil
+	}
+	rows := &scriptedRows{}
+	if s.script.rows != nil {
+		rows.columns, rows.rows = s.script.rows(s.query, args)
+	}
+	if rows.columns == nil {
+		rows.columns = []string{"id"}
+	}
+	return rows, nil
+}
+
+type scriptedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var registerSchema sync.Once
+
+// schemaDB opens an SQLite database with the tables of the given models, for tests that assert
+// the rows a handler leaves behind rather than the SQL it sends. Tables the models cannot create
+// on SQLite are created from their Postgres definitions in schemaTables.
+func schemaDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	registerSchema.Do(func() {
+		base, err := sql.Open(sqlite.DriverName, "")
+		if err != nil {
+			panic(err)
+		}
+		sql.Register("caf-schema", schemaDriver{base.Driver()})
+	})
+	dsn := filepath.Join(t.TempDir(), "caf.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "caf-schema", DSN: dsn}, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open schema database: %v", err)
+	}
+	for _, statement := range schemaTables {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	return db
+}
+
+// seedRows inserts the records into a schema database, in order.
+func seedRows(t *testing.T, db *gorm.DB, records ...interface{}) {
+	t.Helper()
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to insert %T: %v", record, err)
+		}
+	}
+}
+
+// schemaTables are created before the models' tables: user_case_assignments defaults
+// assigned_at with now(), which SQLite does not have.
+var schemaTables = []string{
+	`CREATE TABLE user_case_assignments (id integer PRIMARY KEY AUTOINCREMENT, user_id integer NOT NULL,
+		case_id integer NOT NULL, role text NOT NULL, assigned_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at datetime, updated_at datetime, deleted_at datetime)`,
+}
+
+// schemaDriver is the SQLite driver storing maps as JSON text, as pgx stores them in jsonb
+// columns.
+type schemaDriver struct{ driver.Driver }
+
+func (d schemaDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return schemaConn{conn}, nil
+}
+
+type schemaConn struct{ driver.Conn }
+
+func (c schemaConn) CheckNamedValue(value *driver.NamedValue) error {
+	if fields, ok := value.Value.(map[string]interface{}); ok {
+		if fields == nil {
+			value.Value = nil
+			return nil
+		}
+		encoded, err := json.Marshal(fields)
+		value.Value = string(encoded)
+		return err
+	}
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,160 @@
+// api/handlers/trends.go
+// Organization-wide trend lines for the admin dashboard charts. Where the dashboard
+// stats are point-in-time (e.g. AppointmentSuccessRate), these bucket the same figures
+// by week or month.
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Trend bucket limits
+const (
+	defaultTrendBuckets = 12
+	maxTrendBuckets     = 104
+)
+
+// appointmentCompletionBucket is one point of the completion trend.
+type appointmentCompletionBucket struct {
+	Start          string  `json:"start"` // First day of the bucket, YYYY-MM-DD
+	End            string  `json:"end"`   // Last day of the bucket, YYYY-MM-DD
+	Total          int64   `json:"total"`
+	Completed      int64   `json:"completed"`
+	Cancelled      int64   `json:"cancelled"`
+	NoShow         int64   `json:"noShow"`
+	Rescheduled    int64   `json:"rescheduled"`    // Reschedules made during the bucket
+	CompletionRate float64 `json:"completionRate"` // Completed / Total, as a percentage
+}
+
+// trendBucketStart returns the start of the week (Monday) or month containing t.
+func trendBucketStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == "month" {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// trendBucketNext returns the start of the bucket after start.
+func trendBucketNext(start time.Time, period string) time.Time {
+	if period == "month" {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// GetAppointmentCompletionTrend returns the organization's appointment completion rate for the
+// last ?buckets= (default 12, max 104) weeks or months (?period=week|month, default week),
+// oldest first. The current bucket is included and only counts appointments that have started.
+// Rescheduled counts the reschedules recorded during each bucket, whenever the appointment is.
+func GetAppointmentCompletionTrend(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		period := c.DefaultQuery("period", "week")
+		if period != "week" && period != "month" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period debe ser 'week' o 'month'"})
+			return
+		}
+		buckets := defaultTrendBuckets
+		if v := c.Query("buckets"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > maxTrendBuckets {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "buckets debe ser un número entre 1 y 104"})
+				return
+			}
+			buckets = parsed
+		}
+
+		cacheKey := analyticsCacheKey("appointment-completion-trend", period, buckets)
+		if cached, ok := analyticsCache.get(cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+
+		now := time.Now()
+		current := trendBucketStart(now, period)
+		from := current
+		for i := 1; i < buckets; i++ {
+			if period == "month" {
+				from = from.AddDate(0, -1, 0)
+			} else {
+				from = from.AddDate(0, 0, -7)
+			}
+		}
+
+		type bucketCount struct {
+			Bucket    time.Time
+			Total     int64
+			Completed int64
+			Cancelled int64
+			NoShow    int64
+		}
+		var counts []bucketCount
+		if err := db.Model(&models.Appointment{}).
+			Select(`date_trunc(?, start_time) AS bucket,
+				COUNT(*) AS total,
+				COUNT(*) FILTER (WHERE status = ?) AS completed,
+				COUNT(*) FILTER (WHERE status = ?) AS cancelled,
+				COUNT(*) FILTER (WHERE status = ?) AS no_show`,
+				period, config.StatusCompleted, config.StatusCancelled, config.StatusNoShow).
+			Where("start_time >= ? AND start_time < ?", from, now).
+			Group("bucket").
+			Scan(&counts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la tendencia de citas", "message": err.Error()})
+			return
+		}
+		byBucket := make(map[string]bucketCount, len(counts))
+		for _, row := range counts {
+			byBucket[row.Bucket.Format("2006-01-02")] = row
+		}
+
+		var reschedules []struct {
+			Bucket time.Time
+			Total  int64
+		}
+		if err := db.Model(&models.AppointmentReschedule{}).
+			Select("date_trunc(?, rescheduled_at) AS bucket, COUNT(*) AS total", period).
+			Where("rescheduled_at >= ? AND rescheduled_at < ?", from, now).
+			Group("bucket").
+			Scan(&reschedules).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la tendencia de citas", "message": err.Error()})
+			return
+		}
+		rescheduledByBucket := make(map[string]int64, len(reschedules))
+		for _, row := range reschedules {
+			rescheduledByBucket[row.Bucket.Format("2006-01-02")] = row.Total
+		}
+
+		series := make([]appointmentCompletionBucket, 0, buckets)
+		for start := from; !start.After(current); start = trendBucketNext(start, period) {
+			row := byBucket[start.Format("2006-01-02")]
+			point := appointmentCompletionBucket{
+				Start:       start.Format("2006-01-02"),
+				End:         trendBucketNext(start, period).AddDate(0, 0, -1).Format("2006-01-02"),
+				Total:       row.Total,
+				Completed:   row.Completed,
+				Cancelled:   row.Cancelled,
+				NoShow:      row.NoShow,
+				Rescheduled: rescheduledByBucket[start.Format("2006-01-02")],
+			}
+			if point.Total > 0 {
+				point.CompletionRate = float64(point.Completed) / float64(point.Total) * 100
+			}
+			series = append(series, point)
+		}
+
+		response := gin.H{
+			"period":  period,
+			"buckets": series,
+			"asOf":    now,
+		}
+		analyticsCache.set(cacheKey, response, now)
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,324 @@
+// api/handlers/user_activity.go
+// Everything a single user did over a period, from audit logs and case events, for HR and
+// security reviews. Viewed page by page or exported as CSV/PDF.
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/BryanPMX/CAF/api/pdf"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultUserActivityDays is the period covered when ?from= is not given.
+const defaultUserActivityDays = 30
+
+// userActivityHeader is the column header of the CSV export.
+var userActivityHeader = []string{"Fecha", "Fuente", "Acción", "Recurso", "ID del recurso", "Detalles", "Motivo", "Severidad", "Campos modificados"}
+
+// userActivityKey identifies one audit log or case event in the merged activity of a user.
+type userActivityKey struct {
+	Source     string
+	ID         uint
+	OccurredAt time.Time
+}
+
+// userActivityScope selects a user's audit logs and case events in [from, to) as one keyed
+// list. Deleted case events are included; the trail marks them as such.
+func userActivityScope(db *gorm.DB, userID uint, from, to time.Time) *gorm.DB {
+	union := db.Raw(`SELECT 'audit_log' AS source, id, created_at AS occurred_at FROM audit_logs
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		UNION ALL
+		SELECT 'case_event' AS source, id, created_at AS occurred_at FROM case_events
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?`,
+		userID, from, to, userID, from, to)
+	return db.Table("(?) AS activity", union)
+}
+
+// loadUserActivity loads the entries behind keys, in the order of keys, attributed to user.
+func loadUserActivity(db *gorm.DB, user models.User, keys []userActivityKey) ([]auditTrailEntry, error) {
+	var logIDs, eventIDs []uint
+	for _, key := range keys {
+		if key.Source == "audit_log" {
+			logIDs = append(logIDs, key.ID)
+		} else {
+			eventIDs = append(eventIDs, key.ID)
+		}
+	}
+
+	logs := make(map[uint]models.AuditLog, len(logIDs))
+	if len(logIDs) > 0 {
+		var rows []models.AuditLog
+		if err := db.Where("id IN ?", logIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			logs[row.ID] = row
+		}
+	}
+	events := make(map[uint]models.CaseEvent, len(eventIDs))
+	if len(eventIDs) > 0 {
+		var rows []models.CaseEvent
+		if err := db.Unscoped().Select("id, case_id, user_id, event_type, visibility, comment_text, description, file_name, created_at, deleted_at").
+			Where("id IN ?", eventIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			events[row.ID] = row
+		}
+	}
+
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	entries := make([]auditTrailEntry, 0, len(keys))
+	for _, key := range keys {
+		if key.Source == "audit_log" {
+			if l, ok := logs[key.ID]; ok {
+				entries = append(entries, auditLogTrailEntry(l, name))
+			}
+		} else if e, ok := events[key.ID]; ok {
+			entry := caseEventTrailEntry(e, name)
+			entry.ActorRole = user.Role
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// eachUserActivityBatch calls fn with the user's activity in [from, to), oldest first,
+// exportBatchSize entries at a time. Batches are read by keyset, so entries logged while the
+// export runs do not shift or repeat rows.
+func eachUserActivityBatch(db *gorm.DB, user models.User, from, to time.Time, fn func([]auditTrailEntry) error) error {
+	var after *userActivityKey
+	for {
+		query := userActivityScope(db, user.ID, from, to)
+		if after != nil {
+			query = query.Where("(occurred_at, source, id) > (?, ?, ?)", after.OccurredAt, after.Source, after.ID)
+		}
+		var keys []userActivityKey
+		if err := query.Select("source, id, occurred_at").
+			Order("occurred_at, source, id").Limit(exportBatchSize).
+			Scan(&keys).Error; err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		entries, err := loadUserActivity(db, user, keys)
+		if err != nil {
+			return err
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+		if len(keys) < exportBatchSize {
+			return nil
+		}
+		after = &keys[len(keys)-1]
+	}
+}
+
+// userActivityRow is one CSV row of the export.
+func userActivityRow(entry auditTrailEntry) []string {
+	fields := make([]string, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		fields = append(fields, change.Field)
+	}
+	return []string{
+		entry.Timestamp.Format("2006-01-02 15:04:05"),
+		entry.Source,
+		entry.Action,
+		entry.EntityType,
+		strconv.FormatUint(uint64(entry.EntityID), 10),
+		entry.Details,
+		entry.Reason,
+		entry.Severity,
+		strings.Join(fields, ", "),
+	}
+}
+
+// GetUserActivity returns every audit log and case event attributable to a user (deleted users
+// included) between ?from=&to= (YYYY-MM-DD, to inclusive; default: the last 30 days). The JSON
+// view is paginated newest first; ?format=csv|pdf downloads the whole period oldest first, read
+// in batches, with CSV rows streamed as they are read. Each request is recorded as a
+// data_access audit entry.
+func GetUserActivity(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		format := strings.ToLower(c.DefaultQuery("format", "json"))
+		if format != "json" && format != "csv" && format != "pdf" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato no válido, use json, csv o pdf"})
+			return
+		}
+
+		from, to, ok := parseReportPeriod(c, defaultUserActivityDays)
+		if !ok {
+			return
+		}
+
+		var user models.User
+		if err := db.Unscoped().Select("id, first_name, last_name, email, role, office_id").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el usuario", "message": err.Error()})
+			return
+		}
+
+		period := gin.H{"from": from.Format("2006-01-02"), "to": to.AddDate(0, 0, -1).Format("2006-01-02"), "format": format}
+		if format != "json" {
+			recordDataAccessAuditLog(db, c, "user", user.ID, "export", "user_activity", period)
+			filename := fmt.Sprintf("actividad-usuario-%d-%s-%s", user.ID, from.Format("20060102"), to.AddDate(0, 0, -1).Format("20060102"))
+			if format == "csv" {
+				streamUserActivityCSV(c, db, user, from, to, filename)
+			} else {
+				renderUserActivityPDF(c, db, user, from, to, filename)
+			}
+			return
+		}
+
+		page, pageSize := parseCasePagination(c)
+		var total int64
+		if err := userActivityScope(db, user.ID, from, to).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la actividad del usuario", "message": err.Error()})
+			return
+		}
+		var keys []userActivityKey
+		if err := userActivityScope(db, user.ID, from, to).Select("source, id, occurred_at").
+			Order("occurred_at DESC, source DESC, id DESC").
+			Offset((page - 1) * pageSize).Limit(pageSize).
+			Scan(&keys).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la actividad del usuario", "message": err.Error()})
+			return
+		}
+		entries, err := loadUserActivity(db, user, keys)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la actividad del usuario", "message": err.Error()})
+			return
+		}
+		recordDataAccessAuditLog(db, c, "user", user.ID, "view", "user_activity", period)
+
+		totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"userId": user.ID,
+			"name":   strings.TrimSpace(user.FirstName + " " + user.LastName),
+			"email":  user.Email,
+			"role":   user.Role,
+			"from":   period["from"],
+			"to":     period["to"],
+			"data":   entries,
+			"pagination": gin.H{
+				"page":       page,
+				"pageSize":   pageSize,
+				"total":      total,
+				"totalPages": totalPages,
+				"hasNext":    page < int(totalPages),
+				"hasPrev":    page > 1,
+			},
+		})
+	}
+}
+
+// streamUserActivityCSV writes the user's activity as CSV, flushing after every batch. Once the
+// first row is out an error can no longer change the status, so it ends the file early and is logged.
+func streamUserActivityCSV(c *gin.Context, db *gorm.DB, user models.User, from, to time.Time, filename string) {
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	err := eachUserActivityBatch(db, user, from, to, func(entries []auditTrailEntry) error {
+		if !started {
+			startUserActivityCSV(c, writer, filename)
+			started = true
+		}
+		for _, entry := range entries {
+			if err := writer.Write(userActivityRow(entry)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		c.Writer.Flush()
+		return writer.Error()
+	})
+	if err != nil && !started {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al exportar la actividad del usuario", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("WARNING: User activity export for user %d stopped early: %v", user.ID, err)
+		return
+	}
+	if !started {
+		startUserActivityCSV(c, writer, filename)
+		writer.Flush()
+	}
+}
+
+// startUserActivityCSV sends the download headers, the BOM and the header row.
+func startUserActivityCSV(c *gin.Context, writer *csv.Writer, filename string) {
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	c.Writer.WriteString("\xef\xbb\xbf") // UTF-8 BOM so spreadsheet apps keep accents
+	writer.Write(userActivityHeader)
+}
+
+// renderUserActivityPDF lays the user's activity out as a printable listing, batch by batch.
+func renderUserActivityPDF(c *gin.Context, db *gorm.DB, user models.User, from, to time.Time, filename string) {
+	doc := pdf.NewWithHeader(func(d *pdf.Document) {
+		writeCAFLetterhead(d, nil)
+	})
+	doc.Line(16, true, "ACTIVIDAD DE USUARIO")
+	doc.Line(10, false, fmt.Sprintf("%s %s (%s, %s)", user.FirstName, user.LastName, user.Email, user.Role))
+	doc.Line(10, false, "Periodo: "+from.Format("02/01/2006")+" - "+to.AddDate(0, 0, -1).Format("02/01/2006"))
+	doc.Line(10, false, "Generado: "+time.Now().Format("02/01/2006 15:04"))
+	doc.Space(10)
+
+	columns := []float64{0, 95, 215, 320}
+	doc.Columns(9, true, columns, []string{"Fecha", "Acción", "Recurso", "Detalles"})
+	doc.Rule()
+	count := 0
+	err := eachUserActivityBatch(db, user, from, to, func(entries []auditTrailEntry) error {
+		for _, entry := range entries {
+			details := entry.Details
+			if details == "" {
+				details = entry.Reason
+			}
+			if runes := []rune(details); len(runes) > 50 {
+				details = string(runes[:47]) + "..."
+			}
+			doc.Columns(9, false, columns, []string{
+				entry.Timestamp.Format("02/01/2006 15:04"),
+				entry.Action,
+				fmt.Sprintf("%s #%d", entry.EntityType, entry.EntityID),
+				details,
+			})
+		}
+		count += len(entries)
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al exportar la actividad del usuario", "message": err.Error()})
+		return
+	}
+	if count == 0 {
+		doc.Line(10, false, "Sin actividad registrada en el periodo.")
+	}
+	doc.Space(10)
+	doc.Line(9, false, fmt.Sprintf("Total de registros: %d", count))
+
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.pdf\"", filename))
+	c.Data(http.StatusOK, "application/pdf", doc.Bytes())
+}
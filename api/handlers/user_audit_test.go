@@ -0,0 +1,155 @@
+// api/handlers/user_audit_test.go
+// Unit tests for the audit trail of account changes: a role change is logged with the role
+// before and after it, updates that change nothing are not logged, and deletions keep the
+// deleted user's values.
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// capturedAudit is an audit log INSERT seen by userAuditScript. Columns after an empty array
+// are misaligned in values, since the array binds no argument, so args keeps the raw arguments.
+type capturedAudit struct {
+	values map[string]driver.Value
+	args   []driver.Value
+}
+
+// hasArg reports whether the INSERT bound value.
+func (a capturedAudit) hasArg(value driver.Value) bool {
+	for _, arg := range a.args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+// userAuditScript answers user lookups with lawyer 9 of office 2 and captures every audit log
+// written.
+func userAuditScript(audits *[]capturedAudit) *scriptedSQL {
+	return &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, "count(*)"):
+				return countRow(2)
+			case strings.Contains(query, `FROM "users"`):
+				return []string{"id", "first_name", "last_name", "email", "role", "office_id", "phone"},
+					[][]driver.Value{{int64(9), "Ana", "Ruiz", "ana@caf.mx", "lawyer", int64(2), "6561234567"}}
+			}
+			return nil, nil
+		},
+		affected: func(string) int64 { return 1 },
+		observe: func(query string, args []driver.Value) {
+			if strings.HasPrefix(query, `INSERT INTO "audit_logs"`) {
+				*audits = append(*audits, capturedAudit{statementValues(query, args), args})
+			}
+		},
+	}
+}
+
+// runUserChange runs handler on user 9 as admin 1 with body, if any.
+func runUserChange(t *testing.T, db *gorm.DB, handler func(*gorm.DB) gin.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/admin/users/9", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("User-Agent", "panel-admin")
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+	c.Set("userID", "1")
+	c.Set("userRole", "admin")
+	handler(db)(c)
+	c.Writer.WriteHeaderNow()
+	return w
+}
+
+// auditJSON decodes a JSON column of a captured audit log.
+func auditJSON(t *testing.T, value driver.Value) map[string]interface{} {
+	t.Helper()
+	text, _ := value.(string)
+	decoded := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+		t.Fatalf("audit values %v: %v", value, err)
+	}
+	return decoded
+}
+
+func TestUpdateUserLogsRoleChange(t *testing.T) {
+	var audits []capturedAudit
+	db := scriptedDB(t, userAuditScript(&audits))
+	body := `{"firstName":"Ana","lastName":"Ruiz","email":"ana@caf.mx","role":"office_manager","officeId":2,"phone":"6561234567"}`
+	if w := runUserChange(t, db, UpdateUser, http.MethodPatch, body); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(audits) != 1 {
+		t.Fatalf("audit logs = %v, want 1", audits)
+	}
+
+	audit := audits[0].values
+	if audit["action"] != "role_change" || audit["entity_type"] != "user" || audit["entity_id"] != int64(9) || audit["severity"] != "warning" {
+		t.Errorf("audit = %v", audit)
+	}
+	if audit["user_id"] != int64(1) || audit["user_agent"] != "panel-admin" || audit["ip_address"] == "" {
+		t.Errorf("actor = %v %v %v", audit["user_id"], audit["user_agent"], audit["ip_address"])
+	}
+	// A one-element array is bound as its single value
+	if audit["changed_fields"] != "role" {
+		t.Errorf("changed fields = %v, want [role]", audit["changed_fields"])
+	}
+	if old := auditJSON(t, audit["old_values"]); old["role"] != "lawyer" || len(old) != 1 {
+		t.Errorf("old values = %v", old)
+	}
+	if updated := auditJSON(t, audit["new_values"]); updated["role"] != "office_manager" || len(updated) != 1 {
+		t.Errorf("new values = %v", updated)
+	}
+}
+
+func TestUpdateUserWithoutChangesIsNotLogged(t *testing.T) {
+	var audits []capturedAudit
+	db := scriptedDB(t, userAuditScript(&audits))
+	body := `{"firstName":"Ana","lastName":"Ruiz","email":"ana@caf.mx","role":"lawyer","officeId":2,"phone":" 6561234567 "}`
+	if w := runUserChange(t, db, UpdateUser, http.MethodPatch, body); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(audits) != 0 {
+		t.Errorf("audit logs = %v, want none", audits)
+	}
+}
+
+func TestDeleteUserLogsDeletedValues(t *testing.T) {
+	tests := []struct {
+		handler  func(*gorm.DB) gin.HandlerFunc
+		action   string
+		severity string
+	}{
+		{DeleteUser, "delete", "warning"},
+		{PermanentDeleteUser, "permanent_delete", "critical"},
+	}
+	for _, tt := range tests {
+		var audits []capturedAudit
+		db := scriptedDB(t, userAuditScript(&audits))
+		if w := runUserChange(t, db, tt.handler, http.MethodDelete, ""); w.Code != http.StatusNoContent {
+			t.Fatalf("%s: status = %d: %s", tt.action, w.Code, w.Body.String())
+		}
+		if len(audits) != 1 {
+			t.Fatalf("%s: audit logs = %v, want 1", tt.action, audits)
+		}
+		audit := audits[0].values
+		if audit["action"] != tt.action || audit["entity_id"] != int64(9) || !audits[0].hasArg(tt.severity) {
+			t.Errorf("%s: audit = %v", tt.action, audit)
+		}
+		if old := auditJSON(t, audit["old_values"]); old["email"] != "ana@caf.mx" || old["role"] != "lawyer" || old["officeId"] != float64(2) {
+			t.Errorf("%s: old values = %v", tt.action, old)
+		}
+	}
+}
@@ -0,0 +1,574 @@
+// api/handlers/webhooks.go
+// Outbound webhooks: admins subscribe partner endpoints to CAF events. Each event is queued
+// per subscription with the change that caused it, and a background worker POSTs it as signed
+// JSON, retrying failures with exponential backoff.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// webhookEvents are the events a subscription may ask for.
+var webhookEvents = []string{
+	models.WebhookEventCaseCreated,
+	models.WebhookEventCaseStageChanged,
+	models.WebhookEventAppointmentCreated,
+	models.WebhookEventAppointmentCancelled,
+}
+
+// webhookClient sends deliveries; the per-attempt timeout comes from config.WebhookTimeout.
+var webhookClient = &http.Client{}
+
+// webhookEnvelope is the JSON body of every delivery.
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// caseStageChangedPayload is the data of a case.stage_changed event.
+type caseStageChangedPayload struct {
+	CaseID       uint      `json:"caseId"`
+	CaseNumber   string    `json:"caseNumber"`
+	Title        string    `json:"title"`
+	Category     string    `json:"category"`
+	OfficeID     uint      `json:"officeId"`
+	Court        string    `json:"court"`
+	DocketNumber string    `json:"docketNumber"`
+	FromStage    string    `json:"fromStage"`
+	ToStage      string    `json:"toStage"`
+	FromStatus   string    `json:"fromStatus"`
+	ToStatus     string    `json:"toStatus"`
+	ChangedBy    uint      `json:"changedBy"`
+	ChangedAt    time.Time `json:"changedAt"`
+}
+
+// caseCreatedPayload is the data of a case.created event.
+type caseCreatedPayload struct {
+	CaseID         uint       `json:"caseId"`
+	CaseNumber     string     `json:"caseNumber"`
+	Title          string     `json:"title"`
+	Category       string     `json:"category"`
+	OfficeID       uint       `json:"officeId"`
+	ClientID       *uint      `json:"clientId"`
+	PrimaryStaffID *uint      `json:"primaryStaffId"`
+	Stage          string     `json:"stage"`
+	Status         string     `json:"status"`
+	DueDate        *time.Time `json:"dueDate"`
+	CreatedBy      uint       `json:"createdBy"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// appointmentWebhookPayload is the data of the appointment.created and appointment.cancelled events.
+type appointmentWebhookPayload struct {
+	AppointmentID uint      `json:"appointmentId"`
+	CaseID        uint      `json:"caseId"`
+	StaffID       uint      `json:"staffId"`
+	OfficeID      uint      `json:"officeId"`
+	Title         string    `json:"title"`
+	Category      string    `json:"category"`
+	Department    string    `json:"department"`
+	Status        string    `json:"status"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+	SeriesID      *string   `json:"seriesId,omitempty"`
+	ChangedBy     uint      `json:"changedBy"`
+	ChangedAt     time.Time `json:"changedAt"`
+}
+
+// signWebhook returns the X-CAF-Signature header for body: the hex HMAC-SHA256 of the raw body
+// keyed with the subscription's secret.
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookID returns a random identifier for an event or secret.
+func newWebhookID(prefix string) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + hex.EncodeToString(buf)
+}
+
+// dispatchWebhookEvent queues the event for every active subscription that wants it and wakes
+// the delivery worker. Pass the transaction making the change, if any, so the deliveries are
+// queued only if it commits; the worker sends them once they are visible. Failures are logged
+// and returned, so a caller passing its transaction can roll it back; deliveries queued before
+// the failure are still sent when db is not a transaction.
+func dispatchWebhookEvent(db *gorm.DB, event, stage string, data interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		log.Printf("WARNING: Failed to load webhook subscriptions for %s: %v", event, err)
+		return err
+	}
+
+	envelope := webhookEnvelope{ID: newWebhookID("evt_"), Event: event, CreatedAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("WARNING: Failed to encode webhook event %s: %v", event, err)
+		return err
+	}
+	queued := false
+	defer func() {
+		if queued {
+			wakeWebhookWorker()
+		}
+	}()
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(event, stage) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        envelope.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  &envelope.CreatedAt,
+		}
+		if err := db.Create(&delivery).Error; err != nil {
+			log.Printf("WARNING: Failed to queue webhook %d (%s): %v", subscription.ID, event, err)
+			return err
+		}
+		queued = true
+	}
+	return nil
+}
+
+// webhookWake wakes the delivery worker when new deliveries are queued.
+var webhookWake = make(chan struct{}, 1)
+
+// wakeWebhookWorker asks the delivery worker to look for due deliveries without waiting for
+// its next tick.
+func wakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// webhookBatchSize is how many due deliveries the worker sends per pass.
+const webhookBatchSize = 50
+
+// StartWebhookDeliveries sends due webhook deliveries every config.WebhookWorkerInterval, and
+// whenever an event is queued, until ctx is cancelled. The returned channel is closed once the
+// worker has stopped, after finishing the pass in progress; it is closed immediately when the
+// worker is disabled, and deliveries then stay queued.
+func StartWebhookDeliveries(ctx context.Context, db *gorm.DB) <-chan struct{} {
+	done := make(chan struct{})
+	interval := config.WebhookWorkerInterval()
+	if interval <= 0 {
+		close(done)
+		return done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			// A full batch may have left more due deliveries behind
+			for sendDueWebhookDeliveries(ctx, db, time.Now()) == webhookBatchSize && ctx.Err() == nil {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				log.Println("INFO: Webhook delivery worker stopped")
+				return
+			case <-ticker.C:
+			case <-webhookWake:
+			}
+		}
+	}()
+	return done
+}
+
+// sendDueWebhookDeliveries attempts every pending delivery due at now, up to webhookBatchSize,
+// and returns how many it attempted. Each delivery is claimed with a conditional update that
+// moves its next attempt past the request timeout, so another replica, or this one after a
+// crash, picks it up again only if the attempt was never recorded.
+func sendDueWebhookDeliveries(ctx context.Context, db *gorm.DB, now time.Time) int {
+	var due []models.WebhookDelivery
+	if err := db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at, id").Limit(webhookBatchSize).Find(&due).Error; err != nil {
+		log.Printf("WARNING: Failed to load due webhook deliveries: %v", err)
+		return 0
+	}
+
+	attempted := 0
+	lease := now.Add(config.WebhookTimeout() + time.Minute)
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claim := db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, models.WebhookDeliveryPending, now).
+			Update("next_attempt_at", lease)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		attempted++
+
+		var subscription models.WebhookSubscription
+		if err := db.First(&subscription, delivery.SubscriptionID).Error; err != nil {
+			delivery.Status, delivery.NextAttemptAt, delivery.LastError = models.WebhookDeliveryFailed, nil, "subscription not found"
+		} else if !subscription.IsActive {
+			delivery.Status, delivery.NextAttemptAt, delivery.LastError = models.WebhookDeliveryFailed, nil, "subscription is inactive"
+		} else {
+			attemptWebhookDelivery(subscription, &delivery, now)
+			recordWebhookOutcome(db, subscription.ID, delivery, now)
+		}
+
+		if err := db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error; err != nil {
+			log.Printf("WARNING: Failed to record webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+	return attempted
+}
+
+// attemptWebhookDelivery POSTs the delivery's payload once and updates it: delivered on a 2xx
+// answer, otherwise rescheduled after webhookRetryDelay, or failed once
+// config.WebhookMaxRetries retries have been made.
+func attemptWebhookDelivery(subscription models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	status, err := postWebhook(subscription, delivery.Event, []byte(delivery.Payload))
+	delivery.LastStatusCode = status
+	if err == nil {
+		delivery.Status, delivery.NextAttemptAt, delivery.LastError = models.WebhookDeliveryDelivered, nil, ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	log.Printf("WARNING: Webhook %d (%s) attempt %d failed: %v", subscription.ID, delivery.Event, delivery.Attempts, err)
+	if delivery.Attempts > config.WebhookMaxRetries() {
+		delivery.Status, delivery.NextAttemptAt = models.WebhookDeliveryFailed, nil
+		return
+	}
+	next := now.Add(webhookRetryDelay(delivery.Attempts))
+	delivery.Status, delivery.NextAttemptAt = models.WebhookDeliveryPending, &next
+}
+
+// webhookRetryDelay is the wait after the attempt-th failed attempt: the base delay, doubled
+// for every earlier failure, and at most a day.
+func webhookRetryDelay(attempt int) time.Duration {
+	delay := config.WebhookRetryBaseDelay()
+	for i := 1; i < attempt && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 24*time.Hour {
+		delay = 24 * time.Hour
+	}
+	return delay
+}
+
+// recordWebhookOutcome shows the latest attempt on the subscription as lastStatus/lastError.
+func recordWebhookOutcome(db *gorm.DB, subscriptionID uint, delivery models.WebhookDelivery, now time.Time) {
+	updates := map[string]interface{}{"last_status": "delivered", "last_error": "", "last_fired_at": now}
+	if delivery.Status != models.WebhookDeliveryDelivered {
+		updates["last_status"], updates["last_error"] = "failed", delivery.LastError
+	}
+	if err := db.Model(&models.WebhookSubscription{}).Where("id = ?", subscriptionID).Updates(updates).Error; err != nil {
+		log.Printf("WARNING: Failed to record webhook %d delivery: %v", subscriptionID, err)
+	}
+}
+
+// postWebhook makes a single delivery attempt and returns the response status, 0 when none
+// was received; any non-2xx response is an error.
+func postWebhook(subscription models.WebhookSubscription, event string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.WebhookTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CAF-Webhooks/1.0")
+	req.Header.Set("X-CAF-Event", event)
+	req.Header.Set("X-CAF-Signature", signWebhook(subscription.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// notifyCaseStageChanged fires case.stage_changed for a case that moved from fromStage.
+func notifyCaseStageChanged(db *gorm.DB, caseData *models.Case, fromStage, fromStatus string, changedBy uint) error {
+	if caseData.CurrentStage == fromStage {
+		return nil
+	}
+	return dispatchWebhookEvent(db, models.WebhookEventCaseStageChanged, caseData.CurrentStage, caseStageChangedPayload{
+		CaseID:       caseData.ID,
+		CaseNumber:   caseData.CaseNumber,
+		Title:        caseData.Title,
+		Category:     caseData.Category,
+		OfficeID:     caseData.OfficeID,
+		Court:        caseData.Court,
+		DocketNumber: caseData.DocketNumber,
+		FromStage:    fromStage,
+		ToStage:      caseData.CurrentStage,
+		FromStatus:   fromStatus,
+		ToStatus:     caseData.Status,
+		ChangedBy:    changedBy,
+		ChangedAt:    time.Now().UTC(),
+	})
+}
+
+// notifyCaseCreated fires case.created for a newly created case.
+func notifyCaseCreated(db *gorm.DB, caseData *models.Case, createdBy uint) error {
+	createdAt := caseData.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return dispatchWebhookEvent(db, models.WebhookEventCaseCreated, "", caseCreatedPayload{
+		CaseID:         caseData.ID,
+		CaseNumber:     caseData.CaseNumber,
+		Title:          caseData.Title,
+		Category:       caseData.Category,
+		OfficeID:       caseData.OfficeID,
+		ClientID:       caseData.ClientID,
+		PrimaryStaffID: caseData.PrimaryStaffID,
+		Stage:          caseData.CurrentStage,
+		Status:         caseData.Status,
+		DueDate:        caseData.DueDate,
+		CreatedBy:      createdBy,
+		CreatedAt:      createdAt.UTC(),
+	})
+}
+
+// notifyAppointmentEvent fires an appointment event (appointment.created or
+// appointment.cancelled) for each of the appointments, stopping at the first one that cannot
+// be queued.
+func notifyAppointmentEvent(db *gorm.DB, event string, changedBy uint, appointments ...models.Appointment) error {
+	now := time.Now().UTC()
+	for _, appointment := range appointments {
+		status := string(appointment.Status)
+		if event == models.WebhookEventAppointmentCancelled {
+			status = string(config.StatusCancelled)
+		}
+		if err := dispatchWebhookEvent(db, event, "", appointmentWebhookPayload{
+			AppointmentID: appointment.ID,
+			CaseID:        appointment.CaseID,
+			StaffID:       appointment.StaffID,
+			OfficeID:      appointment.OfficeID,
+			Title:         appointment.Title,
+			Category:      appointment.Category,
+			Department:    appointment.Department,
+			Status:        status,
+			StartTime:     appointment.StartTime.UTC(),
+			EndTime:       appointment.EndTime.UTC(),
+			SeriesID:      appointment.SeriesID,
+			ChangedBy:     changedBy,
+			ChangedAt:     now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookSubscriptionInput is the body of the create and update endpoints.
+type webhookSubscriptionInput struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	Stages   []string `json:"stages"`
+	IsActive *bool    `json:"isActive"`
+}
+
+// apply validates the input and copies it onto subscription. On update, omitted fields keep their values.
+func (input webhookSubscriptionInput) apply(subscription *models.WebhookSubscription) error {
+	if name := strings.TrimSpace(input.Name); name != "" {
+		subscription.Name = name
+	}
+	if input.URL != "" {
+		parsed, err := url.Parse(strings.TrimSpace(input.URL))
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("url debe ser una URL http(s) válida")
+		}
+		subscription.URL = parsed.String()
+	}
+	if input.Events != nil {
+		events := make([]string, 0, len(input.Events))
+		for _, event := range input.Events {
+			known := false
+			for _, allowed := range webhookEvents {
+				known = known || event == allowed
+			}
+			if !known {
+				return fmt.Errorf("evento desconocido %q; permitidos: %s", event, strings.Join(webhookEvents, ", "))
+			}
+			events = append(events, event)
+		}
+		subscription.Events = strings.Join(events, ",")
+	}
+	if input.Stages != nil {
+		stages := make([]string, 0, len(input.Stages))
+		for _, stage := range input.Stages {
+			if !config.IsValidStageLegacy(stage) {
+				return fmt.Errorf("etapa desconocida %q", stage)
+			}
+			stages = append(stages, stage)
+		}
+		subscription.Stages = strings.Join(stages, ",")
+	}
+	if input.IsActive != nil {
+		subscription.IsActive = *input.IsActive
+	}
+	if subscription.Name == "" || subscription.URL == "" || subscription.Events == "" {
+		return fmt.Errorf("name, url y events son obligatorios")
+	}
+	return nil
+}
+
+// GetWebhookSubscriptions lists the webhook subscriptions. Secrets are never returned.
+func GetWebhookSubscriptions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subscriptions := make([]models.WebhookSubscription, 0)
+		if err := db.Order("id").Find(&subscriptions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los webhooks", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": subscriptions, "events": webhookEvents})
+	}
+}
+
+// CreateWebhookSubscription adds a subscription and returns its signing secret, which is
+// shown only in this response.
+func CreateWebhookSubscription(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input webhookSubscriptionInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos", "details": err.Error()})
+			return
+		}
+		subscription := models.WebhookSubscription{IsActive: true, Secret: newWebhookID("whsec_"), CreatedBy: extractUserID(c)}
+		if err := input.apply(&subscription); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Create(&subscription).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el webhook", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "webhook_subscription", subscription.ID, "create", "", map[string]interface{}{
+			"url": subscription.URL, "events": subscription.Events, "stages": subscription.Stages,
+		})
+		c.JSON(http.StatusCreated, gin.H{"data": subscription, "secret": subscription.Secret})
+	}
+}
+
+// UpdateWebhookSubscription changes a subscription's name, url, events, stages or isActive.
+func UpdateWebhookSubscription(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var subscription models.WebhookSubscription
+		if err := db.First(&subscription, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return
+		}
+		var input webhookSubscriptionInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos", "details": err.Error()})
+			return
+		}
+		if err := input.apply(&subscription); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Save(&subscription).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el webhook", "message": err.Error()})
+			return
+		}
+		recordAuditLog(db, c, "webhook_subscription", subscription.ID, "update", "", map[string]interface{}{
+			"url": subscription.URL, "events": subscription.Events, "stages": subscription.Stages, "isActive": subscription.IsActive,
+		})
+		c.JSON(http.StatusOK, gin.H{"data": subscription})
+	}
+}
+
+// DeleteWebhookSubscription removes a subscription.
+func DeleteWebhookSubscription(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		result := db.Delete(&models.WebhookSubscription{}, id)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el webhook"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return
+		}
+		recordAuditLog(db, c, "webhook_subscription", id, "delete", "", nil)
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook eliminado exitosamente"})
+	}
+}
+
+// GetWebhookDeliveries lists a subscription's most recent deliveries, newest first, with their
+// status, attempts and latest response. ?status= filters by pending, delivered or failed.
+func GetWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseIDParam(c)
+		if err != nil {
+			return
+		}
+		var subscription models.WebhookSubscription
+		if err := db.Select("id").First(&subscription, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return
+		}
+		query := db.Where("subscription_id = ?", subscription.ID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		deliveries := make([]models.WebhookDelivery, 0)
+		if err := query.Order("created_at DESC, id DESC").Limit(100).Find(&deliveries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las entregas del webhook", "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": deliveries})
+	}
+}
@@ -0,0 +1,280 @@
+// api/handlers/webhooks_test.go
+// Unit tests for webhook deliveries: the signature receivers verify, retries with backoff on
+// 5xx answers, and queueing one delivery per interested subscription.
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"gorm.io/gorm"
+)
+
+// webhookReceiver answers each delivery with the next status of statuses (the last one once
+// they run out) and keeps the requests it received.
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	statuses []int
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mutex.Lock()
+		defer receiver.mutex.Unlock()
+		receiver.headers = append(receiver.headers, r.Header.Clone())
+		receiver.bodies = append(receiver.bodies, body)
+		status := receiver.statuses[len(receiver.statuses)-1]
+		if len(receiver.bodies) <= len(receiver.statuses) {
+			status = receiver.statuses[len(receiver.bodies)-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return receiver, server
+}
+
+// verifyWebhookSignature checks header the way a receiver would: the hex HMAC-SHA256 of the
+// raw body, keyed with the secret.
+func verifyWebhookSignature(secret, header string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(header), []byte(expected))
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	receiver, server := newWebhookReceiver(t, http.StatusOK)
+	subscription := models.WebhookSubscription{ID: 3, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	payload := `{"id":"evt_1","event":"case.created","data":{"caseId":7}}`
+	delivery := models.WebhookDelivery{ID: 1, Event: models.WebhookEventCaseCreated, Payload: payload, Status: models.WebhookDeliveryPending}
+
+	attemptWebhookDelivery(subscription, &delivery, time.Now())
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.LastStatusCode != http.StatusOK || delivery.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if len(receiver.bodies) != 1 || string(receiver.bodies[0]) != payload {
+		t.Fatalf("received %q", receiver.bodies)
+	}
+	header := receiver.headers[0]
+	if header.Get("X-CAF-Event") != models.WebhookEventCaseCreated || header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", header)
+	}
+	signature := header.Get("X-CAF-Signature")
+	if !verifyWebhookSignature("whsec_test", signature, receiver.bodies[0]) {
+		t.Errorf("signature %q does not verify", signature)
+	}
+	if verifyWebhookSignature("whsec_other", signature, receiver.bodies[0]) {
+		t.Error("signature verifies with another secret")
+	}
+	if verifyWebhookSignature("whsec_test", signature, []byte(strings.Replace(payload, "7", "8", 1))) {
+		t.Error("signature verifies a modified body")
+	}
+}
+
+func TestWebhookDeliveryRetriesServerErrors(t *testing.T) {
+	t.Setenv("WEBHOOK_RETRY_BASE_SECONDS", "30")
+	t.Setenv("WEBHOOK_MAX_RETRIES", "3")
+	receiver, server := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	subscription := models.WebhookSubscription{ID: 3, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	delivery := models.WebhookDelivery{ID: 1, Event: models.WebhookEventAppointmentCreated, Payload: `{}`, Status: models.WebhookDeliveryPending}
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	attemptWebhookDelivery(subscription, &delivery, now)
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusServiceUnavailable ||
+		delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(now.Add(30*time.Second)) || !strings.Contains(delivery.LastError, "503") {
+		t.Fatalf("after the first attempt: %+v", delivery)
+	}
+
+	now = *delivery.NextAttemptAt
+	attemptWebhookDelivery(subscription, &delivery, now)
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 2 || delivery.LastStatusCode != http.StatusBadGateway ||
+		!delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("after the second attempt: %+v", delivery)
+	}
+
+	now = *delivery.NextAttemptAt
+	attemptWebhookDelivery(subscription, &delivery, now)
+	if delivery.Status != models.WebhookDeliveryDelivered || delivery.Attempts != 3 || delivery.LastStatusCode != http.StatusOK ||
+		delivery.NextAttemptAt != nil || delivery.LastError != "" || delivery.DeliveredAt == nil || !delivery.DeliveredAt.Equal(now) {
+		t.Fatalf("after the third attempt: %+v", delivery)
+	}
+	if len(receiver.bodies) != 3 {
+		t.Errorf("received %d requests, want 3", len(receiver.bodies))
+	}
+}
+
+func TestWebhookDeliveryFailsOnceRetriesRunOut(t *testing.T) {
+	t.Setenv("WEBHOOK_RETRY_BASE_SECONDS", "10")
+	t.Setenv("WEBHOOK_MAX_RETRIES", "2")
+	_, server := newWebhookReceiver(t, http.StatusInternalServerError)
+	subscription := models.WebhookSubscription{ID: 3, URL: server.URL, Secret: "whsec_test", IsActive: true}
+	delivery := models.WebhookDelivery{ID: 1, Event: models.WebhookEventCaseStageChanged, Payload: `{}`, Status: models.WebhookDeliveryPending}
+
+	now := time.Now()
+	for attempt := 1; attempt <= 3; attempt++ {
+		attemptWebhookDelivery(subscription, &delivery, now)
+		if attempt < 3 && delivery.Status != models.WebhookDeliveryPending {
+			t.Fatalf("attempt %d: %+v", attempt, delivery)
+		}
+	}
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 || delivery.NextAttemptAt != nil ||
+		delivery.LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestWebhookRetryDelayBacksOff(t *testing.T) {
+	t.Setenv("WEBHOOK_RETRY_BASE_SECONDS", "30")
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{30, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSendDueWebhookDeliveriesRecordsAttempt(t *testing.T) {
+	t.Setenv("WEBHOOK_RETRY_BASE_SECONDS", "30")
+	_, server := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	var mutex sync.Mutex
+	var recorded, outcome map[string]driver.Value
+	script := &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			switch {
+			case strings.Contains(query, `FROM "webhook_deliveries"`):
+				return []string{"id", "subscription_id", "event_id", "event", "payload", "status", "attempts", "next_attempt_at"},
+					[][]driver.Value{{int64(9), int64(3), "evt_1", "case.created", `{"id":"evt_1"}`, "pending", int64(0), now}}
+			case strings.Contains(query, `FROM "webhook_subscriptions"`):
+				return []string{"id", "name", "url", "secret", "events", "is_active"},
+					[][]driver.Value{{int64(3), "CRM", server.URL, "whsec_test", "case.created", true}}
+			}
+			return nil, nil
+		},
+		affected: func(query string) int64 { return 1 },
+		observe: func(query string, args []driver.Value) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case strings.HasPrefix(query, `UPDATE "webhook_deliveries"`) && strings.Contains(query, `"attempts"`):
+				recorded = statementValues(query, args)
+			case strings.HasPrefix(query, `UPDATE "webhook_subscriptions"`):
+				outcome = statementValues(query, args)
+			}
+		},
+	}
+	db := scriptedDB(t, script)
+
+	if attempted := sendDueWebhookDeliveries(context.Background(), db, now); attempted != 1 {
+		t.Fatalf("attempted = %d, want 1", attempted)
+	}
+	if claims := script.ran(`UPDATE "webhook_deliveries" SET "next_attempt_at"`); len(claims) != 1 {
+		t.Errorf("claims = %v", claims)
+	}
+	if recorded["status"] != models.WebhookDeliveryPending || recorded["attempts"] != int64(1) || recorded["last_status_code"] != int64(503) {
+		t.Errorf("recorded delivery = %v", recorded)
+	}
+	if next, ok := recorded["next_attempt_at"].(time.Time); !ok || !next.Equal(now.Add(30*time.Second)) {
+		t.Errorf("next attempt = %v", recorded["next_attempt_at"])
+	}
+	if outcome["last_status"] != "failed" {
+		t.Errorf("subscription outcome = %v", outcome)
+	}
+}
+
+func TestDispatchWebhookEventQueuesInterestedSubscriptions(t *testing.T) {
+	var mutex sync.Mutex
+	var queued []map[string]driver.Value
+	script := &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "webhook_subscriptions"`) {
+				return []string{"id", "url", "secret", "events", "stages", "is_active"}, [][]driver.Value{
+					{int64(1), "https://a.example/hook", "s1", "case.stage_changed", "", true},
+					{int64(2), "https://b.example/hook", "s2", "case.stage_changed", "sentencia", true},
+					{int64(3), "https://c.example/hook", "s3", "appointment.created", "", true},
+				}
+			}
+			if strings.HasPrefix(query, `INSERT INTO "webhook_deliveries"`) {
+				return []string{"id"}, [][]driver.Value{{int64(1)}}
+			}
+			return nil, nil
+		},
+		observe: func(query string, args []driver.Value) {
+			if strings.HasPrefix(query, `INSERT INTO "webhook_deliveries"`) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				queued = append(queued, statementValues(query, args))
+			}
+		},
+	}
+	db := scriptedDB(t, script)
+
+	if err := dispatchWebhookEvent(db, models.WebhookEventCaseStageChanged, "audiencia", caseStageChangedPayload{CaseID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0]["subscription_id"] != int64(1) || queued[0]["status"] != models.WebhookDeliveryPending ||
+		queued[0]["event"] != models.WebhookEventCaseStageChanged || !strings.Contains(queued[0]["payload"].(string), `"caseId":7`) {
+		t.Errorf("queued = %v", queued)
+	}
+	select {
+	case <-webhookWake:
+	default:
+		t.Error("the delivery worker was not woken")
+	}
+}
+
+func TestDispatchWebhookEventReturnsQueueFailure(t *testing.T) {
+	script := &scriptedSQL{
+		rows: func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+			if strings.Contains(query, `FROM "webhook_subscriptions"`) {
+				return []string{"id", "url", "secret", "events", "stages", "is_active"}, [][]driver.Value{
+					{int64(1), "https://a.example/hook", "s1", "case.created", "", true},
+				}
+			}
+			return nil, nil
+		},
+		fail: func(query string) error {
+			if strings.HasPrefix(query, `INSERT INTO "webhook_deliveries"`) {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+	}
+	db := scriptedDB(t, script)
+
+	// Queued in the caller's transaction, which is rolled back on the error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return notifyCaseCreated(tx, &models.Case{ID: 7, Title: "Divorcio"}, 1)
+	})
+	if err == nil {
+		t.Fatal("queue failure was not returned")
+	}
+	if len(script.ran("ROLLBACK")) != 1 || len(script.ran("COMMIT")) != 0 {
+		t.Errorf("statements = %v", script.statements)
+	}
+}
@@ -0,0 +1,114 @@
+// Package ics builds minimal iCalendar (RFC 5545) feeds for appointment calendars.
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+const timestampLayout = "20060102T150405Z"
+
+// Event statuses
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event is a single VEVENT.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+	Updated     time.Time
+}
+
+// Calendar is a VCALENDAR made of events.
+type Calendar struct {
+	name   string
+	events []Event
+}
+
+// NewCalendar creates an empty calendar shown to subscribers under the given name.
+func NewCalendar(name string) *Calendar {
+	return &Calendar{name: name}
+}
+
+// AddEvent appends an event to the calendar.
+func (cal *Calendar) AddEvent(event Event) {
+	cal.events = append(cal.events, event)
+}
+
+// Bytes renders the calendar with CRLF line endings and folded long lines.
+func (cal *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	write := func(line string) {
+		buf.WriteString(fold(line))
+		buf.WriteString("\r\n")
+	}
+
+	now := time.Now().UTC().Format(timestampLayout)
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//CAF//Appointments//ES")
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	if cal.name != "" {
+		write("X-WR-CALNAME:" + escape(cal.name))
+	}
+	for _, event := range cal.events {
+		stamp := now
+		if !event.Updated.IsZero() {
+			stamp = event.Updated.UTC().Format(timestampLayout)
+		}
+		write("BEGIN:VEVENT")
+		write("UID:" + escape(event.UID))
+		write("DTSTAMP:" + stamp)
+		write("DTSTART:" + event.Start.UTC().Format(timestampLayout))
+		write("DTEND:" + event.End.UTC().Format(timestampLayout))
+		write("SUMMARY:" + escape(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION:" + escape(event.Description))
+		}
+		if event.Location != "" {
+			write("LOCATION:" + escape(event.Location))
+		}
+		if event.Status != "" {
+			write("STATUS:" + event.Status)
+		}
+		write("END:VEVENT")
+	}
+	write("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// escape escapes text values per RFC 5545 section 3.3.11.
+func escape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// fold splits lines longer than 75 octets, continuing them with a leading space.
+func fold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -49,6 +49,37 @@ type OfficeRepository interface {
 	GenerateUniqueCode(ctx context.Context, name string, excludeID uint) string
 	// GetDeleteBlockReason returns a non-empty message if the office cannot be deleted (e.g. has users, cases, appointments). Empty means delete is allowed.
 	GetDeleteBlockReason(ctx context.Context, officeID uint) (string, error)
+	// GetDependentCounts returns how many records reference the office.
+	GetDependentCounts(ctx context.Context, officeID uint) (*OfficeDependentCounts, error)
+	// ReassignAndDelete moves every record referencing the office to targetOfficeID and deletes the office, in one transaction
+	// with the audit entry that audit builds from the counts.
+	ReassignAndDelete(ctx context.Context, officeID, targetOfficeID uint, audit func(*OfficeDependentCounts) models.AuditLog) (*OfficeDependentCounts, error)
+	// TransferRecords moves everything that blocks deleting the office to targetOfficeID in one transaction, together
+	// with the audit entry that audit builds from the summary.
+	TransferRecords(ctx context.Context, officeID, targetOfficeID uint, audit func(*OfficeTransferSummary) models.AuditLog) (*OfficeTransferSummary, error)
+}
+
+// OfficeTransferSummary reports what an office transfer moved
+type OfficeTransferSummary struct {
+	Users        int64  `json:"users"`
+	Cases        int64  `json:"cases"`
+	Appointments int64  `json:"appointments"`
+	Capacities   int64  `json:"capacities"` // Therapist capacities dropped from the office
+	CaseIDs      []uint `json:"caseIds"`
+}
+
+// OfficeDependentCounts holds the number of records that reference an office
+type OfficeDependentCounts struct {
+	Users        int64  `json:"users"`
+	OpenCases    int64  `json:"openCases"`
+	Appointments int64  `json:"appointments"`
+	Capacities   int64  `json:"capacities"`
+	CaseIDs      []uint `json:"caseIds,omitempty"` // Set by ReassignAndDelete: every case moved, closed ones included
+}
+
+// Blocking reports whether any of the counted records prevent deleting the office
+func (d OfficeDependentCounts) Blocking() bool {
+	return d.Users > 0 || d.OpenCases > 0 || d.Appointments > 0 || d.Capacities > 0
 }
 
 // Filter structs for query parameters
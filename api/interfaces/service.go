@@ -3,6 +3,8 @@ package interfaces
 
 import (
 	"context"
+	"time"
+
 	"github.com/BryanPMX/CAF/api/models"
 )
 
@@ -45,6 +47,14 @@ type DashboardService interface {
 	GetOfficeManagerDashboard(ctx context.Context, officeID uint, filter DashboardFilter) (*DashboardSummary, error)
 }
 
+// SessionService defines the interface for tracking and revoking issued JWT sessions
+type SessionService interface {
+	CreateSession(ctx context.Context, userID uint, token string, ipAddress, userAgent string, expiresAt time.Time) (*models.Session, error)
+	ValidateSession(ctx context.Context, token string) (*models.Session, error)
+	ListUserSessions(ctx context.Context, userID uint) ([]models.Session, error)
+	RevokeUserSessions(ctx context.Context, userID uint) (int64, error)
+}
+
 // Request/Response structs for service operations
 type CreateCaseRequest struct {
 	Title       string `json:"title" binding:"required"`
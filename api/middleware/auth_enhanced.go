@@ -6,12 +6,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/interfaces"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// EnhancedJWTAuth is a stateless middleware that validates JWT tokens
-// This is a pure JWT-based authentication system with no database session tracking
+// sessionService, when set, is consulted on every request so revoked sessions are rejected immediately
+var sessionService interfaces.SessionService
+
+// SetSessionService enables session tracking for EnhancedJWTAuth
+func SetSessionService(service interfaces.SessionService) {
+	sessionService = service
+}
+
+// EnhancedJWTAuth is a middleware that validates JWT tokens.
+// When a session service is configured, the token must also belong to an active session.
 func EnhancedJWTAuth(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Step 1: Extract the token from the Authorization header
@@ -44,16 +53,32 @@ func EnhancedJWTAuth(jwtSecret string) gin.HandlerFunc {
 
 		// Step 3: Extract claims and set user context (stateless)
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			// Service tokens have no session; their service_tokens record is checked instead
+			if IsServiceToken(claims) {
+				authenticateServiceToken(c)
+				return
+			}
+
 			userID, ok := claims["sub"].(string)
 			if !ok {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 				return
 			}
 
-			// Step 4: Set user ID in context (no database session tracking)
+			// Step 4: Reject tokens whose session was revoked or has expired
+			if sessionService != nil {
+				session, err := sessionService.ValidateSession(c.Request.Context(), tokenString)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked or expired"})
+					return
+				}
+				c.Set("sessionID", session.ID)
+			}
+
+			// Step 5: Set user ID in context
 			c.Set("userID", userID)
 			
-			// Step 5: Set a temporary userRole that will be overwritten by DataAccessControl
+			// Step 6: Set a temporary userRole that will be overwritten by DataAccessControl
 			// This prevents issues where handlers try to access userRole before DataAccessControl runs
 			c.Set("userRole", "pending") // Temporary value
 
@@ -64,6 +89,18 @@ func EnhancedJWTAuth(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// QueryTokenAuth lets clients that cannot set headers, such as calendar subscriptions, send
+// their JWT as the `token` query parameter (like the /ws endpoint). It must run before
+// EnhancedJWTAuth; an Authorization header, when present, takes precedence.
+func QueryTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
 // SessionRateLimit middleware prevents rapid authentication attempts (anti-spam)
 // Note: This is now stateless and doesn't require session service
 func SessionRateLimit() gin.HandlerFunc {
@@ -0,0 +1,194 @@
+// api/middleware/compression.go
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Compression gzips responses whose content type matches config.CompressionContentTypes and
+// whose body reaches config.CompressionMinSizeBytes. Smaller bodies are sent as-is, so the
+// many small JSON responses skip the gzip cost. Returns a no-op when COMPRESSION_LEVEL is 0.
+func Compression() gin.HandlerFunc {
+	level := config.CompressionLevel()
+	if level == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	minSize := config.CompressionMinSizeBytes()
+	types := config.CompressionContentTypes()
+	pool := &sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(io.Discard, level)
+			return gz
+		},
+	}
+
+	return func(c *gin.Context) {
+		req := c.Request
+		if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") ||
+			strings.Contains(req.Header.Get("Connection"), "Upgrade") ||
+			strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		// Responses may differ by encoding even when this one ends up uncompressed
+		c.Header("Vary", "Accept-Encoding")
+		writer := &compressionWriter{ResponseWriter: c.Writer, pool: pool, minSize: minSize, types: types, status: http.StatusOK}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// compressionWriter buffers the start of the body until it knows whether the response is
+// large enough and of a compressible type, then either streams through gzip or writes raw.
+type compressionWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	minSize int
+	types   []string
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *compressionWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressionWriter) WriteHeaderNow() {
+	w.decide(false)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressionWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressionWriter) Written() bool {
+	return w.decided || w.buf.Len() > 0
+}
+
+func (w *compressionWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < w.minSize {
+			return len(data), nil
+		}
+		if err := w.commit(w.compressible()); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressionWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush commits the buffered body so streaming handlers are not held back.
+func (w *compressionWriter) Flush() {
+	w.decide(false)
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decide(false)
+	return w.ResponseWriter.Hijack()
+}
+
+// decide commits without compression if no decision was made yet.
+func (w *compressionWriter) decide(compress bool) {
+	if !w.decided {
+		_ = w.commit(compress && w.compressible())
+	}
+}
+
+// commit writes the status line and any buffered bytes, compressing them when requested.
+func (w *compressionWriter) commit(compress bool) error {
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// finish sends a body that never reached the threshold and closes the gzip stream.
+func (w *compressionWriter) finish() {
+	if !w.decided {
+		_ = w.commit(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.gz.Reset(io.Discard)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// compressible reports whether the response can be gzipped: an allowed content type,
+// no existing encoding, and a status that carries a full body.
+func (w *compressionWriter) compressible() bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent ||
+		w.status == http.StatusPartialContent || w.status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		return false
+	}
+	for _, t := range w.types {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+// api/middleware/cors.go
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers cross-origin requests from origins matching one of the patterns (see
+// matchOrigin). Matched origins are echoed back and may send credentials; other origins get a
+// 403 without CORS headers. A "*" pattern allows any origin, without credentials, since
+// browsers refuse credentials on a wildcard response.
+func CORS(patterns []string) gin.HandlerFunc {
+	config := cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "X-Requested-With", "Accept-Version"},
+		ExposeHeaders: []string{"Content-Length", "Authorization", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-API-Version", "X-API-Current-Version"},
+		MaxAge:        12 * time.Hour,
+	}
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "*" {
+			config.AllowAllOrigins = true
+			return cors.New(config)
+		}
+	}
+	config.AllowCredentials = true
+	config.AllowOriginFunc = func(origin string) bool {
+		for _, pattern := range patterns {
+			if matchOrigin(pattern, origin) {
+				return true
+			}
+		}
+		return false
+	}
+	return cors.New(config)
+}
+
+// matchOrigin reports whether origin matches pattern: an exact origin such as
+// "https://admin.caf-mexico.org", or one whose host starts with a "*." wildcard matching
+// exactly one subdomain label, so "https://*.caf-mexico.org" matches
+// "https://preview-12.caf-mexico.org" but neither "https://caf-mexico.org" nor
+// "https://a.b.caf-mexico.org". Scheme and port must match; case and a trailing slash in the
+// pattern are ignored.
+func matchOrigin(pattern, origin string) bool {
+	pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), "/")
+	origin = strings.ToLower(origin)
+	if pattern == "" || origin == "" {
+		return false
+	}
+	if pattern == origin {
+		return true
+	}
+
+	scheme, host, ok := strings.Cut(pattern, "://")
+	if !ok || !strings.HasPrefix(host, "*.") {
+		return false
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme != scheme || parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+		return false
+	}
+	label, found := strings.CutSuffix(parsed.Host, host[1:])
+	if !found || label == "" {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// api/middleware/cors_test.go
+// Unit tests for CORS origin matching: exact and single-label wildcard origins are echoed back
+// with credentials, anything else is refused without Access-Control-Allow-Origin.
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern, origin string
+		want            bool
+	}{
+		{"https://admin.caf-mexico.org", "https://admin.caf-mexico.org", true},
+		{"https://admin.caf-mexico.org/", "https://ADMIN.caf-mexico.org", true},
+		{"https://admin.caf-mexico.org", "https://admin.caf-mexico.org.evil.com", false},
+		{"https://*.caf-mexico.org", "https://preview-12.caf-mexico.org", true},
+		{"https://*.caf-mexico.org", "https://caf-mexico.org", false},
+		{"https://*.caf-mexico.org", "https://a.b.caf-mexico.org", false},
+		{"https://*.caf-mexico.org", "https://evilcaf-mexico.org", false},
+		{"https://*.caf-mexico.org", "http://preview.caf-mexico.org", false},
+		{"https://*.caf-mexico.org", "https://preview.caf-mexico.org:8443", false},
+		{"https://*.caf-mexico.org:8443", "https://preview.caf-mexico.org:8443", true},
+		{"https://*.caf-mexico.org", "https://pre_view.caf-mexico.org", false},
+		{"https://*.caf-mexico.org", "https://preview.caf-mexico.org/path", false},
+	}
+	for _, tt := range tests {
+		if got := matchOrigin(tt.pattern, tt.origin); got != tt.want {
+			t.Errorf("matchOrigin(%q, %q) = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
+
+// corsRequest sends a request from origin to a router behind CORS with the patterns.
+func corsRequest(patterns []string, method, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS(patterns))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(method, "/ping", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSAllowsWildcardSubdomain(t *testing.T) {
+	patterns := []string{"https://admin.caf-mexico.org", "https://*.caf-mexico.org"}
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := corsRequest(patterns, method, "https://preview-12.caf-mexico.org")
+		if w.Code >= 400 {
+			t.Fatalf("%s: status = %d", method, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://preview-12.caf-mexico.org" {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q", method, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q", method, got)
+		}
+	}
+}
+
+func TestCORSRejectsUnmatchedOrigin(t *testing.T) {
+	patterns := []string{"https://admin.caf-mexico.org", "https://*.caf-mexico.org"}
+	for _, origin := range []string{"https://evil.com", "https://a.b.caf-mexico.org"} {
+		w := corsRequest(patterns, http.MethodGet, origin)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q", origin, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q", origin, got)
+		}
+	}
+}
+
+func TestCORSAnyOriginWithoutCredentials(t *testing.T) {
+	w := corsRequest([]string{"*"}, http.MethodGet, "http://localhost:3000")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q", got)
+	}
+}
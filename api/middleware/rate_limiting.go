@@ -2,14 +2,117 @@
 package middleware
 
 import (
+	"container/list"
+	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+const (
+	// rateLimitRedisPrefix namespaces the shared request counters in Redis.
+	rateLimitRedisPrefix = "caf:ratelimit:"
+	// rateLimitRedisTimeout bounds a Redis round trip before falling back to the local count.
+	rateLimitRedisTimeout = 200 * time.Millisecond
+	// rateLimitRoleTTL is how long a user's role is reused for picking their limit.
+	rateLimitRoleTTL = time.Minute
+	// rateLimitRoleCacheSize caps the users whose role is kept; the least recently used go first.
+	rateLimitRoleCacheSize = 10000
+)
+
+var (
+	// rateLimitRedis shares request counts between replicas; nil keeps them in memory.
+	rateLimitRedis *redis.Client
+
+	// Identifying authenticated callers before EnhancedJWTAuth runs (see EnableRoleRateLimits)
+	rateLimitDB         *gorm.DB
+	rateLimitSecret     string
+	rateLimitRoleLimits map[string]int
+	rateLimitRoles      = newRateLimitRoleCache(rateLimitRoleCacheSize)
+)
+
+// rateLimitRoleCache keeps users' roles as last read for rate limiting. Entries expire after
+// rateLimitRoleTTL and are dropped when next looked up; past maxEntries users the least
+// recently used is evicted.
+type rateLimitRoleCache struct {
+	mutex      sync.Mutex
+	entries    map[string]*list.Element
+	recency    *list.List // *cachedRateLimitRole, most recently used first
+	maxEntries int
+}
+
+// cachedRateLimitRole is a user's role as last read for rate limiting.
+type cachedRateLimitRole struct {
+	userID  string
+	role    string
+	expires time.Time
+}
+
+func newRateLimitRoleCache(maxEntries int) *rateLimitRoleCache {
+	return &rateLimitRoleCache{entries: map[string]*list.Element{}, recency: list.New(), maxEntries: maxEntries}
+}
+
+// get returns the user's cached role unless it has expired by now.
+func (rc *rateLimitRoleCache) get(userID string, now time.Time) (string, bool) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	element, found := rc.entries[userID]
+	if !found {
+		return "", false
+	}
+	entry := element.Value.(*cachedRateLimitRole)
+	if !now.Before(entry.expires) {
+		rc.recency.Remove(element)
+		delete(rc.entries, userID)
+		return "", false
+	}
+	rc.recency.MoveToFront(element)
+	return entry.role, true
+}
+
+// set caches the user's role for rateLimitRoleTTL from now.
+func (rc *rateLimitRoleCache) set(userID, role string, now time.Time) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	if element, found := rc.entries[userID]; found {
+		entry := element.Value.(*cachedRateLimitRole)
+		entry.role, entry.expires = role, now.Add(rateLimitRoleTTL)
+		rc.recency.MoveToFront(element)
+		return
+	}
+	rc.entries[userID] = rc.recency.PushFront(&cachedRateLimitRole{userID: userID, role: role, expires: now.Add(rateLimitRoleTTL)})
+	for rc.maxEntries > 0 && len(rc.entries) > rc.maxEntries {
+		oldest := rc.recency.Back()
+		rc.recency.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedRateLimitRole).userID)
+	}
+}
+
+// UseRedisRateLimits keeps request counts in Redis so limits hold across replicas. A nil client
+// keeps them in memory, as does any Redis error for the request that hit it.
+func UseRedisRateLimits(client *redis.Client) {
+	rateLimitRedis = client
+}
+
+// EnableRoleRateLimits lets GeneralAPIRateLimit count requests carrying a valid user JWT per
+// user instead of per IP, with the limit of the user's role from RATE_LIMIT_ROLE_LIMITS.
+func EnableRoleRateLimits(db *gorm.DB, jwtSecret string) {
+	rateLimitDB = db
+	rateLimitSecret = jwtSecret
+	rateLimitRoleLimits = config.RoleRequestsPerMinute()
+}
+
 // RateLimiter represents a simple in-memory rate limiter
 type RateLimiter struct {
 	requests map[string][]time.Time
@@ -27,39 +130,87 @@ func NewRateLimiter(window time.Duration, limit int) *RateLimiter {
 	}
 }
 
+// rateLimitDecision is the outcome of counting one request against a limit.
+type rateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
 // Allow checks if a request is allowed for the given key
 func (rl *RateLimiter) Allow(key string) bool {
+	return rl.take(key, rl.limit).Allowed
+}
+
+// take counts a request for key against limit, in Redis when UseRedisRateLimits was given a
+// client so every replica shares the count, and in memory otherwise or when Redis fails.
+func (rl *RateLimiter) take(key string, limit int) rateLimitDecision {
+	if client := rateLimitRedis; client != nil {
+		if decision, err := rl.takeShared(client, key, limit); err == nil {
+			return decision
+		}
+	}
+	return rl.takeLocal(key, limit)
+}
+
+// takeLocal counts a request in an in-memory sliding window.
+func (rl *RateLimiter) takeLocal(key string, limit int) rateLimitDecision {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
 	// Use explicit UTC time for consistent rate limiting
-	now := time.Now().UTC().UTC()
+	now := time.Now().UTC()
 	cutoff := now.Add(-rl.window)
 
-	// Get existing requests for this key
-	requests, exists := rl.requests[key]
-	if !exists {
-		requests = []time.Time{}
-	}
-
 	// Remove old requests outside the window
 	var validRequests []time.Time
-	for _, reqTime := range requests {
+	for _, reqTime := range rl.requests[key] {
 		if reqTime.After(cutoff) {
 			validRequests = append(validRequests, reqTime)
 		}
 	}
 
-	// Check if we're under the limit
-	if len(validRequests) >= rl.limit {
-		return false
+	decision := rateLimitDecision{Limit: limit, Reset: now.Add(rl.window)}
+	if len(validRequests) > 0 {
+		decision.Reset = validRequests[0].Add(rl.window)
+	}
+	if len(validRequests) < limit {
+		validRequests = append(validRequests, now)
+		decision.Allowed = true
 	}
-
-	// Add current request
-	validRequests = append(validRequests, now)
 	rl.requests[key] = validRequests
+	if decision.Remaining = limit - len(validRequests); decision.Remaining < 0 {
+		decision.Remaining = 0
+	}
+	return decision
+}
+
+// takeShared counts a request in a fixed window stored in Redis. The first request of a window
+// creates its counter, which expires with the window.
+func (rl *RateLimiter) takeShared(client *redis.Client, key string, limit int) (rateLimitDecision, error) {
+	now := time.Now().UTC()
+	windowStart := now.Truncate(rl.window)
+	redisKey := fmt.Sprintf("%s%s:%d", rateLimitRedisPrefix, key, windowStart.Unix())
+
+	ctx, cancel := context.WithTimeout(context.Background(), rateLimitRedisTimeout)
+	defer cancel()
+	pipe := client.TxPipeline()
+	count := pipe.Incr(ctx, redisKey)
+	pipe.PExpire(ctx, redisKey, rl.window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return rateLimitDecision{}, err
+	}
 
-	return true
+	decision := rateLimitDecision{
+		Allowed: count.Val() <= int64(limit),
+		Limit:   limit,
+		Reset:   windowStart.Add(rl.window),
+	}
+	if remaining := int64(limit) - count.Val(); remaining > 0 {
+		decision.Remaining = int(remaining)
+	}
+	return decision, nil
 }
 
 // GetRemainingRequests returns the number of remaining requests for a key
@@ -153,6 +304,12 @@ var (
 	
 	// Admin operations rate limiter - configurable via environment
 	AdminRateLimiter *RateLimiter
+
+	// Analytics rate limiter for expensive dashboard/report aggregates - configurable via environment
+	AnalyticsRateLimiter *RateLimiter
+
+	// Service token rate limiter - one count per token, in place of the per-user limits
+	ServiceTokenRateLimiter *RateLimiter
 )
 
 // InitializeRateLimiters initializes rate limiters with configuration values
@@ -161,6 +318,8 @@ func InitializeRateLimiters(requestsPerMinute, authRequestsPerMinute, contactReq
 	AuthRateLimiter = NewRateLimiter(time.Minute, authRequestsPerMinute)
 	ContactRateLimiter = NewRateLimiter(time.Hour, contactRequestsPerHour)
 	AdminRateLimiter = NewRateLimiter(time.Minute, adminRequestsPerMinute)
+	AnalyticsRateLimiter = NewRateLimiter(time.Minute, config.AnalyticsRequestsPerMinute())
+	ServiceTokenRateLimiter = NewRateLimiter(time.Minute, config.ServiceTokenRequestsPerMinute())
 	
 	// Start cleanup routine
 	go func() {
@@ -180,40 +339,50 @@ func InitializeRateLimiters(requestsPerMinute, authRequestsPerMinute, contactReq
 			if AdminRateLimiter != nil {
 				AdminRateLimiter.Cleanup()
 			}
+			if AnalyticsRateLimiter != nil {
+				AnalyticsRateLimiter.Cleanup()
+			}
+			if ServiceTokenRateLimiter != nil {
+				ServiceTokenRateLimiter.Cleanup()
+			}
 		}
 	}()
 }
 
 // RateLimitMiddleware creates a rate limiting middleware
 func RateLimitMiddleware(limiter *RateLimiter, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	return limitedRateLimitMiddleware(limiter, func(c *gin.Context) (string, int) {
+		return keyFunc(c), limiter.limit
+	})
+}
+
+// limitedRateLimitMiddleware counts each request against the key and limit keyFunc picks for it.
+// Every response gets X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (Unix
+// seconds); a rejected one is a 429 with Retry-After (seconds).
+func limitedRateLimitMiddleware(limiter *RateLimiter, keyFunc func(*gin.Context) (string, int)) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := keyFunc(c)
-		
-		if !limiter.Allow(key) {
-			resetTime := limiter.GetResetTime(key)
-			remaining := limiter.GetRemainingRequests(key)
-			
-			c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit))
-			c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-			c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
-			
+		key, limit := keyFunc(c)
+		decision := limiter.take(key, limit)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(decision.Reset.Unix(), 10))
+
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(time.Until(decision.Reset).Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Rate limit exceeded",
-				"message": "Too many requests. Please try again later.",
-				"retry_after": int(time.Until(resetTime).Seconds()),
+				"error":       "Rate limit exceeded",
+				"message":     "Too many requests. Please try again later.",
+				"retry_after": retryAfter,
 			})
 			c.Abort()
 			return
 		}
 
-		// Add rate limit headers
-		remaining := limiter.GetRemainingRequests(key)
-		resetTime := limiter.GetResetTime(key)
-		
-		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit))
-		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		c.Header("X-RateLimit-Reset", fmt.Sprintf("%d", resetTime.Unix()))
-		
 		c.Next()
 	}
 }
@@ -251,17 +420,119 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// rateLimitCaller identifies the user making the request for rate limiting: the authenticated
+// user once EnhancedJWTAuth has run, or else the subject of a valid user JWT in the
+// Authorization header whose session is still active. Anything else is counted per IP, so a
+// revoked token cannot claim its user's limit. The role is empty when unknown or unused.
+func rateLimitCaller(c *gin.Context) (userID string, role string, ok bool) {
+	if id, exists := c.Get("userID"); exists {
+		role = strings.ToLower(c.GetString("userRole"))
+		if role == "pending" {
+			role = ""
+		}
+		return fmt.Sprintf("%v", id), role, true
+	}
+	if rateLimitSecret == "" {
+		return "", "", false
+	}
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+		return "", "", false
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(rateLimitSecret), nil
+	}, jwt.WithTimeFunc(func() time.Time {
+		return time.Now().UTC()
+	}))
+	if err != nil || !token.Valid || IsServiceToken(claims) {
+		return "", "", false
+	}
+	userID, _ = claims["sub"].(string)
+	if userID == "" {
+		return "", "", false
+	}
+	if sessionService != nil {
+		if _, err := sessionService.ValidateSession(c.Request.Context(), tokenString); err != nil {
+			return "", "", false
+		}
+	}
+	return userID, rateLimitRole(userID), true
+}
+
+// rateLimitRole returns the user's role, read from the users table at most once per
+// rateLimitRoleTTL, or "" when no per-role limits are configured.
+func rateLimitRole(userID string) string {
+	if len(rateLimitRoleLimits) == 0 {
+		return ""
+	}
+	now := time.Now()
+	if role, found := rateLimitRoles.get(userID, now); found {
+		return role
+	}
+	if rateLimitDB == nil {
+		return ""
+	}
+
+	var user models.User
+	if err := rateLimitDB.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return ""
+	}
+	role := strings.ToLower(user.Role)
+	rateLimitRoles.set(userID, role, now)
+	return role
+}
+
+// UserRateLimitMiddleware applies a per-user (or per-IP) limiter, except to requests made with
+// a service token: those are counted once per request against ServiceTokenRateLimiter, per
+// token.
+func UserRateLimitMiddleware(limiter *RateLimiter, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	return userRateLimitMiddleware(RateLimitMiddleware(limiter, keyFunc))
+}
+
+// userRateLimitMiddleware runs userLimit for requests not made with a service token.
+func userRateLimitMiddleware(userLimit gin.HandlerFunc) gin.HandlerFunc {
+	var serviceLimit gin.HandlerFunc
+	if ServiceTokenRateLimiter != nil {
+		serviceLimit = RateLimitMiddleware(ServiceTokenRateLimiter, func(c *gin.Context) string {
+			token, _ := requestServiceToken(c)
+			return fmt.Sprintf("service:%d", token.ID)
+		})
+	}
+	return func(c *gin.Context) {
+		if _, ok := requestServiceToken(c); !ok {
+			userLimit(c)
+			return
+		}
+		if serviceLimit == nil || c.GetBool("serviceTokenRateLimited") {
+			c.Next()
+			return
+		}
+		c.Set("serviceTokenRateLimited", true)
+		serviceLimit(c)
+	}
+}
+
 // Rate limiting middleware functions for different use cases
 
-// GeneralAPIRateLimit applies general rate limiting to API endpoints
+// GeneralAPIRateLimit applies general rate limiting to API endpoints: per user, with their role's
+// limit from RATE_LIMIT_ROLE_LIMITS when there is one, or per IP for anonymous requests
 func GeneralAPIRateLimit() gin.HandlerFunc {
-	return RateLimitMiddleware(GeneralRateLimiter, func(c *gin.Context) string {
-		// Use user ID if authenticated, otherwise use IP
-		if userID := GetUserID(c); userID != "" {
-			return userID
+	limiter := GeneralRateLimiter
+	return userRateLimitMiddleware(limitedRateLimitMiddleware(limiter, func(c *gin.Context) (string, int) {
+		userID, role, ok := rateLimitCaller(c)
+		if !ok {
+			return fmt.Sprintf("ip:%s", GetClientIP(c)), limiter.limit
 		}
-		return fmt.Sprintf("ip:%s", GetClientIP(c))
-	})
+		if limit, found := rateLimitRoleLimits[role]; found {
+			return fmt.Sprintf("user:%s", userID), limit
+		}
+		return fmt.Sprintf("user:%s", userID), limiter.limit
+	}))
 }
 
 // AuthRateLimit applies stricter rate limiting to authentication endpoints
@@ -282,7 +553,7 @@ func ContactFormRateLimit() gin.HandlerFunc {
 
 // AdminRateLimit applies rate limiting to admin operations
 func AdminRateLimit() gin.HandlerFunc {
-	return RateLimitMiddleware(AdminRateLimiter, func(c *gin.Context) string {
+	return UserRateLimitMiddleware(AdminRateLimiter, func(c *gin.Context) string {
 		// Use user ID for admin operations
 		if userID := GetUserID(c); userID != "" {
 			return fmt.Sprintf("admin:%s", userID)
@@ -291,3 +562,13 @@ func AdminRateLimit() gin.HandlerFunc {
 		return fmt.Sprintf("admin:ip:%s", GetClientIP(c))
 	})
 }
+
+// AnalyticsRateLimit applies a per-user limit to expensive analytics endpoints
+func AnalyticsRateLimit() gin.HandlerFunc {
+	return UserRateLimitMiddleware(AnalyticsRateLimiter, func(c *gin.Context) string {
+		if userID := GetUserID(c); userID != "" {
+			return fmt.Sprintf("analytics:%s", userID)
+		}
+		return fmt.Sprintf("analytics:ip:%s", GetClientIP(c))
+	})
+}
@@ -0,0 +1,246 @@
+// api/middleware/rate_limiting_test.go
+// Unit tests for the rate limiters: headers on every response, 429 with Retry-After past the
+// limit, per-role limits for signed-in users and counts shared through Redis.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testRateLimitSecret = "rate-limit-test-secret"
+
+// useGeneralRateLimiter installs a fresh general limiter and role limits for the test.
+func useGeneralRateLimiter(t *testing.T, limit int, roleLimits map[string]int) {
+	t.Helper()
+	previous, previousLimits, previousSecret := GeneralRateLimiter, rateLimitRoleLimits, rateLimitSecret
+	GeneralRateLimiter = NewRateLimiter(time.Minute, limit)
+	rateLimitRoleLimits = roleLimits
+	rateLimitSecret = testRateLimitSecret
+	t.Cleanup(func() {
+		GeneralRateLimiter, rateLimitRoleLimits, rateLimitSecret = previous, previousLimits, previousSecret
+		rateLimitRoles = newRateLimitRoleCache(rateLimitRoleCacheSize)
+	})
+}
+
+// rateLimitedRouter serves GET /ping behind GeneralAPIRateLimit.
+func rateLimitedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GeneralAPIRateLimit())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+// ping sends GET /ping from ip, with a bearer token when given.
+func ping(r *gin.Engine, ip, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Real-IP", ip)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// userToken signs a login token for userID.
+func userToken(t *testing.T, userID string) string {
+	t.Helper()
+	claims := jwt.RegisteredClaims{Subject: userID, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testRateLimitSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestRateLimitHeadersDecrementUntil429(t *testing.T) {
+	useGeneralRateLimiter(t, 3, nil)
+	r := rateLimitedRouter()
+
+	for want := 2; want >= 0; want-- {
+		w := ping(r, "10.0.0.1", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request allowed under the limit: status %d", w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(want) {
+			t.Errorf("X-RateLimit-Remaining = %q, want %d", got, want)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("X-RateLimit-Limit = %q", got)
+		}
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() || reset > time.Now().Add(time.Minute+time.Second).Unix() {
+			t.Errorf("X-RateLimit-Reset = %q", w.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+
+	w := ping(r, "10.0.0.1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q", w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	// Another IP has its own bucket
+	if w := ping(r, "10.0.0.2", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("other IP: status %d, remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitPerRoleForSignedInUsers(t *testing.T) {
+	useGeneralRateLimiter(t, 5, map[string]int{"client": 2, "admin": 10})
+	for userID, role := range map[string]string{"5": "client", "1": "admin"} {
+		rateLimitRoles.set(userID, role, time.Now())
+	}
+	r := rateLimitedRouter()
+
+	client, admin := userToken(t, "5"), userToken(t, "1")
+	tests := []struct {
+		name  string
+		token string
+		ip    string
+		limit string
+	}{
+		{"client", client, "10.0.0.1", "2"},
+		{"admin", admin, "10.0.0.1", "10"},
+		{"anonymous", "", "10.0.0.1", "5"},
+		{"forged token", "not-a-jwt", "10.0.0.3", "5"},
+	}
+	for _, tt := range tests {
+		if w := ping(r, tt.ip, tt.token); w.Header().Get("X-RateLimit-Limit") != tt.limit {
+			t.Errorf("%s: X-RateLimit-Limit = %q, want %s", tt.name, w.Header().Get("X-RateLimit-Limit"), tt.limit)
+		}
+	}
+
+	// The client is counted per user whatever IP they come from; the admin sharing their IP is not affected
+	if w := ping(r, "10.0.0.9", client); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("client second request: status %d, remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	if w := ping(r, "10.0.0.1", client); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("client past the limit: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := ping(r, "10.0.0.1", admin); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "8" {
+		t.Errorf("admin: status %d, remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitSharedThroughRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	UseRedisRateLimits(client)
+	defer UseRedisRateLimits(nil)
+
+	// Two replicas, each with its own in-memory limiter, share the count
+	useGeneralRateLimiter(t, 3, nil)
+	first := rateLimitedRouter()
+	GeneralRateLimiter = NewRateLimiter(time.Minute, 3)
+	second := rateLimitedRouter()
+
+	for i, r := range []*gin.Engine{first, second, first} {
+		if w := ping(r, "10.0.0.1", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(2-i) {
+			t.Errorf("request %d: status %d, remaining %q", i+1, w.Code, w.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+	w := ping(second, "10.0.0.1", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("past the shared limit: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Without Redis the count falls back to memory
+	server.Close()
+	if w := ping(first, "10.0.0.1", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("Redis down: status %d, remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+// revokedSessions is a session service under which only the listed tokens have a session.
+type revokedSessions struct {
+	active map[string]bool
+}
+
+func (s revokedSessions) CreateSession(ctx context.Context, userID uint, token string, ipAddress, userAgent string, expiresAt time.Time) (*models.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s revokedSessions) ValidateSession(ctx context.Context, token string) (*models.Session, error) {
+	if !s.active[token] {
+		return nil, errors.New("session revoked")
+	}
+	return &models.Session{ID: 1}, nil
+}
+
+func (s revokedSessions) ListUserSessions(ctx context.Context, userID uint) ([]models.Session, error) {
+	return nil, nil
+}
+
+func (s revokedSessions) RevokeUserSessions(ctx context.Context, userID uint) (int64, error) {
+	return 0, nil
+}
+
+func TestRateLimitRevokedSessionCountedPerIP(t *testing.T) {
+	useGeneralRateLimiter(t, 5, map[string]int{"admin": 10})
+	rateLimitRoles.set("1", "admin", time.Now())
+	active := userToken(t, "1")
+	// An earlier login of the same user, signed correctly but signed out since
+	revoked, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "1",
+		ID:        "earlier-login",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(testRateLimitSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := sessionService
+	SetSessionService(revokedSessions{active: map[string]bool{active: true}})
+	t.Cleanup(func() { SetSessionService(previous) })
+	r := rateLimitedRouter()
+
+	if w := ping(r, "10.0.0.1", active); w.Header().Get("X-RateLimit-Limit") != "10" {
+		t.Errorf("active session: X-RateLimit-Limit = %q, want the admin limit", w.Header().Get("X-RateLimit-Limit"))
+	}
+	w := ping(r, "10.0.0.1", revoked)
+	if w.Header().Get("X-RateLimit-Limit") != "5" || w.Header().Get("X-RateLimit-Remaining") != "4" {
+		t.Errorf("revoked session: limit %q, remaining %q; want the IP's", w.Header().Get("X-RateLimit-Limit"), w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitRoleCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newRateLimitRoleCache(2)
+	now := time.Now()
+	cache.set("1", "admin", now)
+	cache.set("2", "client", now)
+	cache.get("1", now)
+	cache.set("3", "staff", now)
+	if _, found := cache.get("2", now); found {
+		t.Error("least recently used user kept past the cap")
+	}
+	if role, found := cache.get("1", now); !found || role != "admin" {
+		t.Errorf("recently used user = %q, %v", role, found)
+	}
+
+	if _, found := cache.get("3", now.Add(rateLimitRoleTTL)); found {
+		t.Error("expired role returned")
+	}
+	if len(cache.entries) != 1 || cache.recency.Len() != 1 {
+		t.Errorf("expired entry not dropped: %d entries", len(cache.entries))
+	}
+}
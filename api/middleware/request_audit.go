@@ -0,0 +1,285 @@
+// api/middleware/request_audit.go
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/config"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestAuditTag marks the baseline entries written by RequestAudit, so reports can tell them
+// apart from the detailed entries handlers record themselves.
+const requestAuditTag = "request"
+
+// requestAuditGroups are the route group segments that come before the resource name.
+var requestAuditGroups = map[string]bool{"admin": true, "staff": true, "manager": true, "client": true, "metrics": true}
+
+// requestAuditOperations are route segments naming an operation on the resource before them
+// rather than a resource: "/cases/:id/complete" changes a case.
+var requestAuditOperations = map[string]bool{
+	"archive": true, "assign": true, "batch": true, "cleanup": true, "complete": true, "dismiss": true,
+	"execute": true, "fix-categories": true, "import": true, "mark-read": true, "merge": true,
+	"permanent": true, "reassign-cases": true, "rerun": true, "reschedule": true, "restore": true,
+	"run": true, "stage": true, "transfer": true, "upload": true, "validate": true,
+}
+
+// requestAuditQueueSize bounds the entries waiting to be written. Once the database falls that
+// far behind, further entries are dropped with a warning rather than holding requests up.
+const requestAuditQueueSize = 1024
+
+// requestAuditWriteTimeout bounds the insert of each entry.
+const requestAuditWriteTimeout = 5 * time.Second
+
+// requestAuditWriter writes queued entries one at a time, off the request goroutines.
+type requestAuditWriter struct {
+	db      *gorm.DB
+	mutex   sync.RWMutex
+	closed  bool
+	entries chan models.AuditLog
+	done    chan struct{}
+}
+
+// requestAuditWriters are the writers started by RequestAudit, for StopRequestAudit.
+var requestAuditWriters = struct {
+	mutex   sync.Mutex
+	writers []*requestAuditWriter
+}{}
+
+func startRequestAuditWriter(db *gorm.DB) *requestAuditWriter {
+	w := &requestAuditWriter{db: db, entries: make(chan models.AuditLog, requestAuditQueueSize), done: make(chan struct{})}
+	requestAuditWriters.mutex.Lock()
+	requestAuditWriters.writers = append(requestAuditWriters.writers, w)
+	requestAuditWriters.mutex.Unlock()
+
+	go func() {
+		defer close(w.done)
+		for entry := range w.entries {
+			w.write(entry)
+		}
+	}()
+	return w
+}
+
+// enqueue hands the entry to the writer, or writes it right away once the writer is stopped.
+func (w *requestAuditWriter) enqueue(entry models.AuditLog) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		w.write(entry)
+		return
+	}
+	select {
+	case w.entries <- entry:
+	default:
+		log.Printf("WARNING: Request audit queue full, dropping %s entry for %s", entry.Action, entry.EntityType)
+	}
+}
+
+func (w *requestAuditWriter) write(entry models.AuditLog) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestAuditWriteTimeout)
+	defer cancel()
+	if err := w.db.WithContext(ctx).Create(&entry).Error; err != nil {
+		log.Printf("WARNING: Failed to record request audit log (%s %s): %v", entry.Action, entry.EntityType, err)
+	}
+}
+
+// StopRequestAudit writes the entries still queued by RequestAudit and stops its writers. Call it
+// once the server has stopped taking requests; it returns ctx's error if they do not finish in
+// time.
+func StopRequestAudit(ctx context.Context) error {
+	requestAuditWriters.mutex.Lock()
+	writers := requestAuditWriters.writers
+	requestAuditWriters.writers = nil
+	requestAuditWriters.mutex.Unlock()
+
+	for _, w := range writers {
+		w.mutex.Lock()
+		if !w.closed {
+			w.closed = true
+			close(w.entries)
+		}
+		w.mutex.Unlock()
+	}
+	for _, w := range writers {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// RequestAudit records a baseline AuditLog entry for every authenticated POST, PUT, PATCH and
+// DELETE request once its handler has run: the user, method, path, resource type and id, IP,
+// user agent and response status. Handlers can still record detailed entries of their own.
+// Unauthenticated requests, unmatched routes and config.AuditRequestSkipRoutes are left out.
+// Entries are queued for a background writer, drained by StopRequestAudit on shutdown.
+// Returns a no-op when AUDIT_REQUESTS_ENABLED is false.
+func RequestAudit(db *gorm.DB) gin.HandlerFunc {
+	if !config.AuditRequestsEnabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	skipRoutes := config.AuditRequestSkipRoutes()
+	writer := startRequestAuditWriter(db)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" || skippedAuditRoute(route, skipRoutes) {
+			return
+		}
+		entityType, param := requestAuditResource(route)
+		action := requestAuditAction(c.Request.Method, param != "")
+		if action == "" {
+			return
+		}
+		entry, ok := newRequestAuditLog(c, action, route, entityType, param)
+		if !ok {
+			return
+		}
+
+		// Written after the response so audit storage never slows the request down
+		writer.enqueue(entry)
+	}
+}
+
+// requestAuditAction maps a mutating method to an audit action, or "" for reads. A POST to a
+// specific resource (e.g. /cases/:id/complete) changes it rather than creating one.
+func requestAuditAction(method string, identified bool) string {
+	switch method {
+	case http.MethodPost:
+		if identified {
+			return "update"
+		}
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return ""
+	}
+}
+
+// skippedAuditRoute reports whether route starts with one of the skipped prefixes.
+func skippedAuditRoute(route string, skipRoutes []string) bool {
+	for _, prefix := range skipRoutes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRequestAuditLog builds the baseline entry for the finished request. It returns false when
+// no user is attached to the request.
+func newRequestAuditLog(c *gin.Context, action, route, entityType, param string) (models.AuditLog, bool) {
+	entry := models.AuditLog{
+		Action:    action,
+		Reason:    "api_request",
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Tags:      []string{requestAuditTag},
+	}
+	if currentUser, exists := c.Get("currentUser"); exists {
+		if user, ok := currentUser.(models.User); ok {
+			entry.UserID = user.ID
+			entry.UserRole = user.Role
+			entry.UserOfficeID = user.OfficeID
+			entry.UserDepartment = user.Department
+		}
+	}
+	if entry.UserID == 0 {
+		return entry, false
+	}
+	if sessionID, exists := c.Get("sessionID"); exists {
+		entry.SessionID = fmt.Sprint(sessionID)
+	}
+	if _, exists := c.Get("serviceTokenID"); exists {
+		entry.Tags = append(entry.Tags, "service_token")
+	}
+
+	status := c.Writer.Status()
+	if status >= http.StatusInternalServerError {
+		entry.Severity = "error"
+	}
+
+	entry.EntityType = entityType
+	values := map[string]interface{}{
+		"method": c.Request.Method,
+		"path":   c.Request.URL.Path,
+		"route":  route,
+		"status": status,
+	}
+	if param != "" {
+		value := c.Param(param)
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			entry.EntityID = uint(id)
+		} else {
+			values["entityKey"] = value
+		}
+	}
+	if encoded, err := json.Marshal(values); err == nil {
+		newValues := string(encoded)
+		entry.NewValues = &newValues
+	}
+	return entry, true
+}
+
+// requestAuditResource derives the entity type, and the route parameter holding its id, from a
+// route template: the last static segment that is not an operation, identified by the parameter
+// right after it. "/api/v1/admin/cases/:id/complete" is a "case" identified by "id",
+// "/api/v1/admin/cases/:caseId/appointments" a new "appointment", and
+// "/api/v1/admin/appointments/import" an "appointment" too.
+func requestAuditResource(route string) (entityType, param string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route, "/api/v1"), "/"), "/")
+	if len(segments) > 0 && requestAuditGroups[segments[0]] {
+		segments = segments[1:]
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		if segment == "" || isRouteParam(segment) || requestAuditOperations[segment] {
+			continue
+		}
+		if i+1 < len(segments) && isRouteParam(segments[i+1]) {
+			param = segments[i+1][1:]
+		}
+		return singularResource(segment), param
+	}
+	return "api", ""
+}
+
+// isRouteParam reports whether a route template segment is a parameter or wildcard.
+func isRouteParam(segment string) bool {
+	return strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+}
+
+// singularResource turns a route segment such as "calendar-colors" into an entity type such as
+// "calendar_color".
+func singularResource(segment string) string {
+	name := strings.ReplaceAll(strings.ToLower(segment), "-", "_")
+	switch {
+	case name == "series" || strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	default:
+		return name
+	}
+}
@@ -0,0 +1,112 @@
+// api/middleware/request_audit_test.go
+// Unit tests for request auditing: the resource named by each route, and that queued entries
+// are written before shutdown completes.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestRequestAuditResource(t *testing.T) {
+	tests := []struct {
+		route, entityType, param string
+	}{
+		{"/api/v1/admin/cases/:id", "case", "id"},
+		{"/api/v1/admin/cases/:id/complete", "case", "id"},
+		{"/api/v1/admin/cases/:caseId/appointments", "appointment", ""},
+		{"/api/v1/admin/cases/:id/documents/batch", "document", ""},
+		{"/api/v1/admin/tasks/:id/dependencies/:dependsOnId", "dependency", "dependsOnId"},
+		{"/api/v1/admin/offices/:id/holidays/:holidayId", "holiday", "holidayId"},
+		{"/api/v1/admin/records/appointments/:id/restore", "appointment", "id"},
+		{"/api/v1/admin/clients/:clientId/merge", "client", "clientId"},
+		{"/api/v1/admin/appointments/import", "appointment", ""},
+		{"/api/v1/admin/appointments/series/:seriesId", "series", "seriesId"},
+		{"/api/v1/admin/calendar-colors/:id", "calendar_color", "id"},
+	}
+	for _, test := range tests {
+		entityType, param := requestAuditResource(test.route)
+		if entityType != test.entityType || param != test.param {
+			t.Errorf("requestAuditResource(%q) = %q, %q; want %q, %q", test.route, entityType, param, test.entityType, test.param)
+		}
+	}
+
+	if action := requestAuditAction(http.MethodPost, false); action != "create" {
+		t.Errorf("POST to a collection = %q, want create", action)
+	}
+	if action := requestAuditAction(http.MethodPost, true); action != "update" {
+		t.Errorf("POST to a resource = %q, want update", action)
+	}
+}
+
+// auditCaptureDB is a dry-run database recording the audit entries created through it.
+func auditCaptureDB(t *testing.T) (*gorm.DB, func() []models.AuditLog) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=caf_test"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mutex sync.Mutex
+	var created []models.AuditLog
+	if err := db.Callback().Create().Before("gorm:create").Register("test:capture", func(tx *gorm.DB) {
+		if entry, ok := tx.Statement.Dest.(*models.AuditLog); ok {
+			mutex.Lock()
+			created = append(created, *entry)
+			mutex.Unlock()
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return db, func() []models.AuditLog {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]models.AuditLog(nil), created...)
+	}
+}
+
+func TestRequestAuditWritesQueuedEntriesOnStop(t *testing.T) {
+	db, created := auditCaptureDB(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("currentUser", models.User{ID: 4, Role: "admin"})
+		c.Next()
+	})
+	r.Use(RequestAudit(db))
+	r.POST("/api/v1/admin/cases/:caseId/appointments", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for i := 0; i < 20; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/admin/cases/7/appointments", nil))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := StopRequestAudit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	entries := created()
+	if len(entries) != 20 {
+		t.Fatalf("%d entries written before stop returned, want 20", len(entries))
+	}
+	if entry := entries[0]; entry.EntityType != "appointment" || entry.Action != "create" || entry.UserID != 4 {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	// Requests still finishing after the stop are written right away
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/admin/cases/7/appointments", nil))
+	if len(created()) != 21 {
+		t.Errorf("late entry not written: %d entries", len(created()))
+	}
+}
@@ -4,6 +4,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -60,3 +61,29 @@ func RoleAuth(db *gorm.DB, requiredRole string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireCapability allows the request only when the authenticated user's role has been
+// granted the capability (see config.CapabilityRoles). It should be used AFTER the JWTAuth middleware.
+func RequireCapability(db *gorm.DB, capability string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !config.HasCapability(user.Role, capability) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied: insufficient permissions", "capability": capability})
+			return
+		}
+
+		c.Set("userRole", user.Role)
+		c.Next()
+	}
+}
@@ -0,0 +1,244 @@
+// api/middleware/service_tokens.go
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// Service tokens are JWTs carrying {"token_type": "service"}; they are checked against the
+// service_tokens table instead of sessions.
+const (
+	ServiceTokenClaim = "token_type"
+	ServiceTokenType  = "service"
+)
+
+// serviceTokenContextKey caches the request's service token once it has been looked up.
+const serviceTokenContextKey = "serviceToken"
+
+// serviceTokenTouchInterval limits how often a token's last_used_at is written.
+const serviceTokenTouchInterval = time.Minute
+
+// serviceTokenRouteScopes maps route templates, without "/api/v1" and the route group (admin,
+// staff, manager, ...) and with every parameter written ":id", to the resource their scope
+// names. A route takes the scope of its longest listed prefix, so "/cases/:id/tasks" needs a
+// task scope while "/cases/:id/complete" needs a case scope. Routes without a listed prefix
+// cannot be called with a service token.
+var serviceTokenRouteScopes = map[string]string{
+	"/announcements":                     "announcement",
+	"/appointment-heatmap":               "appointment",
+	"/appointments":                      "appointment",
+	"/bulk-operations":                   "bulk_operation",
+	"/calendar-colors":                   "calendar_color",
+	"/case-funnel":                       "case_funnel",
+	"/cases":                             "case",
+	"/cases/:id/audit-trail":             "audit",
+	"/cases/:id/document-checklist":      "document_checklist",
+	"/cases/:id/documents":               "document",
+	"/cases/:id/invoice.pdf":             "payment",
+	"/cases/:id/tasks":                   "task",
+	"/cases/courts":                      "court",
+	"/cases/documents":                   "document",
+	"/clients":                           "client",
+	"/clients/:id/cases":                 "case",
+	"/clients/:id/cases-for-appointment": "case",
+	"/clients/:id/communications":        "communication",
+	"/contact-submissions":               "contact_submission",
+	"/courts":                            "court",
+	"/dashboard":                         "dashboard",
+	"/dashboard-summary":                 "dashboard",
+	"/dashboard/announcements":           "announcement",
+	"/document-checklists":               "document_checklist",
+	"/documents":                         "document",
+	"/expenses":                          "expense",
+	"/export":                            "export",
+	"/financial":                         "financial",
+	"/integrity":                         "integrity",
+	"/maintenance":                       "maintenance",
+	"/monthly-report":                    "report",
+	"/my-day":                            "dashboard",
+	"/notes":                             "note",
+	"/notifications":                     "notification",
+	"/offices":                           "office",
+	"/optimized/appointments":            "appointment",
+	"/optimized/cases":                   "case",
+	"/optimized/users":                   "user",
+	"/performance":                       "performance",
+	"/ratings":                           "rating",
+	"/recent-activity":                   "audit",
+	"/records/appointments":              "appointment",
+	"/records/cases":                     "case",
+	"/records/stats":                     "record",
+	"/reports":                           "report",
+	"/reports/audit":                     "audit",
+	"/reports/export":                    "export",
+	"/schedule":                          "appointment",
+	"/schedule.ics":                      "appointment",
+	"/settings":                          "setting",
+	"/site-content":                      "site_content",
+	"/site-events":                       "site_event",
+	"/site-images":                       "site_image",
+	"/site-services":                     "site_service",
+	"/staff/:id/reassign-cases":          "case",
+	"/staff/:id/utilization":             "report",
+	"/system":                            "system",
+	"/tasks":                             "task",
+	"/trends":                            "report",
+	"/users":                             "user",
+	"/users/:id/activity":                "audit",
+	"/users/:id/rating":                  "rating",
+	"/users/:id/sessions":                "session",
+	"/webhooks":                          "webhook",
+	"/workload":                          "report",
+}
+
+// serviceTokenScope returns the resource whose scope a route requires, or "" when service
+// tokens cannot call it.
+func serviceTokenScope(route string) string {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route, "/api/v1"), "/"), "/")
+	if len(segments) > 0 && requestAuditGroups[segments[0]] {
+		segments = segments[1:]
+	}
+	for i, segment := range segments {
+		if isRouteParam(segment) {
+			segments[i] = ":id"
+		}
+	}
+	for n := len(segments); n > 0; n-- {
+		if resource, found := serviceTokenRouteScopes["/"+strings.Join(segments[:n], "/")]; found {
+			return resource
+		}
+	}
+	return ""
+}
+
+var (
+	serviceTokenDB     *gorm.DB
+	serviceTokenSecret string
+)
+
+// EnableServiceTokens lets requests authenticate with service tokens issued by the admin
+// service token endpoints. Without it, service tokens are rejected like any sessionless token.
+func EnableServiceTokens(db *gorm.DB, jwtSecret string) {
+	serviceTokenDB = db
+	serviceTokenSecret = jwtSecret
+}
+
+// IsServiceToken reports whether parsed JWT claims belong to a service token.
+func IsServiceToken(claims jwt.MapClaims) bool {
+	tokenType, _ := claims[ServiceTokenClaim].(string)
+	return tokenType == ServiceTokenType
+}
+
+// requestServiceToken returns the active service token the request is authenticated with, if
+// any. Found tokens are cached on the context so the rate limiters and EnhancedJWTAuth share
+// one lookup.
+func requestServiceToken(c *gin.Context) (models.ServiceToken, bool) {
+	if cached, exists := c.Get(serviceTokenContextKey); exists {
+		if token, ok := cached.(models.ServiceToken); ok {
+			return token, true
+		}
+	}
+	token, ok := lookupServiceToken(c.GetHeader("Authorization"))
+	if ok {
+		c.Set(serviceTokenContextKey, token)
+	}
+	return token, ok
+}
+
+// lookupServiceToken verifies a bearer service token and loads its record. The token must be
+// signed with the JWT secret, not revoked or expired, and act as the user stored on the record.
+func lookupServiceToken(authHeader string) (models.ServiceToken, bool) {
+	var record models.ServiceToken
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if serviceTokenDB == nil || tokenString == "" || tokenString == authHeader {
+		return record, false
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(serviceTokenSecret), nil
+	}, jwt.WithTimeFunc(func() time.Time {
+		return time.Now().UTC()
+	}))
+	if err != nil || !token.Valid || !IsServiceToken(claims) {
+		return record, false
+	}
+	tokenID, _ := claims["jti"].(string)
+	subject, _ := claims["sub"].(string)
+	if tokenID == "" {
+		return record, false
+	}
+
+	if err := serviceTokenDB.Where("token_id = ?", tokenID).First(&record).Error; err != nil {
+		return record, false
+	}
+	if !record.Active(time.Now().UTC()) || subject != strconv.FormatUint(uint64(record.UserID), 10) {
+		return record, false
+	}
+	return record, true
+}
+
+// authenticateServiceToken completes EnhancedJWTAuth for a service token: it checks the token
+// is still active and its scopes cover the route, then sets the same context as a user token.
+// sessionID is "service_token:<id>" so audit entries identify the token.
+func authenticateServiceToken(c *gin.Context) {
+	token, ok := requestServiceToken(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Service token has been revoked or expired"})
+		return
+	}
+
+	route := c.FullPath()
+	if strings.Contains(route, "/service-tokens") {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Service tokens cannot manage service tokens"})
+		return
+	}
+	resource := serviceTokenScope(route)
+	if resource == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Service tokens cannot call this route"})
+		return
+	}
+	write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && c.Request.Method != http.MethodOptions
+	if !token.Allows(resource, write) {
+		scope := resource + ":" + models.ServiceTokenScopeRead
+		if write {
+			scope = resource + ":" + models.ServiceTokenScopeWrite
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Service token scope does not allow this request", "requiredScope": scope})
+		return
+	}
+
+	c.Set("userID", strconv.FormatUint(uint64(token.UserID), 10))
+	c.Set("userRole", "pending") // Overwritten by DataAccessControl
+	c.Set("serviceTokenID", token.ID)
+	c.Set("sessionID", fmt.Sprintf("service_token:%d", token.ID))
+	touchServiceToken(token)
+	c.Next()
+}
+
+// touchServiceToken records when the token was last used, at most once per interval and
+// without delaying the request.
+func touchServiceToken(token models.ServiceToken) {
+	now := time.Now().UTC()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < serviceTokenTouchInterval {
+		return
+	}
+	go func() {
+		if err := serviceTokenDB.Model(&models.ServiceToken{}).Where("id = ?", token.ID).Update("last_used_at", now).Error; err != nil {
+			log.Printf("WARNING: Failed to record service token %d use: %v", token.ID, err)
+		}
+	}()
+}
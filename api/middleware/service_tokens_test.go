@@ -0,0 +1,83 @@
+// api/middleware/service_tokens_test.go
+// Unit tests for service tokens: the scope each route requires, and the per-token rate limit
+// that replaces the per-user limits.
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestServiceTokenScope(t *testing.T) {
+	tests := []struct {
+		route, resource string
+	}{
+		{"/api/v1/admin/cases", "case"},
+		{"/api/v1/admin/cases/:id/complete", "case"},
+		{"/api/v1/admin/cases/:id/tasks", "task"},
+		{"/api/v1/staff/cases/:id/tasks", "task"},
+		{"/api/v1/cases/:id/documents/batch", "document"},
+		{"/api/v1/admin/cases/:id/audit-trail", "audit"},
+		{"/api/v1/admin/clients/:clientId/cases", "case"},
+		{"/api/v1/admin/clients/:clientId/merge", "client"},
+		{"/api/v1/admin/optimized/users", "user"},
+		{"/api/v1/admin/users/:id/sessions", "session"},
+		{"/api/v1/admin/reports/audit", "audit"},
+		{"/api/v1/manager/appointments/series/:seriesId", "appointment"},
+		// Personal and client routes are not for service tokens
+		{"/api/v1/profile", ""},
+		{"/api/v1/client/payments/checkout-session", ""},
+		{"/api/v1/portal/cases", ""},
+	}
+	for _, test := range tests {
+		if resource := serviceTokenScope(test.route); resource != test.resource {
+			t.Errorf("serviceTokenScope(%q) = %q, want %q", test.route, resource, test.resource)
+		}
+	}
+}
+
+func TestServiceTokenRequestsLimitedPerToken(t *testing.T) {
+	useGeneralRateLimiter(t, 100, nil)
+	previous := ServiceTokenRateLimiter
+	ServiceTokenRateLimiter = NewRateLimiter(time.Minute, 2)
+	t.Cleanup(func() { ServiceTokenRateLimiter = previous })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		// What requestServiceToken caches once the bearer token has been looked up
+		if id := c.GetHeader("X-Test-Service-Token"); id != "" {
+			tokenID, _ := strconv.ParseUint(id, 10, 32)
+			c.Set(serviceTokenContextKey, models.ServiceToken{ID: uint(tokenID)})
+		}
+		c.Next()
+	})
+	r.Use(GeneralAPIRateLimit())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	call := func(tokenID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("X-Real-IP", "10.0.0.9")
+		req.Header.Set("X-Test-Service-Token", tokenID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if status := call("5"); status != http.StatusOK {
+			t.Fatalf("request %d with token 5 = %d", i+1, status)
+		}
+	}
+	if status := call("5"); status != http.StatusTooManyRequests {
+		t.Errorf("third request with token 5 = %d, want 429", status)
+	}
+	if status := call("6"); status != http.StatusOK {
+		t.Errorf("another token from the same IP = %d, want its own limit", status)
+	}
+}
@@ -34,7 +34,20 @@ type Appointment struct {
 	// NEW: Department for access control
 	Department string `gorm:"size:100;default:'General';index" json:"department"` // e.g., "Legal", "Psychology", "Administration"
 
+	// Shared by every appointment generated from one recurrence; nil for one-off appointments
+	SeriesID *string `gorm:"size:36;index" json:"seriesId,omitempty"`
+
+	// Last reminder sent to the client and its lead window (e.g. "24h", "1h"); cleared when rescheduled
+	ReminderSentAt *time.Time `gorm:"type:timestamp" json:"reminderSentAt,omitempty"`
+	ReminderStage  string     `gorm:"size:10" json:"reminderStage,omitempty"`
+
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index;type:timestamp" json:"-"`
+
+	// Calendar color resolved from the department/category color settings (not stored)
+	DisplayColor string `gorm:"-" json:"displayColor,omitempty"`
+
+	// "archived" or "deleted" when an admin lists soft-deleted appointments (not stored)
+	RecordState string `gorm:"-" json:"recordState,omitempty"`
 }
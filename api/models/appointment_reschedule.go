@@ -0,0 +1,26 @@
+// api/models/appointment_reschedule.go
+package models
+
+import "time"
+
+// AppointmentReschedule records one move of an appointment to new times. Rows are
+// append-only, so reschedules can be counted apart from status changes and other edits.
+type AppointmentReschedule struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	AppointmentID     uint      `json:"appointmentId" gorm:"not null;index"`
+	CaseID            uint      `json:"caseId" gorm:"not null"`
+	OfficeID          uint      `json:"officeId" gorm:"not null"` // Office at the time of the reschedule
+	StaffID           uint      `json:"staffId" gorm:"not null"`
+	PreviousStartTime time.Time `json:"previousStartTime" gorm:"type:timestamp;not null"`
+	PreviousEndTime   time.Time `json:"previousEndTime" gorm:"type:timestamp;not null"`
+	NewStartTime      time.Time `json:"newStartTime" gorm:"type:timestamp;not null"`
+	NewEndTime        time.Time `json:"newEndTime" gorm:"type:timestamp;not null"`
+	Reason            string    `json:"reason,omitempty" gorm:"type:text"`
+	RescheduledBy     *uint     `json:"rescheduledBy,omitempty"`
+	RescheduledAt     time.Time `json:"rescheduledAt" gorm:"type:timestamp;not null"`
+}
+
+// TableName specifies the table name for the AppointmentReschedule model
+func (AppointmentReschedule) TableName() string {
+	return "appointment_reschedules"
+}
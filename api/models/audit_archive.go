@@ -0,0 +1,23 @@
+// api/models/audit_archive.go
+package models
+
+import "time"
+
+// AuditArchive records one NDJSON export of audit logs made before they were purged.
+// Location is the stored file URL; RowCount was verified against the written file.
+type AuditArchive struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	Location         string    `json:"location" gorm:"type:text;not null"`
+	RowCount         int       `json:"rowCount" gorm:"not null"`
+	FirstLogID       uint      `json:"firstLogId" gorm:"not null"`
+	LastLogID        uint      `json:"lastLogId" gorm:"not null"`
+	OldestAt         time.Time `json:"oldestAt" gorm:"type:timestamp;not null"`
+	NewestAt         time.Time `json:"newestAt" gorm:"type:timestamp;not null"`
+	MaintenanceRunID *uint     `json:"maintenanceRunId,omitempty"`
+	CreatedAt        time.Time `json:"createdAt" gorm:"type:timestamp"`
+}
+
+// TableName specifies the table name for the AuditArchive model
+func (AuditArchive) TableName() string {
+	return "audit_archives"
+}
@@ -0,0 +1,18 @@
+// api/models/calendar_color.go
+package models
+
+import "time"
+
+// CalendarColor overrides the display color of an appointment department or category in calendar views.
+type CalendarColor struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Kind      string    `gorm:"size:20;not null;uniqueIndex:ux_calendar_colors_kind_key" json:"kind"` // "department" or "category"
+	Key       string    `gorm:"size:100;not null;uniqueIndex:ux_calendar_colors_kind_key" json:"key"`
+	Color     string    `gorm:"size:7;not null" json:"color"` // Hex color, e.g. "#1677ff"
+	Label     string    `gorm:"size:255" json:"label,omitempty"`
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (CalendarColor) TableName() string { return "calendar_colors" }
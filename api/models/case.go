@@ -2,14 +2,20 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"gorm.io/gorm"
 )
 
 // Case represents a legal or assistance case
 type Case struct {
 	ID             uint    `json:"id" gorm:"primaryKey"`
+	CaseNumber     string  `json:"caseNumber" gorm:"column:case_number;size:50;index"` // e.g. CAF-CEN-2025-000123, set on creation
 	ClientID       *uint   `json:"clientId" gorm:"column:client_id"`
 	OfficeID       uint    `json:"officeId" gorm:"column:office_id"`
 	Court          string  `json:"court" gorm:"size:50;index"`
@@ -23,6 +29,10 @@ type Case struct {
 	Priority       string  `json:"priority" gorm:"default:'medium'"`
 	PrimaryStaffID *uint   `json:"primaryStaffId" gorm:"column:primary_staff_id"`
 
+	// DueDate is when the case is expected to be resolved, by default its category's SLA from
+	// creation; open cases past it count as overdue
+	DueDate *time.Time `json:"dueDate" gorm:"column:due_date;type:timestamp"`
+
 	// Completion and Archiving Fields
 	IsCompleted    bool       `json:"isCompleted" gorm:"column:is_completed;default:false"`
 	CompletedAt    *time.Time `json:"completedAt" gorm:"column:completed_at;type:timestamp"`
@@ -54,6 +64,108 @@ type Case struct {
 	AssignedStaff []User        `json:"assignedStaff" gorm:"many2many:user_case_assignments;"`
 }
 
+// BeforeCreate assigns the case number from the office and year sequence, and a due date from
+// the category's SLA when none was given. The sequence row is incremented in the same
+// transaction as the insert, so concurrent creates get distinct numbers and a failed insert does
+// not consume one.
+func (c *Case) BeforeCreate(tx *gorm.DB) error {
+	openedAt := c.openedAt()
+	if c.DueDate == nil {
+		c.DueDate = config.CaseDueDate(c.Category, openedAt)
+	}
+	if c.CaseNumber != "" {
+		return nil
+	}
+	number, err := allocateCaseNumber(tx, c.OfficeID, openedAt.Year())
+	if err != nil {
+		return err
+	}
+	c.CaseNumber = number
+	return nil
+}
+
+// openedAt is when the case was opened: its creation time, or now for a case being created.
+func (c *Case) openedAt() time.Time {
+	if c.CreatedAt.IsZero() {
+		return time.Now()
+	}
+	return c.CreatedAt
+}
+
+// allocateCaseNumber takes the next number of the office and year sequence in tx.
+func allocateCaseNumber(tx *gorm.DB, officeID uint, year int) (string, error) {
+	db := tx.Session(&gorm.Session{NewDB: true})
+	var seq int64
+	if err := db.Raw(`INSERT INTO case_number_sequences (office_id, year, last_value) VALUES (?, ?, 1)
+		ON CONFLICT (office_id, year) DO UPDATE SET last_value = case_number_sequences.last_value + 1
+		RETURNING last_value`, officeID, year).Scan(&seq).Error; err != nil {
+		return "", fmt.Errorf("failed to allocate case number: %w", err)
+	}
+
+	var officeCode string
+	db.Table("offices").Where("id = ?", officeID).Select("code").Scan(&officeCode)
+	return FormatCaseNumber(officeCode, officeID, year, seq), nil
+}
+
+// NumberExistingCases numbers the cases created before case numbers existed (soft-deleted ones
+// included), in creation order and with the configured format. main runs it after the
+// migrations; it returns how many cases were numbered. A case numbered meanwhile by another
+// replica is skipped without consuming a number.
+func NumberExistingCases(db *gorm.DB) (int, error) {
+	numbered := 0
+	for {
+		var cases []Case
+		if err := db.Unscoped().Select("id", "office_id", "created_at").
+			Where("case_number IS NULL OR case_number = ''").
+			Order("created_at, id").Limit(500).
+			Find(&cases).Error; err != nil {
+			return numbered, err
+		}
+		if len(cases) == 0 {
+			return numbered, nil
+		}
+		for i := range cases {
+			caseRecord := &cases[i]
+			err := db.Transaction(func(tx *gorm.DB) error {
+				number, err := allocateCaseNumber(tx, caseRecord.OfficeID, caseRecord.openedAt().Year())
+				if err != nil {
+					return err
+				}
+				result := tx.Unscoped().Model(&Case{}).
+					Where("id = ? AND (case_number IS NULL OR case_number = '')", caseRecord.ID).
+					UpdateColumn("case_number", number)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return errCaseAlreadyNumbered
+				}
+				return nil
+			})
+			if errors.Is(err, errCaseAlreadyNumbered) {
+				continue
+			}
+			if err != nil {
+				return numbered, fmt.Errorf("failed to number case %d: %w", caseRecord.ID, err)
+			}
+			numbered++
+		}
+	}
+}
+
+// errCaseAlreadyNumbered rolls back a number taken for a case that got one meanwhile.
+var errCaseAlreadyNumbered = errors.New("case already numbered")
+
+// FormatCaseNumber builds a case number such as CAF-CEN-2025-000123. Offices without a code
+// are identified by their ID.
+func FormatCaseNumber(officeCode string, officeID uint, year int, seq int64) string {
+	office := strings.ToUpper(strings.TrimSpace(officeCode))
+	if office == "" {
+		office = strconv.FormatUint(uint64(officeID), 10)
+	}
+	return fmt.Sprintf("%s-%s-%d-%0*d", config.CaseNumberPrefix(), office, year, config.CaseNumberDigits(), seq)
+}
+
 // BeforeDelete hook for audit logging
 func (c *Case) BeforeDelete(tx *gorm.DB) error {
 	// This will be called for soft deletes
@@ -73,7 +185,7 @@ func (c *Case) Complete(userID uint, note string) {
 	c.CompletedAt = &now
 	c.CompletedBy = &userID
 	c.CompletionNote = note
-	c.Status = "completed"
+	c.Status = string(config.CaseStatusClosed)
 }
 
 // Archive marks a case as archived with a reason
@@ -26,6 +26,8 @@ type CaseEvent struct {
 	FileName string `gorm:"size:255" json:"fileName,omitempty"`
 	FileUrl  string `gorm:"size:512" json:"fileUrl,omitempty"`
 	FileType string `gorm:"size:100" json:"fileType,omitempty"`
+	// DocumentType tags the upload against the category's document checklist (e.g. "acta_matrimonio")
+	DocumentType *string `gorm:"size:100" json:"documentType,omitempty"`
 
 	// Additional metadata in JSON format
 	Metadata map[string]interface{} `gorm:"type:jsonb" json:"metadata,omitempty"`
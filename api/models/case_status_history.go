@@ -0,0 +1,23 @@
+// api/models/case_status_history.go
+package models
+
+import "time"
+
+// CaseStatusHistory records one change of a case's stage or status. The first row for a
+// case has empty From values; rows are append-only and feed the stage funnel report.
+type CaseStatusHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CaseID     uint      `json:"caseId" gorm:"not null;index"`
+	OfficeID   uint      `json:"officeId" gorm:"not null"` // Office at the time of the change
+	FromStage  string    `json:"fromStage" gorm:"size:50"`
+	ToStage    string    `json:"toStage" gorm:"size:50;not null"`
+	FromStatus string    `json:"fromStatus" gorm:"size:50"`
+	ToStatus   string    `json:"toStatus" gorm:"size:50;not null"`
+	ChangedBy  *uint     `json:"changedBy,omitempty"`
+	ChangedAt  time.Time `json:"changedAt" gorm:"type:timestamp;not null"`
+}
+
+// TableName specifies the table name for the CaseStatusHistory model
+func (CaseStatusHistory) TableName() string {
+	return "case_status_history"
+}
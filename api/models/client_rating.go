@@ -0,0 +1,34 @@
+// api/models/client_rating.go
+package models
+
+import "time"
+
+// Client rating targets
+const (
+	RatingEntityAppointment = "appointment"
+	RatingEntityCase        = "case"
+)
+
+// ClientRating is a client's 1-5 satisfaction score for a completed appointment or case.
+// StaffID is the staff member being rated: the appointment's staff or the case's primary staff.
+type ClientRating struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ClientID      uint      `json:"clientId" gorm:"not null;index"`
+	EntityType    string    `json:"entityType" gorm:"type:varchar(20);not null"`
+	CaseID        uint      `json:"caseId" gorm:"not null;index"`
+	AppointmentID *uint     `json:"appointmentId,omitempty" gorm:"index"`
+	StaffID       *uint     `json:"staffId,omitempty" gorm:"index"`
+	OfficeID      *uint     `json:"officeId,omitempty" gorm:"index"`
+	Rating        int       `json:"rating" gorm:"not null"`
+	Comment       string    `json:"comment,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt     time.Time `json:"updatedAt" gorm:"type:timestamp"`
+
+	Client *User `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	Staff  *User `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// TableName specifies the table name for the ClientRating model
+func (ClientRating) TableName() string {
+	return "client_ratings"
+}
@@ -0,0 +1,17 @@
+// api/models/court.go
+package models
+
+import "time"
+
+// Court is an admin-maintained court name. Case.Court values that match a court
+// case-insensitively are saved with its canonical Name.
+type Court struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:50;not null" json:"name"` // Unique case-insensitively; matches the size of Case.Court
+	IsActive  bool      `gorm:"not null;default:true" json:"isActive"`
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (Court) TableName() string { return "courts" }
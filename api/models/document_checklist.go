@@ -0,0 +1,21 @@
+// api/models/document_checklist.go
+package models
+
+import "time"
+
+// DocumentChecklistItem is one document expected in the file of a case of a given category.
+// Uploaded documents satisfy an item when their CaseEvent.DocumentType matches DocumentType.
+type DocumentChecklistItem struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Category     string    `gorm:"size:100;not null;index;uniqueIndex:ux_document_checklist_category_type" json:"category"` // Case category, e.g. "Familiar"
+	DocumentType string    `gorm:"size:100;not null;uniqueIndex:ux_document_checklist_category_type" json:"documentType"`   // Tag used on uploads, e.g. "acta_matrimonio"
+	Label        string    `gorm:"size:255;not null" json:"label"`
+	Description  string    `gorm:"type:text" json:"description,omitempty"`
+	Required     bool      `gorm:"not null;default:true" json:"required"`
+	SortOrder    int       `gorm:"not null;default:0" json:"sortOrder"`
+	UpdatedBy    *uint     `json:"updatedBy,omitempty"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (DocumentChecklistItem) TableName() string { return "document_checklist_items" }
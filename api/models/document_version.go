@@ -0,0 +1,18 @@
+// api/models/document_version.go
+package models
+
+import "time"
+
+// DocumentVersion is one file uploaded for a document (a file_upload CaseEvent). Versions are
+// recorded once the document is first re-uploaded; the event's FileUrl always points at the
+// newest, and earlier files stay in storage so they can still be downloaded.
+type DocumentVersion struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	DocumentEventID uint      `json:"documentEventId" gorm:"not null;index"`
+	Version         int       `json:"version" gorm:"not null"` // 1 is the original upload
+	S3Key           string    `json:"-" gorm:"column:s3_key;size:512;not null"`
+	FileName        string    `json:"fileName" gorm:"size:255"`
+	FileType        string    `json:"fileType" gorm:"size:100"`
+	UploadedBy      *uint     `json:"uploadedBy,omitempty"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"type:timestamp"` // When this version was uploaded
+}
@@ -0,0 +1,27 @@
+// api/models/expense.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Expense is an operating cost recorded by an admin, optionally tied to an office or a case.
+// Financial metrics subtract expenses from the paid revenue in payment_records.
+type Expense struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	OfficeID    *uint          `gorm:"index" json:"officeId,omitempty"`
+	CaseID      *uint          `gorm:"index" json:"caseId,omitempty"`
+	Category    string         `gorm:"size:50;not null" json:"category"`
+	Description string         `gorm:"size:500" json:"description"`
+	AmountCents int64          `gorm:"not null" json:"amountCents"`
+	Currency    string         `gorm:"size:16;not null" json:"currency"`
+	IncurredAt  time.Time      `gorm:"index;not null;type:timestamp" json:"incurredAt"`
+	CreatedBy   *uint          `json:"createdBy,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt   time.Time      `json:"updatedAt" gorm:"type:timestamp"`
+	DeletedAt   gorm.DeletedAt `gorm:"index;type:timestamp" json:"-"`
+}
+
+func (Expense) TableName() string { return "expenses" }
@@ -0,0 +1,38 @@
+// api/models/maintenance_run.go
+package models
+
+import "time"
+
+// Maintenance tasks
+const (
+	MaintenanceTaskVacuumAnalyze = "vacuum_analyze"
+	MaintenanceTaskOrphanFiles   = "orphan_files"
+	MaintenanceTaskAuditPurge    = "audit_retention"
+	MaintenanceTaskRecordPurge   = "record_retention"
+)
+
+// Maintenance run statuses
+const (
+	MaintenanceStatusRunning   = "running"
+	MaintenanceStatusSucceeded = "succeeded"
+	MaintenanceStatusFailed    = "failed"
+	MaintenanceStatusSkipped   = "skipped"
+)
+
+// MaintenanceRun records one execution of a database or storage maintenance task.
+// TriggeredBy is nil for scheduled runs.
+type MaintenanceRun struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Task        string     `json:"task" gorm:"type:varchar(50);not null;index"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null"`
+	Details     string     `json:"details,omitempty" gorm:"type:text"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	TriggeredBy *uint      `json:"triggeredBy,omitempty"`
+	StartedAt   time.Time  `json:"startedAt" gorm:"type:timestamp;not null"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty" gorm:"type:timestamp"`
+}
+
+// TableName specifies the table name for the MaintenanceRun model
+func (MaintenanceRun) TableName() string {
+	return "maintenance_runs"
+}
@@ -0,0 +1,39 @@
+// api/models/notification_delivery.go
+package models
+
+import "time"
+
+// Notification delivery channels
+const (
+	DeliveryChannelInApp = "in_app"
+	DeliveryChannelEmail = "email"
+	DeliveryChannelSMS   = "sms"
+)
+
+// Notification delivery statuses
+const (
+	DeliveryStatusSent      = "sent"
+	DeliveryStatusFailed    = "failed"
+	DeliveryStatusSimulated = "simulated" // No provider configured; the message was only logged
+)
+
+// NotificationDelivery records an outbound email/SMS sent (or attempted) to a user.
+// In-app notifications live in the notifications table.
+type NotificationDelivery struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"userId" gorm:"not null;index"`
+	Channel    string    `json:"channel" gorm:"type:varchar(20);not null;index"`
+	Type       string    `json:"type" gorm:"type:varchar(50);not null"` // e.g. appointment_confirmation
+	Recipient  string    `json:"recipient" gorm:"type:varchar(255)"`
+	Subject    string    `json:"subject,omitempty" gorm:"type:varchar(255)"`
+	Status     string    `json:"status" gorm:"type:varchar(20);not null"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	EntityType string    `json:"entityType,omitempty" gorm:"type:varchar(50)"`
+	EntityID   *uint     `json:"entityId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for the NotificationDelivery model
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
@@ -0,0 +1,68 @@
+// api/models/notification_preference.go
+package models
+
+import "time"
+
+// Notification event types users can opt out of, per channel.
+const (
+	NotificationEventAppointments = "appointments" // Confirmations, reminders and changes
+	NotificationEventCases        = "cases"
+	NotificationEventTasks        = "tasks"
+	NotificationEventPayments     = "payments"
+)
+
+// NotificationPreference holds the channels and event types a user receives notifications
+// for. Users without a row receive everything; see DefaultNotificationPreference.
+type NotificationPreference struct {
+	UserID       uint      `json:"userId" gorm:"primaryKey"`
+	EmailEnabled bool      `json:"emailEnabled" gorm:"not null"`
+	InAppEnabled bool      `json:"inAppEnabled" gorm:"not null"`
+	Appointments bool      `json:"appointments" gorm:"not null"`
+	Cases        bool      `json:"cases" gorm:"not null"`
+	Tasks        bool      `json:"tasks" gorm:"not null"`
+	Payments     bool      `json:"payments" gorm:"not null"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+
+// DefaultNotificationPreference returns the preferences of a user who has not set any: every
+// channel and event type on.
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	return NotificationPreference{
+		UserID:       userID,
+		EmailEnabled: true,
+		InAppEnabled: true,
+		Appointments: true,
+		Cases:        true,
+		Tasks:        true,
+		Payments:     true,
+	}
+}
+
+// Allows reports whether the user receives notifications of the event type on the channel
+// (DeliveryChannelEmail or DeliveryChannelInApp). An empty or unknown event type only checks
+// the channel.
+func (p NotificationPreference) Allows(channel, event string) bool {
+	switch channel {
+	case DeliveryChannelEmail:
+		if !p.EmailEnabled {
+			return false
+		}
+	case DeliveryChannelInApp:
+		if !p.InAppEnabled {
+			return false
+		}
+	}
+	switch event {
+	case NotificationEventAppointments:
+		return p.Appointments
+	case NotificationEventCases:
+		return p.Cases
+	case NotificationEventTasks:
+		return p.Tasks
+	case NotificationEventPayments:
+		return p.Payments
+	}
+	return true
+}
@@ -15,7 +15,13 @@ type Office struct {
 	PhoneCell   string   `gorm:"column:phone_cell;size:50" json:"phoneCell,omitempty"`
 	Latitude   *float64  `gorm:"type:decimal(10,8)" json:"latitude,omitempty"`
 	Longitude  *float64  `gorm:"type:decimal(11,8)" json:"longitude,omitempty"`
+	Timezone   string    `gorm:"size:64" json:"timezone,omitempty"` // IANA zone, e.g. America/Ciudad_Juarez; empty uses the server's zone
 	CreatedAt  time.Time `json:"createdAt" gorm:"type:timestamp"`
 	UpdatedAt  time.Time `json:"updatedAt" gorm:"type:timestamp"`
 	Code       string    `gorm:"size:50;index" json:"code"`
+	IsActive   bool      `gorm:"not null;default:true" json:"isActive"` // Inactive offices receive no transferred records
+
+	// Loaded only by the business hours endpoints
+	BusinessHours []OfficeBusinessHours `gorm:"foreignKey:OfficeID" json:"businessHours,omitempty"`
+	Holidays      []OfficeHoliday       `gorm:"foreignKey:OfficeID" json:"holidays,omitempty"`
 }
@@ -0,0 +1,16 @@
+// api/models/office_appointment_category.go
+package models
+
+import "time"
+
+// OfficeAppointmentCategory enables an appointment category at an office. An office without
+// any rows offers every category; once one is added, appointments there must use a listed one.
+type OfficeAppointmentCategory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OfficeID  uint      `gorm:"not null;index" json:"officeId"`
+	Category  string    `gorm:"size:100;not null" json:"category"` // Unique per office case-insensitively; matches the size of Appointment.Category
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+}
+
+func (OfficeAppointmentCategory) TableName() string { return "office_appointment_categories" }
@@ -0,0 +1,19 @@
+// api/models/office_business_hours.go
+package models
+
+import "time"
+
+// OfficeBusinessHours is the window an office takes appointments on one weekday, in the office's
+// time zone. Weekdays without a row are closed; an office without any rows follows
+// APPOINTMENT_WORKING_HOURS and APPOINTMENT_WORKING_DAYS.
+type OfficeBusinessHours struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OfficeID  uint      `gorm:"not null;index" json:"officeId"`
+	Weekday   int       `gorm:"not null" json:"weekday"`      // 0 = Sunday; unique per office
+	OpenTime  string    `gorm:"size:5;not null" json:"open"`  // HH:MM
+	CloseTime string    `gorm:"size:5;not null" json:"close"` // HH:MM, after OpenTime
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+}
+
+func (OfficeBusinessHours) TableName() string { return "office_business_hours" }
@@ -0,0 +1,16 @@
+// api/models/office_holiday.go
+package models
+
+import "time"
+
+// OfficeHoliday is a date an office takes no appointments, such as a Mexican public holiday.
+type OfficeHoliday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OfficeID  uint      `gorm:"not null;index" json:"officeId"`
+	Date      string    `gorm:"size:10;not null" json:"date"` // YYYY-MM-DD in the office's time zone; unique per office
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+}
+
+func (OfficeHoliday) TableName() string { return "office_holidays" }
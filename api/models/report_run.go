@@ -0,0 +1,26 @@
+// api/models/report_run.go
+package models
+
+import "time"
+
+// ReportRun stores the parameters of a generated report so it can be re-run later.
+// Query is the original query string; DateFrom/DateTo are the range it resolved to.
+type ReportRun struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	ReportType    string     `json:"reportType" gorm:"size:30;not null"` // summary, cases, appointments or export
+	Query         string     `json:"query" gorm:"type:text"`
+	DateFrom      time.Time  `json:"dateFrom" gorm:"type:timestamp;not null"`
+	DateTo        time.Time  `json:"dateTo" gorm:"type:timestamp;not null"`
+	OfficeScopeID *uint      `json:"officeScopeId,omitempty"` // Office the original caller was restricted to
+	RunByID       uint       `json:"runById" gorm:"not null"`
+	RunByRole     string     `json:"runByRole" gorm:"size:50;not null"`
+	RunBy         *User      `json:"runBy,omitempty" gorm:"foreignKey:RunByID"`
+	RerunCount    int        `json:"rerunCount" gorm:"not null;default:0"`
+	LastRerunAt   *time.Time `json:"lastRerunAt,omitempty" gorm:"type:timestamp"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"type:timestamp"`
+}
+
+// TableName specifies the table name for the ReportRun model
+func (ReportRun) TableName() string {
+	return "report_runs"
+}
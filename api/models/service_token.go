@@ -0,0 +1,56 @@
+// api/models/service_token.go
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Service token scopes. A scope may be narrowed to one resource, e.g. "appointment:read".
+const (
+	ServiceTokenScopeRead  = "read"
+	ServiceTokenScopeWrite = "write"
+)
+
+// ServiceToken is a long-lived API token for a scheduled job or integration. It acts as UserID,
+// limited to Scopes, and is identified by TokenID (the JWT "jti"); the token itself is never stored.
+type ServiceToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"size:100;not null"`
+	Description string     `json:"description" gorm:"type:text"`
+	TokenID     string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	UserID      uint       `json:"userId" gorm:"not null;index"`
+	Scopes      string     `json:"scopes" gorm:"size:255;not null"` // Comma-separated, e.g. "read,appointment:write"
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" gorm:"type:timestamp"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty" gorm:"type:timestamp"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty" gorm:"type:timestamp"`
+	CreatedBy   *uint      `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt   time.Time  `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (ServiceToken) TableName() string { return "service_tokens" }
+
+// Active reports whether the token has been neither revoked nor expired at now.
+func (t ServiceToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// Allows reports whether the token's scopes permit access to resource: "read" or "write"
+// covers every resource, "<resource>:read" or "<resource>:write" only that one, and write
+// also grants read.
+func (t ServiceToken) Allows(resource string, write bool) bool {
+	for _, scope := range strings.Split(t.Scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if name, access, found := strings.Cut(scope, ":"); found {
+			if name != resource {
+				continue
+			}
+			scope = access
+		}
+		if scope == ServiceTokenScopeWrite || (scope == ServiceTokenScopeRead && !write) {
+			return true
+		}
+	}
+	return false
+}
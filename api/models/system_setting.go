@@ -0,0 +1,16 @@
+// api/models/system_setting.go
+package models
+
+import "time"
+
+// SystemSetting is an admin override of a configuration default, stored as a string value.
+type SystemSetting struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Key       string    `gorm:"size:100;not null;uniqueIndex" json:"key"`
+	Value     string    `gorm:"size:255;not null" json:"value"`
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (SystemSetting) TableName() string { return "system_settings" }
@@ -0,0 +1,15 @@
+// api/models/task_dependency.go
+package models
+
+import "time"
+
+// TaskDependency records that a task cannot be completed before another task of the same case.
+type TaskDependency struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TaskID          uint      `gorm:"not null;index" json:"taskId"`          // The blocked task
+	DependsOnTaskID uint      `gorm:"not null;index" json:"dependsOnTaskId"` // Must be completed first; unique per task
+	CreatedBy       *uint     `json:"createdBy,omitempty"`
+	CreatedAt       time.Time `json:"createdAt" gorm:"type:timestamp"`
+}
+
+func (TaskDependency) TableName() string { return "task_dependencies" }
@@ -32,15 +32,23 @@ type User struct {
 	Department *string `gorm:"size:100" json:"department,omitempty"` // e.g., "Legal", "Psychology", "Administration"
 	Specialty  *string `gorm:"size:100" json:"specialty,omitempty"`  // e.g., "Criminal Law", "Family Therapy", "HR"
 
+	// IANA zone, e.g. America/Ciudad_Juarez, for report and dashboard periods; empty uses the office's zone
+	Timezone string `gorm:"size:64" json:"timezone,omitempty"`
+
+	// Share of working hours the staff member should have booked, as a percentage; nil uses STAFF_TARGET_UTILIZATION
+	TargetUtilization *float64 `gorm:"column:target_utilization" json:"targetUtilization,omitempty"`
+
 	// NEW: Case assignments for staff members
 	AssignedCases []Case `gorm:"many2many:user_case_assignments;" json:"assignedCases,omitempty"`
 
 	// Account status
-	IsActive  bool           `gorm:"default:true" json:"isActive"` // Whether the user account is active
-	LastLogin *time.Time     `json:"lastLogin" gorm:"index;type:timestamp"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `gorm:"index;type:timestamp" json:"-"`
+	IsActive bool `gorm:"default:true" json:"isActive"` // Whether the user account is active
+	// Set on clients auto-created with a placeholder password by the appointment/case/contact flows
+	MustChangePassword bool           `gorm:"not null;default:false" json:"mustChangePassword"`
+	LastLogin          *time.Time     `json:"lastLogin" gorm:"index;type:timestamp"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	DeletedAt          gorm.DeletedAt `gorm:"index;type:timestamp" json:"-"`
 }
 
 // UserCaseAssignment represents the many-to-many relationship between users and cases
@@ -0,0 +1,32 @@
+// api/models/webhook_delivery.go
+package models
+
+import "time"
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"   // Waiting for its next attempt
+	WebhookDeliveryDelivered = "delivered" // The endpoint answered 2xx
+	WebhookDeliveryFailed    = "failed"    // Every retry failed
+)
+
+// WebhookDelivery is one event queued for one subscription. The worker POSTs Payload when
+// NextAttemptAt is due and, on failure, schedules the next attempt with exponential backoff
+// until the retries run out.
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint       `json:"subscriptionId" gorm:"not null;index"`
+	EventID        string     `json:"eventId" gorm:"size:40;not null"`
+	Event          string     `json:"event" gorm:"size:50;not null"`
+	Payload        string     `json:"-" gorm:"type:text;not null"` // The signed JSON body
+	Status         string     `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty" gorm:"type:timestamp;index"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"` // HTTP status of the latest attempt, 0 when none was received
+	LastError      string     `json:"lastError,omitempty" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty" gorm:"type:timestamp"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt      time.Time  `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
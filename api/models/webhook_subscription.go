@@ -0,0 +1,54 @@
+// api/models/webhook_subscription.go
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook events
+const (
+	WebhookEventCaseCreated          = "case.created"
+	WebhookEventCaseStageChanged     = "case.stage_changed"
+	WebhookEventAppointmentCreated   = "appointment.created"
+	WebhookEventAppointmentCancelled = "appointment.cancelled"
+)
+
+// WebhookSubscription is an external endpoint notified of CAF events. Deliveries are signed
+// with Secret; Stages, when set, limits stage events to cases entering one of those stages.
+type WebhookSubscription struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"size:100;not null"`
+	URL         string     `json:"url" gorm:"size:500;not null"`
+	Secret      string     `json:"-" gorm:"size:100;not null"`
+	Events      string     `json:"events" gorm:"size:255;not null"` // Comma-separated, e.g. "case.stage_changed"
+	Stages      string     `json:"stages" gorm:"size:255"`          // Comma-separated target stages; empty means all
+	IsActive    bool       `json:"isActive" gorm:"default:true"`
+	LastStatus  string     `json:"lastStatus,omitempty" gorm:"size:20"` // Outcome of the latest attempt: "delivered" or "failed"
+	LastError   string     `json:"lastError,omitempty" gorm:"type:text"`
+	LastFiredAt *time.Time `json:"lastFiredAt,omitempty" gorm:"type:timestamp"`
+	CreatedBy   *uint      `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"type:timestamp"`
+	UpdatedAt   time.Time  `json:"updatedAt" gorm:"type:timestamp"`
+}
+
+func (WebhookSubscription) TableName() string { return "webhook_subscriptions" }
+
+// Wants reports whether the subscription receives the event for a case entering stage
+// (empty for events that are not about a stage).
+func (s WebhookSubscription) Wants(event, stage string) bool {
+	if !s.IsActive || !listContains(s.Events, event) {
+		return false
+	}
+	return s.Stages == "" || stage == "" || listContains(s.Stages, stage)
+}
+
+// listContains reports whether the comma-separated list holds value.
+func listContains(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == value {
+			return true
+		}
+	}
+	return false
+}
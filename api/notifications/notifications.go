@@ -8,10 +8,14 @@ import (
 	"github.com/BryanPMX/CAF/api/models"
 )
 
+// Simulated reports that messages are only logged; it stays true until the senders below
+// integrate a provider, and deliveries are recorded as simulated meanwhile.
+const Simulated = true
+
 // SendAppointmentConfirmation is a placeholder function for sending notifications.
 // In a real application, this would integrate with an email service (like SendGrid)
-// or an SMS service (like Twilio).
-func SendAppointmentConfirmation(appointment models.Appointment, client models.User) {
+// or an SMS service (like Twilio), returning the provider's error.
+func SendAppointmentConfirmation(appointment models.Appointment, client models.User) error {
 	// For now, we just print a log message to simulate the action.
 	log.Printf("--- NOTIFICATION SIMULATION ---")
 	log.Printf("To: %s", client.Email)
@@ -22,4 +26,21 @@ func SendAppointmentConfirmation(appointment models.Appointment, client models.U
 		appointment.StartTime.Format(time.RFC822),
 	)
 	log.Printf("-----------------------------")
+	return nil
+}
+
+// SendAppointmentReminder is a placeholder, like SendAppointmentConfirmation, for the reminder
+// sent to the client a lead time (e.g. "24h") before a confirmed appointment.
+func SendAppointmentReminder(appointment models.Appointment, client models.User, lead string) error {
+	log.Printf("--- NOTIFICATION SIMULATION ---")
+	log.Printf("To: %s", client.Email)
+	log.Printf("Subject: Recordatorio de su Cita en CAF")
+	log.Printf("Body: Hola %s, le recordamos su cita para '%s' el %s (recordatorio %s).",
+		client.FirstName,
+		appointment.Title,
+		appointment.StartTime.Format(time.RFC822),
+		lead,
+	)
+	log.Printf("-----------------------------")
+	return nil
 }
@@ -0,0 +1,215 @@
+// api/pdf/pdf.go
+// Minimal PDF writer used to produce printable CAF documents (invoices,
+// reports) without pulling in an external rendering dependency.
+//
+// The writer only supports the standard Helvetica fonts, simple text lines
+// and horizontal rules, which is all the generated documents need. Text is
+// encoded as WinAnsi so Spanish accents render correctly.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PageWidth and PageHeight describe a US Letter page in points.
+	PageWidth  = 612.0
+	PageHeight = 792.0
+	// Margin is the default left/right/top/bottom margin in points.
+	Margin = 50.0
+)
+
+// Document accumulates pages and renders them as a PDF file.
+// Content is laid out top-down using an internal cursor; AddPage is
+// called automatically when the cursor reaches the bottom margin.
+type Document struct {
+	pages  []*bytes.Buffer
+	cursor float64
+	// Header, if set, is drawn at the top of every page (e.g. letterhead).
+	Header func(d *Document)
+}
+
+// New creates an empty document with a single page.
+func New() *Document {
+	d := &Document{}
+	d.AddPage()
+	return d
+}
+
+// NewWithHeader creates a document whose pages all start with header.
+func NewWithHeader(header func(d *Document)) *Document {
+	d := &Document{Header: header}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page and resets the cursor to the top margin.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.cursor = PageHeight - Margin
+	if d.Header != nil {
+		d.Header(d)
+	}
+}
+
+// PageCount returns the number of pages in the document.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// ensureSpace starts a new page when fewer than height points remain.
+func (d *Document) ensureSpace(height float64) {
+	if d.cursor-height < Margin {
+		d.AddPage()
+	}
+}
+
+func (d *Document) current() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// TextAt draws a single line of text at an absolute position.
+func (d *Document) TextAt(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(text))
+}
+
+// Line draws a line of text at the left margin and advances the cursor.
+func (d *Document) Line(size float64, bold bool, text string) {
+	lineHeight := size * 1.4
+	d.ensureSpace(lineHeight)
+	d.cursor -= lineHeight
+	d.TextAt(Margin, d.cursor, size, bold, text)
+}
+
+// Paragraph wraps text to the printable width and writes it line by line.
+func (d *Document) Paragraph(size float64, text string) {
+	maxChars := int((PageWidth - 2*Margin) / (size * 0.5))
+	for _, line := range wrap(text, maxChars) {
+		d.Line(size, false, line)
+	}
+}
+
+// Columns writes one row of text split into columns at the given x offsets
+// (relative to the left margin) and advances the cursor.
+func (d *Document) Columns(size float64, bold bool, offsets []float64, values []string) {
+	lineHeight := size * 1.4
+	d.ensureSpace(lineHeight)
+	d.cursor -= lineHeight
+	for i, value := range values {
+		if i >= len(offsets) {
+			break
+		}
+		d.TextAt(Margin+offsets[i], d.cursor, size, bold, value)
+	}
+}
+
+// Rule draws a horizontal line across the printable width.
+func (d *Document) Rule() {
+	d.ensureSpace(8)
+	d.cursor -= 6
+	fmt.Fprintf(d.current(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", Margin, d.cursor, PageWidth-Margin, d.cursor)
+	d.cursor -= 2
+}
+
+// Space advances the cursor by the given number of points.
+func (d *Document) Space(points float64) {
+	d.ensureSpace(points)
+	d.cursor -= points
+}
+
+// Bytes renders the document as a complete PDF file.
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	offsets := make([]int, 0)
+
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbering: 1 catalog, 2 pages, 3-4 fonts, then page/content pairs.
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+i*2))
+		content := page.Bytes()
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// escape converts text to WinAnsi bytes and escapes PDF string delimiters.
+// Characters outside Latin-1 are replaced with '?'.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20:
+			// Drop control characters
+		case r < 0x100:
+			b.WriteByte(byte(r))
+		case r == '€':
+			b.WriteByte(0x80)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// wrap splits text into lines of at most width characters on word boundaries.
+func wrap(text string, width int) []string {
+	if width <= 0 {
+		return []string{text}
+	}
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		current := words[0]
+		for _, word := range words[1:] {
+			if len([]rune(current))+1+len([]rune(word)) > width {
+				lines = append(lines, current)
+				current = word
+				continue
+			}
+			current += " " + word
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -55,40 +55,151 @@ func (r *OfficeRepositoryImpl) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&models.Office{}, id).Error
 }
 
-// openCaseStatuses are statuses that block office delete; closed/completed cases live in Records and do not block.
-var openCaseStatuses = []string{"open", "in_progress", "pending", "archived"}
+// openCaseStatuses are statuses that block office delete; closed/completed and archived cases live in Records and do not block.
+var openCaseStatuses = []string{"open", "in_progress", "pending"}
 
-// GetDeleteBlockReason returns a non-empty message if the office has users, open cases, appointments, or therapist capacities; empty if delete is allowed.
-func (r *OfficeRepositoryImpl) GetDeleteBlockReason(ctx context.Context, officeID uint) (string, error) {
-	db := r.db.WithContext(ctx)
-	var users, openCases, appointments, capacities int64
-	if err := db.Model(&models.User{}).Where("office_id = ?", officeID).Count(&users).Error; err != nil {
-		return "", err
+// GetDependentCounts counts the users, open cases, appointments and therapist capacities referencing the office.
+func (r *OfficeRepositoryImpl) GetDependentCounts(ctx context.Context, officeID uint) (*interfaces.OfficeDependentCounts, error) {
+	return countOfficeDependents(r.db.WithContext(ctx), officeID)
+}
+
+func countOfficeDependents(db *gorm.DB, officeID uint) (*interfaces.OfficeDependentCounts, error) {
+	counts := &interfaces.OfficeDependentCounts{}
+	if err := db.Model(&models.User{}).Where("office_id = ?", officeID).Count(&counts.Users).Error; err != nil {
+		return nil, err
 	}
-	if err := db.Model(&models.Case{}).Where("office_id = ? AND status IN ?", officeID, openCaseStatuses).Count(&openCases).Error; err != nil {
-		return "", err
+	if err := db.Model(&models.Case{}).Where("office_id = ? AND status IN ?", officeID, openCaseStatuses).Count(&counts.OpenCases).Error; err != nil {
+		return nil, err
 	}
-	if err := db.Model(&models.Appointment{}).Where("office_id = ?", officeID).Count(&appointments).Error; err != nil {
-		return "", err
+	if err := db.Model(&models.Appointment{}).Where("office_id = ?", officeID).Count(&counts.Appointments).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Table("therapist_office_capacities").Where("office_id = ?", officeID).Count(&counts.Capacities).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ReassignAndDelete moves users, cases (including closed ones), appointments, contact submissions and audit
+// references to targetOfficeID, drops the office's therapist capacities, and deletes the office. The audit
+// entry built by audit is created in the same transaction. Returns the dependent counts as they were before
+// the reassignment, with the IDs of the moved cases.
+func (r *OfficeRepositoryImpl) ReassignAndDelete(ctx context.Context, officeID, targetOfficeID uint, audit func(*interfaces.OfficeDependentCounts) models.AuditLog) (*interfaces.OfficeDependentCounts, error) {
+	var counts *interfaces.OfficeDependentCounts
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if counts, err = countOfficeDependents(tx, officeID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Case{}).Where("office_id = ?", officeID).Pluck("id", &counts.CaseIDs).Error; err != nil {
+			return err
+		}
+		for _, table := range []string{"users", "cases", "appointments", "contact_submissions"} {
+			if err := tx.Table(table).Where("office_id = ?", officeID).UpdateColumn("office_id", targetOfficeID).Error; err != nil {
+				return err
+			}
+		}
+		// Before moving audit references, so the entry's own reference to the office moves too
+		entry := audit(counts)
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("audit_logs").Where("user_office_id = ?", officeID).UpdateColumn("user_office_id", targetOfficeID).Error; err != nil {
+			return err
+		}
+		// Capacities are per-office schedules; they are not meaningful at the target office
+		if err := tx.Exec("DELETE FROM therapist_office_capacities WHERE office_id = ?", officeID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Office{}, officeID).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	if err := db.Table("therapist_office_capacities").Where("office_id = ?", officeID).Count(&capacities).Error; err != nil {
+	return counts, nil
+}
+
+// TransferRecords moves users, cases (including closed ones), appointments (past ones too, plus any
+// pending/confirmed appointments of the moved cases), contact submissions and audit references to
+// targetOfficeID, and drops the office's therapist capacities, so the office can then be deleted
+// without force. The audit entry is created in the same transaction.
+func (r *OfficeRepositoryImpl) TransferRecords(ctx context.Context, officeID, targetOfficeID uint, audit func(*interfaces.OfficeTransferSummary) models.AuditLog) (*interfaces.OfficeTransferSummary, error) {
+	summary := &interfaces.OfficeTransferSummary{CaseIDs: []uint{}}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Case{}).Where("office_id = ?", officeID).Pluck("id", &summary.CaseIDs).Error; err != nil {
+			return err
+		}
+
+		appointments := tx.Model(&models.Appointment{})
+		if len(summary.CaseIDs) > 0 {
+			appointments = appointments.Where("office_id = ? OR (case_id IN ? AND status IN ?)", officeID, summary.CaseIDs, []string{"pending", "confirmed"})
+		} else {
+			appointments = appointments.Where("office_id = ?", officeID)
+		}
+		result := appointments.UpdateColumn("office_id", targetOfficeID)
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.Appointments = result.RowsAffected
+
+		if len(summary.CaseIDs) > 0 {
+			result = tx.Model(&models.Case{}).Where("id IN ?", summary.CaseIDs).UpdateColumn("office_id", targetOfficeID)
+			if result.Error != nil {
+				return result.Error
+			}
+			summary.Cases = result.RowsAffected
+		}
+
+		result = tx.Model(&models.User{}).Where("office_id = ?", officeID).UpdateColumn("office_id", targetOfficeID)
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.Users = result.RowsAffected
+
+		if err := tx.Table("contact_submissions").Where("office_id = ?", officeID).UpdateColumn("office_id", targetOfficeID).Error; err != nil {
+			return err
+		}
+		// Capacities are per-office schedules; they are not meaningful at the target office
+		result = tx.Exec("DELETE FROM therapist_office_capacities WHERE office_id = ?", officeID)
+		if result.Error != nil {
+			return result.Error
+		}
+		summary.Capacities = result.RowsAffected
+
+		entry := audit(summary)
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		// Last, so the transfer's own entry does not keep a reference to the office either
+		return tx.Table("audit_logs").Where("user_office_id = ?", officeID).UpdateColumn("user_office_id", targetOfficeID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GetDeleteBlockReason returns a non-empty message if the office has users, open cases, appointments, or therapist capacities; empty if delete is allowed.
+func (r *OfficeRepositoryImpl) GetDeleteBlockReason(ctx context.Context, officeID uint) (string, error) {
+	counts, err := r.GetDependentCounts(ctx, officeID)
+	if err != nil {
 		return "", err
 	}
-	if users == 0 && openCases == 0 && appointments == 0 && capacities == 0 {
+	if !counts.Blocking() {
 		return "", nil
 	}
 	parts := []string{}
-	if users > 0 {
-		parts = append(parts, fmt.Sprintf("%d usuario(s)", users))
+	if counts.Users > 0 {
+		parts = append(parts, fmt.Sprintf("%d usuario(s)", counts.Users))
 	}
-	if openCases > 0 {
-		parts = append(parts, fmt.Sprintf("%d caso(s) abierto(s)", openCases))
+	if counts.OpenCases > 0 {
+		parts = append(parts, fmt.Sprintf("%d caso(s) abierto(s)", counts.OpenCases))
 	}
-	if appointments > 0 {
-		parts = append(parts, fmt.Sprintf("%d cita(s)", appointments))
+	if counts.Appointments > 0 {
+		parts = append(parts, fmt.Sprintf("%d cita(s)", counts.Appointments))
 	}
-	if capacities > 0 {
-		parts = append(parts, fmt.Sprintf("capacidades de terapeutas configuradas (%d)", capacities))
+	if counts.Capacities > 0 {
+		parts = append(parts, fmt.Sprintf("capacidades de terapeutas configuradas (%d)", counts.Capacities))
 	}
 	return "No se puede eliminar la oficina: tiene " + strings.Join(parts, ", ") + ". Reasigne o elimine antes de eliminar la oficina.", nil
 }
@@ -106,7 +217,8 @@ func (r *OfficeRepositoryImpl) ExistsByName(ctx context.Context, name string, ex
 	return count > 0, nil
 }
 
-// GenerateUniqueCode returns a URL-style unique code for the given name
+// GenerateUniqueCode returns a URL-style unique code for the given name. Codes are compared
+// ignoring case and surrounding spaces, as case numbers embed them uppercased.
 func (r *OfficeRepositoryImpl) GenerateUniqueCode(ctx context.Context, name string, excludeID uint) string {
 	base := strings.ToLower(name)
 	base = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(base, "-")
@@ -118,7 +230,7 @@ func (r *OfficeRepositoryImpl) GenerateUniqueCode(ctx context.Context, name stri
 	suffix := 1
 	for {
 		var count int64
-		q := r.db.WithContext(ctx).Model(&models.Office{}).Where("code = ?", code)
+		q := r.db.WithContext(ctx).Model(&models.Office{}).Where("UPPER(TRIM(code)) = UPPER(?)", code)
 		if excludeID != 0 {
 			q = q.Where("id != ?", excludeID)
 		}
@@ -114,6 +114,10 @@ func (s *AppointmentServiceImpl) UpdateAppointment(ctx context.Context, id uint,
 		if err != nil {
 			return nil, errors.New("invalid start_time format")
 		}
+		if !startTime.Equal(appointment.StartTime) {
+			// Rescheduled: the reminders are due again for the new time
+			appointment.ReminderSentAt, appointment.ReminderStage = nil, ""
+		}
 		appointment.StartTime = startTime
 	}
 	if req.EndTime != nil {
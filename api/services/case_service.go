@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/BryanPMX/CAF/api/config"
 	"github.com/BryanPMX/CAF/api/interfaces"
 	"github.com/BryanPMX/CAF/api/models"
 )
@@ -45,7 +46,7 @@ func (s *CaseServiceImpl) CreateCase(ctx context.Context, req interfaces.CreateC
 		OfficeID:    req.OfficeID,
 		Category:    req.Category,
 		Priority:    req.Priority,
-		Status:      "open",
+		Status:      string(config.CaseStatusOpen),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -94,10 +95,14 @@ func (s *CaseServiceImpl) UpdateCase(ctx context.Context, id uint, req interface
 		caseModel.Description = *req.Description
 	}
 	if req.Status != nil {
-		if !s.isValidStatusTransition(caseModel.Status, *req.Status) {
-			return nil, fmt.Errorf("invalid status transition from %s to %s", caseModel.Status, *req.Status)
+		status, ok := config.NormalizeCaseStatus(*req.Status)
+		if !ok {
+			return nil, fmt.Errorf("invalid case status %q", *req.Status)
 		}
-		caseModel.Status = *req.Status
+		if !s.isValidStatusTransition(caseModel.Status, string(status)) {
+			return nil, fmt.Errorf("invalid status transition from %s to %s", caseModel.Status, status)
+		}
+		caseModel.Status = string(status)
 	}
 	if req.Priority != nil {
 		caseModel.Priority = *req.Priority
@@ -216,23 +221,24 @@ func (s *CaseServiceImpl) canModifyCase(caseModel *models.Case, userContext inte
 	return false
 }
 
+// isValidStatusTransition reports whether a case may move between the two statuses; legacy
+// names are normalized first (see config.NormalizeCaseStatus).
 func (s *CaseServiceImpl) isValidStatusTransition(from, to string) bool {
-	validTransitions := map[string][]string{
-		"open":       {"active", "in_progress", "resolved", "closed"},
-		"active":     {"in_progress", "resolved", "closed"},
-		"in_progress": {"resolved", "closed", "completed"},
-		"resolved":   {"closed", "completed"},
-		"closed":     {"reopened"},
-		"completed":  {"reopened"},
+	validTransitions := map[config.CaseStatus][]config.CaseStatus{
+		config.CaseStatusOpen:       {config.CaseStatusInProgress, config.CaseStatusPending, config.CaseStatusClosed},
+		config.CaseStatusInProgress: {config.CaseStatusPending, config.CaseStatusClosed},
+		config.CaseStatusPending:    {config.CaseStatusInProgress, config.CaseStatusClosed},
+		config.CaseStatusClosed:     {config.CaseStatusOpen},
 	}
 
-	validTos, exists := validTransitions[from]
-	if !exists {
+	fromStatus, fromOK := config.NormalizeCaseStatus(from)
+	toStatus, toOK := config.NormalizeCaseStatus(to)
+	if !fromOK || !toOK {
 		return false
 	}
 
-	for _, validTo := range validTos {
-		if validTo == to {
+	for _, validTo := range validTransitions[fromStatus] {
+		if validTo == toStatus {
 			return true
 		}
 	}
@@ -0,0 +1,371 @@
+// api/services/session_service.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/interfaces"
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// ErrSessionRevoked is returned when a token's session was revoked, expired or never issued
+var ErrSessionRevoked = errors.New("session revoked or expired")
+
+// activityUpdateInterval throttles last_activity writes to at most one per interval per session
+const activityUpdateInterval = time.Minute
+
+// Redis keys: one hash per active session, keyed by token hash, one set per user listing the
+// token hashes of that user's sessions, and the time of the user's last revocation
+const (
+	sessionKeyPrefix         = "session:"
+	userSessionsKeyPrefix    = "user_sessions:"
+	sessionsRevokedKeyPrefix = "sessions_revoked:"
+)
+
+// errSessionUncached reports that a cached session disappeared while it was being refreshed,
+// e.g. revoked at the same moment; ValidateSession then checks the database.
+var errSessionUncached = errors.New("session no longer cached")
+
+// sessionLookupScript returns the session hash at KEYS[1] with, as its "revokedAt" field, the
+// last revocation time of the session's user stored under ARGV[1] plus the user ID, so both
+// are read in one round trip.
+var sessionLookupScript = redis.NewScript(`
+local fields = redis.call('HGETALL', KEYS[1])
+if #fields == 0 then return fields end
+local revokedAt = false
+for i = 1, #fields, 2 do
+	if fields[i] == 'userId' then revokedAt = redis.call('GET', ARGV[1] .. fields[i + 1]) end
+end
+table.insert(fields, 'revokedAt')
+table.insert(fields, revokedAt or '')
+return fields
+`)
+
+// sessionRefreshScript records activity on the session hash at KEYS[1] and renews its TTL, but
+// only while the key exists, so a refresh racing a revocation cannot cache the session again.
+var sessionRefreshScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+redis.call('HSET', KEYS[1], 'lastActivity', ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+var (
+	sessionsRevokedMu   sync.RWMutex
+	sessionsRevokedHook func(userID uint)
+)
+
+// OnSessionsRevoked registers a function called with the user's ID after RevokeUserSessions
+// revokes their sessions; main uses it to close the user's notification sockets.
+func OnSessionsRevoked(hook func(userID uint)) {
+	sessionsRevokedMu.Lock()
+	defer sessionsRevokedMu.Unlock()
+	sessionsRevokedHook = hook
+}
+
+// SessionServiceImpl implements the SessionService interface backed by the sessions table.
+// With a Redis client, active sessions are also cached in Redis so every API instance shares
+// them and most requests are validated without a database round-trip. The sessions table stays
+// authoritative: a session missing from Redis is looked up there and cached again.
+type SessionServiceImpl struct {
+	db     *gorm.DB
+	redis  *redis.Client
+	config models.SessionConfig
+}
+
+// NewSessionService creates a new session service. redisClient may be nil, in which case
+// sessions are kept in the database only.
+func NewSessionService(db *gorm.DB, redisClient *redis.Client) interfaces.SessionService {
+	return &SessionServiceImpl{db: db, redis: redisClient, config: models.DefaultSessionConfig}
+}
+
+// hashToken returns the hex SHA-256 digest stored in sessions.token_hash
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession records a newly issued token
+func (s *SessionServiceImpl) CreateSession(ctx context.Context, userID uint, token string, ipAddress, userAgent string, expiresAt time.Time) (*models.Session, error) {
+	now := time.Now().UTC()
+	verifiedAt, verifiedErr := s.redisNow(ctx)
+	session := models.Session{
+		UserID:       userID,
+		TokenHash:    hashToken(token),
+		DeviceInfo:   truncate(userAgent, 500),
+		IPAddress:    truncate(ipAddress, 45),
+		UserAgent:    truncate(userAgent, 500),
+		LastActivity: now,
+		ExpiresAt:    expiresAt,
+		IsActive:     true,
+	}
+	if err := s.db.WithContext(ctx).Create(&session).Error; err != nil {
+		return nil, err
+	}
+	if verifiedErr == nil {
+		s.cacheSession(ctx, &session, now, verifiedAt)
+	}
+	return &session, nil
+}
+
+// ValidateSession checks that the token belongs to an active, unexpired session. Sessions
+// cached in Redis are validated there; the database is only consulted when Redis does not
+// have the session (or is unavailable) and, at most once per activityUpdateInterval, to
+// record activity.
+func (s *SessionServiceImpl) ValidateSession(ctx context.Context, token string) (*models.Session, error) {
+	tokenHash := hashToken(token)
+	now := time.Now().UTC()
+	verifiedErr := errors.New("redis not configured")
+	var verifiedAt int64
+	if s.redis != nil {
+		session, err := s.cachedSession(ctx, tokenHash)
+		if err != nil {
+			log.Printf("WARNING: Redis session lookup failed, using the database: %v", err)
+		} else if session != nil {
+			if session, err = s.validateCachedSession(ctx, session, now); !errors.Is(err, errSessionUncached) {
+				return session, err
+			}
+		}
+		// Taken before the database is read, so a revocation committed after the read is newer
+		verifiedAt, verifiedErr = s.redisNow(ctx)
+	}
+
+	var session models.Session
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+
+	if !session.IsActive || now.After(session.ExpiresAt) {
+		return nil, ErrSessionRevoked
+	}
+	if s.config.InactivityTimeout > 0 && now.Sub(session.LastActivity) > s.config.InactivityTimeout {
+		s.db.WithContext(ctx).Model(&session).Update("is_active", false)
+		return nil, ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastActivity) > activityUpdateInterval {
+		s.db.WithContext(ctx).Model(&session).UpdateColumn("last_activity", now)
+		session.LastActivity = now
+	}
+
+	if verifiedErr == nil {
+		s.cacheSession(ctx, &session, now, verifiedAt)
+	}
+	return &session, nil
+}
+
+// validateCachedSession applies ValidateSession's checks to a session read from Redis. The
+// key's TTL already enforces the inactivity timeout; the explicit checks cover keys written
+// under a longer timeout. Activity is only recorded on a key that still exists; when it is gone
+// errSessionUncached is returned.
+func (s *SessionServiceImpl) validateCachedSession(ctx context.Context, session *models.Session, now time.Time) (*models.Session, error) {
+	inactive := s.config.InactivityTimeout > 0 && now.Sub(session.LastActivity) > s.config.InactivityTimeout
+	if now.After(session.ExpiresAt) || inactive {
+		s.redis.Del(ctx, sessionKeyPrefix+session.TokenHash)
+		if inactive {
+			s.db.WithContext(ctx).Model(session).Update("is_active", false)
+		}
+		return nil, ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastActivity) > activityUpdateInterval {
+		session.LastActivity = now
+		refreshed, err := sessionRefreshScript.Run(ctx, s.redis, []string{sessionKeyPrefix + session.TokenHash},
+			now.Unix(), int64(s.cacheTTL(session, now)/time.Second)).Int()
+		if err != nil {
+			log.Printf("WARNING: Failed to refresh session %d in Redis: %v", session.ID, err)
+		} else if refreshed == 0 {
+			return nil, errSessionUncached
+		}
+		// Keeps ListUserSessions and the database fallback current
+		s.db.WithContext(ctx).Model(session).UpdateColumn("last_activity", now)
+	}
+	return session, nil
+}
+
+// ListUserSessions returns the user's active, unexpired sessions, most recent first
+func (s *SessionServiceImpl) ListUserSessions(ctx context.Context, userID uint) ([]models.Session, error) {
+	sessions := make([]models.Session, 0)
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND is_active = ? AND expires_at > ?", userID, true, time.Now().UTC()).
+		Order("last_activity DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSessions deactivates every active session of the user and returns how many were
+// revoked. The sessions are also removed from Redis; if that fails the error is returned, since
+// the cached sessions would otherwise stay valid until their TTL runs out. The OnSessionsRevoked
+// hook runs once the database rows are revoked.
+func (s *SessionServiceImpl) RevokeUserSessions(ctx context.Context, userID uint) (int64, error) {
+	result := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	sessionsRevokedMu.RLock()
+	hook := sessionsRevokedHook
+	sessionsRevokedMu.RUnlock()
+	if hook != nil {
+		hook(userID)
+	}
+	if s.redis != nil {
+		if err := s.forgetUserSessions(ctx, userID); err != nil {
+			return result.RowsAffected, fmt.Errorf("failed to remove sessions from Redis: %w", err)
+		}
+	}
+	return result.RowsAffected, nil
+}
+
+// cacheTTL is how long the session stays cached from now: the inactivity timeout, capped at the
+// session's expiry.
+func (s *SessionServiceImpl) cacheTTL(session *models.Session, now time.Time) time.Duration {
+	ttl := session.ExpiresAt.Sub(now)
+	if s.config.InactivityTimeout > 0 && s.config.InactivityTimeout < ttl {
+		ttl = s.config.InactivityTimeout
+	}
+	return ttl
+}
+
+// redisNow returns Redis' clock in microseconds. Revocation times and the times cached sessions
+// were read from the database both come from it, so replicas' clocks never have to agree.
+func (s *SessionServiceImpl) redisNow(ctx context.Context) (int64, error) {
+	if s.redis == nil {
+		return 0, errors.New("redis not configured")
+	}
+	now, err := s.redis.Time(ctx).Result()
+	if err != nil {
+		return 0, err
+	}
+	return now.UnixMicro(), nil
+}
+
+// cacheSession stores the session's metadata in Redis with cacheTTL, together with verifiedAt,
+// the Redis time at which the session was last read from the database as active, and adds it to
+// the user's session set. Failures are logged only: the session is still valid through the
+// database.
+func (s *SessionServiceImpl) cacheSession(ctx context.Context, session *models.Session, now time.Time, verifiedAt int64) {
+	if s.redis == nil {
+		return
+	}
+	ttl := s.cacheTTL(session, now)
+	if ttl <= 0 {
+		return
+	}
+
+	key := sessionKeyPrefix + session.TokenHash
+	userKey := userSessionsKeyPrefix + strconv.FormatUint(uint64(session.UserID), 10)
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"id":           session.ID,
+		"userId":       session.UserID,
+		"lastActivity": session.LastActivity.Unix(),
+		"expiresAt":    session.ExpiresAt.Unix(),
+		"deviceInfo":   session.DeviceInfo,
+		"ipAddress":    session.IPAddress,
+		"userAgent":    session.UserAgent,
+		"verifiedAt":   verifiedAt,
+	})
+	pipe.Expire(ctx, key, ttl)
+	pipe.SAdd(ctx, userKey, session.TokenHash)
+	// The set must outlive every session in it, so its TTL is only ever extended
+	if setTTL, err := s.redis.TTL(ctx, userKey).Result(); err != nil || setTTL < session.ExpiresAt.Sub(now) {
+		pipe.Expire(ctx, userKey, session.ExpiresAt.Sub(now))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("WARNING: Failed to cache session %d in Redis: %v", session.ID, err)
+	}
+}
+
+// cachedSession reads a session from Redis. It returns nil, nil when the session is not cached,
+// and also when it was read from the database no later than the user's last revocation: it may
+// have been cached again by a validation racing the revocation, so the database decides.
+func (s *SessionServiceImpl) cachedSession(ctx context.Context, tokenHash string) (*models.Session, error) {
+	key := sessionKeyPrefix + tokenHash
+	values, err := sessionLookupScript.Run(ctx, s.redis, []string{key}, sessionsRevokedKeyPrefix).StringSlice()
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	fields := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		fields[values[i]] = values[i+1]
+	}
+	if fields["revokedAt"] != "" {
+		revokedAt, _ := strconv.ParseInt(fields["revokedAt"], 10, 64)
+		verifiedAt, _ := strconv.ParseInt(fields["verifiedAt"], 10, 64)
+		if verifiedAt <= revokedAt {
+			if err := s.redis.Del(ctx, key).Err(); err != nil {
+				log.Printf("WARNING: Failed to remove revoked session from Redis: %v", err)
+			}
+			return nil, nil
+		}
+	}
+
+	id, idErr := strconv.ParseUint(fields["id"], 10, 64)
+	userID, userErr := strconv.ParseUint(fields["userId"], 10, 64)
+	lastActivity, activityErr := strconv.ParseInt(fields["lastActivity"], 10, 64)
+	expiresAt, expiresErr := strconv.ParseInt(fields["expiresAt"], 10, 64)
+	if err := errors.Join(idErr, userErr, activityErr, expiresErr); err != nil {
+		return nil, fmt.Errorf("malformed cached session: %w", err)
+	}
+	return &models.Session{
+		ID:           uint(id),
+		UserID:       uint(userID),
+		TokenHash:    tokenHash,
+		DeviceInfo:   fields["deviceInfo"],
+		IPAddress:    fields["ipAddress"],
+		UserAgent:    fields["userAgent"],
+		LastActivity: time.Unix(lastActivity, 0).UTC(),
+		ExpiresAt:    time.Unix(expiresAt, 0).UTC(),
+		IsActive:     true,
+	}, nil
+}
+
+// forgetUserSessions records the revocation time of the user's sessions and removes every
+// cached session of the user from Redis. The revocation time outlives any session cached before
+// it, so a session cached again by a validation racing the revocation is still rejected.
+func (s *SessionServiceImpl) forgetUserSessions(ctx context.Context, userID uint) error {
+	revokedAt, err := s.redisNow(ctx)
+	if err != nil {
+		return err
+	}
+	revocationTTL := s.config.SessionTimeout
+	if s.config.InactivityTimeout > revocationTTL {
+		revocationTTL = s.config.InactivityTimeout
+	}
+	userKey := userSessionsKeyPrefix + strconv.FormatUint(uint64(userID), 10)
+	if err := s.redis.Set(ctx, sessionsRevokedKeyPrefix+strconv.FormatUint(uint64(userID), 10), revokedAt, revocationTTL+time.Hour).Err(); err != nil {
+		return err
+	}
+	tokenHashes, err := s.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(tokenHashes)+1)
+	for _, tokenHash := range tokenHashes {
+		keys = append(keys, sessionKeyPrefix+tokenHash)
+	}
+	keys = append(keys, userKey)
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
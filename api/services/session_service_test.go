@@ -0,0 +1,407 @@
+// api/services/session_service_test.go
+// Unit tests for the session service with sessions shared through Redis (miniredis), run
+// against a scripted sessions table so each test can tell whether the database was queried.
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BryanPMX/CAF/api/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// sessionsTable stands in for the sessions table: rows are keyed by token hash and every
+// statement run against it is recorded.
+type sessionsTable struct {
+	mutex      sync.Mutex
+	rows       map[string]models.Session
+	statements []string
+}
+
+func (t *sessionsTable) record(statement string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.statements = append(t.statements, statement)
+}
+
+// ran returns the recorded statements containing fragment.
+func (t *sessionsTable) ran(fragment string) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	matches := make([]string, 0)
+	for _, statement := range t.statements {
+		if strings.Contains(statement, fragment) {
+			matches = append(matches, statement)
+		}
+	}
+	return matches
+}
+
+// put stores the session row the table returns for token.
+func (t *sessionsTable) put(token string, session models.Session) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	session.TokenHash = hashToken(token)
+	t.rows[session.TokenHash] = session
+}
+
+var (
+	sessionTables     = map[string]*sessionsTable{}
+	sessionTablesLock sync.Mutex
+	registerSessions  sync.Once
+)
+
+// newTestSessionService returns a session service over a scripted sessions table and, unless
+// withRedis is false, a fresh miniredis server.
+func newTestSessionService(t *testing.T, withRedis bool) (*SessionServiceImpl, *sessionsTable, *miniredis.Miniredis) {
+	t.Helper()
+	registerSessions.Do(func() { sql.Register("caf-sessions", sessionsDriver{}) })
+	table := &sessionsTable{rows: map[string]models.Session{}}
+	sessionTablesLock.Lock()
+	sessionTables[t.Name()] = table
+	sessionTablesLock.Unlock()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DriverName: "caf-sessions", DSN: t.Name()}), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open scripted database: %v", err)
+	}
+	if !withRedis {
+		return NewSessionService(db, nil).(*SessionServiceImpl), table, nil
+	}
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewSessionService(db, client).(*SessionServiceImpl), table, server
+}
+
+type sessionsDriver struct{}
+
+func (sessionsDriver) Open(name string) (driver.Conn, error) {
+	sessionTablesLock.Lock()
+	defer sessionTablesLock.Unlock()
+	table, ok := sessionTables[name]
+	if !ok {
+		return nil, fmt.Errorf("no sessions table for %q", name)
+	}
+	return sessionsConn{table}, nil
+}
+
+type sessionsConn struct{ table *sessionsTable }
+
+func (c sessionsConn) Prepare(query string) (driver.Stmt, error) {
+	return sessionsStmt{c.table, query}, nil
+}
+func (c sessionsConn) Close() error              { return nil }
+func (c sessionsConn) Begin() (driver.Tx, error) { return sessionsTx{}, nil }
+
+type sessionsTx struct{}
+
+func (sessionsTx) Commit() error   { return nil }
+func (sessionsTx) Rollback() error { return nil }
+
+type sessionsStmt struct {
+	table *sessionsTable
+	query string
+}
+
+func (s sessionsStmt) Close() error  { return nil }
+func (s sessionsStmt) NumInput() int { return -1 }
+
+func (s sessionsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.table.record(s.query)
+	return driver.RowsAffected(1), nil
+}
+
+// Query answers INSERTs with id 1 and session lookups with the row stored for the token hash
+// passed as the first argument.
+func (s sessionsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.table.record(s.query)
+	if strings.HasPrefix(s.query, "INSERT") {
+		return &sessionRows{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}, nil
+	}
+	rows := &sessionRows{columns: []string{"id", "user_id", "token_hash", "last_activity", "expires_at", "is_active"}}
+	if len(args) > 0 {
+		tokenHash, _ := args[0].(string)
+		s.table.mutex.Lock()
+		session, ok := s.table.rows[tokenHash]
+		s.table.mutex.Unlock()
+		if ok {
+			rows.rows = [][]driver.Value{{int64(session.ID), int64(session.UserID), session.TokenHash, session.LastActivity, session.ExpiresAt, session.IsActive}}
+		}
+	}
+	return rows, nil
+}
+
+type sessionRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *sessionRows) Columns() []string { return r.columns }
+func (r *sessionRows) Close() error      { return nil }
+func (r *sessionRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestCreateSessionCachesInRedis(t *testing.T) {
+	service, _, server := newTestSessionService(t, true)
+	ctx := context.Background()
+
+	session, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := sessionKeyPrefix + hashToken("token-a")
+	if got := server.HGet(key, "deviceInfo"); got != "Firefox" {
+		t.Errorf("cached device = %q, want Firefox", got)
+	}
+	if got := server.HGet(key, "id"); got != fmt.Sprint(session.ID) {
+		t.Errorf("cached id = %q, want %d", got, session.ID)
+	}
+	if ttl := server.TTL(key); ttl != service.config.InactivityTimeout {
+		t.Errorf("session TTL = %s, want the inactivity timeout %s", ttl, service.config.InactivityTimeout)
+	}
+	if members, _ := server.SMembers(userSessionsKeyPrefix + "3"); len(members) != 1 || members[0] != hashToken("token-a") {
+		t.Errorf("user session set = %v", members)
+	}
+
+	// A session expiring before the inactivity timeout is not cached past its expiry
+	if _, err := service.CreateSession(ctx, 3, "token-b", "10.0.0.1", "Firefox", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL(sessionKeyPrefix + hashToken("token-b")); ttl > time.Hour {
+		t.Errorf("short session TTL = %s, want at most 1h", ttl)
+	}
+	if ttl := server.TTL(userSessionsKeyPrefix + "3"); ttl < 47*time.Hour {
+		t.Errorf("user session set TTL = %s, shrunk below the longest session", ttl)
+	}
+}
+
+func TestValidateSessionServedFromRedis(t *testing.T) {
+	service, table, _ := newTestSessionService(t, true)
+	ctx := context.Background()
+	if _, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		session, err := service.ValidateSession(ctx, "token-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.ID != 1 || session.UserID != 3 {
+			t.Errorf("session = %+v", session)
+		}
+	}
+	if queries := table.ran(`FROM "sessions"`); len(queries) > 0 {
+		t.Errorf("validation queried the database: %v", queries)
+	}
+}
+
+func TestValidateSessionRefreshesActivity(t *testing.T) {
+	service, table, server := newTestSessionService(t, true)
+	ctx := context.Background()
+	if _, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	key := sessionKeyPrefix + hashToken("token-a")
+	stale := time.Now().Add(-10 * time.Minute).Unix()
+	server.HSet(key, "lastActivity", fmt.Sprint(stale))
+	server.SetTTL(key, time.Hour)
+
+	if _, err := service.ValidateSession(ctx, "token-a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.HGet(key, "lastActivity"); got == fmt.Sprint(stale) {
+		t.Error("last activity was not refreshed in Redis")
+	}
+	if ttl := server.TTL(key); ttl != service.config.InactivityTimeout {
+		t.Errorf("TTL after activity = %s, want %s", ttl, service.config.InactivityTimeout)
+	}
+	if updates := table.ran(`SET "last_activity"`); len(updates) != 1 {
+		t.Errorf("last_activity updates = %v, want one", updates)
+	}
+}
+
+func TestValidateSessionExpiresAfterInactivity(t *testing.T) {
+	service, table, server := newTestSessionService(t, true)
+	ctx := context.Background()
+	if _, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// The database row still reflects the last recorded activity
+	table.put("token-a", models.Session{ID: 1, UserID: 3, LastActivity: time.Now().Add(-25 * time.Hour), ExpiresAt: time.Now().Add(23 * time.Hour), IsActive: true})
+
+	server.FastForward(service.config.InactivityTimeout + time.Minute)
+	if server.Exists(sessionKeyPrefix + hashToken("token-a")) {
+		t.Fatal("session key outlived the inactivity timeout")
+	}
+	if _, err := service.ValidateSession(ctx, "token-a"); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("err = %v, want ErrSessionRevoked", err)
+	}
+	if updates := table.ran(`SET "is_active"`); len(updates) != 1 {
+		t.Errorf("inactive session not deactivated: %v", updates)
+	}
+}
+
+func TestValidateSessionFallsBackToDatabase(t *testing.T) {
+	service, table, server := newTestSessionService(t, true)
+	ctx := context.Background()
+	// Issued by another instance before Redis was enabled, or lost in a Redis restart
+	table.put("token-a", models.Session{ID: 5, UserID: 3, LastActivity: time.Now(), ExpiresAt: time.Now().Add(time.Hour), IsActive: true})
+
+	session, err := service.ValidateSession(ctx, "token-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID != 5 {
+		t.Errorf("session id = %d, want 5", session.ID)
+	}
+	if got := server.HGet(sessionKeyPrefix+hashToken("token-a"), "id"); got != "5" {
+		t.Errorf("session not cached again, id = %q", got)
+	}
+
+	// Redis going away degrades to the database instead of rejecting sessions
+	server.Close()
+	if _, err := service.ValidateSession(ctx, "token-a"); err != nil {
+		t.Errorf("validation with Redis down = %v", err)
+	}
+}
+
+func TestRevokeUserSessionsClearsRedis(t *testing.T) {
+	service, table, server := newTestSessionService(t, true)
+	ctx := context.Background()
+	for _, issued := range []struct {
+		userID uint
+		token  string
+	}{{3, "laptop"}, {3, "phone"}, {4, "other-user"}} {
+		if _, err := service.CreateSession(ctx, issued.userID, issued.token, "10.0.0.1", "Firefox", time.Now().Add(24*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := service.RevokeUserSessions(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{sessionKeyPrefix + hashToken("laptop"), sessionKeyPrefix + hashToken("phone"), userSessionsKeyPrefix + "3"} {
+		if server.Exists(key) {
+			t.Errorf("%s still cached after revocation", key)
+		}
+	}
+	if !server.Exists(sessionKeyPrefix + hashToken("other-user")) {
+		t.Error("another user's session was removed")
+	}
+	if updates := table.ran(`SET "is_active"`); len(updates) != 1 || !strings.Contains(updates[0], "user_id = $") {
+		t.Errorf("revocation updates = %v", updates)
+	}
+
+	// Revoked in the database, so the fallback lookup rejects it too
+	table.put("phone", models.Session{ID: 2, UserID: 3, LastActivity: time.Now(), ExpiresAt: time.Now().Add(time.Hour), IsActive: false})
+	if _, err := service.ValidateSession(ctx, "phone"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("revoked session validated: %v", err)
+	}
+}
+
+func TestRevokeUserSessionsWinsRaceWithValidation(t *testing.T) {
+	service, table, server := newTestSessionService(t, true)
+	ctx := context.Background()
+	if _, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	key := sessionKeyPrefix + hashToken("token-a")
+
+	// A validation read the session from Redis, then the user is revoked before it records activity
+	cached, err := service.cachedSession(ctx, hashToken("token-a"))
+	if err != nil || cached == nil {
+		t.Fatalf("cached session = %v, %v", cached, err)
+	}
+	if _, err := service.RevokeUserSessions(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	table.put("token-a", models.Session{ID: 1, UserID: 3, LastActivity: time.Now(), ExpiresAt: time.Now().Add(time.Hour), IsActive: false})
+	cached.LastActivity = time.Now().Add(-10 * time.Minute)
+	if _, err := service.validateCachedSession(ctx, cached, time.Now().UTC()); !errors.Is(err, errSessionUncached) {
+		t.Errorf("refresh after revocation = %v, want errSessionUncached", err)
+	}
+	if server.Exists(key) {
+		t.Fatal("refresh cached the revoked session again")
+	}
+	if _, err := service.ValidateSession(ctx, "token-a"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("revoked session validated: %v", err)
+	}
+
+	// A validation read the row from the database before the revocation and caches it after
+	stale := &models.Session{ID: 1, UserID: 3, TokenHash: hashToken("token-a"), LastActivity: time.Now(), ExpiresAt: time.Now().Add(time.Hour), IsActive: true}
+	service.cacheSession(ctx, stale, time.Now().UTC(), time.Now().Add(-time.Second).UnixMicro())
+	if _, err := service.ValidateSession(ctx, "token-a"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("session cached before the revocation validated: %v", err)
+	}
+	if server.Exists(key) {
+		t.Error("stale session left in Redis")
+	}
+
+	// Signing in again after the revocation is served from Redis as usual
+	if _, err := service.CreateSession(ctx, 3, "token-b", "10.0.0.1", "Firefox", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	before := len(table.ran(`FROM "sessions"`))
+	if _, err := service.ValidateSession(ctx, "token-b"); err != nil {
+		t.Fatal(err)
+	}
+	if queries := table.ran(`FROM "sessions"`); len(queries) != before {
+		t.Errorf("new session validated against the database: %v", queries[before:])
+	}
+}
+
+func TestSessionServiceWithoutRedis(t *testing.T) {
+	service, table, _ := newTestSessionService(t, false)
+	ctx := context.Background()
+	if _, err := service.CreateSession(ctx, 3, "token-a", "10.0.0.1", "Firefox", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	table.put("token-a", models.Session{ID: 1, UserID: 3, LastActivity: time.Now(), ExpiresAt: time.Now().Add(time.Hour), IsActive: true})
+
+	if _, err := service.ValidateSession(ctx, "token-a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.ran(`FROM "sessions"`)) != 1 {
+		t.Errorf("validation did not use the database: %v", table.statements)
+	}
+	if _, err := service.RevokeUserSessions(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevokeUserSessionsRunsHook(t *testing.T) {
+	service, _, _ := newTestSessionService(t, true)
+	var revoked []uint
+	OnSessionsRevoked(func(userID uint) { revoked = append(revoked, userID) })
+	t.Cleanup(func() { OnSessionsRevoked(nil) })
+
+	if _, err := service.RevokeUserSessions(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked) != 1 || revoked[0] != 3 {
+		t.Errorf("hook called with %v, want [3]", revoked)
+	}
+}
@@ -0,0 +1,44 @@
+// api/services/uptime.go
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	processStartMu sync.RWMutex
+	processStart   = time.Now() // Package load time until main records its own
+)
+
+// SetProcessStart records when the API process started; main calls it as it boots.
+func SetProcessStart(start time.Time) {
+	processStartMu.Lock()
+	defer processStartMu.Unlock()
+	processStart = start
+}
+
+// ProcessStart returns when the API process started.
+func ProcessStart() time.Time {
+	processStartMu.RLock()
+	defer processStartMu.RUnlock()
+	return processStart
+}
+
+// Uptime returns how long the API process has been running.
+func Uptime() time.Duration {
+	return time.Since(ProcessStart())
+}
+
+// UptimePercent returns the share of the trailing window the process has been up, as a
+// percentage: 100 once it has run for the whole window, less after a recent restart.
+func UptimePercent(window time.Duration) float64 {
+	if window <= 0 {
+		return 100
+	}
+	uptime := Uptime()
+	if uptime >= window {
+		return 100
+	}
+	return float64(uptime) / float64(window) * 100
+}
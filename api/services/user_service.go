@@ -123,7 +123,7 @@ func (s *UserServiceImpl) CreateUser(ctx context.Context, userData interfaces.Cr
 	}
 
 	// Validate role
-	validRoles := []string{"admin", "office_manager", "lawyer", "psychologist", "receptionist", "event_coordinator", "client"}
+	validRoles := []string{"admin", "office_manager", "lawyer", "psychologist", "receptionist", "event_coordinator", "finance", "client"}
 	isValidRole := false
 	for _, role := range validRoles {
 		if userData.Role == role {
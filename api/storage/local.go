@@ -13,6 +13,7 @@ package storage
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -151,6 +152,61 @@ func (ls *LocalStorage) Delete(fileURL string) error {
 	return nil
 }
 
+// ListFiles walks the uploads directory under prefix and returns local:// URLs.
+// A missing prefix directory yields an empty list.
+func (ls *LocalStorage) ListFiles(prefix string) ([]StoredFile, error) {
+	root, err := ls.resolvePath(LocalURLPrefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]StoredFile, 0)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) && path == root {
+				return fs.SkipDir
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(ls.baseDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, StoredFile{
+			URL:     LocalURLPrefix + filepath.ToSlash(relative),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, nil
+}
+
+// PutObject writes body to {baseDir}/{key} and returns a local:// URL.
+func (ls *LocalStorage) PutObject(key string, body []byte, contentType string) (string, error) {
+	fileURL := LocalURLPrefix + key
+	destPath, err := ls.resolvePath(fileURL)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(destPath, body, 0644); err != nil {
+		return "", fmt.Errorf("failed to write file to disk: %w", err)
+	}
+	return fileURL, nil
+}
+
 // HealthCheck verifies the uploads directory is accessible and writable.
 func (ls *LocalStorage) HealthCheck() error {
 	info, err := os.Stat(ls.baseDir)
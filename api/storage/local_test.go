@@ -151,6 +151,75 @@ func TestDelete_Idempotent(t *testing.T) {
 	}
 }
 
+func TestListFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	ls, _ := NewLocalStorage(tmpDir)
+
+	url, _ := ls.Upload(createTestFile(t, "a.pdf", "a"), "7")
+	ls.Upload(createTestFile(t, "b.pdf", "b"), "8")
+	ls.UploadAvatar(createTestFile(t, "me.png", "png"), "3")
+
+	files, err := ls.ListFiles("cases/")
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("ListFiles returned %d files, want 2: %v", len(files), files)
+	}
+	found := false
+	for _, f := range files {
+		if f.URL == url {
+			found = true
+		}
+		if f.ModTime.IsZero() {
+			t.Errorf("ModTime not set for %s", f.URL)
+		}
+	}
+	if !found {
+		t.Errorf("uploaded file %s not listed", url)
+	}
+}
+
+func TestListFiles_MissingPrefix(t *testing.T) {
+	tmpDir := t.TempDir()
+	ls, _ := NewLocalStorage(tmpDir)
+
+	files, err := ls.ListFiles("cases/")
+	if err != nil {
+		t.Fatalf("ListFiles on empty storage failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files, got %v", files)
+	}
+}
+
+func TestPutObject(t *testing.T) {
+	tmpDir := t.TempDir()
+	ls, _ := NewLocalStorage(tmpDir)
+
+	url, err := ls.PutObject("archives/audit/test.ndjson", []byte("{\"id\":1}\n"), "application/x-ndjson")
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if url != "local://archives/audit/test.ndjson" {
+		t.Errorf("url = %q, want local://archives/audit/test.ndjson", url)
+	}
+
+	reader, _, err := ls.Get(url)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer reader.Close()
+	data, _ := io.ReadAll(reader)
+	if string(data) != "{\"id\":1}\n" {
+		t.Errorf("content = %q", data)
+	}
+
+	if _, err := ls.PutObject("../escape.ndjson", []byte("x"), "text/plain"); err == nil {
+		t.Error("expected error for path traversal key")
+	}
+}
+
 func TestHealthCheck(t *testing.T) {
 	tmpDir := t.TempDir()
 	ls, _ := NewLocalStorage(tmpDir)
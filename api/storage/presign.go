@@ -0,0 +1,77 @@
+// api/storage/presign.go
+// Short-lived presigned GET URLs, so browsers download documents straight from S3 instead of
+// through the API.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"strconv"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxPresignExpiry is the longest validity S3 accepts for a SigV4 presigned URL.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// PresignExpiry returns how long a presigned download URL stays valid.
+// Configured with S3_PRESIGN_EXPIRY_MINUTES (default 15, at most 10080 — seven days).
+func PresignExpiry() time.Duration {
+	minutes := 15
+	if v := os.Getenv("S3_PRESIGN_EXPIRY_MINUTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			minutes = parsed
+		}
+	}
+	if expiry := time.Duration(minutes) * time.Minute; expiry < maxPresignExpiry {
+		return expiry
+	}
+	return maxPresignExpiry
+}
+
+// objectPresigner signs GetObject requests; *s3.PresignClient implements it and tests replace it.
+type objectPresigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// PresignGetURL returns a URL that downloads the stored file without credentials until expiry.
+// The response is served as fileName, as an attachment when download is set and inline
+// otherwise; contentType, when set, overrides the stored content type.
+func (ss *S3Storage) PresignGetURL(fileURL, fileName, contentType string, download bool, expiry time.Duration) (string, error) {
+	objectKey, err := ss.extractObjectKey(fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	disposition := "inline"
+	if download {
+		disposition = "attachment"
+	}
+	input := &s3.GetObjectInput{
+		Bucket: &ss.bucket,
+		Key:    &objectKey,
+	}
+	if fileName != "" {
+		if formatted := mime.FormatMediaType(disposition, map[string]string{"filename": fileName}); formatted != "" {
+			disposition = formatted
+		}
+	}
+	input.ResponseContentDisposition = &disposition
+	if contentType != "" {
+		input.ResponseContentType = &contentType
+	}
+
+	presigner := ss.presigner
+	if presigner == nil {
+		presigner = s3.NewPresignClient(ss.client)
+	}
+	request, err := presigner.PresignGetObject(context.TODO(), input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 download: %w", err)
+	}
+	return request.URL, nil
+}
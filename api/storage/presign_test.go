@@ -0,0 +1,101 @@
+// api/storage/presign_test.go
+// Unit tests for presigned S3 download URLs, using a mocked presigner.
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakePresigner records the request it signs and returns url or err.
+type fakePresigner struct {
+	url     string
+	err     error
+	input   *s3.GetObjectInput
+	expires time.Duration
+}
+
+func (f *fakePresigner) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	f.input = params
+	var options s3.PresignOptions
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	f.expires = options.Expires
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &v4.PresignedHTTPRequest{URL: f.url, Method: "GET"}, nil
+}
+
+func TestPresignGetURLSignsStoredObject(t *testing.T) {
+	presigner := &fakePresigner{url: "http://localstack:4566/caf-docs/cases/7/abc.pdf?X-Amz-Signature=sig"}
+	store := &S3Storage{bucket: "caf-docs", endpoint: "http://localstack:4566", presigner: presigner}
+
+	url, err := store.PresignGetURL("http://localstack:4566/caf-docs/cases/7/abc.pdf", "informe.pdf", "application/pdf", false, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGetURL: %v", err)
+	}
+	if url != presigner.url {
+		t.Errorf("url = %q, want %q", url, presigner.url)
+	}
+	if got := *presigner.input.Bucket + "/" + *presigner.input.Key; got != "caf-docs/cases/7/abc.pdf" {
+		t.Errorf("signed object = %q, want caf-docs/cases/7/abc.pdf", got)
+	}
+	if presigner.expires != 10*time.Minute {
+		t.Errorf("expires = %v, want 10m", presigner.expires)
+	}
+	if got := *presigner.input.ResponseContentDisposition; got != "inline; filename=informe.pdf" {
+		t.Errorf("disposition = %q", got)
+	}
+	if got := *presigner.input.ResponseContentType; got != "application/pdf" {
+		t.Errorf("content type = %q", got)
+	}
+}
+
+func TestPresignGetURLDownloadsAsAttachment(t *testing.T) {
+	presigner := &fakePresigner{url: "https://caf-docs.s3.us-east-1.amazonaws.com/cases/7/abc.docx?X-Amz-Signature=sig"}
+	store := &S3Storage{bucket: "caf-docs", region: "us-east-1", presigner: presigner}
+
+	if _, err := store.PresignGetURL("https://caf-docs.s3.us-east-1.amazonaws.com/cases/7/abc.docx", "Demanda de pensión.docx", "", true, time.Minute); err != nil {
+		t.Fatalf("PresignGetURL: %v", err)
+	}
+	if got := *presigner.input.Key; got != "cases/7/abc.docx" {
+		t.Errorf("key = %q, want cases/7/abc.docx", got)
+	}
+	// Non-ASCII names are encoded so browsers keep the accent
+	if got, want := *presigner.input.ResponseContentDisposition, "attachment; filename*=utf-8''Demanda%20de%20pensi%C3%B3n.docx"; got != want {
+		t.Errorf("disposition = %q, want %q", got, want)
+	}
+	if presigner.input.ResponseContentType != nil {
+		t.Errorf("content type override = %q, want none", *presigner.input.ResponseContentType)
+	}
+}
+
+func TestPresignGetURLReportsSigningFailure(t *testing.T) {
+	store := &S3Storage{bucket: "caf-docs", presigner: &fakePresigner{err: errors.New("no credentials")}}
+	if _, err := store.PresignGetURL("http://localstack:4566/caf-docs/cases/7/abc.pdf", "abc.pdf", "", false, time.Minute); err == nil {
+		t.Fatal("expected an error when signing fails")
+	}
+}
+
+func TestPresignExpiry(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      15 * time.Minute,
+		"60":    time.Hour,
+		"0":     15 * time.Minute,
+		"abc":   15 * time.Minute,
+		"20000": 7 * 24 * time.Hour,
+	}
+	for value, want := range tests {
+		t.Setenv("S3_PRESIGN_EXPIRY_MINUTES", value)
+		if got := PresignExpiry(); got != want {
+			t.Errorf("S3_PRESIGN_EXPIRY_MINUTES=%q: expiry = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// api/storage/retry.go
+// Timeouts and bounded retries for S3 calls, so a transient S3 failure (timeout,
+// throttling, 5xx) is retried with backoff before it reaches the user.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrStorageUnavailable is returned when a storage operation still fails after every retry.
+// Handlers should answer 503 so clients know to try again later.
+var ErrStorageUnavailable = errors.New("storage temporarily unavailable")
+
+// S3Timeout returns how long one S3 attempt may take before it is abandoned. For downloads
+// it bounds the wait for the response, not streaming the body.
+// Configured with S3_TIMEOUT_SECONDS (default 30).
+func S3Timeout() time.Duration {
+	seconds := 30
+	if v := os.Getenv("S3_TIMEOUT_SECONDS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// S3MaxRetries returns how many times a failed S3 GET/PUT/HEAD is retried.
+// Configured with S3_MAX_RETRIES (default 3, 0 disables retries).
+func S3MaxRetries() int {
+	retries := 3
+	if v := os.Getenv("S3_MAX_RETRIES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			retries = parsed
+		}
+	}
+	return retries
+}
+
+// S3RetryBaseDelay returns the delay before the first retry; each further retry doubles it.
+// Configured with S3_RETRY_BASE_DELAY_MS (default 200).
+func S3RetryBaseDelay() time.Duration {
+	ms := 200
+	if v := os.Getenv("S3_RETRY_BASE_DELAY_MS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			ms = parsed
+		}
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// errS3AttemptTimeout marks an attempt abandoned after S3Timeout.
+var errS3AttemptTimeout = errors.New("S3 request timed out")
+
+// withS3Retry runs fn until it succeeds, fails with a non-retryable error, or the retries
+// are exhausted, in which case the error wraps ErrStorageUnavailable. Each attempt gets its
+// own context, cancelled after S3Timeout unless fn has returned. On success the caller owns
+// the returned cancel func and must call it once done with the response (e.g. after closing
+// a GetObject body).
+func withS3Retry(operation, key string, fn func(ctx context.Context) error) (context.CancelFunc, error) {
+	maxRetries := S3MaxRetries()
+	timeout := S3Timeout()
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := S3RetryBaseDelay() << (attempt - 1)
+			if delay > 0 {
+				delay += time.Duration(rand.Int63n(int64(delay)/2 + 1)) // Jitter so retries don't line up
+			}
+			log.Printf("WARNING: S3 %s %s failed (attempt %d/%d), retrying in %v: %v", operation, key, attempt, maxRetries+1, delay, lastErr)
+			time.Sleep(delay)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		timer := time.AfterFunc(timeout, cancel)
+		err := fn(ctx)
+		if !timer.Stop() && err != nil {
+			err = fmt.Errorf("%w after %v: %v", errS3AttemptTimeout, timeout, err)
+		}
+		if err == nil {
+			return cancel, nil
+		}
+		cancel()
+
+		lastErr = err
+		if !isRetryableS3Error(err) {
+			return nil, err
+		}
+	}
+
+	if maxRetries > 0 {
+		log.Printf("ERROR: S3 %s %s failed after %d attempts: %v", operation, key, maxRetries+1, lastErr)
+	}
+	return nil, fmt.Errorf("%w: S3 %s %s failed after %d attempts: %v", ErrStorageUnavailable, operation, key, maxRetries+1, lastErr)
+}
+
+// isRetryableS3Error reports whether err looks transient: a timeout, a network error,
+// throttling or a 5xx response. Client errors such as 403 or 404 are not retried.
+func isRetryableS3Error(err error) bool {
+	if errors.Is(err, errS3AttemptTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		code := status.HTTPStatusCode()
+		return code == 429 || code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// noSDKRetries disables the SDK's own retryer for a call wrapped in withS3Retry,
+// so attempts are not multiplied and every retry is logged.
+func noSDKRetries(o *s3.Options) {
+	o.RetryMaxAttempts = 1
+}
@@ -4,6 +4,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"mime/multipart"
 	"os"
@@ -36,11 +37,17 @@ func HealthCheck() error {
 		bucketName = "caf-system-bucket" // fallback
 	}
 
-	_, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
-		Bucket: &bucketName,
+	cancel, err := withS3Retry("HEAD", bucketName, func(ctx context.Context) error {
+		_, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: &bucketName,
+		}, noSDKRetries)
+		return err
 	})
-
-	return err
+	if err != nil {
+		return err
+	}
+	cancel()
+	return nil
 }
 
 // InitS3 initializes the S3 client.
@@ -103,8 +110,11 @@ func CreateBucketIfNotExists() error {
 		return fmt.Errorf("S3 client not initialized")
 	}
 
-	_, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
-		Bucket: &bucketName,
+	cancel, err := withS3Retry("HEAD", bucketName, func(ctx context.Context) error {
+		_, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: &bucketName,
+		}, noSDKRetries)
+		return err
 	})
 
 	if err != nil {
@@ -124,6 +134,7 @@ func CreateBucketIfNotExists() error {
 			return fmt.Errorf("failed to check for bucket: %w", err)
 		}
 	} else {
+		cancel()
 		log.Printf("S3 Bucket '%s' already exists.", bucketName)
 	}
 
@@ -146,16 +157,23 @@ func UploadFile(file *multipart.FileHeader, caseID string) (string, error) {
 	uniqueFileName := uuid.New().String() + filepath.Ext(file.Filename)
 	objectKey := fmt.Sprintf("cases/%s/%s", caseID, uniqueFileName)
 
-	_, err = s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket: &bucketName,
-		Key:    &objectKey,
-		Body:   src,
-		ACL:    types.ObjectCannedACLPublicRead,
+	cancel, err := withS3Retry("PUT", objectKey, func(ctx context.Context) error {
+		// Rewind so a retried attempt sends the whole file again
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: &bucketName,
+			Key:    &objectKey,
+			Body:   src,
+			ACL:    types.ObjectCannedACLPublicRead,
+		}, noSDKRetries)
+		return err
 	})
-
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to S3: %w", err)
 	}
+	cancel()
 
 	// Generate the correct file URL based on environment
 	endpoint := os.Getenv("AWS_ENDPOINT_URL")
@@ -192,15 +210,22 @@ func UploadAvatarFile(file *multipart.FileHeader, userID string) (string, error)
 	}
 	objectKey := fmt.Sprintf("avatars/%s%s", userID, ext)
 
-	_, err = s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket: &bucketName,
-		Key:    &objectKey,
-		Body:   src,
-		ACL:    types.ObjectCannedACLPublicRead,
+	cancel, err := withS3Retry("PUT", objectKey, func(ctx context.Context) error {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: &bucketName,
+			Key:    &objectKey,
+			Body:   src,
+			ACL:    types.ObjectCannedACLPublicRead,
+		}, noSDKRetries)
+		return err
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload avatar to S3: %w", err)
 	}
+	cancel()
 
 	endpoint := os.Getenv("AWS_ENDPOINT_URL")
 	region := os.Getenv("AWS_REGION")
@@ -8,6 +8,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -24,6 +25,9 @@ type S3Storage struct {
 	bucket   string
 	region   string
 	endpoint string
+
+	// presigner signs download URLs; nil uses a presign client for client
+	presigner objectPresigner
 }
 
 // NewS3Storage creates an S3Storage provider from the already-initialized
@@ -64,9 +68,14 @@ func (ss *S3Storage) Get(fileURL string) (io.ReadCloser, string, error) {
 		return nil, "", err
 	}
 
-	result, err := ss.client.GetObject(context.TODO(), &s3.GetObjectInput{
-		Bucket: &ss.bucket,
-		Key:    &objectKey,
+	var result *s3.GetObjectOutput
+	cancel, err := withS3Retry("GET", objectKey, func(ctx context.Context) error {
+		var err error
+		result, err = ss.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: &ss.bucket,
+			Key:    &objectKey,
+		}, noSDKRetries)
+		return err
 	})
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to get object from S3: %w", err)
@@ -77,7 +86,19 @@ func (ss *S3Storage) Get(fileURL string) (io.ReadCloser, string, error) {
 		contentType = *result.ContentType
 	}
 
-	return result.Body, contentType, nil
+	return &cancelOnClose{ReadCloser: result.Body, cancel: cancel}, contentType, nil
+}
+
+// cancelOnClose releases a GetObject attempt's context once the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
 }
 
 // Delete removes a file from S3. Idempotent — deleting a non-existent
@@ -99,6 +120,28 @@ func (ss *S3Storage) Delete(fileURL string) error {
 	return nil
 }
 
+// PutObject stores body under key as a private object and returns its URL.
+func (ss *S3Storage) PutObject(key string, body []byte, contentType string) (string, error) {
+	cancel, err := withS3Retry("PUT", key, func(ctx context.Context) error {
+		_, err := ss.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      &ss.bucket,
+			Key:         &key,
+			Body:        bytes.NewReader(body),
+			ContentType: &contentType,
+		}, noSDKRetries)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload object to S3: %w", err)
+	}
+	cancel()
+
+	if ss.endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", ss.endpoint, ss.bucket, key), nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", ss.bucket, ss.region, key), nil
+}
+
 // HealthCheck delegates to the existing package-level HealthCheck.
 func (ss *S3Storage) HealthCheck() error {
 	return HealthCheck()
@@ -7,6 +7,7 @@ package storage
 import (
 	"io"
 	"mime/multipart"
+	"time"
 )
 
 // FileStorage defines the contract for document storage operations.
@@ -30,6 +31,35 @@ type FileStorage interface {
 	HealthCheck() error
 }
 
+// StoredFile describes a file held by a storage backend.
+type StoredFile struct {
+	URL     string
+	ModTime time.Time
+}
+
+// FileLister is implemented by backends that can enumerate stored files.
+// It is optional; maintenance tasks such as orphan cleanup skip backends without it.
+type FileLister interface {
+	// ListFiles returns every file stored under the given prefix (e.g. "cases/").
+	ListFiles(prefix string) ([]StoredFile, error)
+}
+
+// ObjectWriter is implemented by backends that can store an arbitrary object under a key.
+// It is optional; used for exports such as audit log archives.
+type ObjectWriter interface {
+	// PutObject stores body under key (e.g. "archives/audit/2026-01.ndjson") and returns its stored URL.
+	PutObject(key string, body []byte, contentType string) (string, error)
+}
+
+// URLSigner is implemented by backends that can hand out temporary direct download links.
+// It is optional; only S3 supports it, so callers fall back to streaming through Get.
+type URLSigner interface {
+	// PresignGetURL returns a URL that downloads the file stored at fileURL until expiry,
+	// served as fileName (attachment when download is set, inline otherwise) with contentType
+	// when it is not empty.
+	PresignGetURL(fileURL, fileName, contentType string, download bool, expiry time.Duration) (string, error)
+}
+
 // activeStorage holds the initialized storage provider chosen at startup.
 var activeStorage FileStorage
 
@@ -1348,7 +1348,6 @@ class _InfoGrid extends StatelessWidget {
       MapEntry('Oficina', detail.office?.name ?? detail.summary.officeName),
       MapEntry('Profesional',
           detail.primaryStaff?.name ?? detail.summary.primaryStaffName),
-      MapEntry('Monto (referencial)', _currency(detail.summary.fee)),
     ].where((e) => e.value.trim().isNotEmpty).toList(growable: false);
 
     if (items.isEmpty) return const SizedBox.shrink();
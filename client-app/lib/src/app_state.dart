@@ -708,7 +708,8 @@ class AppointmentItem {
     return AppointmentItem(
       id: _asInt(json['id']) ?? 0,
       caseId: _asInt(json['caseId']),
-      caseTitle: _asString(caseMap['title']),
+      caseTitle: _asString(json['caseTitle'],
+          fallback: _asString(caseMap['title'])),
       title: _asString(json['title']),
       status: _asString(json['status']),
       category: _asString(json['category']),
@@ -842,7 +843,7 @@ class CaseTimelineEvent {
       fileName: _asString(json['fileName']),
       fileType: _asString(json['fileType']),
       createdAt: _asDate(json['createdAt']),
-      authorName: _fullName(_asMap(json['user'])),
+      authorName: _fullName(_asMap(json['author'])),
     );
   }
 }
@@ -905,7 +906,7 @@ class CaseDetail {
     final officeMap = _asMap(json['office']);
     return CaseDetail(
       summary: CaseSummary.fromJson(json),
-      events: _asMapList(json['caseEvents'])
+      events: _asMapList(json['events'])
           .map(CaseTimelineEvent.fromJson)
           .toList(growable: false),
       appointments: _asMapList(json['appointments'])